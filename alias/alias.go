@@ -0,0 +1,285 @@
+/*
+Package alias maps the identities people have in other services, such as their
+Slack user IDs, email addresses, GitHub handles or nicknames, to their love
+usernames, so that every integration agrees on who is who. An identity is
+written as its kind and ID, separated by a colon:
+
+	slack:U012AB3CD
+	email:darwin.dog@example.com
+	github:octocat
+	nick:ham
+
+A Map is kept in a JSON file, which holds an object for each kind of identity
+mapping IDs to usernames:
+
+	{
+	  "slack": {"U012AB3CD": "hammy"},
+	  "email": {"darwin.dog@example.com": "darwin"},
+	  "github": {"octocat": "hammy"},
+	  "nick": {"ham": "hammy"}
+	}
+
+Kinds and IDs are matched without regard to case.
+*/
+package alias
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Common kinds of identity.
+const (
+	Slack     = "slack"
+	Email     = "email"
+	GitHub    = "github"
+	GitLab    = "gitlab"
+	PagerDuty = "pagerduty"
+	Nick      = "nick"
+)
+
+/*
+An Alias is an identity of a love user in another service.
+*/
+type Alias struct {
+	Kind     string
+	ID       string
+	Username string
+}
+
+func (a Alias) String() string {
+	return a.Kind + ":" + a.ID
+}
+
+/*
+A Map holds aliases by kind and ID. The zero value is empty, and a nil *Map has
+no aliases.
+*/
+type Map struct {
+	// Email addresses in this domain without an alias are assumed to be
+	// username@EmailDomain.
+	EmailDomain string
+
+	aliases map[string]map[string]Alias
+}
+
+/*
+Read a Map from a JSON file. A missing file, or an empty path, is an empty map.
+*/
+func Read(path string) (*Map, error) {
+	m := &Map{}
+	if path == "" {
+		return m, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var kinds map[string]map[string]string
+	if err := json.Unmarshal(data, &kinds); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	for kind, ids := range kinds {
+		for id, username := range ids {
+			if err := m.Add(kind, id, username); err != nil {
+				return nil, fmt.Errorf("%s: %s", path, err)
+			}
+		}
+	}
+	return m, nil
+}
+
+/*
+Write the map to a JSON file, readable only by its owner.
+*/
+func (m *Map) Write(path string) error {
+	kinds := make(map[string]map[string]string)
+	for _, a := range m.Aliases() {
+		if kinds[a.Kind] == nil {
+			kinds[a.Kind] = make(map[string]string)
+		}
+		kinds[a.Kind][a.ID] = a.Username
+	}
+	data, err := json.MarshalIndent(kinds, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0600)
+}
+
+/*
+Add an alias, replacing any with the same kind and ID.
+*/
+func (m *Map) Add(kind, id, username string) error {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	id = strings.TrimSpace(id)
+	username = strings.TrimSpace(username)
+	if kind == "" || id == "" || username == "" || strings.Contains(kind, ":") {
+		return fmt.Errorf("invalid alias %s:%s for %q", kind, id, username)
+	}
+	if m.aliases == nil {
+		m.aliases = make(map[string]map[string]Alias)
+	}
+	if m.aliases[kind] == nil {
+		m.aliases[kind] = make(map[string]Alias)
+	}
+	m.aliases[kind][strings.ToLower(id)] = Alias{kind, id, username}
+	return nil
+}
+
+/*
+Remove an alias, reporting whether there was one.
+*/
+func (m *Map) Remove(kind, id string) bool {
+	ids := m.aliases[strings.ToLower(kind)]
+	key := strings.ToLower(id)
+	if _, ok := ids[key]; !ok {
+		return false
+	}
+	delete(ids, key)
+	return true
+}
+
+/*
+Return the username of an identity, and whether it has one. Email addresses
+without an alias in EmailDomain are username@EmailDomain.
+*/
+func (m *Map) Lookup(kind, id string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	kind = strings.ToLower(kind)
+	if a, ok := m.aliases[kind][strings.ToLower(id)]; ok {
+		return a.Username, true
+	}
+	if kind == Email && m.EmailDomain != "" {
+		at := strings.LastIndex(id, "@")
+		if at > 0 && strings.EqualFold(id[at+1:], m.EmailDomain) {
+			return id[:at], true
+		}
+	}
+	return "", false
+}
+
+/*
+Return the aliases of a kind as a map of IDs to usernames, as used by
+integrations such as slack.Handler.
+*/
+func (m *Map) Kind(kind string) map[string]string {
+	ids := make(map[string]string)
+	if m == nil {
+		return ids
+	}
+	for _, a := range m.aliases[strings.ToLower(kind)] {
+		ids[a.ID] = a.Username
+	}
+	return ids
+}
+
+/*
+Return every alias, sorted by kind and ID.
+*/
+func (m *Map) Aliases() []Alias {
+	var aliases []Alias
+	if m == nil {
+		return aliases
+	}
+	for _, ids := range m.aliases {
+		for _, a := range ids {
+			aliases = append(aliases, a)
+		}
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		if aliases[i].Kind != aliases[j].Kind {
+			return aliases[i].Kind < aliases[j].Kind
+		}
+		return strings.ToLower(aliases[i].ID) < strings.ToLower(aliases[j].ID)
+	})
+	return aliases
+}
+
+/*
+Split an identity, such as "slack:U012AB3CD" or "@slack:U012AB3CD", into its
+kind and ID. A name without a kind is not an identity.
+*/
+func Parse(identity string) (kind, id string, ok bool) {
+	identity = strings.TrimPrefix(strings.TrimSpace(identity), "@")
+	i := strings.Index(identity, ":")
+	if i <= 0 || i == len(identity)-1 {
+		return "", "", false
+	}
+	return strings.ToLower(identity[:i]), identity[i+1:], true
+}
+
+/*
+Return the username a recipient refers to: the username of an identity, such
+as "@slack:U012AB3CD", or else the recipient itself, without any leading @.
+*/
+func (m *Map) Resolve(recipient string) (string, error) {
+	kind, id, ok := Parse(recipient)
+	if !ok {
+		return strings.TrimPrefix(strings.TrimSpace(recipient), "@"), nil
+	}
+	username, ok := m.Lookup(kind, id)
+	if !ok {
+		return "", fmt.Errorf("no love username for %s:%s", kind, id)
+	}
+	return username, nil
+}
+
+/*
+Resolve each of a comma separated list of recipients, returning the list of
+usernames.
+*/
+func (m *Map) ResolveList(recipients string) (string, error) {
+	var usernames []string
+	for _, r := range strings.Split(recipients, ",") {
+		if strings.TrimSpace(r) == "" {
+			continue
+		}
+		username, err := m.Resolve(r)
+		if err != nil {
+			return "", err
+		}
+		usernames = append(usernames, username)
+	}
+	return strings.Join(usernames, ","), nil
+}
+
+/*
+Check that the username of each alias exists, by looking it up with
+Autocomplete and requiring a result whose username matches exactly, as
+love.Client.ValidateRecipients does. Returns the aliases whose usernames do not
+exist. Each username is looked up once.
+*/
+func (m *Map) Check(service love.LoveService) ([]Alias, error) {
+	exists := make(map[string]bool)
+	var unknown []Alias
+	for _, a := range m.Aliases() {
+		found, ok := exists[a.Username]
+		if !ok {
+			users, err := service.Autocomplete(a.Username)
+			if err != nil {
+				return nil, err
+			}
+			for _, u := range users {
+				if u.Username == a.Username {
+					found = true
+					break
+				}
+			}
+			exists[a.Username] = found
+		}
+		if !found {
+			unknown = append(unknown, a)
+		}
+	}
+	return unknown, nil
+}
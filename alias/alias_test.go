@@ -0,0 +1,136 @@
+package alias
+
+import (
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+const testAliases = `{
+	"slack": {"U012AB3CD": "hammy"},
+	"Email": {"Darwin.Dog@example.com": "darwin"},
+	"github": {"octocat": "hammy"},
+	"nick": {"jj": "jeremy", "ghost": "casper"}
+}`
+
+func readTestMap(t *testing.T) *Map {
+	path := filepath.Join(t.TempDir(), "aliases.json")
+	if err := ioutil.WriteFile(path, []byte(testAliases), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestLookup(t *testing.T) {
+	m := readTestMap(t)
+	username, ok := m.Lookup("slack", "U012AB3CD")
+	assert.True(t, ok)
+	assert.Equal(t, username, "hammy")
+	username, ok = m.Lookup("EMAIL", "darwin.dog@EXAMPLE.com")
+	assert.True(t, ok)
+	assert.Equal(t, username, "darwin")
+	_, ok = m.Lookup("slack", "U999")
+	assert.False(t, ok)
+	_, ok = m.Lookup("email", "jeremy@example.com")
+	assert.False(t, ok)
+
+	m.EmailDomain = "example.com"
+	username, ok = m.Lookup("email", "jeremy@Example.com")
+	assert.True(t, ok)
+	assert.Equal(t, username, "jeremy")
+	_, ok = m.Lookup("email", "jeremy@elsewhere.com")
+	assert.False(t, ok)
+
+	_, ok = (*Map)(nil).Lookup("slack", "U012AB3CD")
+	assert.False(t, ok)
+}
+
+func TestResolve(t *testing.T) {
+	m := readTestMap(t)
+	for recipient, expected := range map[string]string{
+		"@slack:U012AB3CD": "hammy",
+		"nick:JJ":          "jeremy",
+		"darwin":           "darwin",
+		"@darwin":          "darwin",
+	} {
+		username, err := m.Resolve(recipient)
+		assert.Nil(t, err, recipient)
+		assert.Equal(t, username, expected, recipient)
+	}
+	_, err := m.Resolve("@github:nobody")
+	assert.Equal(t, err.Error(), "no love username for github:nobody")
+
+	list, err := m.ResolveList("@slack:U012AB3CD, darwin,,nick:jj")
+	assert.Nil(t, err)
+	assert.Equal(t, list, "hammy,darwin,jeremy")
+	_, err = m.ResolveList("darwin,slack:U999")
+	assert.NotNil(t, err)
+}
+
+func TestParse(t *testing.T) {
+	kind, id, ok := Parse("@Slack:U012AB3CD")
+	assert.True(t, ok)
+	assert.Equal(t, kind, "slack")
+	assert.Equal(t, id, "U012AB3CD")
+	for _, name := range []string{"darwin", ":U1", "slack:"} {
+		_, _, ok := Parse(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestKindAndAliases(t *testing.T) {
+	m := readTestMap(t)
+	assert.Equal(t, m.Kind("slack"), map[string]string{"U012AB3CD": "hammy"})
+	assert.Equal(t, m.Kind("pagerduty"), map[string]string{})
+	aliases := m.Aliases()
+	assert.Len(t, aliases, 5)
+	assert.Equal(t, aliases[0], Alias{"email", "Darwin.Dog@example.com", "darwin"})
+	assert.Equal(t, aliases[4].String(), "slack:U012AB3CD")
+}
+
+func TestAddRemoveWrite(t *testing.T) {
+	m := readTestMap(t)
+	assert.Nil(t, m.Add("github", "OctoCat", "darwin"))
+	username, _ := m.Lookup("github", "octocat")
+	assert.Equal(t, username, "darwin")
+	assert.NotNil(t, m.Add("github", "", "darwin"))
+	assert.NotNil(t, m.Add("github", "someone", " "))
+	assert.True(t, m.Remove("nick", "GHOST"))
+	assert.False(t, m.Remove("nick", "ghost"))
+
+	path := filepath.Join(t.TempDir(), "aliases.json")
+	assert.Nil(t, m.Write(path))
+	written, err := Read(path)
+	assert.Nil(t, err)
+	assert.Equal(t, written.Aliases(), m.Aliases())
+}
+
+func TestReadMissing(t *testing.T) {
+	m, err := Read(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Nil(t, err)
+	assert.Len(t, m.Aliases(), 0)
+	m, err = Read("")
+	assert.Nil(t, err)
+	assert.Len(t, m.Aliases(), 0)
+
+	path := filepath.Join(t.TempDir(), "aliases.json")
+	ioutil.WriteFile(path, []byte(`{"slack": ["hammy"]}`), 0600)
+	_, err = Read(path)
+	assert.NotNil(t, err)
+}
+
+func TestCheck(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	server.AddUser("jeremy", "Jeremy Jones")
+	unknown, err := readTestMap(t).Check(server.Client())
+	assert.Nil(t, err)
+	assert.Equal(t, unknown, []Alias{{"nick", "ghost", "casper"}})
+}
@@ -0,0 +1,268 @@
+/*
+Package ci reads what a continuous integration job is running for, such as the
+pull request which was merged or the commit which fixed a broken build, from
+the environment of GitHub Actions or GitLab CI, so that love can be sent to the
+people involved. People are known to the CI service by their handles there,
+which a Users file maps to love usernames:
+
+	event, err := ci.Detect()
+	if err != nil {
+		log.Fatal(err)
+	}
+	users, err := ci.ReadUsers("users.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if author, ok := users.Username(event.Author); ok {
+		fmt.Println("thanks,", author)
+	}
+*/
+package ci
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+/*
+ErrNotCI is returned by Detect when the environment is not that of a supported
+CI service.
+*/
+var ErrNotCI = errors.New("ci: not running in GitHub Actions or GitLab CI")
+
+// The providers of an Event.
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+/*
+An Event is what a CI job is running for. Handles are those of the CI service.
+The pull request fields are empty when the job is not running for one; on
+GitLab, merge requests are called pull requests here too.
+*/
+type Event struct {
+	// GitHub or GitLab.
+	Provider string
+	// The repository, such as "hacsoc/golove".
+	Repository string
+	// The handle of the user who started the job, such as by pushing.
+	Actor string
+	// The URL of the job's workflow run or pipeline.
+	BuildURL string
+
+	PullRequest int
+	Title       string
+	URL         string
+	// The handle of the user who opened the pull request.
+	Author string
+	// The handles of its reviewers, or on GitLab, its assignees.
+	Reviewers []string
+	// Whether the pull request was merged.
+	Merged bool
+	// The handle of the user who merged it.
+	MergedBy string
+}
+
+/*
+Return the Event of the CI job running in this process's environment.
+*/
+func Detect() (*Event, error) {
+	return FromEnv(os.Getenv)
+}
+
+/*
+Return the Event described by the environment variables in getenv.
+
+On GitHub Actions, pull requests are read from the webhook payload in
+GITHUB_EVENT_PATH, so that a workflow run on pull_request closed events sees
+whether it was merged, and by whom. The payload only lists the reviewers whose
+review is still requested, since GitHub removes a reviewer once they review,
+so workflows which want everyone who reviewed should list them some other way.
+
+On GitLab CI, pull requests are read from the CI_MERGE_REQUEST variables of
+merge request pipelines. They have no author or merged flag: the author is
+taken to be the user who started the pipeline, and Merged is never set.
+*/
+func FromEnv(getenv func(string) string) (*Event, error) {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		return gitHubEvent(getenv)
+	case getenv("GITLAB_CI") == "true":
+		return gitLabEvent(getenv), nil
+	}
+	return nil, ErrNotCI
+}
+
+// The parts of a GitHub webhook payload which are used.
+type gitHubPayload struct {
+	PullRequest *struct {
+		Number             int          `json:"number"`
+		Title              string       `json:"title"`
+		HTMLURL            string       `json:"html_url"`
+		Merged             bool         `json:"merged"`
+		User               gitHubUser   `json:"user"`
+		MergedBy           *gitHubUser  `json:"merged_by"`
+		RequestedReviewers []gitHubUser `json:"requested_reviewers"`
+	} `json:"pull_request"`
+}
+
+type gitHubUser struct {
+	Login string `json:"login"`
+}
+
+func gitHubEvent(getenv func(string) string) (*Event, error) {
+	event := &Event{
+		Provider:   GitHub,
+		Repository: getenv("GITHUB_REPOSITORY"),
+		Actor:      getenv("GITHUB_ACTOR"),
+	}
+	if server, run := getenv("GITHUB_SERVER_URL"), getenv("GITHUB_RUN_ID"); server != "" && run != "" {
+		event.BuildURL = fmt.Sprintf("%s/%s/actions/runs/%s", server, event.Repository, run)
+	}
+	path := getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		return event, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var payload gitHubPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if pr := payload.PullRequest; pr != nil {
+		event.PullRequest = pr.Number
+		event.Title = pr.Title
+		event.URL = pr.HTMLURL
+		event.Author = pr.User.Login
+		event.Merged = pr.Merged
+		if pr.MergedBy != nil {
+			event.MergedBy = pr.MergedBy.Login
+		}
+		for _, reviewer := range pr.RequestedReviewers {
+			event.Reviewers = append(event.Reviewers, reviewer.Login)
+		}
+	}
+	return event, nil
+}
+
+func gitLabEvent(getenv func(string) string) *Event {
+	event := &Event{
+		Provider:   GitLab,
+		Repository: getenv("CI_PROJECT_PATH"),
+		Actor:      getenv("GITLAB_USER_LOGIN"),
+		BuildURL:   getenv("CI_PIPELINE_URL"),
+	}
+	iid := getenv("CI_MERGE_REQUEST_IID")
+	if iid == "" {
+		return event
+	}
+	event.PullRequest, _ = strconv.Atoi(iid)
+	event.Title = getenv("CI_MERGE_REQUEST_TITLE")
+	if project := getenv("CI_MERGE_REQUEST_PROJECT_URL"); project != "" {
+		event.URL = project + "/-/merge_requests/" + iid
+	}
+	event.Author = event.Actor
+	event.Reviewers = splitHandles(getenv("CI_MERGE_REQUEST_ASSIGNEES"))
+	return event
+}
+
+/*
+Split a comma separated list of handles, dropping blanks.
+*/
+func splitHandles(list string) []string {
+	var handles []string
+	for _, handle := range strings.Split(list, ",") {
+		if handle = strings.TrimSpace(handle); handle != "" {
+			handles = append(handles, handle)
+		}
+	}
+	return handles
+}
+
+/*
+Return the fields of the event by name, as strings, for use as template data
+such as in love.RenderMessages.
+*/
+func (e *Event) Fields() map[string]string {
+	fields := map[string]string{
+		"Provider":    e.Provider,
+		"Repository":  e.Repository,
+		"Actor":       e.Actor,
+		"BuildURL":    e.BuildURL,
+		"Title":       e.Title,
+		"URL":         e.URL,
+		"Author":      e.Author,
+		"MergedBy":    e.MergedBy,
+		"Reviewers":   strings.Join(e.Reviewers, ", "),
+		"PullRequest": "",
+	}
+	if e.PullRequest != 0 {
+		fields["PullRequest"] = strconv.Itoa(e.PullRequest)
+	}
+	return fields
+}
+
+/*
+IsBot reports whether a handle belongs to a bot account, such as
+"dependabot[bot]" on GitHub or a "project_1_bot" token user on GitLab, which
+cannot receive love.
+*/
+func IsBot(handle string) bool {
+	return strings.HasSuffix(handle, "[bot]") ||
+		strings.HasPrefix(handle, "project_") && strings.HasSuffix(handle, "_bot") ||
+		strings.HasPrefix(handle, "group_") && strings.HasSuffix(handle, "_bot")
+}
+
+/*
+Users maps the handles of a CI service to love usernames. Handles are matched
+without regard to case, as both services treat them. It may be shared between
+repositories, and kept with the love configuration.
+*/
+type Users map[string]string
+
+/*
+Read a JSON file holding an object which maps handles to love usernames:
+
+	{"octocat": "hammy", "dependabot[bot]": ""}
+
+An empty path maps no users. An empty username marks a handle which should not
+receive love.
+*/
+func ReadUsers(path string) (Users, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var mapping map[string]string
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	users := make(Users, len(mapping))
+	for handle, username := range mapping {
+		users[strings.ToLower(handle)] = username
+	}
+	return users, nil
+}
+
+/*
+Return the love username of a handle, and whether it may receive love. Handles
+which are not in the map are assumed to be the same as the username, unless
+they belong to bots. Handles mapped to an empty username may not receive love.
+*/
+func (u Users) Username(handle string) (string, bool) {
+	if username, ok := u[strings.ToLower(handle)]; ok {
+		return username, username != ""
+	}
+	return handle, handle != "" && !IsBot(handle)
+}
@@ -0,0 +1,160 @@
+package ci
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+const mergedPayload = `{
+	"action": "closed",
+	"pull_request": {
+		"number": 42,
+		"title": "Add golove ci",
+		"html_url": "https://github.com/hacsoc/golove/pull/42",
+		"merged": true,
+		"user": {"login": "Hammy"},
+		"merged_by": {"login": "darwin"},
+		"requested_reviewers": [{"login": "jeremy"}, {"login": "copilot[bot]"}]
+	}
+}`
+
+/*
+Return a getenv which looks up variables in env.
+*/
+func env(vars map[string]string) func(string) string {
+	return func(name string) string {
+		return vars[name]
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFromEnvNotCI(t *testing.T) {
+	_, err := FromEnv(env(nil))
+	assert.Equal(t, err, ErrNotCI)
+}
+
+func TestFromEnvGitHub(t *testing.T) {
+	event, err := FromEnv(env(map[string]string{
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_REPOSITORY": "hacsoc/golove",
+		"GITHUB_ACTOR":      "darwin",
+		"GITHUB_SERVER_URL": "https://github.com",
+		"GITHUB_RUN_ID":     "7",
+		"GITHUB_EVENT_PATH": writeFile(t, "event.json", mergedPayload),
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, event, &Event{
+		Provider:    GitHub,
+		Repository:  "hacsoc/golove",
+		Actor:       "darwin",
+		BuildURL:    "https://github.com/hacsoc/golove/actions/runs/7",
+		PullRequest: 42,
+		Title:       "Add golove ci",
+		URL:         "https://github.com/hacsoc/golove/pull/42",
+		Author:      "Hammy",
+		Reviewers:   []string{"jeremy", "copilot[bot]"},
+		Merged:      true,
+		MergedBy:    "darwin",
+	})
+	assert.Equal(t, event.Fields()["PullRequest"], "42")
+	assert.Equal(t, event.Fields()["Reviewers"], "jeremy, copilot[bot]")
+}
+
+func TestFromEnvGitHubPush(t *testing.T) {
+	event, err := FromEnv(env(map[string]string{
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_ACTOR":      "darwin",
+		"GITHUB_EVENT_PATH": writeFile(t, "event.json", `{"ref": "refs/heads/master"}`),
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, event.Actor, "darwin")
+	assert.Equal(t, event.PullRequest, 0)
+	assert.Equal(t, event.BuildURL, "")
+	assert.Equal(t, event.Fields()["PullRequest"], "")
+}
+
+func TestFromEnvGitHubBadPayload(t *testing.T) {
+	_, err := FromEnv(env(map[string]string{
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_EVENT_PATH": writeFile(t, "event.json", "{"),
+	}))
+	assert.NotNil(t, err)
+}
+
+func TestFromEnvGitLab(t *testing.T) {
+	event, err := FromEnv(env(map[string]string{
+		"GITLAB_CI":                    "true",
+		"CI_PROJECT_PATH":              "hacsoc/golove",
+		"GITLAB_USER_LOGIN":            "hammy",
+		"CI_PIPELINE_URL":              "https://gitlab.com/hacsoc/golove/-/pipelines/9",
+		"CI_MERGE_REQUEST_IID":         "3",
+		"CI_MERGE_REQUEST_TITLE":       "Add golove ci",
+		"CI_MERGE_REQUEST_PROJECT_URL": "https://gitlab.com/hacsoc/golove",
+		"CI_MERGE_REQUEST_ASSIGNEES":   "darwin, jeremy,",
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, event, &Event{
+		Provider:    GitLab,
+		Repository:  "hacsoc/golove",
+		Actor:       "hammy",
+		BuildURL:    "https://gitlab.com/hacsoc/golove/-/pipelines/9",
+		PullRequest: 3,
+		Title:       "Add golove ci",
+		URL:         "https://gitlab.com/hacsoc/golove/-/merge_requests/3",
+		Author:      "hammy",
+		Reviewers:   []string{"darwin", "jeremy"},
+	})
+}
+
+func TestIsBot(t *testing.T) {
+	assert.True(t, IsBot("dependabot[bot]"))
+	assert.True(t, IsBot("project_12_bot"))
+	assert.True(t, IsBot("group_3_bot"))
+	assert.False(t, IsBot("darwin"))
+	assert.False(t, IsBot("robot"))
+}
+
+func TestUsers(t *testing.T) {
+	users, err := ReadUsers(writeFile(t, "users.json",
+		`{"Hammy": "hamilton", "darwin-gh": "darwin", "nobody": ""}`))
+	assert.Nil(t, err)
+
+	username, ok := users.Username("hammy")
+	assert.Equal(t, username, "hamilton")
+	assert.True(t, ok)
+	username, ok = users.Username("Darwin-GH")
+	assert.Equal(t, username, "darwin")
+	assert.True(t, ok)
+	_, ok = users.Username("nobody")
+	assert.False(t, ok)
+	username, ok = users.Username("jeremy")
+	assert.Equal(t, username, "jeremy")
+	assert.True(t, ok)
+	_, ok = users.Username("renovate[bot]")
+	assert.False(t, ok)
+	_, ok = users.Username("")
+	assert.False(t, ok)
+}
+
+func TestReadUsersErrors(t *testing.T) {
+	users, err := ReadUsers("")
+	assert.Nil(t, err)
+	assert.Nil(t, users)
+	username, ok := users.Username("hammy")
+	assert.Equal(t, username, "hammy")
+	assert.True(t, ok)
+
+	_, err = ReadUsers(writeFile(t, "users.json", `["hammy"]`))
+	assert.NotNil(t, err)
+	_, err = ReadUsers(filepath.Join(t.TempDir(), "missing.json"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,197 @@
+/*
+Package digest summarizes the love received over a period, such as a week, as
+an email. A Digest holds the love received by one user, or by a whole team, and
+is rendered into a Message by a set of Templates, which may be customized. A
+Mailer sends Messages over SMTP:
+
+	digests := digest.ForRecipients(loves, start, end)
+	templates := digest.DefaultTemplates()
+	mailer := &digest.Mailer{Addr: "smtp.example.com:587", From: "love@example.com"}
+	for _, d := range digests {
+		msg, err := templates.Render(d)
+		if err != nil {
+			return err
+		}
+		msg.To = []string{d.Recipient + "@example.com"}
+		if err := mailer.Send(msg); err != nil {
+			return err
+		}
+	}
+*/
+package digest
+
+import (
+	"github.com/hacsoc/golove/love"
+	"sort"
+	"time"
+)
+
+/*
+A Digest holds the love received during a period, from Start up to but not
+including End. Recipient is the user who received the love, or empty for a
+team digest, which holds the love received by several users. Loves are sorted
+oldest first.
+
+Profiles holds the profiles of the users in the digest, by username, where the
+instance provides them (see love.Client.GetUser). It may be nil, in which case
+users are shown by username.
+
+Trend compares the love in the digest with the love received before it, as set
+by SetTrend. It may be nil, in which case the digest does not say whether love
+is rising or falling.
+*/
+type Digest struct {
+	Recipient string
+	Start     time.Time
+	End       time.Time
+	Loves     []love.Love
+	Profiles  map[string]*love.Profile
+	Trend     *love.Trend
+}
+
+/*
+The fall in love, as a fraction of the average of the previous periods, at
+which a digest's love is Dropping.
+*/
+const DropThreshold = 0.25
+
+/*
+Return a digest for each user who received love between start and end, sorted by
+recipient. Love outside the period is ignored.
+*/
+func ForRecipients(loves []love.Love, start, end time.Time) []*Digest {
+	byRecipient := make(map[string]*Digest)
+	var digests []*Digest
+	for _, l := range inPeriod(loves, start, end) {
+		d := byRecipient[l.Recipient]
+		if d == nil {
+			d = &Digest{Recipient: l.Recipient, Start: start, End: end}
+			byRecipient[l.Recipient] = d
+			digests = append(digests, d)
+		}
+		d.Loves = append(d.Loves, l)
+	}
+	sort.Slice(digests, func(i, j int) bool {
+		return digests[i].Recipient < digests[j].Recipient
+	})
+	return digests
+}
+
+/*
+Return one digest of all the love sent between start and end.
+*/
+func ForTeam(loves []love.Love, start, end time.Time) *Digest {
+	return &Digest{Start: start, End: end, Loves: inPeriod(loves, start, end)}
+}
+
+func inPeriod(loves []love.Love, start, end time.Time) []love.Love {
+	filter := love.LoveFilter{Since: start, Until: end}
+	var result []love.Love
+	for _, l := range loves {
+		if filter.Match(l) {
+			result = append(result, l)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result
+}
+
+/*
+Set the digest's Trend, comparing its love with the love received during each
+of the periods of the same length before it. loves should include every love
+received since love.TrendSince(d.Start, d.End, periods); for a recipient's
+digest, love received by other users is ignored.
+*/
+func (d *Digest) SetTrend(loves []love.Love, periods int) {
+	var received []love.Love
+	for _, l := range loves {
+		if d.Recipient == "" || l.Recipient == d.Recipient {
+			received = append(received, l)
+		}
+	}
+	trend := love.ComputeTrend(received, d.Start, d.End, periods)
+	d.Trend = &trend
+}
+
+/*
+Report whether the love in the digest fell by DropThreshold or more from the
+average of the periods before it, which the default templates point out to
+teams, so that whoever leads them can prompt them to send more.
+*/
+func (d *Digest) Dropping() bool {
+	return d.Trend != nil && d.Trend.Falling(DropThreshold)
+}
+
+/*
+Return the users who sent the love in the digest, with how much each sent, most
+first.
+*/
+func (d *Digest) Senders() []love.Count {
+	return love.TopCounts(love.ComputeStats(d.Loves).BySender, 0)
+}
+
+/*
+Return the users who received the love in the digest, with how much each
+received, most first.
+*/
+func (d *Digest) Recipients() []love.Count {
+	return love.TopCounts(love.ComputeStats(d.Loves).ByRecipient, 0)
+}
+
+/*
+Return every user who sent or received love in the digest, and the recipient,
+in sorted order.
+*/
+func (d *Digest) Users() []string {
+	seen := make(map[string]bool)
+	if d.Recipient != "" {
+		seen[d.Recipient] = true
+	}
+	for _, l := range d.Loves {
+		seen[l.Sender] = true
+		seen[l.Recipient] = true
+	}
+	users := make([]string, 0, len(seen))
+	for user := range seen {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	return users
+}
+
+/*
+Return the name to show for a user: their full name if their profile is known,
+and otherwise their username.
+*/
+func (d *Digest) Name(username string) string {
+	if p := d.Profiles[username]; p != nil && p.FullName != "" {
+		return p.FullName
+	}
+	return username
+}
+
+/*
+Describe the period of the digest, such as "Apr 3 – Apr 9, 2017". Since End is
+excluded, the last day shown is the day before End.
+*/
+func (d *Digest) Period() string {
+	last := d.End.Add(-time.Nanosecond)
+	if last.Before(d.Start) {
+		last = d.Start
+	}
+	if sameDay(d.Start, last) {
+		return d.Start.Format("Jan 2, 2006")
+	}
+	if d.Start.Year() != last.Year() {
+		return d.Start.Format("Jan 2, 2006") + " – " + last.Format("Jan 2, 2006")
+	}
+	return d.Start.Format("Jan 2") + " – " + last.Format("Jan 2, 2006")
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,98 @@
+package digest
+
+import (
+	"github.com/hacsoc/golove/love"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var (
+	weekStart = time.Date(2017, 4, 3, 0, 0, 0, 0, time.UTC)
+	weekEnd   = weekStart.AddDate(0, 0, 7)
+)
+
+func day(n int) time.Time {
+	return weekStart.AddDate(0, 0, n).Add(12 * time.Hour)
+}
+
+func testLoves() []love.Love {
+	return []love.Love{
+		{Sender: "jeremy", Recipient: "hammy", Message: "late", Timestamp: day(7)},
+		{Sender: "darwin", Recipient: "hammy", Message: "thanks!", Timestamp: day(2)},
+		{Sender: "hammy", Recipient: "darwin", Message: "you too", Timestamp: day(3)},
+		{Sender: "jeremy", Recipient: "hammy", Message: "great demo", Timestamp: day(0)},
+		{Sender: "jeremy", Recipient: "darwin", Message: "early", Timestamp: day(-1)},
+	}
+}
+
+func TestForRecipients(t *testing.T) {
+	digests := ForRecipients(testLoves(), weekStart, weekEnd)
+	assert.Len(t, digests, 2)
+	assert.Equal(t, "darwin", digests[0].Recipient)
+	assert.Len(t, digests[0].Loves, 1)
+	hammy := digests[1]
+	assert.Equal(t, "hammy", hammy.Recipient)
+	assert.Equal(t, weekStart, hammy.Start)
+	assert.Equal(t, weekEnd, hammy.End)
+	assert.Equal(t, []string{"great demo", "thanks!"},
+		[]string{hammy.Loves[0].Message, hammy.Loves[1].Message})
+	assert.Equal(t, []love.Count{{Key: "darwin", Count: 1}, {Key: "jeremy", Count: 1}}, hammy.Senders())
+}
+
+func TestForTeam(t *testing.T) {
+	d := ForTeam(testLoves(), weekStart, weekEnd)
+	assert.Equal(t, "", d.Recipient)
+	assert.Len(t, d.Loves, 3)
+	assert.Equal(t, "great demo", d.Loves[0].Message)
+	assert.Equal(t, []love.Count{{Key: "hammy", Count: 2}, {Key: "darwin", Count: 1}}, d.Recipients())
+}
+
+func TestUsersAndNames(t *testing.T) {
+	d := ForRecipients(testLoves(), weekStart, weekEnd)[1]
+	assert.Equal(t, []string{"darwin", "hammy", "jeremy"}, d.Users())
+	assert.Equal(t, "darwin", d.Name("darwin"))
+
+	d.Profiles = map[string]*love.Profile{
+		"darwin": {Username: "darwin", FullName: "Darwin Dog"},
+		"jeremy": {Username: "jeremy"},
+	}
+	assert.Equal(t, "Darwin Dog", d.Name("darwin"))
+	assert.Equal(t, "jeremy", d.Name("jeremy"))
+	assert.Equal(t, "hammy", d.Name("hammy"))
+}
+
+func TestPeriod(t *testing.T) {
+	d := &Digest{Start: weekStart, End: weekEnd}
+	assert.Equal(t, "Apr 3 – Apr 9, 2017", d.Period())
+	d.End = weekStart.AddDate(0, 0, 1)
+	assert.Equal(t, "Apr 3, 2017", d.Period())
+	d.Start, d.End = time.Date(2016, 12, 26, 0, 0, 0, 0, time.UTC), weekStart
+	assert.Equal(t, "Dec 26, 2016 – Apr 2, 2017", d.Period())
+}
+
+func TestSetTrend(t *testing.T) {
+	loves := testLoves()
+	// Two love in each of the two weeks before, one of them to hammy.
+	for _, n := range []int{-13, -8} {
+		loves = append(loves,
+			love.Love{Sender: "jeremy", Recipient: "hammy", Timestamp: day(n)},
+			love.Love{Sender: "hammy", Recipient: "darwin", Timestamp: day(n)})
+	}
+
+	d := ForTeam(loves, weekStart, weekEnd)
+	assert.False(t, d.Dropping())
+	d.SetTrend(loves, 2)
+	assert.Equal(t, 3, d.Trend.Current)
+	assert.Equal(t, 2.5, d.Trend.Average)
+	assert.False(t, d.Dropping())
+
+	hammy := ForRecipients(loves, weekStart, weekEnd)[1]
+	hammy.SetTrend(loves, 2)
+	assert.Equal(t, love.Trend{Current: 2, Average: 1, Periods: 2, Length: 7 * 24 * time.Hour},
+		*hammy.Trend)
+
+	darwin := ForRecipients(loves, weekStart, weekEnd)[0]
+	darwin.SetTrend(loves, 2)
+	assert.True(t, darwin.Dropping())
+}
@@ -0,0 +1,102 @@
+package digest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+/*
+A Mailer sends Messages through the SMTP server at Addr (host:port), which is
+authenticated with Auth if it is not nil. From is used for messages which don't
+set it.
+*/
+type Mailer struct {
+	Addr string
+	Auth smtp.Auth
+	From string
+
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+/*
+Send a message to its recipients.
+*/
+func (m *Mailer) Send(msg *Message) error {
+	from := msg.From
+	if from == "" {
+		from = m.From
+	}
+	if from == "" {
+		return errors.New("digest: no sender address")
+	}
+	if len(msg.To) == 0 {
+		return errors.New("digest: no recipient addresses")
+	}
+	copied := *msg
+	copied.From = from
+	send := smtp.SendMail
+	if m.sendMail != nil {
+		send = m.sendMail
+	}
+	if err := send(m.Addr, m.Auth, from, msg.To, copied.Bytes(time.Now())); err != nil {
+		return fmt.Errorf("sending digest to %s: %s", strings.Join(msg.To, ", "), err)
+	}
+	return nil
+}
+
+/*
+Encode a message in MIME format, dated at date. A message with HTML is sent as
+multipart/alternative, with the plain text first.
+*/
+func (msg *Message) Bytes(date time.Time) []byte {
+	var buffer bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buffer, "%s: %s\r\n", key, value)
+	}
+	header("From", msg.From)
+	header("To", strings.Join(msg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	if msg.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buffer.WriteString("\r\n")
+		writeQuotedPrintable(&buffer, msg.Text)
+		return buffer.Bytes()
+	}
+	parts := multipart.NewWriter(&buffer)
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buffer.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, _ := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		writeQuotedPrintable(w, part.body)
+	}
+	parts.Close()
+	return buffer.Bytes()
+}
+
+/*
+Write text as quoted-printable, with CRLF line endings.
+*/
+func writeQuotedPrintable(w io.Writer, text string) {
+	text = strings.Replace(text, "\r\n", "\n", -1)
+	qp := quotedprintable.NewWriter(w)
+	qp.Write([]byte(strings.Replace(text, "\n", "\r\n", -1)))
+	qp.Close()
+}
@@ -0,0 +1,100 @@
+package digest
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sentMail struct {
+	addr string
+	from string
+	to   []string
+	msg  []byte
+}
+
+func testMailer(sent *sentMail, err error) *Mailer {
+	return &Mailer{
+		Addr: "smtp.example.com:587",
+		From: "love@example.com",
+		sendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			*sent = sentMail{addr, from, to, msg}
+			return err
+		},
+	}
+}
+
+func TestSendMultipart(t *testing.T) {
+	var sent sentMail
+	msg := &Message{
+		To:      []string{"hammy@example.com"},
+		Subject: "You received 2 loves – again",
+		Text:    "Hi hammy,\nthanks!\n",
+		HTML:    "<p>Hi hammy,</p>",
+	}
+	assert.Nil(t, testMailer(&sent, nil).Send(msg))
+	assert.Equal(t, "smtp.example.com:587", sent.addr)
+	assert.Equal(t, "love@example.com", sent.from)
+	assert.Equal(t, []string{"hammy@example.com"}, sent.to)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(sent.msg)))
+	assert.Nil(t, err)
+	assert.Equal(t, "love@example.com", parsed.Header.Get("From"))
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	assert.Equal(t, "You received 2 loves – again", subject)
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	assert.Nil(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		body, _ := ioutil.ReadAll(part)
+		bodies = append(bodies, part.Header.Get("Content-Type")+": "+string(body))
+	}
+	assert.Equal(t, []string{
+		"text/plain; charset=utf-8: Hi hammy,\r\nthanks!\r\n",
+		"text/html; charset=utf-8: <p>Hi hammy,</p>",
+	}, bodies)
+}
+
+func TestSendPlainText(t *testing.T) {
+	var sent sentMail
+	msg := &Message{From: "team@example.com", To: []string{"a@example.com"},
+		Subject: "Love", Text: "thanks!\n"}
+	assert.Nil(t, testMailer(&sent, nil).Send(msg))
+	assert.Equal(t, "team@example.com", sent.from)
+	parsed, err := mail.ReadMessage(strings.NewReader(string(sent.msg)))
+	assert.Nil(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", parsed.Header.Get("Content-Type"))
+	body, _ := ioutil.ReadAll(parsed.Body)
+	assert.Equal(t, "thanks!\r\n", string(body))
+}
+
+func TestSendErrors(t *testing.T) {
+	var sent sentMail
+	mailer := testMailer(&sent, errors.New("535 authentication failed"))
+	err := mailer.Send(&Message{To: []string{"a@example.com"}})
+	assert.EqualError(t, err, "sending digest to a@example.com: 535 authentication failed")
+
+	assert.NotNil(t, mailer.Send(&Message{}))
+	mailer.From = ""
+	assert.NotNil(t, mailer.Send(&Message{To: []string{"a@example.com"}}))
+}
+
+func TestMessageDate(t *testing.T) {
+	date := time.Date(2017, 4, 10, 9, 0, 0, 0, time.UTC)
+	data := (&Message{Text: "hi"}).Bytes(date)
+	assert.Contains(t, string(data), "Date: Mon, 10 Apr 2017 09:00:00 +0000\r\n")
+}
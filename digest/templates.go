@@ -0,0 +1,137 @@
+package digest
+
+import (
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+/*
+Templates render a Digest as an email. Each template is executed with the
+*Digest, and may call the plural function: {{plural 3 "love"}} is "3 loves".
+Users are best shown with the Digest's Name method, as in {{$.Name .Sender}},
+which uses their full name when it is known. Messages are best shown with the
+markdown function, as in {{markdown .Message}}, which renders the light
+markdown some instances allow: as HTML in the HTML template (see
+love.MarkdownHTML), and as plain text in the others (see love.MarkdownText).
+HTML may be nil, for plain text emails.
+*/
+type Templates struct {
+	Subject *texttemplate.Template
+	Text    *texttemplate.Template
+	HTML    *htmltemplate.Template
+}
+
+/*
+A rendered email. From and To are left for the caller to fill in.
+*/
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return "1 " + word
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+var textFuncs = map[string]interface{}{
+	"plural":   plural,
+	"markdown": love.MarkdownText,
+}
+
+var htmlFuncs = map[string]interface{}{
+	"plural": plural,
+	"markdown": func(message string) htmltemplate.HTML {
+		// MarkdownHTML escapes everything but its own markup.
+		return htmltemplate.HTML(love.MarkdownHTML(message))
+	},
+}
+
+const defaultSubject = `{{if .Recipient}}You received {{plural (len .Loves) "love"}}` +
+	`{{else}}Your team received {{plural (len .Loves) "love"}}{{end}}, {{.Period}}`
+
+const defaultText = `{{if .Recipient}}Hi {{.Name .Recipient}},
+
+You received {{plural (len .Loves) "love"}} from {{.Period}}.
+{{- else}}Your team received {{plural (len .Loves) "love"}} from {{.Period}}.
+{{- if .Dropping}} That's {{.Trend}}. Who could you thank?{{end}}
+{{- end}}
+{{range .Loves}}
+{{$.Name .Sender}}{{if not $.Recipient}} to {{$.Name .Recipient}}{{end}}, {{.Timestamp.Format "Mon Jan 2"}}:
+    {{markdown .Message}}
+{{end}}{{if not .Loves}}
+No love this time. Why not send some?
+{{end}}`
+
+const defaultHTML = `<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; max-width: 40em;">
+{{if .Recipient}}<p>Hi {{.Name .Recipient}},</p>
+<p>You received {{plural (len .Loves) "love"}} from {{.Period}}.</p>
+{{else}}<p>Your team received {{plural (len .Loves) "love"}} from {{.Period}}.
+{{- if .Dropping}} That's {{.Trend}}. Who could you thank?{{end}}</p>
+{{end}}{{range .Loves}}<blockquote style="border-left: 4px solid #d32323; margin: 1em 0; padding-left: 1em;">
+<p>{{markdown .Message}}</p>
+<p style="color: #666;">&mdash; {{$.Name .Sender}}{{if not $.Recipient}} to {{$.Name .Recipient}}{{end}}, {{.Timestamp.Format "Mon Jan 2"}}</p>
+</blockquote>
+{{else}}<p>No love this time. Why not send some?</p>
+{{end}}</body>
+</html>
+`
+
+/*
+Return the default templates, which list each love with its sender and date.
+*/
+func DefaultTemplates() *Templates {
+	return &Templates{
+		Subject: texttemplate.Must(ParseText("subject", defaultSubject)),
+		Text:    texttemplate.Must(ParseText("text", defaultText)),
+		HTML:    htmltemplate.Must(ParseHTML("html", defaultHTML)),
+	}
+}
+
+/*
+Parse a subject or plain text template, in the syntax of text/template.
+*/
+func ParseText(name, text string) (*texttemplate.Template, error) {
+	return texttemplate.New(name).Funcs(textFuncs).Parse(text)
+}
+
+/*
+Parse an HTML template, in the syntax of html/template.
+*/
+func ParseHTML(name, text string) (*htmltemplate.Template, error) {
+	return htmltemplate.New(name).Funcs(htmlFuncs).Parse(text)
+}
+
+/*
+Render a digest as an email.
+*/
+func (t *Templates) Render(d *Digest) (*Message, error) {
+	var subject, text, html strings.Builder
+	if err := t.Subject.Execute(&subject, d); err != nil {
+		return nil, err
+	}
+	if err := t.Text.Execute(&text, d); err != nil {
+		return nil, err
+	}
+	if t.HTML != nil {
+		if err := t.HTML.Execute(&html, d); err != nil {
+			return nil, err
+		}
+	}
+	return &Message{
+		// Headers can't span lines.
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
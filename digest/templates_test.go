@@ -0,0 +1,108 @@
+package digest
+
+import (
+	"github.com/hacsoc/golove/love"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRenderRecipient(t *testing.T) {
+	d := ForRecipients(testLoves(), weekStart, weekEnd)[1]
+	msg, err := DefaultTemplates().Render(d)
+	assert.Nil(t, err)
+	assert.Equal(t, "You received 2 loves, Apr 3 – Apr 9, 2017", msg.Subject)
+	assert.Equal(t, `Hi hammy,
+
+You received 2 loves from Apr 3 – Apr 9, 2017.
+
+jeremy, Mon Apr 3:
+    great demo
+
+darwin, Wed Apr 5:
+    thanks!
+`, msg.Text)
+	assert.Contains(t, msg.HTML, "<p>great demo</p>")
+	assert.Contains(t, msg.HTML, "&mdash; darwin, Wed Apr 5</p>")
+}
+
+func TestRenderTeam(t *testing.T) {
+	d := ForTeam(testLoves()[:1], weekStart, weekEnd)
+	msg, err := DefaultTemplates().Render(d)
+	assert.Nil(t, err)
+	assert.Equal(t, "Your team received 0 loves, Apr 3 – Apr 9, 2017", msg.Subject)
+	assert.Contains(t, msg.Text, "No love this time.")
+	assert.Contains(t, msg.HTML, "No love this time.")
+
+	d = ForTeam(testLoves(), weekStart, weekEnd)
+	msg, err = DefaultTemplates().Render(d)
+	assert.Nil(t, err)
+	assert.Contains(t, msg.Text, "hammy to darwin, Thu Apr 6:\n    you too\n")
+}
+
+func TestRenderTeamDropping(t *testing.T) {
+	d := ForTeam(testLoves(), weekStart, weekEnd)
+	d.Trend = &love.Trend{Current: 3, Average: 6, Periods: 4, Length: weekEnd.Sub(weekStart)}
+	msg, err := DefaultTemplates().Render(d)
+	assert.Nil(t, err)
+	assert.Contains(t, msg.Text, "Your team received 3 loves from Apr 3 – Apr 9, 2017. "+
+		"That's down 50% vs the previous 4 weeks. Who could you thank?\n")
+	assert.Contains(t, msg.HTML, "That's down 50% vs the previous 4 weeks.")
+
+	d.Trend.Average = 3
+	msg, err = DefaultTemplates().Render(d)
+	assert.Nil(t, err)
+	assert.NotContains(t, msg.Text, "That's")
+}
+
+func TestRenderProfiles(t *testing.T) {
+	d := ForRecipients(testLoves(), weekStart, weekEnd)[1]
+	d.Profiles = map[string]*love.Profile{
+		"hammy":  {Username: "hammy", FullName: "Hammy Havoc"},
+		"darwin": {Username: "darwin", FullName: "Darwin Dog"},
+	}
+	msg, err := DefaultTemplates().Render(d)
+	assert.Nil(t, err)
+	assert.Contains(t, msg.Text, "Hi Hammy Havoc,\n")
+	assert.Contains(t, msg.Text, "jeremy, Mon Apr 3:\n")
+	assert.Contains(t, msg.Text, "Darwin Dog, Wed Apr 5:\n")
+	assert.Contains(t, msg.HTML, "&mdash; Darwin Dog, Wed Apr 5</p>")
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	d := ForTeam(testLoves(), weekStart, weekEnd)
+	d.Loves[0].Message = "<b>bold</b> & brave"
+	msg, err := DefaultTemplates().Render(d)
+	assert.Nil(t, err)
+	assert.Contains(t, msg.HTML, "&lt;b&gt;bold&lt;/b&gt; &amp; brave")
+	assert.Contains(t, msg.Text, "<b>bold</b> & brave")
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	d := ForTeam(testLoves(), weekStart, weekEnd)
+	d.Loves[0].Message = "**great** [demo](https://example.com/demo)"
+	msg, err := DefaultTemplates().Render(d)
+	assert.Nil(t, err)
+	assert.Contains(t, msg.HTML,
+		`<p><strong>great</strong> <a href="https://example.com/demo">demo</a></p>`)
+	assert.Contains(t, msg.Text, "    great demo (https://example.com/demo)\n")
+}
+
+func TestCustomTemplates(t *testing.T) {
+	templates := DefaultTemplates()
+	var err error
+	templates.Subject, err = ParseText("subject", "Kudos\nfor {{.Recipient}}")
+	assert.Nil(t, err)
+	templates.Text, err = ParseText("text", `{{range .Senders}}{{.Key}}: {{plural .Count "love"}}
+{{end}}`)
+	assert.Nil(t, err)
+	templates.HTML = nil
+	msg, err := templates.Render(ForRecipients(testLoves(), weekStart, weekEnd)[1])
+	assert.Nil(t, err)
+	assert.Equal(t, "Kudos for hammy", msg.Subject)
+	assert.Equal(t, "darwin: 1 love\njeremy: 1 love\n", msg.Text)
+	assert.Equal(t, "", msg.HTML)
+
+	templates.Text, _ = ParseText("text", "{{.Missing}}")
+	_, err = templates.Render(&Digest{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,240 @@
+/*
+Package feed serves recent love as an Atom feed, so that it can be followed in
+a feed reader or shown on a dashboard. The Handler shows the love received by
+a team of users, or the love sent or received by the users named in the
+request's query:
+
+	/feed.atom                     love received by the Handler's Users
+	/feed.atom?recipient=hammy     love received by hammy
+	/feed.atom?recipient=a,b       love received by a or b
+	/feed.atom?sender=hammy        love sent by hammy
+	/feed.atom?limit=50            the 50 newest love (at most MaxLimit)
+*/
+package feed
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// The number of love in a feed, unless the Handler or request says otherwise.
+	DefaultLimit = 20
+	// The most love a request may ask for.
+	MaxLimit = 100
+)
+
+/*
+A Handler serves an Atom feed of the newest love.
+*/
+type Handler struct {
+	Service love.LoveService
+	// The users whose received love is shown when the request names no one.
+	Users []string
+	// Zero for DefaultLimit.
+	Limit int
+	// The title of the feed; "Love" if empty.
+	Title string
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	limit := h.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	senders := splitUsers(query.Get("sender"))
+	recipients := splitUsers(query.Get("recipient"))
+	if len(senders) == 0 && len(recipients) == 0 {
+		recipients = h.Users
+	}
+	if len(senders) == 0 && len(recipients) == 0 {
+		http.Error(w, "a sender or recipient is required", http.StatusBadRequest)
+		return
+	}
+
+	var loves []love.Love
+	for _, sender := range senders {
+		sent, err := h.Service.GetLove(sender, "", int64(limit))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		loves = append(loves, sent...)
+	}
+	for _, recipient := range recipients {
+		received, err := h.Service.GetLove("", recipient, int64(limit))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		loves = append(loves, received...)
+	}
+	loves = newest(loves, limit)
+
+	title := h.Title
+	if title == "" {
+		title = "Love"
+	}
+	if len(senders) > 0 || len(query.Get("recipient")) > 0 {
+		title = describe(title, senders, recipients)
+	}
+	body, err := Render(loves, title, selfURL(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write(body)
+}
+
+func splitUsers(value string) []string {
+	var users []string
+	for _, user := range strings.Split(value, ",") {
+		if user = strings.TrimSpace(user); user != "" {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+/*
+Return the n newest love, newest first, without duplicates.
+*/
+func newest(loves []love.Love, n int) []love.Love {
+	unique := love.Merge(loves)
+	if len(unique) > n {
+		unique = unique[:n]
+	}
+	return unique
+}
+
+func describe(title string, senders, recipients []string) string {
+	var parts []string
+	if len(senders) > 0 {
+		parts = append(parts, "from "+strings.Join(senders, ", "))
+	}
+	if len(recipients) > 0 {
+		parts = append(parts, "to "+strings.Join(recipients, ", "))
+	}
+	return title + " " + strings.Join(parts, " and ")
+}
+
+/*
+Return the URL of the request, which identifies the feed.
+*/
+func selfURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Author  atomPerson  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string     `xml:"title"`
+	ID      string     `xml:"id"`
+	Updated string     `xml:"updated"`
+	Author  atomPerson `xml:"author"`
+	Content atomText   `xml:"content"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+/*
+Render love as an Atom feed, in the order given. The feed is identified by
+self, its URL. Each love is an entry titled with its sender and recipient,
+identified by a tag URI derived from the love, so that it is stable across
+requests.
+*/
+func Render(loves []love.Love, title, self string) ([]byte, error) {
+	feed := atomFeed{
+		Title:  title,
+		ID:     self,
+		Link:   atomLink{Rel: "self", Href: self},
+		Author: atomPerson{Name: "golove"},
+	}
+	var updated time.Time
+	for _, l := range loves {
+		if l.Timestamp.After(updated) {
+			updated = l.Timestamp
+		}
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:   fmt.Sprintf("%s sent love to %s", l.Sender, l.Recipient),
+			ID:      entryID(l),
+			Updated: l.Timestamp.UTC().Format(time.RFC3339),
+			Author:  atomPerson{Name: l.Sender},
+			Content: atomText{Type: "text", Body: l.Message},
+		})
+	}
+	if updated.IsZero() {
+		// An empty feed has no natural date; the epoch keeps it stable.
+		updated = time.Unix(0, 0)
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+	var buffer bytes.Buffer
+	buffer.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buffer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(feed); err != nil {
+		return nil, err
+	}
+	buffer.WriteString("\n")
+	return buffer.Bytes(), nil
+}
+
+/*
+Return a tag URI (RFC 4151) identifying a love.
+*/
+func entryID(l love.Love) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%s\x00%s\x00%s", l.Sender, l.Recipient,
+		l.Timestamp.UTC().Format(time.RFC3339Nano), l.Message)
+	return fmt.Sprintf("tag:golove,%s:%s", l.Timestamp.UTC().Format("2006-01-02"),
+		hex.EncodeToString(hash.Sum(nil))[:16])
+}
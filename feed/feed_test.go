@@ -0,0 +1,111 @@
+package feed
+
+import (
+	"encoding/xml"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func at(hour int) time.Time {
+	return time.Date(2017, 4, 1, hour, 0, 0, 0, time.UTC)
+}
+
+func newTestServer() *lovetest.Server {
+	server := lovetest.NewServer("secret")
+	for _, user := range []string{"hammy", "darwin", "jeremy"} {
+		server.AddUser(user, user)
+	}
+	server.AddLove(love.Love{Sender: "darwin", Recipient: "hammy", Message: "thanks!", Timestamp: at(1)})
+	server.AddLove(love.Love{Sender: "hammy", Recipient: "jeremy", Message: "<3 & more", Timestamp: at(2)})
+	server.AddLove(love.Love{Sender: "jeremy", Recipient: "darwin", Message: "nice", Timestamp: at(3)})
+	return server
+}
+
+func get(h http.Handler, url string) (*httptest.ResponseRecorder, *atomFeed) {
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest("GET", url, nil))
+	var feed atomFeed
+	xml.Unmarshal(recorder.Body.Bytes(), &feed)
+	return recorder, &feed
+}
+
+func titles(feed *atomFeed) []string {
+	var result []string
+	for _, entry := range feed.Entries {
+		result = append(result, entry.Title)
+	}
+	return result
+}
+
+func TestTeamFeed(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	h := &Handler{Service: server.Client(), Users: []string{"hammy", "jeremy"}}
+	recorder, feed := get(h, "http://example.com/feed.atom")
+	assert.Equal(t, 200, recorder.Code)
+	assert.Equal(t, "application/atom+xml; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "Love", feed.Title)
+	assert.Equal(t, "http://example.com/feed.atom", feed.ID)
+	assert.Equal(t, "2017-04-01T02:00:00Z", feed.Updated)
+	assert.Equal(t, []string{"hammy sent love to jeremy", "darwin sent love to hammy"},
+		titles(feed))
+	assert.Equal(t, "<3 & more", feed.Entries[0].Content.Body)
+	assert.Equal(t, "hammy", feed.Entries[0].Author.Name)
+}
+
+func TestQueryFeed(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	h := &Handler{Service: server.Client(), Users: []string{"hammy"}, Title: "Team love"}
+
+	_, feed := get(h, "/feed.atom?recipient=darwin,jeremy&limit=1")
+	assert.Equal(t, "Team love to darwin, jeremy", feed.Title)
+	assert.Equal(t, []string{"jeremy sent love to darwin"}, titles(feed))
+
+	_, feed = get(h, "/feed.atom?sender=hammy&recipient=hammy")
+	assert.Equal(t, "Team love from hammy and to hammy", feed.Title)
+	assert.Equal(t, []string{"hammy sent love to jeremy", "darwin sent love to hammy"},
+		titles(feed))
+}
+
+func TestStableEntryIDs(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	h := &Handler{Service: server.Client(), Users: []string{"hammy", "darwin"}}
+	_, first := get(h, "/feed.atom")
+	_, second := get(h, "/feed.atom?recipient=darwin")
+	assert.Equal(t, first.Entries[0].ID, second.Entries[0].ID)
+	assert.NotEqual(t, first.Entries[0].ID, first.Entries[1].ID)
+	assert.Regexp(t, `^tag:golove,2017-04-01:[0-9a-f]{16}$`, first.Entries[0].ID)
+}
+
+func TestBadRequests(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	h := &Handler{Service: server.Client()}
+	recorder, _ := get(h, "/feed.atom")
+	assert.Equal(t, 400, recorder.Code)
+	recorder, _ = get(h, "/feed.atom?recipient=hammy&limit=x")
+	assert.Equal(t, 400, recorder.Code)
+	recorder = httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest("POST", "/feed.atom", nil))
+	assert.Equal(t, 405, recorder.Code)
+
+	client := server.Client()
+	client.ApiKey = "wrong"
+	h.Service = client
+	recorder, _ = get(h, "/feed.atom?recipient=hammy")
+	assert.Equal(t, 502, recorder.Code)
+}
+
+func TestRenderEmpty(t *testing.T) {
+	body, err := Render(nil, "Love", "http://example.com/feed.atom")
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), "<updated>1970-01-01T00:00:00Z</updated>")
+	assert.Contains(t, string(body), `<feed xmlns="http://www.w3.org/2005/Atom">`)
+}
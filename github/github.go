@@ -0,0 +1,452 @@
+/*
+Package github turns GitHub webhook events into love, so that contributions are
+appreciated without anyone having to remember to. Its Handler receives the
+webhooks of a repository or organization, and for each event matching one of
+its Rules, sends templated love between the people involved, such as from the
+author of a merged pull request to its reviewers:
+
+	{"event": "pull_request.merged", "from": "author", "to": ["reviewers"],
+	 "message": "Thanks for reviewing {{.Title}}! {{.URL}}"}
+
+To use it, add a webhook with the content type application/json and a secret,
+which the Handler checks the signature of every delivery with, sending the
+pull_request, pull_request_review and issues events. GitHub users are mapped to
+love usernames with a ci.Users map.
+*/
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hacsoc/golove/ci"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/webhook"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The largest delivery accepted. GitHub caps payloads at 25 MB, but those of
+// the events handled are far smaller.
+const maxRequestBytes = 5 * 1024 * 1024
+
+// The headers GitHub sends with each delivery.
+const (
+	EventHeader     = "X-GitHub-Event"
+	SignatureHeader = "X-Hub-Signature-256"
+)
+
+/*
+The events rules may match.
+*/
+const (
+	// A pull request was merged.
+	PullRequestMerged = "pull_request.merged"
+	// An issue was closed as completed.
+	IssueClosed = "issues.closed"
+)
+
+/*
+The people involved in an event, whom rules send love from and to.
+*/
+const (
+	// The user who opened the pull request or issue.
+	RoleAuthor = "author"
+	// The user whose action caused the event, such as merging.
+	RoleActor = "actor"
+	// The users who reviewed the pull request, or whose review is requested.
+	RoleReviewers = "reviewers"
+	// The users the pull request or issue is assigned to.
+	RoleAssignees = "assignees"
+)
+
+/*
+A Rule sends love when an Event happens: from the user in the role From to the
+users in the roles To. Message is a template (see love.ParseMessageTemplate)
+holding the fields of the event: Event, Repository, Number, Title, URL, Author,
+Actor, Reviewers and Assignees, along with Sender and Recipient, the love
+usernames of the sender and recipient.
+
+If From is not a role, it is the love username to send the love from, and if it
+is empty, the love is sent from the Handler's Sender.
+*/
+type Rule struct {
+	Event   string   `json:"event"`
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Message string   `json:"message"`
+}
+
+/*
+The rules used when a Handler has none: the author of a merged pull request
+thanks its reviewers and whoever merged it, and the author of a closed issue
+thanks whoever closed it.
+*/
+var DefaultRules = []Rule{
+	{PullRequestMerged, RoleAuthor, []string{RoleReviewers, RoleActor},
+		"Thanks for reviewing {{.Title}}! {{.URL}}"},
+	{IssueClosed, RoleAuthor, []string{RoleActor},
+		"Thanks for closing {{.Title}}! {{.URL}}"},
+}
+
+/*
+Read rules from a JSON array of rules, checking their events, roles and
+messages.
+*/
+func ParseRules(data []byte) ([]Rule, error) {
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	for i, rule := range rules {
+		if err := rule.check(); err != nil {
+			return nil, fmt.Errorf("rule %d: %s", i+1, err)
+		}
+	}
+	return rules, nil
+}
+
+func (r *Rule) check() error {
+	if r.Event != PullRequestMerged && r.Event != IssueClosed {
+		return fmt.Errorf("unknown event %q", r.Event)
+	}
+	if len(r.To) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+	for _, role := range r.To {
+		if !isRole(role) {
+			return fmt.Errorf("unknown role %q", role)
+		}
+	}
+	if _, err := love.ParseMessageTemplate(r.Message); err != nil {
+		return err
+	}
+	return nil
+}
+
+func isRole(role string) bool {
+	switch role {
+	case RoleAuthor, RoleActor, RoleReviewers, RoleAssignees:
+		return true
+	}
+	return false
+}
+
+/*
+A Handler serves GitHub webhook deliveries by sending love. It answers 200 with
+a line for each love sent, or 502 if any could not be sent, so that the
+delivery shows as failed and can be redelivered from GitHub. Deliveries of
+other events are answered 200 and ignored.
+
+Reviews are remembered from pull_request_review events until the pull request
+is closed, since the pull request payload only lists the reviewers who have
+yet to review. They are kept in memory, and so are lost when the server
+restarts.
+*/
+type Handler struct {
+	// Sends the love.
+	Service love.LoveService
+	// The secret of the webhook, used to verify deliveries.
+	Secret string
+	// The rules to apply, or DefaultRules if nil.
+	Rules []Rule
+	// Maps GitHub logins to love usernames.
+	Users ci.Users
+	// The sender of love for rules without From.
+	Sender string
+
+	mutex   sync.Mutex
+	reviews map[string][]string
+}
+
+// The parts of a webhook payload which are used.
+type payload struct {
+	Action      string       `json:"action"`
+	Sender      user         `json:"sender"`
+	Repository  repository   `json:"repository"`
+	PullRequest *pullRequest `json:"pull_request"`
+	Issue       *issue       `json:"issue"`
+	Review      *struct {
+		User user `json:"user"`
+	} `json:"review"`
+}
+
+type user struct {
+	Login string `json:"login"`
+}
+
+type repository struct {
+	FullName string `json:"full_name"`
+}
+
+type issue struct {
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	HTMLURL     string `json:"html_url"`
+	User        user   `json:"user"`
+	Assignees   []user `json:"assignees"`
+	StateReason string `json:"state_reason"`
+}
+
+type pullRequest struct {
+	issue
+	Merged             bool   `json:"merged"`
+	RequestedReviewers []user `json:"requested_reviewers"`
+}
+
+/*
+What happened, and to whom, as matched by rules.
+*/
+type event struct {
+	Name       string
+	Number     int
+	Title      string
+	URL        string
+	Author     string
+	Actor      string
+	Reviewers  []string
+	Assignees  []string
+	Repository string
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !webhook.Verify(h.Secret, body, r.Header.Get(SignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get(EventHeader) == "ping" {
+		fmt.Fprintln(w, "pong")
+		return
+	}
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	e := h.event(r.Header.Get(EventHeader), &p)
+	if e == nil {
+		fmt.Fprintln(w, "ignored")
+		return
+	}
+	lines, failed := h.apply(e)
+	if failed {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	if len(lines) == 0 {
+		lines = []string{"no love to send"}
+	}
+	fmt.Fprintln(w, strings.Join(lines, "\n"))
+}
+
+/*
+Return the event a delivery is for, or nil if no rule could match it.
+Reviews are recorded, and forgotten when their pull request closes.
+*/
+func (h *Handler) event(name string, p *payload) *event {
+	switch {
+	case name == "pull_request_review" && p.Action == "submitted" &&
+		p.PullRequest != nil && p.Review != nil:
+		h.review(p.Repository.FullName, p.PullRequest.Number, p.Review.User.Login)
+	case name == "pull_request" && p.Action == "closed" && p.PullRequest != nil:
+		pr := p.PullRequest
+		reviewers := h.forget(p.Repository.FullName, pr.Number)
+		if !pr.Merged {
+			return nil
+		}
+		e := newEvent(PullRequestMerged, p, &pr.issue)
+		e.Reviewers = append(reviewers, logins(pr.RequestedReviewers)...)
+		return e
+	case name == "issues" && p.Action == "closed" && p.Issue != nil:
+		if p.Issue.StateReason != "" && p.Issue.StateReason != "completed" {
+			return nil
+		}
+		return newEvent(IssueClosed, p, p.Issue)
+	}
+	return nil
+}
+
+func newEvent(name string, p *payload, i *issue) *event {
+	return &event{
+		Name:       name,
+		Number:     i.Number,
+		Title:      i.Title,
+		URL:        i.HTMLURL,
+		Author:     i.User.Login,
+		Actor:      p.Sender.Login,
+		Assignees:  logins(i.Assignees),
+		Repository: p.Repository.FullName,
+	}
+}
+
+func logins(users []user) []string {
+	var logins []string
+	for _, u := range users {
+		logins = append(logins, u.Login)
+	}
+	return logins
+}
+
+func reviewKey(repository string, number int) string {
+	return repository + "#" + strconv.Itoa(number)
+}
+
+func (h *Handler) review(repository string, number int, login string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.reviews == nil {
+		h.reviews = make(map[string][]string)
+	}
+	key := reviewKey(repository, number)
+	for _, reviewer := range h.reviews[key] {
+		if reviewer == login {
+			return
+		}
+	}
+	h.reviews[key] = append(h.reviews[key], login)
+}
+
+/*
+Forget the reviews of a pull request, returning the reviewers.
+*/
+func (h *Handler) forget(repository string, number int) []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	key := reviewKey(repository, number)
+	reviewers := h.reviews[key]
+	delete(h.reviews, key)
+	return reviewers
+}
+
+/*
+Send the love of each rule matching an event, returning a line describing the
+outcome of each love, and whether any failed.
+*/
+func (h *Handler) apply(e *event) ([]string, bool) {
+	rules := h.Rules
+	if rules == nil {
+		rules = DefaultRules
+	}
+	var lines []string
+	failed := false
+	for _, rule := range rules {
+		if rule.Event != e.Name {
+			continue
+		}
+		sender, err := h.sender(rule.From, e)
+		if err != nil {
+			lines = append(lines, err.Error())
+			continue
+		}
+		recipients := h.recipients(rule.To, e, sender)
+		tmpl, err := love.ParseMessageTemplate(rule.Message)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("invalid message: %s", err))
+			failed = true
+			continue
+		}
+		data := make(map[string]map[string]string, len(recipients))
+		for _, recipient := range recipients {
+			data[recipient] = e.fields()
+		}
+		messages, err := love.RenderMessages(tmpl, sender, recipients, data)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("invalid message: %s", err))
+			failed = true
+			continue
+		}
+		for _, recipient := range recipients {
+			if err := h.Service.SendLove(sender, recipient, messages[recipient]); err != nil {
+				lines = append(lines, fmt.Sprintf("%s -> %s: %s", sender, recipient, err))
+				failed = true
+			} else {
+				lines = append(lines, fmt.Sprintf("%s -> %s: sent", sender, recipient))
+			}
+		}
+	}
+	return lines, failed
+}
+
+/*
+Return the love username of the sender of a rule.
+*/
+func (h *Handler) sender(from string, e *event) (string, error) {
+	switch {
+	case from == "":
+		if h.Sender == "" {
+			return "", fmt.Errorf("no sender is configured")
+		}
+		return h.Sender, nil
+	case isRole(from):
+		handles := e.role(from)
+		if len(handles) != 1 {
+			return "", fmt.Errorf("the %s is not a single user", from)
+		}
+		username, ok := h.Users.Username(handles[0])
+		if !ok {
+			return "", fmt.Errorf("the %s %s has no love username", from, handles[0])
+		}
+		return username, nil
+	}
+	return from, nil
+}
+
+/*
+Return the distinct love usernames of the users in roles, other than sender,
+who may receive love.
+*/
+func (h *Handler) recipients(roles []string, e *event, sender string) []string {
+	seen := map[string]bool{sender: true}
+	var recipients []string
+	for _, role := range roles {
+		for _, handle := range e.role(role) {
+			username, ok := h.Users.Username(handle)
+			if ok && !seen[username] {
+				seen[username] = true
+				recipients = append(recipients, username)
+			}
+		}
+	}
+	return recipients
+}
+
+/*
+Return the GitHub logins of the users in a role.
+*/
+func (e *event) role(role string) []string {
+	switch role {
+	case RoleAuthor:
+		return []string{e.Author}
+	case RoleActor:
+		return []string{e.Actor}
+	case RoleReviewers:
+		return e.Reviewers
+	case RoleAssignees:
+		return e.Assignees
+	}
+	return nil
+}
+
+func (e *event) fields() map[string]string {
+	return map[string]string{
+		"Event":      e.Name,
+		"Repository": e.Repository,
+		"Number":     strconv.Itoa(e.Number),
+		"Title":      e.Title,
+		"URL":        e.URL,
+		"Author":     e.Author,
+		"Actor":      e.Actor,
+		"Reviewers":  strings.Join(e.Reviewers, ", "),
+		"Assignees":  strings.Join(e.Assignees, ", "),
+	}
+}
@@ -0,0 +1,167 @@
+package github
+
+import (
+	"github.com/hacsoc/golove/ci"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/hacsoc/golove/webhook"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testSecret = "It's a Secret to Everybody"
+
+const mergedPayload = `{
+	"action": "closed",
+	"sender": {"login": "darwin"},
+	"repository": {"full_name": "hacsoc/golove"},
+	"pull_request": {
+		"number": 7,
+		"title": "Add webhooks",
+		"html_url": "https://github.com/hacsoc/golove/pull/7",
+		"merged": true,
+		"user": {"login": "hammy-gh"},
+		"requested_reviewers": [{"login": "dependabot[bot]"}]
+	}
+}`
+
+const reviewPayload = `{
+	"action": "submitted",
+	"sender": {"login": "jeremy"},
+	"repository": {"full_name": "hacsoc/golove"},
+	"pull_request": {"number": 7, "user": {"login": "hammy-gh"}},
+	"review": {"user": {"login": "jeremy"}}
+}`
+
+func newTestHandler() (*Handler, *lovetest.Server) {
+	server := lovetest.NewServer("secret")
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	server.AddUser("jeremy", "Jeremy Jones")
+	handler := &Handler{
+		Service: server.Client(),
+		Secret:  testSecret,
+		Users:   ci.Users{"hammy-gh": "hammy"},
+	}
+	return handler, server
+}
+
+func deliver(handler *Handler, event, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/github", strings.NewReader(body))
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(SignatureHeader, webhook.Sign(testSecret, []byte(body)))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func recipients(loves []love.Love) []string {
+	var names []string
+	for _, l := range loves {
+		names = append(names, l.Sender+" -> "+l.Recipient)
+	}
+	return names
+}
+
+func TestMergedPullRequest(t *testing.T) {
+	handler, server := newTestHandler()
+	recorder := deliver(handler, "pull_request_review", reviewPayload)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, recorder.Body.String(), "ignored\n")
+
+	recorder = deliver(handler, "pull_request", mergedPayload)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, recorder.Body.String(), "hammy -> jeremy: sent\nhammy -> darwin: sent\n")
+	loves := server.Loves()
+	assert.ElementsMatch(t, recipients(loves), []string{"hammy -> jeremy", "hammy -> darwin"})
+	assert.Equal(t, loves[0].Message,
+		"Thanks for reviewing Add webhooks! https://github.com/hacsoc/golove/pull/7")
+
+	// The reviews are forgotten once the pull request is closed.
+	assert.Nil(t, handler.forget("hacsoc/golove", 7))
+}
+
+func TestClosedUnmergedPullRequest(t *testing.T) {
+	handler, server := newTestHandler()
+	deliver(handler, "pull_request_review", reviewPayload)
+	body := strings.Replace(mergedPayload, `"merged": true`, `"merged": false`, 1)
+	recorder := deliver(handler, "pull_request", body)
+	assert.Equal(t, recorder.Body.String(), "ignored\n")
+	assert.Len(t, server.Loves(), 0)
+	assert.Nil(t, handler.forget("hacsoc/golove", 7))
+}
+
+func TestClosedIssue(t *testing.T) {
+	handler, server := newTestHandler()
+	handler.Sender = "darwin"
+	handler.Rules = []Rule{{IssueClosed, "", []string{RoleAuthor, RoleAssignees},
+		"{{.Sender}} thanks {{.Recipient}} for #{{.Number}} in {{.Repository}}"}}
+	body := `{
+		"action": "closed",
+		"sender": {"login": "darwin"},
+		"repository": {"full_name": "hacsoc/golove"},
+		"issue": {"number": 3, "title": "Crash", "user": {"login": "hammy-gh"},
+			"assignees": [{"login": "jeremy"}], "state_reason": "completed"}
+	}`
+	recorder := deliver(handler, "issues", body)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	loves := server.Loves()
+	assert.ElementsMatch(t, recipients(loves), []string{"darwin -> hammy", "darwin -> jeremy"})
+	assert.Contains(t, []string{loves[0].Message, loves[1].Message},
+		"darwin thanks hammy for #3 in hacsoc/golove")
+
+	// Issues closed as not planned are ignored.
+	body = strings.Replace(body, "completed", "not_planned", 1)
+	recorder = deliver(handler, "issues", body)
+	assert.Equal(t, recorder.Body.String(), "ignored\n")
+}
+
+func TestSendFailure(t *testing.T) {
+	handler, server := newTestHandler()
+	handler.Users = nil
+	recorder := deliver(handler, "pull_request", mergedPayload)
+	assert.Equal(t, recorder.Code, http.StatusBadGateway)
+	assert.Contains(t, recorder.Body.String(), "hammy-gh -> darwin: ")
+	assert.Len(t, server.Loves(), 0)
+}
+
+func TestSignature(t *testing.T) {
+	handler, _ := newTestHandler()
+	req := httptest.NewRequest("POST", "/github", strings.NewReader(mergedPayload))
+	req.Header.Set(EventHeader, "pull_request")
+	req.Header.Set(SignatureHeader, webhook.Sign("wrong", []byte(mergedPayload)))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, recorder.Code, http.StatusUnauthorized)
+
+	recorder = deliver(handler, "ping", `{"zen": "Keep it logically awesome."}`)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, recorder.Body.String(), "pong\n")
+
+	req = httptest.NewRequest("GET", "/github", nil)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, recorder.Code, http.StatusMethodNotAllowed)
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(`[{"event": "pull_request.merged", "from": "author",
+		"to": ["reviewers"], "message": "thanks {{.Recipient}}"}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, rules, []Rule{
+		{PullRequestMerged, RoleAuthor, []string{RoleReviewers}, "thanks {{.Recipient}}"}})
+
+	for _, bad := range []string{
+		`{}`,
+		`[{"event": "push", "to": ["author"], "message": "thanks"}]`,
+		`[{"event": "issues.closed", "message": "thanks"}]`,
+		`[{"event": "issues.closed", "to": ["everyone"], "message": "thanks"}]`,
+		`[{"event": "issues.closed", "to": ["author"], "message": "{{"}]`,
+	} {
+		_, err := ParseRules([]byte(bad))
+		assert.NotNil(t, err, bad)
+	}
+}
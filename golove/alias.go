@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hacsoc/golove/alias"
+	"os"
+	"path/filepath"
+)
+
+var aliasCommand = &command{
+	Name:    "alias",
+	Args:    "list | check | resolve identity... | add identity username | remove identity",
+	Summary: "map Slack IDs, emails and other identities to usernames",
+	Long: `Manage the aliases file, which maps the identities people have in other
+services to their love usernames, so that every integration agrees on who is
+who. An identity is written as its kind and ID, such as slack:U012AB3CD,
+email:darwin.dog@example.com, github:octocat or nick:ham. See package alias.
+
+"list" prints every alias, and "check" prints those whose usernames do not
+exist, failing if there are any. "resolve" prints the username each identity
+maps to. "add" maps an identity to a username, replacing any alias it had, and
+"remove" removes an identity's alias.
+
+The file is the aliases setting (LOVE_ALIASES), or aliases.json beside the
+configuration file. It is used by "golove send" and "golove schedule", which
+accept identities as recipients, such as @slack:U012AB3CD, and by "golove ci",
+"golove serve" and "golove slack-bot", for users not in their -users files.`,
+	Run: runAlias,
+}
+
+func runAlias(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		return usagef("a subcommand is required")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	aliases, err := cfg.aliases()
+	if err != nil {
+		return err
+	}
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		for _, a := range aliases.Aliases() {
+			fmt.Printf("%s\t%s\n", a, a.Username)
+		}
+	case args[0] == "check" && len(args) == 1:
+		client, err := cfg.client()
+		if err != nil {
+			return err
+		}
+		unknown, err := aliases.Check(client)
+		if err != nil {
+			return err
+		}
+		for _, a := range unknown {
+			fmt.Printf("%s\t%s\n", a, a.Username)
+		}
+		if len(unknown) > 0 {
+			return fmt.Errorf("%d aliases are for users who do not exist", len(unknown))
+		}
+	case args[0] == "resolve" && len(args) > 1:
+		for _, identity := range args[1:] {
+			kind, id, ok := alias.Parse(identity)
+			if !ok {
+				return usagef("invalid identity %q: expected kind:id", identity)
+			}
+			username, ok := aliases.Lookup(kind, id)
+			if !ok {
+				return fmt.Errorf("no love username for %s:%s", kind, id)
+			}
+			fmt.Println(username)
+		}
+	case args[0] == "add" && len(args) == 3:
+		kind, id, ok := alias.Parse(args[1])
+		if !ok {
+			return usagef("invalid identity %q: expected kind:id", args[1])
+		}
+		if err := aliases.Add(kind, id, args[2]); err != nil {
+			return usagef("%s", err)
+		}
+		return writeAliases(cfg, aliases)
+	case args[0] == "remove" && len(args) == 2:
+		kind, id, ok := alias.Parse(args[1])
+		if !ok {
+			return usagef("invalid identity %q: expected kind:id", args[1])
+		}
+		if !aliases.Remove(kind, id) {
+			return fmt.Errorf("%s:%s has no alias", kind, id)
+		}
+		return writeAliases(cfg, aliases)
+	default:
+		return usagef("invalid arguments")
+	}
+	return nil
+}
+
+func writeAliases(cfg *config, aliases *alias.Map) error {
+	path := cfg.aliasesPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return aliases.Write(path)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/store"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var auditCommand = &command{
+	Name:    "audit",
+	Args:    "show [-sender user] [-since date] [-until date] [-failed] [-limit n] [-output format]",
+	Summary: "show the love this machine has tried to send",
+	Long: `Show the audit log, newest first: every attempt golove has made on this machine
+to send love, by any command, with the time, sender, recipients, message and
+company values, whether it was sent, and if not, why not. Since an API key can
+send love as anyone, the log records who love was really sent as.
+
+The log is audit.jsonl in the directory of the local database (see "golove
+help sync"), or the audit_log setting (LOVE_AUDIT_LOG). It is a file of JSON
+lines, which golove only ever appends to, so it may be kept on storage which
+forbids changing what has been written. Setting audit_log to "off" stops
+golove writing it. See love.AuditEntry for the fields of each line.`,
+	Flags: defineAuditFlags,
+	Run:   runAudit,
+}
+
+// The flags of golove audit.
+var auditFlags struct {
+	sender       string
+	since, until dateFlag
+	failed       bool
+	limit        int
+	output       string
+}
+
+func defineAuditFlags(flags *flag.FlagSet) {
+	flags.StringVar(&auditFlags.sender, "sender", "", "only show love sent as `user`")
+	auditFlags.since, auditFlags.until = dateFlag{}, dateFlag{}
+	flags.Var(&auditFlags.since, "since", "only show love sent on or after `date` (YYYY-MM-DD)")
+	flags.Var(&auditFlags.until, "until", "only show love sent before `date` (YYYY-MM-DD)")
+	flags.BoolVar(&auditFlags.failed, "failed", false, "only show love which was not sent")
+	flags.IntVar(&auditFlags.limit, "limit", 0, "show at most the last `n` attempts (default all)")
+	addOutputFlag(flags, &auditFlags.output)
+}
+
+func runAudit(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if helpRequested(args) {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	if len(args) == 0 || args[0] != "show" {
+		return usagef("expected \"show\"")
+	}
+	if err := parseFlags(flags, args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	if err := checkOutputFormat(auditFlags.output); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	path := cfg.auditPath()
+	if path == "" {
+		return errors.New("the audit log is off (audit_log is \"off\")")
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+	entries, err := love.ReadAudit(file)
+	if err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	var shown []love.AuditEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if auditFlags.sender != "" && e.Sender != auditFlags.sender ||
+			!auditFlags.since.IsZero() && e.Time.Before(auditFlags.since.Time) ||
+			!auditFlags.until.IsZero() && !e.Time.Before(auditFlags.until.Time) ||
+			auditFlags.failed && e.Succeeded() {
+			continue
+		}
+		if auditFlags.limit > 0 && len(shown) == auditFlags.limit {
+			break
+		}
+		shown = append(shown, e)
+	}
+	return page(func(w io.Writer) error {
+		return auditRecords(shown).write(w, auditFlags.output)
+	})
+}
+
+var auditColumns = []string{
+	"time", "sender", "recipients", "message", "values", "dry_run", "retries", "status", "error",
+	"already_sent",
+}
+
+func auditRecords(entries []love.AuditEntry) *records {
+	style := fileStyle(os.Stdout)
+	r := &records{
+		Columns: auditColumns,
+		Text: func(w io.Writer, i int) {
+			writeAuditEntry(w, style, entries[i])
+		},
+	}
+	for _, e := range entries {
+		r.Rows = append(r.Rows, []string{
+			e.Time.Local().Format("2006-01-02T15:04:05"),
+			e.Sender,
+			strings.Join(e.Recipients, ","),
+			e.Message,
+			strings.Join(e.Values, ","),
+			strconv.FormatBool(e.DryRun),
+			strconv.Itoa(e.Retries),
+			strconv.Itoa(e.Status),
+			e.Error,
+		})
+		r.Items = append(r.Items, e)
+	}
+	return r
+}
+
+/*
+Print an audit entry as a line saying what happened, followed by the message.
+*/
+func writeAuditEntry(w io.Writer, style textStyle, e love.AuditEntry) {
+	result := "sent"
+	switch {
+	case !e.Succeeded():
+		result = "failed: " + e.Error
+	case e.DryRun:
+		result = "dry run"
+	case e.AlreadySent:
+		result = "already sent"
+	}
+	if e.Retries > 0 {
+		result += fmt.Sprintf(", after %d retries", e.Retries)
+	}
+	fmt.Fprintf(w, "%s  %s to %s (%s)\n", e.Time.Local().Format("2006-01-02 15:04:05"),
+		e.Sender, strings.Join(e.Recipients, ", "), result)
+	fmt.Fprintf(w, "\t%s\n", style.markdown(e.Message))
+	if len(e.Values) > 0 {
+		fmt.Fprintf(w, "\tvalues: %s\n", strings.Join(e.Values, ", "))
+	}
+}
+
+/*
+An audit log file, which is opened to append each entry, so that several golove
+processes can share it, and it is not created until love is sent. Love is sent
+even if the log cannot be written, since it may already have been, but a
+warning is printed.
+*/
+type auditFile struct {
+	path string
+}
+
+func (a auditFile) Write(data []byte) (int, error) {
+	n, err := a.append(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "golove: could not write the audit log: %s\n", err)
+	}
+	return n, err
+}
+
+func (a auditFile) append(data []byte) (int, error) {
+	if err := os.MkdirAll(filepath.Dir(a.path), 0700); err != nil {
+		return 0, err
+	}
+	file, err := os.OpenFile(a.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, err
+	}
+	n, err := file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+/*
+Return the path of the audit log, or "" if it is off.
+*/
+func (c *config) auditPath() string {
+	switch c.AuditLog {
+	case "off":
+		return ""
+	case "":
+		return filepath.Join(filepath.Dir(store.DefaultPath()), "audit.jsonl")
+	}
+	return c.AuditLog
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"os"
+)
+
+var autocompleteCommand = &command{
+	Name:    "autocomplete",
+	Args:    "[-fresh] [-output format] term",
+	Summary: "look up usernames matching a term",
+	Long: `Print the username and full name of each user matching the term. Results are
+cached for an hour, unless -fresh is given.`,
+	Flags: defineAutocompleteFlags,
+	Run:   runAutocomplete,
+}
+
+// The flags of golove autocomplete.
+var autocompleteFlags struct {
+	fresh  bool
+	output string
+}
+
+func defineAutocompleteFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&autocompleteFlags.fresh, "fresh", false, "ignore cached results")
+	addOutputFlag(flags, &autocompleteFlags.output)
+}
+
+func runAutocomplete(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(autocompleteFlags.output); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("exactly one term is required")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	defer useAutocompleteCache(client)()
+	lookup := client.Autocomplete
+	if autocompleteFlags.fresh {
+		lookup = client.RefreshAutocomplete
+	}
+	users, err := lookup(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return userRecords(users).write(os.Stdout, autocompleteFlags.output)
+}
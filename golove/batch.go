@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var sendBatchCommand = &command{
+	Name:    "send-batch",
+	Args:    "[-concurrency n] [-dry-run] [-progress path] [-failures path] file.csv",
+	Summary: "send love for each row of a CSV file",
+	Long: `Send love for each row of a CSV file. The columns are the recipients (comma
+separated, so the field must be quoted if there are several), the message, and
+optionally the sender, which defaults to the configured sender. A first row
+starting with "recipient" is a header, and is skipped:
+
+	recipient,message,sender
+	darwin,Thanks for a great quarter!
+	"hammy,jeremy",Thanks for shipping the new site!,darwin
+
+Rows are sent concurrently, and a line is printed to stderr as each finishes.
+The row number of each love sent is appended to the progress file (file.csv
+with .progress appended by default), and rows already listed there are skipped,
+so a batch which was interrupted or partly failed may be run again without
+sending any love twice. Delete the progress file to start over.
+
+Rows which fail are written to the failures file, if one is given, in the same
+format with the error as a fourth column. Any fourth column is ignored, so the
+failures file may itself be sent as a batch once the problems are fixed.`,
+	Flags: defineSendBatchFlags,
+	Run:   runSendBatch,
+}
+
+// The flags of golove send-batch.
+var sendBatchFlags struct {
+	concurrency  int
+	dryRun       bool
+	progressPath string
+	failuresPath string
+}
+
+func defineSendBatchFlags(flags *flag.FlagSet) {
+	flags.IntVar(&sendBatchFlags.concurrency, "concurrency", love.DefaultConcurrency,
+		"send at most `n` love at once")
+	flags.BoolVar(&sendBatchFlags.dryRun, "dry-run", false,
+		"print the request for each row instead of sending love")
+	flags.StringVar(&sendBatchFlags.progressPath, "progress", "",
+		"record rows sent in `path` (default file.csv.progress)")
+	flags.StringVar(&sendBatchFlags.failuresPath, "failures", "", "write failed rows to CSV file `path`")
+}
+
+func runSendBatch(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("a CSV file is required")
+	}
+	if sendBatchFlags.concurrency <= 0 {
+		return usagef("the concurrency must be positive")
+	}
+	if sendBatchFlags.progressPath == "" {
+		sendBatchFlags.progressPath = flags.Arg(0) + ".progress"
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	rows, err := readBatch(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	for i := range rows {
+		if rows[i].Sender != "" {
+			continue
+		}
+		if rows[i].Sender, err = cfg.sender(); err != nil {
+			return err
+		}
+	}
+	if sendBatchFlags.dryRun {
+		// Requests are printed one at a time, and nothing is recorded.
+		client.DryRun = os.Stdout
+		for _, row := range rows {
+			if err := client.SendLove(row.Sender, row.Recipient, row.Message); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	done, err := readProgress(sendBatchFlags.progressPath)
+	if err != nil {
+		return err
+	}
+	var todo []*batchRow
+	for _, row := range rows {
+		if !done[row.Line] {
+			todo = append(todo, row)
+		}
+	}
+	if skipped := len(rows) - len(todo); skipped > 0 {
+		fmt.Fprintf(os.Stderr, "skipping %d rows already sent (see %s)\n", skipped,
+			sendBatchFlags.progressPath)
+	}
+	progress, err := os.OpenFile(sendBatchFlags.progressPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer progress.Close()
+
+	failed := sendBatch(client, todo, sendBatchFlags.concurrency, progress)
+	fmt.Fprintf(os.Stderr, "%d sent, %d failed\n", len(todo)-len(failed), len(failed))
+	if len(failed) == 0 {
+		return nil
+	}
+	if sendBatchFlags.failuresPath != "" {
+		if err := writeFailures(sendBatchFlags.failuresPath, failed); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%d rows could not be sent", len(failed))
+}
+
+/*
+A row of a batch file. Line is the line number of the row, which identifies it
+in the progress file.
+*/
+type batchRow struct {
+	Line      int
+	Recipient string
+	Message   string
+	Sender    string
+	Err       error
+}
+
+func readBatch(path string) ([]*batchRow, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	var rows []*batchRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(rows) == 0 && line == 1 &&
+			strings.EqualFold(strings.TrimSpace(record[0]), "recipient") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 4 {
+			return nil, fmt.Errorf("%s:%d: expected recipient, message and optional sender",
+				path, line)
+		}
+		row := &batchRow{
+			Line:      line,
+			Recipient: strings.TrimSpace(record[0]),
+			Message:   strings.TrimSpace(record[1]),
+		}
+		if len(record) >= 3 {
+			row.Sender = strings.TrimSpace(record[2])
+		}
+		if row.Recipient == "" || row.Message == "" {
+			return nil, fmt.Errorf("%s:%d: recipient and message are required", path, line)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+/*
+Read the line numbers recorded in a progress file. A missing file records none.
+*/
+func readProgress(path string) (map[int]bool, error) {
+	done := make(map[int]bool)
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid progress file", path)
+		}
+		done[line] = true
+	}
+	return done, scanner.Err()
+}
+
+/*
+Send the love for each row, recording the rows sent in progress, and return the
+rows which failed, in order.
+*/
+func sendBatch(client *love.Client, rows []*batchRow, concurrency int,
+	progress io.Writer) []*batchRow {
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	finished := 0
+	queue := make(chan *batchRow)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range queue {
+				row.Err = client.SendLove(row.Sender, row.Recipient, row.Message)
+				mutex.Lock()
+				finished++
+				status := "ok"
+				if row.Err != nil {
+					status = row.Err.Error()
+				} else {
+					fmt.Fprintln(progress, row.Line)
+				}
+				fmt.Fprintf(os.Stderr, "[%d/%d] line %d, %s: %s\n", finished,
+					len(rows), row.Line, row.Recipient, status)
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, row := range rows {
+		queue <- row
+	}
+	close(queue)
+	wg.Wait()
+	var failed []*batchRow
+	for _, row := range rows {
+		if row.Err != nil {
+			failed = append(failed, row)
+		}
+	}
+	return failed
+}
+
+func writeFailures(path string, rows []*batchRow) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"recipient", "message", "sender", "error"})
+	for _, row := range rows {
+		writer.Write([]string{row.Recipient, row.Message, row.Sender, row.Err.Error()})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/csv"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadBatch(t *testing.T) {
+	path := writeTestConfig(t, "batch.csv", "Recipient,message,sender\n"+
+		"darwin,Thanks for a great quarter!\n"+
+		"\"hammy, jeremy\",\"Thanks for\nshipping the new site!\", darwin \n"+
+		"darwin,Thanks again,hammy,unknown recipient\n")
+	rows, err := readBatch(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, rows, []*batchRow{
+			{Line: 2, Recipient: "darwin", Message: "Thanks for a great quarter!"},
+			{Line: 3, Recipient: "hammy, jeremy", Message: "Thanks for\nshipping the new site!",
+				Sender: "darwin"},
+			// The error column of a failures file is ignored.
+			{Line: 5, Recipient: "darwin", Message: "Thanks again", Sender: "hammy"},
+		})
+	}
+
+	// Only a first row is a header.
+	path = writeTestConfig(t, "batch.csv", "darwin,Thanks!\nrecipient,Thanks!\n")
+	rows, err = readBatch(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, rows, []*batchRow{
+			{Line: 1, Recipient: "darwin", Message: "Thanks!"},
+			{Line: 2, Recipient: "recipient", Message: "Thanks!"},
+		})
+	}
+
+	for contents, error := range map[string]string{
+		"darwin\n":                   ":1: expected recipient, message and optional sender",
+		"darwin,Thanks!,hammy,a,b\n": ":1: expected recipient, message and optional sender",
+		"darwin,Thanks!\ndarwin, \n": ":2: recipient and message are required",
+		" ,Thanks!\n":                ":1: recipient and message are required",
+	} {
+		path = writeTestConfig(t, "batch.csv", contents)
+		_, err = readBatch(path)
+		assert.EqualError(t, err, path+error, contents)
+	}
+}
+
+func TestReadProgress(t *testing.T) {
+	done, err := readProgress(filepath.Join(t.TempDir(), "missing.progress"))
+	assert.NoError(t, err)
+	assert.Empty(t, done)
+
+	path := writeTestConfig(t, "batch.csv.progress", "2\n5\n")
+	done, err = readProgress(path)
+	assert.NoError(t, err)
+	assert.Equal(t, done, map[int]bool{2: true, 5: true})
+
+	path = writeTestConfig(t, "batch.csv.progress", "2\nfive\n")
+	_, err = readProgress(path)
+	assert.EqualError(t, err, path+": invalid progress file")
+}
+
+func TestSendBatch(t *testing.T) {
+	server := newTestServer(t)
+	server.AddUser("jeremy", "Jeremy")
+	path := writeTestConfig(t, "batch.csv", "recipient,message,sender\n"+
+		"darwin,Thanks for a great quarter!\n"+
+		"casper,Thanks for the ghost stories!\n"+
+		"\"darwin,hammy\",Thanks for shipping the new site!,jeremy\n")
+	failures := filepath.Join(t.TempDir(), "failures.csv")
+
+	// casper is not a user yet, so the batch partly fails.
+	status, _, stderr := runGolove(t, "send-batch", "-concurrency", "1", "-failures", failures, path)
+	assert.Equal(t, status, exitError)
+	assert.Contains(t, stderr, "line 3, casper: ")
+	assert.Contains(t, stderr, "2 sent, 1 failed\n")
+	assert.Contains(t, stderr, "1 rows could not be sent")
+	assert.Len(t, server.Loves(), 3)
+	progress, err := ioutil.ReadFile(path + ".progress")
+	assert.NoError(t, err)
+	assert.Equal(t, string(progress), "2\n4\n")
+
+	file, err := os.Open(failures)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if assert.NoError(t, err) && assert.Len(t, records, 2) {
+		assert.Equal(t, records[0], []string{"recipient", "message", "sender", "error"})
+		assert.Equal(t, records[1][:3], []string{"casper", "Thanks for the ghost stories!", "hammy"})
+		assert.NotEmpty(t, records[1][3])
+	}
+
+	// Running it again only sends the row which failed.
+	server.AddUser("casper", "Casper")
+	status, _, stderr = runGolove(t, "send-batch", path)
+	assert.Equal(t, status, exitOK, stderr)
+	assert.Contains(t, stderr, "skipping 2 rows already sent (see "+path+".progress)\n")
+	assert.Contains(t, stderr, "[1/1] line 3, casper: ok\n")
+	assert.Contains(t, stderr, "1 sent, 0 failed\n")
+	loves := server.Loves()
+	if assert.Len(t, loves, 4) {
+		assert.Equal(t, loves[3].Sender, "hammy")
+		assert.Equal(t, loves[3].Recipient, "casper")
+	}
+	progress, err = ioutil.ReadFile(path + ".progress")
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Fields(string(progress)), []string{"2", "4", "3"})
+
+	// The failures file may be sent as a batch itself.
+	server.Reset()
+	status, _, stderr = runGolove(t, "send-batch", failures)
+	assert.Equal(t, status, exitOK, stderr)
+	loves = server.Loves()
+	if assert.Len(t, loves, 1) {
+		assert.Equal(t, loves[0].Recipient, "casper")
+		assert.Equal(t, loves[0].Message, "Thanks for the ghost stories!")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"io"
+	"os"
+)
+
+var betweenCommand = &command{
+	Name:    "between",
+	Args:    "[-since date] [-until date] [-names] [-absolute] [-output format] user [user]",
+	Summary: "list the love two users sent each other",
+	Long: `List every love two users sent each other, oldest first, followed in the text
+format by how many each sent the other. For example:
+
+	golove between hammy darwin
+
+Given one user, the love between them and the configured sender is listed.
+-names and -absolute show love as "golove get" does.`,
+	Flags: defineBetweenFlags,
+	Run:   runBetween,
+}
+
+// The flags of golove between.
+var betweenFlags struct {
+	since, until dateFlag
+	names        bool
+	absolute     bool
+	output       string
+}
+
+func defineBetweenFlags(flags *flag.FlagSet) {
+	betweenFlags.since, betweenFlags.until = dateFlag{}, dateFlag{}
+	flags.Var(&betweenFlags.since, "since", "only list love sent on or after `date` (YYYY-MM-DD)")
+	flags.Var(&betweenFlags.until, "until", "only list love sent before `date` (YYYY-MM-DD)")
+	flags.BoolVar(&betweenFlags.names, "names", false,
+		"show the full names of users, if the instance has profiles")
+	flags.BoolVar(&betweenFlags.absolute, "absolute", false,
+		"show the date and time love was sent, rather than how long ago")
+	addOutputFlag(flags, &betweenFlags.output)
+}
+
+func runBetween(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(betweenFlags.output); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return usagef("a user is required")
+	}
+	if flags.NArg() > 2 {
+		return usagef("unexpected argument %q", flags.Arg(2))
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	user, other := flags.Arg(0), flags.Arg(1)
+	if other == "" {
+		if user, err = cfg.sender(); err != nil {
+			return err
+		}
+		other = flags.Arg(0)
+	}
+	if user == other {
+		return usagef("the users must be different")
+	}
+	directed, err := client.GetLoveBetween(user, other,
+		love.LoveFilter{Since: betweenFlags.since.Time, Until: betweenFlags.until.Time})
+	if err != nil {
+		return err
+	}
+	loves := make([]love.Love, len(directed))
+	sent := 0
+	for i, l := range directed {
+		// Oldest first, so the history reads as a conversation.
+		loves[len(loves)-1-i] = l.Love
+		if l.Direction == love.Sent {
+			sent++
+		}
+	}
+
+	records := loveRecords(loves)
+	var profiles map[string]*love.Profile
+	if betweenFlags.names {
+		profiles = lookupProfiles(client, []string{user, other})
+	}
+	text := loveText{Profiles: profiles, Relative: !betweenFlags.absolute, Style: fileStyle(os.Stdout)}
+	records.Text = func(w io.Writer, i int) {
+		text.write(w, loves[i])
+	}
+	return page(func(w io.Writer) error {
+		if err := records.write(w, betweenFlags.output); err != nil {
+			return err
+		}
+		if betweenFlags.output != "text" {
+			return nil
+		}
+		if len(loves) > 0 {
+			fmt.Fprintln(w)
+		}
+		width := len(user) + len(other) + 4
+		fmt.Fprintf(w, "%-*s %4d\n", width, user+" -> "+other+":", sent)
+		fmt.Fprintf(w, "%-*s %4d\n", width, other+" -> "+user+":", len(loves)-sent)
+		fmt.Fprintf(w, "%-*s %4d\n", width, "Total:", len(loves))
+		return nil
+	})
+}
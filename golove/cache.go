@@ -0,0 +1,38 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// How long autocomplete results are cached between runs of golove.
+const autocompleteTTL = time.Hour
+
+/*
+Attach the on-disk autocomplete cache to a client. Each instance has its own
+cache, named after a hash of its base URL. The returned function saves the
+cache, and should be deferred. Failing to use the cache is not an error; the
+client simply makes every request.
+*/
+func useAutocompleteCache(client *love.Client) func() {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return func() {}
+	}
+	hash := sha256.Sum256([]byte(client.BaseUrl))
+	name := fmt.Sprintf("autocomplete-%x.json", hash[:6])
+	path := filepath.Join(dir, "golove", name)
+	cache, err := love.LoadAutocompleteCache(path, autocompleteTTL)
+	if err != nil {
+		cache = love.NewAutocompleteCache(autocompleteTTL)
+		cache.Path = path
+	}
+	client.AutocompleteCache = cache
+	return func() {
+		cache.Save()
+	}
+}
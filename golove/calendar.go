@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/schedule"
+	"github.com/hacsoc/golove/store"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// The messages sent for each kind of occasion, unless -template is given.
+var defaultOccasionTemplates = map[string]string{
+	"birthday":    "Happy birthday, {{.Recipient}}!",
+	"anniversary": "Happy {{if .Years}}{{.Years}}-year {{end}}work anniversary, {{.Recipient}}! Thanks for all you do.",
+}
+
+var calendarCommand = &command{
+	Name:    "calendar",
+	Args:    "[-db path] [-days n] [-hour h] [-template kind=template]... [-holidays file] [-weekends] [-on-holiday rule] [-dry-run] file",
+	Summary: "schedule love for birthdays and anniversaries",
+	Long: `Schedule love from the configured sender for the birthdays, work anniversaries
+and other yearly occasions in a file, which fall in the next -days days, to be
+sent by "golove scheduler run" at -hour o'clock on the day. Running it every
+day or week from cron keeps the schedule filled. Love is not scheduled again
+for an occasion which already has love scheduled to the same person that day.
+
+The file is CSV, with a row for each occasion: the username, the kind of
+occasion, such as birthday, the date as 2006-01-02 or 05-17, and optionally
+the person's name. Files ending in .ics are read as iCalendar instead. See
+schedule.ReadOccasionsCSV and schedule.ReadOccasionsICal.
+
+The message for each kind of occasion is a template (see "golove send
+-template"), given with -template kind=template, which may be repeated. It
+holds the .Kind, .Name and .Date of the occasion, and .Years, the number of
+years since the first occasion, if the file has its year. There are default
+templates for birthdays and anniversaries.
+
+The -holidays file lists days off, as 2006-01-02 or as 12-25 for every year,
+one per line, and with -weekends, Saturdays and Sundays are days off too. The
+-on-holiday rule says what happens to love for an occasion on a day off: it is
+sent anyway (send), not sent (skip), or sent on the working day before (before)
+or after (after).
+
+With -dry-run, the love is printed instead of being scheduled.`,
+	Flags: defineCalendarFlags,
+	Run:   runCalendar,
+}
+
+// The flags of golove calendar.
+var calendarFlags struct {
+	path         string
+	days         int
+	hour         int
+	templates    listFlag
+	holidaysPath string
+	weekends     bool
+	onHoliday    string
+	dryRun       bool
+}
+
+func defineCalendarFlags(flags *flag.FlagSet) {
+	flags.StringVar(&calendarFlags.path, "db", store.DefaultPath(), "the local database `path`")
+	flags.IntVar(&calendarFlags.days, "days", 30, "schedule love for occasions in the next `n` days")
+	flags.IntVar(&calendarFlags.hour, "hour", schedule.DefaultHour, "send love at `h` o'clock")
+	calendarFlags.templates = nil
+	flags.Var(&calendarFlags.templates, "template",
+		"send `kind=template` for occasions of a kind (may be repeated)")
+	flags.StringVar(&calendarFlags.holidaysPath, "holidays", "", "read days off from `file`")
+	flags.BoolVar(&calendarFlags.weekends, "weekends", false, "treat Saturdays and Sundays as days off")
+	flags.StringVar(&calendarFlags.onHoliday, "on-holiday", "send",
+		"what to do on days off: send, skip, before or after (`rule`)")
+	flags.BoolVar(&calendarFlags.dryRun, "dry-run", false, "print the love instead of scheduling it")
+}
+
+func runCalendar(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("a file of occasions is required")
+	}
+	if calendarFlags.days < 1 {
+		return usagef("-days must be positive")
+	}
+	if calendarFlags.hour < 0 || calendarFlags.hour > 23 {
+		return usagef("-hour must be between 0 and 23")
+	}
+	rule, err := schedule.ParseHolidayRule(calendarFlags.onHoliday)
+	if err != nil {
+		return usagef("%s", err)
+	}
+	messages, err := occasionTemplates(calendarFlags.templates)
+	if err != nil {
+		return err
+	}
+	occasions, err := readOccasions(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	var missing []string
+	for _, o := range occasions {
+		if messages[o.Kind] == nil && !seen[o.Kind] {
+			seen[o.Kind] = true
+			missing = append(missing, o.Kind)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return usagef("no -template for occasions of kind %s", strings.Join(missing, ", "))
+	}
+	planner := &schedule.Planner{Hour: calendarFlags.hour, Rule: rule}
+	if calendarFlags.holidaysPath != "" {
+		file, err := os.Open(calendarFlags.holidaysPath)
+		if err != nil {
+			return err
+		}
+		planner.Holidays, err = schedule.ReadHolidays(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %s", calendarFlags.holidaysPath, err)
+		}
+	}
+	if calendarFlags.weekends {
+		if planner.Holidays == nil {
+			planner.Holidays = &schedule.Holidays{}
+		}
+		planner.Holidays.Weekends = true
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	sender, err := cfg.sender()
+	if err != nil {
+		return err
+	}
+	db, err := store.Open(calendarFlags.path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	now := time.Now()
+	for _, date := range planner.Upcoming(occasions, now, now.AddDate(0, 0, calendarFlags.days)) {
+		if date.Username == sender {
+			continue
+		}
+		rendered, err := love.RenderMessages(messages[date.Kind], sender,
+			[]string{date.Username}, map[string]map[string]string{date.Username: date.Fields()})
+		if err != nil {
+			return fmt.Errorf("%s template: %s", date.Kind, err)
+		}
+		message := rendered[date.Username]
+		when := date.At.Format(scheduleLayout)
+		if calendarFlags.dryRun {
+			fmt.Printf("%s  %s -> %s: %s\n", when, sender, date.Username, message)
+			continue
+		}
+		job := store.Job{
+			Pending: store.Pending{Sender: sender, Recipient: date.Username, Message: message},
+			At:      date.At,
+		}
+		conflicts, err := db.Conflicts(job)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			fmt.Printf("Love to %s for their %s on %s is already scheduled\n",
+				date.Username, date.Kind, when)
+			continue
+		}
+		scheduled, err := db.Schedule(sender, date.Username, message, date.At)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Love to %s for their %s scheduled for %s (ID %d)\n", date.Username,
+			date.Kind, when, scheduled.ID)
+	}
+	return nil
+}
+
+/*
+Parse the message templates for each kind of occasion: the defaults, replaced
+by those given as kind=template.
+*/
+func occasionTemplates(flags []string) (map[string]*template.Template, error) {
+	texts := make(map[string]string)
+	for kind, text := range defaultOccasionTemplates {
+		texts[kind] = text
+	}
+	for _, flag := range flags {
+		parts := strings.SplitN(flag, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, usagef("invalid -template %q: expected kind=template", flag)
+		}
+		texts[strings.ToLower(strings.TrimSpace(parts[0]))] = parts[1]
+	}
+	templates := make(map[string]*template.Template)
+	for kind, text := range texts {
+		tmpl, err := love.ParseMessageTemplate(text)
+		if err != nil {
+			return nil, usagef("invalid %s template: %s", kind, err)
+		}
+		templates[kind] = tmpl
+	}
+	return templates, nil
+}
+
+/*
+Read the occasions in a CSV or, if its name ends in .ics, iCalendar file.
+*/
+func readOccasions(path string) ([]schedule.Occasion, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var occasions []schedule.Occasion
+	if strings.EqualFold(filepath.Ext(path), ".ics") {
+		occasions, err = schedule.ReadOccasionsICal(file)
+	} else {
+		occasions, err = schedule.ReadOccasionsCSV(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return occasions, nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/ci"
+	"github.com/hacsoc/golove/love"
+	"os"
+	"strings"
+)
+
+// The messages sent by "golove ci", unless -message is given.
+const (
+	defaultMergedMessage = "Thanks for reviewing {{.Title}}! {{.URL}}"
+	defaultFixedMessage  = "Thanks for fixing the build of {{.Repository}}! {{.BuildURL}}"
+)
+
+var ciCommand = &command{
+	Name:    "ci",
+	Args:    "[-users file] [-from user] [-reviewer handle] [-message template] [-dry-run] merged|fixed",
+	Summary: "thank the people behind a merged pull request or fixed build",
+	Long: `Send thank-you love from a CI job, on GitHub Actions or GitLab CI, which is
+read from the environment of the job (see the ci package).
+
+"golove ci merged" is run when a pull request is merged, such as in a GitHub
+workflow triggered when pull requests are closed. The author of the pull
+request sends love to each reviewer, and to whoever merged it. On GitHub,
+nothing is sent if the pull request was closed without being merged. GitHub
+only lists the reviewers who have yet to review, so more may be given with
+-reviewer. On GitLab, the reviewers are the assignees of the merge request,
+and since GitLab cannot tell whether it was merged, the job should only run for
+merges, such as in a merge train.
+
+"golove ci fixed" is run when a build passes after failing, which the job must
+work out, such as from the status of the previous run. The configured sender
+sends love to the user who started the build.
+
+With -from, the love is sent by the given user instead. The message is a
+template (see "golove send -template") holding the fields of ci.Event, such as
+.Title, .URL, .Author, .Repository and .BuildURL, along with .Sender and
+.Recipient. With -dry-run, the requests are printed instead of made.
+
+Handles on GitHub or GitLab are mapped to love usernames by the -users file, or
+the ci_users setting (LOVE_CI_USERS), which holds a JSON object such as:
+
+	{"octocat": "hammy", "some-bot": ""}
+
+Aliases of the kind github or gitlab (see "golove alias") are used for handles
+which are not in the file. Other handles are used as usernames, and handles
+mapped to an empty username, or belonging to bots, are sent no love.`,
+	Flags: defineCIFlags,
+	Run:   runCI,
+}
+
+// The flags of golove ci.
+var ciFlags struct {
+	usersPath string
+	from      string
+	reviewers listFlag
+	message   string
+	dryRun    bool
+}
+
+func defineCIFlags(flags *flag.FlagSet) {
+	flags.StringVar(&ciFlags.usersPath, "users", "",
+		"map handles to love usernames with the JSON `file` (default ci_users)")
+	flags.StringVar(&ciFlags.from, "from", "", "send love from `user`")
+	ciFlags.reviewers = nil
+	flags.Var(&ciFlags.reviewers, "reviewer", "also thank the reviewer with `handle` (may be repeated)")
+	flags.StringVar(&ciFlags.message, "message", "", "the message `template`")
+	flags.BoolVar(&ciFlags.dryRun, "dry-run", false, "print the requests instead of sending love")
+}
+
+func runCI(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("expected merged or fixed")
+	}
+	kind := flags.Arg(0)
+	if kind != "merged" && kind != "fixed" {
+		return usagef("unknown event %q", kind)
+	}
+	if ciFlags.message == "" {
+		ciFlags.message = defaultMergedMessage
+		if kind == "fixed" {
+			ciFlags.message = defaultFixedMessage
+		}
+	}
+	tmpl, err := love.ParseMessageTemplate(ciFlags.message)
+	if err != nil {
+		return usagef("invalid template: %s", err)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if ciFlags.usersPath == "" {
+		ciFlags.usersPath = cfg.CIUsers
+	}
+	event, err := ci.Detect()
+	if err != nil {
+		return err
+	}
+	users, err := ciUsers(cfg, ciFlags.usersPath, event.Provider)
+	if err != nil {
+		return err
+	}
+
+	sender := ciFlags.from
+	var handles []string
+	if kind == "merged" {
+		if event.PullRequest == 0 {
+			return errors.New("the job is not running for a pull request")
+		}
+		if event.Provider == ci.GitHub && !event.Merged {
+			fmt.Printf("Pull request #%d was not merged; no love sent\n", event.PullRequest)
+			return nil
+		}
+		if sender == "" {
+			author, ok := users.Username(event.Author)
+			if !ok {
+				return fmt.Errorf("the author %s has no love username", event.Author)
+			}
+			sender = author
+		}
+		handles = append(handles, event.Reviewers...)
+		handles = append(handles, ciFlags.reviewers...)
+		handles = append(handles, event.MergedBy)
+	} else {
+		if sender == "" {
+			if sender, err = cfg.sender(); err != nil {
+				return err
+			}
+		}
+		handles = []string{event.Actor}
+	}
+	recipients := ciRecipients(users, handles, sender)
+	if len(recipients) == 0 {
+		fmt.Println("No one to send love to")
+		return nil
+	}
+
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	if ciFlags.dryRun {
+		client.DryRun = os.Stdout
+	}
+	data := make(map[string]map[string]string)
+	for _, r := range recipients {
+		data[r] = event.Fields()
+	}
+	results, err := client.SendLoveTemplate(sender, recipients, tmpl, data, 0)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, r := range recipients {
+		switch err := results[r]; {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "golove ci: %s: %s\n", r, err)
+			failed++
+		case ciFlags.dryRun:
+			fmt.Printf("Love not sent to %s (dry run)\n", r)
+		default:
+			fmt.Printf("Love sent to %s!\n", r)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("love could not be sent to %d users", failed)
+	}
+	return nil
+}
+
+/*
+Read the file mapping the handles of a CI service to love usernames, and add
+the aliases of that kind for handles it does not map.
+*/
+func ciUsers(cfg *config, path, kind string) (ci.Users, error) {
+	users, err := ci.ReadUsers(path)
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := cfg.aliases()
+	if err != nil {
+		return nil, err
+	}
+	if users == nil {
+		users = make(ci.Users)
+	}
+	for handle, username := range aliases.Kind(kind) {
+		if _, ok := users[strings.ToLower(handle)]; !ok {
+			users[strings.ToLower(handle)] = username
+		}
+	}
+	return users, nil
+}
+
+/*
+Map handles to the distinct love usernames which may receive love from sender,
+in order.
+*/
+func ciRecipients(users ci.Users, handles []string, sender string) []string {
+	seen := map[string]bool{sender: true}
+	var recipients []string
+	for _, handle := range handles {
+		username, ok := users.Username(handle)
+		if ok && !seen[username] {
+			seen[username] = true
+			recipients = append(recipients, username)
+		}
+	}
+	return recipients
+}
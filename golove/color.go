@@ -0,0 +1,207 @@
+package main
+
+import (
+	"github.com/hacsoc/golove/love"
+	"golang.org/x/term"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Set by the -no-color flag.
+var noColor bool
+
+/*
+How text output is shown. Output to a terminal is colored, unless NO_COLOR is
+set, the terminal is dumb, or -no-color is given; messages are wrapped to the
+width of the terminal, and emoji shortcodes and markdown are rendered. Output to
+a file or pipe is left plain, so that scripts see the text as it was sent.
+*/
+type textStyle struct {
+	Color    bool
+	Emoji    bool
+	Markdown bool
+	// The width to wrap messages to, or 0 to not wrap them.
+	Width int
+}
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiDim       = "\x1b[2m"
+	ansiCyan      = "\x1b[36m"
+	ansiMagenta   = "\x1b[35m"
+	ansiYellow    = "\x1b[33m"
+	ansiBoldRed   = "\x1b[1;31m"
+)
+
+/*
+Return the style of text written to a file.
+*/
+func fileStyle(f *os.File) textStyle {
+	fd := int(f.Fd())
+	if !term.IsTerminal(fd) {
+		return textStyle{}
+	}
+	style := textStyle{
+		Color:    !noColor && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb",
+		Emoji:    true,
+		Markdown: true,
+	}
+	if width, _, err := term.GetSize(fd); err == nil {
+		style.Width = width
+	}
+	return style
+}
+
+/*
+Wrap text in an ANSI color code, if the style is colored.
+*/
+func (s textStyle) paint(code, text string) string {
+	if !s.Color || text == "" {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+/*
+Render the markdown in a message, if the style renders it: in bold, italic and
+underlined links followed by their URLs, if the style is colored, or else as
+plain text (see love.MarkdownText).
+*/
+func (s textStyle) markdown(message string) string {
+	if !s.Markdown {
+		return message
+	} else if !s.Color {
+		return love.MarkdownText(message)
+	}
+	var out strings.Builder
+	spans := love.ParseMarkdown(message)
+	for i, span := range spans {
+		text := span.Text
+		if span.Bold {
+			text = ansiBold + text
+		}
+		if span.Italic {
+			text = ansiItalic + text
+		}
+		if span.Link != "" {
+			text = ansiUnderline + text
+		}
+		if text != span.Text {
+			text += ansiReset
+		}
+		out.WriteString(text)
+		if span.Link != "" && (i+1 == len(spans) || spans[i+1].Link != span.Link) {
+			out.WriteString(" " + s.paint(ansiDim, "("+span.Link+")"))
+		}
+	}
+	return out.String()
+}
+
+/*
+Wrap a message to the style's width, given that the first line starts at
+column start. Continuation lines are indented by indent spaces. Lines in the
+message are kept, and words longer than a line are not broken.
+*/
+func (s textStyle) wrap(message string, start, indent int) string {
+	if s.Width <= 0 {
+		return message
+	}
+	var out strings.Builder
+	column := start
+	for i, line := range strings.Split(message, "\n") {
+		if i > 0 {
+			out.WriteString("\n" + strings.Repeat(" ", indent))
+			column = indent
+		}
+		lineStart := column
+		for _, word := range strings.Fields(line) {
+			width := displayWidth(word)
+			if column > lineStart {
+				if column+1+width > s.Width {
+					out.WriteString("\n" + strings.Repeat(" ", indent))
+					column = indent
+					lineStart = indent
+				} else {
+					out.WriteByte(' ')
+					column++
+				}
+			}
+			out.WriteString(word)
+			column += width
+		}
+	}
+	return out.String()
+}
+
+/*
+Return the number of columns a string takes up on a terminal: emoji and other
+wide characters take two, and combining marks, variation selectors and ANSI
+color codes none.
+*/
+func displayWidth(s string) int {
+	width := 0
+	escape := false
+	for _, r := range s {
+		switch {
+		case escape:
+			escape = r != 'm'
+		case r == '\x1b':
+			escape = true
+		case r == '\u200d' || r >= '\ufe00' && r <= '\ufe0f' ||
+			unicode.Is(unicode.Mn, r) || !unicode.IsPrint(r):
+			// Takes no space of its own.
+		case isWide(r):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+func isWide(r rune) bool {
+	return r >= 0x1100 && r <= 0x115f || // Hangul Jamo
+		r >= 0x2e80 && r <= 0xa4cf || // CJK
+		r >= 0xac00 && r <= 0xd7a3 || // Hangul syllables
+		r >= 0xf900 && r <= 0xfaff ||
+		r >= 0xff00 && r <= 0xff60 || // Fullwidth forms
+		r >= 0x1f300 && r <= 0x1faff || // Emoji
+		r == '☕' || r == '⭐' || r == '✅' || r == '✨' || r == '❤' ||
+		r >= 0x20000 && r <= 0x3fffd
+}
+
+/*
+Cut a string down to at most width columns, keeping any ANSI color codes, and
+resetting the color at the end if there are any.
+*/
+func truncate(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	var out strings.Builder
+	used := 0
+	escape := false
+	for _, r := range s {
+		switch {
+		case escape:
+			escape = r != 'm'
+		case r == '\x1b':
+			escape = true
+		default:
+			w := displayWidth(string(r))
+			if used+w > width {
+				if strings.ContainsRune(s, '\x1b') {
+					out.WriteString(ansiReset)
+				}
+				return out.String()
+			}
+			used += w
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
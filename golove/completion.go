@@ -0,0 +1,367 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hacsoc/golove/store"
+	"sort"
+	"strings"
+)
+
+var completionCommand = &command{
+	Name:    "completion",
+	Args:    "bash | zsh | fish",
+	Summary: "print a shell completion script",
+	Long: `Print a script which completes golove commands, flags and usernames in the given
+shell. To enable completion, add one of the following to the shell's startup
+file:
+
+	source <(golove completion bash)           # ~/.bashrc
+	source <(golove completion zsh)            # ~/.zshrc
+	golove completion fish | source            # ~/.config/fish/config.fish
+
+The scripts call golove to find the completions of each word, so recipients are
+completed with the usernames of the configured love instance, through the
+autocomplete cache. The people the sender has sent love to most often and most
+recently with "golove send" are suggested first, and completing an empty
+recipient suggests only them.`,
+	Run: runCompletion,
+}
+
+func runCompletion(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("exactly one shell is required")
+	}
+	script, ok := completionScripts[flags.Arg(0)]
+	if !ok {
+		return usagef("unsupported shell %q", flags.Arg(0))
+	}
+	fmt.Print(script)
+	return nil
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for golove
+_golove() {
+	local IFS=$'\n'
+	COMPREPLY=($(golove __complete "${COMP_WORDS[@]:1:$COMP_CWORD}" 2>/dev/null))
+}
+# Keep the order of the completions, which puts frequent recipients first, in
+# versions of bash which support it.
+complete -o default -o nosort -F _golove golove 2>/dev/null ||
+	complete -o default -F _golove golove
+`,
+	"zsh": `#compdef golove
+# zsh completion for golove
+_golove() {
+	local -a candidates
+	candidates=(${(f)"$(golove __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+	if (( ${#candidates} )); then
+		compadd -V golove -Q -- $candidates
+	else
+		_files
+	fi
+}
+if [ "$funcstack[1]" = "_golove" ]; then
+	_golove "$@"
+else
+	compdef _golove golove
+fi
+`,
+	"fish": `# fish completion for golove
+function __golove_complete
+	golove __complete (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null
+end
+complete -c golove -f -k -a '(__golove_complete)'
+complete -c golove -n '__fish_seen_subcommand_from send-batch import' -F
+`,
+}
+
+/*
+The hidden command run by the completion scripts. Its arguments are the words
+following "golove" on the command line, the last of which is being completed
+(and may be empty). It prints the possible completions of that word, one per
+line.
+*/
+var completeCommand = &command{
+	Name:   "__complete",
+	Args:   "word...",
+	Hidden: true,
+	Run:    runComplete,
+}
+
+func runComplete(cmd *command, args []string) error {
+	if len(args) == 0 {
+		return usagef("a word is required")
+	}
+	for _, candidate := range complete(args[:len(args)-1], args[len(args)-1]) {
+		fmt.Println(candidate)
+	}
+	return nil
+}
+
+/*
+Return the completions of word, which follows the given words on the command
+line.
+*/
+func complete(words []string, word string) []string {
+	// Skip the global flags.
+	for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		if strings.HasPrefix(word, "-") {
+			return withPrefix([]string{"-debug", "-verbose", "-no-color", "-no-pager"}, word)
+		}
+		names := withPrefix(commandNames(), word)
+		if len(names) == 0 && word != "" {
+			// golove recipient message
+			return completeRecipients(word)
+		}
+		return names
+	}
+	cmd := findCommand(words[0])
+	if cmd == nil || cmd.Hidden {
+		return nil
+	}
+	flags := commandFlags(cmd)
+	words = words[1:]
+	if n := len(words); n > 0 && flags[words[n-1]] && !strings.Contains(words[n-1], "=") {
+		return completeFlagValue(cmd, words[n-1], word)
+	}
+	if strings.HasPrefix(word, "-") {
+		var names []string
+		for name := range flags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return withPrefix(names, word)
+	}
+	var positional []string
+	for i := 0; i < len(words); i++ {
+		if flags[words[i]] {
+			i++
+		} else if !strings.HasPrefix(words[i], "-") {
+			positional = append(positional, words[i])
+		}
+	}
+	return completeArgument(cmd, positional, word)
+}
+
+/*
+Return the flags of a command, parsed from its usage, and whether each takes a
+value. In "[-user user] [-sent]", -user takes a value and -sent does not.
+*/
+func commandFlags(cmd *command) map[string]bool {
+	flags := make(map[string]bool)
+	fields := strings.Fields(cmd.Args)
+	for i, field := range fields {
+		name := strings.TrimLeft(field, "[")
+		if !strings.HasPrefix(name, "-") {
+			continue
+		}
+		name = strings.TrimRight(name, "]")
+		flags[name] = !strings.HasSuffix(field, "]") && i+1 < len(fields) &&
+			fields[i+1] != "|" && !strings.HasPrefix(strings.TrimLeft(fields[i+1], "["), "-")
+	}
+	if flags["-output"] {
+		// The shorthand is not shown in the usage.
+		flags["-o"] = true
+	}
+	return flags
+}
+
+func completeFlagValue(cmd *command, flag, word string) []string {
+	switch flag {
+	case "-user", "-from", "-to", "-teammate", "-sender":
+		return completeUsers(word)
+	case "-output", "-o":
+		return withPrefix(outputFormats, word)
+	case "-format":
+		if cmd == graphCommand {
+			return withPrefix([]string{"dot", "graphml"}, word)
+		}
+		if cmd == reportCommand {
+			return withPrefix([]string{"html", "markdown"}, word)
+		}
+		return withPrefix([]string{"csv", "json", "jsonl"}, word)
+	}
+	return nil
+}
+
+/*
+Complete a positional argument of a command, given the positional arguments
+before it.
+*/
+func completeArgument(cmd *command, positional []string, word string) []string {
+	switch {
+	case cmd == sendCommand && len(positional) == 0:
+		return completeRecipients(word)
+	case cmd == scheduleCommand && len(positional) == 0:
+		return withPrefix([]string{"list", "remove"}, word)
+	case cmd == scheduleCommand && len(positional) == 1:
+		return completeRecipients(word)
+	case cmd == schedulerCommand && len(positional) == 0:
+		return withPrefix([]string{"run"}, word)
+	case cmd == auditCommand && len(positional) == 0:
+		return withPrefix([]string{"show"}, word)
+	case cmd == ciCommand && len(positional) == 0:
+		return withPrefix([]string{"merged", "fixed"}, word)
+	case cmd == aliasCommand && len(positional) == 0:
+		return withPrefix([]string{"list", "check", "resolve", "add", "remove"}, word)
+	case cmd == aliasCommand && len(positional) == 2 && positional[0] == "add":
+		return completeUsers(word)
+	case cmd == groupCommand && len(positional) == 0:
+		return withPrefix([]string{"list", "add", "remove"}, word)
+	case cmd == groupCommand && len(positional) == 1:
+		return completeGroups(word)
+	case cmd == groupCommand && len(positional) > 1:
+		return completeUsers(word)
+	case cmd == syncCommand || cmd == autocompleteCommand || cmd == pairCommand:
+		return completeUsers(word)
+	case cmd == betweenCommand && len(positional) < 2:
+		return completeUsers(word)
+	case cmd == helpCommand && len(positional) == 0:
+		return withPrefix(commandNames(), word)
+	case cmd == completionCommand && len(positional) == 0:
+		return withPrefix([]string{"bash", "fish", "zsh"}, word)
+	case cmd == docsCommand && len(positional) == 0:
+		return withPrefix([]string{"man", "markdown"}, word)
+	case cmd == serveCommand && len(positional) == 0:
+		var names []string
+		for _, mode := range serveModes {
+			names = append(names, mode.Name)
+		}
+		return withPrefix(names, word)
+	case cmd == configCommand && len(positional) == 0:
+		return withPrefix([]string{"get", "set", "path"}, word)
+	case cmd == configCommand && len(positional) == 1 && positional[0] != "path":
+		var names []string
+		for _, key := range configKeys {
+			names = append(names, key.Name)
+		}
+		return withPrefix(names, word)
+	}
+	return nil
+}
+
+/*
+Complete the last of a comma-separated list of recipients.
+*/
+func completeRecipients(word string) []string {
+	done := ""
+	if i := strings.LastIndex(word, ","); i >= 0 {
+		done, word = word[:i+1], word[i+1:]
+	}
+	var users []string
+	if strings.HasPrefix(word, "@") {
+		users = completeGroups(word)
+	} else {
+		users = completeFrequentUsers(word)
+	}
+	for i := range users {
+		users[i] = done + users[i]
+	}
+	return users
+}
+
+/*
+Return the usernames starting with prefix. Nothing is returned for an empty
+prefix, rather than every user, or when the users can't be looked up: errors
+would only garble the command line.
+*/
+func completeUsers(prefix string) []string {
+	if prefix == "" {
+		return nil
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return nil
+	}
+	defer useAutocompleteCache(client)()
+	users, err := client.Autocomplete(prefix)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, u := range users {
+		names = append(names, u.Username)
+	}
+	return withPrefix(names, prefix)
+}
+
+/*
+Return the usernames starting with prefix, the configured sender's frequent
+recipients first, in order, followed by the other users. For an empty prefix,
+only the most frequent recipients are returned.
+*/
+func completeFrequentUsers(prefix string) []string {
+	var frequent []string
+	if cfg, err := loadConfig(); err == nil {
+		if sender, err := cfg.sender(); err == nil {
+			frequent = withPrefix(frequentRecipients(store.DefaultPath(), sender, prefix), prefix)
+		}
+	}
+	if prefix == "" && len(frequent) > maxSuggestions {
+		frequent = frequent[:maxSuggestions]
+	}
+	seen := make(map[string]bool, len(frequent))
+	for _, username := range frequent {
+		seen[username] = true
+	}
+	names := frequent
+	for _, username := range completeUsers(prefix) {
+		if !seen[username] {
+			names = append(names, username)
+		}
+	}
+	return names
+}
+
+/*
+Complete the name of a group, with a leading @ if the word has one.
+*/
+func completeGroups(word string) []string {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+	groups, err := cfg.groups()
+	if err != nil {
+		return nil
+	}
+	names := groups.Names()
+	if strings.HasPrefix(word, "@") {
+		for i := range names {
+			names[i] = "@" + names[i]
+		}
+	}
+	return withPrefix(names, word)
+}
+
+func commandNames() []string {
+	var names []string
+	for _, cmd := range commands {
+		if !cmd.Hidden {
+			names = append(names, cmd.Name)
+		}
+	}
+	return names
+}
+
+func withPrefix(candidates []string, prefix string) []string {
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
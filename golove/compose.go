@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"golang.org/x/term"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+var errAborted = errors.New("aborted")
+
+/*
+Prompt for recipients and a message on the terminal. While typing recipients,
+pressing tab completes the current username with the Autocomplete endpoint,
+suggesting the frequent recipients, in order, first. Pressing tab before typing
+anything lists the most frequent recipients. Returns the comma separated
+recipients, and the message.
+*/
+func compose(client *love.Client, frequent []string) (string, string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", "", errors.New("interactive mode requires a terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", "", err
+	}
+	defer term.Restore(fd, state)
+
+	screen := struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}
+	terminal := term.NewTerminal(screen, "To: ")
+	fmt.Fprintln(terminal, "Enter recipients separated by commas. Press tab to complete a username.")
+	terminal.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		return completeRecipient(client, frequent, terminal, line, pos)
+	}
+	var recipients string
+	for recipients == "" {
+		line, err := terminal.ReadLine()
+		if err != nil {
+			fmt.Fprintln(terminal)
+			return "", "", errAborted
+		}
+		recipients = normalizeRecipients(line)
+	}
+	terminal.AutoCompleteCallback = nil
+
+	fmt.Fprintln(terminal, "Enter your message. End it with a line containing only \".\", or Ctrl-D.")
+	terminal.SetPrompt("> ")
+	var lines []string
+	for {
+		line, err := terminal.ReadLine()
+		if err == io.EOF || line == "." {
+			break
+		} else if err != nil {
+			return "", "", err
+		}
+		lines = append(lines, line)
+	}
+	message := strings.TrimSpace(strings.Join(lines, "\n"))
+	if message == "" {
+		return "", "", errors.New("the message is empty")
+	}
+	return recipients, message, nil
+}
+
+/*
+Complete the username being typed at pos, which is the text following the last
+comma. A unique match is completed entirely. Otherwise, the longest common
+prefix of the matches is completed, and the matches are listed, frequent
+recipients first.
+*/
+func completeRecipient(client *love.Client, frequent []string, terminal *term.Terminal,
+	line string, pos int) (string, int, bool) {
+	start := strings.LastIndex(line[:pos], ",") + 1
+	partial := strings.TrimSpace(line[start:pos])
+	if partial == "" {
+		for i, username := range frequent {
+			if i == maxSuggestions {
+				break
+			}
+			fmt.Fprintf(terminal, "  %s\n", username)
+		}
+		return "", 0, false
+	}
+	users, err := client.Autocomplete(partial)
+	if err != nil {
+		users = nil
+	}
+	users = suggestRecipients(frequent, partial, users)
+	if len(users) == 0 {
+		return "", 0, false
+	}
+	completion := users[0].Username + ","
+	if len(users) > 1 {
+		for _, u := range users {
+			fmt.Fprintf(terminal, "  %s\n", u.Display)
+		}
+		completion = users[0].Username
+		for _, u := range users[1:] {
+			completion = commonPrefix(completion, u.Username)
+		}
+		if len(completion) < len(partial) {
+			completion = partial
+		}
+	}
+	prefix := line[:start]
+	if start > 0 {
+		prefix += " "
+	}
+	newLine := prefix + completion + line[pos:]
+	return newLine, len(prefix) + len(completion), true
+}
+
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
+
+/*
+Convert a list of recipients as typed into the form the API expects, removing
+whitespace and empty entries.
+*/
+func normalizeRecipients(line string) string {
+	var recipients []string
+	for _, r := range strings.Split(line, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			recipients = append(recipients, r)
+		}
+	}
+	return strings.Join(recipients, ",")
+}
+
+/*
+Return the user's editor: $VISUAL, $EDITOR, or a default for the platform.
+*/
+func editor() string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+/*
+Open the user's editor on a template for a message to the recipients, like "git
+commit" does, and return the message. Lines starting with # are removed, and
+the message is trimmed. An empty message aborts sending.
+*/
+func composeInEditor(sender, recipients string) (string, error) {
+	file, err := ioutil.TempFile("", "golove-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	fmt.Fprintf(file, "\n# Write your love for %s above.\n", recipients)
+	fmt.Fprint(file, "# Lines starting with \"#\" are ignored, and an empty message aborts sending.\n#\n")
+	fmt.Fprintf(file, "# From: %s\n# To:   %s\n", sender, recipients)
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		fields := strings.Fields(editor())
+		cmd = exec.Command(fields[0], append(fields[1:], file.Name())...)
+	} else {
+		// The editor may have arguments, as in EDITOR="code --wait".
+		cmd = exec.Command("/bin/sh", "-c", editor()+` "$1"`, "editor", file.Name())
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %s", editor(), err)
+	}
+	contents, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+	message := stripComments(string(contents))
+	if message == "" {
+		return "", errors.New("the message is empty; no love was sent")
+	}
+	return message, nil
+}
+
+/*
+Return the message written in an editor: the lines which do not start with #,
+without trailing whitespace, and trimmed.
+*/
+func stripComments(contents string) string {
+	var lines []string
+	for _, line := range strings.Split(contents, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestStripComments(t *testing.T) {
+	for _, test := range []struct {
+		contents string
+		expected string
+	}{
+		{"Thanks!\n", "Thanks!"},
+		{"Thanks!\n\n# Write your love for darwin above.\n#\n# To:   darwin\n", "Thanks!"},
+		{"\n\nThanks for the launch.   \r\n\nAnd the cake.\t\n\n# From: hammy\n",
+			"Thanks for the launch.\n\nAnd the cake."},
+		// Only lines starting with # are comments.
+		{"Thanks for #launch!\n # not a comment\n#comment\n", "Thanks for #launch!\n # not a comment"},
+		{"# Write your love for darwin above.\n#\n", ""},
+		{"", ""},
+	} {
+		assert.Equal(t, stripComments(test.contents), test.expected, test.contents)
+	}
+}
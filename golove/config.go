@@ -0,0 +1,693 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/hacsoc/golove/alias"
+	"github.com/hacsoc/golove/love"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/*
+The settings golove needs to talk to a love instance.
+*/
+type config struct {
+	ApiKey  string
+	BaseUrl string
+	Sender  string
+	// The file of aliases, such as Slack user IDs, for love usernames.
+	Aliases string
+	// The file of groups of recipients, such as teams.
+	Groups string
+
+	// Other base URLs of the instance, separated by commas.
+	FallbackUrls string
+	// Consecutive failures after which requests stop for a while.
+	CircuitBreaker string
+	// The header to send the API key in, instead of the URL.
+	ApiKeyHeader string
+	// Used instead of the API key, for instances behind a proxy.
+	BearerToken string
+	AuthHeader  string
+
+	// Used by "golove serve slack".
+	SlackSigningSecret string
+	// Used by "golove slack-bot".
+	SlackBotToken string
+	SlackAppToken string
+	// Used by "golove digest".
+	SMTPServer   string
+	SMTPUsername string
+	SMTPPassword string
+	EmailFrom    string
+	EmailDomain  string
+	// Used by "golove watch -webhook".
+	WebhookSecret string
+	// Used by "golove ci" and "golove serve github".
+	CIUsers string
+	// Used by "golove serve github".
+	GitHubWebhookSecret string
+	// Used by "golove serve pagerduty".
+	PagerDutyWebhookSecret string
+	PagerDutyToken         string
+	// Authorizes sending love through "golove serve graphql" and grpc.
+	ServeToken string
+	// Limits on the recipients and message of love.
+	MaxRecipients     string
+	ConfirmRecipients string
+	MaxMessageLength  string
+	BlockedWords      string
+	// Whether to send emoji shortcodes as they are written.
+	KeepShortcodes string
+	// Used by "golove export -anonymize".
+	AnonymizeKey string
+	// The file every attempt to send love is recorded in, or "off".
+	AuditLog string
+	// Whether to refuse to send love as anyone but the sender.
+	LockSender string
+	// How long to refuse to send the same love to the same recipient again.
+	DuplicateWindow string
+	// Identifies the program using golove to the instance, such as a bot.
+	UserAgent string
+
+	// The configuration file, whether or not it exists.
+	Path string
+	// The groups defined in the configuration file, as group.name = "members".
+	groupDefs map[string]string
+}
+
+/*
+A setting which may be stored in the configuration file, under Name, or given in
+the environment variable Env. The environment takes precedence. Secret values
+are not shown when listing the configuration.
+*/
+type configKey struct {
+	Name   string
+	Env    string
+	Secret bool
+	field  func(c *config) *string
+}
+
+var configKeys = []configKey{
+	{"api_key", "LOVE_API_KEY", true, func(c *config) *string { return &c.ApiKey }},
+	{"base_url", "LOVE_BASE_URL", false, func(c *config) *string { return &c.BaseUrl }},
+	{"sender", "LOVE_SENDER", false, func(c *config) *string { return &c.Sender }},
+	{"aliases", "LOVE_ALIASES", false, func(c *config) *string { return &c.Aliases }},
+	{"groups", "LOVE_GROUPS", false, func(c *config) *string { return &c.Groups }},
+	{"fallback_urls", "LOVE_FALLBACK_URLS", false,
+		func(c *config) *string { return &c.FallbackUrls }},
+	{"circuit_breaker", "LOVE_CIRCUIT_BREAKER", false,
+		func(c *config) *string { return &c.CircuitBreaker }},
+	{"api_key_header", "LOVE_API_KEY_HEADER", false,
+		func(c *config) *string { return &c.ApiKeyHeader }},
+	{"bearer_token", "LOVE_BEARER_TOKEN", true, func(c *config) *string { return &c.BearerToken }},
+	{"auth_header", "LOVE_AUTH_HEADER", true, func(c *config) *string { return &c.AuthHeader }},
+	{"slack_signing_secret", "SLACK_SIGNING_SECRET", true,
+		func(c *config) *string { return &c.SlackSigningSecret }},
+	{"slack_bot_token", "SLACK_BOT_TOKEN", true,
+		func(c *config) *string { return &c.SlackBotToken }},
+	{"slack_app_token", "SLACK_APP_TOKEN", true,
+		func(c *config) *string { return &c.SlackAppToken }},
+	{"smtp_server", "LOVE_SMTP_SERVER", false, func(c *config) *string { return &c.SMTPServer }},
+	{"smtp_username", "LOVE_SMTP_USERNAME", false,
+		func(c *config) *string { return &c.SMTPUsername }},
+	{"smtp_password", "LOVE_SMTP_PASSWORD", true,
+		func(c *config) *string { return &c.SMTPPassword }},
+	{"email_from", "LOVE_EMAIL_FROM", false, func(c *config) *string { return &c.EmailFrom }},
+	{"email_domain", "LOVE_EMAIL_DOMAIN", false,
+		func(c *config) *string { return &c.EmailDomain }},
+	{"webhook_secret", "LOVE_WEBHOOK_SECRET", true,
+		func(c *config) *string { return &c.WebhookSecret }},
+	{"ci_users", "LOVE_CI_USERS", false, func(c *config) *string { return &c.CIUsers }},
+	{"github_webhook_secret", "GITHUB_WEBHOOK_SECRET", true,
+		func(c *config) *string { return &c.GitHubWebhookSecret }},
+	{"pagerduty_webhook_secret", "PAGERDUTY_WEBHOOK_SECRET", true,
+		func(c *config) *string { return &c.PagerDutyWebhookSecret }},
+	{"pagerduty_token", "PAGERDUTY_TOKEN", true,
+		func(c *config) *string { return &c.PagerDutyToken }},
+	{"serve_token", "LOVE_SERVE_TOKEN", true, func(c *config) *string { return &c.ServeToken }},
+	{"max_recipients", "LOVE_MAX_RECIPIENTS", false,
+		func(c *config) *string { return &c.MaxRecipients }},
+	{"confirm_recipients", "LOVE_CONFIRM_RECIPIENTS", false,
+		func(c *config) *string { return &c.ConfirmRecipients }},
+	{"max_message_length", "LOVE_MAX_MESSAGE_LENGTH", false,
+		func(c *config) *string { return &c.MaxMessageLength }},
+	{"blocked_words", "LOVE_BLOCKED_WORDS", false,
+		func(c *config) *string { return &c.BlockedWords }},
+	{"keep_shortcodes", "LOVE_KEEP_SHORTCODES", false,
+		func(c *config) *string { return &c.KeepShortcodes }},
+	{"anonymize_key", "LOVE_ANONYMIZE_KEY", true,
+		func(c *config) *string { return &c.AnonymizeKey }},
+	{"audit_log", "LOVE_AUDIT_LOG", false, func(c *config) *string { return &c.AuditLog }},
+	{"lock_sender", "LOVE_LOCK_SENDER", false, func(c *config) *string { return &c.LockSender }},
+	{"duplicate_window", "LOVE_DUPLICATE_WINDOW", false,
+		func(c *config) *string { return &c.DuplicateWindow }},
+	{"user_agent", "LOVE_USER_AGENT", false, func(c *config) *string { return &c.UserAgent }},
+}
+
+func findConfigKey(name string) *configKey {
+	for i := range configKeys {
+		if configKeys[i].Name == name {
+			return &configKeys[i]
+		}
+	}
+	return nil
+}
+
+/*
+Return the path of the configuration file. LOVE_CONFIG overrides the default,
+which is config.toml, config.yaml or config.yml (the first which exists) in
+$XDG_CONFIG_HOME/golove, or ~/.config/golove.
+*/
+func configPath() string {
+	if path := os.Getenv("LOVE_CONFIG"); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	dir = filepath.Join(dir, "golove")
+	for _, name := range []string{"config.toml", "config.yaml", "config.yml"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, "config.toml")
+}
+
+/*
+Load the configuration from the configuration file, if there is one, and the
+environment.
+*/
+func loadConfig() (*config, error) {
+	path := configPath()
+	values, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return resolveConfig(path, values), nil
+}
+
+/*
+Combine the settings read from the configuration file at path with the
+environment. Keys starting with "group." define groups of recipients.
+*/
+func resolveConfig(path string, values map[string]string) *config {
+	c := &config{Path: path, groupDefs: make(map[string]string)}
+	for _, key := range configKeys {
+		if value := os.Getenv(key.Env); value != "" {
+			*key.field(c) = value
+		} else {
+			*key.field(c) = values[key.Name]
+		}
+	}
+	for key, value := range values {
+		if strings.HasPrefix(key, groupKeyPrefix) {
+			c.groupDefs[strings.ToLower(strings.TrimPrefix(key, groupKeyPrefix))] = value
+		}
+	}
+	return c
+}
+
+/*
+Return the path of the aliases file: the aliases setting, or aliases.json
+beside the configuration file.
+*/
+func (c *config) aliasesPath() string {
+	if c.Aliases != "" || c.Path == "" {
+		return c.Aliases
+	}
+	return filepath.Join(filepath.Dir(c.Path), "aliases.json")
+}
+
+/*
+Read the aliases file, which maps the identities of users in other services to
+love usernames. A missing file has no aliases. Email addresses in email_domain
+need no alias.
+*/
+func (c *config) aliases() (*alias.Map, error) {
+	aliases, err := alias.Read(c.aliasesPath())
+	if err != nil {
+		return nil, err
+	}
+	aliases.EmailDomain = c.EmailDomain
+	return aliases, nil
+}
+
+/*
+Return the path of the groups file: the groups setting, or groups.json beside
+the configuration file.
+*/
+func (c *config) groupsPath() string {
+	if c.Groups != "" || c.Path == "" {
+		return c.Groups
+	}
+	return filepath.Join(filepath.Dir(c.Path), "groups.json")
+}
+
+/*
+Return the groups of recipients: those in the groups file, and those defined in
+the configuration file, which replace any in the groups file of the same name.
+*/
+func (c *config) groups() (love.Groups, error) {
+	groups, err := readGroups(c.groupsPath())
+	if err != nil {
+		return nil, err
+	}
+	for name, members := range c.groupDefs {
+		groups.Remove(name)
+		if err := groups.Add(name, strings.Split(members, ",")...); err != nil {
+			return nil, fmt.Errorf("%s: %s", c.Path, err)
+		}
+	}
+	return groups, nil
+}
+
+/*
+Create a client from the configuration, failing if it is incomplete.
+*/
+func (c *config) client() (*love.Client, error) {
+	auth, err := c.auth()
+	if err != nil {
+		return nil, err
+	}
+	if c.ApiKey == "" && auth == nil {
+		return nil, c.missing("api_key")
+	}
+	if c.BaseUrl == "" {
+		return nil, c.missing("base_url")
+	}
+	client := love.NewClient(c.ApiKey, c.BaseUrl)
+	client.Auth = auth
+	client.UserAgent = c.userAgent()
+	for _, fallback := range strings.Split(c.FallbackUrls, ",") {
+		if fallback = strings.TrimSpace(fallback); fallback != "" {
+			client.FallbackUrls = append(client.FallbackUrls, strings.TrimSuffix(fallback, "/"))
+		}
+	}
+	if c.CircuitBreaker != "" {
+		threshold, err := c.number("circuit_breaker", c.CircuitBreaker)
+		if err != nil {
+			return nil, err
+		}
+		if threshold > 0 {
+			client.Breaker = &love.CircuitBreaker{Threshold: threshold}
+		}
+	}
+	if c.MaxRecipients != "" {
+		max, err := c.number("max_recipients", c.MaxRecipients)
+		if err != nil {
+			return nil, err
+		}
+		client.MaxRecipients = max
+	}
+	if c.MaxMessageLength != "" {
+		max, err := c.number("max_message_length", c.MaxMessageLength)
+		if err != nil {
+			return nil, err
+		}
+		client.MaxMessageLength = max
+	}
+	if c.BlockedWords != "" {
+		words, err := readList(c.BlockedWords)
+		if err != nil {
+			return nil, err
+		}
+		client.MessageFilter = love.BlockWords(words...)
+	}
+	if c.KeepShortcodes != "" {
+		keep, err := strconv.ParseBool(c.KeepShortcodes)
+		if err != nil {
+			return nil, fmt.Errorf("keep_shortcodes must be true or false, not %q", c.KeepShortcodes)
+		}
+		client.MessageOptions.KeepShortcodes = keep
+	}
+	if client.Groups, err = c.groups(); err != nil {
+		return nil, err
+	}
+	if c.LockSender != "" {
+		lock, err := strconv.ParseBool(c.LockSender)
+		if err != nil {
+			return nil, fmt.Errorf("lock_sender must be true or false, not %q", c.LockSender)
+		}
+		if lock {
+			sender, err := c.sender()
+			if err != nil {
+				return nil, err
+			}
+			client.LockSender(sender)
+		}
+	}
+	if c.DuplicateWindow != "" {
+		var window daysFlag
+		if err := window.Set(strings.TrimSpace(c.DuplicateWindow)); err != nil {
+			return nil, fmt.Errorf("duplicate_window must be a duration such as 10m, not %q",
+				c.DuplicateWindow)
+		}
+		client.DuplicateWindow = window.Duration
+	}
+	if path := c.auditPath(); path != "" {
+		client.Audit = auditFile{path}
+	}
+	enableDebug(client)
+	instrument(client)
+	return client, nil
+}
+
+/*
+Return the User-Agent golove sends, such as "golove/0.2.0 (slack-bot)", naming
+the command it is running, so that bots can be told apart from people. If
+user_agent is set, it comes first, to identify whoever runs golove.
+*/
+func (c *config) userAgent() string {
+	product := "golove/" + version
+	if running != nil {
+		product += " (" + running.Name + ")"
+	}
+	if c.UserAgent != "" {
+		return strings.TrimSpace(c.UserAgent) + " " + product
+	}
+	return product
+}
+
+/*
+Return the Authenticator configured instead of sending the API key in the URL:
+the API key in the api_key_header, a bearer token, or a header given as
+"Name: value". Returns nil if none is configured.
+*/
+func (c *config) auth() (love.Authenticator, error) {
+	switch {
+	case c.ApiKeyHeader != "" && (c.BearerToken != "" || c.AuthHeader != ""):
+		return nil, errors.New("api_key_header cannot be used with bearer_token or auth_header")
+	case c.ApiKeyHeader != "":
+		if c.ApiKey == "" {
+			return nil, c.missing("api_key")
+		}
+		return love.APIKeyAuth{Key: c.ApiKey, Header: c.ApiKeyHeader}, nil
+	case c.BearerToken != "" && c.AuthHeader != "":
+		return nil, errors.New("only one of bearer_token and auth_header may be set")
+	case c.BearerToken != "":
+		return love.BearerAuth{Token: c.BearerToken}, nil
+	case c.AuthHeader != "":
+		parts := strings.SplitN(c.AuthHeader, ":", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" {
+			return nil, errors.New(`auth_header must have the form "Name: value"`)
+		}
+		return love.HeaderAuth{Name: name, Value: strings.TrimSpace(parts[1])}, nil
+	}
+	return nil, nil
+}
+
+/*
+Return the configured sender, failing if there is none.
+*/
+func (c *config) sender() (string, error) {
+	if c.Sender == "" {
+		return "", c.missing("sender")
+	}
+	return c.Sender, nil
+}
+
+/*
+Return the number of recipients above which "golove send" asks for
+confirmation. It is 5 unless confirm_recipients is set, and 0 disables
+confirmation.
+*/
+func (c *config) confirmThreshold() (int, error) {
+	if c.ConfirmRecipients == "" {
+		return defaultConfirmRecipients, nil
+	}
+	return c.number("confirm_recipients", c.ConfirmRecipients)
+}
+
+const defaultConfirmRecipients = 5
+
+/*
+Parse the value of a numeric setting, which may not be negative.
+*/
+func (c *config) number(name, value string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a number, not %q", name, value)
+	}
+	return n, nil
+}
+
+func (c *config) missing(name string) error {
+	return fmt.Errorf("%s is not configured: set %s or run \"golove config set %s\"",
+		name, findConfigKey(name).Env, name)
+}
+
+func isYAML(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+/*
+Read the settings in a configuration file. A missing file has no settings.
+
+Only a flat subset of TOML and YAML is supported, with one setting on each line.
+Keys are bare, made of letters, digits, "_", "-" and ".". In TOML, each line is
+key = "value" or key = 'value', and in YAML, key: value, key: "value" or
+key: 'value'. Double-quoted values may hold escapes such as \n, and single
+quotes may be doubled inside single-quoted YAML values. A value may be followed
+by a # comment, and blank lines and lines starting with # are ignored. Tables,
+arrays, multi-line strings, and unquoted TOML values such as numbers and
+booleans are rejected.
+*/
+func readConfigFile(path string) (map[string]string, error) {
+	values := make(map[string]string)
+	if path == "" {
+		return values, nil
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return values, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+		key, value, err := parseConfigLine(line, isYAML(path))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s", path, lineno, err)
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+func parseConfigLine(line string, yaml bool) (string, string, error) {
+	separator := "="
+	if yaml {
+		separator = ":"
+	}
+	parts := strings.SplitN(line, separator, 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("expected key %s value", separator)
+	}
+	key := strings.TrimSpace(parts[0])
+	if !validConfigKey(key) {
+		return "", "", fmt.Errorf("invalid key %q", key)
+	}
+	value := strings.TrimSpace(parts[1])
+	switch {
+	case strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'"):
+		unquoted, rest, err := unquoteConfigValue(value, yaml)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid string for %s", key)
+		}
+		if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", "", fmt.Errorf("unexpected %q after the value of %s", rest, key)
+		}
+		return key, unquoted, nil
+	case !yaml:
+		return "", "", fmt.Errorf("value of %s must be a quoted string", key)
+	}
+	// Unquoted YAML scalars end at a # following a space.
+	for i := 0; i < len(value); i++ {
+		if value[i] == '#' && (i == 0 || value[i-1] == ' ' || value[i-1] == '\t') {
+			value = strings.TrimSpace(value[:i])
+			break
+		}
+	}
+	return key, value, nil
+}
+
+func validConfigKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			r == '_' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Unquote the quoted string at the start of a value, returning it and whatever
+follows the closing quote.
+*/
+func unquoteConfigValue(value string, yaml bool) (string, string, error) {
+	quote := value[0]
+	for i := 1; i < len(value); i++ {
+		if quote == '"' && value[i] == '\\' {
+			i++
+			continue
+		}
+		if value[i] != quote {
+			continue
+		}
+		if quote == '"' {
+			unquoted, err := strconv.Unquote(value[:i+1])
+			return unquoted, value[i+1:], err
+		}
+		// YAML writes a single quote inside single quotes as two.
+		if yaml && i+1 < len(value) && value[i+1] == '\'' {
+			i++
+			continue
+		}
+		unquoted := value[1:i]
+		if yaml {
+			unquoted = strings.Replace(unquoted, "''", "'", -1)
+		}
+		return unquoted, value[i+1:], nil
+	}
+	return "", "", errors.New("unterminated string")
+}
+
+func formatConfigLine(key, value string, yaml bool) string {
+	if yaml {
+		return fmt.Sprintf("%s: %s", key, strconv.Quote(value))
+	}
+	return fmt.Sprintf("%s = %s", key, strconv.Quote(value))
+}
+
+/*
+Set a value in a configuration file, creating the file if necessary. Other lines
+of the file, including comments, are preserved. The file is only readable by its
+owner, since it may contain the API key.
+*/
+func writeConfigValue(path, key, value string) error {
+	yaml := isYAML(path)
+	var lines []string
+	contents, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(contents) > 0 {
+		lines = strings.Split(strings.TrimRight(string(contents), "\n"), "\n")
+	}
+	replaced := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if k, _, err := parseConfigLine(trimmed, yaml); err == nil && k == key {
+			lines[i] = formatConfigLine(key, value, yaml)
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, formatConfigLine(key, value, yaml))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data := []byte(strings.Join(lines, "\n") + "\n")
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+var configCommand = &command{
+	Name:    "config",
+	Args:    "get [key] | set key value | path",
+	Summary: "read and write the configuration file",
+	Long: `Manage the configuration file. "get" prints the effective value of a key (or
+of every key), after applying the environment. "set" stores a value in the
+file, and "path" prints the location of the file. Keys of the form group.name
+hold the members of a group (see "golove help group").
+
+The file holds one setting on each line, as key = "value" in TOML, or as
+key: value in YAML if its name ends in .yaml or .yml. Values may be followed by
+a # comment. Only this flat subset of each format is read: tables, arrays, and
+unquoted TOML values such as numbers and booleans are rejected.`,
+	Run: runConfig,
+}
+
+func runConfig(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		return usagef("a subcommand is required")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	switch {
+	case args[0] == "path" && len(args) == 1:
+		fmt.Println(cfg.Path)
+	case args[0] == "get" && len(args) == 1:
+		// Print an explicitly requested secret, but not the whole listing.
+		for _, key := range configKeys {
+			value := *key.field(cfg)
+			if key.Secret && value != "" {
+				value = "REDACTED"
+			}
+			fmt.Printf("%s = %s\n", key.Name, strconv.Quote(value))
+		}
+	case args[0] == "get" && len(args) == 2 && strings.HasPrefix(args[1], groupKeyPrefix):
+		fmt.Println(cfg.groupDefs[strings.ToLower(strings.TrimPrefix(args[1], groupKeyPrefix))])
+	case args[0] == "get" && len(args) == 2:
+		key := findConfigKey(args[1])
+		if key == nil {
+			return usagef("unknown key %q", args[1])
+		}
+		fmt.Println(*key.field(cfg))
+	case args[0] == "set" && len(args) == 3 && strings.HasPrefix(args[1], groupKeyPrefix):
+		name := strings.ToLower(strings.TrimPrefix(args[1], groupKeyPrefix))
+		if !love.ValidGroupName(name) {
+			return usagef("invalid group name %q", name)
+		}
+		return writeConfigValue(cfg.Path, groupKeyPrefix+name, args[2])
+	case args[0] == "set" && len(args) == 3:
+		key := findConfigKey(args[1])
+		if key == nil {
+			return usagef("unknown key %q", args[1])
+		}
+		if err := writeConfigValue(cfg.Path, key.Name, args[2]); err != nil {
+			return err
+		}
+		if os.Getenv(key.Env) != "" {
+			fmt.Fprintf(os.Stderr, "warning: %s is set and overrides the file\n",
+				key.Env)
+		}
+	default:
+		return usagef("invalid arguments")
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, name, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	for name, contents := range map[string]string{
+		"config.toml": `# golove
+api_key = "secret"
+base_url = 'https://love.example.com/api'
+
+sender = "hammy"
+group.Platform = "darwin,jeremy"
+`,
+		"config.yaml": `---
+# golove
+api_key: "secret"
+base_url: https://love.example.com/api
+sender: hammy # the bot
+group.Platform: darwin,jeremy
+`,
+	} {
+		setTestEnv(t, nil)
+		path := writeTestConfig(t, name, contents)
+		t.Setenv("LOVE_CONFIG", path)
+		cfg, err := loadConfig()
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		assert.Equal(t, cfg.Path, path, name)
+		assert.Equal(t, cfg.ApiKey, "secret", name)
+		assert.Equal(t, cfg.BaseUrl, "https://love.example.com/api", name)
+		assert.Equal(t, cfg.Sender, "hammy", name)
+		assert.Equal(t, cfg.groupDefs, map[string]string{"platform": "darwin,jeremy"}, name)
+	}
+}
+
+func TestLoadConfigEnv(t *testing.T) {
+	setTestEnv(t, nil)
+	t.Setenv("LOVE_CONFIG", writeTestConfig(t, "config.toml", "api_key = \"secret\"\nsender = \"hammy\"\n"))
+	t.Setenv("LOVE_SENDER", "darwin")
+	cfg, err := loadConfig()
+	if assert.NoError(t, err) {
+		assert.Equal(t, cfg.ApiKey, "secret")
+		assert.Equal(t, cfg.Sender, "darwin")
+	}
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	setTestEnv(t, nil)
+	t.Setenv("LOVE_API_KEY", "secret")
+	cfg, err := loadConfig()
+	if assert.NoError(t, err) {
+		assert.Equal(t, cfg.ApiKey, "secret")
+		assert.Empty(t, cfg.Sender)
+	}
+	_, err = cfg.sender()
+	assert.Error(t, err)
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	setTestEnv(t, nil)
+	path := writeTestConfig(t, "config.toml", "api_key = \"secret\"\nsender = hammy\n")
+	t.Setenv("LOVE_CONFIG", path)
+	_, err := loadConfig()
+	assert.EqualError(t, err, path+":2: value of sender must be a quoted string")
+}
+
+func TestConfigPath(t *testing.T) {
+	setTestEnv(t, nil)
+	t.Setenv("LOVE_CONFIG", "")
+	dir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "golove")
+	assert.Equal(t, configPath(), filepath.Join(dir, "config.toml"))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.yml"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, configPath(), filepath.Join(dir, "config.yml"))
+	t.Setenv("LOVE_CONFIG", "/etc/golove.toml")
+	assert.Equal(t, configPath(), "/etc/golove.toml")
+}
+
+func TestParseConfigLine(t *testing.T) {
+	for _, test := range []struct {
+		line       string
+		yaml       bool
+		key, value string
+	}{
+		{`api_key = "secret"`, false, "api_key", "secret"},
+		{`api_key="secret"`, false, "api_key", "secret"},
+		{`api_key = "secret" # from the admin page`, false, "api_key", "secret"},
+		{`api_key = "secret"# note`, false, "api_key", "secret"},
+		{`message = "Thanks # for everything"`, false, "message", "Thanks # for everything"},
+		{`message = "say \"hi\"\n" # note`, false, "message", "say \"hi\"\n"},
+		{`base_url = 'C:\love' # note`, false, "base_url", `C:\love`},
+		{`group.platform-team = "darwin,jeremy"`, false, "group.platform-team", "darwin,jeremy"},
+		{`empty = ""`, false, "empty", ""},
+		{`api_key: secret`, true, "api_key", "secret"},
+		{`api_key: secret # from the admin page`, true, "api_key", "secret"},
+		{`base_url: https://love.example.com/api#top`, true, "base_url", "https://love.example.com/api#top"},
+		{`api_key: "secret" # note`, true, "api_key", "secret"},
+		{`message: 'it''s # fine' # note`, true, "message", "it's # fine"},
+		{`empty: # nothing`, true, "empty", ""},
+		{`time: 10:30`, true, "time", "10:30"},
+	} {
+		key, value, err := parseConfigLine(test.line, test.yaml)
+		if assert.NoError(t, err, test.line) {
+			assert.Equal(t, key, test.key, test.line)
+			assert.Equal(t, value, test.value, test.line)
+		}
+	}
+}
+
+func TestParseConfigLineInvalid(t *testing.T) {
+	for _, test := range []struct {
+		line  string
+		yaml  bool
+		error string
+	}{
+		{`[golove]`, false, "expected key = value"},
+		{`api_key`, true, "expected key : value"},
+		{`api_key = secret`, false, "value of api_key must be a quoted string"},
+		{`max_recipients = 10`, false, "value of max_recipients must be a quoted string"},
+		{`lock_sender = true`, false, "value of lock_sender must be a quoted string"},
+		{`users = ["darwin"]`, false, "value of users must be a quoted string"},
+		{`api_key = "secret`, false, "invalid string for api_key"},
+		{`api_key = "secret\q"`, false, "invalid string for api_key"},
+		{`api_key = 'secret`, false, "invalid string for api_key"},
+		{`message = """Thanks"""`, false, `unexpected "\"Thanks\"\"\"" after the value of message`},
+		{`api_key = "secret" "more"`, false, `unexpected "\"more\"" after the value of api_key`},
+		{`"api_key" = "secret"`, false, `invalid key "\"api_key\""`},
+		{`api key = "secret"`, false, `invalid key "api key"`},
+		{` = "secret"`, false, `invalid key ""`},
+		{`message: 'it's'`, true, `unexpected "s'" after the value of message`},
+	} {
+		_, _, err := parseConfigLine(test.line, test.yaml)
+		assert.EqualError(t, err, test.error, test.line)
+	}
+}
+
+func TestWriteConfigValue(t *testing.T) {
+	path := writeTestConfig(t, "config.toml", "# golove\napi_key = \"old\" # note\nsender = \"hammy\"\n")
+	assert.NoError(t, writeConfigValue(path, "api_key", "new"))
+	assert.NoError(t, writeConfigValue(path, "base_url", "https://love.example.com/api"))
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), "# golove\napi_key = \"new\"\nsender = \"hammy\"\n"+
+		"base_url = \"https://love.example.com/api\"\n")
+}
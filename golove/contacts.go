@@ -0,0 +1,86 @@
+package main
+
+import (
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/store"
+	"strings"
+)
+
+// The most contacts suggested when nothing has been typed yet.
+const maxSuggestions = 10
+
+/*
+Return the usernames of the people a sender has sent love to which start with
+prefix, those sent the most love recently first. Suggestions are only a
+convenience, so if the database cannot be read, such as while another golove
+holds it open, there are none.
+*/
+func frequentRecipients(path, sender, prefix string) []string {
+	db, err := store.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer db.Close()
+	return contactNames(db, sender, prefix)
+}
+
+/*
+Return the usernames of a sender's contacts in an open database, as
+frequentRecipients does.
+*/
+func contactNames(db *store.Store, sender, prefix string) []string {
+	contacts, err := db.Contacts(sender, prefix)
+	if err != nil {
+		return nil
+	}
+	var usernames []string
+	for _, c := range contacts {
+		usernames = append(usernames, c.Username)
+	}
+	return usernames
+}
+
+/*
+Record the comma separated recipients of love a sender has sent, to suggest
+them in future. Failing to is not an error, since the love was sent.
+*/
+func recordContacts(path, sender, recipients string) {
+	db, err := store.Open(path)
+	if err != nil {
+		return
+	}
+	defer db.Close()
+	db.RecordContacts(sender, strings.Split(recipients, ","))
+}
+
+/*
+Merge the users Autocomplete found for a prefix with the sender's frequent
+recipients: the frequent recipients which start with the prefix come first, in
+order, followed by the other users. Frequent recipients Autocomplete did not
+find are shown by their usernames.
+*/
+func suggestRecipients(frequent []string, prefix string, users []love.User) []love.User {
+	byUsername := make(map[string]love.User, len(users))
+	for _, u := range users {
+		byUsername[u.Username] = u
+	}
+	var suggestions []love.User
+	suggested := make(map[string]bool)
+	for _, username := range frequent {
+		if !strings.HasPrefix(strings.ToLower(username), strings.ToLower(prefix)) {
+			continue
+		}
+		u, ok := byUsername[username]
+		if !ok {
+			u = love.User{Username: username, Display: username}
+		}
+		suggestions = append(suggestions, u)
+		suggested[username] = true
+	}
+	for _, u := range users {
+		if !suggested[u.Username] {
+			suggestions = append(suggestions, u)
+		}
+	}
+	return suggestions
+}
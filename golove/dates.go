@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The layout of dates given on the command line.
+const dateLayout = "2006-01-02"
+
+/*
+A flag.Value holding a date, given as YYYY-MM-DD in local time. The zero value
+means no date was given.
+*/
+type dateFlag struct {
+	time.Time
+}
+
+func (d *dateFlag) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(dateLayout)
+}
+
+func (d *dateFlag) Set(value string) error {
+	t, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return fmt.Errorf("expected a date like %s", dateLayout)
+	}
+	d.Time = t
+	return nil
+}
+
+/*
+A flag.Value holding a duration, given as for time.ParseDuration or as a number
+of days or weeks, such as 14d or 2w.
+*/
+type daysFlag struct {
+	time.Duration
+}
+
+func (d *daysFlag) String() string {
+	const day = 24 * time.Hour
+	switch {
+	case d.Duration == 0:
+		return "0"
+	case d.Duration%(7*day) == 0:
+		return fmt.Sprintf("%dw", d.Duration/(7*day))
+	case d.Duration%day == 0:
+		return fmt.Sprintf("%dd", d.Duration/day)
+	}
+	return d.Duration.String()
+}
+
+func (d *daysFlag) Set(value string) error {
+	unit := 24 * time.Hour
+	switch {
+	case strings.HasSuffix(value, "w"):
+		unit *= 7
+		fallthrough
+	case strings.HasSuffix(value, "d"):
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil || n < 0 {
+			return fmt.Errorf("expected a duration like 14d")
+		}
+		d.Duration = time.Duration(n) * unit
+		return nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return fmt.Errorf("expected a duration like 14d")
+	}
+	d.Duration = duration
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Set by the -debug and -verbose flags.
+var debug, verbose bool
+
+/*
+An http.RoundTripper which prints each request and the status of its response
+to stderr, with the API key redacted.
+*/
+type debugTransport struct {
+	next   http.RoundTripper
+	client *love.Client
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fmt.Fprintf(os.Stderr, "> %s %s\n", req.Method, t.client.Redact(req.URL.String()))
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			contents, _ := ioutil.ReadAll(body)
+			body.Close()
+			if len(contents) > 0 {
+				fmt.Fprintf(os.Stderr, "> %s\n", t.client.Redact(string(contents)))
+			}
+		}
+	}
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "< error after %s: %s\n", elapsed,
+			t.client.Redact(err.Error()))
+		return resp, err
+	}
+	fmt.Fprintf(os.Stderr, "< %s (%s)\n", resp.Status, elapsed)
+	return resp, nil
+}
+
+/*
+Print the requests made by the client when -debug is given, and log them when
+-verbose is given.
+*/
+func enableDebug(client *love.Client) {
+	if verbose {
+		client.Logger = slog.New(slog.NewTextHandler(os.Stderr,
+			&slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	if !debug {
+		return
+	}
+	client.Middleware = append(client.Middleware, func(next http.RoundTripper) http.RoundTripper {
+		return &debugTransport{next: next, client: client}
+	})
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/digest"
+	"github.com/hacsoc/golove/love"
+	htmltemplate "html/template"
+	"io/ioutil"
+	"net"
+	"net/smtp"
+	"os"
+	"time"
+)
+
+var digestCommand = &command{
+	Name:    "digest",
+	Args:    "[-daily | -weekly | -since date [-until date]] [-user user] [-team -to address] [-trend n] [-subject template] [-text file] [-html file] [-dry-run]",
+	Summary: "email a summary of the love received",
+	Long: `Email each user (the configured sender by default; -user may be repeated) a
+digest of the love they received during a period: the last 7 days with -weekly,
+which is the default, the last day with -daily, or the dates given by -since
+and -until. Days end at midnight, local time, so a digest sent by cron early on
+Monday morning with -weekly covers the previous week. Users who received no love
+are not emailed.
+
+With -team, a single digest of the love received by all the users is emailed
+to each -to address instead. If the team's love fell by a quarter or more from
+the average of the -trend periods of the same length before, 4 by default, the
+digest says so, to prompt the team to send more.
+
+Each user's digest is emailed to username@email_domain. Mail is sent through
+smtp_server (host:port), authenticated with smtp_username and smtp_password if
+they are set, from email_from. Each setting may be given in the environment, as
+LOVE_EMAIL_DOMAIN, LOVE_SMTP_SERVER and so on. With -dry-run, the emails are
+printed instead of sent.
+
+The subject, text and HTML of the emails may be customized with templates, in
+the syntax of text/template (html/template for -html), which are executed with
+a digest.Digest. For example:
+
+	golove digest -subject '{{plural (len .Loves) "kudo"}} for {{.Recipient}}'
+
+The markdown function renders the bold, italic and links in a message, as
+HTML in the HTML template and as plain text in the others, as the default
+templates do with {{markdown .Message}}. See digest.Templates.
+
+On instances which provide user profiles, the digest shows users by their full
+names.`,
+	Flags: defineDigestFlags,
+	Run:   runDigest,
+}
+
+// The flags of golove digest.
+var digestFlags struct {
+	daily        bool
+	weekly       bool
+	since, until dateFlag
+	users, to    listFlag
+	team         bool
+	trend        int
+	subject      string
+	textPath     string
+	htmlPath     string
+	dryRun       bool
+}
+
+func defineDigestFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&digestFlags.daily, "daily", false, "summarize the last day")
+	flags.BoolVar(&digestFlags.weekly, "weekly", false, "summarize the last 7 days (the default)")
+	digestFlags.since, digestFlags.until = dateFlag{}, dateFlag{}
+	flags.Var(&digestFlags.since, "since", "summarize love sent on or after `date` (YYYY-MM-DD)")
+	flags.Var(&digestFlags.until, "until", "summarize love sent before `date` (default today)")
+	digestFlags.users, digestFlags.to = nil, nil
+	flags.Var(&digestFlags.users, "user", "summarize love received by `user` (may be repeated)")
+	flags.BoolVar(&digestFlags.team, "team", false, "send one digest of the love received by every user")
+	flags.Var(&digestFlags.to, "to", "email the team digest to `address` (may be repeated)")
+	flags.IntVar(&digestFlags.trend, "trend", 4,
+		"compare the love with the `n` periods before (0 to not compare)")
+	flags.StringVar(&digestFlags.subject, "subject", "", "subject `template`")
+	flags.StringVar(&digestFlags.textPath, "text", "", "plain text template `file`")
+	flags.StringVar(&digestFlags.htmlPath, "html", "", "HTML template `file`")
+	flags.BoolVar(&digestFlags.dryRun, "dry-run", false, "print the emails instead of sending them")
+}
+
+func runDigest(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	periods := 0
+	for _, set := range []bool{digestFlags.daily, digestFlags.weekly, !digestFlags.since.IsZero()} {
+		if set {
+			periods++
+		}
+	}
+	if periods > 1 {
+		return usagef("only one of -daily, -weekly and -since may be given")
+	}
+	if !digestFlags.until.IsZero() && digestFlags.since.IsZero() {
+		return usagef("-until requires -since")
+	}
+	if digestFlags.team != (len(digestFlags.to) > 0) {
+		return usagef("-team and -to must be given together")
+	}
+	if digestFlags.trend < 0 {
+		return usagef("-trend must not be negative")
+	}
+	templates, err := digestTemplates(digestFlags.subject, digestFlags.textPath, digestFlags.htmlPath)
+	if err != nil {
+		return err
+	}
+
+	year, month, day := time.Now().Date()
+	end := time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	start := end.AddDate(0, 0, -7)
+	switch {
+	case digestFlags.daily:
+		start = end.AddDate(0, 0, -1)
+	case !digestFlags.since.IsZero():
+		start = digestFlags.since.Time
+		if !digestFlags.until.IsZero() {
+			end = digestFlags.until.Time
+		}
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if !digestFlags.team && cfg.EmailDomain == "" {
+		return cfg.missing("email_domain")
+	}
+	var mailer *digest.Mailer
+	if !digestFlags.dryRun {
+		if mailer, err = cfg.mailer(); err != nil {
+			return err
+		}
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	if len(digestFlags.users) == 0 {
+		sender, err := cfg.sender()
+		if err != nil {
+			return err
+		}
+		digestFlags.users = listFlag{sender}
+	}
+	var loves []love.Love
+	for _, user := range digestFlags.users {
+		received, err := client.GetLoveFiltered(love.LoveFilter{
+			Recipient: user,
+			Since:     love.TrendSince(start, end, digestFlags.trend),
+			Until:     end,
+		})
+		if err != nil {
+			return err
+		}
+		loves = append(loves, received...)
+	}
+	for i := range loves {
+		loves[i].Timestamp = loves[i].Timestamp.Local()
+	}
+
+	var digests []*digest.Digest
+	if digestFlags.team {
+		digests = []*digest.Digest{digest.ForTeam(loves, start, end)}
+	} else {
+		digests = digest.ForRecipients(loves, start, end)
+	}
+	for _, d := range digests {
+		if digestFlags.trend > 0 {
+			d.SetTrend(loves, digestFlags.trend)
+		}
+		d.Profiles = lookupProfiles(client, d.Users())
+		msg, err := templates.Render(d)
+		if err != nil {
+			return err
+		}
+		msg.To = digestFlags.to
+		if !digestFlags.team {
+			msg.To = []string{d.Recipient + "@" + cfg.EmailDomain}
+		}
+		if digestFlags.dryRun {
+			msg.From = cfg.EmailFrom
+			os.Stdout.Write(msg.Bytes(time.Now()))
+			fmt.Println()
+			continue
+		}
+		if err := mailer.Send(msg); err != nil {
+			return err
+		}
+		fmt.Printf("Digest sent to %s\n", msg.To[0])
+	}
+	return nil
+}
+
+/*
+Return the default digest templates, replacing any which are given.
+*/
+func digestTemplates(subject, textPath, htmlPath string) (*digest.Templates, error) {
+	templates := digest.DefaultTemplates()
+	var err error
+	if subject != "" {
+		if templates.Subject, err = digest.ParseText("subject", subject); err != nil {
+			return nil, err
+		}
+	}
+	if textPath != "" {
+		data, err := ioutil.ReadFile(textPath)
+		if err != nil {
+			return nil, err
+		}
+		if templates.Text, err = digest.ParseText(textPath, string(data)); err != nil {
+			return nil, err
+		}
+	}
+	if htmlPath != "" {
+		data, err := ioutil.ReadFile(htmlPath)
+		if err != nil {
+			return nil, err
+		}
+		var html *htmltemplate.Template
+		if html, err = digest.ParseHTML(htmlPath, string(data)); err != nil {
+			return nil, err
+		}
+		templates.HTML = html
+	}
+	return templates, nil
+}
+
+/*
+Create a Mailer from the configuration, failing if it is incomplete.
+*/
+func (c *config) mailer() (*digest.Mailer, error) {
+	if c.SMTPServer == "" {
+		return nil, c.missing("smtp_server")
+	}
+	if c.EmailFrom == "" {
+		return nil, c.missing("email_from")
+	}
+	mailer := &digest.Mailer{Addr: c.SMTPServer, From: c.EmailFrom}
+	if c.SMTPUsername != "" {
+		host, _, err := net.SplitHostPort(c.SMTPServer)
+		if err != nil {
+			return nil, fmt.Errorf("smtp_server: %s", err)
+		}
+		mailer.Auth = smtp.PlainAuth("", c.SMTPUsername, c.SMTPPassword, host)
+	}
+	return mailer, nil
+}
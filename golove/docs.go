@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var docsCommand = &command{
+	Name:    "docs",
+	Args:    "[-dir dir] man | markdown",
+	Summary: "write man pages or a Markdown reference",
+	Long: `Write documentation of golove and each of its commands, generated from the
+commands themselves, into a directory: man pages, golove.1 and golove-send.1
+and so on, or a Markdown reference, golove.md linking to golove-send.md and so
+on. For example, to install man pages:
+
+	golove docs -dir /usr/share/man/man1 man
+
+The date on the man pages is the time in SOURCE_DATE_EPOCH, if it is set, so
+that packages can be built reproducibly.`,
+	Flags: defineDocsFlags,
+	Run:   runDocs,
+}
+
+// The flags of golove docs.
+var docsFlags struct {
+	dir string
+}
+
+func defineDocsFlags(flags *flag.FlagSet) {
+	flags.StringVar(&docsFlags.dir, "dir", ".", "write the documentation into `dir`")
+}
+
+func runDocs(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return usagef("a format is required")
+	}
+	if flags.NArg() > 1 {
+		return usagef("unexpected argument %q", flags.Arg(1))
+	}
+	var ext string
+	var writeMain func(w io.Writer, date time.Time)
+	var writeCommand func(w io.Writer, doc commandDoc, date time.Time)
+	switch flags.Arg(0) {
+	case "man":
+		ext, writeMain, writeCommand = ".1", writeMainMan, writeCommandMan
+	case "markdown":
+		ext, writeMain, writeCommand = ".md", writeMainMarkdown, writeCommandMarkdown
+	default:
+		return usagef("unknown format %q", flags.Arg(0))
+	}
+	date, err := docsDate()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(docsFlags.dir, 0755); err != nil {
+		return err
+	}
+	if err := writeDoc(filepath.Join(docsFlags.dir, "golove"+ext), func(w io.Writer) {
+		writeMain(w, date)
+	}); err != nil {
+		return err
+	}
+	for _, c := range commands {
+		if c.Hidden {
+			continue
+		}
+		doc := describe(c)
+		if err := writeDoc(filepath.Join(docsFlags.dir, "golove-"+c.Name+ext), func(w io.Writer) {
+			writeCommand(w, doc, date)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Return the date of the documentation: the time in SOURCE_DATE_EPOCH, or now.
+*/
+func docsDate() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now().UTC(), nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q", epoch)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+func writeDoc(path string, write func(w io.Writer)) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	write(w)
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+/*
+The documentation of a command: its usage, its description, and each of its
+flags. Modes holds the flags of each mode of golove serve.
+*/
+type commandDoc struct {
+	Command *command
+	Flags   []flagDoc
+	Modes   []modeDoc
+}
+
+type modeDoc struct {
+	Name  string
+	Flags []flagDoc
+}
+
+type flagDoc struct {
+	Name string
+	// The name of the flag's value, such as "user", or "" for a boolean flag.
+	Arg     string
+	Usage   string
+	Default string
+}
+
+func describe(cmd *command) commandDoc {
+	doc := commandDoc{Command: cmd, Flags: flagDocs(cmd.flagSet())}
+	if cmd == serveCommand {
+		for _, mode := range serveModes {
+			if flags := flagDocs(mode.flagSet(cmd)); len(flags) > 0 {
+				doc.Modes = append(doc.Modes, modeDoc{mode.Name, flags})
+			}
+		}
+	}
+	return doc
+}
+
+/*
+Split a command's description into paragraphs. Paragraphs indented by a tab,
+such as examples, are returned with code set and the tab removed.
+*/
+func paragraphs(text string) (paragraphs []string, code []bool) {
+	for _, p := range strings.Split(text, "\n\n") {
+		if p = strings.Trim(p, "\n"); p == "" {
+			continue
+		}
+		indented := strings.HasPrefix(p, "\t")
+		if indented {
+			lines := strings.Split(p, "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimPrefix(line, "\t")
+			}
+			p = strings.Join(lines, "\n")
+		}
+		paragraphs = append(paragraphs, p)
+		code = append(code, indented)
+	}
+	return paragraphs, code
+}
+
+func flagDocs(flags *flag.FlagSet) []flagDoc {
+	home, _ := os.UserHomeDir()
+	var docs []flagDoc
+	flags.VisitAll(func(f *flag.Flag) {
+		// The name of the value is "" for boolean flags.
+		arg, usage := flag.UnquoteUsage(f)
+		def := f.DefValue
+		switch def {
+		case "", "false", "0", "0s", "[]":
+			def = ""
+		}
+		// Defaults such as the database path are under the home directory of
+		// whoever generated the documentation.
+		if home != "" && strings.HasPrefix(def, home+string(filepath.Separator)) {
+			def = "~" + strings.TrimPrefix(def, home)
+		}
+		docs = append(docs, flagDoc{f.Name, arg, usage, def})
+	})
+	return docs
+}
+
+/*
+Escape text for roff, so that hyphens, backslashes and lines beginning with a
+period are printed as they are.
+*/
+func roff(text string) string {
+	text = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(text)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func writeManHeader(w io.Writer, title string, date time.Time) {
+	fmt.Fprintf(w, ".TH %q 1 %q %q \"golove manual\"\n", strings.ToUpper(title),
+		date.Format("January 2006"), "golove "+version)
+}
+
+func writeFlagsMan(w io.Writer, flags []flagDoc) {
+	for _, f := range flags {
+		fmt.Fprintln(w, ".TP")
+		if f.Arg == "" {
+			fmt.Fprintf(w, ".B %s\n", roff("-"+f.Name))
+		} else {
+			fmt.Fprintf(w, ".BI %s \" %s\"\n", roff("-"+f.Name), roff(f.Arg))
+		}
+		usage := f.Usage
+		if f.Default != "" {
+			usage += fmt.Sprintf(" (default %s)", f.Default)
+		}
+		fmt.Fprintln(w, roff(usage))
+	}
+}
+
+func writeMainMan(w io.Writer, date time.Time) {
+	writeManHeader(w, "golove", date)
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintln(w, `golove \- a command\-line client for Yelp Love`)
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintln(w, ".B golove")
+	fmt.Fprintln(w, roff("[-debug] [-verbose] [-no-color] [-no-pager] command [arguments]"))
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	fmt.Fprintln(w, roff("golove sends and lists love on a Yelp Love instance, and bridges it to "+
+		"other services. Each command is described in its own page, such as golove-send(1), "+
+		`and by "golove help command".`))
+	fmt.Fprintln(w, ".SH OPTIONS")
+	writeFlagsMan(w, flagDocs(globalFlags()))
+	fmt.Fprintln(w, ".SH COMMANDS")
+	for _, c := range commands {
+		if c.Hidden {
+			continue
+		}
+		fmt.Fprintln(w, ".TP")
+		fmt.Fprintf(w, ".BR %s (1)\n", roff("golove-"+c.Name))
+		fmt.Fprintln(w, roff(c.Summary))
+	}
+	fmt.Fprintln(w, ".SH ENVIRONMENT")
+	for _, key := range configKeys {
+		fmt.Fprintln(w, ".TP")
+		fmt.Fprintf(w, ".B %s\n", roff(key.Env))
+		fmt.Fprintf(w, "The %s setting, which takes precedence over the configuration file.\n",
+			roff(key.Name))
+	}
+	fmt.Fprintln(w, ".TP")
+	fmt.Fprintln(w, ".B LOVE_CONFIG")
+	fmt.Fprintln(w, "The path of the configuration file.")
+	fmt.Fprintln(w, ".TP")
+	fmt.Fprintln(w, ".B GOLOVE_PAGER")
+	fmt.Fprintln(w, "The pager long output is shown through, instead of PAGER or less.")
+	fmt.Fprintln(w, ".TP")
+	fmt.Fprintln(w, ".B NO_COLOR")
+	fmt.Fprintln(w, "Turns off colored output.")
+	fmt.Fprintln(w, ".SH FILES")
+	fmt.Fprintln(w, ".TP")
+	fmt.Fprintln(w, ".I ~/.config/golove/config.toml")
+	fmt.Fprintln(w, roff(`The configuration file, read and written by "golove config".`))
+}
+
+func writeCommandMan(w io.Writer, doc commandDoc, date time.Time) {
+	cmd := doc.Command
+	writeManHeader(w, "golove-"+cmd.Name, date)
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintf(w, "%s \\- %s\n", roff("golove-"+cmd.Name), roff(cmd.Summary))
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintf(w, ".B golove %s\n", roff(cmd.Name))
+	if cmd.Args != "" {
+		fmt.Fprintln(w, roff(cmd.Args))
+	}
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	text, code := paragraphs(cmd.Long)
+	for i, p := range text {
+		if i > 0 {
+			fmt.Fprintln(w, ".PP")
+		}
+		if code[i] {
+			fmt.Fprintf(w, ".RS\n.nf\n%s\n.fi\n.RE\n", roff(p))
+		} else {
+			fmt.Fprintln(w, roff(p))
+		}
+	}
+	if len(doc.Flags) > 0 {
+		fmt.Fprintln(w, ".SH OPTIONS")
+		writeFlagsMan(w, doc.Flags)
+	}
+	for _, mode := range doc.Modes {
+		fmt.Fprintf(w, ".SS \"Options of %s mode\"\n", roff(mode.Name))
+		writeFlagsMan(w, mode.Flags)
+	}
+	fmt.Fprintln(w, ".SH SEE ALSO")
+	fmt.Fprintln(w, ".BR golove (1)")
+}
+
+/*
+Escape text for Markdown, so that characters such as * and < are printed as
+they are.
+*/
+func markdown(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "<", `\<`, "[", `\[`, "`", "\\`").Replace(text)
+}
+
+func writeFlagsMarkdown(w io.Writer, flags []flagDoc) {
+	for _, f := range flags {
+		name := "-" + f.Name
+		if f.Arg != "" {
+			name += " " + f.Arg
+		}
+		fmt.Fprintf(w, "- `%s`: %s", name, f.Usage)
+		if f.Default != "" {
+			fmt.Fprintf(w, " (default `%s`)", f.Default)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func writeMainMarkdown(w io.Writer, date time.Time) {
+	fmt.Fprint(w, "# golove\n\nA command-line client for Yelp Love.\n\n")
+	fmt.Fprint(w, "```\ngolove [-debug] [-verbose] [-no-color] [-no-pager] command [arguments]\n```\n\n")
+	fmt.Fprint(w, "## Options\n\n")
+	writeFlagsMarkdown(w, flagDocs(globalFlags()))
+	fmt.Fprint(w, "\n## Commands\n\n")
+	for _, c := range commands {
+		if c.Hidden {
+			continue
+		}
+		fmt.Fprintf(w, "- [golove %s](golove-%s.md): %s\n", c.Name, c.Name, c.Summary)
+	}
+	fmt.Fprint(w, "\n## Environment\n\n")
+	fmt.Fprint(w, "Each setting may be given in an environment variable, which takes precedence ")
+	fmt.Fprint(w, "over the configuration file.\n\n")
+	fmt.Fprint(w, "| Variable | Setting |\n| --- | --- |\n")
+	for _, key := range configKeys {
+		fmt.Fprintf(w, "| `%s` | `%s` |\n", key.Env, key.Name)
+	}
+	fmt.Fprintf(w, "\n_Generated for golove %s on %s._\n", version, date.Format("2006-01-02"))
+}
+
+func writeCommandMarkdown(w io.Writer, doc commandDoc, date time.Time) {
+	cmd := doc.Command
+	fmt.Fprintf(w, "# golove %s\n\n%s.\n\n", cmd.Name,
+		strings.ToUpper(cmd.Summary[:1])+cmd.Summary[1:])
+	fmt.Fprintf(w, "```\ngolove %s %s\n```\n", cmd.Name, cmd.Args)
+	text, code := paragraphs(cmd.Long)
+	for i, p := range text {
+		if code[i] {
+			fmt.Fprintf(w, "\n```\n%s\n```\n", p)
+		} else {
+			fmt.Fprintf(w, "\n%s\n", markdown(p))
+		}
+	}
+	if len(doc.Flags) > 0 {
+		fmt.Fprint(w, "\n## Options\n\n")
+		writeFlagsMarkdown(w, doc.Flags)
+	}
+	for _, mode := range doc.Modes {
+		fmt.Fprintf(w, "\n### Options of %s mode\n\n", mode.Name)
+		writeFlagsMarkdown(w, mode.Flags)
+	}
+	fmt.Fprint(w, "\nSee also [golove](golove.md).\n")
+}
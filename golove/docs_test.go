@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var testDocsDate = time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC)
+
+func TestDescribe(t *testing.T) {
+	doc := describe(auditCommand)
+	var names []string
+	for _, f := range doc.Flags {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, names, []string{"failed", "limit", "o", "output", "sender", "since", "until"})
+	assert.Equal(t, doc.Flags[4], flagDoc{"sender", "user", "only show love sent as user", ""})
+	assert.Empty(t, doc.Modes)
+
+	doc = describe(serveCommand)
+	assert.Len(t, doc.Flags, 1)
+	assert.Len(t, doc.Modes, len(serveModes))
+	assert.Equal(t, doc.Modes[0].Name, "slack")
+}
+
+func TestParagraphs(t *testing.T) {
+	text, code := paragraphs("Send love.\nTo anyone:\n\n\tgolove send darwin Thanks!\n\t  -v\n\n\nThe end.\n")
+	assert.Equal(t, text, []string{"Send love.\nTo anyone:", "golove send darwin Thanks!\n  -v", "The end."})
+	assert.Equal(t, code, []bool{false, true, false})
+}
+
+func TestWriteCommandMan(t *testing.T) {
+	var buffer bytes.Buffer
+	writeCommandMan(&buffer, describe(aliasCommand), testDocsDate)
+	man := buffer.String()
+	assert.Contains(t, man, ".TH \"GOLOVE-ALIAS\" 1 \"October 2026\"")
+	assert.Contains(t, man, ".SH DESCRIPTION\nManage the aliases file,")
+	assert.Contains(t, man, "\n.PP\n\"list\" prints every alias,")
+	assert.NotContains(t, man, ".SH OPTIONS")
+
+	buffer.Reset()
+	writeCommandMan(&buffer, describe(serveCommand), testDocsDate)
+	man = buffer.String()
+	assert.Contains(t, man, ".RS\n.nf\ngolove serve slack [\\-users file] [\\-trust\\-names]\n.fi\n.RE\n")
+	assert.Contains(t, man, ".SS \"Options of proxy mode\"\n.TP\n.BI \\-ttl \" duration\"\n")
+}
+
+func TestWriteCommandMarkdown(t *testing.T) {
+	var buffer bytes.Buffer
+	writeCommandMarkdown(&buffer, describe(serveCommand), testDocsDate)
+	md := buffer.String()
+	assert.Contains(t, md, "# golove serve\n\nRun an HTTP server which bridges another service to love.\n")
+	assert.Contains(t, md, "\n```\ngolove serve slack [-users file] [-trust-names]\n```\n")
+	assert.Contains(t, md, `"Authorization: Bearer \<token>"`)
+	assert.Contains(t, md, "\n### Options of proxy mode\n\n- `-ttl duration`: cache responses for duration (default `30s`)\n")
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+var doctorCommand = &command{
+	Name:    "doctor",
+	Args:    "",
+	Summary: "check the configuration and connection to love",
+	Long: `Diagnose the most common problems with golove: the configuration file and
+environment, reaching the server at the base URL, its TLS certificate, the API
+key, the local clock, and the sender. The result of each check is printed,
+along with how to fix any problem. Checks which depend on a failed check are
+skipped.`,
+	Run: runDoctor,
+}
+
+// How long doctor waits to connect to the server.
+const doctorTimeout = 10 * time.Second
+
+// The largest difference from the server's clock which is not reported.
+const maxClockSkew = time.Minute
+
+// Certificates expiring sooner than this are reported.
+const certificateWarning = 14 * 24 * time.Hour
+
+/*
+The outcome of a check by doctor. Fix suggests how to correct a failure.
+*/
+type diagnosis struct {
+	OK      bool
+	Message string
+	Fix     string
+}
+
+func (d *diagnosis) print() {
+	status := "ok  "
+	if !d.OK {
+		status = "FAIL"
+	}
+	fmt.Printf("%s  %s\n", status, d.Message)
+	if !d.OK && d.Fix != "" {
+		fmt.Printf("      fix: %s\n", d.Fix)
+	}
+}
+
+func pass(format string, args ...interface{}) *diagnosis {
+	return &diagnosis{OK: true, Message: fmt.Sprintf(format, args...)}
+}
+
+func runDoctor(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	failed := 0
+	report := func(d *diagnosis) bool {
+		d.print()
+		if !d.OK {
+			failed++
+		}
+		return d.OK
+	}
+
+	path := configPath()
+	values, err := readConfigFile(path)
+	report(checkConfigFile(path, err))
+	cfg := resolveConfig(path, values)
+	configured := true
+	for _, key := range configKeys[:3] {
+		if key.Name == "api_key" && (cfg.BearerToken != "" || cfg.AuthHeader != "") {
+			// Not needed with other credentials.
+			continue
+		}
+		if !report(checkSetting(key, cfg, values)) {
+			// The API can be checked without a sender.
+			configured = configured && key.Name == "sender"
+		}
+	}
+
+	if configured {
+		base, err := url.Parse(cfg.BaseUrl)
+		reachable := report(checkReachable(base, err))
+		if reachable && base.Scheme == "https" {
+			reachable = report(checkTLS(base))
+		}
+		if reachable {
+			client, err := cfg.client()
+			if err != nil {
+				return err
+			}
+			var date string
+			client.Middleware = append(client.Middleware, recordDate(&date))
+			if report(checkPing(client, cfg)) {
+				report(checkClock(date, time.Now()))
+				if cfg.Sender != "" {
+					report(checkSender(client, cfg.Sender))
+				}
+			}
+		}
+	}
+	if failed == 1 {
+		return errors.New("1 problem found")
+	} else if failed > 0 {
+		return fmt.Errorf("%d problems found", failed)
+	}
+	return nil
+}
+
+/*
+Check that the configuration file, if there is one, can be read and is private.
+*/
+func checkConfigFile(path string, readErr error) *diagnosis {
+	if readErr != nil {
+		return &diagnosis{
+			Message: fmt.Sprintf("the configuration file is invalid: %s", readErr),
+			Fix:     "each line should be key = \"value\" (or key: value in YAML)",
+		}
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return pass("no configuration file at %s", path)
+	} else if err != nil {
+		return &diagnosis{Message: err.Error()}
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return &diagnosis{
+			Message: fmt.Sprintf("%s is readable by other users, and may hold the API key",
+				path),
+			Fix: fmt.Sprintf("run \"chmod 600 %s\"", path),
+		}
+	}
+	return pass("read configuration file %s", path)
+}
+
+/*
+Check that a setting has a value, and report where it came from.
+*/
+func checkSetting(key configKey, cfg *config, values map[string]string) *diagnosis {
+	value := *key.field(cfg)
+	if value == "" {
+		return &diagnosis{
+			Message: key.Name + " is not configured",
+			Fix: fmt.Sprintf("set %s or run \"golove config set %s VALUE\"",
+				key.Env, key.Name),
+		}
+	}
+	shown := value
+	if key.Secret {
+		shown = "REDACTED"
+	}
+	if os.Getenv(key.Env) != "" {
+		if values[key.Name] != "" && values[key.Name] != value {
+			return pass("%s = %q, from %s, overriding the configuration file",
+				key.Name, shown, key.Env)
+		}
+		return pass("%s = %q, from %s", key.Name, shown, key.Env)
+	}
+	return pass("%s = %q, from the configuration file", key.Name, shown)
+}
+
+/*
+Check that the base URL is valid, and that its host accepts connections.
+*/
+func checkReachable(base *url.URL, parseErr error) *diagnosis {
+	fix := "the base URL should end in /api, without a trailing slash, " +
+		"like https://cwrulove.appspot.com/api"
+	if parseErr != nil || (base.Scheme != "http" && base.Scheme != "https") ||
+		base.Host == "" {
+		return &diagnosis{Message: "base_url is not an http or https URL", Fix: fix}
+	}
+	address := hostPort(base)
+	conn, err := net.DialTimeout("tcp", address, doctorTimeout)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return &diagnosis{
+				Message: fmt.Sprintf("the host %s does not exist", base.Hostname()),
+				Fix:     fix,
+			}
+		}
+		return &diagnosis{
+			Message: fmt.Sprintf("could not connect to %s: %s", address, err),
+			Fix:     "check your network connection, proxy and firewall, or try again later",
+		}
+	}
+	conn.Close()
+	return pass("connected to %s", address)
+}
+
+func hostPort(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+/*
+Check that the server's TLS certificate is valid for its host, and is not about
+to expire.
+*/
+func checkTLS(base *url.URL) *diagnosis {
+	dialer := &net.Dialer{Timeout: doctorTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", hostPort(base),
+		&tls.Config{ServerName: base.Hostname()})
+	if err != nil {
+		return &diagnosis{
+			Message: fmt.Sprintf("the TLS certificate of %s is not valid: %s",
+				base.Hostname(), err),
+			Fix: "check the host in the base URL; if it is right, the server's " +
+				"administrator must fix its certificate",
+		}
+	}
+	defer conn.Close()
+	expires := conn.ConnectionState().PeerCertificates[0].NotAfter
+	if time.Until(expires) < certificateWarning {
+		return &diagnosis{
+			Message: fmt.Sprintf("the TLS certificate of %s expires on %s",
+				base.Hostname(), expires.Format(dateLayout)),
+			Fix: "ask the server's administrator to renew its certificate",
+		}
+	}
+	return pass("the TLS certificate of %s is valid until %s", base.Hostname(),
+		expires.Format(dateLayout))
+}
+
+/*
+Check that the API accepts the base URL and API key.
+*/
+func checkPing(client *love.Client, cfg *config) *diagnosis {
+	err := client.Ping()
+	switch {
+	case err == nil:
+		return pass("the API accepts the base URL and API key")
+	case errors.Is(err, love.ErrUnauthorized) &&
+		(cfg.BearerToken != "" || cfg.AuthHeader != ""):
+		return &diagnosis{
+			Message: "the API rejected the configured credentials",
+			Fix:     "check the bearer_token or auth_header setting",
+		}
+	case errors.Is(err, love.ErrUnauthorized):
+		return &diagnosis{
+			Message: "the API rejected the API key",
+			Fix: "create a key under Admin > API Keys on the love site, and " +
+				"set LOVE_API_KEY or run \"golove config set api_key KEY\"",
+		}
+	case errors.Is(err, love.ErrBadBaseURL):
+		return &diagnosis{
+			Message: fmt.Sprintf("%s is not the love API: %s", cfg.BaseUrl, err),
+			Fix: "the base URL should end in /api, without a trailing slash, " +
+				"like https://cwrulove.appspot.com/api",
+		}
+	default:
+		return &diagnosis{
+			Message: fmt.Sprintf("the server could not be reached: %s", err),
+			Fix:     "check your network connection, or try again later",
+		}
+	}
+}
+
+/*
+Middleware which records the Date header of the last response.
+*/
+func recordDate(date *string) love.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return love.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			if err == nil {
+				*date = resp.Header.Get("Date")
+			}
+			return resp, err
+		})
+	}
+}
+
+/*
+Check that the local clock agrees with the server's, which matters when
+filtering love by time.
+*/
+func checkClock(date string, now time.Time) *diagnosis {
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return pass("the server did not send its time, so the clock was not checked")
+	}
+	skew := now.Sub(serverTime)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxClockSkew {
+		direction := "ahead of"
+		if now.Before(serverTime) {
+			direction = "behind"
+		}
+		return &diagnosis{
+			Message: fmt.Sprintf("the local clock is %s %s the server's",
+				skew.Round(time.Second), direction),
+			Fix: "enable automatic time synchronization (NTP) on this computer",
+		}
+	}
+	return pass("the local clock agrees with the server's")
+}
+
+/*
+Check that the sender is an existing user.
+*/
+func checkSender(client *love.Client, sender string) *diagnosis {
+	unknown, err := client.ValidateRecipients([]string{sender})
+	if err != nil {
+		return &diagnosis{Message: fmt.Sprintf("could not look up %s: %s", sender, err)}
+	}
+	if len(unknown) > 0 {
+		return &diagnosis{
+			Message: fmt.Sprintf("the sender %s is not a user", sender),
+			Fix:     "set LOVE_SENDER or run \"golove config set sender USERNAME\"",
+		}
+	}
+	return pass("the sender %s is a user", sender)
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"github.com/hacsoc/golove/love"
+	"io"
+	"os"
+)
+
+var exportCommand = &command{
+	Name:    "export",
+	Args:    "[-user user] [-sent | -received] [-since date] [-until date] [-anonymize [-strip-messages]] [-format format]",
+	Summary: "write the full love history of a user as CSV or JSON",
+	Long: `Write every love sent and received by a user (the configured sender by
+default), newest first, to stdout. Love is written as it is fetched, so
+exporting a long history does not hold it all in memory. For example:
+
+	golove export -user darwin -since 2023-01-01 -format csv > love.csv
+
+The columns are always timestamp, sender, recipient and message, in that order.
+The formats are csv (with a header row), json (an array of objects) and jsonl
+(one object per line).
+
+With -anonymize, usernames are replaced by pseudonyms, such as
+user-3f2a9c01b7de, so that how much love is sent can be shared with leadership
+or researchers without showing who sent it, including usernames mentioned in
+messages. A username always has the same pseudonym under the same key, which
+is the anonymize_key setting (LOVE_ANONYMIZE_KEY); keep it secret, since anyone
+with it can check a guessed username against a pseudonym. Messages may still
+name people in other ways, so add -strip-messages to leave them empty.`,
+	Flags: defineExportFlags,
+	Run:   runExport,
+}
+
+// The flags of golove export.
+var exportFlags struct {
+	user          string
+	sentOnly      bool
+	receivedOnly  bool
+	since, until  dateFlag
+	anonymize     bool
+	stripMessages bool
+	format        string
+}
+
+func defineExportFlags(flags *flag.FlagSet) {
+	flags.StringVar(&exportFlags.user, "user", "", "export love of `user` (default the configured sender)")
+	flags.BoolVar(&exportFlags.sentOnly, "sent", false, "only export love sent by the user")
+	flags.BoolVar(&exportFlags.receivedOnly, "received", false, "only export love received by the user")
+	exportFlags.since, exportFlags.until = dateFlag{}, dateFlag{}
+	flags.Var(&exportFlags.since, "since", "only export love sent on or after `date` (YYYY-MM-DD)")
+	flags.Var(&exportFlags.until, "until", "only export love sent before `date` (YYYY-MM-DD)")
+	flags.BoolVar(&exportFlags.anonymize, "anonymize", false, "replace usernames with pseudonyms")
+	flags.BoolVar(&exportFlags.stripMessages, "strip-messages", false,
+		"with -anonymize, leave messages empty")
+	flags.StringVar(&exportFlags.format, "format", "csv", "output `format`: csv, json or jsonl")
+}
+
+func runExport(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	if exportFlags.sentOnly && exportFlags.receivedOnly {
+		return usagef("-sent and -received cannot be combined")
+	}
+	if exportFlags.stripMessages && !exportFlags.anonymize {
+		return usagef("-strip-messages requires -anonymize")
+	}
+	out := bufio.NewWriter(os.Stdout)
+	writer := newLoveWriter(out, exportFlags.format)
+	if writer == nil {
+		return usagef("unknown format %q", exportFlags.format)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	if exportFlags.user == "" {
+		if exportFlags.user, err = cfg.sender(); err != nil {
+			return err
+		}
+	}
+	write := writer.Write
+	if exportFlags.anonymize {
+		if cfg.AnonymizeKey == "" {
+			return cfg.missing("anonymize_key")
+		}
+		anonymizer := &love.Anonymizer{Key: []byte(cfg.AnonymizeKey), StripMessages: exportFlags.stripMessages}
+		write = func(l love.Love) error {
+			return writer.Write(anonymizer.Anonymize(l))
+		}
+	}
+
+	filter := love.LoveFilter{Since: exportFlags.since.Time, Until: exportFlags.until.Time}
+	var sent, received *love.LoveIterator
+	if !exportFlags.receivedOnly {
+		f := filter
+		f.Sender = exportFlags.user
+		sent = client.IterLoveFiltered(f)
+	}
+	if !exportFlags.sentOnly {
+		f := filter
+		f.Recipient = exportFlags.user
+		received = client.IterLoveFiltered(f)
+	}
+	err = mergeLove(sent, received, write)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+/*
+Call write with the love from two iterators, either of which may be nil, newest
+first. Each iterator returns love newest first, so they are merged as they go.
+*/
+func mergeLove(a, b *love.LoveIterator, write func(love.Love) error) error {
+	next := func(it *love.LoveIterator) (*love.Love, error) {
+		if it == nil || !it.Next() {
+			if it != nil {
+				return nil, it.Err()
+			}
+			return nil, nil
+		}
+		l := it.Love()
+		return &l, nil
+	}
+	la, err := next(a)
+	if err != nil {
+		return err
+	}
+	lb, err := next(b)
+	if err != nil {
+		return err
+	}
+	for la != nil || lb != nil {
+		if lb == nil || (la != nil && !la.Timestamp.Before(lb.Timestamp)) {
+			if err := write(*la); err != nil {
+				return err
+			}
+			if la, err = next(a); err != nil {
+				return err
+			}
+		} else {
+			if err := write(*lb); err != nil {
+				return err
+			}
+			if lb, err = next(b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+/*
+Writes love one at a time in a structured format. Close finishes the output, and
+must be called even if no love was written.
+*/
+type loveWriter interface {
+	Write(l love.Love) error
+	Close() error
+}
+
+/*
+Return a loveWriter for a format, or nil if the format is unknown.
+*/
+func newLoveWriter(w io.Writer, format string) loveWriter {
+	switch format {
+	case "csv":
+		return &csvLoveWriter{writer: csv.NewWriter(w)}
+	case "json":
+		return &jsonLoveWriter{w: w}
+	case "jsonl":
+		return &jsonlLoveWriter{encoder: json.NewEncoder(w)}
+	}
+	return nil
+}
+
+type csvLoveWriter struct {
+	writer *csv.Writer
+	header bool
+}
+
+func (c *csvLoveWriter) Write(l love.Love) error {
+	if !c.header {
+		c.writer.Write(loveColumns)
+		c.header = true
+	}
+	c.writer.Write(loveRow(l))
+	return c.writer.Error()
+}
+
+func (c *csvLoveWriter) Close() error {
+	if !c.header {
+		c.writer.Write(loveColumns)
+	}
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type jsonLoveWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonLoveWriter) Write(l love.Love) error {
+	data, err := json.MarshalIndent(object{loveColumns, loveRow(l)}, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	separator := ",\n  "
+	if j.count == 0 {
+		separator = "[\n  "
+	}
+	j.count++
+	if _, err := io.WriteString(j.w, separator); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonLoveWriter) Close() error {
+	if j.count == 0 {
+		_, err := io.WriteString(j.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(j.w, "\n]\n")
+	return err
+}
+
+type jsonlLoveWriter struct {
+	encoder *json.Encoder
+}
+
+func (j *jsonlLoveWriter) Write(l love.Love) error {
+	return j.encoder.Encode(object{loveColumns, loveRow(l)})
+}
+
+func (j *jsonlLoveWriter) Close() error {
+	return nil
+}
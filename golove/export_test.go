@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"github.com/hacsoc/golove/love"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLoveWriter(t *testing.T) {
+	loves := []love.Love{
+		{Sender: "hammy", Recipient: "darwin", Message: "Thanks!",
+			Timestamp: time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)},
+		{Sender: "darwin", Recipient: "hammy", Message: "Thanks, \"hammy\"\nfor everything",
+			Timestamp: time.Date(2026, time.October, 15, 9, 30, 0, 0, time.UTC)},
+	}
+	for _, test := range []struct {
+		format   string
+		loves    []love.Love
+		expected string
+	}{
+		{"csv", nil, "timestamp,sender,recipient,message\n"},
+		{"csv", loves, "timestamp,sender,recipient,message\n" +
+			"2026-10-16T12:00:00,hammy,darwin,Thanks!\n" +
+			"2026-10-15T09:30:00,darwin,hammy,\"Thanks, \"\"hammy\"\"\nfor everything\"\n"},
+		{"json", nil, "[]\n"},
+		{"json", loves[:1], `[
+  {
+    "timestamp": "2026-10-16T12:00:00",
+    "sender": "hammy",
+    "recipient": "darwin",
+    "message": "Thanks!"
+  }
+]
+`},
+		{"json", loves, `[
+  {
+    "timestamp": "2026-10-16T12:00:00",
+    "sender": "hammy",
+    "recipient": "darwin",
+    "message": "Thanks!"
+  },
+  {
+    "timestamp": "2026-10-15T09:30:00",
+    "sender": "darwin",
+    "recipient": "hammy",
+    "message": "Thanks, \"hammy\"\nfor everything"
+  }
+]
+`},
+		{"jsonl", nil, ""},
+		{"jsonl", loves,
+			`{"timestamp":"2026-10-16T12:00:00","sender":"hammy","recipient":"darwin","message":"Thanks!"}` + "\n" +
+				`{"timestamp":"2026-10-15T09:30:00","sender":"darwin","recipient":"hammy",` +
+				`"message":"Thanks, \"hammy\"\nfor everything"}` + "\n"},
+	} {
+		var buffer bytes.Buffer
+		writer := newLoveWriter(&buffer, test.format)
+		if !assert.NotNil(t, writer, test.format) {
+			continue
+		}
+		for _, l := range test.loves {
+			assert.NoError(t, writer.Write(l), test.format)
+		}
+		assert.NoError(t, writer.Close(), test.format)
+		assert.Equal(t, buffer.String(), test.expected, test.format)
+	}
+	assert.Nil(t, newLoveWriter(&bytes.Buffer{}, "xml"))
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/store"
+	"os"
+	"os/signal"
+	"time"
+)
+
+var flushCommand = &command{
+	Name:    "flush",
+	Args:    "[-db path] [-list] [-every duration [-metrics address]]",
+	Summary: "send love queued by \"golove send -queue\"",
+	Long: `Try to send every love in the queue. Love which fails because the API still
+cannot be reached stays queued; love which fails for any other reason is
+dropped from the queue and reported. Love which the API received even though
+sending it seemed to fail, such as by timing out, is found among the sender's
+recent love and not sent again. With -list, the queue is printed instead.
+
+With -every, golove keeps running, and flushes the queue every duration until
+interrupted. Love which keeps failing is retried less often, up to once an hour.
+With -metrics, Prometheus metrics about the requests made to the love API are
+served at /metrics on the address meanwhile.`,
+	Flags: defineFlushFlags,
+	Run:   runFlush,
+}
+
+// The flags of golove flush.
+var flushFlags struct {
+	path        string
+	list        bool
+	every       time.Duration
+	metricsAddr string
+}
+
+func defineFlushFlags(flags *flag.FlagSet) {
+	flags.StringVar(&flushFlags.path, "db", store.DefaultPath(), "the local database `path`")
+	flags.BoolVar(&flushFlags.list, "list", false, "list the queued love instead of sending it")
+	flags.DurationVar(&flushFlags.every, "every", 0, "flush the queue every `duration` until interrupted")
+	flags.StringVar(&flushFlags.metricsAddr, "metrics", "",
+		"serve metrics at /metrics on `address`, with -every")
+}
+
+func runFlush(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	if flushFlags.every < 0 {
+		return usagef("the duration must be positive")
+	}
+	if flushFlags.metricsAddr != "" && flushFlags.every == 0 {
+		return usagef("-metrics requires -every")
+	}
+	if flushFlags.list {
+		return listQueue(flushFlags.path)
+	}
+	if flushFlags.every == 0 {
+		failed, err := flushQueue(flushFlags.path, true)
+		if err == nil && failed > 0 {
+			err = fmt.Errorf("%d love could not be sent", failed)
+		}
+		return err
+	}
+
+	if flushFlags.metricsAddr != "" {
+		serveMetrics(flushFlags.metricsAddr)
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	ticker := time.NewTicker(flushFlags.every)
+	defer ticker.Stop()
+	for {
+		// The database is only held open while flushing, so that other
+		// commands may use it in between.
+		if _, err := flushQueue(flushFlags.path, false); err != nil {
+			fmt.Fprintf(os.Stderr, "golove flush: %s\n", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-interrupt:
+			return nil
+		}
+	}
+}
+
+func listQueue(path string) error {
+	db, err := store.Open(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	pending, err := db.Pending()
+	if err != nil {
+		return err
+	}
+	for _, p := range pending {
+		fmt.Printf("%s  %s -> %s: %s\n", p.Queued.Local().Format(dateLayout+" 15:04"),
+			p.Sender, p.Recipient, p.Message)
+		if p.Attempts > 0 {
+			fmt.Printf("\t%d failed attempts, last: %s\n", p.Attempts, p.LastError)
+		}
+	}
+	return nil
+}
+
+/*
+Flush the queue once, printing the outcome, and return the number of love which
+failed permanently.
+*/
+func flushQueue(path string, force bool) (int, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return 0, err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return 0, err
+	}
+	db, err := store.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	client.SendRecords = db
+	result, err := db.Flush(client, force)
+	if result != nil {
+		for _, p := range result.Sent {
+			fmt.Printf("Love sent to %s!\n", p.Recipient)
+		}
+		for _, p := range result.Failed {
+			fmt.Fprintf(os.Stderr, "golove flush: love to %s dropped: %s\n",
+				p.Recipient, p.LastError)
+		}
+		if result.Deferred > 0 && force {
+			fmt.Printf("%d love still queued\n", result.Deferred)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(result.Failed), nil
+}
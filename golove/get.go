@@ -0,0 +1,174 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"io"
+	"os"
+)
+
+var getCommand = &command{
+	Name:    "get",
+	Args:    "[-from user] [-to user] [-team file] [-tag tag] [-value value] [-limit n] [-all] [-names] [-absolute] [-output format]",
+	Summary: "list love sent from or to a user",
+	Long: `List love, newest first. With neither -from nor -to, love received by the
+configured sender is listed. With -tag, only love whose message is tagged with
+#tag is listed; since the server cannot filter by tag, pages of love are fetched
+until -limit have been found. Similarly, with -value, only love tagged with the
+company value is listed.
+
+With -team, love sent or received by any of the users in the file, one per line,
+is listed, fetching the love of several users at once. -limit applies to the
+merged list.
+
+With -names, senders and recipients are shown by their full names in the text
+format, on instances which provide user profiles.
+
+The text format says when love was sent relative to now, such as "3 hours ago"
+or "last Tuesday". With -absolute, the date and time are shown instead. Either
+way, times are in the local time zone.
+
+In the text format, love is numbered, so that it can be replied to with
+"golove reply n" until love is next listed.
+
+On a terminal, love which does not fit on the screen is shown through the pager,
+$GOLOVE_PAGER or $PAGER (less by default), unless "golove -no-pager get" is
+used.`,
+	Flags: defineGetFlags,
+	Run:   runGet,
+}
+
+// The flags of golove get.
+var getFlags struct {
+	from     string
+	to       string
+	team     string
+	tag      string
+	value    string
+	limit    int64
+	all      bool
+	names    bool
+	absolute bool
+	output   string
+}
+
+func defineGetFlags(flags *flag.FlagSet) {
+	flags.StringVar(&getFlags.from, "from", "", "only list love sent by `user`")
+	flags.StringVar(&getFlags.to, "to", "", "only list love received by `user`")
+	flags.StringVar(&getFlags.team, "team", "", "list love sent or received by the users in `file`")
+	flags.StringVar(&getFlags.tag, "tag", "", "only list love tagged with #`tag`")
+	flags.StringVar(&getFlags.value, "value", "", "only list love tagged with the company `value`")
+	flags.Int64Var(&getFlags.limit, "limit", 20, "list at most `n` love")
+	flags.BoolVar(&getFlags.all, "all", false, "list every love, ignoring -limit")
+	flags.BoolVar(&getFlags.names, "names", false,
+		"show the full names of users, if the instance has profiles")
+	flags.BoolVar(&getFlags.absolute, "absolute", false,
+		"show the date and time love was sent, rather than how long ago")
+	addOutputFlag(flags, &getFlags.output)
+}
+
+func runGet(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(getFlags.output); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	if getFlags.team != "" && (getFlags.from != "" || getFlags.to != "") {
+		return usagef("-team cannot be used with -from or -to")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	if getFlags.team != "" {
+		return getTeam(client, getFlags.team, getFlags.tag, getFlags.value, getFlags.limit,
+			getFlags.all, getFlags.names, getFlags.absolute, getFlags.output)
+	}
+	if getFlags.from == "" && getFlags.to == "" {
+		if getFlags.to, err = cfg.sender(); err != nil {
+			return err
+		}
+	}
+	var loves []love.Love
+	switch {
+	case getFlags.tag != "" || getFlags.value != "":
+		f := love.LoveFilter{Sender: getFlags.from, Recipient: getFlags.to, Limit: getFlags.limit,
+			Tag: getFlags.tag, Value: getFlags.value}
+		if getFlags.all {
+			f.Limit = 0
+		}
+		loves, err = client.GetLoveFiltered(f)
+	case getFlags.all:
+		loves, err = client.GetLoveAll(getFlags.from, getFlags.to)
+	default:
+		loves, err = client.GetLove(getFlags.from, getFlags.to, getFlags.limit)
+	}
+	if err != nil {
+		return err
+	}
+	return writeLoves(client, loves, getFlags.names, getFlags.absolute, getFlags.output)
+}
+
+/*
+List the love sent or received by the users in a file. Users whose love could
+not be fetched are reported, and the love of the others is still listed.
+*/
+func getTeam(client *love.Client, path, tag, value string, limit int64, all, names,
+	absolute bool, output string) error {
+	users, err := readList(path)
+	if err != nil {
+		return err
+	}
+	f := love.LoveFilter{Limit: limit, Tag: tag, Value: value}
+	if all {
+		f.Limit = 0
+	}
+	loves, errs := client.GetLoveForUsers(users, f, 0)
+	if f.Limit > 0 && int64(len(loves)) > f.Limit {
+		loves = loves[:f.Limit]
+	}
+	if err := writeLoves(client, loves, names, absolute, output); err != nil {
+		return err
+	}
+	failed := 0
+	for _, user := range users {
+		if err := errs[user]; err != nil {
+			fmt.Fprintf(os.Stderr, "golove get: %s: %s\n", user, err)
+			delete(errs, user)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("could not fetch the love of %d users", failed)
+	}
+	return nil
+}
+
+func writeLoves(client *love.Client, loves []love.Love, names, absolute bool,
+	output string) error {
+	records := loveRecords(loves)
+	var profiles map[string]*love.Profile
+	if names {
+		profiles = lookupProfiles(client, loveUsers(loves))
+	}
+	text := loveText{Profiles: profiles, Relative: !absolute, Style: fileStyle(os.Stdout)}
+	records.Text = func(w io.Writer, i int) {
+		numbered := text
+		numbered.Number = i + 1
+		numbered.write(w, loves[i])
+	}
+	saveListing(client, loves)
+	return page(func(w io.Writer) error {
+		return records.write(w, output)
+	})
+}
@@ -1,52 +1,367 @@
 /*
-A command-line application for sending love. Usage is as follows:
+A command-line client for Yelp Love. Usage is as follows:
 
-	golove recipient[,recipient...] message
+	golove [-debug] [-verbose] [-no-color] [-no-pager] command [arguments]
 
-The message may be multiple command line arguments - they will be joined into a
-single string with a space separator.
+The commands are:
 
-In order for this program to work, the following environment variables must be
-set. LOVE_API_KEY must contain a valid API key. API keys may be generated by
-administrators on their love instance. Select "API Keys" from the Admin
-dropdown, type a description, and hit "Add".
+	send          send love to one or more recipients
+	send-batch    send love for each row of a CSV file
+	reply         reply to love listed by golove get
+	flush         send love queued by "golove send -queue"
+	schedule      schedule love to send later
+	scheduler     send scheduled love when it is due
+	calendar      schedule love for birthdays and anniversaries
+	get           list love sent from or to a user
+	search        search the messages of love
+	between       list the love two users sent each other
+	tui           browse love history in a terminal UI
+	export        write the full love history of a user as CSV or JSON
+	import        send the love in a file written by "golove export"
+	stats         summarize the love sent and received by a user
+	leaderboard   rank the top senders and recipients of love
+	remind        suggest who to send love to
+	pair          assign each user a secret target to appreciate
+	ci            thank the people behind a merged pull request or fixed build
+	graph         write a graph of who sent love to whom
+	digest        email a summary of the love received
+	report        write an HTML love wall or Markdown report
+	words         count the words used in love, for a word cloud
+	sync          copy love history into the local database
+	watch         print new love as it arrives
+	serve         run an HTTP server which bridges another service to love
+	slack-bot     post love to a Slack channel, and answer slash commands
+	autocomplete  look up usernames matching a term
+	alias         map Slack IDs, emails and other identities to usernames
+	group         manage named groups of recipients, such as teams
+	audit         show the love this machine has tried to send
+	whoami        show the configured sender
+	doctor        check the configuration and connection to love
+	config        read and write the configuration file
+	completion    print a shell completion script
+	docs          write man pages or a Markdown reference
+	version       print the version of golove
+	self-update   replace golove with its latest release
+	help          show help for a command
 
-LOVE_BASE_URL must be set to the base URL of the love API. This should include
-the "api" part of the URL, but not the trailing slash. For example:
-https://cwrulove.appspot.com/api.
+Run "golove help command" for the arguments and flags of each command. For
+compatibility with earlier versions, "golove recipient[,recipient...] message"
+is the same as "golove send recipient[,recipient...] message".
 
-Finally, the LOVE_SENDER environment variable must be sent to a username, which
-will be used as the sender of your love.
+In order for this program to work, three settings are required. Each may be
+given in an environment variable, or stored in the configuration file with
+"golove config set key value". Environment variables take precedence.
 
-In future versions, these environment variables will most likely be replaced by
-a configuration file. Also, in the future, hopefully user-specific API keys will
-be available so that non-administrators can send love using the API.
+The api_key setting (LOVE_API_KEY) must contain a valid API key. API keys may be
+generated by administrators on their love instance. Select "API Keys" from the
+Admin dropdown, type a description, and hit "Add". The key is sent in the URL
+of each request, as Yelp Love expects, where it may be recorded in the logs of
+servers and proxies. If the instance accepts the key in a header, set
+api_key_header (LOVE_API_KEY_HEADER) to the name of the header, such as
+X-Api-Key, to send it there instead.
+
+The base_url setting (LOVE_BASE_URL) must be set to the base URL of the love
+API. This should include the "api" part of the URL, but not the trailing slash.
+For example: https://cwrulove.appspot.com/api. If the instance has other
+addresses, such as a custom domain, they may be listed in the fallback_urls
+setting (LOVE_FALLBACK_URLS), separated by commas, and requests are sent to
+them while the base URL cannot be reached. The circuit_breaker setting
+(LOVE_CIRCUIT_BREAKER) is a number of consecutive failed requests, after which
+golove stops making requests for 30 seconds, so that bots do not hammer an
+instance which is down.
+
+Instances behind an authenticating proxy may need other credentials instead
+of an API key: either a token sent in the Authorization header, in the
+bearer_token setting (LOVE_BEARER_TOKEN), or a custom header, in the
+auth_header setting (LOVE_AUTH_HEADER), such as "X-Auth-Token: secret".
+
+Finally, the sender setting (LOVE_SENDER) must be set to a username, which will
+be used as the sender of your love.
+
+The configuration file is ~/.config/golove/config.toml (or config.yaml), or the
+path in LOVE_CONFIG. It holds one setting per line:
+
+	api_key = "..."
+	base_url = "https://cwrulove.appspot.com/api"
+	sender = "hammy"
+
+People's identities in other services, such as Slack user IDs and email
+addresses, are mapped to their love usernames by the aliases file, which is the
+aliases setting (LOVE_ALIASES), or aliases.json beside the configuration file.
+See "golove help alias". Named groups of recipients, such as teams, are kept
+in the groups file, the groups setting (LOVE_GROUPS) or groups.json beside the
+configuration file, so that love for @backend-team reaches every member. See
+"golove help group".
+
+Love may be checked before it is sent. The max_message_length setting
+(LOVE_MAX_MESSAGE_LENGTH) limits the length of messages, and blocked_words
+(LOVE_BLOCKED_WORDS) is the path of a file of words, one per line, which
+messages may not contain. See "golove help send" for the limits on recipients.
+Emoji shortcodes, such as :tada:, are sent as the emoji they stand for, unless
+keep_shortcodes (LOVE_KEEP_SHORTCODES) is true.
+
+Since an API key can send love as anyone, every attempt to send love, by any
+command, is recorded in an audit log; see "golove help audit". If lock_sender
+(LOVE_LOCK_SENDER) is true, golove refuses to send love as anyone but the
+sender, so commands which send as others, such as "golove import" and "golove
+serve", fail. Set LOVE_LOCK_SENDER=false for a single command to allow it.
+
+Requests identify golove to the instance with a User-Agent header naming its
+version and the command, such as "golove/0.2.0 (slack-bot)". Bots and scripts
+can identify themselves further with the user_agent setting (LOVE_USER_AGENT),
+such as "team-bot/1.0 (ops@example.com)", which is sent before it.
+
+The -debug flag prints the details of every request made to the API, with the
+API key redacted, to stderr. The key is also redacted from error messages. The
+-verbose flag logs the endpoint, status and duration of every request to stderr,
+in the key=value format of log/slog.
+
+On a terminal, love is shown in color, with messages wrapped to the width of
+the terminal and emoji shortcodes such as :tada: rendered. The -no-color flag,
+or setting NO_COLOR, turns off the color. Output to a file or pipe is plain.
+
+Long lists of love, such as the output of "golove get -all", are shown through
+a pager when they do not fit on the terminal, like "git log". The pager is
+GOLOVE_PAGER, or PAGER, or less by default; setting GOLOVE_PAGER to an empty
+string, or giving the -no-pager flag, turns it off.
+
+golove exits with status 0 on success, 1 when a request fails, and 2 when it is
+invoked incorrectly.
+
+In the future, hopefully user-specific API keys will be available so that
+non-administrators can send love using the API.
 */
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"github.com/hacsoc/golove/love"
 	"os"
 	"strings"
 )
 
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+/*
+A subcommand of golove. Run receives the arguments following the command name.
+Hidden commands are used by golove itself, and are not listed in the usage.
+*/
+type command struct {
+	Name    string
+	Args    string
+	Summary string
+	// A description of the command, shown by "golove help".
+	Long   string
+	Hidden bool
+	// Defines the flags of the command, if it has any.
+	Flags func(flags *flag.FlagSet)
+	Run   func(cmd *command, args []string) error
+}
+
+/*
+Return a FlagSet for the command, with its flags defined, which prints the
+command's usage on error.
+*/
+func (cmd *command) flagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	flags.Usage = func() {
+		cmd.usage(flags)
+	}
+	if cmd.Flags != nil {
+		cmd.Flags(flags)
+	}
+	return flags
+}
+
+func (cmd *command) usage(flags *flag.FlagSet) {
+	fmt.Fprintf(os.Stderr, "usage: golove %s %s\n\n%s\n", cmd.Name, cmd.Args,
+		cmd.Summary)
+	if flags != nil {
+		fmt.Fprintln(os.Stderr)
+		flags.PrintDefaults()
+	}
+}
+
+/*
+A usageError indicates that golove was invoked incorrectly.
+*/
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{fmt.Sprintf(format, args...)}
+}
+
+// Returned when the flag package has already reported a usage error.
+var errFlagUsage = errors.New("invalid flags")
+
+/*
+Parse the flags of a command. Errors other than a request for help have already
+been printed along with the usage, and are replaced by errFlagUsage.
+*/
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil && err != flag.ErrHelp {
+		return errFlagUsage
+	}
+	return err
+}
+
+/*
+Report whether the arguments of a command which takes a subcommand before its
+flags, such as "golove audit show", ask for help instead.
+*/
+func helpRequested(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "-h", "-help", "--h", "--help":
+		return true
+	}
+	return false
+}
+
+var commands []*command
+
+// The command golove is running.
+var running *command
+
+func init() {
+	commands = []*command{
+		sendCommand,
+		sendBatchCommand,
+		replyCommand,
+		flushCommand,
+		scheduleCommand,
+		schedulerCommand,
+		calendarCommand,
+		getCommand,
+		searchCommand,
+		betweenCommand,
+		tuiCommand,
+		exportCommand,
+		importCommand,
+		statsCommand,
+		leaderboardCommand,
+		remindCommand,
+		pairCommand,
+		ciCommand,
+		graphCommand,
+		digestCommand,
+		reportCommand,
+		wordsCommand,
+		syncCommand,
+		watchCommand,
+		serveCommand,
+		slackBotCommand,
+		autocompleteCommand,
+		aliasCommand,
+		groupCommand,
+		auditCommand,
+		whoamiCommand,
+		doctorCommand,
+		configCommand,
+		completionCommand,
+		completeCommand,
+		docsCommand,
+		versionCommand,
+		selfUpdateCommand,
+		helpCommand,
+	}
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func mainUsage() {
+	fmt.Fprint(os.Stderr, "usage: golove [-debug] [-verbose] [-no-color] [-no-pager] command [arguments]\n\nThe commands are:\n\n")
+	for _, cmd := range commands {
+		if cmd.Hidden {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "\t%-14s%s\n", cmd.Name, cmd.Summary)
+	}
+	fmt.Fprint(os.Stderr, "\nThe -debug flag prints each request made, with the API key redacted.\n")
+	fmt.Fprint(os.Stderr, "The -verbose flag logs the endpoint, status and duration of each request.\n")
+	fmt.Fprint(os.Stderr, "The -no-color flag turns off colored output, and -no-pager the pager.\n")
+	fmt.Fprintln(os.Stderr, "\nRun \"golove help command\" for more information.")
+}
+
 func main() {
-	api_key := os.Getenv("LOVE_API_KEY")
-	base_url := os.Getenv("LOVE_BASE_URL")
-	sender := os.Getenv("LOVE_SENDER")
-	fmt.Println(api_key)
-	if len(os.Args) < 3 {
-		fmt.Println("usage: golove recipient[,recipient] message")
-		return
-	}
-	recipient := os.Args[1]
-	message := strings.Join(os.Args[2:], " ")
-	client := love.NewClient(api_key, base_url)
-	err := client.SendLove(sender, recipient, message)
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Printf("Love sent to %s!", recipient)
+	os.Exit(run(os.Args[1:]))
+}
+
+/*
+Return the flags given before the command.
+*/
+func globalFlags() *flag.FlagSet {
+	global := flag.NewFlagSet("golove", flag.ContinueOnError)
+	global.Usage = mainUsage
+	global.BoolVar(&debug, "debug", false, "print each request made, with the API key redacted, to stderr")
+	global.BoolVar(&verbose, "verbose", false, "log the endpoint, status and duration of each request to stderr")
+	global.BoolVar(&noColor, "no-color", false, "turn off colored output")
+	global.BoolVar(&noPager, "no-pager", false, "do not show long output through a pager")
+	return global
+}
+
+/*
+Run golove with the given arguments, returning the exit status.
+*/
+func run(args []string) int {
+	global := globalFlags()
+	if err := global.Parse(args); err == flag.ErrHelp {
+		return exitOK
+	} else if err != nil {
+		return exitUsage
+	}
+	args = global.Args()
+	if len(args) == 0 {
+		mainUsage()
+		return exitUsage
+	}
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		if len(args) < 2 || strings.HasPrefix(args[0], "-") {
+			fmt.Fprintf(os.Stderr, "golove: unknown command %q\n", args[0])
+			mainUsage()
+			return exitUsage
+		}
+		// golove recipient message
+		cmd, args = sendCommand, append([]string{"send"}, args...)
+	}
+	running = cmd
+	err := cmd.Run(cmd, args[1:])
+	var usageErr *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errFlagUsage):
+		return exitUsage
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(os.Stderr, "golove %s: %s\n", cmd.Name, err)
+		cmd.usage(nil)
+		return exitUsage
+	default:
+		fmt.Fprintf(os.Stderr, "golove %s: %s\n", cmd.Name, err)
+		return exitError
 	}
 }
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/*
+Point golove at server, with a configuration file, home and data directory of
+its own, and none of the settings of whoever runs the tests.
+*/
+func setTestEnv(t *testing.T, server *lovetest.Server) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, ".local", "share"))
+	t.Setenv("LOVE_CONFIG", filepath.Join(home, "config.toml"))
+	for _, key := range configKeys {
+		t.Setenv(key.Env, "")
+	}
+	t.Setenv("LOVE_AUDIT_LOG", "off")
+	t.Setenv("NO_COLOR", "1")
+	t.Setenv("GOLOVE_PAGER", "")
+	if server != nil {
+		t.Setenv("LOVE_API_KEY", server.ApiKey)
+		t.Setenv("LOVE_BASE_URL", server.BaseUrl())
+		t.Setenv("LOVE_SENDER", "hammy")
+	}
+}
+
+/*
+Run golove with the given arguments, returning its exit status and what it
+printed to stdout and stderr.
+*/
+func runGolove(t *testing.T, args ...string) (int, string, string) {
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+	savedStdout, savedStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	status := run(args)
+	os.Stdout, os.Stderr = savedStdout, savedStderr
+	out, err := ioutil.ReadFile(stdout.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	errOut, err := ioutil.ReadFile(stderr.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return status, string(out), string(errOut)
+}
+
+func newTestServer(t *testing.T) *lovetest.Server {
+	server := lovetest.NewServer("secret")
+	t.Cleanup(server.Close)
+	server.AddUser("hammy", "Hammy Hamster")
+	server.AddUser("darwin", "Darwin Dog")
+	setTestEnv(t, server)
+	return server
+}
+
+func TestRunExitStatus(t *testing.T) {
+	for _, test := range []struct {
+		args   []string
+		status int
+	}{
+		{nil, exitUsage},
+		{[]string{"-h"}, exitOK},
+		{[]string{"-bogus"}, exitUsage},
+		{[]string{"bogus"}, exitUsage},
+		{[]string{"help"}, exitOK},
+		{[]string{"help", "send"}, exitOK},
+		{[]string{"help", "bogus"}, exitUsage},
+		{[]string{"help", "-h"}, exitOK},
+		{[]string{"send"}, exitUsage},
+		{[]string{"send", "-h"}, exitOK},
+		{[]string{"send", "-bogus", "darwin", "Thanks!"}, exitUsage},
+		{[]string{"get", "-output", "xml"}, exitUsage},
+		{[]string{"get", "-o", "{{.Sender"}, exitUsage},
+		{[]string{"serve", "bogus"}, exitUsage},
+		{[]string{"serve", "feed", "-h"}, exitOK},
+		{[]string{"audit", "-h"}, exitOK},
+		{[]string{"audit", "-help"}, exitOK},
+		{[]string{"audit"}, exitUsage},
+		{[]string{"audit", "show", "-h"}, exitOK},
+		{[]string{"scheduler", "-h"}, exitOK},
+		{[]string{"scheduler", "--help"}, exitOK},
+		{[]string{"scheduler"}, exitUsage},
+		{[]string{"scheduler", "run", "-h"}, exitOK},
+		{[]string{"config", "path"}, exitOK},
+		{[]string{"send", "darwin", "Thanks!"}, exitOK},
+		{[]string{"darwin", "Thanks!"}, exitOK},
+		{[]string{"send", "nobody", "Thanks!"}, exitError},
+		{[]string{"get", "-from", "hammy"}, exitOK},
+	} {
+		newTestServer(t)
+		status, _, _ := runGolove(t, test.args...)
+		assert.Equal(t, status, test.status, "%q", test.args)
+	}
+}
+
+func TestRunSend(t *testing.T) {
+	server := newTestServer(t)
+	status, _, stderr := runGolove(t, "send", "darwin", "Thanks", "for", "the", "help!")
+	assert.Equal(t, status, exitOK, stderr)
+	loves := server.Loves()
+	if assert.Len(t, loves, 1) {
+		assert.Equal(t, loves[0].Sender, "hammy")
+		assert.Equal(t, loves[0].Recipient, "darwin")
+		assert.Equal(t, loves[0].Message, "Thanks for the help!")
+	}
+}
+
+func TestRunError(t *testing.T) {
+	newTestServer(t)
+	t.Setenv("LOVE_API_KEY", "wrong")
+	status, stdout, stderr := runGolove(t, "get", "-from", "hammy")
+	assert.Equal(t, status, exitError)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "golove get: ")
+	assert.NotContains(t, stderr, "wrong")
+}
+
+func TestRunUsageError(t *testing.T) {
+	setTestEnv(t, nil)
+	status, stdout, stderr := runGolove(t, "send")
+	assert.Equal(t, status, exitUsage)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "usage: golove send ")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"github.com/hacsoc/golove/love"
+	"os"
+)
+
+var graphCommand = &command{
+	Name:    "graph",
+	Args:    "[-user user] [-team] [-since date] [-until date] [-format format]",
+	Summary: "write a graph of who sent love to whom",
+	Long: `Write a directed graph of who sent love to whom to stdout, with an edge from
+each sender to each of their recipients, weighted by the number of love. The
+graph covers the love sent and received by each user (the configured sender by
+default; -user may be repeated). With -team, only love sent between the users
+is included, which shows how a team appreciates itself.
+
+The formats are dot, for Graphviz, and graphml, for tools such as Gephi. For
+example:
+
+	golove graph -user hammy -user darwin -user jeremy -team | dot -Tsvg > team.svg`,
+	Flags: defineGraphFlags,
+	Run:   runGraph,
+}
+
+// The flags of golove graph.
+var graphFlags struct {
+	users        listFlag
+	team         bool
+	since, until dateFlag
+	format       string
+}
+
+func defineGraphFlags(flags *flag.FlagSet) {
+	graphFlags.users = nil
+	flags.Var(&graphFlags.users, "user", "include love sent and received by `user` (may be repeated)")
+	flags.BoolVar(&graphFlags.team, "team", false, "only include love sent between the users")
+	graphFlags.since, graphFlags.until = dateFlag{}, dateFlag{}
+	flags.Var(&graphFlags.since, "since", "only include love sent on or after `date` (YYYY-MM-DD)")
+	flags.Var(&graphFlags.until, "until", "only include love sent before `date` (YYYY-MM-DD)")
+	flags.StringVar(&graphFlags.format, "format", "dot", "output `format`: dot or graphml")
+}
+
+func runGraph(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	if graphFlags.format != "dot" && graphFlags.format != "graphml" {
+		return usagef("unknown format %q", graphFlags.format)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	if len(graphFlags.users) == 0 {
+		sender, err := cfg.sender()
+		if err != nil {
+			return err
+		}
+		graphFlags.users = listFlag{sender}
+	}
+	members := make(map[string]bool)
+	for _, user := range graphFlags.users {
+		members[user] = true
+	}
+	seen := make(map[love.LoveKey]bool)
+	var loves []love.Love
+	for _, user := range graphFlags.users {
+		for _, f := range []love.LoveFilter{
+			{Sender: user, Since: graphFlags.since.Time, Until: graphFlags.until.Time},
+			{Recipient: user, Since: graphFlags.since.Time, Until: graphFlags.until.Time},
+		} {
+			found, err := client.GetLoveFiltered(f)
+			if err != nil {
+				return err
+			}
+			for _, l := range found {
+				if seen[l.Key()] || graphFlags.team && !(members[l.Sender] && members[l.Recipient]) {
+					continue
+				}
+				seen[l.Key()] = true
+				loves = append(loves, l)
+			}
+		}
+	}
+	graph := love.ComputeGraph(loves)
+	out := bufio.NewWriter(os.Stdout)
+	if graphFlags.format == "graphml" {
+		err = graph.WriteGraphML(out)
+	} else {
+		err = graph.WriteDOT(out)
+	}
+	if err != nil {
+		return err
+	}
+	return out.Flush()
+}
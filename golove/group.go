@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Keys of the configuration file starting with this define groups.
+const groupKeyPrefix = "group."
+
+var groupCommand = &command{
+	Name:    "group",
+	Args:    "list [name] | add name member... | remove name [member...]",
+	Summary: "manage named groups of recipients, such as teams",
+	Long: `Manage groups of recipients. Love for @name, where name is a group, is sent to
+every member of the group, by "golove send" and every other command which sends
+love, so that a whole team can be thanked at once:
+
+	golove group add backend-team darwin jeremy
+	golove send @backend-team "Thanks for the smooth migration!"
+
+Members may be other groups, written as @name. "list" prints every group and
+its members, or with a name, the members of that group, one per line. "add"
+adds members to a group, creating it if necessary, and "remove" removes
+members from a group, or the whole group if no members are given.
+
+Groups are kept in the groups file, which is the groups setting (LOVE_GROUPS),
+or groups.json beside the configuration file, and may be shared by a team.
+Groups may also be defined in the configuration file, with a key of group.name
+and a comma separated list of members, such as:
+
+	group.backend-team = "darwin,jeremy"
+
+which replaces any group of the same name in the groups file. Such groups are
+changed with "golove config set", not "golove group".`,
+	Run: runGroup,
+}
+
+func runGroup(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		return usagef("a subcommand is required")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	switch {
+	case args[0] == "list" && len(args) <= 2:
+		groups, err := cfg.groups()
+		if err != nil {
+			return err
+		}
+		if len(args) == 1 {
+			for _, name := range groups.Names() {
+				fmt.Printf("@%s\t%s\n", name, strings.Join(groups[name], ","))
+			}
+			return nil
+		}
+		name := strings.ToLower(strings.TrimPrefix(args[1], "@"))
+		members, ok := groups[name]
+		if !ok {
+			return fmt.Errorf("no group is named %s", name)
+		}
+		for _, member := range members {
+			fmt.Println(member)
+		}
+	case args[0] == "add" && len(args) > 2, args[0] == "remove" && len(args) > 1:
+		name := strings.ToLower(strings.TrimPrefix(args[1], "@"))
+		if _, ok := cfg.groupDefs[name]; ok {
+			return fmt.Errorf("group %s is defined in %s; use \"golove config set %s%s\"",
+				name, cfg.Path, groupKeyPrefix, name)
+		}
+		path := cfg.groupsPath()
+		groups, err := readGroups(path)
+		if err != nil {
+			return err
+		}
+		if args[0] == "add" {
+			if err := groups.Add(name, args[2:]...); err != nil {
+				return usagef("%s", err)
+			}
+		} else if !groups.Remove(name, args[2:]...) {
+			return fmt.Errorf("no group is named %s", name)
+		}
+		return writeGroups(path, groups)
+	default:
+		return usagef("invalid arguments")
+	}
+	return nil
+}
+
+/*
+Read groups from a JSON file, which holds an object mapping the name of each
+group to a list of its members. A missing file, or an empty path, has no
+groups.
+*/
+func readGroups(path string) (love.Groups, error) {
+	groups := make(love.Groups)
+	if path == "" {
+		return groups, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return groups, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var lists map[string][]string
+	if err := json.Unmarshal(data, &lists); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	for name, members := range lists {
+		if err := groups.Add(name, members...); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+	return groups, nil
+}
+
+func writeGroups(path string, groups love.Groups) error {
+	data, err := json.MarshalIndent(groups, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+var helpCommand = &command{
+	Name:    "help",
+	Args:    "[command]",
+	Summary: "show help for a command",
+	Long: `Show the usage of a command, its description, and its flags. Without a
+command, list the commands and the flags golove takes before them.`,
+	Run: runHelp,
+}
+
+func runHelp(cmd *command, args []string) error {
+	if len(args) == 0 {
+		mainUsage()
+		return nil
+	}
+	if helpRequested(args) {
+		cmd.usage(nil)
+		return flag.ErrHelp
+	}
+	if len(args) > 1 {
+		return usagef("unexpected argument %q", args[1])
+	}
+	target := findCommand(args[0])
+	if target == nil {
+		return usagef("unknown command %q", args[0])
+	}
+	target.help(os.Stdout)
+	return nil
+}
+
+/*
+Write the help for a command: its usage, its description, and its flags. The
+flags of each mode of golove serve follow its own.
+*/
+func (cmd *command) help(w io.Writer) {
+	fmt.Fprintf(w, "usage: golove %s %s\n\n", cmd.Name, cmd.Args)
+	if cmd.Long != "" {
+		fmt.Fprintln(w, cmd.Long)
+	} else {
+		fmt.Fprintln(w, cmd.Summary)
+	}
+	printFlags(w, "Flags:", cmd.flagSet())
+	if cmd == serveCommand {
+		for _, mode := range serveModes {
+			printFlags(w, fmt.Sprintf("Flags of %s mode:", mode.Name), mode.flagSet(cmd))
+		}
+	}
+}
+
+func printFlags(w io.Writer, title string, flags *flag.FlagSet) {
+	defined := false
+	flags.VisitAll(func(*flag.Flag) { defined = true })
+	if !defined {
+		return
+	}
+	fmt.Fprintf(w, "\n%s\n\n", title)
+	flags.SetOutput(w)
+	flags.PrintDefaults()
+}
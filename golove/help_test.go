@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestHelp(t *testing.T) {
+	setTestEnv(t, nil)
+	for _, cmd := range commands {
+		if cmd.Hidden {
+			continue
+		}
+		assert.NotEmpty(t, cmd.Long, cmd.Name)
+		status, stdout, stderr := runGolove(t, "help", cmd.Name)
+		assert.Equal(t, status, exitOK, cmd.Name)
+		assert.Empty(t, stderr, cmd.Name)
+		assert.True(t, strings.HasPrefix(stdout, "usage: golove "+cmd.Name+" "+cmd.Args+"\n"), cmd.Name)
+		assert.Contains(t, stdout, cmd.Long, cmd.Name)
+		cmd.flagSet().VisitAll(func(f *flag.Flag) {
+			assert.Contains(t, stdout, "\n  -"+f.Name, "%s -%s", cmd.Name, f.Name)
+		})
+	}
+}
+
+func TestHelpServe(t *testing.T) {
+	setTestEnv(t, nil)
+	status, stdout, _ := runGolove(t, "help", "serve")
+	assert.Equal(t, status, exitOK)
+	assert.Contains(t, stdout, "serve_token")
+	for _, mode := range serveModes {
+		if mode.Flags == nil {
+			continue
+		}
+		assert.Contains(t, stdout, "\nFlags of "+mode.Name+" mode:\n", mode.Name)
+		mode.flagSet(serveCommand).VisitAll(func(f *flag.Flag) {
+			assert.Contains(t, stdout, "\n  -"+f.Name, "%s -%s", mode.Name, f.Name)
+		})
+	}
+}
+
+func TestHelpWithoutFlags(t *testing.T) {
+	setTestEnv(t, nil)
+	status, stdout, _ := runGolove(t, "help", "help")
+	assert.Equal(t, status, exitOK)
+	assert.Equal(t, stdout, "usage: golove help [command]\n\n"+helpCommand.Long+"\n")
+}
+
+func TestHelpUsage(t *testing.T) {
+	setTestEnv(t, nil)
+	status, stdout, stderr := runGolove(t, "help")
+	assert.Equal(t, status, exitOK)
+	assert.Empty(t, stdout)
+	assert.Contains(t, stderr, "The commands are:")
+	for _, args := range [][]string{{"help", "bogus"}, {"help", "send", "get"}} {
+		status, _, stderr = runGolove(t, args...)
+		assert.Equal(t, status, exitUsage, "%q", args)
+		assert.Contains(t, stderr, "usage: golove help [command]", "%q", args)
+	}
+}
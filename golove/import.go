@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var importCommand = &command{
+	Name:    "import",
+	Args:    "[-format format] [-dry-run] file",
+	Summary: "send the love in a file written by \"golove export\"",
+	Long: `Send each love in a file written by "golove export", from its original sender
+to its original recipient, with its original message. This copies history from
+one love instance to another. The server records the time each love is sent, so
+the original timestamps are lost, but love is sent oldest first to keep its
+order.
+
+Love is skipped if the instance already has love with the same sender,
+recipient and message, so an import which was interrupted may be run again.
+
+The format is csv, json or jsonl, according to the file extension unless
+-format is given. CSV files must have a header row naming the sender, recipient
+and message columns.`,
+	Flags: defineImportFlags,
+	Run:   runImport,
+}
+
+// The flags of golove import.
+var importFlags struct {
+	format string
+	dryRun bool
+}
+
+func defineImportFlags(flags *flag.FlagSet) {
+	flags.StringVar(&importFlags.format, "format", "",
+		"file `format`: csv, json or jsonl (default from the extension)")
+	flags.BoolVar(&importFlags.dryRun, "dry-run", false,
+		"print the request for each love instead of sending it")
+}
+
+func runImport(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("a file is required")
+	}
+	path := flags.Arg(0)
+	if importFlags.format == "" {
+		importFlags.format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	if importFlags.format != "csv" && importFlags.format != "json" && importFlags.format != "jsonl" {
+		return usagef("unknown format %q", importFlags.format)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	loves, err := readLoveFile(path, importFlags.format)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(loves, func(i, j int) bool {
+		return loves[i].Timestamp.Before(loves[j].Timestamp)
+	})
+	if importFlags.dryRun {
+		client.DryRun = os.Stdout
+	}
+
+	existing := newLoveIndex(client)
+	sent, skipped, failed := 0, 0, 0
+	for _, l := range loves {
+		found, err := existing.Contains(l)
+		if err != nil {
+			return err
+		}
+		if found {
+			skipped++
+			continue
+		}
+		if err := client.SendLove(l.Sender, l.Recipient, l.Message); err != nil {
+			fmt.Fprintf(os.Stderr, "golove import: %s -> %s: %s\n", l.Sender,
+				l.Recipient, err)
+			failed++
+			continue
+		}
+		existing.Add(l)
+		sent++
+	}
+	verb := "sent"
+	if importFlags.dryRun {
+		verb = "to send"
+	}
+	fmt.Fprintf(os.Stderr, "%d %s, %d skipped (already present), %d failed\n",
+		sent, verb, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d love could not be sent", failed)
+	}
+	return nil
+}
+
+/*
+Read love from an exported file.
+*/
+func readLoveFile(path, format string) ([]love.Love, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var loves []love.Love
+	switch format {
+	case "json":
+		err = json.NewDecoder(file).Decode(&loves)
+	case "jsonl":
+		decoder := json.NewDecoder(file)
+		for {
+			var l love.Love
+			if err = decoder.Decode(&l); err == io.EOF {
+				err = nil
+				break
+			} else if err != nil {
+				break
+			}
+			loves = append(loves, l)
+		}
+	case "csv":
+		loves, err = readLoveCSV(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	for i, l := range loves {
+		if l.Sender == "" || l.Recipient == "" || l.Message == "" {
+			return nil, fmt.Errorf("%s: love %d has no sender, recipient or message",
+				path, i+1)
+		}
+	}
+	return loves, nil
+}
+
+func readLoveCSV(r io.Reader) ([]love.Love, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"sender", "recipient", "message"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("no %s column", name)
+		}
+	}
+	var loves []love.Love
+	for _, record := range records[1:] {
+		l := love.Love{
+			Sender:    record[columns["sender"]],
+			Recipient: record[columns["recipient"]],
+			Message:   record[columns["message"]],
+		}
+		if i, ok := columns["timestamp"]; ok {
+			// Only used to order the love, so an invalid timestamp is
+			// harmless.
+			l.Timestamp, _ = time.Parse("2006-01-02T15:04:05", record[i])
+		}
+		loves = append(loves, l)
+	}
+	return loves, nil
+}
+
+/*
+The love already sent by each sender, fetched the first time it is needed, for
+finding duplicates. Love is identified by sender, recipient and message, since
+timestamps change when love is copied.
+*/
+type loveIndex struct {
+	client  *love.Client
+	senders map[string]map[[2]string]bool
+}
+
+func newLoveIndex(client *love.Client) *loveIndex {
+	return &loveIndex{client, make(map[string]map[[2]string]bool)}
+}
+
+func (x *loveIndex) Contains(l love.Love) (bool, error) {
+	sent, ok := x.senders[l.Sender]
+	if !ok {
+		sent = make(map[[2]string]bool)
+		it := x.client.IterLove(l.Sender, "")
+		for it.Next() {
+			existing := it.Love()
+			sent[[2]string{existing.Recipient, existing.Message}] = true
+		}
+		if err := it.Err(); err != nil {
+			return false, err
+		}
+		x.senders[l.Sender] = sent
+	}
+	return sent[[2]string{l.Recipient, l.Message}], nil
+}
+
+func (x *loveIndex) Add(l love.Love) {
+	x.senders[l.Sender][[2]string{l.Recipient, l.Message}] = true
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"github.com/hacsoc/golove/love"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadLoveCSV(t *testing.T) {
+	loves, err := readLoveCSV(strings.NewReader(
+		" Message ,Recipient,SENDER,timestamp\n" +
+			"Thanks!,darwin,hammy,2026-10-16T12:00:00\n" +
+			"\"Thanks, again\",hammy,darwin,yesterday\n"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, loves, []love.Love{
+			{Sender: "hammy", Recipient: "darwin", Message: "Thanks!",
+				Timestamp: time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)},
+			// An invalid timestamp is left zero.
+			{Sender: "darwin", Recipient: "hammy", Message: "Thanks, again"},
+		})
+	}
+
+	loves, err = readLoveCSV(strings.NewReader("sender,recipient,message\nhammy,darwin,Thanks!\n"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, loves, []love.Love{{Sender: "hammy", Recipient: "darwin", Message: "Thanks!"}})
+	}
+
+	loves, err = readLoveCSV(strings.NewReader(""))
+	assert.NoError(t, err)
+	assert.Empty(t, loves)
+
+	_, err = readLoveCSV(strings.NewReader("sender,to,message\nhammy,darwin,Thanks!\n"))
+	assert.EqualError(t, err, "no recipient column")
+}
+
+func TestLoveIndex(t *testing.T) {
+	server := newTestServer(t)
+	server.AddLove(love.Love{Sender: "hammy", Recipient: "darwin", Message: "Thanks!",
+		Timestamp: time.Now()})
+	index := newLoveIndex(server.Client())
+
+	for _, test := range []struct {
+		love     love.Love
+		expected bool
+	}{
+		{love.Love{Sender: "hammy", Recipient: "darwin", Message: "Thanks!"}, true},
+		// Timestamps are ignored, since they change when love is copied.
+		{love.Love{Sender: "hammy", Recipient: "darwin", Message: "Thanks!",
+			Timestamp: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)}, true},
+		{love.Love{Sender: "hammy", Recipient: "darwin", Message: "Thanks again!"}, false},
+		{love.Love{Sender: "darwin", Recipient: "hammy", Message: "Thanks!"}, false},
+	} {
+		found, err := index.Contains(test.love)
+		if assert.NoError(t, err) {
+			assert.Equal(t, found, test.expected, test.love)
+		}
+	}
+
+	l := love.Love{Sender: "darwin", Recipient: "hammy", Message: "Thanks!"}
+	index.Add(l)
+	found, err := index.Contains(l)
+	assert.NoError(t, err)
+	assert.True(t, found)
+}
+
+func TestImportExport(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	exported := []love.Love{
+		{Sender: "hammy", Recipient: "darwin", Message: "Thanks for the review",
+			Timestamp: now.Add(-3 * time.Hour)},
+		{Sender: "darwin", Recipient: "hammy", Message: "Thanks, \"hammy\"!",
+			Timestamp: now.Add(-2 * time.Hour)},
+		{Sender: "hammy", Recipient: "darwin", Message: "Thanks again",
+			Timestamp: now.Add(-time.Hour)},
+	}
+	for _, format := range []string{"csv", "json", "jsonl"} {
+		from := newTestServer(t)
+		for _, l := range exported {
+			from.AddLove(l)
+		}
+		status, stdout, stderr := runGolove(t, "export", "-format", format)
+		if !assert.Equal(t, status, exitOK, stderr) {
+			continue
+		}
+		path := writeTestConfig(t, "love."+format, stdout)
+
+		to := newTestServer(t)
+		to.AddLove(exported[1])
+		status, _, stderr = runGolove(t, "import", path)
+		assert.Equal(t, status, exitOK, stderr)
+		assert.Equal(t, stderr, "2 sent, 1 skipped (already present), 0 failed\n", format)
+		var imported []love.Love
+		for _, l := range to.Loves() {
+			imported = append(imported, love.Love{Sender: l.Sender, Recipient: l.Recipient, Message: l.Message})
+		}
+		assert.Equal(t, imported, []love.Love{
+			{Sender: exported[1].Sender, Recipient: exported[1].Recipient, Message: exported[1].Message},
+			{Sender: exported[0].Sender, Recipient: exported[0].Recipient, Message: exported[0].Message},
+			{Sender: exported[2].Sender, Recipient: exported[2].Recipient, Message: exported[2].Message},
+		}, format)
+
+		// Importing again sends nothing.
+		status, _, stderr = runGolove(t, "import", "-format", format, path)
+		assert.Equal(t, status, exitOK, stderr)
+		assert.Equal(t, stderr, "0 sent, 3 skipped (already present), 0 failed\n", format)
+		assert.Len(t, to.Loves(), 3, format)
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	newTestServer(t)
+	path := filepath.Join(t.TempDir(), "love.txt")
+	status, _, stderr := runGolove(t, "import", path)
+	assert.Equal(t, status, exitUsage)
+	assert.Contains(t, stderr, `unknown format "txt"`)
+
+	path = writeTestConfig(t, "love.jsonl",
+		`{"sender": "hammy", "recipient": "darwin", "message": "", "timestamp": "2026-10-16T12:00:00"}`+"\n")
+	status, _, stderr = runGolove(t, "import", path)
+	assert.Equal(t, status, exitError)
+	assert.Contains(t, stderr, path+": love 1 has no sender, recipient or message")
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var leaderboardCommand = &command{
+	Name:    "leaderboard",
+	Args:    "[-period period] [-user user]... [-roster file] [-top n] [-output format]",
+	Summary: "rank the top senders and recipients of love",
+	Long: `Print the users who sent and received the most love during a period: this_week
+(the default), last_week, this_month or last_month.
+
+The leaderboard comes from the instance's leaderboard endpoint if it has one.
+Otherwise, it is computed from the love sent and received by each -user (which
+may be repeated) and each user in the -roster file, or by the configured sender
+if neither is given.`,
+	Flags: defineLeaderboardFlags,
+	Run:   runLeaderboard,
+}
+
+// The flags of golove leaderboard.
+var leaderboardFlags struct {
+	period     string
+	users      listFlag
+	rosterPath string
+	top        int
+	output     string
+}
+
+func defineLeaderboardFlags(flags *flag.FlagSet) {
+	flags.StringVar(&leaderboardFlags.period, "period", string(love.ThisWeek),
+		"rank love sent during `period`: "+periodNames())
+	leaderboardFlags.users = nil
+	flags.Var(&leaderboardFlags.users, "user",
+		"without a leaderboard endpoint, count love involving `user` (may be repeated)")
+	flags.StringVar(&leaderboardFlags.rosterPath, "roster", "",
+		"without a leaderboard endpoint, count love involving the users in `file`")
+	flags.IntVar(&leaderboardFlags.top, "top", 10, "list the top `n` senders and recipients")
+	addOutputFlag(flags, &leaderboardFlags.output)
+}
+
+func runLeaderboard(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(leaderboardFlags.output); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	if _, _, err := love.Period(leaderboardFlags.period).Range(time.Now()); err != nil {
+		return usagef("%s", err)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	if leaderboardFlags.rosterPath != "" {
+		roster, err := readList(leaderboardFlags.rosterPath)
+		if err != nil {
+			return err
+		}
+		leaderboardFlags.users = append(leaderboardFlags.users, roster...)
+	}
+	if len(leaderboardFlags.users) == 0 {
+		sender, err := cfg.sender()
+		if err != nil {
+			return err
+		}
+		leaderboardFlags.users = listFlag{sender}
+	}
+	board, err := client.GetLeaderboard(love.Period(leaderboardFlags.period), leaderboardFlags.users)
+	if err != nil {
+		return err
+	}
+	return leaderboardRecords(board, leaderboardFlags.top).write(os.Stdout, leaderboardFlags.output)
+}
+
+func periodNames() string {
+	names := make([]string, len(love.Periods))
+	for i, p := range love.Periods {
+		names[i] = string(p)
+	}
+	return strings.Join(names, ", ")
+}
+
+/*
+The top entries of a leaderboard, senders first, as records with a list column
+saying which list each entry is from.
+*/
+func leaderboardRecords(board *love.Leaderboard, top int) *records {
+	type item struct {
+		List string
+		love.LeaderboardEntry
+	}
+	var items []item
+	for _, list := range []struct {
+		name    string
+		entries []love.LeaderboardEntry
+	}{{"senders", board.Senders}, {"recipients", board.Recipients}} {
+		for i, e := range list.entries {
+			if top > 0 && i >= top {
+				break
+			}
+			items = append(items, item{list.name, e})
+		}
+	}
+	r := &records{
+		Columns: []string{"list", "rank", "user", "sent", "received"},
+		Text: func(w io.Writer, i int) {
+			e := items[i]
+			if i == 0 || items[i-1].List != e.List {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintf(w, "Top %s:\n", e.List)
+			}
+			count := e.Sent
+			if e.List == "recipients" {
+				count = e.Received
+			}
+			fmt.Fprintf(w, "  %3d. %-20s %d\n", e.Rank, e.User, count)
+		},
+	}
+	for _, e := range items {
+		r.Rows = append(r.Rows, []string{e.List, strconv.Itoa(e.Rank), e.User,
+			strconv.Itoa(e.Sent), strconv.Itoa(e.Received)})
+		r.Items = append(r.Items, e)
+	}
+	return r
+}
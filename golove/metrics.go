@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/metrics"
+	"net/http"
+	"os"
+)
+
+// Records the requests of every client created, when set by a daemon command.
+var clientMetrics *metrics.Metrics
+
+/*
+Record the requests made by a client, if metrics are enabled.
+*/
+func instrument(client *love.Client) {
+	if clientMetrics != nil {
+		clientMetrics.Instrument(client)
+	}
+}
+
+/*
+Enable metrics, and serve them at /metrics on addr in the background. Failing
+to listen is reported, but does not stop the command.
+*/
+func serveMetrics(addr string) {
+	clientMetrics = metrics.New()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", clientMetrics.Handler())
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Fprintf(os.Stderr, "golove: metrics: %s\n", err)
+		}
+	}()
+}
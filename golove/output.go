@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+/*
+The result of a read command, in a form which can be printed in any output
+format. Columns names the fields of each record, and Rows holds the fields of
+each record as text. Items holds the original values, which templates are
+executed against. Text prints a record in the default format.
+*/
+type records struct {
+	Columns []string
+	Rows    [][]string
+	Items   []interface{}
+	Text    func(w io.Writer, i int)
+}
+
+// The formats accepted by -output, besides templates.
+var outputFormats = []string{"text", "json", "jsonl", "csv", "table"}
+
+/*
+Add the -output flag, and its shorthand -o, to a read command, setting format.
+*/
+func addOutputFlag(flags *flag.FlagSet, format *string) {
+	flags.StringVar(format, "output", "text", "output `format`: "+
+		strings.Join(outputFormats, ", ")+", or a Go template such as '{{.Sender}}'")
+	flags.StringVar(format, "o", "text", "shorthand for -output `format`")
+}
+
+func isTemplate(format string) bool {
+	return strings.Contains(format, "{{")
+}
+
+/*
+Check an output format before doing any work.
+*/
+func checkOutputFormat(format string) error {
+	if isTemplate(format) {
+		if _, err := template.New("output").Parse(format); err != nil {
+			return usagef("invalid template: %s", err)
+		}
+		return nil
+	}
+	for _, f := range outputFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return usagef("unknown output format %q", format)
+}
+
+/*
+Print the records in an output format. Templates are executed once per record,
+followed by a newline.
+*/
+func (r *records) write(w io.Writer, format string) error {
+	if isTemplate(format) {
+		tmpl, err := template.New("output").Parse(format)
+		if err != nil {
+			return err
+		}
+		for _, item := range r.Items {
+			if err := tmpl.Execute(w, item); err != nil {
+				return err
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+	}
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r.objects())
+	case "jsonl":
+		encoder := json.NewEncoder(w)
+		for _, object := range r.objects() {
+			if err := encoder.Encode(object); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write(r.Columns)
+		writer.WriteAll(r.Rows)
+		return writer.Error()
+	case "table":
+		writer := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.ToUpper(strings.Join(r.Columns, "\t")))
+		for _, row := range r.Rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = strings.Replace(cell, "\n", " ", -1)
+			}
+			fmt.Fprintln(writer, strings.Join(cells, "\t"))
+		}
+		return writer.Flush()
+	default:
+		for i := range r.Rows {
+			r.Text(w, i)
+		}
+		return nil
+	}
+}
+
+/*
+A record as a JSON object, with its fields in column order.
+*/
+type object struct {
+	columns []string
+	row     []string
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, column := range o.columns {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		key, _ := json.Marshal(column)
+		value, _ := json.Marshal(o.row[i])
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+/*
+Convert each row to an object keyed by column name.
+*/
+func (r *records) objects() []object {
+	objects := make([]object, len(r.Rows))
+	for i, row := range r.Rows {
+		objects[i] = object{r.Columns, row}
+	}
+	return objects
+}
+
+// The columns of love in structured output formats.
+var loveColumns = []string{"timestamp", "sender", "recipient", "message"}
+
+func loveRow(l love.Love) []string {
+	return []string{
+		l.Timestamp.Format("2006-01-02T15:04:05"), l.Sender, l.Recipient, l.Message,
+	}
+}
+
+func loveRecords(loves []love.Love) *records {
+	text := loveText{Style: fileStyle(os.Stdout)}
+	r := &records{
+		Columns: loveColumns,
+		Text: func(w io.Writer, i int) {
+			text.write(w, loves[i])
+		},
+	}
+	for _, l := range loves {
+		r.Rows = append(r.Rows, loveRow(l))
+		r.Items = append(r.Items, l)
+	}
+	return r
+}
+
+/*
+How love is printed in the text format. Users with one of the Profiles are shown
+by their full name. The time is shown in the local time zone, relative to now if
+Relative is set. Text in messages matching Highlight is highlighted, if the
+Style is colored. If Number is set, the love is numbered with it.
+*/
+type loveText struct {
+	Profiles  map[string]*love.Profile
+	Relative  bool
+	Style     textStyle
+	Highlight *regexp.Regexp
+	Number    int
+}
+
+func (t loveText) write(w io.Writer, l love.Love) {
+	style := t.Style
+	name := func(user string) string {
+		if p := t.Profiles[user]; p != nil && p.FullName != "" {
+			return p.FullName
+		}
+		return user
+	}
+	when := l.Timestamp.In(time.Local).Format("2006-01-02 15:04")
+	if t.Relative {
+		when = love.FormatRelative(l.Timestamp, time.Now())
+	}
+	sender, recipient := name(l.Sender), name(l.Recipient)
+	start := displayWidth(fmt.Sprintf("%s  %s -> %s: ", when, sender, recipient))
+	if t.Number > 0 {
+		number := fmt.Sprintf("%3d  ", t.Number)
+		start += len(number)
+		fmt.Fprint(w, style.paint(ansiDim, number))
+	}
+	fmt.Fprintf(w, "%s  %s -> %s: %s", style.paint(ansiDim, when),
+		style.paint(ansiCyan, sender), style.paint(ansiMagenta, recipient),
+		style.wrap(t.message(l.Message), start, 4))
+	if len(l.Values) > 0 {
+		fmt.Fprintf(w, " %s", style.paint(ansiYellow, "["+strings.Join(l.Values, ", ")+"]"))
+	}
+	fmt.Fprintln(w)
+}
+
+/*
+Return a message as it is shown, with emoji and markdown rendered and matches
+highlighted, depending on the style. Matches are found in the message as it was
+sent, so markdown is not rendered when they are highlighted.
+*/
+func (t loveText) message(message string) string {
+	render := func(s string) string {
+		if t.Style.Emoji {
+			return love.RenderEmoji(s)
+		}
+		return s
+	}
+	if t.Highlight == nil || !t.Style.Color {
+		return t.Style.markdown(render(message))
+	}
+	var out strings.Builder
+	last := 0
+	for _, match := range t.Highlight.FindAllStringIndex(message, -1) {
+		out.WriteString(render(message[last:match[0]]))
+		out.WriteString(t.Style.paint(ansiBoldRed, render(message[match[0]:match[1]])))
+		last = match[1]
+	}
+	out.WriteString(render(message[last:]))
+	return out.String()
+}
+
+func userRecords(users []love.User) *records {
+	r := &records{
+		Columns: []string{"username", "display"},
+		Text: func(w io.Writer, i int) {
+			fmt.Fprintf(w, "%s\t%s\n", users[i].Username, users[i].Display)
+		},
+	}
+	for _, u := range users {
+		r.Rows = append(r.Rows, []string{u.Username, u.Display})
+		r.Items = append(r.Items, u)
+	}
+	return r
+}
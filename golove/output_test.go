@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"github.com/hacsoc/golove/love"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testUsers = []love.User{
+	{Username: "hammy", Display: "Hammy Hamster"},
+	{Username: "darwin", Display: "Darwin, the \"Dog\""},
+}
+
+func TestWriteRecords(t *testing.T) {
+	for format, expected := range map[string]string{
+		"text": "hammy\tHammy Hamster\ndarwin\tDarwin, the \"Dog\"\n",
+		"json": `[
+  {
+    "username": "hammy",
+    "display": "Hammy Hamster"
+  },
+  {
+    "username": "darwin",
+    "display": "Darwin, the \"Dog\""
+  }
+]
+`,
+		"jsonl": `{"username":"hammy","display":"Hammy Hamster"}
+{"username":"darwin","display":"Darwin, the \"Dog\""}
+`,
+		"csv": `username,display
+hammy,Hammy Hamster
+darwin,"Darwin, the ""Dog"""
+`,
+		"table": `USERNAME  DISPLAY
+hammy     Hammy Hamster
+darwin    Darwin, the "Dog"
+`,
+		"{{.Username}}: {{.Display}}": "hammy: Hammy Hamster\ndarwin: Darwin, the \"Dog\"\n",
+	} {
+		assert.NoError(t, checkOutputFormat(format), format)
+		var buffer bytes.Buffer
+		assert.NoError(t, userRecords(testUsers).write(&buffer, format), format)
+		assert.Equal(t, buffer.String(), expected, format)
+	}
+}
+
+func TestWriteRecordsEmpty(t *testing.T) {
+	for format, expected := range map[string]string{
+		"text":  "",
+		"json":  "[]\n",
+		"jsonl": "",
+		"csv":   "username,display\n",
+	} {
+		var buffer bytes.Buffer
+		assert.NoError(t, userRecords(nil).write(&buffer, format), format)
+		assert.Equal(t, buffer.String(), expected, format)
+	}
+}
+
+func TestCheckOutputFormat(t *testing.T) {
+	for _, format := range []string{"", "xml", "JSON", "{{.Username"} {
+		err := checkOutputFormat(format)
+		var usageErr *usageError
+		assert.ErrorAs(t, err, &usageErr, format)
+	}
+}
+
+func TestOutputFlag(t *testing.T) {
+	server := newTestServer(t)
+	server.AddLove(love.Love{Sender: "hammy", Recipient: "darwin", Message: "Thanks!"})
+	for _, args := range [][]string{
+		{"get", "-output", "jsonl", "-from", "hammy"},
+		{"get", "-o", "jsonl", "-from", "hammy"},
+	} {
+		status, stdout, stderr := runGolove(t, args...)
+		assert.Equal(t, status, exitOK, stderr)
+		assert.Contains(t, stdout, `"sender":"hammy","recipient":"darwin","message":"Thanks!"`, "%q", args)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Set by the -no-pager flag.
+var noPager bool
+
+/*
+Return the user's pager: $GOLOVE_PAGER, $PAGER, or a default for the platform.
+An empty GOLOVE_PAGER, or a pager of "cat", means no pager.
+*/
+func pager() string {
+	if value, ok := os.LookupEnv("GOLOVE_PAGER"); ok {
+		return value
+	}
+	if value := os.Getenv("PAGER"); value != "" {
+		return value
+	}
+	if runtime.GOOS == "windows" {
+		return "more"
+	}
+	return "less"
+}
+
+/*
+Call write with the standard output, like "git log" does: if it is a terminal,
+and the output is longer than the terminal, it is shown through the user's pager
+instead, unless -no-pager is given. If the pager cannot be run, the output is
+written directly.
+*/
+func page(write func(w io.Writer) error) error {
+	fd := int(os.Stdout.Fd())
+	command := pager()
+	if noPager || command == "" || command == "cat" || !term.IsTerminal(fd) {
+		return write(os.Stdout)
+	}
+	var output bytes.Buffer
+	if err := write(&output); err != nil {
+		return err
+	}
+	_, height, err := term.GetSize(fd)
+	if err != nil || bytes.Count(output.Bytes(), []byte("\n")) < height {
+		_, err := os.Stdout.Write(output.Bytes())
+		return err
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		fields := strings.Fields(command)
+		cmd = exec.Command(fields[0], fields[1:]...)
+	} else {
+		// The pager may have arguments, as in PAGER="less -S".
+		cmd = exec.Command("/bin/sh", "-c", "exec "+command)
+	}
+	cmd.Stdin = bytes.NewReader(output.Bytes())
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if os.Getenv("LESS") == "" {
+		// Quit if the output fits, keep colors, and leave the output on the
+		// screen, as git does.
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() != 127 {
+			// The pager ran, and the user has seen the output.
+			return nil
+		}
+		_, err := os.Stdout.Write(output.Bytes())
+		return err
+	}
+	return nil
+}
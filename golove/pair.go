@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"os"
+	"strings"
+	"time"
+)
+
+// The message sent by "golove pair -send", unless -message is given.
+const defaultPairMessage = "Psst! Your secret appreciation target this week is {{.Target}}. Send them some love!"
+
+var pairCommand = &command{
+	Name:    "pair",
+	Args:    "[-roster file] [-search term] [-seed n] [-send [-message template] [-dry-run]] [user...]",
+	Summary: "assign each user a secret target to appreciate",
+	Long: `Assign each user in a roster another user to appreciate, secret santa style,
+and print the assignments. The roster is the users given as arguments, the
+usernames in the -roster file (one per line; blank lines and lines starting
+with # are ignored), and the users found by looking up -search with
+autocomplete, such as the common prefix of a team's usernames. Users given as
+arguments or in the file must exist.
+
+The draw is random, but is the same for the same roster and -seed, which
+defaults to the current week (as printed), so running it again during the week
+reproduces it. See love.DrawPairs.
+
+With -send, the assignments are kept secret: instead of printing them, the
+configured sender sends each user love telling them their target. The message
+is a template (see "golove send -template") in which .Target is the user's
+target. If the sender is in the roster, their own assignment is printed, since
+they cannot send love to themselves. With -dry-run, the requests are printed
+instead of made.`,
+	Flags: definePairFlags,
+	Run:   runPair,
+}
+
+// The flags of golove pair.
+var pairFlags struct {
+	rosterPath string
+	search     string
+	seed       int64
+	send       bool
+	message    string
+	dryRun     bool
+}
+
+func definePairFlags(flags *flag.FlagSet) {
+	flags.StringVar(&pairFlags.rosterPath, "roster", "", "read usernames from `file`, one per line")
+	flags.StringVar(&pairFlags.search, "search", "", "add the users autocomplete finds for `term`")
+	flags.Int64Var(&pairFlags.seed, "seed", love.WeekSeed(time.Now()), "seed the draw with `n`")
+	flags.BoolVar(&pairFlags.send, "send", false, "send each user love telling them their target")
+	flags.StringVar(&pairFlags.message, "message", defaultPairMessage, "with -send, the message `template`")
+	flags.BoolVar(&pairFlags.dryRun, "dry-run", false,
+		"with -send, print the requests instead of sending love")
+}
+
+func runPair(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if pairFlags.dryRun && !pairFlags.send {
+		return usagef("-dry-run requires -send")
+	}
+	tmpl, err := love.ParseMessageTemplate(pairFlags.message)
+	if err != nil {
+		return usagef("invalid template: %s", err)
+	}
+	roster := flags.Args()
+	if pairFlags.rosterPath != "" {
+		users, err := readList(pairFlags.rosterPath)
+		if err != nil {
+			return err
+		}
+		roster = append(roster, users...)
+	}
+	if len(roster) == 0 && pairFlags.search == "" {
+		return usagef("a roster is required")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	if len(roster) > 0 {
+		unknown, err := client.ValidateRecipients(roster)
+		if err != nil {
+			return err
+		}
+		if len(unknown) > 0 {
+			return &love.UnknownRecipientsError{Names: unknown}
+		}
+	}
+	if pairFlags.search != "" {
+		users, err := client.Autocomplete(pairFlags.search)
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			roster = append(roster, u.Username)
+		}
+	}
+	pairs, err := love.DrawPairs(roster, pairFlags.seed)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Seed: %d\n", pairFlags.seed)
+	if !pairFlags.send {
+		for _, p := range pairs {
+			fmt.Printf("%-20s -> %s\n", p.User, p.Target)
+		}
+		return nil
+	}
+
+	sender, err := cfg.sender()
+	if err != nil {
+		return err
+	}
+	if pairFlags.dryRun {
+		client.DryRun = os.Stdout
+	}
+	var recipients []string
+	data := make(map[string]map[string]string)
+	for _, p := range pairs {
+		if p.User == sender {
+			fmt.Printf("Your target is %s\n", p.Target)
+			continue
+		}
+		recipients = append(recipients, p.User)
+		data[p.User] = map[string]string{"Target": p.Target}
+	}
+	results, err := client.SendLoveTemplate(sender, recipients, tmpl, data, 1)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, r := range recipients {
+		switch err := results[r]; {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "golove pair: %s: %s\n", r, err)
+			failed++
+		case pairFlags.dryRun:
+			fmt.Printf("Love not sent to %s (dry run)\n", r)
+		default:
+			fmt.Printf("Love sent to %s!\n", r)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("love could not be sent to %d users", failed)
+	}
+	return nil
+}
+
+/*
+Read a list from a file, such as the usernames in a roster, one per line. Blank
+lines and lines starting with # are ignored.
+*/
+func readList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var users []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			users = append(users, line)
+		}
+	}
+	return users, scanner.Err()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"github.com/hacsoc/golove/love"
+)
+
+/*
+Look up the profiles of users with GetUser, to show their full names. Users
+without a profile are left out. Nothing is looked up once it is clear that the
+instance does not provide profiles, and a failed lookup stops the rest, since
+the names are only decoration.
+*/
+func lookupProfiles(client *love.Client, users []string) map[string]*love.Profile {
+	profiles := make(map[string]*love.Profile)
+	for _, user := range users {
+		if _, ok := profiles[user]; ok {
+			continue
+		}
+		profile, err := client.GetUser(user)
+		if errors.Is(err, love.ErrNoSuchUser) {
+			continue
+		} else if err != nil {
+			break
+		}
+		profiles[user] = profile
+	}
+	return profiles
+}
+
+/*
+Return the senders and recipients of love.
+*/
+func loveUsers(loves []love.Love) []string {
+	var users []string
+	for _, l := range loves {
+		users = append(users, l.Sender, l.Recipient)
+	}
+	return users
+}
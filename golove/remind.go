@@ -0,0 +1,164 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/notify"
+	"strings"
+	"time"
+)
+
+var remindCommand = &command{
+	Name:    "remind",
+	Args:    "[-if-idle duration] [-teammate user] [-top n] [-notify]",
+	Summary: "suggest who to send love to",
+	Long: `Print a nudge to send love, listing the users the configured sender has not
+appreciated lately: those they never sent love to first, then those they sent
+love to longest ago. The users considered are each -teammate, which may be
+repeated, or else everyone the sender has exchanged love with. Users the sender
+sent love to within the -if-idle duration, or the last 14 days without it, are
+left out.
+
+With -if-idle, nothing is printed unless the sender has not sent any love for
+that long, given as a duration such as 14d or 2w. This suits running it from
+cron, or a shell profile. With -notify, the nudge is shown as a desktop
+notification instead of printed.`,
+	Flags: defineRemindFlags,
+	Run:   runRemind,
+}
+
+// How recently the sender must have sent love to a user for golove remind to
+// leave them out, without -if-idle.
+const defaultRemindWindow = 14 * 24 * time.Hour
+
+// The flags of golove remind.
+var remindFlags struct {
+	idle       daysFlag
+	teammates  listFlag
+	top        int
+	notifyFlag bool
+}
+
+func defineRemindFlags(flags *flag.FlagSet) {
+	remindFlags.idle = daysFlag{}
+	flags.Var(&remindFlags.idle, "if-idle",
+		"only remind if no love has been sent for `duration`, such as 14d")
+	remindFlags.teammates = nil
+	flags.Var(&remindFlags.teammates, "teammate",
+		"suggest `user` (may be repeated; default everyone you exchanged love with)")
+	flags.IntVar(&remindFlags.top, "top", 5, "suggest at most `n` users")
+	flags.BoolVar(&remindFlags.notifyFlag, "notify", false, "show the reminder as a desktop notification")
+}
+
+func runRemind(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	var notifier notify.Notifier
+	if remindFlags.notifyFlag {
+		var err error
+		if notifier, err = notify.Detect(); err != nil {
+			return err
+		}
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	sender, err := cfg.sender()
+	if err != nil {
+		return err
+	}
+	loves, err := client.GetLoveAll(sender, "")
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	last, sent := love.LastSent(loves)[sender]
+	if remindFlags.idle.Duration > 0 && sent && now.Sub(last) < remindFlags.idle.Duration {
+		return nil
+	}
+	if len(remindFlags.teammates) == 0 {
+		// Love received is only needed to find who else to suggest.
+		received, err := client.GetLoveAll("", sender)
+		if err != nil {
+			return err
+		}
+		loves = append(loves, received...)
+	}
+	window := remindFlags.idle.Duration
+	if window <= 0 {
+		window = defaultRemindWindow
+	}
+	suggestions := love.SuggestRecipients(sender, loves, remindFlags.teammates)
+	suggestions = withoutRecent(suggestions, now, window)
+	if remindFlags.top > 0 && len(suggestions) > remindFlags.top {
+		suggestions = suggestions[:remindFlags.top]
+	}
+
+	var nudge string
+	if sent {
+		nudge = fmt.Sprintf("You last sent love %s.", ago(now, last))
+	} else {
+		nudge = "You haven't sent any love yet."
+	}
+	if notifier != nil {
+		message := nudge
+		if len(suggestions) > 0 {
+			var names []string
+			for _, s := range suggestions {
+				names = append(names, s.User)
+			}
+			message += " How about " + strings.Join(names, ", ") + "?"
+		}
+		return notifier.Notify("Time to send some love", message)
+	}
+	fmt.Println(nudge)
+	if len(suggestions) > 0 {
+		fmt.Println("\nTeammates you haven't appreciated lately:")
+		for _, s := range suggestions {
+			when := "never"
+			if !s.LastSent.IsZero() {
+				when = ago(now, s.LastSent)
+			}
+			fmt.Printf("  %-20s %s\n", s.User, when)
+		}
+	}
+	return nil
+}
+
+/*
+Remove the suggestions of users who were sent love within window of now.
+*/
+func withoutRecent(suggestions []love.Suggestion, now time.Time, window time.Duration) []love.Suggestion {
+	var kept []love.Suggestion
+	for _, s := range suggestions {
+		if s.LastSent.IsZero() || now.Sub(s.LastSent) >= window {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+/*
+Describe how long before now a time was, in days, such as "3 days ago".
+*/
+func ago(now, t time.Time) string {
+	days := int(now.Sub(t) / (24 * time.Hour))
+	switch days {
+	case 0:
+		return "today"
+	case 1:
+		return "yesterday"
+	}
+	return fmt.Sprintf("%d days ago", days)
+}
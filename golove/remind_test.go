@@ -0,0 +1,52 @@
+package main
+
+import (
+	"github.com/hacsoc/golove/love"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWithoutRecent(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	suggestions := []love.Suggestion{
+		{User: "casper"},
+		{User: "jeremy", LastSent: now.Add(-30 * day)},
+		{User: "hammy", LastSent: now.Add(-14 * day)},
+		{User: "darwin", LastSent: now.Add(-2 * day)},
+	}
+	assert.Equal(t, withoutRecent(suggestions, now, 14*day), suggestions[:3])
+	assert.Equal(t, withoutRecent(suggestions, now, day), suggestions)
+	assert.Equal(t, withoutRecent(suggestions, now, 60*day), suggestions[:1])
+	assert.Empty(t, withoutRecent(nil, now, day))
+}
+
+func TestRemind(t *testing.T) {
+	server := newTestServer(t)
+	server.AddUser("jeremy", "Jeremy")
+	server.AddUser("casper", "Casper")
+	now := time.Now()
+	day := 24 * time.Hour
+	server.AddLove(love.Love{Sender: "hammy", Recipient: "jeremy", Message: "Thanks!",
+		Timestamp: now.Add(-30 * day)})
+	server.AddLove(love.Love{Sender: "hammy", Recipient: "darwin", Message: "Thanks!",
+		Timestamp: now.Add(-2 * day)})
+	server.AddLove(love.Love{Sender: "casper", Recipient: "hammy", Message: "Thanks!",
+		Timestamp: now.Add(-day)})
+
+	status, stdout, stderr := runGolove(t, "remind")
+	assert.Equal(t, status, exitOK, stderr)
+	assert.Equal(t, stdout, "You last sent love 2 days ago.\n\n"+
+		"Teammates you haven't appreciated lately:\n"+
+		"  casper               never\n"+
+		"  jeremy               30 days ago\n")
+
+	status, stdout, stderr = runGolove(t, "remind", "-if-idle", "1d")
+	assert.Equal(t, status, exitOK, stderr)
+	assert.Contains(t, stdout, "  darwin               2 days ago\n")
+
+	status, stdout, stderr = runGolove(t, "remind", "-teammate", "darwin")
+	assert.Equal(t, status, exitOK, stderr)
+	assert.Equal(t, stdout, "You last sent love 2 days ago.\n")
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/store"
+	"golang.org/x/term"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var replyCommand = &command{
+	Name:    "reply",
+	Args:    "[-dry-run] n [message | -]",
+	Summary: "reply to love listed by golove get",
+	Long: `Send love back to the other user of the nth love listed by the last "golove
+get", from the configured sender. The reply refers to and quotes the love it
+replies to:
+
+	Re: your note on Apr 3 ("Thanks for the great demo!"): You're welcome!
+
+The message is given as by "golove send": as arguments, on stdin with "-", or
+written in an editor.`,
+	Flags: defineReplyFlags,
+	Run:   runReply,
+}
+
+// The flags of golove reply.
+var replyFlags struct {
+	dryRun bool
+}
+
+func defineReplyFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&replyFlags.dryRun, "dry-run", false,
+		"print the request which would be made instead of sending love")
+}
+
+func runReply(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return usagef("the number of the love to reply to is required")
+	}
+	n, err := strconv.Atoi(flags.Arg(0))
+	if err != nil || n < 1 {
+		return usagef("invalid love number %q", flags.Arg(0))
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	sender, err := cfg.sender()
+	if err != nil {
+		return err
+	}
+	if replyFlags.dryRun {
+		client.DryRun = os.Stdout
+	}
+	listing, err := loadListing(client)
+	if err != nil {
+		return err
+	}
+	if n > len(listing) {
+		return fmt.Errorf("the last listing has only %d love", len(listing))
+	}
+	original := listing[n-1]
+	original.Timestamp = original.Timestamp.Local()
+	recipient := love.ReplyRecipient(sender, original)
+
+	var message string
+	if flags.NArg() == 1 && term.IsTerminal(int(os.Stdin.Fd())) {
+		if message, err = composeInEditor(sender, recipient); err != nil {
+			return err
+		}
+	} else if flags.NArg() == 1 || flags.NArg() == 2 && flags.Arg(1) == "-" {
+		if message, err = readMessage(os.Stdin); err != nil {
+			return err
+		}
+	} else {
+		message = strings.Join(flags.Args()[1:], " ")
+	}
+	if err := client.ReplyTo(sender, original, message); err != nil {
+		return err
+	}
+	if replyFlags.dryRun {
+		fmt.Printf("\nLove not sent to %s (dry run)\n", recipient)
+		return nil
+	}
+	recordContacts(store.DefaultPath(), sender, recipient)
+	fmt.Printf("Love sent to %s!\n", recipient)
+	return nil
+}
+
+/*
+Return the path of the listing of love last shown by "golove get". Each
+instance has its own listing, as it has its own autocomplete cache.
+*/
+func listingPath(client *love.Client) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256([]byte(client.BaseUrl))
+	return filepath.Join(dir, "golove", fmt.Sprintf("listing-%x.json", hash[:6])), nil
+}
+
+/*
+Save the love listed by "golove get", in order, for "golove reply". Failing to
+save it is not an error; it simply cannot be replied to.
+*/
+func saveListing(client *love.Client, loves []love.Love) {
+	path, err := listingPath(client)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(loves)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(path), 0700) != nil {
+		return
+	}
+	ioutil.WriteFile(path, data, 0600)
+}
+
+/*
+Return the love last listed by "golove get".
+*/
+func loadListing(client *love.Client) ([]love.Love, error) {
+	path, err := listingPath(client)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errors.New(`no love has been listed; list some with "golove get" first`)
+	} else if err != nil {
+		return nil, err
+	}
+	var loves []love.Love
+	if err := json.Unmarshal(data, &loves); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return loves, nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/report"
+	"github.com/hacsoc/golove/store"
+	"io/ioutil"
+	"os"
+)
+
+var reportCommand = &command{
+	Name:    "report",
+	Args:    "[-user user] [-team] [-since date] [-until date] [-remote] [-db path] [-title title] [-format format] [-refresh duration] [-out file]",
+	Summary: "write an HTML love wall or Markdown report",
+	Long: `Write a "love wall" to stdout, or to a file with -out: a self-contained HTML
+page with a card for each love, the top senders, recipients and hashtags, and
+filters by person and text, to show on a TV or share after an event. For
+example:
+
+	golove report -since 2017-04-03 -title "Hackathon 2017" -out wall.html
+
+The love is read from the local database written by "golove sync". If there is
+no database, or -remote is given, it is fetched from the API instead. The wall
+covers the love sent and received by each user (-user may be repeated); by
+default, every love in the database, or the love of the configured sender in
+the API. With -team, only love sent between the users is included.
+
+With -refresh, the page reloads itself that often, so a wall regenerated by
+cron stays up to date on screen. The file is only replaced once the whole page
+has been rendered.
+
+With -format markdown, a Markdown report is written instead, for pasting into a
+wiki or newsletter: the totals, the top recipients and senders, a few notable
+quotes, and the love sent each week.`,
+	Flags: defineReportFlags,
+	Run:   runReport,
+}
+
+// The flags of golove report.
+var reportFlags struct {
+	users        listFlag
+	team         bool
+	since, until dateFlag
+	remote       bool
+	path         string
+	title        string
+	format       string
+	refresh      daysFlag
+	out          string
+}
+
+func defineReportFlags(flags *flag.FlagSet) {
+	reportFlags.users = nil
+	flags.Var(&reportFlags.users, "user", "include love sent and received by `user` (may be repeated)")
+	flags.BoolVar(&reportFlags.team, "team", false, "only include love sent between the users")
+	reportFlags.since, reportFlags.until = dateFlag{}, dateFlag{}
+	flags.Var(&reportFlags.since, "since", "only include love sent on or after `date` (YYYY-MM-DD)")
+	flags.Var(&reportFlags.until, "until", "only include love sent before `date` (YYYY-MM-DD)")
+	flags.BoolVar(&reportFlags.remote, "remote", false,
+		"fetch love from the API, rather than the local database")
+	flags.StringVar(&reportFlags.path, "db", store.DefaultPath(), "the local database `path`")
+	flags.StringVar(&reportFlags.title, "title", "",
+		"the `title` of the page (default \"Love wall\" or \"Love report\")")
+	flags.StringVar(&reportFlags.format, "format", "html", "output `format`: html or markdown")
+	reportFlags.refresh = daysFlag{}
+	flags.Var(&reportFlags.refresh, "refresh", "reload the page every `duration` (default never)")
+	flags.StringVar(&reportFlags.out, "out", "", "write the page to `file` (default stdout)")
+}
+
+func runReport(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	if reportFlags.format != "html" && reportFlags.format != "markdown" {
+		return usagef("unknown format %q", reportFlags.format)
+	}
+	if reportFlags.format == "markdown" && reportFlags.refresh.Duration > 0 {
+		return usagef("-refresh cannot be used with -format markdown")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	filter := love.LoveFilter{Since: reportFlags.since.Time, Until: reportFlags.until.Time}
+	var loves []love.Love
+	if _, statErr := os.Stat(reportFlags.path); statErr == nil && !reportFlags.remote {
+		loves, err = reportStore(reportFlags.path, filter, reportFlags.users)
+	} else {
+		if len(reportFlags.users) == 0 {
+			sender, err := cfg.sender()
+			if err != nil {
+				return err
+			}
+			reportFlags.users = listFlag{sender}
+		}
+		loves, err = reportAPI(client, filter, reportFlags.users)
+	}
+	if err != nil {
+		return err
+	}
+	if reportFlags.team {
+		loves = between(loves, reportFlags.users)
+	}
+	for i := range loves {
+		loves[i].Timestamp = loves[i].Timestamp.Local()
+	}
+	wall := &report.Wall{
+		Title:    reportFlags.title,
+		Since:    reportFlags.since.Time,
+		Until:    reportFlags.until.Time,
+		Loves:    loves,
+		Profiles: lookupProfiles(client, loveUsers(loves)),
+		Refresh:  reportFlags.refresh.Duration,
+	}
+	var page bytes.Buffer
+	if reportFlags.format == "markdown" {
+		err = wall.WriteMarkdown(&page)
+	} else {
+		err = wall.Write(&page)
+	}
+	if err != nil {
+		return err
+	}
+	if reportFlags.out == "" {
+		_, err = os.Stdout.Write(page.Bytes())
+		return err
+	}
+	return ioutil.WriteFile(reportFlags.out, page.Bytes(), 0644)
+}
+
+/*
+Return the love in the local database matching a filter, involving one of the
+users if any are given.
+*/
+func reportStore(path string, f love.LoveFilter, users []string) ([]love.Love, error) {
+	db, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	if len(users) == 0 {
+		return db.Query(f)
+	}
+	var lists [][]love.Love
+	for _, user := range users {
+		sent, received := f, f
+		sent.Sender, received.Recipient = user, user
+		for _, g := range []love.LoveFilter{sent, received} {
+			found, err := db.Query(g)
+			if err != nil {
+				return nil, err
+			}
+			lists = append(lists, found)
+		}
+	}
+	return love.Merge(lists...), nil
+}
+
+/*
+Return the love sent between the users.
+*/
+func between(loves []love.Love, users []string) []love.Love {
+	members := make(map[string]bool)
+	for _, user := range users {
+		members[user] = true
+	}
+	var result []love.Love
+	for _, l := range loves {
+		if members[l.Sender] && members[l.Recipient] {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+/*
+Fetch the love matching a filter which each of the users sent or received from
+the API.
+*/
+func reportAPI(client *love.Client, f love.LoveFilter, users []string) ([]love.Love, error) {
+	loves, errs := client.GetLoveForUsers(users, f, 0)
+	for _, user := range users {
+		if err := errs[user]; err != nil {
+			return nil, fmt.Errorf("%s: %s", user, err)
+		}
+	}
+	return loves, nil
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/schedule"
+	"github.com/hacsoc/golove/store"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var scheduleCommand = &command{
+	Name:    "schedule",
+	Args:    "[-db path] [-strict] [-force] [-dry-run [-n count]] (when | -cron rule) recipient[,recipient...] message | list | remove id...",
+	Summary: "schedule love to send later",
+	Long: `Schedule love from the configured sender, to be sent when it is due by
+"golove scheduler run". The message may be multiple arguments, as with
+"golove send", but the time must be a single argument, such as:
+
+	golove schedule "next friday 9am" darwin "thanks for shipping the release!"
+
+Times are local. See schedule.ParseTime for the forms they may take. With
+-strict, the recipients are checked now, rather than when the love is sent.
+Recipients may be aliases and groups, as with "golove send", which are resolved
+now, so later changes to a group do not change who the love is sent to.
+
+With -cron, the love recurs according to a cron rule (see schedule.ParseRule)
+instead, and there is no time argument. For example, at 9am on the 15th of
+every month:
+
+	golove schedule -cron "0 9 15 * *" darwin "happy monthiversary, mentor!"
+
+Love is not scheduled if it conflicts with scheduled love from the same sender
+to any of the same recipients, which would be sent on the same day, unless
+-force is given. With -dry-run, the conflicts and the next -n times the love
+would be sent are printed, and nothing is scheduled.
+
+"golove schedule list" lists the scheduled love, with the ID of each, and
+"golove schedule remove" removes the love with the given IDs.`,
+	Flags: defineScheduleFlags,
+	Run:   runSchedule,
+}
+
+// The flags of golove schedule.
+var scheduleFlags struct {
+	path   string
+	strict bool
+	cron   string
+	force  bool
+	dryRun bool
+	count  int
+}
+
+func defineScheduleFlags(flags *flag.FlagSet) {
+	flags.StringVar(&scheduleFlags.path, "db", store.DefaultPath(), "the local database `path`")
+	flags.BoolVar(&scheduleFlags.strict, "strict", false,
+		"refuse to schedule if any recipient does not exist")
+	flags.StringVar(&scheduleFlags.cron, "cron", "", "send the love at each occurrence of a cron `rule`")
+	flags.BoolVar(&scheduleFlags.force, "force", false, "schedule the love even if it conflicts")
+	flags.BoolVar(&scheduleFlags.dryRun, "dry-run", false,
+		"print when the love would be sent instead of scheduling it")
+	flags.IntVar(&scheduleFlags.count, "n", 5,
+		"with -dry-run, print the next `count` times recurring love would be sent")
+}
+
+func runSchedule(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if scheduleFlags.count < 1 {
+		return usagef("-n must be positive")
+	}
+	switch {
+	case flags.Arg(0) == "list" && flags.NArg() == 1:
+		return listSchedule(scheduleFlags.path)
+	case flags.Arg(0) == "remove" && flags.NArg() > 1:
+		return removeScheduled(scheduleFlags.path, flags.Args()[1:])
+	}
+	job := store.Job{}
+	var rule *schedule.Rule
+	positional := flags.Args()
+	if scheduleFlags.cron != "" {
+		if len(positional) < 2 {
+			return usagef("recipient and message are required")
+		}
+		var err error
+		if rule, err = schedule.ParseRule(scheduleFlags.cron); err != nil {
+			return usagef("%s", err)
+		}
+		job.Rule = rule.String()
+		job.At = rule.Next(time.Now())
+	} else {
+		if len(positional) < 3 {
+			return usagef("time, recipient and message are required")
+		}
+		var err error
+		if job.At, err = schedule.ParseTime(positional[0], time.Now()); err != nil {
+			return usagef("%s", err)
+		}
+		positional = positional[1:]
+	}
+	job.Recipient = positional[0]
+	job.Message = strings.Join(positional[1:], " ")
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if job.Sender, err = cfg.sender(); err != nil {
+		return err
+	}
+	groups, err := cfg.groups()
+	if err != nil {
+		return err
+	}
+	recipients, err := groups.Expand(strings.Split(job.Recipient, ","))
+	if err != nil {
+		return err
+	}
+	aliases, err := cfg.aliases()
+	if err != nil {
+		return err
+	}
+	if job.Recipient, err = aliases.ResolveList(strings.Join(recipients, ",")); err != nil {
+		return err
+	}
+	if scheduleFlags.strict {
+		if err := checkRecipients(cfg, job.Recipient); err != nil {
+			return err
+		}
+	}
+	db, err := store.Open(scheduleFlags.path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	conflicts, err := db.Conflicts(job)
+	if err != nil {
+		return err
+	}
+	if scheduleFlags.dryRun {
+		times := []time.Time{job.At}
+		if rule != nil {
+			times = append(times, rule.Occurrences(job.At, scheduleFlags.count-1)...)
+		}
+		fmt.Printf("Love to %s would be sent at:\n", job.Recipient)
+		for _, t := range times {
+			fmt.Printf("  %s\n", t.Format(scheduleLayout))
+		}
+		if len(conflicts) > 0 {
+			fmt.Println("\nIt conflicts with:")
+			printJobs(os.Stdout, conflicts)
+		}
+		return nil
+	}
+	if len(conflicts) > 0 && !scheduleFlags.force {
+		fmt.Fprintln(os.Stderr, "Scheduled love sent on the same day:")
+		printJobs(os.Stderr, conflicts)
+		return fmt.Errorf("the love conflicts with %d scheduled love; use -force to schedule anyway",
+			len(conflicts))
+	}
+	var scheduled *store.Job
+	if rule != nil {
+		scheduled, err = db.ScheduleRule(job.Sender, job.Recipient, job.Message, rule)
+	} else {
+		scheduled, err = db.Schedule(job.Sender, job.Recipient, job.Message, job.At)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Love to %s scheduled for %s (ID %d)\n", job.Recipient,
+		scheduled.At.Format(scheduleLayout), scheduled.ID)
+	return nil
+}
+
+// The layout of the times scheduled love is sent.
+const scheduleLayout = "Mon " + dateLayout + " 15:04"
+
+func listSchedule(path string) error {
+	db, err := store.Open(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	jobs, err := db.Jobs()
+	if err != nil {
+		return err
+	}
+	printJobs(os.Stdout, jobs)
+	return nil
+}
+
+/*
+Print scheduled love, with its ID, the next time it is sent, and the rule and
+failed attempts of each, if any.
+*/
+func printJobs(w io.Writer, jobs []store.Job) {
+	for _, job := range jobs {
+		fmt.Fprintf(w, "%4d  %s  %s -> %s: %s\n", job.ID, job.At.Local().Format(scheduleLayout),
+			job.Sender, job.Recipient, job.Message)
+		if job.Rule != "" {
+			fmt.Fprintf(w, "\trecurs: %s\n", job.Rule)
+		}
+		if job.Attempts > 0 {
+			fmt.Fprintf(w, "\t%d failed attempts, last: %s\n", job.Attempts, job.LastError)
+		}
+	}
+}
+
+func removeScheduled(path string, args []string) error {
+	var ids []uint64
+	for _, arg := range args {
+		id, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return usagef("invalid ID %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	db, err := store.Open(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	for _, id := range ids {
+		if err := db.Unschedule(id); err == store.ErrNoJob {
+			return fmt.Errorf("no scheduled love has ID %d", id)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Fail with a *love.UnknownRecipientsError if any of the comma separated
+recipients do not exist.
+*/
+func checkRecipients(cfg *config, recipient string) error {
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	names := strings.Split(recipient, ",")
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	unknown, err := client.ValidateRecipients(names)
+	if err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		return &love.UnknownRecipientsError{Names: unknown}
+	}
+	return nil
+}
+
+var schedulerCommand = &command{
+	Name:    "scheduler",
+	Args:    "run [-db path] [-once] [-every duration] [-metrics address]",
+	Summary: "send scheduled love when it is due",
+	Long: `Run the scheduler, which checks for due love every minute (or -every duration)
+until interrupted, and sends it. Love which fails because the API cannot be
+reached is retried, less often after each failure, up to once an hour; love
+which fails for any other reason is reported, and dropped unless it recurs. With -once, the due
+love is sent and the scheduler exits, which suits running it from cron.
+
+With -metrics, Prometheus metrics about the requests made to the love API are
+served at /metrics on the address meanwhile.`,
+	Flags: defineSchedulerFlags,
+	Run:   runScheduler,
+}
+
+// The flags of golove scheduler.
+var schedulerFlags struct {
+	path        string
+	once        bool
+	every       time.Duration
+	metricsAddr string
+}
+
+func defineSchedulerFlags(flags *flag.FlagSet) {
+	flags.StringVar(&schedulerFlags.path, "db", store.DefaultPath(), "the local database `path`")
+	flags.BoolVar(&schedulerFlags.once, "once", false, "send the due love and exit")
+	flags.DurationVar(&schedulerFlags.every, "every", time.Minute, "check for due love every `duration`")
+	flags.StringVar(&schedulerFlags.metricsAddr, "metrics", "", "serve metrics at /metrics on `address`")
+}
+
+func runScheduler(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if helpRequested(args) {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	if len(args) == 0 || args[0] != "run" {
+		return usagef("expected \"run\"")
+	}
+	if err := parseFlags(flags, args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	if schedulerFlags.every <= 0 {
+		return usagef("the duration must be positive")
+	}
+	if schedulerFlags.once {
+		if schedulerFlags.metricsAddr != "" {
+			return usagef("-metrics cannot be combined with -once")
+		}
+		failed, err := sendDue(schedulerFlags.path)
+		if err == nil && failed > 0 {
+			err = fmt.Errorf("%d love could not be sent", failed)
+		}
+		return err
+	}
+
+	if schedulerFlags.metricsAddr != "" {
+		serveMetrics(schedulerFlags.metricsAddr)
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	ticker := time.NewTicker(schedulerFlags.every)
+	defer ticker.Stop()
+	for {
+		// As with "golove flush -every", the database is only held open
+		// while sending.
+		if _, err := sendDue(schedulerFlags.path); err != nil {
+			fmt.Fprintf(os.Stderr, "golove scheduler: %s\n", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-interrupt:
+			return nil
+		}
+	}
+}
+
+/*
+Send the due love once, printing the outcome, and return the number of love
+which failed permanently.
+*/
+func sendDue(path string) (int, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return 0, err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return 0, err
+	}
+	db, err := store.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	client.SendRecords = db
+	result, err := db.SendDue(client)
+	if result != nil {
+		for _, job := range result.Sent {
+			fmt.Printf("Love sent to %s!\n", job.Recipient)
+		}
+		for _, job := range result.Failed {
+			fmt.Fprintf(os.Stderr, "golove scheduler: love to %s failed: %s\n",
+				job.Recipient, job.LastError)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(result.Failed), nil
+}
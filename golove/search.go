@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/store"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var searchCommand = &command{
+	Name:    "search",
+	Args:    "[-user user] [-regex] [-case] [-remote] [-db path] [-limit n] [-absolute] [-output format] query...",
+	Summary: "search the messages of love",
+	Long: `List love whose message contains the query, newest first, highlighting the
+matches on a terminal. Case is ignored unless -case is given. With -regex, the
+query is a regular expression, in the syntax of Go's regexp package.
+
+The love is searched in the local database written by "golove sync". If there
+is no database, or -remote is given, the history of the user is fetched from
+the API and searched instead, which is much slower. With -user, only love sent
+or received by the user is searched; by default, every love in the database is
+searched, or the love of the configured sender in the API.`,
+	Flags: defineSearchFlags,
+	Run:   runSearch,
+}
+
+// The flags of golove search.
+var searchFlags struct {
+	user          string
+	regex         bool
+	caseSensitive bool
+	remote        bool
+	path          string
+	limit         int64
+	absolute      bool
+	output        string
+}
+
+func defineSearchFlags(flags *flag.FlagSet) {
+	flags.StringVar(&searchFlags.user, "user", "", "only search love sent or received by `user`")
+	flags.BoolVar(&searchFlags.regex, "regex", false, "treat the query as a regular expression")
+	flags.BoolVar(&searchFlags.caseSensitive, "case", false, "match case")
+	flags.BoolVar(&searchFlags.remote, "remote", false, "search the API, rather than the local database")
+	flags.StringVar(&searchFlags.path, "db", store.DefaultPath(), "the local database `path`")
+	flags.Int64Var(&searchFlags.limit, "limit", 0, "list at most `n` love (default all)")
+	flags.BoolVar(&searchFlags.absolute, "absolute", false,
+		"show the date and time love was sent, rather than how long ago")
+	addOutputFlag(flags, &searchFlags.output)
+}
+
+func runSearch(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(searchFlags.output); err != nil {
+		return err
+	}
+	query := strings.Join(flags.Args(), " ")
+	if query == "" {
+		return usagef("a query is required")
+	}
+	pattern, err := searchPattern(query, searchFlags.regex, searchFlags.caseSensitive)
+	if err != nil {
+		return usagef("invalid regular expression: %s", err)
+	}
+	var loves []love.Love
+	if _, statErr := os.Stat(searchFlags.path); statErr == nil && !searchFlags.remote {
+		loves, err = searchStore(searchFlags.path, pattern, searchFlags.user)
+	} else {
+		loves, err = searchAPI(pattern, searchFlags.user)
+	}
+	if err != nil {
+		return err
+	}
+	if searchFlags.limit > 0 && int64(len(loves)) > searchFlags.limit {
+		loves = loves[:searchFlags.limit]
+	}
+	records := loveRecords(loves)
+	text := loveText{Relative: !searchFlags.absolute, Style: fileStyle(os.Stdout), Highlight: pattern}
+	records.Text = func(w io.Writer, i int) {
+		text.write(w, loves[i])
+	}
+	return page(func(w io.Writer) error {
+		return records.write(w, searchFlags.output)
+	})
+}
+
+/*
+Compile a search query: a literal string unless regex is set, which ignores
+case unless caseSensitive is set.
+*/
+func searchPattern(query string, regex, caseSensitive bool) (*regexp.Regexp, error) {
+	if !regex {
+		query = regexp.QuoteMeta(query)
+	}
+	if !caseSensitive {
+		query = "(?i)" + query
+	}
+	return regexp.Compile(query)
+}
+
+/*
+Search the love in the local database, involving the user if one is given.
+*/
+func searchStore(path string, pattern *regexp.Regexp, user string) ([]love.Love, error) {
+	db, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	if user == "" {
+		return db.Search(pattern, love.LoveFilter{})
+	}
+	sent, err := db.Search(pattern, love.LoveFilter{Sender: user})
+	if err != nil {
+		return nil, err
+	}
+	received, err := db.Search(pattern, love.LoveFilter{Recipient: user})
+	if err != nil {
+		return nil, err
+	}
+	return love.Merge(sent, received), nil
+}
+
+/*
+Fetch the whole history of the user (the configured sender by default) from the
+API, and search it.
+*/
+func searchAPI(pattern *regexp.Regexp, user string) ([]love.Love, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return nil, err
+	}
+	if user == "" {
+		if user, err = cfg.sender(); err != nil {
+			return nil, err
+		}
+	}
+	sent, err := client.GetLoveAll(user, "")
+	if err != nil {
+		return nil, err
+	}
+	received, err := client.GetLoveAll("", user)
+	if err != nil {
+		return nil, err
+	}
+	var matches []love.Love
+	for _, l := range love.Merge(sent, received) {
+		if pattern.MatchString(l.Message) {
+			matches = append(matches, l)
+		}
+	}
+	return matches, nil
+}
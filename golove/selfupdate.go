@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// The file of a release listing the SHA-256 checksum of each binary.
+const checksumsAsset = "checksums.txt"
+
+var selfUpdateCommand = &command{
+	Name:    "self-update",
+	Args:    "[-force] [-dry-run]",
+	Summary: "replace golove with its latest release",
+	Long: `Download the latest release of golove for this operating system and
+architecture, and replace the running binary with it.
+
+Each release has a binary for each platform, named for it, such as
+golove_linux_amd64 or golove_windows_amd64.exe, and a checksums.txt file
+listing the SHA-256 checksum of each, in the format of sha256sum. The binary is
+only installed if its checksum matches. It is written beside the running
+binary, then renamed over it, so that golove is never left half written.
+
+Nothing is done if golove is already the latest version, unless -force is
+given. -dry-run checks for and verifies the release, but does not install it.
+Releases are found as by "golove version -check".`,
+	Flags: defineSelfUpdateFlags,
+	Run:   runSelfUpdate,
+}
+
+// The flags of golove self-update.
+var selfUpdateFlags struct {
+	force  bool
+	dryRun bool
+}
+
+func defineSelfUpdateFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&selfUpdateFlags.force, "force", false,
+		"install the latest release even if it is not newer")
+	flags.BoolVar(&selfUpdateFlags.dryRun, "dry-run", false,
+		"download and verify the latest release, but do not install it")
+}
+
+func runSelfUpdate(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	latest, err := latestRelease()
+	if err != nil {
+		return fmt.Errorf("checking for a newer release: %s", err)
+	}
+	if compareVersions(latest.Version, version) <= 0 && !selfUpdateFlags.force {
+		fmt.Printf("golove %s is up to date.\n", version)
+		return nil
+	}
+	name := releaseBinary(runtime.GOOS, runtime.GOARCH)
+	binaryURL, ok := latest.Assets[name]
+	if !ok {
+		return fmt.Errorf("golove %s has no release for %s/%s: %s",
+			latest.Version, runtime.GOOS, runtime.GOARCH, latest.URL)
+	}
+	checksumsURL, ok := latest.Assets[checksumsAsset]
+	if !ok {
+		return fmt.Errorf("golove %s has no %s to verify the release with", latest.Version, checksumsAsset)
+	}
+	checksums, err := download(checksumsURL)
+	if err != nil {
+		return err
+	}
+	want, err := findChecksum(checksums, name)
+	if err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(exe), ".golove-update-")
+	if err != nil {
+		return fmt.Errorf("cannot write beside %s: %s", exe, err)
+	}
+	defer os.Remove(tmp.Name())
+	fmt.Printf("Downloading golove %s for %s/%s...\n", latest.Version, runtime.GOOS, runtime.GOARCH)
+	got, err := downloadTo(tmp, binaryURL)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("%s: checksum %s does not match %s in %s", name, got, want, checksumsAsset)
+	}
+	if selfUpdateFlags.dryRun {
+		fmt.Printf("Verified golove %s; not installed (dry run)\n", latest.Version)
+		return nil
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := replaceFile(tmp.Name(), exe); err != nil {
+		return err
+	}
+	fmt.Printf("Updated golove %s to %s.\n", version, latest.Version)
+	return nil
+}
+
+/*
+Return the name of the release binary for an operating system and
+architecture.
+*/
+func releaseBinary(goos, goarch string) string {
+	name := "golove_" + goos + "_" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+/*
+Return the checksum of a file listed in the output of sha256sum.
+*/
+func findChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(strings.NewReader(string(checksums)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// sha256sum marks files read in binary mode with a "*".
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no checksum for %s", checksumsAsset, name)
+}
+
+/*
+Return the body of a file of a release.
+*/
+func download(url string) ([]byte, error) {
+	resp, err := getRelease(url, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+/*
+Write a file of a release to w, returning its SHA-256 checksum in hex.
+*/
+func downloadTo(w io.Writer, url string) (string, error) {
+	// Binaries are larger, and may come through slow proxies.
+	resp, err := getRelease(url, 5*time.Minute)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, hash), resp.Body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func getRelease(url string, timeout time.Duration) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "golove/"+version)
+	resp, err := releaseClient(timeout).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+/*
+Replace the file at path with the file at src, by renaming it. Windows does not
+allow a running binary to be replaced, but does allow it to be renamed, so
+there it is moved aside to path.old first.
+*/
+func replaceFile(src, path string) error {
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return err
+		}
+		if err := os.Rename(src, path); err != nil {
+			os.Rename(old, path)
+			return err
+		}
+		return nil
+	}
+	return os.Rename(src, path)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+const testDigest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func TestFindChecksum(t *testing.T) {
+	for _, test := range []struct {
+		checksums string
+		expected  string
+		error     string
+	}{
+		{testDigest + "  golove_linux_amd64\n", testDigest, ""},
+		// sha256sum marks files read in binary mode with a "*".
+		{testDigest + " *golove_linux_amd64\n", testDigest, ""},
+		{"9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08  golove_linux_amd64",
+			testDigest, ""},
+		{"0000  golove_darwin_arm64\n" + testDigest + "  golove_linux_amd64\n", testDigest, ""},
+		{testDigest + "  golove_linux_amd64.exe\n", "",
+			"checksums.txt has no checksum for golove_linux_amd64"},
+		{testDigest + "  golove_linux_amd64 extra\n", "",
+			"checksums.txt has no checksum for golove_linux_amd64"},
+		{"", "", "checksums.txt has no checksum for golove_linux_amd64"},
+	} {
+		checksum, err := findChecksum([]byte(test.checksums), "golove_linux_amd64")
+		if test.error != "" {
+			assert.EqualError(t, err, test.error, test.checksums)
+		} else if assert.NoError(t, err, test.checksums) {
+			assert.Equal(t, checksum, test.expected, test.checksums)
+		}
+	}
+}
+
+func TestReplaceFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "golove")
+	src := filepath.Join(dir, "golove.new")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("old"), 0755))
+	assert.NoError(t, ioutil.WriteFile(src, []byte("new"), 0755))
+	assert.NoError(t, replaceFile(src, path))
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), "new")
+	assert.NoFileExists(t, src)
+
+	// A failed replacement leaves the original in place.
+	assert.Error(t, replaceFile(filepath.Join(dir, "missing"), path))
+	data, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), "new")
+}
+
+/*
+Serve a release of golove 99.0.0 holding binary for this platform, with
+checksums listing checksums.
+*/
+func newReleaseServer(t *testing.T, binary, checksums string) {
+	name := releaseBinary(runtime.GOOS, runtime.GOARCH)
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	mux.HandleFunc("/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"tag_name": "v99.0.0",
+			"html_url": server.URL + "/v99.0.0",
+			"assets": []map[string]string{
+				{"name": name, "browser_download_url": server.URL + "/" + name},
+				{"name": checksumsAsset, "browser_download_url": server.URL + "/" + checksumsAsset},
+			},
+		})
+	})
+	mux.HandleFunc("/"+name, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(binary))
+	})
+	mux.HandleFunc("/"+checksumsAsset, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(checksums))
+	})
+	t.Setenv("GOLOVE_RELEASES_URL", server.URL+"/latest")
+}
+
+func TestSelfUpdateVerifies(t *testing.T) {
+	setTestEnv(t, nil)
+	name := releaseBinary(runtime.GOOS, runtime.GOARCH)
+	sum := sha256.Sum256([]byte("binary"))
+	newReleaseServer(t, "binary", hex.EncodeToString(sum[:])+" *"+name+"\n")
+	status, stdout, stderr := runGolove(t, "self-update", "-dry-run")
+	assert.Equal(t, status, exitOK, stderr)
+	assert.Contains(t, stdout, "Verified golove 99.0.0; not installed (dry run)\n")
+
+	newReleaseServer(t, "tampered", hex.EncodeToString(sum[:])+" *"+name+"\n")
+	status, stdout, stderr = runGolove(t, "self-update", "-dry-run")
+	assert.Equal(t, status, exitError)
+	assert.NotContains(t, stdout, "Verified")
+	assert.Contains(t, stderr, name+": checksum ")
+	assert.Contains(t, stderr, " does not match "+hex.EncodeToString(sum[:]))
+
+	newReleaseServer(t, "binary", hex.EncodeToString(sum[:])+"  golove_plan9_386\n")
+	status, _, stderr = runGolove(t, "self-update", "-dry-run")
+	assert.Equal(t, status, exitError)
+	assert.Contains(t, stderr, "checksums.txt has no checksum for "+name)
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/store"
+	"golang.org/x/term"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+var sendCommand = &command{
+	Name:    "send",
+	Args:    "[-strict] [-verify] [-dry-run] [-yes] [-allow-duplicate] [-value value]... [-keep-shortcodes] [-queue] [-db path] [-template] [-data file] recipient[,recipient...] [message | -] | -i",
+	Summary: "send love to one or more recipients",
+	Long: `Send love from the configured sender. The message may be multiple arguments,
+which are joined with a space separator. Without a message, it is written in
+$VISUAL or $EDITOR (vi by default), like the message of "git commit", which is
+easier for love of several paragraphs. With -i, the recipients and message are
+entered interactively instead, and pressing tab completes usernames, suggesting
+the people love has been sent to most often and most recently first.
+
+If the message is "-", or it is missing and stdin is not a terminal, the
+message is read from stdin, so that scripts can send love:
+
+	echo "Thanks for fixing the build!" | golove send darwin -
+
+Love for more than confirm_recipients (LOVE_CONFIRM_RECIPIENTS, 5 by default)
+recipients is only sent once the resolved list of recipients has been shown and
+confirmed, or with -yes, so that a mistake does not spam the whole
+organization. Setting confirm_recipients to 0 turns this off. If max_recipients
+(LOVE_MAX_RECIPIENTS) is set, love for more recipients than that is refused
+outright.
+
+The server accepts love for several recipients even if some of them do not
+exist. With -verify, each recipient is checked, and sent their own love, and
+whether it reached them is reported separately for each.
+
+A recipient may also be given as an alias, such as @slack:U012AB3CD, which is
+replaced by the username it maps to in the aliases file (see "golove alias"),
+or as a group, such as @backend-team, which is replaced by its members (see
+"golove group").
+
+Emoji shortcodes in the message, such as :tada: and :heart:, are replaced by
+the emoji they stand for, as in chat apps, unless -keep-shortcodes is given or
+the keep_shortcodes setting (LOVE_KEEP_SHORTCODES) is true.
+
+With -value, which may be repeated, the love is tagged with a company value,
+on instances which support them.
+
+If duplicate_window (LOVE_DUPLICATE_WINDOW) is set to a duration, such as 10m,
+love is refused if the same message was already sent to a recipient within it,
+as found in the sender's recent love in the API, so that a script which is run
+twice does not send love twice. With -allow-duplicate, a warning is printed and
+the love is sent anyway.
+
+With -dry-run, the request which would send the love is printed, with the API
+key redacted, and nothing is sent.
+
+With -queue, love which cannot be sent because the network or API is down is
+added to the queue in the local database, to be sent later by "golove flush".
+If the API might have received it anyway, such as when the request timed out,
+"golove flush" only sends it if it is not found among the sender's recent love.
+
+With -template, the message is a text/template, which is rendered separately
+for each recipient, and each recipient is sent their own love. The template may
+use .Sender and .Recipient, and any field given for the recipient in the -data
+file: either a JSON object of objects keyed by recipient, or a CSV file with a
+header row whose "recipient" column names the recipient of each row. For
+example:
+
+	golove send -template -data reasons.csv darwin,jeremy '{{.FirstName}}, thanks for {{.Reason}}!'`,
+	Flags: defineSendFlags,
+	Run:   runSend,
+}
+
+// The flags of golove send.
+var sendFlags struct {
+	interactive    bool
+	strict         bool
+	verify         bool
+	dryRun         bool
+	queue          bool
+	path           string
+	template       bool
+	dataPath       string
+	values         listFlag
+	yes            bool
+	allowDuplicate bool
+	keepShortcodes bool
+}
+
+func defineSendFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&sendFlags.interactive, "i", false,
+		"prompt for recipients, with tab completion, and the message")
+	flags.BoolVar(&sendFlags.strict, "strict", false,
+		"refuse to send if any recipient does not exist")
+	flags.BoolVar(&sendFlags.verify, "verify", false,
+		"send each recipient their own love, and report which did not receive it")
+	flags.BoolVar(&sendFlags.dryRun, "dry-run", false,
+		"print the request which would be made instead of sending love")
+	flags.BoolVar(&sendFlags.queue, "queue", false,
+		"queue the love to send later if the API cannot be reached")
+	flags.StringVar(&sendFlags.path, "db", store.DefaultPath(),
+		"the local database `path`, for -queue and suggesting recipients")
+	flags.BoolVar(&sendFlags.template, "template", false,
+		"render the message separately for each recipient as a template")
+	flags.StringVar(&sendFlags.dataPath, "data", "", "JSON or CSV `file` of template fields by recipient")
+	sendFlags.values = nil
+	flags.Var(&sendFlags.values, "value", "tag the love with the company `value` (may be repeated)")
+	flags.BoolVar(&sendFlags.yes, "yes", false, "send to many recipients without asking for confirmation")
+	flags.BoolVar(&sendFlags.allowDuplicate, "allow-duplicate", false,
+		"send love even if it was already sent within duplicate_window")
+	flags.BoolVar(&sendFlags.keepShortcodes, "keep-shortcodes", false,
+		"send emoji shortcodes such as :tada: as they are written")
+}
+
+func runSend(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if sendFlags.interactive && flags.NArg() != 0 {
+		return usagef("-i does not take arguments")
+	} else if !sendFlags.interactive && flags.NArg() == 0 {
+		return usagef("a recipient is required")
+	}
+	if sendFlags.template && sendFlags.queue {
+		return usagef("-template and -queue cannot be combined")
+	} else if sendFlags.template && sendFlags.verify {
+		return usagef("-template and -verify cannot be combined")
+	} else if len(sendFlags.values) > 0 && (sendFlags.template || sendFlags.verify || sendFlags.queue) {
+		return usagef("-value cannot be combined with -template, -verify or -queue")
+	} else if sendFlags.dataPath != "" && !sendFlags.template {
+		return usagef("-data requires -template")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	sender, err := cfg.sender()
+	if err != nil {
+		return err
+	}
+	client.StrictRecipients = sendFlags.strict
+	if sendFlags.keepShortcodes {
+		client.MessageOptions.KeepShortcodes = true
+	}
+	if sendFlags.dryRun {
+		client.DryRun = os.Stdout
+	}
+	if sendFlags.allowDuplicate {
+		client.OnDuplicate = func(err *love.DuplicateLoveError) error {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+			return nil
+		}
+	}
+	var recipient, message string
+	if !sendFlags.interactive {
+		aliases, err := cfg.aliases()
+		if err != nil {
+			return err
+		}
+		recipients, err := client.Groups.Expand(strings.Split(flags.Arg(0), ","))
+		if err != nil {
+			return err
+		}
+		if recipient, err = aliases.ResolveList(strings.Join(recipients, ",")); err != nil {
+			return err
+		}
+	}
+	if sendFlags.interactive {
+		defer useAutocompleteCache(client)()
+		frequent := frequentRecipients(sendFlags.path, sender, "")
+		if recipient, message, err = compose(client, frequent); err != nil {
+			return err
+		}
+	} else if flags.NArg() == 1 && term.IsTerminal(int(os.Stdin.Fd())) {
+		recipient = normalizeRecipients(recipient)
+		if message, err = composeInEditor(sender, recipient); err != nil {
+			return err
+		}
+	} else if flags.NArg() == 1 || flags.NArg() == 2 && flags.Arg(1) == "-" {
+		if message, err = readMessage(os.Stdin); err != nil {
+			return err
+		}
+	} else {
+		message = strings.Join(flags.Args()[1:], " ")
+	}
+	if !sendFlags.yes && !sendFlags.dryRun {
+		threshold, err := cfg.confirmThreshold()
+		if err != nil {
+			return err
+		}
+		if err := confirmRecipients(client, recipient, threshold); err != nil {
+			return err
+		}
+	}
+	if sendFlags.template || sendFlags.verify {
+		if sendFlags.template {
+			err = sendTemplate(client, sender, recipient, message, sendFlags.dataPath, sendFlags.dryRun)
+		} else {
+			err = sendVerified(client, sender, recipient, message,
+				sendFlags.queue, sendFlags.path, sendFlags.dryRun)
+		}
+		if err == nil && !sendFlags.dryRun {
+			recordContacts(sendFlags.path, sender, recipient)
+		}
+		return err
+	}
+	started := time.Now()
+	err = client.SendLoveValues(sender, recipient, message, sendFlags.values)
+	if err != nil && sendFlags.queue && love.IsTemporary(err) {
+		return enqueue(sendFlags.path, sender, recipient, message, started, err)
+	} else if err != nil {
+		return err
+	}
+	if sendFlags.dryRun {
+		fmt.Printf("\nLove not sent to %s (dry run)\n", recipient)
+		return nil
+	}
+	recordContacts(sendFlags.path, sender, recipient)
+	fmt.Printf("Love sent to %s!\n", recipient)
+	return nil
+}
+
+/*
+If there are more than threshold recipients, list them with their display
+names and ask whether to send the love. Without a terminal to ask on, -yes is
+required instead. A threshold of 0 never asks.
+*/
+func confirmRecipients(client *love.Client, recipient string, threshold int) error {
+	names := strings.Split(normalizeRecipients(recipient), ",")
+	if threshold <= 0 || len(names) <= threshold {
+		return nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("sending to %d recipients requires confirmation: pass -yes",
+			len(names))
+	}
+	fmt.Printf("Love will be sent to %d recipients:\n", len(names))
+	for _, name := range names {
+		fmt.Printf("  %-20s %s\n", name, displayName(client, name))
+	}
+	fmt.Print("Send? [y/N] ")
+	var answer string
+	fmt.Scanln(&answer)
+	if answer = strings.ToLower(answer); answer != "y" && answer != "yes" {
+		return errAborted
+	}
+	return nil
+}
+
+/*
+Return the display name of a user, looked up with Autocomplete.
+*/
+func displayName(client *love.Client, username string) string {
+	users, err := client.Autocomplete(username)
+	if err != nil {
+		return "(" + err.Error() + ")"
+	}
+	for _, u := range users {
+		if u.Username == username {
+			return u.Display
+		}
+	}
+	return "(unknown user)"
+}
+
+/*
+Read a message from r, such as stdin, trimming surrounding whitespace.
+*/
+func readMessage(r io.Reader) (string, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	message := strings.TrimSpace(string(data))
+	if message == "" {
+		return "", errors.New("the message read from stdin is empty")
+	}
+	return message, nil
+}
+
+/*
+Send love with SendLovesVerified, and report the outcome for each recipient.
+With queue, love for recipients who could not be reached for a temporary reason
+is queued.
+*/
+func sendVerified(client *love.Client, sender, recipient, message string, queue bool,
+	path string, dryRun bool) error {
+	recipients := strings.Split(normalizeRecipients(recipient), ",")
+	started := time.Now()
+	err := client.SendLovesVerified(sender, recipients, message)
+	var multiErr *love.MultiError
+	if err == nil {
+		multiErr = &love.MultiError{Sent: recipients}
+	} else if !errors.As(err, &multiErr) {
+		return err
+	}
+	for _, r := range multiErr.Sent {
+		if dryRun {
+			fmt.Printf("Love not sent to %s (dry run)\n", r)
+		} else {
+			fmt.Printf("Love sent to %s!\n", r)
+		}
+	}
+	failed := 0
+	for _, failure := range multiErr.Failed {
+		if queue && love.IsTemporary(failure.Err) {
+			err = enqueue(path, sender, failure.Recipient, message, started,
+				failure.Err)
+		} else {
+			err = failure
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "golove send: %s\n", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("love could not be sent to %d recipients", failed)
+	}
+	return nil
+}
+
+/*
+Queue love which failed to send after started. Since it may have reached the
+server anyway, such as if the request timed out, the attempt is recorded, so
+that "golove flush" looks for the love in the API before sending it again.
+*/
+func enqueue(path, sender, recipient, message string, started time.Time, sendErr error) error {
+	db, err := store.Open(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	p, err := db.Enqueue(sender, recipient, message)
+	if err != nil {
+		return err
+	}
+	if err := db.PutSendRecord(&love.SendRecord{Key: p.Key, Started: started}); err != nil {
+		return err
+	}
+	fmt.Printf("Love to %s queued: %s\n", recipient, sendErr)
+	return nil
+}
@@ -0,0 +1,502 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/alias"
+	"github.com/hacsoc/golove/feed"
+	"github.com/hacsoc/golove/github"
+	"github.com/hacsoc/golove/graphql"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/lovepb"
+	"github.com/hacsoc/golove/metrics"
+	"github.com/hacsoc/golove/pagerduty"
+	"github.com/hacsoc/golove/proxy"
+	"github.com/hacsoc/golove/slack"
+	"google.golang.org/grpc"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+/*
+A server run by "golove serve". Its flags are parsed from the arguments
+following the mode, and Run returns the handler to serve.
+*/
+type serveMode struct {
+	Name    string
+	Summary string
+	// Defines the flags of the mode, if it has any.
+	Flags func(flags *flag.FlagSet)
+	Run   func(cfg *config) (http.Handler, error)
+}
+
+var serveModes = []*serveMode{
+	{"slack", "Slack slash command bridge", defineServeSlackFlags, serveSlack},
+	{"github", "GitHub webhooks which send love", defineServeGitHubFlags, serveGitHub},
+	{"pagerduty", "PagerDuty webhooks which thank responders", defineServePagerDutyFlags, servePagerDuty},
+	{"feed", "Atom feed of recent love", defineServeFeedFlags, serveFeed},
+	{"graphql", "GraphQL gateway to the love API", defineServeGraphQLFlags, serveGraphQL},
+	{"grpc", "gRPC service for the love API", defineServeGRPCFlags, serveGRPC},
+	{"proxy", "caching proxy for the love API", defineServeProxyFlags, serveProxy},
+}
+
+/*
+Return a FlagSet for the mode, with its flags defined, which prints the usage
+of cmd, golove serve, on error.
+*/
+func (mode *serveMode) flagSet(cmd *command) *flag.FlagSet {
+	flags := flag.NewFlagSet("serve "+mode.Name, flag.ContinueOnError)
+	flags.Usage = func() {
+		cmd.usage(flags)
+	}
+	if mode.Flags != nil {
+		mode.Flags(flags)
+	}
+	return flags
+}
+
+var serveCommand = &command{
+	Name:    "serve",
+	Args:    "[-addr address] mode [arguments]",
+	Summary: "run an HTTP server which bridges another service to love",
+	Long: `Run an HTTP server until interrupted. Besides the requests of the mode, the
+server serves Prometheus metrics about the requests made to the love API at
+/metrics. The server listens on -addr, which is only reachable from this
+machine by default; webhooks from other services need an address such as
+:8080, which listens on every interface. The modes are:
+
+	slack      Slack slash command bridge
+	github     GitHub webhooks which send love
+	pagerduty  PagerDuty webhooks which thank responders
+	feed       Atom feed of recent love
+	graphql    GraphQL gateway to the love API
+	grpc       gRPC service for the love API
+	proxy      caching proxy for the love API
+
+In slack mode, the server handles the requests of a Slack slash command at
+any other path. Its arguments are:
+
+	golove serve slack [-users file] [-trust-names]
+
+The app's signing secret must be set in slack_signing_secret (or
+SLACK_SIGNING_SECRET). The users file is a JSON object which maps Slack user IDs
+to love usernames:
+
+	{"U012AB3CD": "hammy"}
+
+Aliases of the kind slack (see "golove alias") are used for users who are not
+in the file. Only users who are mapped may send love, since anyone can change
+their Slack username to someone else's; with -trust-names, other users send
+love as their Slack username. Recipients who are not mapped are always assumed
+to have the same Slack and love username.
+
+In github mode, the server receives GitHub webhooks at any other path, and
+sends love for merged pull requests and closed issues, as described in the
+github package. Its arguments are:
+
+	golove serve github [-users file] [-rules file]
+
+The webhook's secret must be set in github_webhook_secret (or
+GITHUB_WEBHOOK_SECRET). The users file maps GitHub logins to love usernames, as
+for "golove ci", and defaults to the ci_users setting; github aliases are used
+for logins which are not in it. The rules file is a JSON array of rules, such
+as:
+
+	[{"event": "pull_request.merged", "from": "author", "to": ["reviewers"],
+	  "message": "Thanks for reviewing {{.Title}}!"}]
+
+By default, the author of a merged pull request thanks its reviewers and
+whoever merged it, and the author of a closed issue thanks whoever closed it.
+Rules without "from" send love from the configured sender.
+
+In pagerduty mode, the server receives PagerDuty webhooks at any other path,
+and when an incident is resolved, sends love to everyone who was assigned to,
+acknowledged or resolved it, as described in the pagerduty package. Its
+arguments are:
+
+	golove serve pagerduty [-users file] [-from user] [-message template]
+
+The webhook subscription's signing secret must be set in
+pagerduty_webhook_secret (or PAGERDUTY_WEBHOOK_SECRET). The love is sent from
+the -from user, such as a bot, or else the configured sender. The users file
+is a JSON object which maps PagerDuty user IDs or email addresses to love
+usernames:
+
+	{"PXPGF42": "hammy", "darwin@example.com": "darwin"}
+
+Aliases of the kinds pagerduty and email are used for users who are not in the
+file. Email addresses are only known if pagerduty_token (or PAGERDUTY_TOKEN)
+holds a PagerDuty REST API token to look them up with. Addresses in
+email_domain which are not in the file are assumed to be username@email_domain.
+The message is a template (see "golove send -template") holding the incident's
+.Number, .Title, .URL, .Service, .Priority and .Urgency, and the responder's
+.Name.
+
+In feed mode, the server serves an Atom feed of the newest love at /feed.atom.
+Its arguments are:
+
+	golove serve feed [-user user] [-limit n] [-title title]
+
+The feed shows the love received by each -user, which may be repeated, unless
+the request's query names a sender or recipient, as in
+/feed.atom?recipient=hammy. See the feed package for the query parameters.
+
+In graphql mode, the server answers GraphQL queries POSTed to /graphql, as
+described in the graphql package. Its arguments are:
+
+	golove serve graphql [-cache-ttl duration] [-read-only]
+
+Query results are cached for -cache-ttl. The sendLove mutation sends love as
+anyone, so it is only allowed for requests bearing the token in serve_token (or
+LOVE_SERVE_TOKEN), as "Authorization: Bearer <token>". Without serve_token, or
+with -read-only, it is disabled.
+
+In grpc mode, the server implements the LoveService defined in
+lovepb/love.proto, over HTTP/2 without TLS. Its arguments are:
+
+	golove serve grpc [-read-only]
+
+As in graphql mode, SendLove is only allowed for calls bearing serve_token in
+their authorization metadata, as "Bearer <token>", and is disabled without
+serve_token or with -read-only, failing with PermissionDenied.
+
+In proxy mode, the server forwards requests to the love API at base_url,
+caching the love and autocomplete results for each API key, as described in the
+proxy package. Its arguments are:
+
+	golove serve proxy [-ttl duration]
+
+Clients use the server in place of the love server: for example, with
+-addr :8080, their base URL is http://host:8080/api. Only base_url is required,
+since clients supply their own API keys. Keys and tokens sent in the
+Authorization and X-Api-Key headers are forwarded, as are those in the headers
+named by api_key_header and auth_header.`,
+	Flags: defineServeFlags,
+	Run:   runServe,
+}
+
+// The flags of golove serve.
+var serveFlags struct {
+	addr string
+}
+
+func defineServeFlags(flags *flag.FlagSet) {
+	flags.StringVar(&serveFlags.addr, "addr", "127.0.0.1:8080", "listen on `address`")
+}
+
+func runServe(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return usagef("a mode is required")
+	}
+	var mode *serveMode
+	for _, m := range serveModes {
+		if m.Name == flags.Arg(0) {
+			mode = m
+		}
+	}
+	if mode == nil {
+		return usagef("unknown mode %q", flags.Arg(0))
+	}
+	modeFlags := mode.flagSet(cmd)
+	if err := parseFlags(modeFlags, flags.Args()[1:]); err != nil {
+		return err
+	}
+	if modeFlags.NArg() != 0 {
+		return usagef("unexpected argument %q", modeFlags.Arg(0))
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	clientMetrics = metrics.New()
+	handler, err := mode.Run(cfg)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", clientMetrics.Handler())
+	mux.Handle("/", handler)
+	fmt.Fprintf(os.Stderr, "serving %s on %s\n", mode.Name, serveFlags.addr)
+	server := &http.Server{Addr: serveFlags.addr, Handler: mux, Protocols: new(http.Protocols)}
+	// gRPC clients use HTTP/2 without TLS.
+	server.Protocols.SetHTTP1(true)
+	server.Protocols.SetUnencryptedHTTP2(true)
+	return server.ListenAndServe()
+}
+
+// The flags of golove serve slack.
+var serveSlackFlags struct {
+	usersPath  string
+	trustNames bool
+}
+
+func defineServeSlackFlags(flags *flag.FlagSet) {
+	flags.StringVar(&serveSlackFlags.usersPath, "users", "", "map Slack user IDs to usernames with JSON `file`")
+	flags.BoolVar(&serveSlackFlags.trustNames, "trust-names", false,
+		"let users who are not mapped send love as their Slack username")
+}
+
+func serveSlack(cfg *config) (http.Handler, error) {
+	if cfg.SlackSigningSecret == "" {
+		return nil, cfg.missing("slack_signing_secret")
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return nil, err
+	}
+	handler := &slack.Handler{
+		Service:        client,
+		SigningSecret:  cfg.SlackSigningSecret,
+		TrustUserNames: serveSlackFlags.trustNames,
+	}
+	if handler.Users, err = cfg.userMap(serveSlackFlags.usersPath, alias.Slack); err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+/*
+Read a JSON file mapping the IDs of users in another service, such as Slack
+user IDs, to love usernames, and add the aliases of the given kinds which it
+does not map. An empty path maps only the aliases.
+*/
+func (c *config) userMap(path string, kinds ...string) (map[string]string, error) {
+	users := make(map[string]string)
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &users); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+	aliases, err := c.aliases()
+	if err != nil {
+		return nil, err
+	}
+	for _, kind := range kinds {
+		for id, username := range aliases.Kind(kind) {
+			if _, ok := users[id]; !ok {
+				users[id] = username
+			}
+		}
+	}
+	return users, nil
+}
+
+// The flags of golove serve github.
+var serveGitHubFlags struct {
+	usersPath string
+	rulesPath string
+}
+
+func defineServeGitHubFlags(flags *flag.FlagSet) {
+	flags.StringVar(&serveGitHubFlags.usersPath, "users", "",
+		"map GitHub logins to usernames with JSON `file` (default ci_users)")
+	flags.StringVar(&serveGitHubFlags.rulesPath, "rules", "", "send love by the rules in JSON `file`")
+}
+
+func serveGitHub(cfg *config) (http.Handler, error) {
+	if cfg.GitHubWebhookSecret == "" {
+		return nil, cfg.missing("github_webhook_secret")
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return nil, err
+	}
+	handler := &github.Handler{
+		Service: client,
+		Secret:  cfg.GitHubWebhookSecret,
+		Sender:  cfg.Sender,
+	}
+	usersPath := serveGitHubFlags.usersPath
+	if usersPath == "" {
+		usersPath = cfg.CIUsers
+	}
+	if handler.Users, err = ciUsers(cfg, usersPath, alias.GitHub); err != nil {
+		return nil, err
+	}
+	if serveGitHubFlags.rulesPath != "" {
+		data, err := ioutil.ReadFile(serveGitHubFlags.rulesPath)
+		if err != nil {
+			return nil, err
+		}
+		if handler.Rules, err = github.ParseRules(data); err != nil {
+			return nil, fmt.Errorf("%s: %s", serveGitHubFlags.rulesPath, err)
+		}
+	}
+	return handler, nil
+}
+
+// The flags of golove serve pagerduty.
+var servePagerDutyFlags struct {
+	usersPath string
+	from      string
+	message   string
+}
+
+func defineServePagerDutyFlags(flags *flag.FlagSet) {
+	flags.StringVar(&servePagerDutyFlags.usersPath, "users", "",
+		"map PagerDuty user IDs or emails to usernames with JSON `file`")
+	flags.StringVar(&servePagerDutyFlags.from, "from", "", "send love from `user` (default sender)")
+	flags.StringVar(&servePagerDutyFlags.message, "message", pagerduty.DefaultMessage, "the message `template`")
+}
+
+func servePagerDuty(cfg *config) (http.Handler, error) {
+	if _, err := love.ParseMessageTemplate(servePagerDutyFlags.message); err != nil {
+		return nil, usagef("invalid template: %s", err)
+	}
+	if cfg.PagerDutyWebhookSecret == "" {
+		return nil, cfg.missing("pagerduty_webhook_secret")
+	}
+	sender := servePagerDutyFlags.from
+	if sender == "" {
+		var err error
+		if sender, err = cfg.sender(); err != nil {
+			return nil, err
+		}
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return nil, err
+	}
+	handler := &pagerduty.Handler{
+		Service:     client,
+		Secret:      cfg.PagerDutyWebhookSecret,
+		Sender:      sender,
+		Message:     servePagerDutyFlags.message,
+		EmailDomain: cfg.EmailDomain,
+		APIToken:    cfg.PagerDutyToken,
+	}
+	if handler.Users, err = cfg.userMap(servePagerDutyFlags.usersPath, alias.PagerDuty, alias.Email); err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
+
+// The flags of golove serve feed.
+var serveFeedFlags struct {
+	users listFlag
+	limit int
+	title string
+}
+
+func defineServeFeedFlags(flags *flag.FlagSet) {
+	serveFeedFlags.users = nil
+	flags.Var(&serveFeedFlags.users, "user", "show love received by `user` (may be repeated)")
+	flags.IntVar(&serveFeedFlags.limit, "limit", feed.DefaultLimit, "show the newest `n` love")
+	flags.StringVar(&serveFeedFlags.title, "title", "Love", "the `title` of the feed")
+}
+
+func serveFeed(cfg *config) (http.Handler, error) {
+	client, err := cfg.client()
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/feed.atom", &feed.Handler{
+		Service: client,
+		Users:   serveFeedFlags.users,
+		Limit:   serveFeedFlags.limit,
+		Title:   serveFeedFlags.title,
+	})
+	return mux, nil
+}
+
+// The flags of golove serve graphql.
+var serveGraphQLFlags struct {
+	ttl      time.Duration
+	readOnly bool
+}
+
+func defineServeGraphQLFlags(flags *flag.FlagSet) {
+	flags.DurationVar(&serveGraphQLFlags.ttl, "cache-ttl", graphql.DefaultCacheTTL,
+		"cache query results for `duration`")
+	flags.BoolVar(&serveGraphQLFlags.readOnly, "read-only", false, "disable the sendLove mutation")
+}
+
+func serveGraphQL(cfg *config) (http.Handler, error) {
+	client, err := cfg.client()
+	if err != nil {
+		return nil, err
+	}
+	ttl := serveGraphQLFlags.ttl
+	client.AutocompleteCache = love.NewAutocompleteCache(ttl)
+	handler := &graphql.Handler{Client: client, CacheTTL: ttl, Token: cfg.ServeToken,
+		ReadOnly: serveGraphQLFlags.readOnly}
+	if ttl <= 0 {
+		handler.CacheTTL = -1
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", handler)
+	return mux, nil
+}
+
+// The flags of golove serve grpc.
+var serveGRPCFlags struct {
+	readOnly bool
+}
+
+func defineServeGRPCFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&serveGRPCFlags.readOnly, "read-only", false, "disable SendLove")
+}
+
+func serveGRPC(cfg *config) (http.Handler, error) {
+	client, err := cfg.client()
+	if err != nil {
+		return nil, err
+	}
+	server := grpc.NewServer(grpc.UnaryInterceptor(lovepb.AuthInterceptor(cfg.ServeToken)))
+	lovepb.RegisterLoveServiceServer(server, &lovepb.Server{
+		Client:   client,
+		ReadOnly: serveGRPCFlags.readOnly || cfg.ServeToken == "",
+	})
+	return server, nil
+}
+
+// The flags of golove serve proxy.
+var serveProxyFlags struct {
+	ttl time.Duration
+}
+
+func defineServeProxyFlags(flags *flag.FlagSet) {
+	flags.DurationVar(&serveProxyFlags.ttl, "ttl", proxy.DefaultTTL, "cache responses for `duration`")
+}
+
+func serveProxy(cfg *config) (http.Handler, error) {
+	if serveProxyFlags.ttl <= 0 {
+		return nil, usagef("-ttl must be positive")
+	}
+	if cfg.BaseUrl == "" {
+		return nil, cfg.missing("base_url")
+	}
+	target, err := url.Parse(cfg.BaseUrl)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("base_url %q is not an absolute URL", cfg.BaseUrl)
+	}
+	var authHeaders []string
+	if cfg.ApiKeyHeader != "" {
+		authHeaders = append(authHeaders, cfg.ApiKeyHeader)
+	}
+	if cfg.AuthHeader != "" {
+		authHeaders = append(authHeaders, strings.TrimSpace(strings.SplitN(cfg.AuthHeader, ":", 2)[0]))
+	}
+	return &proxy.Proxy{
+		Target:      &url.URL{Scheme: target.Scheme, Host: target.Host},
+		TTL:         serveProxyFlags.ttl,
+		AuthHeaders: authHeaders,
+	}, nil
+}
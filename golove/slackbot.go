@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/alias"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/slack"
+	slackapi "github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+)
+
+var slackBotCommand = &command{
+	Name:    "slack-bot",
+	Args:    "-channel id [-users file] [-trust-names] [-user user] [-interval duration] [-metrics address]",
+	Summary: "post love to a Slack channel, and answer slash commands",
+	Long: `Run a Slack bot until interrupted. The bot connects to Slack in Socket Mode, so
+it needs no public URL. It posts love received by each -user, which may be
+repeated, to the channel, and sends love for the app's slash command, like
+"golove serve slack". The users are watched by polling every -interval.
+
+The users file is a JSON object which maps Slack user IDs to love usernames, as
+for "golove serve slack", and slack aliases (see "golove alias") are added to
+it. These users are mentioned in the channel, and are watched if no -user is
+given. As in "golove serve slack", only these users may send love, unless
+-trust-names is given.
+
+The app's bot token (with the chat:write scope) must be set in slack_bot_token
+(or SLACK_BOT_TOKEN), and an app-level token (with the connections:write scope)
+in slack_app_token (or SLACK_APP_TOKEN). The bot must be invited to the channel.`,
+	Flags: defineSlackBotFlags,
+	Run:   runSlackBot,
+}
+
+// The flags of golove slack-bot.
+var slackBotFlags struct {
+	channel     string
+	usersPath   string
+	trustNames  bool
+	users       listFlag
+	interval    time.Duration
+	metricsAddr string
+}
+
+func defineSlackBotFlags(flags *flag.FlagSet) {
+	flags.StringVar(&slackBotFlags.channel, "channel", "", "post love to the channel with `id`")
+	flags.StringVar(&slackBotFlags.usersPath, "users", "", "map Slack user IDs to usernames with JSON `file`")
+	flags.BoolVar(&slackBotFlags.trustNames, "trust-names", false,
+		"let users who are not mapped send love as their Slack username")
+	slackBotFlags.users = nil
+	flags.Var(&slackBotFlags.users, "user", "post love received by `user` (may be repeated)")
+	flags.DurationVar(&slackBotFlags.interval, "interval", time.Minute, "poll every `duration`")
+	flags.StringVar(&slackBotFlags.metricsAddr, "metrics", "", "serve metrics at /metrics on `address`")
+}
+
+func runSlackBot(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	if slackBotFlags.channel == "" {
+		return usagef("a channel is required")
+	}
+	if slackBotFlags.interval <= 0 {
+		return usagef("the interval must be positive")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	slackUsers, err := cfg.userMap(slackBotFlags.usersPath, alias.Slack)
+	if err != nil {
+		return err
+	}
+	if len(slackBotFlags.users) == 0 {
+		slackBotFlags.users = mappedUsers(slackUsers)
+		if len(slackBotFlags.users) == 0 {
+			return usagef("a user to watch, or a users file, is required")
+		}
+	}
+	if cfg.SlackBotToken == "" {
+		return cfg.missing("slack_bot_token")
+	}
+	if cfg.SlackAppToken == "" {
+		return cfg.missing("slack_app_token")
+	}
+	if slackBotFlags.metricsAddr != "" {
+		serveMetrics(slackBotFlags.metricsAddr)
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+
+	api := slackapi.New(cfg.SlackBotToken, slackapi.OptionAppLevelToken(cfg.SlackAppToken))
+	bot := &slack.Bot{
+		Handler: &slack.Handler{Service: client, Users: slackUsers, TrustUserNames: slackBotFlags.trustNames},
+		Wall:    &slack.Wall{Client: api, Channel: slackBotFlags.channel, Users: slackUsers},
+		Socket:  socketmode.New(api),
+		OnError: func(err error) {
+			fmt.Fprintf(os.Stderr, "golove slack-bot: %s\n", err)
+		},
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	loves := make(chan love.Love)
+	for _, user := range slackBotFlags.users {
+		watched, errs := client.Watch(ctx, love.LoveFilter{Recipient: user}, slackBotFlags.interval)
+		go forwardLove(ctx, watched, errs, loves, bot.OnError)
+	}
+	fmt.Fprintf(os.Stderr, "posting love for %d users to %s\n",
+		len(slackBotFlags.users), slackBotFlags.channel)
+	if err := bot.Run(ctx, loves); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+/*
+Send the love and errors of a watch on, until it stops or the context is done.
+*/
+func forwardLove(ctx context.Context, watched <-chan love.Love, errs <-chan error,
+	loves chan<- love.Love, onError func(error)) {
+	for {
+		select {
+		case l, ok := <-watched:
+			if !ok {
+				return
+			}
+			select {
+			case loves <- l:
+			case <-ctx.Done():
+				return
+			}
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+			onError(err)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+/*
+Return the love usernames in a map of Slack users, without duplicates.
+*/
+func mappedUsers(slackUsers map[string]string) []string {
+	seen := make(map[string]bool)
+	var users []string
+	for _, user := range slackUsers {
+		if !seen[user] {
+			seen[user] = true
+			users = append(users, user)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
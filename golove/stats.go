@@ -0,0 +1,249 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"sort"
+	"time"
+)
+
+var statsCommand = &command{
+	Name:    "stats",
+	Args:    "[-user user] [-since date] [-until date] [-top n] [-by day|week|month] [-insights [-inactive duration]]",
+	Summary: "summarize the love sent and received by a user",
+	Long: `Print the number of love sent and received by a user, the users they exchanged
+the most love with, and their busiest weeks. Weeks are ISO weeks, starting on
+Monday in the local time zone.
+
+With -by, also print the love the user sent and received during each day, week
+or month, oldest first, with the average love of the last 4 of them.
+
+With -insights, also print the user's weekly sending streaks, whether they have
+sent love within the -inactive duration, whose love went unreturned in either
+direction, and how the love they sent and received during the last 7 days
+compares with the 4 weeks before. Streaks, inactivity and trends are measured
+as of -until, or now.`,
+	Flags: defineStatsFlags,
+	Run:   runStats,
+}
+
+/*
+A user's correspondent, and the love exchanged with them.
+*/
+type correspondent struct {
+	User     string
+	Sent     int
+	Received int
+}
+
+// The flags of golove stats.
+var statsFlags struct {
+	user         string
+	since, until dateFlag
+	top          int
+	by           string
+	insights     bool
+	inactive     time.Duration
+}
+
+func defineStatsFlags(flags *flag.FlagSet) {
+	flags.StringVar(&statsFlags.user, "user", "", "summarize `user` (default the configured sender)")
+	statsFlags.since, statsFlags.until = dateFlag{}, dateFlag{}
+	flags.Var(&statsFlags.since, "since", "only count love sent on or after `date` (YYYY-MM-DD)")
+	flags.Var(&statsFlags.until, "until", "only count love sent before `date` (YYYY-MM-DD)")
+	flags.IntVar(&statsFlags.top, "top", 5, "list the top `n` correspondents and weeks")
+	flags.StringVar(&statsFlags.by, "by", "", "print the love sent and received each `day`, week or month")
+	flags.BoolVar(&statsFlags.insights, "insights", false, "print streaks, inactivity and reciprocity")
+	flags.DurationVar(&statsFlags.inactive, "inactive", 30*24*time.Hour,
+		"with -insights, report the user as inactive after `duration` without sending love")
+}
+
+func runStats(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	granularity := love.Granularity(statsFlags.by)
+	if _, err := granularity.Start(time.Now()); statsFlags.by != "" && err != nil {
+		return usagef("-by must be day, week or month, not %q", statsFlags.by)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	if statsFlags.user == "" {
+		if statsFlags.user, err = cfg.sender(); err != nil {
+			return err
+		}
+	}
+	involving, err := client.GetLoveInvolving(statsFlags.user,
+		love.LoveFilter{Since: statsFlags.since.Time, Until: statsFlags.until.Time})
+	if err != nil {
+		return err
+	}
+	var all, sent, received []love.Love
+	for _, l := range involving {
+		all = append(all, l.Love)
+		if l.Direction == love.Sent {
+			sent = append(sent, l.Love)
+		} else {
+			received = append(received, l.Love)
+		}
+	}
+
+	fmt.Printf("Love for %s", statsFlags.user)
+	if !statsFlags.since.IsZero() {
+		fmt.Printf(" since %s", statsFlags.since.String())
+	}
+	if !statsFlags.until.IsZero() {
+		fmt.Printf(" until %s", statsFlags.until.String())
+	}
+	fmt.Printf("\n\nSent:      %d\nReceived:  %d\n", len(sent), len(received))
+
+	sentStats := love.ComputeStats(sent)
+	receivedStats := love.ComputeStats(received)
+	correspondents := make(map[string]*correspondent)
+	get := func(name string) *correspondent {
+		if correspondents[name] == nil {
+			correspondents[name] = &correspondent{User: name}
+		}
+		return correspondents[name]
+	}
+	for name, count := range sentStats.ByRecipient {
+		get(name).Sent = count
+	}
+	for name, count := range receivedStats.BySender {
+		get(name).Received = count
+	}
+	var ranked []*correspondent
+	for _, c := range correspondents {
+		ranked = append(ranked, c)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Sent+a.Received != b.Sent+b.Received {
+			return a.Sent+a.Received > b.Sent+b.Received
+		}
+		return a.User < b.User
+	})
+	if statsFlags.top > 0 && len(ranked) > statsFlags.top {
+		ranked = ranked[:statsFlags.top]
+	}
+	if len(ranked) > 0 {
+		fmt.Println("\nTop correspondents:")
+		for _, c := range ranked {
+			fmt.Printf("  %-20s %4d sent  %4d received\n", c.User, c.Sent, c.Received)
+		}
+	}
+
+	weeks := make(map[string]int)
+	for _, week := range love.BucketByWeek(all, time.Local) {
+		if week.Count > 0 {
+			weeks[week.Start.Format(dateLayout)] = week.Count
+		}
+	}
+	if len(weeks) > 0 {
+		fmt.Println("\nBusiest weeks:")
+		for _, week := range love.TopCounts(weeks, statsFlags.top) {
+			fmt.Printf("  week of %s %4d\n", week.Key, week.Count)
+		}
+	}
+	if statsFlags.by != "" {
+		buckets, err := love.SplitBy(granularity, statsFlags.user, all, time.Local)
+		if err != nil {
+			return err
+		}
+		if len(buckets) > 0 {
+			fmt.Printf("\nBy %s:\n", granularity)
+		}
+		averages := love.RollingAverage(buckets, trendWeeks)
+		for i, b := range buckets {
+			fmt.Printf("  %-10s %4d sent  %4d received  %6.1f average\n",
+				granularity.Label(b.Start), b.Sent, b.Received, averages[i])
+		}
+	}
+	if statsFlags.insights {
+		now := time.Now()
+		if !statsFlags.until.IsZero() {
+			now = statsFlags.until.Time
+		}
+		printInsights(statsFlags.user, sent, received, now, statsFlags.inactive, statsFlags.top)
+	}
+	return nil
+}
+
+/*
+The number of weeks before the last 7 days which "golove stats" compares them
+with.
+*/
+const trendWeeks = 4
+
+/*
+Print the insights of "golove stats -insights", listing at most top users under
+each heading.
+*/
+func printInsights(user string, sent, received []love.Love, now time.Time,
+	inactive time.Duration, top int) {
+	fmt.Println("\nInsights:")
+	streak := love.ComputeStreak(user, sent, now)
+	fmt.Printf("  Weekly streak:  %s", weeks(streak.Current))
+	if streak.Longest > 0 {
+		fmt.Printf(" (longest %s, from week of %s)", weeks(streak.Longest),
+			streak.LongestStart.Format(dateLayout))
+	}
+	fmt.Println()
+	weekAgo := now.AddDate(0, 0, -7)
+	for _, trend := range []struct {
+		heading string
+		loves   []love.Love
+	}{
+		{"Sent:", sent},
+		{"Received:", received},
+	} {
+		t := love.ComputeTrend(trend.loves, weekAgo, now, trendWeeks)
+		fmt.Printf("  %-15s %d in the last 7 days, %s\n", trend.heading, t.Current, t)
+	}
+	if last, ok := love.LastSent(sent)[user]; !ok {
+		fmt.Println("  Inactive:       no love sent")
+	} else if now.Sub(last) > inactive {
+		fmt.Printf("  Inactive:       last sent love on %s\n", last.Format(dateLayout))
+	}
+	reciprocity := love.ComputeReciprocity(user, append(sent, received...))
+	for _, list := range []struct {
+		heading string
+		counts  []love.Count
+	}{
+		{"Sent love, but received none back from:", reciprocity.Unreturned},
+		{"Received love, but sent none back to:", reciprocity.Unacknowledged},
+	} {
+		if len(list.counts) == 0 {
+			continue
+		}
+		fmt.Printf("  %s\n", list.heading)
+		counts := list.counts
+		if top > 0 && len(counts) > top {
+			counts = counts[:top]
+		}
+		for _, c := range counts {
+			fmt.Printf("    %-20s %4d\n", c.Key, c.Count)
+		}
+		if len(counts) < len(list.counts) {
+			fmt.Printf("    and %d more\n", len(list.counts)-len(counts))
+		}
+	}
+}
+
+func weeks(n int) string {
+	if n == 1 {
+		return "1 week"
+	}
+	return fmt.Sprintf("%d weeks", n)
+}
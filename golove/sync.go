@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/store"
+)
+
+var syncCommand = &command{
+	Name:    "sync",
+	Args:    "[-db path] [user...]",
+	Summary: "copy love history into the local database",
+	Long: `Fetch the love sent and received by each user (the configured sender by
+default) which is newer than the last sync, and add it to the local database.`,
+	Flags: defineSyncFlags,
+	Run:   runSync,
+}
+
+// The flags of golove sync.
+var syncFlags struct {
+	path string
+}
+
+func defineSyncFlags(flags *flag.FlagSet) {
+	flags.StringVar(&syncFlags.path, "db", store.DefaultPath(), "the local database `path`")
+}
+
+func runSync(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	users := flags.Args()
+	if len(users) == 0 {
+		sender, err := cfg.sender()
+		if err != nil {
+			return err
+		}
+		users = []string{sender}
+	}
+	db, err := store.Open(syncFlags.path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	for _, user := range users {
+		sent, err := db.Sync(client, love.LoveFilter{Sender: user})
+		if err != nil {
+			return err
+		}
+		received, err := db.Sync(client, love.LoveFilter{Recipient: user})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d new love sent, %d new love received\n", user, sent,
+			received)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+Send each recipient their own love, rendering the message as a template with
+the fields for each recipient in the data file, if any.
+*/
+func sendTemplate(client *love.Client, sender, recipient, message, dataPath string,
+	dryRun bool) error {
+	tmpl, err := love.ParseMessageTemplate(message)
+	if err != nil {
+		return err
+	}
+	var data map[string]map[string]string
+	if dataPath != "" {
+		if data, err = readTemplateData(dataPath); err != nil {
+			return err
+		}
+	}
+	recipients := strings.Split(recipient, ",")
+	concurrency := love.DefaultConcurrency
+	if dryRun {
+		// Print one request at a time.
+		concurrency = 1
+	}
+	results, err := client.SendLoveTemplate(sender, recipients, tmpl, data, concurrency)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, r := range recipients {
+		err, ok := results[r]
+		switch {
+		case !ok:
+			// A duplicate recipient, which was only sent love once.
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "golove send: %s: %s\n", r, err)
+			failed++
+		case dryRun:
+			fmt.Printf("Love not sent to %s (dry run)\n", r)
+		default:
+			fmt.Printf("Love sent to %s!\n", r)
+		}
+		delete(results, r)
+	}
+	if failed > 0 {
+		return fmt.Errorf("love could not be sent to %d recipients", failed)
+	}
+	return nil
+}
+
+/*
+Read template fields by recipient from a JSON object of objects, or from a CSV
+file with a header row and a "recipient" column, according to its extension.
+*/
+func readTemplateData(path string) (map[string]map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data := make(map[string]map[string]string)
+	if !strings.EqualFold(filepath.Ext(path), ".csv") {
+		if err := json.NewDecoder(file).Decode(&data); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		return data, nil
+	}
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return data, nil
+	}
+	header := records[0]
+	column := -1
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), "recipient") {
+			column = i
+		}
+	}
+	if column < 0 {
+		return nil, fmt.Errorf("%s: no recipient column", path)
+	}
+	for _, record := range records[1:] {
+		fields := make(map[string]string, len(header))
+		for i, name := range header {
+			fields[strings.TrimSpace(name)] = record[i]
+		}
+		data[strings.TrimSpace(record[column])] = fields
+	}
+	return data, nil
+}
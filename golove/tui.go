@@ -0,0 +1,557 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/store"
+	"golang.org/x/term"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var tuiCommand = &command{
+	Name:    "tui",
+	Args:    "[-user user] [-db path] [-no-sync]",
+	Summary: "browse love history in a terminal UI",
+	Long: `Browse the love sent and received by a user (the configured sender by default)
+in a full-screen terminal UI. The love is read from the local database, after
+syncing it with the API, unless -no-sync is given. The top pane lists the love,
+newest first, and the bottom pane shows the selected love in full.
+
+The keys are:
+
+	up, k / down, j       select the previous / next love
+	pgup / pgdn           move a page at a time
+	home, g / end, G      select the newest / oldest love
+	/                     filter by a word in the message, sender or recipient
+	esc                   clear the filter
+	c                     compose new love, completing usernames with tab
+	s                     sync with the API
+	q, ctrl-c             quit
+
+Without an API key or base URL, the database can still be browsed, but love
+cannot be sent or synced.`,
+	Flags: defineTUIFlags,
+	Run:   runTUI,
+}
+
+// The flags of golove tui.
+var tuiFlags struct {
+	user   string
+	path   string
+	noSync bool
+}
+
+func defineTUIFlags(flags *flag.FlagSet) {
+	flags.StringVar(&tuiFlags.user, "user", "", "browse the love of `user` (default the configured sender)")
+	flags.StringVar(&tuiFlags.path, "db", store.DefaultPath(), "the local database `path`")
+	flags.BoolVar(&tuiFlags.noSync, "no-sync", false, "do not sync with the API on start")
+}
+
+func runTUI(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return fmt.Errorf("the terminal UI requires a terminal")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	sender, err := cfg.sender()
+	if err != nil {
+		return err
+	}
+	if tuiFlags.user == "" {
+		tuiFlags.user = sender
+	}
+	ui := &tui{user: tuiFlags.user, sender: sender, style: fileStyle(os.Stdout)}
+	if ui.client, err = cfg.client(); err != nil {
+		ui.status = fmt.Sprintf("offline: %s", err)
+	} else {
+		defer useAutocompleteCache(ui.client)()
+	}
+	if ui.db, err = store.Open(tuiFlags.path); err != nil {
+		return err
+	}
+	defer ui.db.Close()
+	if !tuiFlags.noSync {
+		fmt.Fprintf(os.Stderr, "syncing love for %s...\n", ui.user)
+		ui.sync()
+	}
+	if err := ui.load(); err != nil {
+		return err
+	}
+	return ui.run(fd)
+}
+
+/*
+The state of the terminal UI.
+*/
+type tui struct {
+	user   string
+	sender string
+	client *love.Client
+	db     *store.Store
+	style  textStyle
+
+	all      []love.Love
+	visible  []love.Love
+	selected int
+	offset   int
+	filter   string
+	editing  bool
+	status   string
+}
+
+/*
+Sync the love of the user from the API into the database, reporting the result
+in the status line.
+*/
+func (ui *tui) sync() {
+	if ui.client == nil {
+		return
+	}
+	added := 0
+	for _, f := range []love.LoveFilter{{Sender: ui.user}, {Recipient: ui.user}} {
+		n, err := ui.db.Sync(ui.client, f)
+		if err != nil {
+			ui.status = fmt.Sprintf("sync failed: %s", err)
+			return
+		}
+		added += n
+	}
+	ui.status = fmt.Sprintf("synced %d new love", added)
+}
+
+/*
+Read the love of the user from the database.
+*/
+func (ui *tui) load() error {
+	sent, err := ui.db.Query(love.LoveFilter{Sender: ui.user})
+	if err != nil {
+		return err
+	}
+	received, err := ui.db.Query(love.LoveFilter{Recipient: ui.user})
+	if err != nil {
+		return err
+	}
+	ui.all = love.Merge(sent, received)
+	ui.applyFilter()
+	return nil
+}
+
+/*
+Show the love matching the filter, keeping the selection in range.
+*/
+func (ui *tui) applyFilter() {
+	ui.visible = ui.all
+	if ui.filter != "" {
+		pattern, _ := searchPattern(ui.filter, false, false)
+		ui.visible = nil
+		for _, l := range ui.all {
+			if pattern.MatchString(l.Message) || pattern.MatchString(l.Sender) ||
+				pattern.MatchString(l.Recipient) {
+				ui.visible = append(ui.visible, l)
+			}
+		}
+	}
+	ui.move(0, 0)
+}
+
+/*
+Move the selection by delta, scrolling the list of the given height so that the
+selection is visible.
+*/
+func (ui *tui) move(delta, height int) {
+	ui.selected += delta
+	if ui.selected >= len(ui.visible) {
+		ui.selected = len(ui.visible) - 1
+	}
+	if ui.selected < 0 {
+		ui.selected = 0
+	}
+	if ui.selected < ui.offset {
+		ui.offset = ui.selected
+	} else if height > 0 && ui.selected >= ui.offset+height {
+		ui.offset = ui.selected - height + 1
+	}
+}
+
+/*
+Return the height of the list and detail panes on a screen of the given height.
+One line each is left for the header, the separator and the status line.
+*/
+func tuiPanes(height int) (int, int) {
+	detail := height / 3
+	if detail > 10 {
+		detail = 10
+	}
+	list := height - 3 - detail
+	if list < 1 {
+		return height - 2, 0
+	}
+	return list, detail
+}
+
+/*
+Draw the screen.
+*/
+func (ui *tui) render(w io.Writer, width, height int) {
+	listHeight, detailHeight := tuiPanes(height)
+	ui.move(0, listHeight)
+	var lines []string
+	header := fmt.Sprintf(" golove: love of %s (%d)", ui.user, len(ui.visible))
+	if ui.filter != "" {
+		header += fmt.Sprintf(" matching %q", ui.filter)
+	}
+	lines = append(lines, "\x1b[7m"+padRight(truncate(header, width), width)+ansiReset)
+	for i := ui.offset; i < ui.offset+listHeight; i++ {
+		if i >= len(ui.visible) {
+			lines = append(lines, "")
+			continue
+		}
+		lines = append(lines, ui.listLine(ui.visible[i], i == ui.selected, width))
+	}
+	if detailHeight > 0 {
+		lines = append(lines, ui.style.paint(ansiDim, strings.Repeat("─", width)))
+		detail := ui.detailLines(width)
+		for i := 0; i < detailHeight; i++ {
+			if i < len(detail) {
+				lines = append(lines, truncate(detail[i], width))
+			} else {
+				lines = append(lines, "")
+			}
+		}
+	}
+	if ui.editing {
+		lines = append(lines, truncate("/"+ui.filter+"_", width))
+	} else {
+		status := "j/k move  / filter  c compose  s sync  q quit"
+		if ui.status != "" {
+			status = ui.status + "  |  " + status
+		}
+		lines = append(lines, ui.style.paint(ansiDim, truncate(status, width)))
+	}
+	out := bufio.NewWriter(w)
+	out.WriteString("\x1b[H")
+	for i, line := range lines {
+		if i > 0 {
+			out.WriteString("\r\n")
+		}
+		out.WriteString(line + "\x1b[K")
+	}
+	out.WriteString("\x1b[J")
+	out.Flush()
+}
+
+/*
+Return the line listing a love: when, who, and the start of the message. The
+selected love is shown in reverse video.
+*/
+func (ui *tui) listLine(l love.Love, selected bool, width int) string {
+	style := ui.style
+	if selected {
+		style.Color = false
+	}
+	message := strings.Join(strings.Fields(l.Message), " ")
+	if style.Emoji {
+		message = love.RenderEmoji(message)
+	}
+	message = style.markdown(message)
+	when := padRight(love.FormatRelative(l.Timestamp, time.Now()), 15)
+	line := fmt.Sprintf(" %s %s -> %s: %s", style.paint(ansiDim, when),
+		style.paint(ansiCyan, l.Sender), style.paint(ansiMagenta, l.Recipient), message)
+	line = truncate(line, width)
+	if selected {
+		return "\x1b[7m" + padRight(line, width) + ansiReset
+	}
+	return line
+}
+
+/*
+Return the lines showing the selected love in full.
+*/
+func (ui *tui) detailLines(width int) []string {
+	if len(ui.visible) == 0 {
+		if ui.filter != "" {
+			return []string{" No love matches the filter."}
+		}
+		return []string{" No love yet. Press c to send some, or s to sync."}
+	}
+	l := ui.visible[ui.selected]
+	style := ui.style
+	lines := []string{
+		fmt.Sprintf(" From %s to %s", style.paint(ansiCyan, l.Sender),
+			style.paint(ansiMagenta, l.Recipient)),
+		style.paint(ansiDim, fmt.Sprintf(" %s (%s)",
+			l.Timestamp.In(time.Local).Format("Monday, January 2, 2006 15:04"),
+			love.FormatRelative(l.Timestamp, time.Now()))),
+	}
+	if len(l.Values) > 0 {
+		lines = append(lines, " "+style.paint(ansiYellow, "["+strings.Join(l.Values, ", ")+"]"))
+	}
+	lines = append(lines, "")
+	message := l.Message
+	if style.Emoji {
+		message = love.RenderEmoji(message)
+	}
+	message = style.markdown(message)
+	wrapped := textStyle{Width: width - 1}.wrap(message, 1, 1)
+	for _, line := range strings.Split(wrapped, "\n") {
+		if !strings.HasPrefix(line, " ") {
+			line = " " + line
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func padRight(s string, width int) string {
+	if n := displayWidth(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+/*
+Run the UI until the user quits, with the terminal in raw mode on the alternate
+screen.
+*/
+func (ui *tui) run(fd int) error {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	enter := func() {
+		fmt.Print("\x1b[?1049h\x1b[?25l")
+	}
+	leave := func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+	}
+	enter()
+	defer func() {
+		leave()
+		term.Restore(fd, state)
+	}()
+
+	// Keys are only read when asked for, so that composing love can read the
+	// terminal itself.
+	want := make(chan bool)
+	keys := make(chan []string)
+	go func() {
+		buf := make([]byte, 256)
+		for range want {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				keys <- []string{"ctrl-c"}
+				continue
+			}
+			keys <- parseKeys(buf[:n])
+		}
+	}()
+	defer close(want)
+	want <- true
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	var width, height int
+	for {
+		w, h, err := term.GetSize(fd)
+		if err != nil {
+			return err
+		}
+		if w != width || h != height {
+			width, height = w, h
+			ui.render(os.Stdout, width, height)
+		}
+		select {
+		case <-ticker.C:
+			continue
+		case pressed := <-keys:
+			listHeight, _ := tuiPanes(height)
+			for _, key := range pressed {
+				switch ui.handleKey(key, listHeight) {
+				case tuiQuit:
+					return nil
+				case tuiCompose:
+					leave()
+					term.Restore(fd, state)
+					ui.compose()
+					if state, err = term.MakeRaw(fd); err != nil {
+						return err
+					}
+					enter()
+				}
+			}
+			ui.render(os.Stdout, width, height)
+			want <- true
+		}
+	}
+}
+
+type tuiAction int
+
+const (
+	tuiNone tuiAction = iota
+	tuiQuit
+	tuiCompose
+)
+
+/*
+Handle a key, returning an action for run to take.
+*/
+func (ui *tui) handleKey(key string, listHeight int) tuiAction {
+	if ui.editing {
+		switch key {
+		case "enter":
+			ui.editing = false
+		case "esc", "ctrl-c":
+			ui.editing = false
+			ui.filter = ""
+		case "backspace":
+			if ui.filter != "" {
+				_, size := utf8.DecodeLastRuneInString(ui.filter)
+				ui.filter = ui.filter[:len(ui.filter)-size]
+			}
+		default:
+			if utf8.RuneCountInString(key) == 1 {
+				ui.filter += key
+			}
+		}
+		ui.applyFilter()
+		return tuiNone
+	}
+	ui.status = ""
+	switch key {
+	case "q", "ctrl-c":
+		return tuiQuit
+	case "up", "k":
+		ui.move(-1, listHeight)
+	case "down", "j":
+		ui.move(1, listHeight)
+	case "pgup":
+		ui.move(-listHeight, listHeight)
+	case "pgdn", " ":
+		ui.move(listHeight, listHeight)
+	case "home", "g":
+		ui.move(-len(ui.visible), listHeight)
+	case "end", "G":
+		ui.move(len(ui.visible), listHeight)
+	case "/":
+		ui.editing = true
+	case "esc":
+		ui.filter = ""
+		ui.applyFilter()
+	case "s":
+		if ui.client == nil {
+			ui.status = "cannot sync: the API is not configured"
+			break
+		}
+		ui.sync()
+		if err := ui.load(); err != nil {
+			ui.status = err.Error()
+		}
+	case "c":
+		if ui.client == nil {
+			ui.status = "cannot send love: the API is not configured"
+			break
+		}
+		return tuiCompose
+	}
+	return tuiNone
+}
+
+/*
+Compose and send love on the normal screen, then sync it into the database.
+*/
+func (ui *tui) compose() {
+	recipients, message, err := compose(ui.client, contactNames(ui.db, ui.sender, ""))
+	if err == nil {
+		err = ui.client.SendLove(ui.sender, recipients, message)
+	}
+	if err == nil {
+		ui.db.RecordContacts(ui.sender, strings.Split(recipients, ","))
+	}
+	switch {
+	case err == errAborted:
+		ui.status = "not sent"
+	case err != nil:
+		ui.status = fmt.Sprintf("failed to send love: %s", err)
+	default:
+		ui.sync()
+		ui.load()
+		ui.status = fmt.Sprintf("sent love to %s", recipients)
+	}
+}
+
+/*
+Split the bytes read from the terminal into keys: printable characters, or the
+names of special keys, such as "up" and "enter".
+*/
+func parseKeys(b []byte) []string {
+	sequences := []struct {
+		bytes string
+		key   string
+	}{
+		{"\x1b[A", "up"}, {"\x1bOA", "up"},
+		{"\x1b[B", "down"}, {"\x1bOB", "down"},
+		{"\x1b[5~", "pgup"}, {"\x1b[6~", "pgdn"},
+		{"\x1b[H", "home"}, {"\x1b[1~", "home"}, {"\x1bOH", "home"},
+		{"\x1b[F", "end"}, {"\x1b[4~", "end"}, {"\x1bOF", "end"},
+	}
+	var keys []string
+	s := string(b)
+	for s != "" {
+		matched := false
+		for _, seq := range sequences {
+			if strings.HasPrefix(s, seq.bytes) {
+				keys = append(keys, seq.key)
+				s = s[len(seq.bytes):]
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		switch s[0] {
+		case '\x1b':
+			// A lone escape, or a sequence for a key which is not used.
+			if len(s) > 1 && s[1] == '[' {
+				end := strings.IndexFunc(s[2:], func(r rune) bool { return r >= '@' && r <= '~' })
+				if end >= 0 {
+					s = s[end+3:]
+					continue
+				}
+			}
+			keys = append(keys, "esc")
+			s = s[1:]
+		case '\r', '\n':
+			keys = append(keys, "enter")
+			s = s[1:]
+		case 0x7f, 0x08:
+			keys = append(keys, "backspace")
+			s = s[1:]
+		case 0x03:
+			keys = append(keys, "ctrl-c")
+			s = s[1:]
+		default:
+			r, size := utf8.DecodeRuneInString(s)
+			keys = append(keys, string(r))
+			s = s[size:]
+		}
+	}
+	return keys
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"net/http"
+	"os"
+	"runtime"
+	buildinfo "runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The version of golove, which is that of the love package it is built with.
+var version = love.Version
+
+/*
+The commit golove was built from, and when, set when building a release:
+
+	go build -ldflags "-X main.commit=$(git rev-parse --short HEAD) -X main.buildDate=$(date -u +%F)"
+
+If commit is not set, the commit the Go toolchain recorded is used, if any.
+*/
+var commit, buildDate string
+
+// Where the latest release of golove is described.
+const releasesURL = "https://api.github.com/repos/hacsoc/golove/releases/latest"
+
+var versionCommand = &command{
+	Name:    "version",
+	Args:    "[-check]",
+	Summary: "print the version of golove",
+	Long: `Print the version of golove, the commit it was built from and when, and the
+version of Go it was built with.
+
+With -check, also ask GitHub whether a newer release of golove is available.
+Nothing is sent but the request for the latest release. The request goes
+through the proxy in HTTPS_PROXY, if it is set; GOLOVE_RELEASES_URL replaces
+the address of the latest release, for mirrors.`,
+	Flags: defineVersionFlags,
+	Run:   runVersion,
+}
+
+// The flags of golove version.
+var versionFlags struct {
+	check bool
+}
+
+func defineVersionFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&versionFlags.check, "check", false, "check whether a newer release is available")
+}
+
+func runVersion(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	fmt.Printf("golove %s\n", version)
+	if c := buildCommit(); c != "" {
+		fmt.Printf("commit:  %s\n", c)
+	}
+	if buildDate != "" {
+		fmt.Printf("built:   %s\n", buildDate)
+	}
+	fmt.Printf("go:      %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if !versionFlags.check {
+		return nil
+	}
+	latest, err := latestRelease()
+	if err != nil {
+		return fmt.Errorf("checking for a newer release: %s", err)
+	}
+	if compareVersions(latest.Version, version) > 0 {
+		fmt.Printf("\ngolove %s is available: %s\n", latest.Version, latest.URL)
+		fmt.Println(`Run "golove self-update" to install it.`)
+	} else {
+		fmt.Printf("\ngolove is up to date.\n")
+	}
+	return nil
+}
+
+/*
+Return the commit golove was built from, as set when building, or as recorded
+by the Go toolchain, with "-dirty" if there were uncommitted changes. Returns
+"" if it is not known.
+*/
+func buildCommit() string {
+	if commit != "" {
+		return commit
+	}
+	info, ok := buildinfo.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	return commitFromSettings(info.Settings)
+}
+
+/*
+Return the commit recorded in the settings of a build, shortened, with "-dirty"
+if there were uncommitted changes.
+*/
+func commitFromSettings(settings []buildinfo.BuildSetting) string {
+	revision, dirty := "", false
+	for _, setting := range settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			dirty = setting.Value == "true"
+		}
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if revision != "" && dirty {
+		revision += "-dirty"
+	}
+	return revision
+}
+
+/*
+A release of golove, as described by GitHub.
+*/
+type release struct {
+	// The version, without a leading "v".
+	Version string
+	// The address of the page of the release.
+	URL string
+	// The download addresses of the files of the release, by name.
+	Assets map[string]string
+}
+
+/*
+Return an HTTP client for fetching releases, which uses the proxy in
+HTTPS_PROXY, if it is set.
+*/
+func releaseClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+	}
+}
+
+/*
+Return the latest release of golove.
+*/
+func latestRelease() (*release, error) {
+	url := releasesURL
+	if env := os.Getenv("GOLOVE_RELEASES_URL"); env != "" {
+		url = env
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "golove/"+version)
+	resp, err := releaseClient(10 * time.Second).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	var body struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.TagName == "" {
+		return nil, fmt.Errorf("%s: no release version", url)
+	}
+	latest := &release{
+		Version: strings.TrimPrefix(body.TagName, "v"),
+		URL:     body.HTMLURL,
+		Assets:  make(map[string]string),
+	}
+	for _, asset := range body.Assets {
+		latest.Assets[asset.Name] = asset.URL
+	}
+	return latest, nil
+}
+
+/*
+Compare two versions such as 0.2.0 and 0.10.1 number by number, returning a
+positive number if a is newer, negative if b is, and 0 if they are the same.
+A leading "v", as in v0.2.0, and anything after a number, such as "-rc1", are
+ignored.
+*/
+func compareVersions(a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, y := versionPart(as, i), versionPart(bs, i)
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
+
+func versionPart(parts []string, i int) int {
+	if i >= len(parts) {
+		return 0
+	}
+	part := parts[i]
+	if end := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+		part = part[:end]
+	}
+	n, _ := strconv.Atoi(part)
+	return n
+}
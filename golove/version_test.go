@@ -0,0 +1,66 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	buildinfo "runtime/debug"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"0.2.0", "0.2.0", 0},
+		{"0.10.0", "0.2.0", 1},
+		{"0.2", "0.10", -1},
+		{"1.0.0", "0.99.99", 1},
+		{"v0.3.0", "0.2.0", 1},
+		{"0.2.0", "v0.3.0", -1},
+		{"v1.0.0", "1.0.0", 0},
+		{"0.2.0-rc1", "0.2.0", 0},
+		{"0.3.0-rc1", "0.2.0", 1},
+		{"0.2", "0.2.0", 0},
+		{"0.2.1", "0.2", 1},
+		{"1", "0.9.9", 1},
+		{"0.2.0.1", "0.2.0", 1},
+	} {
+		result := compareVersions(test.a, test.b)
+		switch {
+		case test.expected > 0:
+			assert.True(t, result > 0, "%s > %s", test.a, test.b)
+		case test.expected < 0:
+			assert.True(t, result < 0, "%s < %s", test.a, test.b)
+		default:
+			assert.Equal(t, result, 0, "%s = %s", test.a, test.b)
+		}
+	}
+}
+
+func TestCommitFromSettings(t *testing.T) {
+	for _, test := range []struct {
+		settings []buildinfo.BuildSetting
+		expected string
+	}{
+		{nil, ""},
+		{[]buildinfo.BuildSetting{{Key: "vcs.revision", Value: "abc123"}}, "abc123"},
+		{[]buildinfo.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef0123456789abcdef01234567"},
+			{Key: "vcs.modified", Value: "false"},
+		}, "0123456789ab"},
+		{[]buildinfo.BuildSetting{
+			{Key: "vcs.modified", Value: "true"},
+			{Key: "vcs.revision", Value: "0123456789abcdef0123456789abcdef01234567"},
+		}, "0123456789ab-dirty"},
+		{[]buildinfo.BuildSetting{{Key: "vcs.modified", Value: "true"}}, ""},
+	} {
+		assert.Equal(t, commitFromSettings(test.settings), test.expected, "%v", test.settings)
+	}
+}
+
+func TestBuildCommit(t *testing.T) {
+	saved := commit
+	defer func() { commit = saved }()
+	commit = "abc1234"
+	assert.Equal(t, buildCommit(), "abc1234")
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/notify"
+	"github.com/hacsoc/golove/webhook"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Enough for the pages of love the watcher requests on each poll.
+const watchCacheEntries = 16
+
+var watchCommand = &command{
+	Name:    "watch",
+	Args:    "[-user user] [-sent] [-interval duration] [-exec command] [-notify] [-webhook url] [-metrics address] [-cursor file] [-output format]",
+	Summary: "print new love as it arrives",
+	Long: `Poll for love received by a user (the configured sender by default), printing
+each new love until interrupted. With -notify, a desktop notification is shown
+for each new love, using terminal-notifier or osascript on macOS, notify-send
+on Linux, or PowerShell on Windows.
+
+With -exec, a shell command is run for each new love. The command's environment
+contains GOLOVE_SENDER, GOLOVE_RECIPIENT, GOLOVE_MESSAGE and GOLOVE_TIMESTAMP.
+For example:
+
+	golove watch -exec 'notify-send "Love from $GOLOVE_SENDER" "$GOLOVE_MESSAGE"'
+
+With -webhook, which may be repeated, each new love is POSTed as JSON to the
+URL, retrying failed deliveries. If webhook_secret (or LOVE_WEBHOOK_SECRET) is
+configured, requests are signed as described in the webhook package.
+
+With -metrics, Prometheus metrics about the requests made to the love API are
+served at /metrics on the address.
+
+With -cursor, how far golove has got is saved in the file after each love, and
+golove watch resumes from it when it starts again, so that love which arrived
+in between is printed, and handled, then, and no love is handled twice. Without
+it, only love which arrives after golove starts is printed.
+
+Polls are conditional requests, so that if the server supports ETag or
+Last-Modified, love which has not changed is not downloaded again.`,
+	Flags: defineWatchFlags,
+	Run:   runWatch,
+}
+
+// The flags of golove watch.
+var watchFlags struct {
+	user        string
+	sent        bool
+	interval    time.Duration
+	command     string
+	notifyFlag  bool
+	webhooks    listFlag
+	metricsAddr string
+	cursorPath  string
+	output      string
+}
+
+func defineWatchFlags(flags *flag.FlagSet) {
+	flags.StringVar(&watchFlags.user, "user", "",
+		"watch love received by `user` (default the configured sender)")
+	flags.BoolVar(&watchFlags.sent, "sent", false, "watch love sent by the user instead")
+	flags.DurationVar(&watchFlags.interval, "interval", time.Minute, "poll every `duration`")
+	flags.StringVar(&watchFlags.command, "exec", "", "run shell `command` for each new love")
+	flags.BoolVar(&watchFlags.notifyFlag, "notify", false, "show a desktop notification for each new love")
+	watchFlags.webhooks = nil
+	flags.Var(&watchFlags.webhooks, "webhook", "POST each new love to `url` (may be repeated)")
+	flags.StringVar(&watchFlags.metricsAddr, "metrics", "", "serve metrics at /metrics on `address`")
+	flags.StringVar(&watchFlags.cursorPath, "cursor", "",
+		"resume from, and save the position of the watch in, `file`")
+	addOutputFlag(flags, &watchFlags.output)
+}
+
+func runWatch(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	if err := checkOutputFormat(watchFlags.output); err != nil {
+		return err
+	}
+	if watchFlags.interval <= 0 {
+		return usagef("the interval must be positive")
+	}
+	var notifier notify.Notifier
+	if watchFlags.notifyFlag {
+		var err error
+		if notifier, err = notify.Detect(); err != nil {
+			return err
+		}
+	}
+	if watchFlags.metricsAddr != "" {
+		serveMetrics(watchFlags.metricsAddr)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	client.ResponseCache = love.NewResponseCache(watchCacheEntries)
+	if watchFlags.user == "" {
+		if watchFlags.user, err = cfg.sender(); err != nil {
+			return err
+		}
+	}
+	var poster *webhook.Poster
+	if len(watchFlags.webhooks) > 0 {
+		poster = &webhook.Poster{
+			URLs:       watchFlags.webhooks,
+			Secret:     cfg.WebhookSecret,
+			HTTPClient: client.HTTPClient,
+		}
+	}
+	filter := love.LoveFilter{Recipient: watchFlags.user}
+	if watchFlags.sent {
+		filter = love.LoveFilter{Sender: watchFlags.user}
+	}
+
+	var cursor love.Cursor
+	if watchFlags.cursorPath != "" {
+		if cursor, err = readCursor(watchFlags.cursorPath); err != nil {
+			return err
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	loves, errs := client.WatchFrom(ctx, filter, watchFlags.interval, cursor)
+	for {
+		select {
+		case l, ok := <-loves:
+			if !ok {
+				return nil
+			}
+			if err := loveRecords([]love.Love{l}).write(os.Stdout, watchFlags.output); err != nil {
+				return err
+			}
+			if notifier != nil {
+				if err := notify.Love(notifier, l); err != nil {
+					fmt.Fprintf(os.Stderr, "golove watch: -notify: %s\n", err)
+				}
+			}
+			if watchFlags.command != "" {
+				runHook(watchFlags.command, l)
+			}
+			if poster != nil {
+				if err := poster.Post(l); err != nil {
+					fmt.Fprintf(os.Stderr, "golove watch: %s\n", err)
+				}
+			}
+			if watchFlags.cursorPath != "" {
+				cursor.Add(l)
+				if err := writeCursor(watchFlags.cursorPath, cursor); err != nil {
+					fmt.Fprintf(os.Stderr, "golove watch: -cursor: %s\n", err)
+				}
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "golove watch: %s\n", err)
+		}
+	}
+}
+
+/*
+Read a cursor saved by writeCursor, or return a zero cursor if the file does
+not exist yet.
+*/
+func readCursor(path string) (love.Cursor, error) {
+	var cursor love.Cursor
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cursor, nil
+	} else if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("%s: %s", path, err)
+	}
+	return cursor, nil
+}
+
+/*
+Save a cursor as JSON, replacing the file at once, so that it is never left
+half written.
+*/
+func writeCursor(path string, cursor love.Cursor) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".cursor")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+/*
+Run a shell command for a love, describing it in the environment. Failures are
+reported, but do not stop watching.
+*/
+func runHook(command string, l love.Love) {
+	hook := exec.Command("sh", "-c", command)
+	hook.Env = append(os.Environ(),
+		"GOLOVE_SENDER="+l.Sender,
+		"GOLOVE_RECIPIENT="+l.Recipient,
+		"GOLOVE_MESSAGE="+l.Message,
+		"GOLOVE_TIMESTAMP="+l.Timestamp.Format(time.RFC3339),
+	)
+	hook.Stdout = os.Stderr
+	hook.Stderr = os.Stderr
+	if err := hook.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "golove watch: -exec: %s\n", err)
+	}
+}
+
+/*
+A flag.Value collecting every value of a repeated flag.
+*/
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
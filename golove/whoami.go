@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+)
+
+var whoamiCommand = &command{
+	Name:    "whoami",
+	Args:    "",
+	Summary: "show the configured sender",
+	Long: `Print the configured sender and instance. The sender is looked up in the
+instance's user profiles, or with Autocomplete if there are none, which
+confirms that the user exists and the API key works. Their department and
+photo are shown if their profile has them.`,
+	Run: runWhoami,
+}
+
+func runWhoami(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	sender, err := cfg.sender()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	profile, err := client.GetUser(sender)
+	switch {
+	case err == nil:
+		fmt.Printf("%s (%s) on %s\n", profile.Name(), sender, cfg.BaseUrl)
+		if profile.Department != "" {
+			fmt.Printf("Department: %s\n", profile.Department)
+		}
+		if profile.PhotoURL != "" {
+			fmt.Printf("Photo: %s\n", profile.PhotoURL)
+		}
+		return nil
+	case errors.Is(err, love.ErrNoSuchUser):
+		return fmt.Errorf("user %s does not exist on %s", sender, cfg.BaseUrl)
+	case !errors.Is(err, love.ErrNotSupported):
+		return err
+	}
+	users, err := client.Autocomplete(sender)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if u.Username == sender {
+			fmt.Printf("%s on %s\n", u.Display, cfg.BaseUrl)
+			return nil
+		}
+	}
+	return fmt.Errorf("user %s does not exist on %s", sender, cfg.BaseUrl)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/store"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+)
+
+var wordsCommand = &command{
+	Name:    "words",
+	Args:    "[-user user] [-team] [-since date] [-until date] [-remote] [-db path] [-top n] [-bigrams] [-stopwords file] [-output format]",
+	Summary: "count the words used in love, for a word cloud",
+	Long: `List the words used most in the messages of love, most first, with the number
+of love each was used in, for a word cloud in a retrospective. For example:
+
+	golove words -since 2017-04-03 -top 100 -output csv > words.csv
+
+Common words such as "the" and "for", numbers, emoji shortcodes and @mentions
+are left out, and hashtags are counted as words. With -bigrams, pairs of words
+such as "great demo" are counted as well. With -stopwords, the words in a file,
+separated by spaces or newlines, are left out instead of the common words.
+
+The love is chosen as by "golove report": from the local database written by
+"golove sync", or the API if there is none or -remote is given; sent or
+received by each -user, or between them with -team.`,
+	Flags: defineWordsFlags,
+	Run:   runWords,
+}
+
+// The flags of golove words.
+var wordsFlags struct {
+	users         listFlag
+	team          bool
+	since, until  dateFlag
+	remote        bool
+	path          string
+	top           int
+	bigrams       bool
+	stopwordsPath string
+	output        string
+}
+
+func defineWordsFlags(flags *flag.FlagSet) {
+	wordsFlags.users = nil
+	flags.Var(&wordsFlags.users, "user", "include love sent and received by `user` (may be repeated)")
+	flags.BoolVar(&wordsFlags.team, "team", false, "only include love sent between the users")
+	wordsFlags.since, wordsFlags.until = dateFlag{}, dateFlag{}
+	flags.Var(&wordsFlags.since, "since", "only include love sent on or after `date` (YYYY-MM-DD)")
+	flags.Var(&wordsFlags.until, "until", "only include love sent before `date` (YYYY-MM-DD)")
+	flags.BoolVar(&wordsFlags.remote, "remote", false,
+		"fetch love from the API, rather than the local database")
+	flags.StringVar(&wordsFlags.path, "db", store.DefaultPath(), "the local database `path`")
+	flags.IntVar(&wordsFlags.top, "top", 50, "list the top `n` words (0 for all)")
+	flags.BoolVar(&wordsFlags.bigrams, "bigrams", false, "count pairs of words as well as words")
+	flags.StringVar(&wordsFlags.stopwordsPath, "stopwords", "",
+		"leave out the words in `file` instead of common words")
+	addOutputFlag(flags, &wordsFlags.output)
+}
+
+func runWords(cmd *command, args []string) error {
+	flags := cmd.flagSet()
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usagef("unexpected argument %q", flags.Arg(0))
+	}
+	if err := checkOutputFormat(wordsFlags.output); err != nil {
+		return err
+	}
+	options := love.WordOptions{Bigrams: wordsFlags.bigrams}
+	if wordsFlags.stopwordsPath != "" {
+		data, err := ioutil.ReadFile(wordsFlags.stopwordsPath)
+		if err != nil {
+			return err
+		}
+		options.Stopwords = make(map[string]bool)
+		for _, word := range love.Tokenize(string(data)) {
+			options.Stopwords[word] = true
+		}
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	client, err := cfg.client()
+	if err != nil {
+		return err
+	}
+	filter := love.LoveFilter{Since: wordsFlags.since.Time, Until: wordsFlags.until.Time}
+	var loves []love.Love
+	if _, statErr := os.Stat(wordsFlags.path); statErr == nil && !wordsFlags.remote {
+		loves, err = reportStore(wordsFlags.path, filter, wordsFlags.users)
+	} else {
+		if len(wordsFlags.users) == 0 {
+			sender, err := cfg.sender()
+			if err != nil {
+				return err
+			}
+			wordsFlags.users = listFlag{sender}
+		}
+		loves, err = reportAPI(client, filter, wordsFlags.users)
+	}
+	if err != nil {
+		return err
+	}
+	if wordsFlags.team {
+		loves = between(loves, wordsFlags.users)
+	}
+	words := love.WordFrequencies(loves, options, wordsFlags.top)
+	return page(func(w io.Writer) error {
+		return wordRecords(words).write(w, wordsFlags.output)
+	})
+}
+
+func wordRecords(words []love.Count) *records {
+	r := &records{Columns: []string{"word", "count"}}
+	for _, word := range words {
+		r.Rows = append(r.Rows, []string{word.Key, strconv.Itoa(word.Count)})
+		r.Items = append(r.Items, word)
+	}
+	r.Text = func(w io.Writer, i int) {
+		fmt.Fprintf(w, "%5d  %s\n", words[i].Count, words[i].Key)
+	}
+	return r
+}
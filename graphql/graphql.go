@@ -0,0 +1,321 @@
+/*
+Package graphql serves the love API over GraphQL, so that dashboards can fetch
+exactly the love and users they need in one request. The schema is:
+
+	scalar Time
+
+	type Love {
+		sender: String!
+		recipient: String!
+		message: String!
+		timestamp: Time!
+	}
+
+	type User {
+		username: String!
+		display: String!
+	}
+
+	type Query {
+		loves(sender: String, recipient: String, since: Time, until: Time, limit: Int = 20): [Love!]!
+		users(term: String!): [User!]!
+	}
+
+	type Mutation {
+		sendLove(sender: String!, recipients: [String!]!, message: String!): Boolean!
+	}
+
+Times are RFC 3339 strings. As in the love API, loves requires a sender or a
+recipient, and returns the newest love first. For example:
+
+	query {
+		loves(recipient: "hammy", limit: 5) { sender message timestamp }
+	}
+
+Requests are POSTed to the Handler as JSON, in the usual form:
+
+	{"query": "...", "variables": {...}}
+
+The sendLove mutation must be authorized with the Handler's Token, as a bearer
+token:
+
+	Authorization: Bearer <token>
+*/
+package graphql
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	gql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/hacsoc/golove/love"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long the results of loves queries are cached by default.
+const DefaultCacheTTL = 30 * time.Second
+
+// The largest limit a loves query may ask for.
+const MaxLimit = 1000
+
+// The most results of loves queries which are cached at once.
+const MaxCacheEntries = 1000
+
+const schema = `
+scalar Time
+
+type Love {
+	sender: String!
+	recipient: String!
+	message: String!
+	timestamp: Time!
+}
+
+type User {
+	username: String!
+	display: String!
+}
+
+type Query {
+	loves(sender: String, recipient: String, since: Time, until: Time, limit: Int = 20): [Love!]!
+	users(term: String!): [User!]!
+}
+
+type Mutation {
+	sendLove(sender: String!, recipients: [String!]!, message: String!): Boolean!
+}
+`
+
+/*
+A Handler serves GraphQL requests using Client. The results of loves queries are
+cached for CacheTTL, so that many dashboards showing the same love make few
+requests to the API; sending love clears the cache. At most MaxCacheEntries
+results are cached, since clients choose the queries. Autocomplete results are
+cached if the Client has an AutocompleteCache.
+
+The sendLove mutation sends love as any sender, so it is only allowed for
+requests bearing Token. Without a Token, or if ReadOnly, it is rejected.
+*/
+type Handler struct {
+	Client *love.Client
+	// Zero for DefaultCacheTTL, negative for no caching.
+	CacheTTL time.Duration
+	// The bearer token which authorizes sendLove.
+	Token string
+	// Rejects sendLove, even with the Token.
+	ReadOnly bool
+
+	once    sync.Once
+	handler http.Handler
+	mutex   sync.Mutex
+	cache   map[love.LoveFilter]cachedLove
+	now     func() time.Time
+}
+
+type cachedLove struct {
+	loves   []love.Love
+	expires time.Time
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.once.Do(func() {
+		h.handler = &relay.Handler{Schema: gql.MustParseSchema(schema, &resolver{h})}
+	})
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := context.WithValue(r.Context(), authorizedKey{}, h.authorized(r))
+	h.handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// The context key of whether a request may send love.
+type authorizedKey struct{}
+
+/*
+Report whether a request bears the Token.
+*/
+func (h *Handler) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && h.Token != "" &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(h.Token)) == 1
+}
+
+func (h *Handler) ttl() time.Duration {
+	if h.CacheTTL == 0 {
+		return DefaultCacheTTL
+	}
+	return h.CacheTTL
+}
+
+func (h *Handler) time() time.Time {
+	if h.now != nil {
+		return h.now()
+	}
+	return time.Now()
+}
+
+/*
+Return the love matching a filter, from the cache if possible.
+*/
+func (h *Handler) getLove(f love.LoveFilter) ([]love.Love, error) {
+	ttl := h.ttl()
+	if ttl > 0 {
+		h.mutex.Lock()
+		cached, ok := h.cache[f]
+		if ok && !h.time().Before(cached.expires) {
+			delete(h.cache, f)
+			ok = false
+		}
+		h.mutex.Unlock()
+		if ok {
+			return cached.loves, nil
+		}
+	}
+	loves, err := h.Client.GetLoveFiltered(f)
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		h.store(f, cachedLove{loves, h.time().Add(ttl)})
+	}
+	return loves, nil
+}
+
+/*
+Cache the love matching a filter, removing the results which have expired, and
+if the cache is still full, the result which expires first.
+*/
+func (h *Handler) store(f love.LoveFilter, cached cachedLove) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.cache == nil {
+		h.cache = make(map[love.LoveFilter]cachedLove)
+	}
+	if _, ok := h.cache[f]; !ok && len(h.cache) >= MaxCacheEntries {
+		now := h.time()
+		var oldest love.LoveFilter
+		var oldestExpires time.Time
+		for key, c := range h.cache {
+			if !now.Before(c.expires) {
+				delete(h.cache, key)
+			} else if oldestExpires.IsZero() || c.expires.Before(oldestExpires) {
+				oldest, oldestExpires = key, c.expires
+			}
+		}
+		if len(h.cache) >= MaxCacheEntries {
+			delete(h.cache, oldest)
+		}
+	}
+	h.cache[f] = cached
+}
+
+func (h *Handler) clearCache() {
+	h.mutex.Lock()
+	h.cache = nil
+	h.mutex.Unlock()
+}
+
+type resolver struct {
+	h *Handler
+}
+
+type lovesArgs struct {
+	Sender    *string
+	Recipient *string
+	Since     *gql.Time
+	Until     *gql.Time
+	Limit     int32
+}
+
+func (r *resolver) Loves(args lovesArgs) ([]*loveResolver, error) {
+	var f love.LoveFilter
+	if args.Sender != nil {
+		f.Sender = *args.Sender
+	}
+	if args.Recipient != nil {
+		f.Recipient = *args.Recipient
+	}
+	if f.Sender == "" && f.Recipient == "" {
+		return nil, errors.New("a sender or recipient is required")
+	}
+	if args.Since != nil {
+		f.Since = args.Since.Time
+	}
+	if args.Until != nil {
+		f.Until = args.Until.Time
+	}
+	f.Limit = int64(args.Limit)
+	if f.Limit <= 0 || f.Limit > MaxLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+	}
+	loves, err := r.h.getLove(f)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*loveResolver, len(loves))
+	for i := range loves {
+		resolvers[i] = &loveResolver{loves[i]}
+	}
+	return resolvers, nil
+}
+
+func (r *resolver) Users(args struct{ Term string }) ([]*userResolver, error) {
+	users, err := r.h.Client.Autocomplete(args.Term)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*userResolver, len(users))
+	for i := range users {
+		resolvers[i] = &userResolver{users[i]}
+	}
+	return resolvers, nil
+}
+
+type sendLoveArgs struct {
+	Sender     string
+	Recipients []string
+	Message    string
+}
+
+func (r *resolver) SendLove(ctx context.Context, args sendLoveArgs) (bool, error) {
+	if r.h.ReadOnly || r.h.Token == "" {
+		return false, errors.New("sending love is disabled")
+	}
+	if authorized, _ := ctx.Value(authorizedKey{}).(bool); !authorized {
+		return false, errors.New("sending love requires the token")
+	}
+	if len(args.Recipients) == 0 {
+		return false, errors.New("at least one recipient is required")
+	}
+	err := r.h.Client.SendLoveContext(ctx, args.Sender,
+		strings.Join(args.Recipients, ","), args.Message)
+	if err != nil {
+		return false, err
+	}
+	r.h.clearCache()
+	return true, nil
+}
+
+type loveResolver struct {
+	l love.Love
+}
+
+func (r *loveResolver) Sender() string    { return r.l.Sender }
+func (r *loveResolver) Recipient() string { return r.l.Recipient }
+func (r *loveResolver) Message() string   { return r.l.Message }
+func (r *loveResolver) Timestamp() gql.Time {
+	return gql.Time{Time: r.l.Timestamp}
+}
+
+type userResolver struct {
+	u love.User
+}
+
+func (r *userResolver) Username() string { return r.u.Username }
+func (r *userResolver) Display() string  { return r.u.Display }
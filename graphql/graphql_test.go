@@ -0,0 +1,177 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func at(hour int) time.Time {
+	return time.Date(2017, 4, 1, hour, 0, 0, 0, time.UTC)
+}
+
+func newTestHandler() (*Handler, *lovetest.Server) {
+	server := lovetest.NewServer("secret")
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	server.AddUser("jeremy", "Jeremy Jones")
+	server.AddLove(love.Love{Sender: "darwin", Recipient: "hammy", Message: "thanks!", Timestamp: at(1)})
+	server.AddLove(love.Love{Sender: "jeremy", Recipient: "hammy", Message: "great demo", Timestamp: at(2)})
+	server.AddLove(love.Love{Sender: "hammy", Recipient: "darwin", Message: "you too", Timestamp: at(3)})
+	return &Handler{Client: server.Client()}, server
+}
+
+type result struct {
+	Data   map[string]interface{}
+	Errors []struct{ Message string }
+}
+
+func query(h *Handler, q string, variables map[string]interface{}) *result {
+	return queryWithToken(h, "", q, variables)
+}
+
+func queryWithToken(h *Handler, token, q string, variables map[string]interface{}) *result {
+	body, _ := json.Marshal(map[string]interface{}{"query": q, "variables": variables})
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/graphql", bytes.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	h.ServeHTTP(recorder, req)
+	var r result
+	json.Unmarshal(recorder.Body.Bytes(), &r)
+	return &r
+}
+
+func TestLoves(t *testing.T) {
+	h, server := newTestHandler()
+	defer server.Close()
+	r := query(h, `{ loves(recipient: "hammy") { sender message timestamp } }`, nil)
+	assert.Empty(t, r.Errors)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"sender": "jeremy", "message": "great demo",
+			"timestamp": "2017-04-01T02:00:00Z"},
+		map[string]interface{}{"sender": "darwin", "message": "thanks!",
+			"timestamp": "2017-04-01T01:00:00Z"},
+	}, r.Data["loves"])
+
+	r = query(h, `query($since: Time) { loves(recipient: "hammy", since: $since, limit: 5) { sender } }`,
+		map[string]interface{}{"since": "2017-04-01T01:30:00Z"})
+	assert.Empty(t, r.Errors)
+	assert.Equal(t, []interface{}{map[string]interface{}{"sender": "jeremy"}}, r.Data["loves"])
+}
+
+func TestLovesErrors(t *testing.T) {
+	h, server := newTestHandler()
+	defer server.Close()
+	r := query(h, `{ loves { sender } }`, nil)
+	assert.Equal(t, "a sender or recipient is required", r.Errors[0].Message)
+	r = query(h, `{ loves(sender: "hammy", limit: 0) { sender } }`, nil)
+	assert.Equal(t, "limit must be between 1 and 1000", r.Errors[0].Message)
+}
+
+func TestLovesCached(t *testing.T) {
+	h, server := newTestHandler()
+	defer server.Close()
+	now := at(12)
+	h.now = func() time.Time { return now }
+	q := `{ loves(sender: "darwin") { message } }`
+	assert.Len(t, query(h, q, nil).Data["loves"], 1)
+	server.AddLove(love.Love{Sender: "darwin", Recipient: "jeremy", Message: "hi", Timestamp: at(4)})
+	assert.Len(t, query(h, q, nil).Data["loves"], 1)
+	now = now.Add(DefaultCacheTTL)
+	assert.Len(t, query(h, q, nil).Data["loves"], 2)
+
+	h.CacheTTL = -1
+	server.AddLove(love.Love{Sender: "darwin", Recipient: "jeremy", Message: "hey", Timestamp: at(5)})
+	assert.Len(t, query(h, q, nil).Data["loves"], 3)
+}
+
+func TestCacheLimited(t *testing.T) {
+	h, server := newTestHandler()
+	defer server.Close()
+	now := at(12)
+	h.now = func() time.Time { return now }
+	q := `query($since: Time) { loves(sender: "darwin", since: $since) { message } }`
+	for i := 0; i < MaxCacheEntries+10; i++ {
+		since := at(0).Add(time.Duration(i) * time.Second).Format(time.RFC3339)
+		query(h, q, map[string]interface{}{"since": since})
+		now = now.Add(time.Millisecond)
+	}
+	assert.Equal(t, MaxCacheEntries, len(h.cache))
+	// The first queries, which expire first, were removed.
+	_, ok := h.cache[love.LoveFilter{Sender: "darwin", Since: at(0), Limit: 20}]
+	assert.False(t, ok)
+
+	// Expired results are removed, rather than replaced.
+	now = now.Add(DefaultCacheTTL)
+	query(h, q, map[string]interface{}{"since": at(0).Format(time.RFC3339)})
+	assert.Equal(t, 1, len(h.cache))
+}
+
+func TestUsers(t *testing.T) {
+	h, server := newTestHandler()
+	defer server.Close()
+	r := query(h, `{ users(term: "ha") { username display } }`, nil)
+	assert.Empty(t, r.Errors)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"username": "hammy", "display": "Hammy Havoc (hammy)"},
+	}, r.Data["users"])
+}
+
+func TestSendLove(t *testing.T) {
+	h, server := newTestHandler()
+	defer server.Close()
+	h.Token = "token"
+	q := `{ loves(sender: "jeremy") { message } }`
+	assert.Len(t, query(h, q, nil).Data["loves"], 1)
+
+	r := queryWithToken(h, "token",
+		`mutation { sendLove(sender: "jeremy", recipients: ["hammy", "darwin"], message: "thanks") }`, nil)
+	assert.Empty(t, r.Errors)
+	assert.Equal(t, true, r.Data["sendLove"])
+	// Sending love clears the cache.
+	assert.Len(t, query(h, q, nil).Data["loves"], 3)
+
+	r = queryWithToken(h, "token",
+		`mutation { sendLove(sender: "jeremy", recipients: ["nobody"], message: "hi") }`, nil)
+	assert.NotEmpty(t, r.Errors)
+	r = queryWithToken(h, "token",
+		`mutation { sendLove(sender: "jeremy", recipients: [], message: "hi") }`, nil)
+	assert.Equal(t, "at least one recipient is required", r.Errors[0].Message)
+	assert.Len(t, server.Loves(), 5)
+}
+
+func TestSendLoveUnauthorized(t *testing.T) {
+	h, server := newTestHandler()
+	defer server.Close()
+	mutation := `mutation { sendLove(sender: "jeremy", recipients: ["hammy"], message: "hi") }`
+
+	// Without a Token, no one may send love.
+	r := query(h, mutation, nil)
+	assert.Equal(t, "sending love is disabled", r.Errors[0].Message)
+
+	h.Token = "token"
+	r = query(h, mutation, nil)
+	assert.Equal(t, "sending love requires the token", r.Errors[0].Message)
+	r = queryWithToken(h, "wrong", mutation, nil)
+	assert.Equal(t, "sending love requires the token", r.Errors[0].Message)
+
+	h.ReadOnly = true
+	r = queryWithToken(h, "token", mutation, nil)
+	assert.Equal(t, "sending love is disabled", r.Errors[0].Message)
+	assert.Len(t, server.Loves(), 3)
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	h, server := newTestHandler()
+	defer server.Close()
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest("GET", "/graphql", nil))
+	assert.Equal(t, 405, recorder.Code)
+}
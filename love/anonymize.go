@@ -0,0 +1,49 @@
+package love
+
+import "crypto/hmac"
+import "crypto/sha256"
+import "encoding/hex"
+import "strings"
+
+/*
+An Anonymizer replaces the usernames in love with pseudonyms, so that how much
+love is sent, and between how many people, can be shared without showing who
+sent it. Each username always has the same pseudonym for the same Key, so love
+anonymized separately can be combined, but the pseudonyms cannot be reversed,
+or checked against a guessed username, without the Key.
+
+Usernames mentioned in messages are replaced as well, but messages may still
+name people in other ways; set StripMessages to remove them entirely.
+*/
+type Anonymizer struct {
+	Key           []byte
+	StripMessages bool
+}
+
+/*
+Return the pseudonym of a username: "user-" followed by the first 12 hex digits
+of the HMAC-SHA256 of the username, in lower case, under the Key.
+*/
+func (a *Anonymizer) Pseudonym(username string) string {
+	mac := hmac.New(sha256.New, a.Key)
+	mac.Write([]byte(strings.ToLower(username)))
+	return "user-" + hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+/*
+Return a copy of a love with the sender, recipient and mentions in the message
+replaced by their pseudonyms, or the message removed if StripMessages is set.
+The time and company values are kept.
+*/
+func (a *Anonymizer) Anonymize(l Love) Love {
+	l.Sender = a.Pseudonym(l.Sender)
+	l.Recipient = a.Pseudonym(l.Recipient)
+	if a.StripMessages {
+		l.Message = ""
+	} else {
+		l.Message = ReplaceMentions(l.Message, func(username string) string {
+			return a.Pseudonym(username)
+		})
+	}
+	return l
+}
@@ -0,0 +1,32 @@
+package love
+
+import "testing"
+import "time"
+import "github.com/stretchr/testify/assert"
+
+func TestPseudonym(t *testing.T) {
+	a := &Anonymizer{Key: []byte("secret")}
+	pseudonym := a.Pseudonym("hammy")
+	assert.Regexp(t, "^user-[0-9a-f]{12}$", pseudonym)
+	assert.Equal(t, pseudonym, a.Pseudonym("Hammy"))
+	assert.NotEqual(t, pseudonym, a.Pseudonym("darwin"))
+	other := &Anonymizer{Key: []byte("other")}
+	assert.NotEqual(t, pseudonym, other.Pseudonym("hammy"))
+}
+
+func TestAnonymize(t *testing.T) {
+	a := &Anonymizer{Key: []byte("secret")}
+	sent := time.Date(2017, 4, 3, 12, 0, 0, 0, time.UTC)
+	l := Love{Sender: "hammy", Recipient: "darwin", Message: "thanks, with @jeremy",
+		Timestamp: sent, Values: []string{"teamwork"}}
+	anonymized := a.Anonymize(l)
+	assert.Equal(t, anonymized.Sender, a.Pseudonym("hammy"))
+	assert.Equal(t, anonymized.Recipient, a.Pseudonym("darwin"))
+	assert.Equal(t, anonymized.Message, "thanks, with @"+a.Pseudonym("jeremy"))
+	assert.Equal(t, anonymized.Timestamp, sent)
+	assert.Equal(t, anonymized.Values, []string{"teamwork"})
+	assert.Equal(t, l.Sender, "hammy")
+
+	a.StripMessages = true
+	assert.Equal(t, a.Anonymize(l).Message, "")
+}
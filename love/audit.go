@@ -0,0 +1,95 @@
+package love
+
+import "bufio"
+import "context"
+import "encoding/json"
+import "fmt"
+import "io"
+import "time"
+
+/*
+An AuditEntry records an attempt to send love, as written to a client's Audit.
+Recipients and Message are as they were sent, after groups were expanded and
+shortcodes replaced, or as given if the love was refused before then. Retries
+counts the requests which were tried again at a fallback URL. Status is the
+status of the server's response, or zero if there was none, and Error is empty
+if the love was sent. Key is the idempotency key the love was sent with, if the
+client kept a record of it, and AlreadySent is set if no love was sent because
+that key was already sent.
+*/
+type AuditEntry struct {
+	Time        time.Time `json:"time"`
+	Sender      string    `json:"sender"`
+	Recipients  []string  `json:"recipients"`
+	Message     string    `json:"message"`
+	Values      []string  `json:"values,omitempty"`
+	DryRun      bool      `json:"dry_run,omitempty"`
+	Retries     int       `json:"retries"`
+	Status      int       `json:"status,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Key         string    `json:"idempotency_key,omitempty"`
+	AlreadySent bool      `json:"already_sent,omitempty"`
+}
+
+/*
+Report whether the love was sent, or would have been in a dry run.
+*/
+func (e AuditEntry) Succeeded() bool {
+	return e.Error == ""
+}
+
+// The context key of the number of requests tried again at a fallback URL.
+type retriesKey struct{}
+
+/*
+Count the requests made with the returned context which are tried again at a
+fallback URL.
+*/
+func countRetries(ctx context.Context, retries *int) context.Context {
+	return context.WithValue(ctx, retriesKey{}, retries)
+}
+
+func addRetry(ctx context.Context) {
+	if retries, ok := ctx.Value(retriesKey{}).(*int); ok {
+		*retries++
+	}
+}
+
+/*
+Write an entry to the client's Audit, as a line of JSON. The audit is written
+by a single call to Write, so that a file opened for appending is not
+interleaved when several goroutines or processes send love at once. Failing to
+write it does not fail the love, which may already have been sent; it is logged
+to the client's Logger instead.
+*/
+func (c *Client) audit(ctx context.Context, entry *AuditEntry) {
+	data, err := json.Marshal(entry)
+	if err == nil {
+		c.auditMutex.Lock()
+		_, err = c.Audit.Write(append(data, '\n'))
+		c.auditMutex.Unlock()
+	}
+	if err != nil && c.Logger != nil {
+		c.Logger.ErrorContext(ctx, "could not write the audit log", "error", err)
+	}
+}
+
+/*
+Read the entries of an audit log, oldest first.
+*/
+func ReadAudit(r io.Reader) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
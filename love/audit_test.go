@@ -0,0 +1,81 @@
+package love
+
+import "bytes"
+import "net/http/httptest"
+import "strings"
+import "testing"
+import "github.com/stretchr/testify/assert"
+
+func TestAuditSend(t *testing.T) {
+	down := httptest.NewServer(nil)
+	down.Close()
+	up, _ := newCountingServer(201, "Love sent!")
+	defer up.Close()
+
+	var log bytes.Buffer
+	client := NewClient(testApiKey, down.URL+"/api")
+	client.FallbackUrls = []string{up.URL + "/api"}
+	client.Groups = Groups{"team": {"darwin", "jeremy"}}
+	client.Audit = &log
+	err := client.SendLoveValues("hammy", "@team", "thanks :tada:", []string{"teamwork"})
+	assert.Nil(t, err)
+
+	entries, err := ReadAudit(&log)
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), 1)
+	entry := entries[0]
+	assert.False(t, entry.Time.IsZero())
+	assert.Equal(t, entry.Sender, "hammy")
+	assert.Equal(t, entry.Recipients, []string{"darwin", "jeremy"})
+	assert.Equal(t, entry.Message, "thanks 🎉")
+	assert.Equal(t, entry.Values, []string{"teamwork"})
+	assert.Equal(t, entry.Retries, 1)
+	assert.Equal(t, entry.Status, 201)
+	assert.True(t, entry.Succeeded())
+}
+
+func TestAuditFailures(t *testing.T) {
+	server, _ := newCountingServer(500, "oops "+testApiKey)
+	defer server.Close()
+
+	var log bytes.Buffer
+	client := NewClient(testApiKey, server.URL+"/api")
+	client.Audit = &log
+	assert.NotNil(t, client.SendLove("hammy", "darwin", "thanks"))
+	assert.NotNil(t, client.SendLove("hammy", "darwin", "  "))
+
+	entries, err := ReadAudit(&log)
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), 2)
+	assert.Equal(t, entries[0].Status, 500)
+	assert.False(t, entries[0].Succeeded())
+	assert.NotContains(t, entries[0].Error, testApiKey)
+	assert.Equal(t, entries[1].Status, 0)
+	assert.Equal(t, entries[1].Message, "  ")
+	assert.False(t, entries[1].Succeeded())
+}
+
+func TestAuditDryRun(t *testing.T) {
+	var out, log bytes.Buffer
+	client := getTestClient()
+	client.DryRun = &out
+	client.Audit = &log
+	assert.Nil(t, client.SendLove("hammy", "darwin", "thanks"))
+	entries, err := ReadAudit(&log)
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), 1)
+	assert.True(t, entries[0].DryRun)
+	assert.True(t, entries[0].Succeeded())
+}
+
+func TestReadAudit(t *testing.T) {
+	entries, err := ReadAudit(strings.NewReader(
+		`{"time":"2017-04-03T12:00:00Z","sender":"hammy","recipients":["darwin"],"message":"hi","retries":0}` +
+			"\n\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Sender, "hammy")
+
+	_, err = ReadAudit(strings.NewReader("{}\nnot json\n"))
+	assert.EqualError(t, err, "line 2: invalid character 'o' in literal null (expecting 'u')")
+}
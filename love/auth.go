@@ -0,0 +1,123 @@
+package love
+
+import "io"
+import "io/ioutil"
+import "net/http"
+import "net/url"
+import "strings"
+
+/*
+An Authenticator adds credentials to each request made by a Client, before it
+is passed to the Middleware. The Client uses APIKeyAuth with its ApiKey unless
+its Auth is set, which allows instances behind an OAuth2 proxy, or which expect
+a token in a header, to be used:
+
+	client := love.NewClient("", "https://love.example.com/api")
+	client.Auth = love.BearerAuth{Token: token}
+*/
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+/*
+Implemented by the Authenticators in this package, so that their credentials
+can be removed by Redact.
+*/
+type secretAuthenticator interface {
+	secret() string
+}
+
+const formContentType = "application/x-www-form-urlencoded"
+
+/*
+APIKeyAuth authenticates with an API key, as stock Yelp Love expects: in the
+api_key field of a form POST, and in the api_key query parameter of any other
+request.
+
+A key in the query string may end up in the logs of the server and of proxies
+along the way. If the instance accepts the key in a header, set Header to its
+name, and the key is sent in that header instead, and never in the URL or form.
+*/
+type APIKeyAuth struct {
+	Key    string
+	Header string
+}
+
+func (a APIKeyAuth) Authenticate(req *http.Request) error {
+	if a.Header != "" {
+		req.Header.Set(a.Header, a.Key)
+		return nil
+	}
+	if req.Body == nil || req.Header.Get("Content-Type") != formContentType {
+		query := req.URL.Query()
+		query.Set("api_key", a.Key)
+		req.URL.RawQuery = query.Encode()
+		return nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+	values.Set("api_key", a.Key)
+	encoded := values.Encode()
+	req.ContentLength = int64(len(encoded))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader(encoded)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return nil
+}
+
+func (a APIKeyAuth) secret() string {
+	return a.Key
+}
+
+/*
+BearerAuth authenticates with a token in the Authorization header, as OAuth2
+proxies expect.
+*/
+type BearerAuth struct {
+	Token string
+}
+
+func (a BearerAuth) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+func (a BearerAuth) secret() string {
+	return a.Token
+}
+
+/*
+HeaderAuth authenticates by setting a header, Name, to Value, for instances
+behind a proxy which expects a custom header.
+*/
+type HeaderAuth struct {
+	Name  string
+	Value string
+}
+
+func (a HeaderAuth) Authenticate(req *http.Request) error {
+	req.Header.Set(a.Name, a.Value)
+	return nil
+}
+
+func (a HeaderAuth) secret() string {
+	return a.Value
+}
+
+/*
+Return the Authenticator which the client uses.
+*/
+func (c *Client) authenticator() Authenticator {
+	if c.Auth != nil {
+		return c.Auth
+	}
+	return APIKeyAuth{Key: c.ApiKey}
+}
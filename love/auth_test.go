@@ -0,0 +1,126 @@
+package love
+
+import "bytes"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "io/ioutil"
+import "net/http"
+import "net/url"
+import "strings"
+
+func TestAPIKeyAuth(t *testing.T) {
+	auth := APIKeyAuth{Key: "a+b"}
+	req, _ := http.NewRequest("GET", testLoveUrl+"?sender=hammy", nil)
+	assert.Nil(t, auth.Authenticate(req))
+	assert.Equal(t, req.URL.RawQuery, "api_key=a%2Bb&sender=hammy")
+
+	req, _ = http.NewRequest("POST", testLoveUrl, strings.NewReader("sender=hammy"))
+	req.Header.Set("Content-Type", formContentType)
+	assert.Nil(t, auth.Authenticate(req))
+	body, _ := ioutil.ReadAll(req.Body)
+	assert.Equal(t, string(body), "api_key=a%2Bb&sender=hammy")
+	assert.Equal(t, req.ContentLength, int64(len(body)))
+	assert.Equal(t, req.URL.RawQuery, "")
+}
+
+func TestAPIKeyAuthHeader(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var header string
+	var query, form url.Values
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			header = req.Header.Get("X-Api-Key")
+			query = req.URL.Query()
+			return httpmock.NewStringResponse(200, "[]"), nil
+		},
+	)
+	httpmock.RegisterResponder(
+		"POST", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			header = req.Header.Get("X-Api-Key")
+			body, _ := ioutil.ReadAll(req.Body)
+			form, _ = url.ParseQuery(string(body))
+			return httpmock.NewStringResponse(201, "Love sent!"), nil
+		},
+	)
+
+	client := getTestClient()
+	client.Auth = APIKeyAuth{Key: testApiKey, Header: "X-Api-Key"}
+	_, err := client.GetLove("hammy", "", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, header, testApiKey)
+	assert.Equal(t, query.Get("sender"), "hammy")
+	assert.Equal(t, query.Get("api_key"), "")
+
+	header = ""
+	err = client.SendLove("hammy", "darwin", "thanks")
+	assert.Nil(t, err)
+	assert.Equal(t, header, testApiKey)
+	assert.Equal(t, form.Get("message"), "thanks")
+	assert.Equal(t, form.Get("api_key"), "")
+}
+
+func TestBearerAuth(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var authorization string
+	var query url.Values
+	httpmock.RegisterResponder(
+		"GET", testAutocompleteUrl,
+		func(req *http.Request) (*http.Response, error) {
+			authorization = req.Header.Get("Authorization")
+			query = req.URL.Query()
+			return httpmock.NewStringResponse(200, "[]"), nil
+		},
+	)
+
+	client := NewClient("", testBaseUrl)
+	client.Auth = BearerAuth{Token: "token"}
+	_, err := client.Autocomplete("ha")
+	assert.Nil(t, err)
+	assert.Equal(t, authorization, "Bearer token")
+	assert.Equal(t, query.Get("term"), "ha")
+	_, ok := query["api_key"]
+	assert.False(t, ok)
+	assert.Equal(t, client.Redact("Bearer token"), "Bearer REDACTED")
+}
+
+func TestHeaderAuth(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var header string
+	httpmock.RegisterResponder(
+		"POST", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			header = req.Header.Get("X-Love-Token")
+			return httpmock.NewStringResponse(201, "Love sent!"), nil
+		},
+	)
+
+	client := NewClient("", testBaseUrl)
+	client.Auth = HeaderAuth{Name: "X-Love-Token", Value: "secret"}
+	err := client.SendLove("hammy", "darwin", "thanks")
+	assert.Nil(t, err)
+	assert.Equal(t, header, "secret")
+}
+
+func TestDryRunRedactsAuth(t *testing.T) {
+	var out bytes.Buffer
+	client := NewClient("", testBaseUrl)
+	client.Auth = BearerAuth{Token: "token"}
+	client.DryRun = &out
+
+	err := client.SendLove("hammy", "darwin", "thanks")
+	assert.Nil(t, err)
+	assert.Equal(t, out.String(), "POST "+testLoveUrl+"\n"+
+		"Authorization: Bearer REDACTED\n"+
+		"Content-Type: application/x-www-form-urlencoded\n"+
+		"User-Agent: "+DefaultUserAgent+"\n\n"+
+		"message=thanks&recipient=darwin&sender=hammy\n")
+}
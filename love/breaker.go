@@ -0,0 +1,221 @@
+package love
+
+import "context"
+import "errors"
+import "log/slog"
+import "net"
+import "net/http"
+import "sync"
+import "time"
+
+/*
+ErrCircuitOpen is returned, without making a request, while a client's
+CircuitBreaker is open. It is temporary, according to IsTemporary.
+*/
+var ErrCircuitOpen = errors.New("love: circuit breaker open")
+
+/*
+The defaults used by a CircuitBreaker whose Threshold or Cooldown is zero.
+*/
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+/*
+The state of a CircuitBreaker.
+*/
+type CircuitState int
+
+const (
+	// Requests are made as usual.
+	CircuitClosed CircuitState = iota
+	// Requests fail with ErrCircuitOpen.
+	CircuitOpen
+	// A single request is made to find out whether the server has recovered.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+/*
+A CircuitBreaker stops a client from making requests to a server which is down,
+so that a bot retrying in a loop does not hammer it, and callers fail quickly.
+
+After Threshold consecutive requests fail because the server could not be
+reached, or responded with a temporary error (5xx or 429), the circuit opens,
+and every request fails immediately with ErrCircuitOpen. Once Cooldown has
+passed, the circuit is half-open: a single request is let through as a probe,
+while others still fail. If the probe succeeds the circuit closes, and if it
+fails the circuit opens for another Cooldown. Errors caused by the request
+itself, such as bad parameters, show that the server is up, and do not count
+as failures.
+
+OnStateChange, if it is set, is called whenever the state changes. It must not
+block. A CircuitBreaker is safe to share between clients of the same instance,
+and must not be copied after it is first used.
+*/
+type CircuitBreaker struct {
+	Threshold     int
+	Cooldown      time.Duration
+	OnStateChange func(from, to CircuitState)
+
+	mutex    sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+	rejected int64
+}
+
+/*
+Return the current state of the circuit. An open circuit whose Cooldown has
+passed is reported as half-open.
+*/
+func (b *CircuitBreaker) State() CircuitState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.state == CircuitOpen && b.cooledDown() {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+/*
+Return the number of requests which have failed with ErrCircuitOpen.
+*/
+func (b *CircuitBreaker) Rejected() int64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.rejected
+}
+
+func (b *CircuitBreaker) threshold() int {
+	if b.Threshold <= 0 {
+		return DefaultBreakerThreshold
+	}
+	return b.Threshold
+}
+
+func (b *CircuitBreaker) cooledDown() bool {
+	cooldown := b.Cooldown
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return time.Since(b.openedAt) >= cooldown
+}
+
+/*
+Ask to make a request, returning ErrCircuitOpen if it may not be made. A request
+which is allowed must be followed by a call to done.
+*/
+func (b *CircuitBreaker) allow() (CircuitState, CircuitState, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	from := b.state
+	if b.state == CircuitOpen && b.cooledDown() {
+		b.state = CircuitHalfOpen
+	}
+	if b.state == CircuitHalfOpen {
+		if b.probing {
+			b.rejected++
+			return from, b.state, ErrCircuitOpen
+		}
+		b.probing = true
+	}
+	if b.state == CircuitOpen {
+		b.rejected++
+		return from, b.state, ErrCircuitOpen
+	}
+	return from, b.state, nil
+}
+
+/*
+The outcome of a request, as far as the circuit breaker is concerned.
+*/
+type breakerOutcome int
+
+const (
+	// The server responded, if only to say the request was bad.
+	breakerSuccess breakerOutcome = iota
+	// The server could not be reached, or is in trouble.
+	breakerFailure
+	// Nothing was learned about the server, eg because the request was canceled.
+	breakerIgnored
+)
+
+/*
+Record the outcome of a request allowed by allow, returning the states before
+and after.
+*/
+func (b *CircuitBreaker) done(outcome breakerOutcome) (CircuitState, CircuitState) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	from := b.state
+	if b.state == CircuitHalfOpen {
+		b.probing = false
+	}
+	switch outcome {
+	case breakerSuccess:
+		b.state = CircuitClosed
+		b.failures = 0
+	case breakerFailure:
+		b.failures++
+		if b.state == CircuitHalfOpen || b.failures >= b.threshold() {
+			b.state = CircuitOpen
+			b.openedAt = time.Now()
+		}
+	}
+	return from, b.state
+}
+
+/*
+Classify the result of a request for the circuit breaker.
+*/
+func breakerResult(ctx context.Context, resp *http.Response, err error) breakerOutcome {
+	if ctx.Err() != nil {
+		return breakerIgnored
+	}
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			return breakerFailure
+		}
+		return breakerIgnored
+	}
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return breakerFailure
+	}
+	return breakerSuccess
+}
+
+/*
+Report a change in the state of the client's circuit breaker to OnStateChange
+and the Logger.
+*/
+func (c *Client) breakerChanged(ctx context.Context, from, to CircuitState) {
+	if from == to {
+		return
+	}
+	if c.Breaker.OnStateChange != nil {
+		c.Breaker.OnStateChange(from, to)
+	}
+	if c.Logger != nil {
+		level := slog.LevelInfo
+		if to == CircuitOpen {
+			level = slog.LevelWarn
+		}
+		c.Logger.LogAttrs(ctx, level, "love circuit breaker",
+			slog.String("from", from.String()), slog.String("state", to.String()))
+	}
+}
@@ -0,0 +1,109 @@
+package love
+
+import "bytes"
+import "errors"
+import "log/slog"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+import "net/http/httptest"
+import "sync/atomic"
+import "time"
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	server, requests := newCountingServer(503, "unavailable")
+	defer server.Close()
+
+	var logs bytes.Buffer
+	var changes []string
+	client := NewClient(testApiKey, server.URL+"/api")
+	client.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	client.Breaker = &CircuitBreaker{
+		Threshold: 2,
+		Cooldown:  time.Hour,
+		OnStateChange: func(from, to CircuitState) {
+			changes = append(changes, from.String()+" -> "+to.String())
+		},
+	}
+	for i := 0; i < 2; i++ {
+		_, err := client.GetLove("hammy", "", 1)
+		assert.False(t, errors.Is(err, ErrCircuitOpen))
+	}
+	assert.Equal(t, client.Breaker.State(), CircuitOpen)
+
+	_, err := client.GetLove("hammy", "", 1)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.True(t, IsTemporary(err))
+	err = client.SendLove("hammy", "darwin", "thanks")
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, atomic.LoadInt32(requests), int32(2))
+	assert.Equal(t, client.Breaker.Rejected(), int64(2))
+	assert.Equal(t, changes, []string{"closed -> open"})
+	assert.Contains(t, logs.String(), `msg="love circuit breaker" from=closed state=open`)
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	var status int32 = 503
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(int(atomic.LoadInt32(&status)))
+			w.Write([]byte("[]"))
+		}))
+	defer server.Close()
+
+	client := NewClient(testApiKey, server.URL+"/api")
+	client.Breaker = &CircuitBreaker{Threshold: 1, Cooldown: time.Millisecond}
+	_, err := client.GetLove("hammy", "", 1)
+	assert.NotNil(t, err)
+	time.Sleep(2 * time.Millisecond)
+	assert.Equal(t, client.Breaker.State(), CircuitHalfOpen)
+
+	// A failed probe opens the circuit again.
+	_, err = client.GetLove("hammy", "", 1)
+	assert.False(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, client.Breaker.state, CircuitOpen)
+
+	// Only one probe is made at a time.
+	time.Sleep(2 * time.Millisecond)
+	from, to, err := client.Breaker.allow()
+	assert.Nil(t, err)
+	assert.Equal(t, from, CircuitOpen)
+	assert.Equal(t, to, CircuitHalfOpen)
+	_, _, err = client.Breaker.allow()
+	assert.Equal(t, err, ErrCircuitOpen)
+	client.Breaker.done(breakerIgnored)
+	assert.Equal(t, client.Breaker.State(), CircuitHalfOpen)
+
+	// A successful probe closes it.
+	atomic.StoreInt32(&status, 200)
+	_, err = client.GetLove("hammy", "", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, client.Breaker.State(), CircuitClosed)
+}
+
+func TestCircuitBreakerIgnoresBadRequests(t *testing.T) {
+	server, _ := newCountingServer(loveBadParamsStatusCode, "bad params")
+	defer server.Close()
+
+	client := NewClient(testApiKey, server.URL+"/api")
+	client.Breaker = &CircuitBreaker{Threshold: 1}
+	for i := 0; i < 3; i++ {
+		err := client.SendLove("hammy", "darwin", "thanks")
+		assert.True(t, errors.Is(err, ErrBadParams))
+	}
+	assert.Equal(t, client.Breaker.State(), CircuitClosed)
+}
+
+func TestCircuitBreakerUnreachable(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	client := NewClient(testApiKey, down.URL+"/api")
+	client.Breaker = &CircuitBreaker{}
+	for i := 0; i < DefaultBreakerThreshold; i++ {
+		err := client.SendLove("hammy", "darwin", "thanks")
+		assert.False(t, errors.Is(err, ErrCircuitOpen))
+	}
+	err := client.SendLove("hammy", "darwin", "thanks")
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+}
@@ -0,0 +1,150 @@
+package love
+
+import "encoding/json"
+import "io/ioutil"
+import "os"
+import "path/filepath"
+import "sync"
+import "time"
+
+/*
+An AutocompleteCache holds Autocomplete results for a period of time, so that
+interactive tools do not request the same term on every keystroke. Set it as
+the AutocompleteCache of a Client to use it. It is safe for concurrent use.
+
+A cache created with LoadAutocompleteCache is backed by a file, and Save writes
+the unexpired entries back to it, so that results may be shared between runs
+of a program.
+*/
+type AutocompleteCache struct {
+	// How long results remain fresh.
+	TTL time.Duration
+	// The file the cache is saved to, if any.
+	Path string
+
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	Users   []User    `json:"users"`
+	Fetched time.Time `json:"fetched"`
+}
+
+/*
+Create an empty in-memory cache whose results expire after ttl.
+*/
+func NewAutocompleteCache(ttl time.Duration) *AutocompleteCache {
+	return &AutocompleteCache{
+		TTL:     ttl,
+		entries: make(map[string]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+/*
+Create a cache backed by a file, loading its unexpired entries. A missing file
+results in an empty cache.
+*/
+func LoadAutocompleteCache(path string, ttl time.Duration) (*AutocompleteCache, error) {
+	cache := NewAutocompleteCache(ttl)
+	cache.Path = path
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(contents, &cache.entries); err != nil {
+		return nil, err
+	}
+	cache.expire()
+	return cache, nil
+}
+
+/*
+Return the cached result for a term, if it has not expired.
+*/
+func (c *AutocompleteCache) Get(term string) ([]User, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[term]
+	if !ok || c.expired(entry) {
+		return nil, false
+	}
+	return entry.Users, true
+}
+
+/*
+Store the result for a term.
+*/
+func (c *AutocompleteCache) Put(term string, users []User) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[term] = cacheEntry{Users: users, Fetched: c.now()}
+}
+
+/*
+Remove the result for a term.
+*/
+func (c *AutocompleteCache) Invalidate(term string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, term)
+}
+
+/*
+Remove every result.
+*/
+func (c *AutocompleteCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = make(map[string]cacheEntry)
+}
+
+/*
+Write the unexpired results to Path, if it is set. The file is replaced
+atomically, so concurrent readers never see a partial cache.
+*/
+func (c *AutocompleteCache) Save() error {
+	if c.Path == "" {
+		return nil
+	}
+	c.mutex.Lock()
+	c.expire()
+	contents, err := json.Marshal(c.entries)
+	c.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(c.Path), ".autocomplete")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.Path)
+}
+
+func (c *AutocompleteCache) expired(entry cacheEntry) bool {
+	return c.now().Sub(entry.Fetched) >= c.TTL
+}
+
+// Must be called with the mutex held, or before the cache is shared.
+func (c *AutocompleteCache) expire() {
+	for term, entry := range c.entries {
+		if c.expired(entry) {
+			delete(c.entries, term)
+		}
+	}
+}
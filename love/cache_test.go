@@ -0,0 +1,92 @@
+package love
+
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+import "path/filepath"
+import "time"
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestAutocompleteCache(t *testing.T) {
+	clock := &fakeClock{time.Now()}
+	cache := NewAutocompleteCache(time.Minute)
+	cache.now = clock.Now
+	users := []User{{Display: "Hammy Havoc (hammy)", Username: "hammy"}}
+
+	_, ok := cache.Get("ha")
+	assert.False(t, ok)
+	cache.Put("ha", users)
+	cached, ok := cache.Get("ha")
+	assert.True(t, ok)
+	assert.Equal(t, cached, users)
+
+	clock.now = clock.now.Add(time.Minute)
+	_, ok = cache.Get("ha")
+	assert.False(t, ok)
+
+	cache.Put("ha", users)
+	cache.Invalidate("ha")
+	_, ok = cache.Get("ha")
+	assert.False(t, ok)
+
+	cache.Put("ha", users)
+	cache.Clear()
+	_, ok = cache.Get("ha")
+	assert.False(t, ok)
+}
+
+func TestAutocompleteCacheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "autocomplete.json")
+	cache, err := LoadAutocompleteCache(path, time.Hour)
+	assert.Nil(t, err)
+	users := []User{{Display: "Hammy Havoc (hammy)", Username: "hammy"}}
+	cache.Put("ha", users)
+	assert.Nil(t, cache.Save())
+
+	loaded, err := LoadAutocompleteCache(path, time.Hour)
+	assert.Nil(t, err)
+	cached, ok := loaded.Get("ha")
+	assert.True(t, ok)
+	assert.Equal(t, cached, users)
+
+	expired, err := LoadAutocompleteCache(path, 0)
+	assert.Nil(t, err)
+	_, ok = expired.Get("ha")
+	assert.False(t, ok)
+}
+
+func TestAutocompleteUsesCache(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	requests := 0
+	httpmock.RegisterResponder(
+		"GET", testAutocompleteUrl,
+		func(*http.Request) (*http.Response, error) {
+			requests++
+			return httpmock.NewStringResponse(200,
+				`[{"label": "Hammy Havoc (hammy)", "value": "hammy"}]`), nil
+		},
+	)
+
+	client := getTestClient()
+	client.AutocompleteCache = NewAutocompleteCache(time.Minute)
+	for i := 0; i < 3; i++ {
+		users, err := client.Autocomplete("ha")
+		assert.Nil(t, err)
+		assert.Equal(t, users[0].Username, "hammy")
+	}
+	assert.Equal(t, requests, 1)
+
+	_, err := client.RefreshAutocomplete("ha")
+	assert.Nil(t, err)
+	assert.Equal(t, requests, 2)
+}
@@ -0,0 +1,153 @@
+package love
+
+import "context"
+import "net/http"
+import "sync"
+
+/*
+A ResponseCache holds the responses to GetLove and Autocomplete requests along
+with their validators, the ETag and Last-Modified headers. When a request is
+repeated, the client sends the validators in If-None-Match and
+If-Modified-Since, and if the server answers 304 Not Modified, the cached
+response is used instead of downloading it again. This saves bandwidth for
+dashboards and watchers which poll the same queries. Set it as the
+ResponseCache of a Client to use it. It is safe for concurrent use.
+
+Unlike the AutocompleteCache, a ResponseCache never returns results without
+asking the server, so they are never stale. Responses without validators are
+not cached. Once MaxEntries responses are cached, the oldest is forgotten to
+make room for a new one; if MaxEntries is zero, there is no limit.
+*/
+type ResponseCache struct {
+	MaxEntries int
+
+	mutex   sync.Mutex
+	entries map[string]*cachedResponse
+	order   []string
+	hits    int64
+}
+
+type cachedResponse struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+/*
+Create an empty cache which holds up to maxEntries responses.
+*/
+func NewResponseCache(maxEntries int) *ResponseCache {
+	return &ResponseCache{MaxEntries: maxEntries}
+}
+
+/*
+Return the number of requests answered from the cache.
+*/
+func (c *ResponseCache) Hits() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.hits
+}
+
+/*
+Remove every response.
+*/
+func (c *ResponseCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = nil
+	c.order = nil
+}
+
+func (c *ResponseCache) get(key string) *cachedResponse {
+	if c == nil {
+		return nil
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.entries[key]
+}
+
+func (c *ResponseCache) hit() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.hits++
+}
+
+/*
+Store a response, if it has validators, or forget the cached one if it does
+not.
+*/
+func (c *ResponseCache) put(key string, header http.Header, body []byte) {
+	if c == nil {
+		return
+	}
+	entry := &cachedResponse{
+		etag:         header.Get("ETag"),
+		lastModified: header.Get("Last-Modified"),
+		body:         body,
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.entries[key]; ok {
+		for i, k := range c.order {
+			if k == key {
+				c.order = append(c.order[:i], c.order[i+1:]...)
+				break
+			}
+		}
+		delete(c.entries, key)
+	}
+	if entry.etag == "" && entry.lastModified == "" {
+		return
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]*cachedResponse)
+	}
+	if c.MaxEntries > 0 && len(c.order) >= c.MaxEntries {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.entries[key] = entry
+	c.order = append(c.order, key)
+}
+
+/*
+Make a GET request and return the body of a successful response. If the client
+has a ResponseCache, the request is conditional on the cached response, which
+is returned if the server says it has not been modified. Unsuccessful responses
+are returned as an *APIError for the endpoint.
+*/
+func (c *Client) getBody(ctx context.Context, r *apiRequest) ([]byte, error) {
+	key := r.path()
+	cached := c.ResponseCache.get(key)
+	if cached != nil {
+		conditional := *r
+		conditional.header = make(http.Header)
+		if cached.etag != "" {
+			conditional.header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			conditional.header.Set("If-Modified-Since", cached.lastModified)
+		}
+		r = &conditional
+	}
+	resp, err := c.do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		c.ResponseCache.hit()
+		return cached.body, nil
+	}
+	if err := checkStatus(r.endpoint, resp, loveGetStatusCode); err != nil {
+		return nil, err
+	}
+	body, err := c.readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	c.ResponseCache.put(key, resp.Header, body)
+	return body, nil
+}
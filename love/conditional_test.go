@@ -0,0 +1,104 @@
+package love
+
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+import "net/http/httptest"
+
+/*
+Start a server which answers with body and an ETag, or with 304 if the request
+has the same ETag in If-None-Match. The headers of each request are recorded.
+*/
+func newETagServer(body string) (*httptest.Server, *[]http.Header) {
+	var requests []http.Header
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Header)
+			etag := `"` + r.URL.Path + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			w.Write([]byte(body))
+		}))
+	return server, &requests
+}
+
+func TestResponseCacheGetLove(t *testing.T) {
+	server, requests := newETagServer(
+		`[{"sender": "hammy", "recipient": "darwin", "message": "thanks", "timestamp": "2017-01-02T03:04:05"}]`)
+	defer server.Close()
+
+	client := NewClient(testApiKey, server.URL+"/api")
+	client.ResponseCache = NewResponseCache(0)
+	first, err := client.GetLove("hammy", "", 1)
+	assert.Nil(t, err)
+	second, err := client.GetLove("hammy", "", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, first, second)
+	assert.Len(t, second, 1)
+	assert.Equal(t, (*requests)[0].Get("If-None-Match"), "")
+	assert.Equal(t, (*requests)[1].Get("If-None-Match"), `"/api/love"`)
+	assert.Equal(t, client.ResponseCache.Hits(), int64(1))
+
+	// A different query is not conditional.
+	_, err = client.GetLove("darwin", "", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, (*requests)[2].Get("If-None-Match"), "")
+}
+
+func TestResponseCacheAutocomplete(t *testing.T) {
+	server, requests := newETagServer(`[{"label": "Darwin Dog (darwin)", "value": "darwin"}]`)
+	defer server.Close()
+
+	client := NewClient(testApiKey, server.URL+"/api")
+	client.ResponseCache = NewResponseCache(0)
+	for i := 0; i < 2; i++ {
+		users, err := client.Autocomplete("dar")
+		assert.Nil(t, err)
+		assert.Equal(t, users, []User{{"Darwin Dog (darwin)", "darwin"}})
+	}
+	assert.Equal(t, (*requests)[1].Get("If-None-Match"), `"/api/autocomplete"`)
+	assert.Equal(t, client.ResponseCache.Hits(), int64(1))
+}
+
+func TestResponseCacheLastModified(t *testing.T) {
+	const modified = "Mon, 02 Jan 2017 03:04:05 GMT"
+	var since []string
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			since = append(since, r.Header.Get("If-Modified-Since"))
+			w.Header().Set("Last-Modified", modified)
+			w.Write([]byte("[]"))
+		}))
+	defer server.Close()
+
+	client := NewClient(testApiKey, server.URL+"/api")
+	client.ResponseCache = NewResponseCache(0)
+	for i := 0; i < 2; i++ {
+		_, err := client.GetLove("hammy", "", 1)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, since, []string{"", modified})
+	assert.Equal(t, client.ResponseCache.Hits(), int64(0))
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	cache := NewResponseCache(2)
+	header := http.Header{"Etag": {`"1"`}}
+	cache.put("/a", header, []byte("a"))
+	cache.put("/b", header, []byte("b"))
+	cache.put("/a", header, []byte("a2"))
+	cache.put("/c", header, []byte("c"))
+	assert.Nil(t, cache.get("/b"))
+	assert.Equal(t, cache.get("/a").body, []byte("a2"))
+	assert.Equal(t, cache.get("/c").body, []byte("c"))
+
+	// A response without validators replaces the cached one.
+	cache.put("/a", http.Header{}, []byte("a3"))
+	assert.Nil(t, cache.get("/a"))
+
+	cache.Clear()
+	assert.Nil(t, cache.get("/c"))
+}
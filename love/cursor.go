@@ -0,0 +1,73 @@
+package love
+
+import "crypto/sha256"
+import "encoding/hex"
+import "time"
+
+/*
+A Cursor marks how far a reader, such as a watch or a sync of the local
+history, has got through the love matching a filter, so that it can carry on
+later without missing or repeating any love, even after a restart. Newest is
+the timestamp of the newest love seen. Since several love may share a
+timestamp, and more may arrive with that timestamp after the cursor was saved,
+Seen holds the Hash of each love seen with the Newest timestamp. A zero Cursor
+has seen no love. A Cursor may be saved as JSON.
+*/
+type Cursor struct {
+	Newest time.Time `json:"newest"`
+	Seen   []string  `json:"seen,omitempty"`
+}
+
+/*
+Report whether the cursor has seen no love.
+*/
+func (c Cursor) IsZero() bool {
+	return c.Newest.IsZero() && len(c.Seen) == 0
+}
+
+/*
+Report whether the cursor is past a love: the love is older than the newest
+one seen, or is one of those seen with the same timestamp.
+*/
+func (c Cursor) Covers(l Love) bool {
+	if l.Timestamp.Before(c.Newest) {
+		return true
+	}
+	if !l.Timestamp.Equal(c.Newest) {
+		return false
+	}
+	hash := l.Hash()
+	for _, seen := range c.Seen {
+		if seen == hash {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Move the cursor past a love, and report whether it was not already. Love must
+be added oldest first; love older than the newest one seen is ignored, as it is
+already covered.
+*/
+func (c *Cursor) Add(l Love) bool {
+	if c.Covers(l) {
+		return false
+	}
+	if l.Timestamp.After(c.Newest) {
+		c.Newest = l.Timestamp
+		c.Seen = nil
+	}
+	c.Seen = append(c.Seen, l.Hash())
+	return true
+}
+
+/*
+Return a hash of the sender, recipient and message of the love, which tells
+apart love sent at the same time. Love which differs only in its timestamp has
+the same hash.
+*/
+func (l Love) Hash() string {
+	sum := sha256.Sum256([]byte(l.Sender + "\x00" + l.Recipient + "\x00" + l.Message))
+	return hex.EncodeToString(sum[:8])
+}
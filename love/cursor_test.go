@@ -0,0 +1,54 @@
+package love
+
+import "encoding/json"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "time"
+
+func cursorLove(message string, minute int) Love {
+	return Love{
+		Sender:    "hammy",
+		Recipient: "darwin",
+		Message:   message,
+		Timestamp: time.Date(2000, 1, 1, 0, minute, 0, 0, time.UTC),
+	}
+}
+
+func TestCursor(t *testing.T) {
+	var cursor Cursor
+	assert.True(t, cursor.IsZero())
+	first := cursorLove("first", 1)
+	assert.False(t, cursor.Covers(first))
+	assert.True(t, cursor.Add(first))
+	assert.False(t, cursor.Add(first))
+	assert.False(t, cursor.IsZero())
+
+	// Love sent at the same time is told apart by its message.
+	same := cursorLove("same time", 1)
+	assert.False(t, cursor.Covers(same))
+	assert.True(t, cursor.Add(same))
+	assert.Equal(t, len(cursor.Seen), 2)
+
+	assert.True(t, cursor.Add(cursorLove("later", 2)))
+	assert.Equal(t, len(cursor.Seen), 1)
+	assert.True(t, cursor.Covers(first))
+	assert.True(t, cursor.Covers(cursorLove("earlier, but never seen", 0)))
+	assert.False(t, cursor.Add(same))
+
+	data, err := json.Marshal(cursor)
+	assert.Nil(t, err)
+	var saved Cursor
+	assert.Nil(t, json.Unmarshal(data, &saved))
+	assert.True(t, saved.Covers(cursorLove("later", 2)))
+	assert.False(t, saved.Covers(cursorLove("also later", 2)))
+}
+
+func TestHash(t *testing.T) {
+	l := cursorLove("thanks", 1)
+	assert.Equal(t, len(l.Hash()), 16)
+	assert.Equal(t, l.Hash(), cursorLove("thanks", 2).Hash())
+	assert.NotEqual(t, l.Hash(), cursorLove("thanks!", 1).Hash())
+	other := l
+	other.Recipient = "jeremy"
+	assert.NotEqual(t, l.Hash(), other.Hash())
+}
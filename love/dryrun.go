@@ -0,0 +1,41 @@
+package love
+
+import "context"
+import "fmt"
+import "io/ioutil"
+import "sort"
+
+/*
+Describe a form POST to c.DryRun, in the form of an HTTP request, with any
+credentials added by the client's Authenticator redacted:
+
+	POST https://cwrulove.appspot.com/api/love
+	Content-Type: application/x-www-form-urlencoded
+
+	api_key=REDACTED&message=thanks&recipient=darwin&sender=hammy
+*/
+func (c *Client) writeDryRun(r *apiRequest) error {
+	req, err := c.newRequest(context.Background(), c.BaseUrl, r)
+	if err != nil {
+		return err
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if _, err := fmt.Fprintf(c.DryRun, "%s %s\n", r.method, c.Redact(req.URL.String())); err != nil {
+		return err
+	}
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			fmt.Fprintf(c.DryRun, "%s: %s\n", name, c.Redact(value))
+		}
+	}
+	_, err = fmt.Fprintf(c.DryRun, "\n%s\n", c.Redact(string(body)))
+	return err
+}
@@ -0,0 +1,23 @@
+package love
+
+import "bytes"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+
+func TestSendLoveDryRun(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var out bytes.Buffer
+	client := getTestClient()
+	client.DryRun = &out
+
+	err := client.SendLoves("hammy", []string{"darwin", "jeremy"}, "thanks & more")
+	assert.Nil(t, err)
+	assert.Equal(t, out.String(), "POST "+testLoveUrl+"\n"+
+		"Content-Type: application/x-www-form-urlencoded\n"+
+		"User-Agent: "+DefaultUserAgent+"\n\n"+
+		"api_key=REDACTED&message=thanks+%26+more&recipient=darwin%2Cjeremy&sender=hammy\n")
+	assert.NotContains(t, out.String(), testApiKey)
+}
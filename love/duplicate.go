@@ -0,0 +1,103 @@
+package love
+
+import "context"
+import "errors"
+import "fmt"
+import "strings"
+import "time"
+
+/*
+ErrDuplicateLove matches a *DuplicateLoveError with errors.Is.
+*/
+var ErrDuplicateLove = errors.New("love: duplicate love")
+
+/*
+DuplicateLoveError is returned when the same love was already sent from the
+same sender to one or more of the recipients within the client's
+DuplicateWindow. Duplicates holds the earlier love, one for each such
+recipient. No love is sent.
+*/
+type DuplicateLoveError struct {
+	Duplicates []Love
+	Window     time.Duration
+}
+
+func (e *DuplicateLoveError) Error() string {
+	var recipients []string
+	for _, l := range e.Duplicates {
+		recipients = append(recipients, l.Recipient)
+	}
+	return fmt.Sprintf("love: %s already sent the same love to %s in the last %s",
+		e.Duplicates[0].Sender, strings.Join(recipients, ", "), e.Window)
+}
+
+func (e *DuplicateLoveError) Is(target error) bool {
+	return target == ErrDuplicateLove
+}
+
+/*
+A LoveQuerier returns the love matching a filter, such as a *store.Store
+holding the local history.
+*/
+type LoveQuerier interface {
+	Query(f LoveFilter) ([]Love, error)
+}
+
+/*
+The number of the sender's most recent love which are fetched from the API to
+look for duplicates, in a single request.
+*/
+const duplicateCheckLimit = 100
+
+/*
+Check that love from a sender to the recipients with a message was not already
+sent within the client's DuplicateWindow, by looking it up in the client's
+DuplicateHistory, or in the sender's most recent love in the API, requested with
+ctx.
+*/
+func (c *Client) checkDuplicate(ctx context.Context, from string, recipients []string,
+	message string) error {
+	if c.DuplicateWindow <= 0 {
+		return nil
+	}
+	f := LoveFilter{Sender: from, Since: time.Now().Add(-c.DuplicateWindow)}
+	var sent []Love
+	var err error
+	if c.DuplicateHistory != nil {
+		sent, err = c.DuplicateHistory.Query(f)
+	} else {
+		sent, err = c.GetLoveContext(ctx, from, "", duplicateCheckLimit)
+	}
+	if err != nil {
+		return err
+	}
+	duplicates := sameLove(sent, f, recipients, message)
+	if len(duplicates) == 0 {
+		return nil
+	}
+	dupErr := &DuplicateLoveError{Duplicates: duplicates, Window: c.DuplicateWindow}
+	if c.OnDuplicate != nil {
+		return c.OnDuplicate(dupErr)
+	}
+	return dupErr
+}
+
+/*
+Return the love among sent which matches f, and has the same message as
+message, to any of the recipients, with only the first love to each recipient.
+*/
+func sameLove(sent []Love, f LoveFilter, recipients []string, message string) []Love {
+	wanted := make(map[string]bool, len(recipients))
+	for _, r := range recipients {
+		wanted[r] = true
+	}
+	message = strings.TrimSpace(message)
+	var same []Love
+	for _, l := range sent {
+		if f.Match(l) && wanted[l.Recipient] && strings.TrimSpace(l.Message) == message {
+			wanted[l.Recipient] = false
+			same = append(same, l)
+		}
+	}
+	return same
+}
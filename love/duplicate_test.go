@@ -0,0 +1,101 @@
+package love
+
+import "context"
+import "errors"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "io/ioutil"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+import "time"
+
+type sliceQuerier []Love
+
+func (s sliceQuerier) Query(f LoveFilter) ([]Love, error) {
+	var loves []Love
+	for _, l := range s {
+		if f.Match(l) {
+			loves = append(loves, l)
+		}
+	}
+	return loves, nil
+}
+
+func TestDuplicateAPI(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	recent := time.Now().UTC().Add(-time.Minute).Format("2006-01-02T15:04:05")
+	httpmock.RegisterResponder("GET", testLoveUrl, newGetValidateResponder(t, 200,
+		`[{"timestamp": "`+recent+`", "message": "thanks! ", "sender": "hammy", "recipient": "darwin"},
+{"timestamp": "2000-01-01T01:01:01", "message": "old", "sender": "hammy", "recipient": "jeremy"}]`,
+		map[string]string{"api_key": testApiKey, "sender": "hammy", "limit": "100"}))
+	sent := 0
+	httpmock.RegisterResponder("POST", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			sent++
+			return httpmock.NewStringResponse(loveCreatedStatusCode, "Love sent!"), nil
+		})
+
+	client := getTestClient()
+	client.DuplicateWindow = 10 * time.Minute
+	err := client.SendLove("hammy", "jeremy,darwin", "thanks!")
+	assert.True(t, errors.Is(err, ErrDuplicateLove))
+	assert.EqualError(t, err, "love: hammy already sent the same love to darwin in the last 10m0s")
+	assert.Equal(t, sent, 0)
+
+	assert.Nil(t, client.SendLove("hammy", "darwin", "thanks again!"))
+	assert.Nil(t, client.SendLove("hammy", "jeremy", "old"))
+	assert.Equal(t, sent, 2)
+
+	var warned *DuplicateLoveError
+	client.OnDuplicate = func(err *DuplicateLoveError) error {
+		warned = err
+		return nil
+	}
+	assert.Nil(t, client.SendLove("hammy", "darwin", "thanks!"))
+	assert.Equal(t, warned.Duplicates[0].Recipient, "darwin")
+	assert.Equal(t, sent, 3)
+}
+
+type duplicateKey struct{}
+
+func TestDuplicateContext(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", testLoveUrl, httpmock.NewStringResponder(200, "[]"))
+	httpmock.RegisterResponder("POST", testLoveUrl,
+		httpmock.NewStringResponder(loveCreatedStatusCode, "Love sent!"))
+
+	client := getTestClient()
+	client.DuplicateWindow = 10 * time.Minute
+	var lookups []interface{}
+	client.Middleware = []Middleware{func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Method == "GET" {
+				lookups = append(lookups, req.Context().Value(duplicateKey{}))
+			}
+			return next.RoundTrip(req)
+		})
+	}}
+	ctx := context.WithValue(context.Background(), duplicateKey{}, "traced")
+	assert.Nil(t, client.SendLoveContext(ctx, "hammy", "darwin", "thanks!"))
+	assert.Equal(t, lookups, []interface{}{"traced"})
+}
+
+func TestDuplicateHistory(t *testing.T) {
+	client := getTestClient()
+	client.DryRun = ioutil.Discard
+	client.DuplicateWindow = time.Hour
+	client.DuplicateHistory = sliceQuerier{
+		{Sender: "hammy", Recipient: "darwin", Message: "thanks", Timestamp: time.Now()},
+		{Sender: "hammy", Recipient: "jeremy", Message: "thanks", Timestamp: time.Now()},
+	}
+	var dupErr *DuplicateLoveError
+	err := client.SendLove("hammy", "darwin,jeremy", "thanks")
+	assert.True(t, errors.As(err, &dupErr))
+	assert.Equal(t, len(dupErr.Duplicates), 2)
+	assert.Nil(t, client.SendLove("darwin", "jeremy", "thanks"))
+
+	client.DuplicateWindow = 0
+	assert.Nil(t, client.SendLove("hammy", "darwin", "thanks"))
+}
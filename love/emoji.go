@@ -0,0 +1,79 @@
+package love
+
+import "regexp"
+
+/*
+The emoji for the shortcodes most used in love, as written in Slack and GitHub.
+*/
+var emojiShortcodes = map[string]string{
+	"+1":                    "👍",
+	"100":                   "💯",
+	"balloon":               "🎈",
+	"beers":                 "🍻",
+	"blue_heart":            "💙",
+	"blush":                 "😊",
+	"bulb":                  "💡",
+	"cake":                  "🍰",
+	"clap":                  "👏",
+	"coffee":                "☕",
+	"confetti_ball":         "🎊",
+	"fire":                  "🔥",
+	"gift":                  "🎁",
+	"green_heart":           "💚",
+	"grin":                  "😁",
+	"handshake":             "🤝",
+	"heart":                 "❤️",
+	"heart_eyes":            "😍",
+	"hugs":                  "🤗",
+	"joy":                   "😂",
+	"medal":                 "🏅",
+	"muscle":                "💪",
+	"ok_hand":               "👌",
+	"partying_face":         "🥳",
+	"pray":                  "🙏",
+	"purple_heart":          "💜",
+	"raised_hands":          "🙌",
+	"rocket":                "🚀",
+	"slightly_smiling_face": "🙂",
+	"smile":                 "😄",
+	"smiley":                "😃",
+	"sparkles":              "✨",
+	"sparkling_heart":       "💖",
+	"star":                  "⭐",
+	"sunglasses":            "😎",
+	"tada":                  "🎉",
+	"thumbsup":              "👍",
+	"trophy":                "🏆",
+	"two_hearts":            "💕",
+	"wave":                  "👋",
+	"white_check_mark":      "✅",
+	"yellow_heart":          "💛",
+}
+
+var shortcodePattern = regexp.MustCompile(`:([a-z0-9_+-]+):`)
+
+// A <3 which is not part of a larger word or number, such as <30.
+var heartPattern = regexp.MustCompile(`(^|\s)<3+\b`)
+
+/*
+ExpandShortcodes replaces the emoji shortcodes in a message, such as :tada:,
+with the emoji they stand for. Unknown shortcodes are left alone. Messages are
+expanded before they are sent, unless the client's MessageOptions say not to.
+*/
+func ExpandShortcodes(message string) string {
+	return shortcodePattern.ReplaceAllStringFunc(message, func(code string) string {
+		if emoji, ok := emojiShortcodes[code[1:len(code)-1]]; ok {
+			return emoji
+		}
+		return code
+	})
+}
+
+/*
+RenderEmoji replaces the emoji shortcodes in a message, as ExpandShortcodes
+does, and <3 with a heart, for display on a terminal or other place which does
+not render them itself.
+*/
+func RenderEmoji(message string) string {
+	return heartPattern.ReplaceAllString(ExpandShortcodes(message), "${1}❤️")
+}
@@ -0,0 +1,24 @@
+package love
+
+import "testing"
+import "github.com/stretchr/testify/assert"
+
+func TestRenderEmoji(t *testing.T) {
+	for message, expected := range map[string]string{
+		"thanks :tada:":                "thanks 🎉",
+		":+1::100: great work":         "👍💯 great work",
+		"see you at 10:30:00":          "see you at 10:30:00",
+		"an :unknown: code":            "an :unknown: code",
+		"<3 you all":                   "❤️ you all",
+		"thanks <333":                  "thanks ❤️",
+		"less than <30 minutes":        "less than <30 minutes",
+		"a<3b":                         "a<3b",
+		"ship it :rocket: :sparkles:!": "ship it 🚀 ✨!",
+	} {
+		assert.Equal(t, RenderEmoji(message), expected)
+	}
+}
+
+func TestExpandShortcodes(t *testing.T) {
+	assert.Equal(t, ExpandShortcodes("thanks :heart: <3 :nope:"), "thanks ❤️ <3 :nope:")
+}
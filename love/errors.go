@@ -0,0 +1,117 @@
+package love
+
+import "errors"
+import "fmt"
+import "io"
+import "io/ioutil"
+import "net"
+import "net/http"
+import "strings"
+
+/*
+Sentinel errors describing the common failure modes of the Yelp Love API. An
+*APIError matches one of these with errors.Is according to its status code, so
+callers may branch on the kind of failure without inspecting the body:
+
+	if errors.Is(err, love.ErrBadParams) {
+		// fix the request
+	}
+*/
+var (
+	// The server rejected the request parameters (422).
+	ErrBadParams = errors.New("love: bad request parameters")
+	// The server was unable to create the love (418).
+	ErrLoveFailed = errors.New("love: failed to send love")
+	// The API key was missing or invalid (401 or 403).
+	ErrUnauthorized = errors.New("love: unauthorized")
+)
+
+/*
+ErrResponseTooLarge is returned when the body of a response is larger than the
+client's MaxResponseBytes.
+*/
+var ErrResponseTooLarge = errors.New("love: response too large")
+
+// The most of an error response kept in an APIError's Body.
+const maxErrorBodyBytes = 64 * 1024
+
+/*
+APIError is returned whenever the Yelp Love API responds with an unexpected
+status code. Endpoint is the path of the API endpoint that was requested (eg
+"/love"), StatusCode is the HTTP status code, and Body holds the text of the
+response, which usually contains a message from the server.
+*/
+type APIError struct {
+	Endpoint   string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	status := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	body := strings.TrimSpace(e.Body)
+	if body == "" {
+		return fmt.Sprintf("Love API Error: %s: %s", e.Endpoint, status)
+	}
+	return fmt.Sprintf("Love API Error: %s: %s: %s", e.Endpoint, status, body)
+}
+
+/*
+Is reports whether the APIError corresponds to one of the sentinel errors, based
+on its status code.
+*/
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadParams:
+		return e.StatusCode == loveBadParamsStatusCode
+	case ErrLoveFailed:
+		return e.StatusCode == loveFailedStatusCode
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized ||
+			e.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+/*
+Temporary reports whether the request may succeed if it is repeated later: the
+server failed (5xx) or asked the client to slow down (429).
+*/
+func (e *APIError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+/*
+IsTemporary reports whether an error returned by the Client is likely to go
+away if the request is repeated later, because the server could not be reached,
+an *APIError is Temporary, or the circuit breaker is open. Errors caused by the
+request itself, such as bad parameters or an invalid API key, are not temporary.
+*/
+func IsTemporary(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+/*
+Build an *APIError from an unsuccessful response. The response body is consumed
+and closed. Only the start of a long body is kept.
+*/
+func newAPIError(endpoint string, resp *http.Response) error {
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	if err != nil {
+		return err
+	}
+	return &APIError{
+		Endpoint:   endpoint,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}
+}
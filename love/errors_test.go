@@ -0,0 +1,116 @@
+package love
+
+import "errors"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net"
+import "net/http"
+import "net/url"
+import "strings"
+
+func TestGetLoveAPIError(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		httpmock.NewStringResponder(loveBadParamsStatusCode, "bad limit"),
+	)
+
+	_, err := client.GetLove("hammy", "", 0)
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, apiErr.StatusCode, loveBadParamsStatusCode)
+	assert.Equal(t, apiErr.Body, "bad limit")
+	assert.Equal(t, apiErr.Endpoint, "/love")
+	assert.True(t, errors.Is(err, ErrBadParams))
+	assert.False(t, errors.Is(err, ErrLoveFailed))
+}
+
+func TestSendLoveAPIError(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+
+	httpmock.RegisterResponder(
+		"POST", testLoveUrl,
+		httpmock.NewStringResponder(loveFailedStatusCode, "no such user"),
+	)
+
+	err := client.SendLove("hammy", "nobody", "message")
+	assert.True(t, errors.Is(err, ErrLoveFailed))
+	assert.Contains(t, err.Error(), "no such user")
+}
+
+func TestAutocompleteUnauthorized(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+
+	httpmock.RegisterResponder(
+		"GET", testAutocompleteUrl,
+		httpmock.NewStringResponder(http.StatusUnauthorized, ""),
+	)
+
+	_, err := client.Autocomplete("ha")
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, apiErr.Endpoint, "/autocomplete")
+}
+
+func TestIsTemporary(t *testing.T) {
+	assert.True(t, IsTemporary(&APIError{"/love", 503, ""}))
+	assert.True(t, IsTemporary(&APIError{"/love", 429, ""}))
+	assert.False(t, IsTemporary(&APIError{"/love", loveBadParamsStatusCode, ""}))
+	assert.False(t, IsTemporary(&APIError{"/love", 401, ""}))
+
+	netErr := &url.Error{
+		Op:  "Post",
+		URL: testLoveUrl,
+		Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+	}
+	assert.True(t, IsTemporary(netErr))
+	assert.False(t, IsTemporary(errors.New("love: sender or recipient required")))
+}
+
+func TestResponseTooLarge(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	client.MaxResponseBytes = 10
+	httpmock.RegisterResponder("GET", testLoveUrl,
+		httpmock.NewStringResponder(200, "[          ]"))
+	httpmock.RegisterResponder("GET", testAutocompleteUrl,
+		httpmock.NewStringResponder(200, "[]"))
+
+	_, err := client.GetLove("hammy", "", 1)
+	assert.True(t, errors.Is(err, ErrResponseTooLarge))
+	users, err := client.Autocomplete("dar")
+	assert.Nil(t, err)
+	assert.Equal(t, len(users), 0)
+
+	client.MaxResponseBytes = -1
+	_, err = client.GetLove("hammy", "", 1)
+	assert.Nil(t, err)
+}
+
+func TestAPIErrorBodyTruncated(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	httpmock.RegisterResponder("GET", testLoveUrl,
+		httpmock.NewStringResponder(500, strings.Repeat("x", 2*maxErrorBodyBytes)))
+
+	_, err := client.GetLove("hammy", "", 1)
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, len(apiErr.Body), maxErrorBodyBytes)
+}
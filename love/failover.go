@@ -0,0 +1,141 @@
+package love
+
+import "context"
+import "errors"
+import "net"
+import "net/http"
+import "sort"
+import "sync"
+import "time"
+
+/*
+How long a base URL which failed is avoided, in favor of the other URLs.
+*/
+const FailoverCooldown = 30 * time.Second
+
+/*
+The health of the client's base URLs: when each URL which recently failed may
+be tried again.
+*/
+type failoverState struct {
+	mutex          sync.Mutex
+	unhealthyUntil map[string]time.Time
+}
+
+/*
+Return the base URLs to try, in order: those which have not failed recently,
+starting with BaseUrl and then the FallbackUrls in order, followed by those
+which have, soonest to recover first.
+*/
+func (c *Client) baseUrls() []string {
+	if len(c.FallbackUrls) == 0 {
+		return []string{c.BaseUrl}
+	}
+	all := append([]string{c.BaseUrl}, c.FallbackUrls...)
+	c.failover.mutex.Lock()
+	defer c.failover.mutex.Unlock()
+	now := time.Now()
+	var healthy, unhealthy []string
+	for _, base := range all {
+		if until, ok := c.failover.unhealthyUntil[base]; ok && now.Before(until) {
+			unhealthy = append(unhealthy, base)
+		} else {
+			healthy = append(healthy, base)
+		}
+	}
+	sort.SliceStable(unhealthy, func(i, j int) bool {
+		return c.failover.unhealthyUntil[unhealthy[i]].Before(
+			c.failover.unhealthyUntil[unhealthy[j]])
+	})
+	return append(healthy, unhealthy...)
+}
+
+/*
+Record whether a request to a base URL succeeded.
+*/
+func (c *Client) recordHealth(base string, healthy bool) {
+	if len(c.FallbackUrls) == 0 {
+		return
+	}
+	c.failover.mutex.Lock()
+	defer c.failover.mutex.Unlock()
+	if healthy {
+		delete(c.failover.unhealthyUntil, base)
+		return
+	}
+	if c.failover.unhealthyUntil == nil {
+		c.failover.unhealthyUntil = make(map[string]time.Time)
+	}
+	c.failover.unhealthyUntil[base] = time.Now().Add(FailoverCooldown)
+}
+
+/*
+Report whether a request should be tried again at another base URL. Requests
+which only read are retried when the server cannot be reached, or responds that
+it is unavailable. Others, which may have had an effect, are only retried when
+no connection could be made, so that love is never sent twice.
+*/
+func shouldFailover(ctx context.Context, method string, resp *http.Response,
+	err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		var opErr *net.OpError
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) || errors.As(err, &opErr) && opErr.Op == "dial" {
+			return true
+		}
+		return method == "GET"
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return method == "GET"
+	}
+	return false
+}
+
+/*
+Make a request. If the client has FallbackUrls, the request is made to the
+healthiest base URL, and if that fails, it is tried at the next, as described
+by shouldFailover. If the client has a Breaker, the request fails with
+ErrCircuitOpen while it is open.
+*/
+func (c *Client) do(ctx context.Context, r *apiRequest) (*http.Response, error) {
+	if c.Breaker == nil {
+		return c.tryBases(ctx, r)
+	}
+	from, to, err := c.Breaker.allow()
+	c.breakerChanged(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.tryBases(ctx, r)
+	from, to = c.Breaker.done(breakerResult(ctx, resp, err))
+	c.breakerChanged(ctx, from, to)
+	return resp, err
+}
+
+func (c *Client) tryBases(ctx context.Context, r *apiRequest) (*http.Response, error) {
+	bases := c.baseUrls()
+	for i, base := range bases {
+		req, err := c.newRequest(ctx, base, r)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.httpClient().Do(req)
+		failed := shouldFailover(ctx, r.method, resp, err)
+		if !failed || i == len(bases)-1 {
+			if ctx.Err() == nil {
+				c.recordHealth(base, !failed)
+			}
+			return resp, c.redactError(err)
+		}
+		c.recordHealth(base, false)
+		addRetry(ctx)
+		if resp != nil {
+			resp.Body.Close()
+		}
+	}
+	panic("unreachable")
+}
@@ -0,0 +1,83 @@
+package love
+
+import "errors"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+import "net/http/httptest"
+import "sync/atomic"
+
+/*
+Start a server which counts its requests, and responds to every request with
+status.
+*/
+func newCountingServer(status int, body string) (*httptest.Server, *int32) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&count, 1)
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+	return server, &count
+}
+
+func TestFailoverUnreachable(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	up, requests := newCountingServer(201, "Love sent!")
+	defer up.Close()
+
+	client := NewClient(testApiKey, down.URL+"/api")
+	client.FallbackUrls = []string{up.URL + "/api"}
+	err := client.SendLove("hammy", "darwin", "thanks")
+	assert.Nil(t, err)
+	assert.Equal(t, atomic.LoadInt32(requests), int32(1))
+	assert.Equal(t, client.baseUrls(), []string{up.URL + "/api", down.URL + "/api"})
+}
+
+func TestFailoverUnavailable(t *testing.T) {
+	primary, primaryRequests := newCountingServer(503, "unavailable")
+	defer primary.Close()
+	fallback, fallbackRequests := newCountingServer(200, "[]")
+	defer fallback.Close()
+
+	client := NewClient(testApiKey, primary.URL+"/api")
+	client.FallbackUrls = []string{fallback.URL + "/api"}
+	_, err := client.GetLove("hammy", "", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, atomic.LoadInt32(primaryRequests), int32(1))
+	assert.Equal(t, atomic.LoadInt32(fallbackRequests), int32(1))
+
+	// The primary is avoided while it is unhealthy.
+	_, err = client.GetLove("hammy", "", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, atomic.LoadInt32(primaryRequests), int32(1))
+	assert.Equal(t, atomic.LoadInt32(fallbackRequests), int32(2))
+
+	// Love which may have been sent is not sent again.
+	client = NewClient(testApiKey, primary.URL+"/api")
+	client.FallbackUrls = []string{fallback.URL + "/api"}
+	err = client.SendLove("hammy", "darwin", "thanks")
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, apiErr.StatusCode, 503)
+	assert.Equal(t, atomic.LoadInt32(primaryRequests), int32(2))
+	assert.Equal(t, atomic.LoadInt32(fallbackRequests), int32(2))
+}
+
+func TestFailoverAllDown(t *testing.T) {
+	primary, primaryRequests := newCountingServer(503, "unavailable")
+	defer primary.Close()
+	fallback, fallbackRequests := newCountingServer(503, "unavailable")
+	defer fallback.Close()
+
+	client := NewClient(testApiKey, primary.URL+"/api")
+	client.FallbackUrls = []string{fallback.URL + "/api"}
+	_, err := client.GetLove("hammy", "", 1)
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, apiErr.StatusCode, 503)
+	assert.Equal(t, atomic.LoadInt32(primaryRequests), int32(1))
+	assert.Equal(t, atomic.LoadInt32(fallbackRequests), int32(1))
+}
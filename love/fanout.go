@@ -0,0 +1,144 @@
+package love
+
+import "sync"
+
+/*
+The number of concurrent requests made by SendLoveEach when no concurrency is
+given.
+*/
+const DefaultConcurrency = 8
+
+/*
+Send a separate love from a user to each of the recipients, using at most
+concurrency simultaneous requests (DefaultConcurrency if concurrency <= 0).
+Unlike SendLoves, which makes a single request with a comma separated list of
+recipients, this creates one love record per recipient and reports the outcome
+of each.
+
+The returned map has an entry for every distinct recipient, which is nil if the
+love was sent successfully, and the error otherwise. Groups are expanded, so
+the entries are for their members; if they cannot be expanded, every recipient
+given has the error. If there are more distinct
+recipients than the client's MaxRecipients, no love is sent, and every entry
+holds a *TooManyRecipientsError.
+*/
+func (c *Client) SendLoveEach(from string, to []string, message string,
+	concurrency int) map[string]error {
+	recipients, err := c.expandGroups(to)
+	if err != nil {
+		results := make(map[string]error, len(to))
+		for _, recipient := range to {
+			results[recipient] = err
+		}
+		return results
+	}
+	return c.sendEach(from, recipients, func(string) string { return message }, concurrency)
+}
+
+/*
+Send a separate love from a user to each distinct recipient, with the message
+returned by message for that recipient, as described by SendLoveEach.
+*/
+func (c *Client) sendEach(from string, to []string, message func(string) string,
+	concurrency int) map[string]error {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	results := make(map[string]error, len(to))
+	if err := c.checkRecipientCount(to); err != nil {
+		for _, recipient := range to {
+			results[recipient] = err
+		}
+		return results
+	}
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	recipients := make(chan string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for recipient := range recipients {
+				err := c.SendLove(from, recipient, message(recipient))
+				mutex.Lock()
+				results[recipient] = err
+				mutex.Unlock()
+			}
+		}()
+	}
+	seen := make(map[string]bool, len(to))
+	for _, recipient := range to {
+		if seen[recipient] {
+			continue
+		}
+		seen[recipient] = true
+		recipients <- recipient
+	}
+	close(recipients)
+	wg.Wait()
+	return results
+}
+
+/*
+Retrieve the love sent and received by each of the users which matches the rest
+of a filter, whose Sender and Recipient are ignored. The users are fetched with
+at most concurrency simultaneous requests (DefaultConcurrency if concurrency <=
+0), as GetLoveFiltered would, so the filter's Limit applies to the love sent
+and received by each user separately.
+
+The love is merged, with love between two of the users appearing once, and
+sorted newest first. If fetching the love of some users fails, the love of the
+others is still returned, along with a map from each user who failed to the
+error. The map is nil if every user succeeded.
+*/
+func (c *Client) GetLoveForUsers(users []string, f LoveFilter,
+	concurrency int) ([]Love, map[string]error) {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	var errs map[string]error
+	var found [][]Love
+	queue := make(chan string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for user := range queue {
+				sent, received := f, f
+				sent.Sender, sent.Recipient = user, ""
+				received.Sender, received.Recipient = "", user
+				var loves [][]Love
+				var err error
+				for _, g := range []LoveFilter{sent, received} {
+					var page []Love
+					if page, err = c.GetLoveFiltered(g); err != nil {
+						break
+					}
+					loves = append(loves, page)
+				}
+				mutex.Lock()
+				if err != nil {
+					if errs == nil {
+						errs = make(map[string]error)
+					}
+					errs[user] = err
+				}
+				found = append(found, loves...)
+				mutex.Unlock()
+			}
+		}()
+	}
+	queued := make(map[string]bool, len(users))
+	for _, user := range users {
+		if queued[user] {
+			continue
+		}
+		queued[user] = true
+		queue <- user
+	}
+	close(queue)
+	wg.Wait()
+	return Merge(found...), errs
+}
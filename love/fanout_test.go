@@ -0,0 +1,75 @@
+package love
+
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "io/ioutil"
+import "net/http"
+import "net/http/httptest"
+import "net/url"
+import "sync"
+
+func TestSendLoveEach(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	var mutex sync.Mutex
+	received := make(map[string]int)
+
+	httpmock.RegisterResponder(
+		"POST", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			values, _ := url.ParseQuery(string(body))
+			recipient := values.Get("recipient")
+			mutex.Lock()
+			received[recipient]++
+			mutex.Unlock()
+			if recipient == "nobody" {
+				return httpmock.NewStringResponse(418, "no such user"), nil
+			}
+			return httpmock.NewStringResponse(201, "Love sent!"), nil
+		},
+	)
+
+	recipients := []string{"darwin", "jeremy", "nobody", "darwin"}
+	results := client.SendLoveEach("hammy", recipients, "message", 2)
+	assert.Equal(t, len(results), 3)
+	assert.Nil(t, results["darwin"])
+	assert.Nil(t, results["jeremy"])
+	assert.NotNil(t, results["nobody"])
+	assert.Equal(t, received["darwin"], 1)
+	assert.Equal(t, received["jeremy"], 1)
+	assert.Equal(t, received["nobody"], 1)
+}
+
+func TestGetLoveForUsers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("sender") + ">" + r.URL.Query().Get("recipient") {
+			case "hammy>":
+				w.Write([]byte(`[{"sender": "hammy", "recipient": "darwin", "message": "a", "timestamp": "2017-01-02T00:00:00"}]`))
+			case ">hammy":
+				w.Write([]byte(`[{"sender": "jeremy", "recipient": "hammy", "message": "b", "timestamp": "2017-01-03T00:00:00"}]`))
+			case ">darwin":
+				w.Write([]byte(`[{"sender": "hammy", "recipient": "darwin", "message": "a", "timestamp": "2017-01-02T00:00:00"},
+					{"sender": "jeremy", "recipient": "darwin", "message": "c", "timestamp": "2017-01-01T00:00:00"}]`))
+			case "broken>", ">broken":
+				w.WriteHeader(500)
+			default:
+				w.Write([]byte("[]"))
+			}
+		}))
+	defer server.Close()
+
+	client := NewClient(testApiKey, server.URL+"/api")
+	loves, errs := client.GetLoveForUsers([]string{"hammy", "darwin", "hammy"}, LoveFilter{}, 2)
+	assert.Nil(t, errs)
+	assert.Equal(t, messages(loves), []string{"b", "a", "c"})
+
+	loves, errs = client.GetLoveForUsers([]string{"hammy", "broken"}, LoveFilter{}, 0)
+	assert.Len(t, loves, 2)
+	assert.Len(t, errs, 1)
+	assert.True(t, IsTemporary(errs["broken"]))
+}
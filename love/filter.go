@@ -0,0 +1,122 @@
+package love
+
+import "context"
+import "net/url"
+import "strconv"
+import "strings"
+import "time"
+
+/*
+A LoveFilter describes which love to retrieve. At least one of Sender and
+Recipient must be set. Limit works as it does in GetLove.
+
+Since and Until restrict the love to a time range: love sent at or after Since,
+and strictly before Until. Keyword restricts the love to those whose message
+contains it, ignoring case, and Tag to those whose message is tagged with it,
+as in Love.HasHashtag. Value restricts the love to those tagged with a company
+value, as in Love.HasValue. Zero values mean no restriction.
+
+The time range, keyword and value are sent to the server as the since, until,
+keyword and value parameters, for instances which support them. Since stock
+Yelp Love does not, they are always applied on the client as well. Tag is only
+applied on the client.
+*/
+type LoveFilter struct {
+	Sender    string
+	Recipient string
+	Limit     int64
+	Since     time.Time
+	Until     time.Time
+	Keyword   string
+	Tag       string
+	Value     string
+}
+
+/*
+Report whether the filter has criteria which must be applied on the client.
+*/
+func (f LoveFilter) clientSide() bool {
+	return !f.Since.IsZero() || !f.Until.IsZero() || f.Keyword != "" || f.Tag != "" ||
+		f.Value != ""
+}
+
+/*
+Report whether a love satisfies every criteria of the filter.
+*/
+func (f LoveFilter) Match(l Love) bool {
+	if f.Sender != "" && l.Sender != f.Sender {
+		return false
+	}
+	if f.Recipient != "" && l.Recipient != f.Recipient {
+		return false
+	}
+	if !f.Since.IsZero() && l.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !l.Timestamp.Before(f.Until) {
+		return false
+	}
+	if f.Keyword != "" &&
+		!strings.Contains(strings.ToLower(l.Message), strings.ToLower(f.Keyword)) {
+		return false
+	}
+	if f.Tag != "" && !l.HasHashtag(f.Tag) {
+		return false
+	}
+	if f.Value != "" && !l.HasValue(f.Value) {
+		return false
+	}
+	return true
+}
+
+/*
+Add the query parameters corresponding to the filter. Times are sent in loc.
+*/
+func (f LoveFilter) encode(values url.Values, loc *time.Location) {
+	if f.Sender != "" {
+		values.Set("sender", f.Sender)
+	}
+	if f.Recipient != "" {
+		values.Set("recipient", f.Recipient)
+	}
+	if f.Limit > 0 {
+		values.Set("limit", strconv.FormatInt(f.Limit, 10))
+	}
+	if !f.Since.IsZero() {
+		values.Set("since", f.Since.In(loc).Format(timestampFormat))
+	}
+	if !f.Until.IsZero() {
+		values.Set("until", f.Until.In(loc).Format(timestampFormat))
+	}
+	if f.Keyword != "" {
+		values.Set("keyword", f.Keyword)
+	}
+	if f.Value != "" {
+		values.Set("value", f.Value)
+	}
+}
+
+/*
+Retrieve love matching a filter. When the filter only has a sender, recipient
+and limit, this makes a single request, exactly like GetLove. Otherwise, pages
+of love are fetched and filtered until Limit matching love have been found, or
+the history is exhausted. Setting a Limit is therefore even more important when
+filtering by time or keyword.
+*/
+func (c *Client) GetLoveFiltered(f LoveFilter) ([]Love, error) {
+	if !f.clientSide() {
+		return c.getLove(context.Background(), f, 0)
+	}
+	loves := []Love{}
+	it := c.IterLoveFiltered(f)
+	for it.Next() {
+		loves = append(loves, it.Love())
+		if f.Limit > 0 && int64(len(loves)) >= f.Limit {
+			break
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return loves, nil
+}
@@ -0,0 +1,98 @@
+package love
+
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "time"
+
+func TestLoveFilterMatch(t *testing.T) {
+	l := Love{
+		Sender:    "hammy",
+		Recipient: "darwin",
+		Message:   "Great job fixing the site! #oncall",
+		Timestamp: time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC),
+		Values:    []string{"Be Humble", "Ownership"},
+	}
+	day := 24 * time.Hour
+
+	assert.True(t, LoveFilter{Sender: "hammy"}.Match(l))
+	assert.False(t, LoveFilter{Sender: "darwin"}.Match(l))
+	assert.False(t, LoveFilter{Recipient: "hammy"}.Match(l))
+	assert.True(t, LoveFilter{Since: l.Timestamp}.Match(l))
+	assert.False(t, LoveFilter{Since: l.Timestamp.Add(day)}.Match(l))
+	assert.True(t, LoveFilter{Until: l.Timestamp.Add(day)}.Match(l))
+	assert.False(t, LoveFilter{Until: l.Timestamp}.Match(l))
+	assert.True(t, LoveFilter{Keyword: "FIXING"}.Match(l))
+	assert.False(t, LoveFilter{Keyword: "breaking"}.Match(l))
+	assert.True(t, LoveFilter{Tag: "#OnCall"}.Match(l))
+	assert.False(t, LoveFilter{Tag: "call"}.Match(l))
+	assert.True(t, LoveFilter{Value: "ownership"}.Match(l))
+	assert.False(t, LoveFilter{Value: "Humble"}.Match(l))
+}
+
+func TestGetLoveFilteredNoClientSide(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	params := map[string]string{
+		"sender":  "hammy",
+		"limit":   "20",
+		"api_key": testApiKey,
+	}
+
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		newGetValidateResponder(t, 200, twoGetLoveResponse, params),
+	)
+
+	loves, err := client.GetLoveFiltered(LoveFilter{Sender: "hammy", Limit: 20})
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 2)
+}
+
+func TestGetLoveFilteredSince(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	params := map[string]string{
+		"recipient": "darwin",
+		"limit":     "100",
+		"since":     "2000-01-15T00:00:00",
+		"api_key":   testApiKey,
+	}
+
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		newGetValidateResponder(t, 200, twoGetLoveResponse, params),
+	)
+
+	loves, err := client.GetLoveFiltered(LoveFilter{
+		Recipient: "darwin",
+		Since:     time.Date(2000, 1, 15, 0, 0, 0, 0, time.UTC),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 0)
+}
+
+func TestGetLoveFilteredKeywordLimit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		newPagingResponder(t, makeLoveJSON(250), false),
+	)
+
+	loves, err := client.GetLoveFiltered(LoveFilter{
+		Sender:  "hammy",
+		Keyword: "message 1",
+		Limit:   5,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 5)
+	assert.Equal(t, loves[0].Message, "message 1")
+	assert.Equal(t, loves[1].Message, "message 10")
+}
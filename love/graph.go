@@ -0,0 +1,166 @@
+package love
+
+import "encoding/xml"
+import "fmt"
+import "io"
+import "sort"
+import "strings"
+
+/*
+An Edge of a Graph: Weight is the number of love Sender sent to Recipient.
+*/
+type Edge struct {
+	Sender    string
+	Recipient string
+	Weight    int
+}
+
+/*
+A Graph of who sent love to whom. Nodes holds every sender and recipient, in
+alphabetical order. Edges holds one edge for each sender and recipient pair,
+heaviest first, then alphabetically. Love sent in opposite directions between
+two users are separate edges.
+*/
+type Graph struct {
+	Nodes []string
+	Edges []Edge
+}
+
+/*
+Build the graph of a collection of love.
+*/
+func ComputeGraph(loves []Love) *Graph {
+	type pair struct{ sender, recipient string }
+	weights := make(map[pair]int)
+	nodes := make(map[string]bool)
+	for _, l := range loves {
+		weights[pair{l.Sender, l.Recipient}]++
+		nodes[l.Sender] = true
+		nodes[l.Recipient] = true
+	}
+	g := &Graph{Nodes: []string{}, Edges: []Edge{}}
+	for node := range nodes {
+		g.Nodes = append(g.Nodes, node)
+	}
+	sort.Strings(g.Nodes)
+	for p, weight := range weights {
+		g.Edges = append(g.Edges, Edge{p.sender, p.recipient, weight})
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		switch {
+		case a.Weight != b.Weight:
+			return a.Weight > b.Weight
+		case a.Sender != b.Sender:
+			return a.Sender < b.Sender
+		}
+		return a.Recipient < b.Recipient
+	})
+	return g
+}
+
+/*
+Write the graph in the DOT language of Graphviz, as a digraph whose edges are
+labeled with, and drawn more thickly according to, their weight. For example:
+
+	golove graph | dot -Tsvg > love.svg
+*/
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph love {\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&b, "\t%s;\n", dotQuote(node))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s [weight=%d, label=\"%d\", penwidth=%d];\n",
+			dotQuote(e.Sender), dotQuote(e.Recipient), e.Weight, e.Weight,
+			penWidth(e.Weight))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+/*
+Scale the width of edges logarithmically, so that heavy edges do not swamp the
+graph.
+*/
+func penWidth(weight int) int {
+	width := 1
+	for weight > 1 {
+		weight /= 2
+		width++
+	}
+	return width
+}
+
+func dotQuote(id string) string {
+	id = strings.ReplaceAll(id, `\`, `\\`)
+	return `"` + strings.ReplaceAll(id, `"`, `\"`) + `"`
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"http://graphml.graphdrawing.org/xmlns graphml"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID string `xml:"id,attr"`
+}
+
+type graphMLEdge struct {
+	Source string      `xml:"source,attr"`
+	Target string      `xml:"target,attr"`
+	Data   graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value int    `xml:",chardata"`
+}
+
+/*
+Write the graph as GraphML, which tools such as Gephi and yEd can import. Nodes
+are identified by username, and each edge has a weight attribute.
+*/
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		Keys:  []graphMLKey{{ID: "weight", For: "edge", Name: "weight", Type: "int"}},
+		Graph: graphMLGraph{ID: "love", EdgeDefault: "directed"},
+	}
+	for _, node := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{node})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: e.Sender,
+			Target: e.Recipient,
+			Data:   graphMLData{Key: "weight", Value: e.Weight},
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
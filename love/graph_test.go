@@ -0,0 +1,66 @@
+package love
+
+import "bytes"
+import "encoding/xml"
+import "testing"
+import "github.com/stretchr/testify/assert"
+
+func graphTestLove() []Love {
+	return []Love{
+		{Sender: "hammy", Recipient: "darwin"},
+		{Sender: "jeremy", Recipient: "darwin"},
+		{Sender: "hammy", Recipient: "darwin"},
+		{Sender: "darwin", Recipient: "hammy"},
+		{Sender: "hammy", Recipient: "jeremy"},
+	}
+}
+
+func TestComputeGraph(t *testing.T) {
+	g := ComputeGraph(graphTestLove())
+	assert.Equal(t, []string{"darwin", "hammy", "jeremy"}, g.Nodes)
+	assert.Equal(t, []Edge{
+		{"hammy", "darwin", 2},
+		{"darwin", "hammy", 1},
+		{"hammy", "jeremy", 1},
+		{"jeremy", "darwin", 1},
+	}, g.Edges)
+
+	empty := ComputeGraph(nil)
+	assert.Empty(t, empty.Nodes)
+	assert.Empty(t, empty.Edges)
+}
+
+func TestWriteDOT(t *testing.T) {
+	g := ComputeGraph([]Love{
+		{Sender: "hammy", Recipient: `da"rwin`},
+		{Sender: "hammy", Recipient: `da"rwin`},
+		{Sender: "hammy", Recipient: `da"rwin`},
+		{Sender: `da"rwin`, Recipient: "hammy"},
+	})
+	var b bytes.Buffer
+	assert.Nil(t, g.WriteDOT(&b))
+	assert.Equal(t, `digraph love {
+	"da\"rwin";
+	"hammy";
+	"hammy" -> "da\"rwin" [weight=3, label="3", penwidth=2];
+	"da\"rwin" -> "hammy" [weight=1, label="1", penwidth=1];
+}
+`, b.String())
+}
+
+func TestWriteGraphML(t *testing.T) {
+	var b bytes.Buffer
+	assert.Nil(t, ComputeGraph(graphTestLove()).WriteGraphML(&b))
+	assert.Contains(t, b.String(), `<?xml version="1.0" encoding="UTF-8"?>`)
+	assert.Contains(t, b.String(), `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	assert.Contains(t, b.String(), `<edge source="hammy" target="darwin">
+      <data key="weight">2</data>
+    </edge>`)
+
+	var doc graphML
+	assert.Nil(t, xml.Unmarshal(b.Bytes(), &doc))
+	assert.Equal(t, "directed", doc.Graph.EdgeDefault)
+	assert.Equal(t, "weight", doc.Keys[0].ID)
+	assert.Len(t, doc.Graph.Nodes, 3)
+	assert.Len(t, doc.Graph.Edges, 4)
+}
@@ -0,0 +1,166 @@
+package love
+
+import "fmt"
+import "sort"
+import "strings"
+
+/*
+Groups are named lists of recipients, such as a team, keyed by name in lower
+case. A recipient written as @name, where name is a group, stands for every
+member of the group. Members may themselves be groups, written as @name.
+
+When a Client has Groups, SendLove, SendLoves and the other methods which send
+love expand them, so that love for "@backend-team" is sent to each member.
+*/
+type Groups map[string][]string
+
+/*
+Report whether a name may be used for a group: it must be non-empty, and, like
+a username, consist of letters, digits, dots, dashes and underscores.
+*/
+func ValidGroupName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !isWordRune(r) && r != '.' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Return the group a recipient refers to, as @name, and whether there is one.
+*/
+func (g Groups) lookup(recipient string) (string, []string, bool) {
+	if !strings.HasPrefix(recipient, "@") {
+		return "", nil, false
+	}
+	name := strings.ToLower(recipient[1:])
+	members, ok := g[name]
+	return name, members, ok
+}
+
+/*
+Expand the groups among recipients into their members, recursively, returning
+the distinct recipients in order of first appearance. Recipients which are not
+groups, including those starting with @ which do not name a group, are kept as
+they are. A group which contains itself, directly or not, is an error.
+*/
+func (g Groups) Expand(recipients []string) ([]string, error) {
+	var expanded []string
+	seen := make(map[string]bool)
+	var expand func(recipients []string, path []string) error
+	expand = func(recipients []string, path []string) error {
+		for _, recipient := range recipients {
+			recipient = strings.TrimSpace(recipient)
+			if recipient == "" {
+				continue
+			}
+			name, members, ok := g.lookup(recipient)
+			if !ok {
+				if !seen[recipient] {
+					seen[recipient] = true
+					expanded = append(expanded, recipient)
+				}
+				continue
+			}
+			for _, outer := range path {
+				if outer == name {
+					return fmt.Errorf("group @%s contains itself", name)
+				}
+			}
+			if err := expand(members, append(path, name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := expand(recipients, nil); err != nil {
+		return nil, err
+	}
+	return expanded, nil
+}
+
+/*
+Add members to a group, creating it if necessary. Members it already has are
+not added again.
+*/
+func (g Groups) Add(name string, members ...string) error {
+	name = strings.ToLower(strings.TrimPrefix(name, "@"))
+	if !ValidGroupName(name) {
+		return fmt.Errorf("invalid group name %q", name)
+	}
+	group := g[name]
+	for _, member := range members {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		if strings.EqualFold(member, "@"+name) {
+			return fmt.Errorf("group @%s cannot contain itself", name)
+		}
+		if !containsString(group, member) {
+			group = append(group, member)
+		}
+	}
+	g[name] = group
+	return nil
+}
+
+/*
+Remove members from a group, or the whole group if no members are given.
+Reports whether the group exists.
+*/
+func (g Groups) Remove(name string, members ...string) bool {
+	name = strings.ToLower(strings.TrimPrefix(name, "@"))
+	group, ok := g[name]
+	if !ok {
+		return false
+	}
+	if len(members) == 0 {
+		delete(g, name)
+		return true
+	}
+	var kept []string
+	for _, member := range group {
+		if !containsString(members, member) {
+			kept = append(kept, member)
+		}
+	}
+	g[name] = kept
+	return true
+}
+
+/*
+Return the names of the groups, sorted.
+*/
+func (g Groups) Names() []string {
+	names := make([]string, 0, len(g))
+	for name := range g {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+/*
+Expand the client's Groups among recipients, as Groups.Expand does. Without
+groups, the recipients are returned as they are.
+*/
+func (c *Client) expandGroups(recipients []string) ([]string, error) {
+	if len(c.Groups) == 0 {
+		return recipients, nil
+	}
+	return c.Groups.Expand(recipients)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package love
+
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "io/ioutil"
+import "net/http"
+import "net/url"
+import "sync"
+
+func testGroups() Groups {
+	return Groups{
+		"backend":  {"darwin", "jeremy"},
+		"frontend": {"hammy", "darwin"},
+		"eng":      {"@backend", "@Frontend", "casper"},
+	}
+}
+
+func TestGroupsExpand(t *testing.T) {
+	groups := testGroups()
+	expanded, err := groups.Expand([]string{"@eng", " alice", "darwin", "@nobody", ""})
+	assert.Nil(t, err)
+	assert.Equal(t, expanded, []string{"darwin", "jeremy", "hammy", "casper", "alice", "@nobody"})
+
+	expanded, err = groups.Expand([]string{"@BACKEND"})
+	assert.Nil(t, err)
+	assert.Equal(t, expanded, []string{"darwin", "jeremy"})
+
+	groups["backend"] = append(groups["backend"], "@eng")
+	_, err = groups.Expand([]string{"@frontend", "@backend"})
+	assert.Equal(t, err.Error(), "group @backend contains itself")
+}
+
+func TestGroupsAddRemove(t *testing.T) {
+	groups := Groups{}
+	assert.Nil(t, groups.Add("@Backend", "darwin", "jeremy", "darwin", " "))
+	assert.Nil(t, groups.Add("backend", "hammy", "jeremy"))
+	assert.Equal(t, groups["backend"], []string{"darwin", "jeremy", "hammy"})
+	assert.NotNil(t, groups.Add("back end", "darwin"))
+	assert.NotNil(t, groups.Add("backend", "@backend"))
+	assert.Nil(t, groups.Add("frontend"))
+	assert.Equal(t, groups.Names(), []string{"backend", "frontend"})
+
+	assert.True(t, groups.Remove("backend", "jeremy", "nobody"))
+	assert.Equal(t, groups["backend"], []string{"darwin", "hammy"})
+	assert.True(t, groups.Remove("@frontend"))
+	assert.False(t, groups.Remove("frontend"))
+	assert.Equal(t, groups.Names(), []string{"backend"})
+}
+
+func TestValidGroupName(t *testing.T) {
+	for _, name := range []string{"backend-team", "team_1", "eng.ops"} {
+		assert.True(t, ValidGroupName(name), name)
+	}
+	for _, name := range []string{"", "back end", "@eng", "eng,ops", "slack:U1"} {
+		assert.False(t, ValidGroupName(name), name)
+	}
+}
+
+func TestSendLoveGroups(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var recipient string
+	httpmock.RegisterResponder(
+		"POST", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			values, _ := url.ParseQuery(string(body))
+			recipient = values.Get("recipient")
+			return httpmock.NewStringResponse(201, "Love sent!"), nil
+		},
+	)
+
+	client := getTestClient()
+	client.Groups = testGroups()
+	err := client.SendLoves("hammy", []string{"@backend", "alice"}, "Thanks, team!")
+	assert.Nil(t, err)
+	assert.Equal(t, recipient, "darwin,jeremy,alice")
+
+	client.MaxRecipients = 3
+	err = client.SendLove("hammy", "@eng", "Thanks, everyone!")
+	assert.Equal(t, err, &TooManyRecipientsError{Count: 4, Max: 3})
+}
+
+func TestSendLoveEachGroups(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var mutex sync.Mutex
+	received := make(map[string]int)
+	httpmock.RegisterResponder(
+		"POST", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			values, _ := url.ParseQuery(string(body))
+			mutex.Lock()
+			received[values.Get("recipient")]++
+			mutex.Unlock()
+			return httpmock.NewStringResponse(201, "Love sent!"), nil
+		},
+	)
+
+	client := getTestClient()
+	client.Groups = testGroups()
+	results := client.SendLoveEach("hammy", []string{"@frontend", "jeremy"}, "Thanks!", 2)
+	assert.Equal(t, results, map[string]error{"hammy": nil, "darwin": nil, "jeremy": nil})
+	assert.Equal(t, received, map[string]int{"hammy": 1, "darwin": 1, "jeremy": 1})
+
+	client.Groups["frontend"] = []string{"@frontend"}
+	results = client.SendLoveEach("hammy", []string{"@frontend"}, "Thanks!", 2)
+	assert.NotNil(t, results["@frontend"])
+}
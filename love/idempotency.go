@@ -0,0 +1,131 @@
+package love
+
+import "context"
+import "crypto/rand"
+import "encoding/hex"
+import "time"
+
+/*
+A SendRecord is what a client's SendRecords remember about love sent with an
+idempotency key: when it was first tried, and whether it is known to have been
+sent.
+*/
+type SendRecord struct {
+	Key     string    `json:"key"`
+	Started time.Time `json:"started"`
+	Sent    bool      `json:"sent,omitempty"`
+}
+
+/*
+A SendRecordStore keeps SendRecords by their keys, such as a *store.Store.
+SendRecord returns nil, and no error, for a key which has no record.
+*/
+type SendRecordStore interface {
+	SendRecord(key string) (*SendRecord, error)
+	PutSendRecord(r *SendRecord) error
+}
+
+/*
+The time the server's clock may be behind the client's, when looking for love
+which may have been sent at or after a time by the client's clock.
+*/
+const clockSkew = time.Minute
+
+// The context keys of the idempotency key, and of ForceResend.
+type idempotencyKey struct{}
+type forceResendKey struct{}
+
+/*
+Return a new random idempotency key.
+*/
+func NewIdempotencyKey() string {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(key)
+}
+
+/*
+Return a context with which love is sent with an idempotency key, which
+identifies a single logical send of love, however many times it is tried:
+
+	ctx := love.WithIdempotencyKey(context.Background(), pending.Key)
+	err := client.SendLoveContext(ctx, "hammy", "darwin", "thanks!")
+
+If the client has SendRecords, love sent with a key is recorded there before it
+is sent, and marked sent once the server says so. Sending it again with the
+same key then does nothing and returns nil. If an earlier attempt failed, such
+as by timing out after the server received the love, the sender's most recent
+love in the API is searched for it first, and it is only sent again if it is
+not found. So love which is retried after an error, for instance from a queue,
+is not sent twice.
+*/
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+/*
+Return a context with which love is sent even if it was already sent with the
+same idempotency key.
+*/
+func ForceResend(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceResendKey{}, true)
+}
+
+/*
+Return the record of love from a sender to the recipients with a message, which
+is being sent with the idempotency key of ctx, or nil if it has no key or the
+client has no SendRecords. The record is marked Sent if the love was already
+sent. Otherwise it must be stored with PutSendRecord before the love is sent.
+*/
+func (c *Client) sendRecord(ctx context.Context, from string, recipients []string,
+	message string) (*SendRecord, error) {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	if key == "" || c.SendRecords == nil || c.DryRun != nil {
+		return nil, nil
+	}
+	record, err := c.SendRecords.SendRecord(key)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil || ctx.Value(forceResendKey{}) != nil {
+		return &SendRecord{Key: key, Started: time.Now()}, nil
+	}
+	if record.Sent {
+		return record, nil
+	}
+	// The love has been tried before, and failed or was interrupted, but it
+	// may have reached the server anyway. The server sends love to every
+	// recipient at once, and ignores those who do not exist, so love to
+	// any of them means it was sent.
+	f := LoveFilter{Sender: from, Since: record.Started.Add(-clockSkew)}
+	sent, err := c.GetLoveContext(ctx, from, "", duplicateCheckLimit)
+	if err != nil {
+		return nil, err
+	}
+	if len(sameLove(sent, f, recipients, message)) > 0 {
+		record.Sent = true
+		if err := c.SendRecords.PutSendRecord(record); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+/*
+Mark the record of love which the server says it has received as sent. The
+love is not failed if this cannot be stored, since it was sent; if it is tried
+again, the love is found in the API instead. The error is logged to the
+client's Logger.
+*/
+func (c *Client) recordSent(ctx context.Context, record *SendRecord) {
+	if record == nil {
+		return
+	}
+	record.Sent = true
+	err := c.SendRecords.PutSendRecord(record)
+	if err != nil && c.Logger != nil {
+		c.Logger.ErrorContext(ctx, "could not record love as sent", "key", record.Key, "error", err)
+	}
+}
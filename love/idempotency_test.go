@@ -0,0 +1,121 @@
+package love
+
+import "bytes"
+import "context"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+import "time"
+
+type mapRecords map[string]SendRecord
+
+func (m mapRecords) SendRecord(key string) (*SendRecord, error) {
+	r, ok := m[key]
+	if !ok {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+func (m mapRecords) PutSendRecord(r *SendRecord) error {
+	m[r.Key] = *r
+	return nil
+}
+
+/*
+Respond to requests for love with loves, and count the love sent.
+*/
+func registerLove(loves string, sent *int) {
+	httpmock.RegisterResponder("GET", testLoveUrl, httpmock.NewStringResponder(200, loves))
+	httpmock.RegisterResponder("POST", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			*sent++
+			return httpmock.NewStringResponse(loveCreatedStatusCode, "Love sent!"), nil
+		})
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	sent := 0
+	registerLove("[]", &sent)
+
+	records := mapRecords{}
+	var log bytes.Buffer
+	client := getTestClient()
+	client.SendRecords = records
+	client.Audit = &log
+	ctx := WithIdempotencyKey(context.Background(), "key")
+	assert.Nil(t, client.SendLoveContext(ctx, "hammy", "darwin", "thanks"))
+	assert.Equal(t, sent, 1)
+	assert.True(t, records["key"].Sent)
+	assert.False(t, records["key"].Started.IsZero())
+
+	assert.Nil(t, client.SendLoveContext(ctx, "hammy", "darwin", "thanks"))
+	assert.Equal(t, sent, 1)
+	assert.Nil(t, client.SendLoveContext(ForceResend(ctx), "hammy", "darwin", "thanks"))
+	assert.Equal(t, sent, 2)
+
+	// Love without a key is not tracked.
+	assert.Nil(t, client.SendLove("hammy", "darwin", "thanks"))
+	assert.Nil(t, client.SendLove("hammy", "darwin", "thanks"))
+	assert.Equal(t, sent, 4)
+	assert.Equal(t, len(records), 1)
+
+	entries, err := ReadAudit(&log)
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), 5)
+	assert.Equal(t, entries[0].Key, "key")
+	assert.False(t, entries[0].AlreadySent)
+	assert.True(t, entries[1].AlreadySent)
+	assert.True(t, entries[1].Succeeded())
+	assert.Equal(t, entries[3].Key, "")
+}
+
+func TestIdempotencyKeyInterrupted(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	recent := time.Now().UTC().Add(-time.Minute).Format("2006-01-02T15:04:05")
+	sent := 0
+	registerLove(`[{"timestamp": "`+recent+`", "message": "thanks :tada:", "sender": "hammy", "recipient": "darwin"},
+{"timestamp": "2000-01-01T01:01:01", "message": "thanks", "sender": "hammy", "recipient": "jeremy"}]`, &sent)
+
+	// The first attempt at each love timed out after the server received
+	// the love to darwin, but not to jeremy.
+	started := time.Now().Add(-2 * time.Minute)
+	records := mapRecords{
+		"darwin": {Key: "darwin", Started: started},
+		"jeremy": {Key: "jeremy", Started: started},
+	}
+	client := getTestClient()
+	client.SendRecords = records
+	client.MessageOptions.KeepShortcodes = true
+	ctx := context.Background()
+	assert.Nil(t, client.SendLoveContext(WithIdempotencyKey(ctx, "darwin"), "hammy", "darwin", "thanks :tada:"))
+	assert.Equal(t, sent, 0)
+	assert.True(t, records["darwin"].Sent)
+	assert.Equal(t, records["darwin"].Started, started)
+
+	assert.Nil(t, client.SendLoveContext(WithIdempotencyKey(ctx, "jeremy"), "hammy", "jeremy", "thanks"))
+	assert.Equal(t, sent, 1)
+	assert.True(t, records["jeremy"].Sent)
+	assert.Equal(t, records["jeremy"].Started, started)
+}
+
+func TestIdempotencyKeyDryRun(t *testing.T) {
+	var out bytes.Buffer
+	records := mapRecords{}
+	client := getTestClient()
+	client.SendRecords = records
+	client.DryRun = &out
+	ctx := WithIdempotencyKey(context.Background(), "key")
+	assert.Nil(t, client.SendLoveContext(ctx, "hammy", "darwin", "thanks"))
+	assert.Equal(t, len(records), 0)
+}
+
+func TestNewIdempotencyKey(t *testing.T) {
+	key := NewIdempotencyKey()
+	assert.Equal(t, len(key), 32)
+	assert.NotEqual(t, key, NewIdempotencyKey())
+}
@@ -0,0 +1,193 @@
+package love
+
+import "sort"
+import "time"
+
+/*
+Reciprocity describes whether the love a user sent was returned. Unreturned
+counts the love the user sent to each user who never sent any back, and
+Unacknowledged counts the love sent to the user by each user they never sent
+any to. Both are ordered as by TopCounts. Mutual lists, alphabetically, the
+users with whom love went both ways.
+*/
+type Reciprocity struct {
+	Unreturned     []Count
+	Unacknowledged []Count
+	Mutual         []string
+}
+
+/*
+Compute the reciprocity of a user's love, given the love they sent and
+received. Love not involving the user is ignored, as is love they sent
+themselves.
+*/
+func ComputeReciprocity(user string, loves []Love) *Reciprocity {
+	sent := make(map[string]int)
+	received := make(map[string]int)
+	for _, l := range loves {
+		switch {
+		case l.Sender == l.Recipient:
+		case l.Sender == user:
+			sent[l.Recipient]++
+		case l.Recipient == user:
+			received[l.Sender]++
+		}
+	}
+	unreturned := make(map[string]int)
+	unacknowledged := make(map[string]int)
+	r := &Reciprocity{Mutual: []string{}}
+	for other, count := range sent {
+		if received[other] == 0 {
+			unreturned[other] = count
+		} else {
+			r.Mutual = append(r.Mutual, other)
+		}
+	}
+	for other, count := range received {
+		if sent[other] == 0 {
+			unacknowledged[other] = count
+		}
+	}
+	sort.Strings(r.Mutual)
+	r.Unreturned = TopCounts(unreturned, 0)
+	r.Unacknowledged = TopCounts(unacknowledged, 0)
+	return r
+}
+
+/*
+A Streak measures how many consecutive weeks a user sent love in, as weeks are
+defined by WeekStart. Current is the length of the streak which is still going:
+it includes the current week if the user has sent love during it, but is not
+broken until the current week ends without any. Longest is the length of the
+longest streak, which began in the week starting LongestStart (the most recent,
+if there are several). Every field is zero if the user sent no love.
+*/
+type Streak struct {
+	Current      int
+	Longest      int
+	LongestStart time.Time
+}
+
+/*
+Compute a user's weekly sending streak as of now, from the love they sent.
+Weeks are taken in now's location.
+*/
+func ComputeStreak(user string, loves []Love, now time.Time) Streak {
+	active := make(map[time.Time]bool)
+	for _, l := range loves {
+		if l.Sender == user && !l.Timestamp.After(now) {
+			active[WeekStart(l.Timestamp.In(now.Location()))] = true
+		}
+	}
+	weeks := make([]time.Time, 0, len(active))
+	for week := range active {
+		weeks = append(weeks, week)
+	}
+	sort.Slice(weeks, func(i, j int) bool { return weeks[i].Before(weeks[j]) })
+
+	var s Streak
+	length := 0
+	for i, week := range weeks {
+		if i > 0 && weeks[i-1].AddDate(0, 0, 7).Equal(week) {
+			length++
+		} else {
+			length = 1
+		}
+		if length >= s.Longest {
+			s.Longest = length
+			s.LongestStart = weeks[i-length+1]
+		}
+	}
+
+	week := WeekStart(now)
+	if !active[week] {
+		week = week.AddDate(0, 0, -7)
+	}
+	for active[week] {
+		s.Current++
+		week = week.AddDate(0, 0, -7)
+	}
+	return s
+}
+
+/*
+Return the time each user last sent love, among a collection of love.
+*/
+func LastSent(loves []Love) map[string]time.Time {
+	last := make(map[string]time.Time)
+	for _, l := range loves {
+		if l.Timestamp.After(last[l.Sender]) {
+			last[l.Sender] = l.Timestamp
+		}
+	}
+	return last
+}
+
+/*
+Return, alphabetically, the users who have not sent any love since a time,
+according to a collection of love which should include every love they sent
+since then.
+*/
+func Inactive(users []string, loves []Love, since time.Time) []string {
+	last := LastSent(loves)
+	inactive := []string{}
+	for _, user := range users {
+		if last[user].Before(since) {
+			inactive = append(inactive, user)
+		}
+	}
+	sort.Strings(inactive)
+	return inactive
+}
+
+/*
+A user a sender might send love to, and when they last did. LastSent is zero if
+they never have.
+*/
+type Suggestion struct {
+	User     string
+	LastSent time.Time
+}
+
+/*
+Rank the users a sender might send love to, given the love they sent: those they
+never sent love to first, then those they appreciated longest ago. Ties are
+broken alphabetically. Candidates are the users to consider, such as the
+sender's team; if there are none, every user the sender exchanged love with is
+considered. The sender is never suggested.
+*/
+func SuggestRecipients(sender string, loves []Love, candidates []string) []Suggestion {
+	last := make(map[string]time.Time)
+	known := make(map[string]bool)
+	for _, l := range loves {
+		if l.Sender == sender {
+			if l.Timestamp.After(last[l.Recipient]) {
+				last[l.Recipient] = l.Timestamp
+			}
+			known[l.Recipient] = true
+		} else if l.Recipient == sender {
+			known[l.Sender] = true
+		}
+	}
+	if len(candidates) == 0 {
+		for user := range known {
+			candidates = append(candidates, user)
+		}
+	}
+	suggestions := []Suggestion{}
+	seen := map[string]bool{sender: true}
+	for _, user := range candidates {
+		if !seen[user] {
+			seen[user] = true
+			suggestions = append(suggestions, Suggestion{user, last[user]})
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if !a.LastSent.Equal(b.LastSent) {
+			return a.LastSent.Before(b.LastSent)
+		}
+		return a.User < b.User
+	})
+	return suggestions
+}
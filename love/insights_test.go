@@ -0,0 +1,103 @@
+package love
+
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "time"
+
+// A Monday.
+var insightsWeek = time.Date(2017, 4, 3, 12, 0, 0, 0, time.UTC)
+
+func sentInWeeks(user string, weeks ...int) []Love {
+	var loves []Love
+	for _, week := range weeks {
+		loves = append(loves, Love{
+			Sender:    user,
+			Recipient: "darwin",
+			Timestamp: insightsWeek.AddDate(0, 0, 7*week+2),
+		})
+	}
+	return loves
+}
+
+func TestComputeReciprocity(t *testing.T) {
+	loves := []Love{
+		{Sender: "hammy", Recipient: "darwin"},
+		{Sender: "hammy", Recipient: "darwin"},
+		{Sender: "hammy", Recipient: "jeremy"},
+		{Sender: "jeremy", Recipient: "hammy"},
+		{Sender: "alice", Recipient: "hammy"},
+		{Sender: "hammy", Recipient: "bob"},
+		{Sender: "hammy", Recipient: "hammy"},
+		{Sender: "alice", Recipient: "darwin"},
+	}
+	r := ComputeReciprocity("hammy", loves)
+	assert.Equal(t, []Count{{"darwin", 2}, {"bob", 1}}, r.Unreturned)
+	assert.Equal(t, []Count{{"alice", 1}}, r.Unacknowledged)
+	assert.Equal(t, []string{"jeremy"}, r.Mutual)
+
+	r = ComputeReciprocity("nobody", loves)
+	assert.Empty(t, r.Unreturned)
+	assert.Empty(t, r.Unacknowledged)
+	assert.Empty(t, r.Mutual)
+}
+
+func TestComputeStreak(t *testing.T) {
+	loves := append(sentInWeeks("hammy", 0, 1, 2, 5, 6), sentInWeeks("darwin", 3, 4)...)
+	loves = append(loves, Love{Sender: "darwin", Recipient: "hammy",
+		Timestamp: insightsWeek.AddDate(0, 0, 7*7)})
+
+	// During week 6, after sending.
+	s := ComputeStreak("hammy", loves, insightsWeek.AddDate(0, 0, 7*6+3))
+	assert.Equal(t, Streak{Current: 2, Longest: 3, LongestStart: WeekStart(insightsWeek)}, s)
+
+	// During week 7: the streak continues until the week ends.
+	s = ComputeStreak("hammy", loves, insightsWeek.AddDate(0, 0, 7*7+3))
+	assert.Equal(t, 2, s.Current)
+
+	// During week 8: it is broken.
+	s = ComputeStreak("hammy", loves, insightsWeek.AddDate(0, 0, 7*8))
+	assert.Equal(t, 0, s.Current)
+	assert.Equal(t, 3, s.Longest)
+
+	// Love sent after now is ignored.
+	s = ComputeStreak("hammy", loves, insightsWeek.AddDate(0, 0, 7*1))
+	assert.Equal(t, Streak{Current: 1, Longest: 1, LongestStart: WeekStart(insightsWeek)}, s)
+
+	// The most recent of equal streaks is reported.
+	s = ComputeStreak("darwin", sentInWeeks("darwin", 0, 1, 4, 5), insightsWeek.AddDate(0, 0, 7*9))
+	assert.Equal(t, Streak{Longest: 2, LongestStart: WeekStart(insightsWeek.AddDate(0, 0, 7*4))}, s)
+
+	assert.Equal(t, Streak{}, ComputeStreak("jeremy", loves, insightsWeek))
+}
+
+func TestInactive(t *testing.T) {
+	loves := append(sentInWeeks("hammy", 0, 4), sentInWeeks("jeremy", 1)...)
+	last := LastSent(loves)
+	assert.Equal(t, insightsWeek.AddDate(0, 0, 7*4+2), last["hammy"])
+	assert.Equal(t, []string{"darwin", "jeremy"},
+		Inactive([]string{"jeremy", "hammy", "darwin"}, loves, insightsWeek.AddDate(0, 0, 14)))
+	assert.Empty(t, Inactive([]string{"hammy"}, loves, insightsWeek))
+}
+
+func TestSuggestRecipients(t *testing.T) {
+	loves := []Love{
+		{Sender: "hammy", Recipient: "darwin", Timestamp: insightsWeek},
+		{Sender: "hammy", Recipient: "jeremy", Timestamp: insightsWeek.AddDate(0, 0, 1)},
+		{Sender: "hammy", Recipient: "darwin", Timestamp: insightsWeek.AddDate(0, 0, 2)},
+		{Sender: "alice", Recipient: "hammy", Timestamp: insightsWeek},
+		{Sender: "hammy", Recipient: "hammy", Timestamp: insightsWeek},
+		{Sender: "bob", Recipient: "darwin", Timestamp: insightsWeek},
+	}
+	assert.Equal(t, []Suggestion{
+		{"alice", time.Time{}},
+		{"jeremy", insightsWeek.AddDate(0, 0, 1)},
+		{"darwin", insightsWeek.AddDate(0, 0, 2)},
+	}, SuggestRecipients("hammy", loves, nil))
+
+	assert.Equal(t, []Suggestion{
+		{"carol", time.Time{}},
+		{"darwin", insightsWeek.AddDate(0, 0, 2)},
+	}, SuggestRecipients("hammy", loves, []string{"darwin", "hammy", "carol", "darwin"}))
+
+	assert.Empty(t, SuggestRecipients("nobody", loves, nil))
+}
@@ -0,0 +1,91 @@
+package love
+
+/*
+The Direction of a love, from the point of view of one user.
+*/
+type Direction int
+
+const (
+	// The user sent the love.
+	Sent Direction = iota
+	// The user received the love.
+	Received
+)
+
+func (d Direction) String() string {
+	if d == Received {
+		return "received"
+	}
+	return "sent"
+}
+
+/*
+A DirectedLove is a love along with whether it was sent or received by the user
+it was retrieved for.
+*/
+type DirectedLove struct {
+	Love
+	Direction Direction
+}
+
+/*
+Retrieve the love sent and received by a user which matches the rest of a
+filter, whose Sender and Recipient are ignored. The two queries are made at
+once, as GetLoveFiltered would make them, and merged, newest first. Each love
+is marked with its direction; love a user sent to themselves is marked Sent.
+Limit applies to the merged love.
+*/
+func (c *Client) GetLoveInvolving(username string, f LoveFilter) ([]DirectedLove, error) {
+	sent, received := f, f
+	sent.Sender, sent.Recipient = username, ""
+	received.Sender, received.Recipient = "", username
+	return c.getDirected(username, sent, received, f.Limit)
+}
+
+/*
+Retrieve the love two users sent each other which matches the rest of a
+filter, whose Sender and Recipient are ignored, merged newest first, as by
+GetLoveInvolving. Each love is marked with its direction from the point of view
+of username.
+*/
+func (c *Client) GetLoveBetween(username, other string, f LoveFilter) ([]DirectedLove, error) {
+	sent, received := f, f
+	sent.Sender, sent.Recipient = username, other
+	received.Sender, received.Recipient = other, username
+	return c.getDirected(username, sent, received, f.Limit)
+}
+
+/*
+Make the queries for the love a user sent and received at once, and merge them.
+*/
+func (c *Client) getDirected(username string, sent, received LoveFilter, limit int64) ([]DirectedLove, error) {
+	type result struct {
+		loves []Love
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		loves, err := c.GetLoveFiltered(received)
+		results <- result{loves, err}
+	}()
+	sentLoves, sentErr := c.GetLoveFiltered(sent)
+	r := <-results
+	if sentErr != nil {
+		return nil, sentErr
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	merged := Merge(sentLoves, r.loves)
+	if limit > 0 && int64(len(merged)) > limit {
+		merged = merged[:limit]
+	}
+	directed := make([]DirectedLove, len(merged))
+	for i, l := range merged {
+		directed[i] = DirectedLove{l, Received}
+		if l.Sender == username {
+			directed[i].Direction = Sent
+		}
+	}
+	return directed, nil
+}
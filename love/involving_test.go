@@ -0,0 +1,67 @@
+package love
+
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+import "net/http/httptest"
+
+func TestGetLoveInvolving(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("sender") == "hammy" {
+				w.Write([]byte(`[{"sender": "hammy", "recipient": "darwin", "message": "a", "timestamp": "2017-01-02T00:00:00"},
+					{"sender": "hammy", "recipient": "jeremy", "message": "b", "timestamp": "2016-12-31T00:00:00"}]`))
+			} else {
+				w.Write([]byte(`[{"sender": "darwin", "recipient": "hammy", "message": "c", "timestamp": "2017-01-01T00:00:00"}]`))
+			}
+		}))
+	defer server.Close()
+
+	client := NewClient(testApiKey, server.URL+"/api")
+	loves, err := client.GetLoveInvolving("hammy", LoveFilter{Recipient: "ignored"})
+	assert.Nil(t, err)
+	var directions []string
+	for _, l := range loves {
+		directions = append(directions, l.Message+" "+l.Direction.String())
+	}
+	assert.Equal(t, directions, []string{"a sent", "c received", "b sent"})
+
+	loves, err = client.GetLoveInvolving("hammy", LoveFilter{Limit: 2})
+	assert.Nil(t, err)
+	assert.Len(t, loves, 2)
+}
+
+func TestGetLoveInvolvingError(t *testing.T) {
+	server, _ := newCountingServer(500, "oops")
+	defer server.Close()
+
+	client := NewClient(testApiKey, server.URL+"/api")
+	_, err := client.GetLoveInvolving("hammy", LoveFilter{})
+	assert.True(t, IsTemporary(err))
+}
+
+func TestGetLoveBetween(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			switch query.Get("sender") + " " + query.Get("recipient") {
+			case "hammy darwin":
+				w.Write([]byte(`[{"sender": "hammy", "recipient": "darwin", "message": "a", "timestamp": "2017-01-02T00:00:00"},
+					{"sender": "hammy", "recipient": "darwin", "message": "b", "timestamp": "2016-12-31T00:00:00"}]`))
+			case "darwin hammy":
+				w.Write([]byte(`[{"sender": "darwin", "recipient": "hammy", "message": "c", "timestamp": "2017-01-01T00:00:00"}]`))
+			default:
+				w.WriteHeader(400)
+			}
+		}))
+	defer server.Close()
+
+	client := NewClient(testApiKey, server.URL+"/api")
+	loves, err := client.GetLoveBetween("darwin", "hammy", LoveFilter{Sender: "ignored"})
+	assert.Nil(t, err)
+	var directions []string
+	for _, l := range loves {
+		directions = append(directions, l.Message+" "+l.Direction.String())
+	}
+	assert.Equal(t, directions, []string{"a received", "c sent", "b received"})
+}
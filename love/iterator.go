@@ -0,0 +1,195 @@
+package love
+
+import "context"
+
+/*
+The number of love requested per page by a LoveIterator, unless PageSize is set.
+This stays well below the maximum the server is willing to return at once.
+*/
+const DefaultPageSize = 100
+
+/*
+The number of love a LoveIterator requests at once from a server which ignores
+the offset parameter, as stock Yelp Love does: the most the server is likely to
+return.
+*/
+const FallbackLimit = 2000
+
+/*
+A LoveIterator pages through every love matching a sender and recipient, making
+one request per page. Pages are requested using the limit and offset
+parameters. Use it like a bufio.Scanner:
+
+	it := client.IterLove("", "darwin")
+	for it.Next() {
+		l := it.Love()
+		// ...
+	}
+	if err := it.Err(); err != nil {
+		// handle error
+	}
+
+Iteration stops when the server returns a short page. If the server returns a
+full page identical to the previous one, it is ignoring offset. The iterator
+then requests up to FallbackLimit love at once, and continues with those it has
+not yet returned, rather than returning the first page again. Love beyond
+FallbackLimit cannot be fetched from such a server.
+*/
+type LoveIterator struct {
+	// Number of love requested per page. Defaults to DefaultPageSize.
+	PageSize int64
+
+	client *Client
+	ctx    context.Context
+	filter LoveFilter
+	offset int64
+	page   []Love
+	index  int
+	last   bool
+	err    error
+}
+
+/*
+Create a LoveIterator over all love sent from a user, to a user. As with GetLove,
+either from or to (but not both) may be empty.
+*/
+func (c *Client) IterLove(from string, to string) *LoveIterator {
+	return c.IterLoveFiltered(LoveFilter{Sender: from, Recipient: to})
+}
+
+/*
+Create a LoveIterator over all love matching a filter. The Limit of the filter
+is ignored, since the iterator controls the size of each page. Love which do not
+match the filter are skipped.
+*/
+func (c *Client) IterLoveFiltered(f LoveFilter) *LoveIterator {
+	return c.IterLoveFilteredContext(context.Background(), f)
+}
+
+/*
+IterLoveFilteredContext is like IterLoveFiltered, but each page is requested
+with a context, which may cancel it.
+*/
+func (c *Client) IterLoveFilteredContext(ctx context.Context, f LoveFilter) *LoveIterator {
+	return &LoveIterator{
+		PageSize: DefaultPageSize,
+		client:   c,
+		ctx:      ctx,
+		filter:   f,
+		index:    -1,
+	}
+}
+
+/*
+Advance the iterator to the next love, fetching another page if necessary.
+Returns false when there are no more love, or an error occurred.
+*/
+func (it *LoveIterator) Next() bool {
+	for it.advance() {
+		if it.filter.Match(it.page[it.index]) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Move to the next love, whether or not it matches the filter.
+*/
+func (it *LoveIterator) advance() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.index+1 < len(it.page) {
+		it.index++
+		return true
+	}
+	if it.last {
+		return false
+	}
+	f := it.filter
+	f.Limit = it.PageSize
+	if f.Limit <= 0 {
+		f.Limit = DefaultPageSize
+	}
+	page, err := it.client.getLove(it.ctx, f, it.offset)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	if int64(len(page)) < f.Limit {
+		it.last = true
+	}
+	if len(page) > 0 && len(it.page) > 0 && samePage(page, it.page) {
+		if page, err = it.fallback(f); err != nil {
+			it.err = err
+			return false
+		}
+	}
+	it.offset += int64(len(page))
+	it.page = page
+	it.index = 0
+	return len(page) > 0
+}
+
+/*
+Fetch every love the server will return at once, for a server which ignores
+offset, and return those after the ones already returned. It is the last page.
+*/
+func (it *LoveIterator) fallback(f LoveFilter) ([]Love, error) {
+	f.Limit = FallbackLimit
+	page, err := it.client.getLove(it.ctx, f, 0)
+	if err != nil {
+		return nil, err
+	}
+	it.last = true
+	if int64(len(page)) <= it.offset {
+		return nil, nil
+	}
+	return page[it.offset:], nil
+}
+
+/*
+Return the love the iterator currently points to. Only valid after a call to
+Next has returned true.
+*/
+func (it *LoveIterator) Love() Love {
+	return it.page[it.index]
+}
+
+/*
+Return the first error encountered during iteration, if any.
+*/
+func (it *LoveIterator) Err() error {
+	return it.err
+}
+
+/*
+Retrieve every love sent from a user, to a user, fetching as many pages as
+necessary. Prefer IterLove when the history may be large. If the server does not
+support paging, at most FallbackLimit love are returned.
+*/
+func (c *Client) GetLoveAll(from string, to string) ([]Love, error) {
+	var loves []Love
+	it := c.IterLove(from, to)
+	for it.Next() {
+		loves = append(loves, it.Love())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return loves, nil
+}
+
+func samePage(a, b []Love) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Timestamp.Equal(b[i].Timestamp) || a[i].Sender != b[i].Sender ||
+			a[i].Recipient != b[i].Recipient || a[i].Message != b[i].Message {
+			return false
+		}
+	}
+	return true
+}
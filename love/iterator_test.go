@@ -0,0 +1,146 @@
+package love
+
+import "fmt"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+import "strconv"
+import "strings"
+
+func makeLoveJSON(n int) []string {
+	loves := make([]string, n)
+	for i := range loves {
+		loves[i] = fmt.Sprintf(`{"timestamp": "2000-01-01T01:01:%02d",
+"message": "message %d", "sender": "hammy", "recipient": "darwin"}`, i%60, i)
+	}
+	return loves
+}
+
+func newPagingResponder(t *testing.T, loves []string,
+	ignoreOffset bool) func(*http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		query := req.URL.Query()
+		limit, err := strconv.Atoi(query.Get("limit"))
+		assert.Nil(t, err)
+		offset := 0
+		if query.Get("offset") != "" && !ignoreOffset {
+			offset, err = strconv.Atoi(query.Get("offset"))
+			assert.Nil(t, err)
+		}
+		end := offset + limit
+		if end > len(loves) {
+			end = len(loves)
+		}
+		if offset > len(loves) {
+			offset = len(loves)
+		}
+		body := "[" + strings.Join(loves[offset:end], ",") + "]"
+		return httpmock.NewStringResponse(200, body), nil
+	}
+}
+
+func TestGetLoveAllPages(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		newPagingResponder(t, makeLoveJSON(250), false),
+	)
+
+	loves, err := client.GetLoveAll("hammy", "")
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 250)
+	assert.Equal(t, loves[0].Message, "message 0")
+	assert.Equal(t, loves[249].Message, "message 249")
+}
+
+func TestGetLoveAllExactPage(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		newPagingResponder(t, makeLoveJSON(DefaultPageSize), false),
+	)
+
+	loves, err := client.GetLoveAll("hammy", "")
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), DefaultPageSize)
+}
+
+func TestIterLoveOffsetIgnored(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		newPagingResponder(t, makeLoveJSON(20), true),
+	)
+
+	it := client.IterLove("hammy", "")
+	it.PageSize = 10
+	var loves []Love
+	for it.Next() {
+		loves = append(loves, it.Love())
+	}
+	assert.Nil(t, it.Err())
+	if assert.Equal(t, len(loves), 20) {
+		assert.Equal(t, loves[9].Message, "message 9")
+		assert.Equal(t, loves[10].Message, "message 10")
+		assert.Equal(t, loves[19].Message, "message 19")
+	}
+}
+
+func TestGetLoveAllOffsetIgnored(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		newPagingResponder(t, makeLoveJSON(250), true),
+	)
+
+	loves, err := client.GetLoveAll("hammy", "")
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 250)
+	assert.Equal(t, loves[249].Message, "message 249")
+
+	// Only FallbackLimit love can be fetched at once.
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		newPagingResponder(t, makeLoveJSON(FallbackLimit+50), true),
+	)
+	loves, err = client.GetLoveAll("hammy", "")
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), FallbackLimit)
+
+	// A single short page needs no paging.
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		newPagingResponder(t, makeLoveJSON(20), true),
+	)
+	loves, err = client.GetLoveAll("hammy", "")
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 20)
+}
+
+func TestIterLoveError(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		httpmock.NewStringResponder(loveBadParamsStatusCode, "nope"),
+	)
+
+	loves, err := client.GetLoveAll("hammy", "")
+	assert.NotNil(t, err)
+	assert.Nil(t, loves)
+}
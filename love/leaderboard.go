@@ -0,0 +1,205 @@
+package love
+
+import "context"
+import "fmt"
+import "net/http"
+import "net/url"
+import "sort"
+import "time"
+
+/*
+A user's position on a Leaderboard. Users with equal counts share a rank, and
+the next rank is skipped (1, 2, 2, 4).
+*/
+type LeaderboardEntry struct {
+	Rank     int
+	User     string
+	Sent     int
+	Received int
+}
+
+/*
+A Leaderboard ranks users by the love they sent (Senders) and the love they
+received (Recipients). Users who sent no love do not appear in Senders, and
+users who received none do not appear in Recipients.
+*/
+type Leaderboard struct {
+	Senders    []LeaderboardEntry
+	Recipients []LeaderboardEntry
+}
+
+/*
+Rank the senders and recipients of a collection of love.
+*/
+func ComputeLeaderboard(loves []Love) *Leaderboard {
+	stats := ComputeStats(loves)
+	entry := func(user string) LeaderboardEntry {
+		return LeaderboardEntry{
+			User:     user,
+			Sent:     stats.BySender[user],
+			Received: stats.ByRecipient[user],
+		}
+	}
+	board := &Leaderboard{}
+	for user := range stats.BySender {
+		board.Senders = append(board.Senders, entry(user))
+	}
+	for user := range stats.ByRecipient {
+		board.Recipients = append(board.Recipients, entry(user))
+	}
+	rank(board.Senders, func(e LeaderboardEntry) int { return e.Sent })
+	rank(board.Recipients, func(e LeaderboardEntry) int { return e.Received })
+	return board
+}
+
+/*
+Sort entries by a score, highest first, and assign their ranks.
+*/
+func rank(entries []LeaderboardEntry, score func(LeaderboardEntry) int) {
+	sort.Slice(entries, func(i, j int) bool {
+		if score(entries[i]) != score(entries[j]) {
+			return score(entries[i]) > score(entries[j])
+		}
+		return entries[i].User < entries[j].User
+	})
+	for i := range entries {
+		if i > 0 && score(entries[i]) == score(entries[i-1]) {
+			entries[i].Rank = entries[i-1].Rank
+		} else {
+			entries[i].Rank = i + 1
+		}
+	}
+}
+
+/*
+Fetch the love sent and received by each of the users during the last window
+of time (for example, a week), and rank them. The API cannot list all love, so
+the leaderboard only covers love involving at least one of the given users; for
+a team leaderboard, pass every member of the team.
+*/
+func (c *Client) RecentLeaderboard(users []string,
+	window time.Duration) (*Leaderboard, error) {
+	return c.leaderboardBetween(users, time.Now().Add(-window), time.Time{})
+}
+
+/*
+Fetch the love sent and received by each of the users at or after since, and
+before until if it is not zero, and rank them.
+*/
+func (c *Client) leaderboardBetween(users []string, since,
+	until time.Time) (*Leaderboard, error) {
+	loves, errs := c.GetLoveForUsers(users, LoveFilter{Since: since, Until: until}, 0)
+	for _, user := range users {
+		if err := errs[user]; err != nil {
+			return nil, err
+		}
+	}
+	return ComputeLeaderboard(loves), nil
+}
+
+/*
+A Period is a span of time covered by a leaderboard, named as the leaderboard
+endpoint expects. Weeks start on Monday.
+*/
+type Period string
+
+const (
+	ThisWeek  Period = "this_week"
+	LastWeek  Period = "last_week"
+	ThisMonth Period = "this_month"
+	LastMonth Period = "last_month"
+)
+
+/*
+The periods, in the order they are usually listed.
+*/
+var Periods = []Period{ThisWeek, LastWeek, ThisMonth, LastMonth}
+
+/*
+Return the start and end of the period containing now, in now's location. The
+period includes since, but not until.
+*/
+func (p Period) Range(now time.Time) (since, until time.Time, err error) {
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	firstOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+	switch p {
+	case ThisWeek:
+		return monday, monday.AddDate(0, 0, 7), nil
+	case LastWeek:
+		return monday.AddDate(0, 0, -7), monday, nil
+	case ThisMonth:
+		return firstOfMonth, firstOfMonth.AddDate(0, 1, 0), nil
+	case LastMonth:
+		return firstOfMonth.AddDate(0, -1, 0), firstOfMonth, nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("unknown period %q", string(p))
+}
+
+/*
+The JSON form of a LeaderboardEntry returned by the leaderboard endpoint.
+*/
+type jsonLeaderboardEntry struct {
+	Rank     int    `json:"rank"`
+	User     string `json:"username"`
+	Sent     int    `json:"sent"`
+	Received int    `json:"received"`
+}
+
+/*
+Retrieve the leaderboard for a period from the leaderboard endpoint, which some
+instances provide. It responds with the senders and recipients ranked by the
+server:
+
+	{"senders": [{"rank": 1, "username": "hammy", "sent": 3, "received": 1}, ...],
+	 "recipients": [...]}
+
+Stock Yelp Love has no such endpoint. If the server responds with 404 Not
+Found, the leaderboard is computed on the client instead, from the love sent
+and received by users during the period in the server's Location, as by
+RecentLeaderboard. users is only used for this; if it is empty, the 404 is
+returned as an *APIError.
+*/
+func (c *Client) GetLeaderboard(period Period, users []string) (*Leaderboard, error) {
+	return c.GetLeaderboardContext(context.Background(), period, users)
+}
+
+/*
+GetLeaderboardContext is like GetLeaderboard, but the request to the leaderboard
+endpoint is made with a context.
+*/
+func (c *Client) GetLeaderboardContext(ctx context.Context, period Period,
+	users []string) (*Leaderboard, error) {
+	since, until, err := period.Range(time.Now().In(c.location()))
+	if err != nil {
+		return nil, err
+	}
+	values := make(url.Values)
+	values.Set("period", string(period))
+	resp, err := c.do(ctx, getRequest("/leaderboard", values))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound && len(users) > 0 {
+		resp.Body.Close()
+		return c.leaderboardBetween(users, since, until)
+	} else if err := checkStatus("/leaderboard", resp, loveGetStatusCode); err != nil {
+		return nil, err
+	}
+	var response struct {
+		Senders    []jsonLeaderboardEntry `json:"senders"`
+		Recipients []jsonLeaderboardEntry `json:"recipients"`
+	}
+	if err := c.decodeJSON(resp, &response); err != nil {
+		return nil, err
+	}
+	board := &Leaderboard{}
+	for _, e := range response.Senders {
+		board.Senders = append(board.Senders, LeaderboardEntry(e))
+	}
+	for _, e := range response.Recipients {
+		board.Recipients = append(board.Recipients, LeaderboardEntry(e))
+	}
+	return board, nil
+}
@@ -0,0 +1,167 @@
+package love
+
+import "errors"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+import "time"
+
+func TestComputeLeaderboard(t *testing.T) {
+	now := time.Now()
+	loves := []Love{
+		testLove("hammy", "darwin", now),
+		testLove("hammy", "jeremy", now),
+		testLove("darwin", "jeremy", now),
+		testLove("jeremy", "hammy", now),
+		testLove("hammy", "darwin", now),
+	}
+
+	board := ComputeLeaderboard(loves)
+	assert.Equal(t, board.Senders, []LeaderboardEntry{
+		{Rank: 1, User: "hammy", Sent: 3, Received: 1},
+		{Rank: 2, User: "darwin", Sent: 1, Received: 2},
+		{Rank: 2, User: "jeremy", Sent: 1, Received: 2},
+	})
+	assert.Equal(t, board.Recipients, []LeaderboardEntry{
+		{Rank: 1, User: "darwin", Sent: 1, Received: 2},
+		{Rank: 1, User: "jeremy", Sent: 1, Received: 2},
+		{Rank: 3, User: "hammy", Sent: 3, Received: 1},
+	})
+}
+
+func TestRecentLeaderboard(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	recent := time.Now().UTC().Add(-time.Hour).Format(timestampFormat)
+	old := time.Now().UTC().Add(-30 * 24 * time.Hour).Format(timestampFormat)
+	responses := map[string]string{
+		"sender=hammy": `[
+{"sender": "hammy", "recipient": "darwin", "message": "a", "timestamp": "` + recent + `"},
+{"sender": "hammy", "recipient": "jeremy", "message": "b", "timestamp": "` + old + `"}]`,
+		"recipient=hammy": `[]`,
+		"sender=darwin":   `[]`,
+		"recipient=darwin": `[
+{"sender": "hammy", "recipient": "darwin", "message": "a", "timestamp": "` + recent + `"}]`,
+	}
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			query := req.URL.Query()
+			key := "sender=" + query.Get("sender")
+			if query.Get("recipient") != "" {
+				key = "recipient=" + query.Get("recipient")
+			}
+			return httpmock.NewStringResponse(200, responses[key]), nil
+		},
+	)
+
+	board, err := client.RecentLeaderboard([]string{"hammy", "darwin"},
+		7*24*time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, board.Senders, []LeaderboardEntry{
+		{Rank: 1, User: "hammy", Sent: 1, Received: 0},
+	})
+	assert.Equal(t, board.Recipients, []LeaderboardEntry{
+		{Rank: 1, User: "darwin", Sent: 0, Received: 1},
+	})
+}
+
+func TestPeriodRange(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2024, 3, 6, 15, 0, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	for _, test := range []struct {
+		period       Period
+		since, until time.Time
+	}{
+		{ThisWeek, day(3, 4), day(3, 11)},
+		{LastWeek, day(2, 26), day(3, 4)},
+		{ThisMonth, day(3, 1), day(4, 1)},
+		{LastMonth, day(2, 1), day(3, 1)},
+	} {
+		since, until, err := test.period.Range(now)
+		assert.Nil(t, err)
+		assert.Equal(t, since, test.since, string(test.period))
+		assert.Equal(t, until, test.until, string(test.period))
+	}
+
+	// Sunday belongs to the week which started on Monday.
+	since, _, _ := ThisWeek.Range(day(3, 10))
+	assert.Equal(t, since, day(3, 4))
+	_, _, err := Period("forever").Range(now)
+	assert.NotNil(t, err)
+}
+
+func TestGetLeaderboard(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"GET", testBaseUrl+"/leaderboard",
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, req.URL.Query().Get("period"), "last_week")
+			return httpmock.NewStringResponse(200, `{
+"senders": [{"rank": 1, "username": "hammy", "sent": 3, "received": 1}],
+"recipients": [{"rank": 1, "username": "darwin", "sent": 0, "received": 2},
+{"rank": 2, "username": "hammy", "sent": 3, "received": 1}]}`), nil
+		},
+	)
+
+	client := getTestClient()
+	board, err := client.GetLeaderboard(LastWeek, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, board.Senders, []LeaderboardEntry{
+		{Rank: 1, User: "hammy", Sent: 3, Received: 1},
+	})
+	assert.Equal(t, board.Recipients, []LeaderboardEntry{
+		{Rank: 1, User: "darwin", Sent: 0, Received: 2},
+		{Rank: 2, User: "hammy", Sent: 3, Received: 1},
+	})
+
+	_, err = client.GetLeaderboard(Period("forever"), nil)
+	assert.NotNil(t, err)
+}
+
+func TestGetLeaderboardFallback(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"GET", testBaseUrl+"/leaderboard",
+		httpmock.NewStringResponder(404, "Not Found"),
+	)
+	since, _, _ := ThisWeek.Range(time.Now().UTC())
+	inside := since.Add(time.Minute).Format(timestampFormat)
+	before := since.Add(-time.Minute).Format(timestampFormat)
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("sender") != "hammy" {
+				return httpmock.NewStringResponse(200, "[]"), nil
+			}
+			return httpmock.NewStringResponse(200, `[
+{"sender": "hammy", "recipient": "darwin", "message": "a", "timestamp": "`+inside+`"},
+{"sender": "hammy", "recipient": "jeremy", "message": "b", "timestamp": "`+before+`"}]`), nil
+		},
+	)
+
+	client := getTestClient()
+	board, err := client.GetLeaderboard(ThisWeek, []string{"hammy"})
+	assert.Nil(t, err)
+	assert.Equal(t, board.Senders, []LeaderboardEntry{
+		{Rank: 1, User: "hammy", Sent: 1, Received: 0},
+	})
+	assert.Equal(t, board.Recipients, []LeaderboardEntry{
+		{Rank: 1, User: "darwin", Sent: 0, Received: 1},
+	})
+
+	_, err = client.GetLeaderboard(ThisWeek, nil)
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, apiErr.StatusCode, 404)
+}
@@ -0,0 +1,64 @@
+package love
+
+import "context"
+import "errors"
+import "fmt"
+
+/*
+ErrWrongSender matches a *WrongSenderError with errors.Is.
+*/
+var ErrWrongSender = errors.New("love: wrong sender")
+
+/*
+WrongSenderError is returned when love is sent as Sender by a client locked to
+another user, Locked, with LockSender. No love is sent.
+*/
+type WrongSenderError struct {
+	Sender string
+	Locked string
+}
+
+func (e *WrongSenderError) Error() string {
+	return fmt.Sprintf("love: cannot send love as %s: the client is locked to %s",
+		e.Sender, e.Locked)
+}
+
+func (e *WrongSenderError) Is(target error) bool {
+	return target == ErrWrongSender
+}
+
+/*
+Lock the client to a sender, so that SendLove and the functions built on it
+refuse to send love as anyone else, with a *WrongSenderError. An API key can
+send love as any user, so this keeps a script which means to send as one person
+from sending as another by mistake. An empty sender unlocks the client.
+
+Love may still be sent as another user deliberately, with a context returned by
+AllowImpersonation.
+*/
+func (c *Client) LockSender(sender string) {
+	c.lockedSender = sender
+}
+
+// The context key which allows love to be sent as anyone.
+type impersonationKey struct{}
+
+/*
+Return a context with which love may be sent as any user, even by a client
+locked to one with LockSender:
+
+	client.SendLoveContext(love.AllowImpersonation(ctx), "darwin", "hammy", "thanks!")
+*/
+func AllowImpersonation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, impersonationKey{}, true)
+}
+
+/*
+Check that love may be sent as a sender, by a client which may be locked.
+*/
+func (c *Client) checkSender(ctx context.Context, sender string) error {
+	if c.lockedSender == "" || sender == c.lockedSender || ctx.Value(impersonationKey{}) != nil {
+		return nil
+	}
+	return &WrongSenderError{Sender: sender, Locked: c.lockedSender}
+}
@@ -0,0 +1,37 @@
+package love
+
+import "context"
+import "errors"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "net/http"
+import "testing"
+import "github.com/stretchr/testify/assert"
+
+func TestLockSender(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	sent := 0
+	httpmock.RegisterResponder("POST", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			sent++
+			return httpmock.NewStringResponse(loveCreatedStatusCode, "Love sent!"), nil
+		})
+
+	client := getTestClient()
+	client.LockSender("hammy")
+	assert.Nil(t, client.SendLove("hammy", "darwin", "thanks"))
+
+	err := client.SendLove("darwin", "hammy", "thanks")
+	assert.True(t, errors.Is(err, ErrWrongSender))
+	assert.EqualError(t, err, "love: cannot send love as darwin: the client is locked to hammy")
+	results := client.SendLoveEach("darwin", []string{"hammy", "jeremy"}, "thanks", 2)
+	assert.True(t, errors.Is(results["jeremy"], ErrWrongSender))
+	assert.Equal(t, sent, 1)
+
+	ctx := AllowImpersonation(context.Background())
+	assert.Nil(t, client.SendLoveContext(ctx, "darwin", "hammy", "thanks"))
+	assert.Equal(t, sent, 2)
+
+	client.LockSender("")
+	assert.Nil(t, client.SendLove("darwin", "hammy", "thanks"))
+}
@@ -0,0 +1,49 @@
+package love
+
+import "log/slog"
+import "net/http"
+import "net/url"
+import "strings"
+import "time"
+
+/*
+Return the API endpoint a request made by the client is for, such as "/love", by
+removing the path of the BaseUrl. This is useful for middleware which reports
+on requests, since the URL itself contains the API key.
+*/
+func (c *Client) Endpoint(req *http.Request) string {
+	if base, err := url.Parse(c.BaseUrl); err == nil {
+		return "/" + strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, base.Path), "/")
+	}
+	return req.URL.Path
+}
+
+/*
+Log each request made through next to c.Logger: at debug level when the server
+responds successfully, and at warn level when the request fails or the server
+responds with an error status. The URL is not logged, since it may contain the
+API key, and errors are redacted.
+*/
+func (c *Client) logTransport(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		attrs := []slog.Attr{
+			slog.String("method", req.Method),
+			slog.String("endpoint", c.Endpoint(req)),
+			slog.Duration("duration", time.Since(start)),
+		}
+		level := slog.LevelDebug
+		if err != nil {
+			level = slog.LevelWarn
+			attrs = append(attrs, slog.String("error", c.Redact(err.Error())))
+		} else {
+			if resp.StatusCode >= 400 {
+				level = slog.LevelWarn
+			}
+			attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		}
+		c.Logger.LogAttrs(req.Context(), level, "love API request", attrs...)
+		return resp, err
+	})
+}
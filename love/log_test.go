@@ -0,0 +1,52 @@
+package love
+
+import "bytes"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "log/slog"
+import "strings"
+import "testing"
+import "github.com/stretchr/testify/assert"
+
+func TestLogger(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", testLoveUrl,
+		httpmock.NewStringResponder(200, "[]"))
+	httpmock.RegisterResponder("POST", testLoveUrl,
+		httpmock.NewStringResponder(loveFailedStatusCode, "no such user"))
+
+	var out bytes.Buffer
+	client := getTestClient()
+	client.Logger = slog.New(slog.NewTextHandler(&out,
+		&slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	_, err := client.GetLove("hammy", "", 1)
+	assert.Nil(t, err)
+	assert.NotNil(t, client.SendLove("hammy", "nobody", "thanks"))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, len(lines), 2)
+	assert.Contains(t, lines[0], "level=DEBUG")
+	assert.Contains(t, lines[0], "method=GET endpoint=/love")
+	assert.Contains(t, lines[0], "status=200")
+	assert.Contains(t, lines[1], "level=WARN")
+	assert.Contains(t, lines[1], "method=POST endpoint=/love")
+	assert.Contains(t, lines[1], "status=418")
+	assert.NotContains(t, out.String(), testApiKey)
+}
+
+func TestLoggerLevel(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", testLoveUrl,
+		httpmock.NewStringResponder(200, "[]"))
+
+	var out bytes.Buffer
+	client := getTestClient()
+	client.Logger = slog.New(slog.NewTextHandler(&out, nil))
+	_, err := client.GetLove("hammy", "", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, out.String(), "")
+}
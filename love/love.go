@@ -9,14 +9,16 @@ The API tokens allow you to send love from any user to any user.
 */
 package love
 
+import "context"
 import "encoding/json"
 import "errors"
-import "fmt"
-import "io/ioutil"
+import "io"
+import "log/slog"
 import "net/http"
 import "net/url"
 import "strconv"
 import "strings"
+import "sync"
 import "time"
 
 /*
@@ -48,59 +50,255 @@ const loveCreatedStatusCode = 201
 const loveFailedStatusCode = 418
 const loveBadParamsStatusCode = 422
 
+// The format of timestamps used by the API.
+const timestampFormat = "2006-01-02T15:04:05"
+
+// The request timeout of the HTTP client created by NewClient.
+const DefaultTimeout = 30 * time.Second
+
+/*
+The largest response body the client reads, unless MaxResponseBytes is set.
+This is far more than the server returns for any reasonable request.
+*/
+const DefaultMaxResponseBytes = 32 << 20
+
 /*
 The Client holds necessary state for creating requests to the Yelp Love API.
 ApiKey is generated from the Admin section of the website. BaseUrl should
 include the "api" part, but no trailing slash.
 EG: https://cwrulove.appspot.com/api
+
+Requests are authenticated with the ApiKey, unless Auth is set, in which case
+it authenticates them instead; see Authenticator.
+
+FallbackUrls are other base URLs for the same instance, such as a custom domain
+besides the App Engine one. If a request to BaseUrl fails because the server
+cannot be reached, or is unavailable, it is tried at each fallback in turn,
+and a URL which failed is avoided for the FailoverCooldown. Love is only sent
+again at another URL if no connection was made, so it is never sent twice.
+
+If Breaker is set, it stops the client from making requests while the server
+appears to be down; see CircuitBreaker.
+
+HTTPClient makes every request. Connections are kept alive and reused between
+requests, so a single Client should be shared rather than creating one per
+request. Replace HTTPClient to change timeouts, proxies or connection pool
+limits. If it is nil, http.DefaultClient is used. Every request also passes
+through the Middleware, in order. If Logger is set, each request is logged
+with its endpoint, status and duration, at debug level, or at warn level if it
+failed. The API key is never logged.
+
+The API sends timestamps without a time zone, in the local time of the server.
+Location is the server's time zone, which timestamps are interpreted in and
+returned in. Yelp Love runs on App Engine, where local time is UTC, so a nil
+Location means UTC. Timestamps sent to the server are converted to Location.
+
+Responses larger than MaxResponseBytes (DefaultMaxResponseBytes if it is zero)
+fail with ErrResponseTooLarge, so that a misbehaving server cannot exhaust the
+client's memory. A negative MaxResponseBytes means no limit.
+
+Each request identifies the client in its User-Agent header: UserAgent if it
+is set, and otherwise DefaultUserAgent. Programs built on the package should
+identify themselves, as in client.UserAgent = UserAgent("love-bot/1.2"), so
+that the administrators of an instance can tell them apart.
+
+If AutocompleteCache is set, Autocomplete returns cached results when it can.
+If ResponseCache is set, GetLove and Autocomplete make conditional requests, and
+reuse the previous response when the server says it has not changed.
+
+The server silently ignores recipients who do not exist. If StrictRecipients is
+set, SendLove checks every recipient with ValidateRecipients first, and refuses
+to send any love if one is unknown.
+
+MaxRecipients guards against accidentally sending love to a whole organization.
+If it is greater than zero, love for more distinct recipients than that fails
+with a *TooManyRecipientsError, whether they are given to a single SendLove or
+to SendLoveEach and the like, and no love is sent.
+
+If Groups is set, recipients written as @name, where name is one of the
+Groups, are replaced by the members of the group before love is sent, so that
+a whole team can be thanked at once. MaxRecipients and StrictRecipients apply
+to the members.
+
+Messages are checked with ValidateMessage before they are sent, since the
+server's response to a bad message does not say what is wrong with it. Empty
+messages are always refused. MaxMessageLength limits the length of a message,
+and MessageFilter, if it is set, may refuse a message by returning an error;
+see BlockWords. Emoji shortcodes, such as :tada:, are expanded into the emoji
+they stand for first, as chat users expect, unless MessageOptions say not to.
+
+If DuplicateWindow is greater than zero, SendLove refuses to send the same
+message from the same sender to a recipient who was already sent it within
+that window, with a *DuplicateLoveError, which guards against flaky scripts and
+retries sending love twice. The earlier love is looked up in DuplicateHistory,
+such as the local history, or else in the sender's most recent love in the
+API. If OnDuplicate is set, it is called with the error instead, and the love
+is sent if it returns nil, for instance after warning about it.
+
+A timeout after the server received love looks like a failure, so love which
+is retried, such as from a queue, may be sent twice. If SendRecords is set,
+love sent with an idempotency key (see WithIdempotencyKey) is only sent once
+for each key, unless ForceResend says otherwise.
+
+If DryRun is set, SendLove writes the request it would make to DryRun, with the
+API key redacted, instead of making it. Requests which only read, such as those
+made by GetLove or by StrictRecipients, are still made.
+
+Since an API key can send love as anyone, a client may be locked to a single
+sender with LockSender, and every attempt to send love may be recorded for
+accountability. If Audit is set, SendLove and the functions built
+on it write an AuditEntry to it for each attempt, as a line of JSON, whether the
+love was sent, refused or failed. Open a file for appending to keep a log; see
+ReadAudit.
 */
 type Client struct {
-	ApiKey  string
-	BaseUrl string
+	ApiKey            string
+	BaseUrl           string
+	FallbackUrls      []string
+	Auth              Authenticator
+	HTTPClient        *http.Client
+	Location          *time.Location
+	UserAgent         string
+	AutocompleteCache *AutocompleteCache
+	ResponseCache     *ResponseCache
+	StrictRecipients  bool
+	MaxRecipients     int
+	Groups            Groups
+	MaxMessageLength  int
+	MessageFilter     func(message string) error
+	MessageOptions    MessageOptions
+	DryRun            io.Writer
+	Audit             io.Writer
+	DuplicateWindow   time.Duration
+	DuplicateHistory  LoveQuerier
+	OnDuplicate       func(err *DuplicateLoveError) error
+	SendRecords       SendRecordStore
+	Middleware        []Middleware
+	Logger            *slog.Logger
+	Breaker           *CircuitBreaker
+	MaxResponseBytes  int64
+
+	// Set once GetUser finds that the instance has no users endpoint.
+	noUsersEndpoint int32
+	failover        failoverState
+	auditMutex      sync.Mutex
+	// Set by LockSender.
+	lockedSender string
 }
 
 /*
-A structure representing a Love.
+A structure representing a Love. Values holds the company values the love is
+tagged with, on instances which support them, and is otherwise empty.
+
+Since Values is a slice, Love cannot be compared with == or used as a map key;
+use Key instead.
 */
 type Love struct {
 	Sender    string
 	Recipient string
 	Message   string
 	Timestamp time.Time
+	Values    []string
+}
+
+/*
+A LoveKey identifies a love, and is comparable, unlike Love.
+*/
+type LoveKey struct {
+	Sender    string
+	Recipient string
+	Message   string
+	Timestamp time.Time
 }
 
 /*
-Implementing the UnmarshalJSON interface so that we can parse Love.
+Return the key identifying the love. Two loves with the same sender, recipient,
+message and timestamp are the same love.
+*/
+func (l Love) Key() LoveKey {
+	return LoveKey{l.Sender, l.Recipient, l.Message, l.Timestamp}
+}
+
+/*
+Report whether the love is tagged with a company value, ignoring case.
+*/
+func (l Love) HasValue(value string) bool {
+	for _, v := range l.Values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Implementing the UnmarshalJSON interface so that we can parse Love. Timestamps
+without a time zone are assumed to be UTC.
 */
 func (l *Love) UnmarshalJSON(b []byte) error {
-	var sender, recipient, message, timestamp string
-	var ok bool
-	var dict map[string]string
+	return l.unmarshal(b, time.UTC)
+}
+
+/*
+Implementing the MarshalJSON interface so that Love may be serialized in the
+same form the API returns. The timestamp is written in UTC without a zone, with
+microseconds unless they are zero, like Python's isoformat. Values are only
+written if there are any.
+*/
+func (l Love) MarshalJSON() ([]byte, error) {
+	timestamp := l.Timestamp.UTC()
+	layout := timestampFormat
+	if timestamp.Nanosecond()/1000 != 0 {
+		layout += ".000000"
+	}
+	dict := map[string]interface{}{
+		"sender":    l.Sender,
+		"recipient": l.Recipient,
+		"message":   l.Message,
+		"timestamp": timestamp.Format(layout),
+	}
+	if len(l.Values) > 0 {
+		dict["values"] = l.Values
+	}
+	return json.Marshal(dict)
+}
+
+/*
+Parse a Love, interpreting its timestamp in loc.
+*/
+func (l *Love) unmarshal(b []byte, loc *time.Location) error {
+	var dict struct {
+		Sender    *string
+		Recipient *string
+		Message   *string
+		Timestamp *string
+		Values    []string
+	}
 	if err := json.Unmarshal(b, &dict); err != nil {
 		return err
 	}
 
-	if sender, ok = dict["sender"]; !ok {
+	if dict.Sender == nil {
 		return errors.New("missing key sender")
 	}
-	if recipient, ok = dict["recipient"]; !ok {
+	if dict.Recipient == nil {
 		return errors.New("missing key recipient")
 	}
-	if message, ok = dict["message"]; !ok {
+	if dict.Message == nil {
 		return errors.New("missing key message")
 	}
-	if timestamp, ok = dict["timestamp"]; !ok {
+	if dict.Timestamp == nil {
 		return errors.New("missing key timestamp")
 	}
 
 	var err error
-	l.Timestamp, err = time.Parse("2006-01-02T15:04:05", timestamp)
-	if err != nil {
-		return errors.New("invalid timestamp encoding")
+	if l.Timestamp, err = parseTimestamp(*dict.Timestamp, loc); err != nil {
+		return err
 	}
-	l.Recipient = recipient
-	l.Message = message
-	l.Sender = sender
+	l.Recipient = *dict.Recipient
+	l.Message = *dict.Message
+	l.Sender = *dict.Sender
+	l.Values = dict.Values
 	return nil
 }
 
@@ -132,15 +330,44 @@ func (u *User) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+/*
+Implements the JSON Marshalling interface so that Users are serialized in the
+same form Autocomplete returns them.
+*/
+func (u User) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"label": u.Display,
+		"value": u.Username,
+	})
+}
+
 /*
 Create a Client. See documentation of Client for more details on the
 arguments.
 */
 func NewClient(ApiKey string, BaseUrl string) *Client {
 	return &Client{
-		ApiKey:  ApiKey,
-		BaseUrl: BaseUrl,
+		ApiKey:     ApiKey,
+		BaseUrl:    BaseUrl,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+/*
+Return the http.Client which makes every request, with its transport wrapped in
+the client's Middleware.
+*/
+func (c *Client) httpClient() *http.Client {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if len(c.Middleware) == 0 && c.Logger == nil {
+		return client
 	}
+	wrapped := *client
+	wrapped.Transport = c.transport(client.Transport)
+	return &wrapped
 }
 
 /*
@@ -152,37 +379,45 @@ setting it to some sensible default like 20 is highly encouraged, to avoid
 overloading the server. A hard maximum of 2000 love is likely.
 */
 func (c *Client) GetLove(from string, to string, limit int64) ([]Love, error) {
+	return c.GetLoveContext(context.Background(), from, to, limit)
+}
+
+/*
+GetLoveContext is like GetLove, but the request is made with a context, which
+may cancel it, and which is available to Middleware.
+*/
+func (c *Client) GetLoveContext(ctx context.Context, from string, to string,
+	limit int64) ([]Love, error) {
+	return c.getLove(ctx, LoveFilter{Sender: from, Recipient: to, Limit: limit}, 0)
+}
+
+/*
+Perform a single request to the love endpoint. Every field of the filter is sent
+to the server, but no filtering is done on the client. When offset is greater
+than zero, it is sent along so that the server skips that many love.
+*/
+func (c *Client) getLove(ctx context.Context, f LoveFilter, offset int64) ([]Love, error) {
 	var err error
-	var resp *http.Response
-	var body []byte
-	var loves []Love
-	if from == "" && to == "" {
+	var raw []json.RawMessage
+	if f.Sender == "" && f.Recipient == "" {
 		return nil, errors.New("Must specify at least one of `from` and `to`")
 	}
 	values := make(url.Values)
-	values.Set("api_key", c.ApiKey)
-	if from != "" {
-		values.Set("sender", from)
-	}
-	if to != "" {
-		values.Set("recipient", to)
-	}
-	if limit > 0 {
-		values.Set("limit", strconv.FormatInt(limit, 10))
+	f.encode(values, c.location())
+	if offset > 0 {
+		values.Set("offset", strconv.FormatInt(offset, 10))
 	}
-	finalUrl := c.BaseUrl + "/love?" + values.Encode()
-	if resp, err = http.Get(finalUrl); err != nil {
+	if err = c.getJSON(ctx, getRequest("/love", values), &raw); err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != 200 {
-		return nil, errors.New(resp.Status)
+	if raw == nil {
+		return nil, nil
 	}
-	defer resp.Body.Close()
-	if body, err = ioutil.ReadAll(resp.Body); err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(body, &loves); err != nil {
-		return nil, err
+	loves := make([]Love, len(raw))
+	for i := range raw {
+		if err = loves[i].unmarshal(raw[i], c.location()); err != nil {
+			return nil, err
+		}
 	}
 	return loves, nil
 }
@@ -193,25 +428,126 @@ single string. In fact, the recipient may actually be several usernames
 separated by commas.
 */
 func (c *Client) SendLove(from string, to string, message string) error {
+	return c.SendLoveContext(context.Background(), from, to, message)
+}
+
+/*
+SendLoveContext is like SendLove, but the request is made with a context, which
+may cancel it, and which is available to Middleware.
+*/
+func (c *Client) SendLoveContext(ctx context.Context, from string, to string,
+	message string) error {
+	return c.SendLoveValuesContext(ctx, from, to, message, nil)
+}
+
+/*
+Send love tagged with company values, for instances which support them. Each
+value is sent in a values form field. Instances which do not support values
+ignore them, and send the love untagged.
+*/
+func (c *Client) SendLoveValues(from string, to string, message string,
+	companyValues []string) error {
+	return c.SendLoveValuesContext(context.Background(), from, to, message, companyValues)
+}
+
+/*
+SendLoveValuesContext is like SendLoveValues, but the request is made with a
+context, as in SendLoveContext.
+*/
+func (c *Client) SendLoveValuesContext(ctx context.Context, from string, to string,
+	message string, companyValues []string) error {
+	if c.Audit == nil {
+		return c.sendLove(ctx, from, to, message, companyValues, &AuditEntry{})
+	}
+	entry := &AuditEntry{
+		Time:       time.Now(),
+		Sender:     from,
+		Recipients: strings.Split(to, ","),
+		Message:    message,
+		Values:     companyValues,
+		DryRun:     c.DryRun != nil,
+	}
+	err := c.sendLove(countRetries(ctx, &entry.Retries), from, to, message, companyValues, entry)
+	if err != nil {
+		entry.Error = c.Redact(err.Error())
+	}
+	c.audit(ctx, entry)
+	return err
+}
+
+/*
+Send love, recording what was sent in entry.
+*/
+func (c *Client) sendLove(ctx context.Context, from string, to string,
+	message string, companyValues []string, entry *AuditEntry) error {
 	var err error
 	var resp *http.Response
-	finalUrl := c.BaseUrl + "/love"
+	if err = c.checkSender(ctx, from); err != nil {
+		return err
+	}
+	if message, err = c.ValidateMessage(message); err != nil {
+		return err
+	}
+	entry.Message = message
+	expanded, err := c.expandGroups(strings.Split(to, ","))
+	if err != nil {
+		return err
+	}
+	var recipients []string
+	for _, name := range expanded {
+		if name = strings.TrimSpace(name); name != "" {
+			recipients = append(recipients, name)
+		}
+	}
+	entry.Recipients = recipients
+	to = strings.Join(recipients, ",")
+	if err = c.checkRecipientCount(recipients); err != nil {
+		return err
+	}
+	if c.StrictRecipients {
+		if err = c.checkRecipients(ctx, recipients); err != nil {
+			return err
+		}
+	}
+	record, err := c.sendRecord(ctx, from, recipients, message)
+	if err != nil {
+		return err
+	}
+	if record != nil {
+		entry.Key = record.Key
+		if record.Sent {
+			entry.AlreadySent = true
+			return nil
+		}
+	}
+	if err = c.checkDuplicate(ctx, from, recipients, message); err != nil {
+		return err
+	}
 	values := make(url.Values)
-	values.Set("api_key", c.ApiKey)
 	values.Set("sender", from)
 	values.Set("recipient", to)
 	values.Set("message", message)
-	if resp, err = http.PostForm(finalUrl, values); err != nil {
-		return err
+	for _, value := range companyValues {
+		values.Add("values", value)
+	}
+	r := &apiRequest{method: "POST", endpoint: "/love", form: values}
+	if c.DryRun != nil {
+		return c.writeDryRun(r)
 	}
-	if resp.StatusCode != loveCreatedStatusCode {
-		defer resp.Body.Close()
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return err // who knows what error this could be
+	if record != nil {
+		if err = c.SendRecords.PutSendRecord(record); err != nil {
+			return err
 		}
-		return fmt.Errorf("Love API Error: %s", body)
 	}
+	if resp, err = c.do(ctx, r); err != nil {
+		return err
+	}
+	entry.Status = resp.StatusCode
+	if err = checkStatus("/love", resp, loveCreatedStatusCode); err != nil {
+		return err
+	}
+	resp.Body.Close()
+	c.recordSent(ctx, record)
 	return nil
 }
 
@@ -225,29 +561,43 @@ func (c *Client) SendLoves(from string, to []string, message string) error {
 
 /*
 Return completions for a given string. The completions could come from the
-username, first, or last name of a user.
+username, first, or last name of a user. If the client has an
+AutocompleteCache holding a fresh result for the term, no request is made.
 */
 func (c *Client) Autocomplete(term string) ([]User, error) {
-	var err error
-	var resp *http.Response
-	var body []byte
+	return c.AutocompleteContext(context.Background(), term)
+}
+
+/*
+AutocompleteContext is like Autocomplete, but any request is made with a
+context, which may cancel it, and which is available to Middleware.
+*/
+func (c *Client) AutocompleteContext(ctx context.Context, term string) ([]User, error) {
+	if c.AutocompleteCache != nil {
+		if users, ok := c.AutocompleteCache.Get(term); ok {
+			return users, nil
+		}
+	}
+	return c.refreshAutocomplete(ctx, term)
+}
+
+/*
+Return completions for a given string from the server, bypassing the
+AutocompleteCache. The cache, if any, is updated with the result.
+*/
+func (c *Client) RefreshAutocomplete(term string) ([]User, error) {
+	return c.refreshAutocomplete(context.Background(), term)
+}
+
+func (c *Client) refreshAutocomplete(ctx context.Context, term string) ([]User, error) {
 	var users []User
 	values := make(url.Values)
-	values.Set("api_key", c.ApiKey)
 	values.Set("term", term)
-	finalUrl := c.BaseUrl + "/autocomplete?" + values.Encode()
-	if resp, err = http.Get(finalUrl); err != nil {
+	if err := c.getJSON(ctx, getRequest("/autocomplete", values), &users); err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != loveGetStatusCode {
-		return nil, errors.New(resp.Status)
-	}
-	defer resp.Body.Close()
-	if body, err = ioutil.ReadAll(resp.Body); err != nil {
-		return nil, err
-	}
-	if err = json.Unmarshal(body, &users); err != nil {
-		return nil, err
+	if c.AutocompleteCache != nil {
+		c.AutocompleteCache.Put(term, users)
 	}
 	return users, nil
 }
@@ -1,10 +1,14 @@
 package love
 
+import "context"
+import "encoding/json"
+import "errors"
 import "gopkg.in/jarcoal/httpmock.v1"
 import "testing"
 import "github.com/stretchr/testify/assert"
 import "io/ioutil"
 import "net/http"
+import "net/http/httptest"
 import "net/url"
 import "time"
 
@@ -69,6 +73,44 @@ func TestNewClient(t *testing.T) {
 	client := getTestClient()
 	assert.Equal(t, client.ApiKey, testApiKey)
 	assert.Equal(t, client.BaseUrl, testBaseUrl)
+	assert.NotNil(t, client.HTTPClient)
+	assert.Equal(t, client.HTTPClient.Timeout, DefaultTimeout)
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return httpmock.NewStringResponse(200, "[]"), nil
+}
+
+func TestCustomHTTPClient(t *testing.T) {
+	transport := &countingTransport{}
+	client := getTestClient()
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	_, err := client.GetLove("hammy", "", 0)
+	assert.Nil(t, err)
+	_, err = client.Autocomplete("ha")
+	assert.Nil(t, err)
+	assert.Equal(t, transport.requests, 2)
+}
+
+func TestZeroClient(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := &Client{ApiKey: testApiKey, BaseUrl: testBaseUrl}
+	httpmock.RegisterResponder(
+		"GET", testAutocompleteUrl,
+		httpmock.NewStringResponder(200, "[]"),
+	)
+
+	users, err := client.Autocomplete("ha")
+	assert.Nil(t, err)
+	assert.Equal(t, len(users), 0)
 }
 
 func TestGetLoveOnlySender(t *testing.T) {
@@ -368,3 +410,118 @@ func TestAutocompleteNon200(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Nil(t, users)
 }
+
+func TestLoveMarshalRoundTrip(t *testing.T) {
+	var loves []Love
+	err := json.Unmarshal([]byte(twoGetLoveResponse), &loves)
+	assert.Nil(t, err)
+
+	encoded, err := json.Marshal(loves)
+	assert.Nil(t, err)
+	assert.Contains(t, string(encoded), `"timestamp":"2000-01-01T01:01:01.552636"`)
+	assert.Contains(t, string(encoded), `"timestamp":"2000-02-01T01:01:01"`)
+
+	var decoded []Love
+	err = json.Unmarshal(encoded, &decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, decoded, loves)
+}
+
+func TestLoveMarshalUTC(t *testing.T) {
+	l := Love{
+		Sender:    "hammy",
+		Recipient: "darwin",
+		Message:   "message",
+		Timestamp: time.Date(2000, 1, 1, 1, 0, 0, 0, time.FixedZone("X", 3600)),
+	}
+	encoded, err := json.Marshal(l)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(encoded), `{"sender": "hammy", "recipient": "darwin",
+"message": "message", "timestamp": "2000-01-01T00:00:00"}`)
+}
+
+func TestLoveValues(t *testing.T) {
+	var l Love
+	err := json.Unmarshal([]byte(`{"sender": "hammy", "recipient": "darwin",
+"message": "message", "timestamp": "2000-01-01T00:00:00",
+"values": ["Be Humble", "Ownership"]}`), &l)
+	assert.Nil(t, err)
+	assert.Equal(t, l.Values, []string{"Be Humble", "Ownership"})
+	assert.True(t, l.HasValue("be humble"))
+	assert.False(t, l.HasValue("humble"))
+
+	encoded, err := json.Marshal(l)
+	assert.Nil(t, err)
+	assert.Contains(t, string(encoded), `"values":["Be Humble","Ownership"]`)
+
+	untagged := l
+	untagged.Values = nil
+	assert.Equal(t, l.Key(), untagged.Key())
+	err = json.Unmarshal([]byte(`{"sender": "hammy", "recipient": "darwin",
+"message": 1, "timestamp": "2000-01-01T00:00:00"}`), &l)
+	assert.NotNil(t, err)
+}
+
+func TestSendLoveValues(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var sent url.Values
+	httpmock.RegisterResponder(
+		"POST", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			sent, _ = url.ParseQuery(string(body))
+			return httpmock.NewStringResponse(201, "Love sent!"), nil
+		},
+	)
+
+	client := getTestClient()
+	err := client.SendLoveValues("hammy", "darwin", "message", []string{"Be Humble", "Ownership"})
+	assert.Nil(t, err)
+	assert.Equal(t, sent["values"], []string{"Be Humble", "Ownership"})
+
+	err = client.SendLove("hammy", "darwin", "message")
+	assert.Nil(t, err)
+	assert.Nil(t, sent["values"])
+}
+
+func TestUserMarshalRoundTrip(t *testing.T) {
+	user := User{Display: "Hammy Havoc (hammy)", Username: "hammy"}
+	encoded, err := json.Marshal(user)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(encoded), `{"label": "Hammy Havoc (hammy)", "value": "hammy"}`)
+
+	var decoded User
+	err = json.Unmarshal(encoded, &decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, decoded, user)
+}
+
+func TestContextCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				w.WriteHeader(201)
+			} else {
+				w.Write([]byte("[]"))
+			}
+		}))
+	defer server.Close()
+	client := NewClient(testApiKey, server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(t, client.SendLoveContext(ctx, "hammy", "darwin", "thanks"))
+	_, err := client.GetLoveContext(ctx, "hammy", "", 1)
+	assert.Nil(t, err)
+
+	cancel()
+	err = client.SendLoveContext(ctx, "hammy", "darwin", "thanks")
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.NotContains(t, err.Error(), testApiKey)
+	_, err = client.GetLoveContext(ctx, "hammy", "", 1)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.NotContains(t, err.Error(), testApiKey)
+	_, err = client.AutocompleteContext(ctx, "dar")
+	assert.True(t, errors.Is(err, context.Canceled))
+}
@@ -0,0 +1,192 @@
+package love
+
+import "html"
+import "regexp"
+import "strings"
+import "unicode/utf8"
+
+/*
+A MarkdownSpan is a run of text in a message with the same formatting. Link is
+the URL the text links to, if any.
+*/
+type MarkdownSpan struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Link   string
+}
+
+/*
+A kind of markdown formatting, found by a pattern whose first group is the
+formatted text.
+*/
+type markdownFormat struct {
+	pattern *regexp.Regexp
+	// Underscores only format whole words, so snake_case and shortcodes
+	// such as :white_check_mark: are left alone.
+	wordBoundary bool
+	apply        func(span *MarkdownSpan, match []string)
+}
+
+var markdownFormats = []markdownFormat{
+	{
+		// Only web and email links, so that rendering them is safe.
+		pattern: regexp.MustCompile(`\[([^\[\]\n]+)\]\(((?:https?://|mailto:)[^\s()]+)\)`),
+		apply:   func(span *MarkdownSpan, match []string) { span.Link = match[2] },
+	},
+	{
+		pattern: regexp.MustCompile(`\*\*([^\s*](?:[^\n]*?[^\s*])?)\*\*`),
+		apply:   func(span *MarkdownSpan, match []string) { span.Bold = true },
+	},
+	{
+		pattern:      regexp.MustCompile(`__([^\s_](?:[^\n]*?[^\s_])?)__`),
+		wordBoundary: true,
+		apply:        func(span *MarkdownSpan, match []string) { span.Bold = true },
+	},
+	{
+		pattern: regexp.MustCompile(`\*([^\s*](?:[^*\n]*?[^\s*])?)\*`),
+		apply:   func(span *MarkdownSpan, match []string) { span.Italic = true },
+	},
+	{
+		pattern:      regexp.MustCompile(`_([^\s_](?:[^_\n]*?[^\s_])?)_`),
+		wordBoundary: true,
+		apply:        func(span *MarkdownSpan, match []string) { span.Italic = true },
+	},
+}
+
+/*
+Return the first match of the format in text, as submatch indexes, or nil.
+*/
+func (f markdownFormat) find(text string) []int {
+	for offset := 0; offset < len(text); {
+		loc := f.pattern.FindStringSubmatchIndex(text[offset:])
+		if loc == nil {
+			return nil
+		}
+		for i := range loc {
+			if loc[i] >= 0 {
+				loc[i] += offset
+			}
+		}
+		if !f.wordBoundary || !isWordBefore(text, loc[0]) && !isWordAfter(text, loc[1]) {
+			return loc
+		}
+		offset = loc[0] + 1
+	}
+	return nil
+}
+
+func isWordBefore(text string, i int) bool {
+	r, size := utf8.DecodeLastRuneInString(text[:i])
+	return size > 0 && isWordRune(r)
+}
+
+func isWordAfter(text string, i int) bool {
+	r, size := utf8.DecodeRuneInString(text[i:])
+	return size > 0 && isWordRune(r)
+}
+
+/*
+ParseMarkdown splits a message into spans of the light markdown some instances
+allow: **bold** or __bold__, *italic* or _italic_, and [links](https://...).
+Anything else, including links to other kinds of URL, is plain text.
+*/
+func ParseMarkdown(message string) []MarkdownSpan {
+	return parseMarkdown(message, MarkdownSpan{}, nil)
+}
+
+func parseMarkdown(text string, style MarkdownSpan, spans []MarkdownSpan) []MarkdownSpan {
+	for text != "" {
+		var format *markdownFormat
+		var loc []int
+		for i := range markdownFormats {
+			if l := markdownFormats[i].find(text); l != nil && (loc == nil || l[0] < loc[0]) {
+				format, loc = &markdownFormats[i], l
+			}
+		}
+		if loc == nil {
+			break
+		}
+		match := make([]string, len(loc)/2)
+		for i := range match {
+			if loc[2*i] >= 0 {
+				match[i] = text[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		spans = appendSpan(spans, style, text[:loc[0]])
+		inner := style
+		format.apply(&inner, match)
+		spans = parseMarkdown(match[1], inner, spans)
+		text = text[loc[1]:]
+	}
+	return appendSpan(spans, style, text)
+}
+
+/*
+Append text in a style to spans, joining it to the last span if it has the same
+style.
+*/
+func appendSpan(spans []MarkdownSpan, style MarkdownSpan, text string) []MarkdownSpan {
+	if text == "" {
+		return spans
+	}
+	if n := len(spans); n > 0 {
+		last := &spans[n-1]
+		if last.Bold == style.Bold && last.Italic == style.Italic && last.Link == style.Link {
+			last.Text += text
+			return spans
+		}
+	}
+	style.Text = text
+	return append(spans, style)
+}
+
+/*
+MarkdownText renders the markdown in a message as plain text, for places which
+cannot show formatting: the markers are removed, and links are followed by their
+URLs in parentheses, unless the text is the URL.
+*/
+func MarkdownText(message string) string {
+	var out strings.Builder
+	spans := ParseMarkdown(message)
+	linkText := ""
+	for i, span := range spans {
+		out.WriteString(span.Text)
+		if span.Link == "" {
+			continue
+		}
+		// The text of a link may be split into several spans.
+		linkText += span.Text
+		if i+1 < len(spans) && spans[i+1].Link == span.Link {
+			continue
+		}
+		if linkText != span.Link && linkText != strings.TrimPrefix(span.Link, "mailto:") {
+			out.WriteString(" (" + span.Link + ")")
+		}
+		linkText = ""
+	}
+	return out.String()
+}
+
+/*
+MarkdownHTML renders the markdown in a message as HTML, with <strong>, <em> and
+<a> elements, and line breaks as <br>. Everything else is escaped, so the
+result is safe to include in a page.
+*/
+func MarkdownHTML(message string) string {
+	var out strings.Builder
+	for _, span := range ParseMarkdown(message) {
+		text := strings.ReplaceAll(html.EscapeString(span.Text), "\n", "<br>\n")
+		if span.Italic {
+			text = "<em>" + text + "</em>"
+		}
+		if span.Bold {
+			text = "<strong>" + text + "</strong>"
+		}
+		if span.Link != "" {
+			text = `<a href="` + html.EscapeString(span.Link) + `">` + text + "</a>"
+		}
+		out.WriteString(text)
+	}
+	return out.String()
+}
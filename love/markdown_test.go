@@ -0,0 +1,47 @@
+package love
+
+import "testing"
+import "github.com/stretchr/testify/assert"
+
+func TestParseMarkdown(t *testing.T) {
+	assert.Equal(t, ParseMarkdown("thanks for **all** the *help*, see [the doc](https://example.com/doc)"),
+		[]MarkdownSpan{
+			{Text: "thanks for "},
+			{Text: "all", Bold: true},
+			{Text: " the "},
+			{Text: "help", Italic: true},
+			{Text: ", see "},
+			{Text: "the doc", Link: "https://example.com/doc"},
+		})
+	assert.Equal(t, ParseMarkdown("__really *very* good__"), []MarkdownSpan{
+		{Text: "really ", Bold: true},
+		{Text: "very", Bold: true, Italic: true},
+		{Text: " good", Bold: true},
+	})
+	for _, plain := range []string{
+		"snake_case_names and :white_check_mark:",
+		"2 * 3 * 4",
+		"** not bold **",
+		"[click](javascript:alert(1))",
+		"",
+	} {
+		spans := ParseMarkdown(plain)
+		assert.Equal(t, MarkdownText(plain), plain, plain)
+		assert.True(t, len(spans) <= 1, plain)
+	}
+}
+
+func TestMarkdownText(t *testing.T) {
+	assert.Equal(t, MarkdownText("**great** [demo](https://example.com) by [https://x.io](https://x.io)"),
+		"great demo (https://example.com) by https://x.io")
+	assert.Equal(t, MarkdownText("mail [me@example.com](mailto:me@example.com)"),
+		"mail me@example.com")
+	assert.Equal(t, MarkdownText("[**big** news](https://example.com)!"),
+		"big news (https://example.com)!")
+}
+
+func TestMarkdownHTML(t *testing.T) {
+	assert.Equal(t, MarkdownHTML("<b>**bold** & _it_</b>\n[a \"link\"](https://example.com/?a=1&b=2)"),
+		"&lt;b&gt;<strong>bold</strong> &amp; <em>it</em>&lt;/b&gt;<br>\n"+
+			`<a href="https://example.com/?a=1&amp;b=2">a &#34;link&#34;</a>`)
+}
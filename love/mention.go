@@ -0,0 +1,125 @@
+package love
+
+import "strings"
+import "unicode"
+
+/*
+ExtractMentions returns the usernames mentioned in a message, as @username,
+without the @ and in order of first appearance. A mention must not follow a
+letter or digit, so email addresses are not mentions. Usernames consist of
+letters, digits, dots, dashes and underscores, though a trailing dot is taken to
+end the sentence.
+*/
+func ExtractMentions(message string) []string {
+	return extract(message, '@', isMentionRune, false)
+}
+
+func isMentionRune(r rune) bool {
+	return isWordRune(r) || r == '.' || r == '-'
+}
+
+/*
+ExtractHashtags returns the tags in a message, as #tag, in lower case, without
+the # and in order of first appearance. Like mentions, a tag must not follow a
+letter or digit. Tags consist of letters, digits, dashes and underscores, and
+must contain a letter, so "#1" is not a tag.
+*/
+func ExtractHashtags(message string) []string {
+	return extract(message, '#', func(r rune) bool {
+		return isWordRune(r) || r == '-'
+	}, true)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+/*
+Extract the distinct words in a message which follow a marker, consisting of
+the runes accepted by valid.
+*/
+func extract(message string, marker rune, valid func(rune) bool, lower bool) []string {
+	var words []string
+	seen := make(map[string]bool)
+	scan([]rune(message), marker, valid, func(start, end int, word string) {
+		if lower {
+			word = strings.ToLower(word)
+		}
+		if word == "" || seen[word] || marker == '#' && strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			return
+		}
+		seen[word] = true
+		words = append(words, word)
+	})
+	return words
+}
+
+/*
+Call found with each word in runes which follows a marker, consisting of the
+runes accepted by valid, along with the indexes of its marker and of the rune
+after it. A trailing dot or dash is not part of a word.
+*/
+func scan(runes []rune, marker rune, valid func(rune) bool, found func(start, end int, word string)) {
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != marker || i > 0 && isWordRune(runes[i-1]) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && valid(runes[end]) {
+			end++
+		}
+		word := strings.TrimRight(string(runes[i+1:end]), ".-")
+		found(i, i+1+len([]rune(word)), word)
+		i = end - 1
+	}
+}
+
+/*
+ReplaceMentions returns a message with each username mentioned in it, as found
+by ExtractMentions, replaced by the result of calling replace with it. The @ is
+kept.
+*/
+func ReplaceMentions(message string, replace func(username string) string) string {
+	var out strings.Builder
+	runes := []rune(message)
+	last := 0
+	scan(runes, '@', isMentionRune, func(start, end int, word string) {
+		if word == "" {
+			return
+		}
+		out.WriteString(string(runes[last : start+1]))
+		out.WriteString(replace(word))
+		last = end
+	})
+	out.WriteString(string(runes[last:]))
+	return out.String()
+}
+
+/*
+Mentions returns the usernames mentioned in the message of a love. See
+ExtractMentions.
+*/
+func (l Love) Mentions() []string {
+	return ExtractMentions(l.Message)
+}
+
+/*
+Hashtags returns the tags in the message of a love. See ExtractHashtags.
+*/
+func (l Love) Hashtags() []string {
+	return ExtractHashtags(l.Message)
+}
+
+/*
+HasHashtag reports whether the message of a love is tagged with tag, ignoring
+case. A leading # in tag is ignored.
+*/
+func (l Love) HasHashtag(tag string) bool {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	for _, t := range l.Hashtags() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package love
+
+import "strings"
+import "testing"
+import "github.com/stretchr/testify/assert"
+
+func TestExtractMentions(t *testing.T) {
+	assert.Equal(t, []string{"hammy", "jeremy.w"},
+		ExtractMentions("thanks @hammy and @jeremy.w. Also @hammy!"))
+	assert.Equal(t, []string{"d_arwin-2"}, ExtractMentions("(@d_arwin-2)"))
+	assert.Nil(t, ExtractMentions("email hammy@example.com"))
+	assert.Nil(t, ExtractMentions("@ alone"))
+	assert.Nil(t, ExtractMentions(""))
+}
+
+func TestReplaceMentions(t *testing.T) {
+	upper := func(username string) string { return strings.ToUpper(username) }
+	assert.Equal(t, "thanks @HAMMY and @JEREMY.W. Also @HAMMY!",
+		ReplaceMentions("thanks @hammy and @jeremy.w. Also @hammy!", upper))
+	assert.Equal(t, "email hammy@example.com, @ alone, (@DARWIN)",
+		ReplaceMentions("email hammy@example.com, @ alone, (@darwin)", upper))
+	assert.Equal(t, "", ReplaceMentions("", upper))
+}
+
+func TestExtractHashtags(t *testing.T) {
+	assert.Equal(t, []string{"teamwork", "über-helpful"},
+		ExtractHashtags("#TeamWork, #Über-helpful and #teamwork again"))
+	assert.Equal(t, []string{"ship_it"}, ExtractHashtags("great job #ship_it."))
+	assert.Nil(t, ExtractHashtags("we're #1 and issue#42"))
+	assert.Nil(t, ExtractHashtags("# heading"))
+}
+
+func TestLoveHashtags(t *testing.T) {
+	l := Love{Message: "@darwin nice work #Teamwork #oncall"}
+	assert.Equal(t, []string{"darwin"}, l.Mentions())
+	assert.Equal(t, []string{"teamwork", "oncall"}, l.Hashtags())
+	assert.True(t, l.HasHashtag("teamwork"))
+	assert.True(t, l.HasHashtag("#TEAMWORK"))
+	assert.False(t, l.HasHashtag("team"))
+}
@@ -0,0 +1,44 @@
+package love
+
+import "sort"
+
+/*
+Sort love newest first, the order in which the API returns it. Love sent at the
+same time keeps its order.
+*/
+func SortByTimestamp(loves []Love) {
+	sort.SliceStable(loves, func(i, j int) bool {
+		return loves[i].Timestamp.After(loves[j].Timestamp)
+	})
+}
+
+/*
+Return the love without duplicates, keeping the first of each, in order. Two
+loves are the same if they have the same Key: the same sender, recipient,
+message and timestamp. The slice passed in is not modified.
+*/
+func Dedupe(loves []Love) []Love {
+	seen := make(map[LoveKey]bool, len(loves))
+	unique := make([]Love, 0, len(loves))
+	for _, l := range loves {
+		if !seen[l.Key()] {
+			seen[l.Key()] = true
+			unique = append(unique, l)
+		}
+	}
+	return unique
+}
+
+/*
+Combine the results of several queries, such as the love sent and received by a
+user, into a single list without duplicates, newest first.
+*/
+func Merge(lists ...[]Love) []Love {
+	var all []Love
+	for _, loves := range lists {
+		all = append(all, loves...)
+	}
+	merged := Dedupe(all)
+	SortByTimestamp(merged)
+	return merged
+}
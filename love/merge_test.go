@@ -0,0 +1,52 @@
+package love
+
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "time"
+
+func TestSortByTimestamp(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2017, 1, day, 0, 0, 0, 0, time.UTC) }
+	loves := []Love{
+		{Message: "a", Timestamp: at(1)},
+		{Message: "b", Timestamp: at(3)},
+		{Message: "c", Timestamp: at(1)},
+		{Message: "d", Timestamp: at(2)},
+	}
+	SortByTimestamp(loves)
+	assert.Equal(t, messages(loves), []string{"b", "d", "a", "c"})
+}
+
+func TestDedupe(t *testing.T) {
+	now := time.Now()
+	loves := []Love{
+		{"hammy", "darwin", "thanks", now, []string{"Teamwork"}},
+		{"hammy", "darwin", "thanks", now.Add(time.Second), nil},
+		{"hammy", "darwin", "thanks", now, nil},
+		{"hammy", "jeremy", "thanks", now, nil},
+	}
+	unique := Dedupe(loves)
+	assert.Equal(t, unique, []Love{loves[0], loves[1], loves[3]})
+	assert.Len(t, loves, 4)
+	assert.Equal(t, Dedupe(nil), []Love{})
+}
+
+func TestMerge(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2017, 1, day, 0, 0, 0, 0, time.UTC) }
+	sent := []Love{
+		{"hammy", "darwin", "a", at(2), nil},
+		{"hammy", "jeremy", "b", at(1), nil},
+	}
+	received := []Love{
+		{"darwin", "hammy", "c", at(3), nil},
+		{"hammy", "darwin", "a", at(2), nil},
+	}
+	assert.Equal(t, messages(Merge(sent, received)), []string{"c", "a", "b"})
+}
+
+func messages(loves []Love) []string {
+	result := make([]string, len(loves))
+	for i, l := range loves {
+		result[i] = l.Message
+	}
+	return result
+}
@@ -0,0 +1,119 @@
+package love
+
+import "errors"
+import "fmt"
+import "regexp"
+import "strings"
+import "unicode"
+import "unicode/utf8"
+
+/*
+ErrInvalidMessage matches an *InvalidMessageError with errors.Is.
+*/
+var ErrInvalidMessage = errors.New("love: invalid message")
+
+/*
+InvalidMessageError is returned by SendLove when the message is rejected before
+it is sent, because it is empty, too long, or refused by the client's
+MessageFilter, whose error is Err. Reason describes the problem. It also
+matches ErrBadParams, since the server would have rejected the message as a bad
+parameter, with less detail.
+*/
+type InvalidMessageError struct {
+	Reason string
+	Err    error
+}
+
+func (e *InvalidMessageError) Error() string {
+	return "invalid message: " + e.Reason
+}
+
+func (e *InvalidMessageError) Is(target error) bool {
+	return target == ErrInvalidMessage || target == ErrBadParams
+}
+
+func (e *InvalidMessageError) Unwrap() error {
+	return e.Err
+}
+
+/*
+Return the message with surrounding whitespace trimmed, line endings converted
+to "\n", and other control characters, apart from tabs, removed.
+*/
+func CleanMessage(message string) string {
+	message = strings.ReplaceAll(message, "\r\n", "\n")
+	message = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, message)
+	return strings.TrimSpace(message)
+}
+
+/*
+MessageOptions control how a Client prepares messages before sending them. The
+zero value expands emoji shortcodes.
+*/
+type MessageOptions struct {
+	// Send emoji shortcodes, such as :tada:, as they are written, rather
+	// than expanding them with ExpandShortcodes.
+	KeepShortcodes bool
+}
+
+/*
+Prepare a message according to the options.
+*/
+func (o MessageOptions) apply(message string) string {
+	if !o.KeepShortcodes {
+		message = ExpandShortcodes(message)
+	}
+	return message
+}
+
+/*
+Check a message before it is sent, returning it cleaned by CleanMessage and
+prepared according to MessageOptions. An empty message, one longer than
+MaxMessageLength characters (if it is greater than zero), or one refused by
+MessageFilter (if it is set) fails with an *InvalidMessageError.
+*/
+func (c *Client) ValidateMessage(message string) (string, error) {
+	message = c.MessageOptions.apply(CleanMessage(message))
+	if message == "" {
+		return "", &InvalidMessageError{Reason: "the message is empty"}
+	}
+	if n := utf8.RuneCountInString(message); c.MaxMessageLength > 0 && n > c.MaxMessageLength {
+		return "", &InvalidMessageError{Reason: fmt.Sprintf(
+			"%d characters is more than the maximum of %d", n, c.MaxMessageLength)}
+	}
+	if c.MessageFilter != nil {
+		if err := c.MessageFilter(message); err != nil {
+			return "", &InvalidMessageError{Reason: err.Error(), Err: err}
+		}
+	}
+	return message, nil
+}
+
+/*
+Return a MessageFilter which refuses messages containing any of the words, such
+as a list of profanity. Words are matched regardless of case, and only in
+full, so blocking "ass" does not block "class".
+*/
+func BlockWords(words ...string) func(string) error {
+	var quoted []string
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return func(string) error { return nil }
+	}
+	pattern := regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+	return func(message string) error {
+		if word := pattern.FindString(message); word != "" {
+			return fmt.Errorf("%q is not allowed", word)
+		}
+		return nil
+	}
+}
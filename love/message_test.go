@@ -0,0 +1,95 @@
+package love
+
+import "errors"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "io/ioutil"
+import "net/http"
+import "net/url"
+
+func TestCleanMessage(t *testing.T) {
+	assert.Equal(t, CleanMessage("  thanks\r\nfor\tthe\x07 help\x1b[0m \n"),
+		"thanks\nfor\tthe help[0m")
+	assert.Equal(t, CleanMessage("\x00\n "), "")
+}
+
+func TestValidateMessage(t *testing.T) {
+	client := getTestClient()
+	message, err := client.ValidateMessage(" thanks! ")
+	assert.Nil(t, err)
+	assert.Equal(t, message, "thanks!")
+
+	_, err = client.ValidateMessage(" \x00 ")
+	assert.True(t, errors.Is(err, ErrInvalidMessage))
+	assert.True(t, errors.Is(err, ErrBadParams))
+	assert.Equal(t, err.Error(), "invalid message: the message is empty")
+
+	client.MaxMessageLength = 5
+	_, err = client.ValidateMessage("héllo")
+	assert.Nil(t, err)
+	_, err = client.ValidateMessage("héllo!")
+	assert.True(t, errors.Is(err, ErrInvalidMessage))
+
+	filterErr := errors.New("no")
+	client.MessageFilter = func(string) error { return filterErr }
+	_, err = client.ValidateMessage("hello")
+	assert.True(t, errors.Is(err, ErrInvalidMessage))
+	assert.True(t, errors.Is(err, filterErr))
+}
+
+func TestValidateMessageShortcodes(t *testing.T) {
+	client := getTestClient()
+	message, err := client.ValidateMessage("shipped :tada: at 10:30:00 :unknown:")
+	assert.Nil(t, err)
+	assert.Equal(t, message, "shipped 🎉 at 10:30:00 :unknown:")
+
+	// The expanded message is what is limited.
+	client.MaxMessageLength = 1
+	_, err = client.ValidateMessage(":tada:")
+	assert.Nil(t, err)
+
+	client.MessageOptions.KeepShortcodes = true
+	_, err = client.ValidateMessage(":tada:")
+	assert.True(t, errors.Is(err, ErrInvalidMessage))
+	client.MaxMessageLength = 0
+	message, err = client.ValidateMessage("shipped :tada:")
+	assert.Nil(t, err)
+	assert.Equal(t, message, "shipped :tada:")
+}
+
+func TestBlockWords(t *testing.T) {
+	filter := BlockWords("darn", " heck ", "", "a.b")
+	assert.Nil(t, filter("thanks for darning my socks in class"))
+	assert.Nil(t, filter("axb"))
+	assert.NotNil(t, filter("Darn, that was good"))
+	assert.Equal(t, filter("what the HECK").Error(), `"HECK" is not allowed`)
+	assert.NotNil(t, filter("a.b"))
+	assert.Nil(t, BlockWords()("anything"))
+}
+
+func TestSendLoveCleansMessage(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var sent string
+	httpmock.RegisterResponder(
+		"POST", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			values, _ := url.ParseQuery(string(body))
+			sent = values.Get("message")
+			return httpmock.NewStringResponse(201, "Love sent!"), nil
+		},
+	)
+
+	client := getTestClient()
+	err := client.SendLove("hammy", "darwin", "\x1bthanks\r\n")
+	assert.Nil(t, err)
+	assert.Equal(t, sent, "thanks")
+
+	sent = ""
+	err = client.SendLove("hammy", "darwin", "")
+	assert.True(t, errors.Is(err, ErrInvalidMessage))
+	assert.Equal(t, sent, "")
+}
@@ -0,0 +1,50 @@
+package love
+
+import "net/http"
+
+/*
+A Middleware wraps the http.RoundTripper which makes the client's requests,
+returning a RoundTripper which may inspect or change each request and its
+response, or answer the request itself. Use it to add logging, metrics, headers
+or caching to every request without changing the client:
+
+	client.Middleware = append(client.Middleware, func(next http.RoundTripper) http.RoundTripper {
+		return love.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			log.Printf("%s %s took %s", req.Method, req.URL.Path, time.Since(start))
+			return resp, err
+		})
+	})
+
+Requests contain the API key, which should be removed with Redact before a URL
+or body is logged.
+*/
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+/*
+RoundTripperFunc adapts a function to the http.RoundTripper interface.
+*/
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+/*
+Wrap a transport in the client's middleware. The first middleware is the
+outermost, so it sees each request first and each response last. Logging is
+innermost, so that it records the requests actually made.
+*/
+func (c *Client) transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if c.Logger != nil {
+		base = c.logTransport(base)
+	}
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		base = c.Middleware[i](base)
+	}
+	return base
+}
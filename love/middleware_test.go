@@ -0,0 +1,78 @@
+package love
+
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "io/ioutil"
+import "net/http"
+import "strings"
+
+func recordingMiddleware(name string, log *[]string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			*log = append(*log, name+" "+req.Method+" "+req.URL.Path)
+			resp, err := next.RoundTrip(req)
+			*log = append(*log, name+" done")
+			return resp, err
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", testLoveUrl,
+		httpmock.NewStringResponder(200, "[]"))
+	httpmock.RegisterResponder("POST", testLoveUrl,
+		httpmock.NewStringResponder(201, "Love sent!"))
+	httpmock.RegisterResponder("GET", testAutocompleteUrl,
+		httpmock.NewStringResponder(200, "[]"))
+
+	var log []string
+	client := getTestClient()
+	client.Middleware = []Middleware{
+		recordingMiddleware("outer", &log),
+		recordingMiddleware("inner", &log),
+	}
+
+	_, err := client.GetLove("hammy", "", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, log, []string{
+		"outer GET /api/love", "inner GET /api/love", "inner done", "outer done",
+	})
+
+	log = nil
+	assert.Nil(t, client.SendLove("hammy", "darwin", "thanks"))
+	_, err = client.Autocomplete("dar")
+	assert.Nil(t, err)
+	assert.Equal(t, log, []string{
+		"outer POST /api/love", "inner POST /api/love", "inner done", "outer done",
+		"outer GET /api/autocomplete", "inner GET /api/autocomplete", "inner done",
+		"outer done",
+	})
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	client.Middleware = []Middleware{
+		func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				body := `[{"label": "Darwin Dog (darwin)", "value": "darwin"}]`
+				return &http.Response{
+					StatusCode: 200,
+					Body:       ioutil.NopCloser(strings.NewReader(body)),
+					Request:    req,
+				}, nil
+			})
+		},
+	}
+
+	users, err := client.Autocomplete("dar")
+	assert.Nil(t, err)
+	assert.Equal(t, users, []User{{Display: "Darwin Dog (darwin)", Username: "darwin"}})
+	assert.Nil(t, client.HTTPClient.Transport)
+}
@@ -0,0 +1,65 @@
+package love
+
+import "errors"
+import "math/rand"
+import "sort"
+import "time"
+
+/*
+A Pair assigns a user a Target to appreciate, as drawn by DrawPairs.
+*/
+type Pair struct {
+	User   string
+	Target string
+}
+
+/*
+DrawPairs assigns each of the users another user to appreciate, secret santa
+style: everyone has one target, and is the target of one other user. The users
+form a single random cycle, so no one is their own target, and with three or
+more users, no two users are each other's targets.
+
+The draw depends only on the set of users and the seed, not the order of the
+users, so it can be reproduced by drawing again with the same seed. WeekSeed
+gives a seed which changes weekly. Duplicate users are ignored. The pairs are
+returned in alphabetical order of User.
+*/
+func DrawPairs(users []string, seed int64) ([]Pair, error) {
+	seen := make(map[string]bool)
+	var sorted []string
+	for _, user := range users {
+		if !seen[user] {
+			seen[user] = true
+			sorted = append(sorted, user)
+		}
+	}
+	if len(sorted) < 2 {
+		return nil, errors.New("love: at least two users are required to draw pairs")
+	}
+	sort.Strings(sorted)
+	cycle := append([]string(nil), sorted...)
+	// Sattolo's algorithm, which only produces single cycles.
+	random := rand.New(rand.NewSource(seed))
+	for i := len(cycle) - 1; i > 0; i-- {
+		j := random.Intn(i)
+		cycle[i], cycle[j] = cycle[j], cycle[i]
+	}
+	targets := make(map[string]string, len(cycle))
+	for i, user := range cycle {
+		targets[user] = cycle[(i+1)%len(cycle)]
+	}
+	pairs := make([]Pair, len(sorted))
+	for i, user := range sorted {
+		pairs[i] = Pair{user, targets[user]}
+	}
+	return pairs, nil
+}
+
+/*
+WeekSeed returns a seed for DrawPairs which is the same throughout the ISO 8601
+week t falls in, such as 201714 for the 14th week of 2017.
+*/
+func WeekSeed(t time.Time) int64 {
+	year, week := t.ISOWeek()
+	return int64(year*100 + week)
+}
@@ -0,0 +1,59 @@
+package love
+
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "time"
+
+func TestDrawPairs(t *testing.T) {
+	users := []string{"hammy", "darwin", "jeremy", "alice", "bob", "darwin"}
+	for seed := int64(0); seed < 50; seed++ {
+		pairs, err := DrawPairs(users, seed)
+		assert.Nil(t, err)
+		assert.Len(t, pairs, 5)
+		targets := make(map[string]string)
+		for _, p := range pairs {
+			assert.NotEqual(t, p.User, p.Target)
+			targets[p.User] = p.Target
+		}
+		// Everyone is a target exactly once, in a single cycle.
+		user, steps := "alice", 0
+		for {
+			user = targets[user]
+			steps++
+			if user == "alice" {
+				break
+			}
+		}
+		assert.Equal(t, 5, steps)
+	}
+}
+
+func TestDrawPairsReproducible(t *testing.T) {
+	first, _ := DrawPairs([]string{"hammy", "darwin", "jeremy", "alice"}, 42)
+	second, _ := DrawPairs([]string{"alice", "jeremy", "darwin", "hammy"}, 42)
+	assert.Equal(t, first, second)
+	assert.Equal(t, "alice", first[0].User)
+	assert.Equal(t, "darwin", first[1].User)
+
+	different := false
+	for seed := int64(0); seed < 10 && !different; seed++ {
+		other, _ := DrawPairs([]string{"hammy", "darwin", "jeremy", "alice"}, seed)
+		different = !assert.ObjectsAreEqual(first, other)
+	}
+	assert.True(t, different)
+}
+
+func TestDrawPairsTooFew(t *testing.T) {
+	_, err := DrawPairs([]string{"hammy", "hammy"}, 1)
+	assert.NotNil(t, err)
+	pairs, err := DrawPairs([]string{"hammy", "darwin"}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []Pair{{"darwin", "hammy"}, {"hammy", "darwin"}}, pairs)
+}
+
+func TestWeekSeed(t *testing.T) {
+	assert.Equal(t, int64(201714), WeekSeed(time.Date(2017, 4, 3, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, int64(201714), WeekSeed(time.Date(2017, 4, 9, 23, 0, 0, 0, time.UTC)))
+	// January 1, 2017 is in the last week of 2016.
+	assert.Equal(t, int64(201652), WeekSeed(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)))
+}
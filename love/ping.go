@@ -0,0 +1,81 @@
+package love
+
+import "context"
+import "encoding/json"
+import "errors"
+import "fmt"
+import "net"
+import "net/url"
+
+/*
+Errors returned by Ping, besides ErrUnauthorized, describing why the API cannot
+be used. The error returned by Ping matches one of them with errors.Is, and also
+wraps the underlying error.
+*/
+var (
+	// The BaseUrl is invalid, its host does not exist, or it does not point
+	// at the love API.
+	ErrBadBaseURL = errors.New("love: bad base URL")
+	// The server could not be reached, or failed to respond.
+	ErrServerUnavailable = errors.New("love: server unavailable")
+)
+
+/*
+Check that the API can be used, by making a cheap authenticated request. Returns
+nil if the BaseUrl and API key work. Otherwise, the error matches
+ErrBadBaseURL, ErrUnauthorized or ErrServerUnavailable with errors.Is:
+
+	switch err := client.Ping(); {
+	case errors.Is(err, love.ErrUnauthorized):
+		// check the API key
+	case errors.Is(err, love.ErrBadBaseURL):
+		// check the base URL
+	}
+
+The request is to the autocomplete endpoint, bypassing the AutocompleteCache.
+*/
+func (c *Client) Ping() error {
+	return c.PingContext(context.Background())
+}
+
+/*
+PingContext is like Ping, but the request is made with a context.
+*/
+func (c *Client) PingContext(ctx context.Context) error {
+	base, err := url.Parse(c.BaseUrl)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return fmt.Errorf("%w: %q is not an http or https URL", ErrBadBaseURL,
+			c.BaseUrl)
+	}
+	values := make(url.Values)
+	values.Set("term", "a")
+	resp, err := c.do(ctx, getRequest("/autocomplete", values))
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return fmt.Errorf("%w: %w", ErrBadBaseURL, err)
+		}
+		return fmt.Errorf("%w: %w", ErrServerUnavailable, err)
+	}
+	if err := checkStatus("/autocomplete", resp, loveGetStatusCode); err != nil {
+		var apiErr *APIError
+		errors.As(err, &apiErr)
+		switch {
+		case errors.Is(err, ErrUnauthorized):
+			return err
+		case apiErr != nil && apiErr.Temporary():
+			return fmt.Errorf("%w: %w", ErrServerUnavailable, err)
+		default:
+			return fmt.Errorf("%w: %w", ErrBadBaseURL, err)
+		}
+	}
+	body, err := c.readBody(resp)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrServerUnavailable, err)
+	}
+	var users []User
+	if err := json.Unmarshal(body, &users); err != nil {
+		return fmt.Errorf("%w: the response is not from the love API", ErrBadBaseURL)
+	}
+	return nil
+}
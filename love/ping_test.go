@@ -0,0 +1,64 @@
+package love
+
+import "errors"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+import "net/http/httptest"
+
+func newPingServer(status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("api_key") != testApiKey {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+}
+
+func TestPing(t *testing.T) {
+	server := newPingServer(200, `[{"label": "Darwin Dog (darwin)", "value": "darwin"}]`)
+	defer server.Close()
+
+	client := NewClient(testApiKey, server.URL)
+	assert.Nil(t, client.Ping())
+
+	client.ApiKey = "wrong"
+	err := client.Ping()
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+	assert.False(t, errors.Is(err, ErrBadBaseURL))
+}
+
+func TestPingBadBaseURL(t *testing.T) {
+	for _, baseUrl := range []string{"", "cwrulove.appspot.com/api", "ftp://example.com"} {
+		err := NewClient(testApiKey, baseUrl).Ping()
+		assert.True(t, errors.Is(err, ErrBadBaseURL), baseUrl)
+	}
+
+	notFound := newPingServer(404, "not found")
+	defer notFound.Close()
+	err := NewClient(testApiKey, notFound.URL).Ping()
+	assert.True(t, errors.Is(err, ErrBadBaseURL))
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+
+	html := newPingServer(200, "<html>Love</html>")
+	defer html.Close()
+	err = NewClient(testApiKey, html.URL).Ping()
+	assert.True(t, errors.Is(err, ErrBadBaseURL))
+}
+
+func TestPingServerUnavailable(t *testing.T) {
+	failing := newPingServer(503, "maintenance")
+	defer failing.Close()
+	err := NewClient(testApiKey, failing.URL).Ping()
+	assert.True(t, errors.Is(err, ErrServerUnavailable))
+
+	closed := newPingServer(200, "[]")
+	closed.Close()
+	err = NewClient(testApiKey, closed.URL).Ping()
+	assert.True(t, errors.Is(err, ErrServerUnavailable))
+	assert.NotContains(t, err.Error(), testApiKey)
+}
@@ -0,0 +1,111 @@
+package love
+
+import "context"
+import "errors"
+import "net/http"
+import "net/url"
+import "strings"
+import "sync/atomic"
+
+/*
+ErrNotSupported is returned when the instance lacks an optional endpoint.
+*/
+var ErrNotSupported = errors.New("love: not supported by this instance")
+
+/*
+ErrNoSuchUser is returned by GetUser when the user does not exist.
+*/
+var ErrNoSuchUser = errors.New("love: no such user")
+
+/*
+A Profile holds the details of a user from the instance's employee directory.
+Fields the instance does not provide are empty.
+*/
+type Profile struct {
+	Username   string
+	FullName   string
+	Department string
+	PhotoURL   string
+}
+
+/*
+Return the user's full name, or their username if it is not known.
+*/
+func (p *Profile) Name() string {
+	if p == nil {
+		return ""
+	}
+	if p.FullName != "" {
+		return p.FullName
+	}
+	return p.Username
+}
+
+/*
+The JSON form of a Profile returned by the users endpoint. Instances which keep
+first and last names separately may return those instead of full_name.
+*/
+type jsonProfile struct {
+	Username   string `json:"username"`
+	FullName   string `json:"full_name"`
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	Department string `json:"department"`
+	PhotoURL   string `json:"photo_url"`
+}
+
+/*
+Retrieve the profile of a user from the users endpoint, which some instances
+provide, and stock Yelp Love does not. The endpoint responds with a list of the
+profiles of the users whose username matches exactly, which is empty if there
+is no such user, in which case ErrNoSuchUser is returned.
+
+If the instance does not have the endpoint, GetUser returns ErrNotSupported,
+and the client remembers this, so that later calls return immediately. Callers
+should fall back to Autocomplete or the bare username.
+*/
+func (c *Client) GetUser(username string) (*Profile, error) {
+	return c.GetUserContext(context.Background(), username)
+}
+
+/*
+GetUserContext is like GetUser, but the request is made with a context.
+*/
+func (c *Client) GetUserContext(ctx context.Context, username string) (*Profile, error) {
+	if atomic.LoadInt32(&c.noUsersEndpoint) != 0 {
+		return nil, ErrNotSupported
+	}
+	values := make(url.Values)
+	values.Set("username", username)
+	resp, err := c.do(ctx, getRequest("/users", values))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		atomic.StoreInt32(&c.noUsersEndpoint, 1)
+		return nil, ErrNotSupported
+	} else if err := checkStatus("/users", resp, loveGetStatusCode); err != nil {
+		return nil, err
+	}
+	var profiles []jsonProfile
+	if err := c.decodeJSON(resp, &profiles); err != nil {
+		return nil, err
+	}
+	for _, p := range profiles {
+		if p.Username != username {
+			continue
+		}
+		fullName := p.FullName
+		if fullName == "" {
+			fullName = strings.TrimSpace(p.FirstName + " " + p.LastName)
+		}
+		return &Profile{
+			Username:   p.Username,
+			FullName:   fullName,
+			Department: p.Department,
+			PhotoURL:   p.PhotoURL,
+		}, nil
+	}
+	return nil, ErrNoSuchUser
+}
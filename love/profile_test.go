@@ -0,0 +1,74 @@
+package love
+
+import "errors"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+
+const testUsersUrl = testBaseUrl + "/users"
+
+func TestGetUser(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"GET", testUsersUrl,
+		func(req *http.Request) (*http.Response, error) {
+			switch req.URL.Query().Get("username") {
+			case "hammy":
+				return httpmock.NewStringResponse(200, `[{"username": "hammy",
+"full_name": "Hammy Havoc", "department": "Engineering",
+"photo_url": "https://example.com/hammy.jpg"}]`), nil
+			case "darwin":
+				return httpmock.NewStringResponse(200, `[{"username": "darwin",
+"first_name": "Darwin", "last_name": "Dog"}]`), nil
+			}
+			return httpmock.NewStringResponse(200, "[]"), nil
+		},
+	)
+
+	client := getTestClient()
+	profile, err := client.GetUser("hammy")
+	assert.Nil(t, err)
+	assert.Equal(t, profile, &Profile{
+		Username:   "hammy",
+		FullName:   "Hammy Havoc",
+		Department: "Engineering",
+		PhotoURL:   "https://example.com/hammy.jpg",
+	})
+
+	profile, err = client.GetUser("darwin")
+	assert.Nil(t, err)
+	assert.Equal(t, profile.Name(), "Darwin Dog")
+
+	_, err = client.GetUser("nobody")
+	assert.True(t, errors.Is(err, ErrNoSuchUser))
+}
+
+func TestGetUserNotSupported(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	requests := 0
+	httpmock.RegisterResponder(
+		"GET", testUsersUrl,
+		func(*http.Request) (*http.Response, error) {
+			requests++
+			return httpmock.NewStringResponse(404, "Not Found"), nil
+		},
+	)
+
+	client := getTestClient()
+	_, err := client.GetUser("hammy")
+	assert.True(t, errors.Is(err, ErrNotSupported))
+	_, err = client.GetUser("darwin")
+	assert.True(t, errors.Is(err, ErrNotSupported))
+	assert.Equal(t, requests, 1)
+}
+
+func TestProfileName(t *testing.T) {
+	var profile *Profile
+	assert.Equal(t, profile.Name(), "")
+	assert.Equal(t, (&Profile{Username: "hammy"}).Name(), "hammy")
+}
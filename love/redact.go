@@ -0,0 +1,42 @@
+package love
+
+import "errors"
+import "net/url"
+import "strings"
+
+// Replaces the API key in redacted text.
+const redactedKey = "REDACTED"
+
+/*
+Replace every occurrence of the client's API key in s, whether raw or URL
+encoded, with a placeholder. The credentials of the Authenticators in this
+package are replaced as well. Use this before showing a URL or any other text
+which may contain the key to users or writing it to a log. Errors returned by
+the client are already redacted.
+*/
+func (c *Client) Redact(s string) string {
+	secrets := []string{c.ApiKey}
+	if auth, ok := c.Auth.(secretAuthenticator); ok {
+		secrets = append(secrets, auth.secret())
+	}
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		s = strings.Replace(s, secret, redactedKey, -1)
+		s = strings.Replace(s, url.QueryEscape(secret), redactedKey, -1)
+	}
+	return s
+}
+
+/*
+Remove the API key from an error returned by net/http. The URL of a *url.Error
+contains the query string, which includes the key for GET requests.
+*/
+func (c *Client) redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return &url.Error{Op: urlErr.Op, URL: c.Redact(urlErr.URL), Err: urlErr.Err}
+	}
+	return err
+}
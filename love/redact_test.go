@@ -0,0 +1,34 @@
+package love
+
+import "errors"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+
+func TestRedact(t *testing.T) {
+	client := NewClient("a+b/c", testBaseUrl)
+	assert.Equal(t, client.Redact("key a+b/c"), "key REDACTED")
+	assert.Equal(t, client.Redact("?api_key=a%2Bb%2Fc"), "?api_key=REDACTED")
+
+	client = NewClient("", testBaseUrl)
+	assert.Equal(t, client.Redact("nothing"), "nothing")
+}
+
+func TestGetLoveErrorRedacted(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		},
+	)
+
+	_, err := client.GetLove("hammy", "", 0)
+	assert.NotNil(t, err)
+	assert.NotContains(t, err.Error(), testApiKey)
+	assert.Contains(t, err.Error(), "connection refused")
+}
@@ -0,0 +1,84 @@
+package love
+
+import "context"
+import "fmt"
+import "regexp"
+import "strings"
+
+/*
+The most runes of the original love quoted by ReplyMessage.
+*/
+const ReplySnippetLength = 60
+
+// The reference ReplyMessage begins a reply with, as it may be quoted itself.
+var replyPattern = regexp.MustCompile(`^Re: your note on [^(]+ \(".*?"\): `)
+
+/*
+Return the message of a reply to a love: a reference to the original, quoting
+the start of its message, followed by the reply:
+
+	Re: your note on Apr 3 ("Thanks for the great demo!"): You're welcome!
+
+The quote is the original message with markdown rendered as plain text and its
+lines joined, cut at a word to at most ReplySnippetLength runes. If the
+original was itself a reply, its reference is left out of the quote, so replies
+to replies do not nest.
+*/
+func ReplyMessage(original Love, reply string) string {
+	return fmt.Sprintf("Re: your note on %s (\"%s\"): %s",
+		original.Timestamp.Format("Jan 2"), snippet(original.Message), reply)
+}
+
+/*
+Return the start of a message, as quoted by ReplyMessage.
+*/
+func snippet(message string) string {
+	message = replyPattern.ReplaceAllString(message, "")
+	words := strings.Fields(MarkdownText(message))
+	text := strings.Join(words, " ")
+	if len([]rune(text)) <= ReplySnippetLength {
+		return text
+	}
+	cut := ""
+	for _, word := range words {
+		next := strings.TrimSpace(cut + " " + word)
+		if len([]rune(next))+1 > ReplySnippetLength {
+			break
+		}
+		cut = next
+	}
+	if cut == "" {
+		cut = string([]rune(text)[:ReplySnippetLength-1])
+	}
+	return cut + "…"
+}
+
+/*
+Send love from a user back to the other user of a love they sent or received,
+with a message which refers to and quotes the original, as ReplyMessage
+returns.
+*/
+func (c *Client) ReplyTo(from string, original Love, message string) error {
+	return c.ReplyToContext(context.Background(), from, original, message)
+}
+
+/*
+ReplyToContext is like ReplyTo, but the request is made with a context, as in
+SendLoveContext.
+*/
+func (c *Client) ReplyToContext(ctx context.Context, from string, original Love,
+	message string) error {
+	return c.SendLoveContext(ctx, from, ReplyRecipient(from, original),
+		ReplyMessage(original, message))
+}
+
+/*
+Return the user a reply to a love from a user goes to: the sender of the love,
+unless the user sent it, in which case its recipient.
+*/
+func ReplyRecipient(from string, original Love) string {
+	if original.Sender == from {
+		return original.Recipient
+	}
+	return original.Sender
+}
@@ -0,0 +1,57 @@
+package love
+
+import "bytes"
+import "net/url"
+import "strings"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "time"
+
+var replyOriginal = Love{
+	Sender:    "darwin",
+	Recipient: "hammy",
+	Message:   "Thanks for the **great** demo!",
+	Timestamp: time.Date(2017, 4, 3, 12, 0, 0, 0, time.UTC),
+}
+
+func TestReplyMessage(t *testing.T) {
+	assert.Equal(t, ReplyMessage(replyOriginal, "You're welcome!"),
+		`Re: your note on Apr 3 ("Thanks for the great demo!"): You're welcome!`)
+
+	long := replyOriginal
+	long.Message = "Thanks for staying late\nto fix the build before the release, and for the " +
+		"write-up afterwards"
+	message := ReplyMessage(long, "Any time")
+	assert.Equal(t, message, `Re: your note on Apr 3 ("Thanks for staying late to fix the build `+
+		`before the…"): Any time`)
+
+	// A reply to a reply quotes the reply, not the reference.
+	reply := replyOriginal
+	reply.Message = message
+	assert.Equal(t, ReplyMessage(reply, "Thanks"),
+		`Re: your note on Apr 3 ("Any time"): Thanks`)
+}
+
+func TestSnippetLongWord(t *testing.T) {
+	word := strings.Repeat("a", 100)
+	assert.Equal(t, snippet(word), strings.Repeat("a", ReplySnippetLength-1)+"…")
+}
+
+func TestReplyRecipient(t *testing.T) {
+	assert.Equal(t, ReplyRecipient("hammy", replyOriginal), "darwin")
+	assert.Equal(t, ReplyRecipient("darwin", replyOriginal), "hammy")
+}
+
+func TestReplyTo(t *testing.T) {
+	var out bytes.Buffer
+	client := getTestClient()
+	client.DryRun = &out
+
+	assert.Nil(t, client.ReplyTo("hammy", replyOriginal, "You're welcome!"))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	form, err := url.ParseQuery(lines[len(lines)-1])
+	assert.Nil(t, err)
+	assert.Equal(t, form.Get("sender"), "hammy")
+	assert.Equal(t, form.Get("recipient"), "darwin")
+	assert.Equal(t, form.Get("message"), ReplyMessage(replyOriginal, "You're welcome!"))
+}
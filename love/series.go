@@ -0,0 +1,182 @@
+package love
+
+import "fmt"
+import "sort"
+import "time"
+
+/*
+A Granularity is the span of time covered by each Bucket of a series: a day, a
+week or a calendar month. Weeks start on Monday, as in ISO 8601, so that a
+weekly bucket is exactly one ISO week.
+*/
+type Granularity string
+
+const (
+	Daily   Granularity = "day"
+	Weekly  Granularity = "week"
+	Monthly Granularity = "month"
+)
+
+/*
+The granularities, finest first.
+*/
+var Granularities = []Granularity{Daily, Weekly, Monthly}
+
+/*
+Return midnight at the start of the bucket t falls in, in t's location.
+*/
+func (g Granularity) Start(t time.Time) (time.Time, error) {
+	year, month, day := t.Date()
+	switch g {
+	case Daily:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location()), nil
+	case Weekly:
+		return WeekStart(t), nil
+	case Monthly:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location()), nil
+	}
+	return time.Time{}, fmt.Errorf("unknown granularity %q", g)
+}
+
+/*
+Return the start of the bucket after the one starting at start. Buckets are
+counted in calendar days, so a day is 23 or 25 hours long where the clocks
+change.
+*/
+func (g Granularity) next(start time.Time) time.Time {
+	switch g {
+	case Daily:
+		return start.AddDate(0, 0, 1)
+	case Weekly:
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+/*
+Return a short name for the bucket starting at start: the date, such as
+2017-04-03, for a day; the ISO week, such as 2017-W14, for a week; and the year
+and month, such as 2017-04, for a month.
+*/
+func (g Granularity) Label(start time.Time) string {
+	switch g {
+	case Weekly:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case Monthly:
+		return start.Format("2006-01")
+	}
+	return start.Format("2006-01-02")
+}
+
+/*
+A Bucket counts the love sent during one day, week or month of a series,
+starting at Start. When the series was split for a user, Sent and Received
+count the love the user sent and received during it; otherwise they are zero.
+*/
+type Bucket struct {
+	Start    time.Time
+	Count    int
+	Sent     int
+	Received int
+}
+
+/*
+Return the ISO 8601 year and week of the start of the bucket, which for a
+weekly bucket are those of every day in it.
+*/
+func (b Bucket) ISOWeek() (year, week int) {
+	return b.Start.ISOWeek()
+}
+
+/*
+Count the love sent during each day, week or month from the first love to the
+last, oldest first, including the buckets in between in which none was. Days
+start at midnight in loc, which is UTC if nil, so that love sent late in the
+evening in one time zone is counted on the day it was sent there rather than
+the next. A series of no love is empty.
+*/
+func BucketBy(g Granularity, loves []Love, loc *time.Location) ([]Bucket, error) {
+	return series(g, loves, loc, "")
+}
+
+/*
+Count the love sent during each day, as BucketBy(Daily, loves, loc) does.
+*/
+func BucketByDay(loves []Love, loc *time.Location) []Bucket {
+	buckets, _ := series(Daily, loves, loc, "")
+	return buckets
+}
+
+/*
+Count the love sent during each week, as BucketBy(Weekly, loves, loc) does.
+*/
+func BucketByWeek(loves []Love, loc *time.Location) []Bucket {
+	buckets, _ := series(Weekly, loves, loc, "")
+	return buckets
+}
+
+/*
+Count the love sent during each month, as BucketBy(Monthly, loves, loc) does.
+*/
+func BucketByMonth(loves []Love, loc *time.Location) []Bucket {
+	buckets, _ := series(Monthly, loves, loc, "")
+	return buckets
+}
+
+/*
+Like BucketBy, but count the love a user sent and received during each bucket
+separately. Love which the user neither sent nor received is ignored.
+*/
+func SplitBy(g Granularity, user string, loves []Love, loc *time.Location) ([]Bucket, error) {
+	var involving []Love
+	for _, l := range loves {
+		if l.Sender == user || l.Recipient == user {
+			involving = append(involving, l)
+		}
+	}
+	return series(g, involving, loc, user)
+}
+
+func series(g Granularity, loves []Love, loc *time.Location, user string) ([]Bucket, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if _, err := g.Start(time.Time{}); err != nil {
+		return nil, err
+	}
+	buckets := make(map[int64]*Bucket)
+	var starts []time.Time
+	for _, l := range loves {
+		start, _ := g.Start(l.Timestamp.In(loc))
+		b := buckets[start.Unix()]
+		if b == nil {
+			b = &Bucket{Start: start}
+			buckets[start.Unix()] = b
+			starts = append(starts, start)
+		}
+		b.Count++
+		if user == "" {
+			continue
+		}
+		if l.Sender == user {
+			b.Sent++
+		}
+		if l.Recipient == user {
+			b.Received++
+		}
+	}
+	if len(starts) == 0 {
+		return nil, nil
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	var result []Bucket
+	for start := starts[0]; !start.After(starts[len(starts)-1]); start = g.next(start) {
+		if b := buckets[start.Unix()]; b != nil {
+			result = append(result, *b)
+		} else {
+			result = append(result, Bucket{Start: start})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,119 @@
+package love
+
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "time"
+
+func TestGranularityStart(t *testing.T) {
+	// 2024-01-03 was a Wednesday.
+	wednesday := time.Date(2024, 1, 3, 15, 4, 5, 0, time.UTC)
+	start, err := Daily.Start(wednesday)
+	assert.Nil(t, err)
+	assert.Equal(t, start, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+	start, _ = Weekly.Start(wednesday)
+	assert.Equal(t, start, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	start, _ = Monthly.Start(wednesday)
+	assert.Equal(t, start, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	_, err = Granularity("fortnight").Start(wednesday)
+	assert.NotNil(t, err)
+}
+
+func TestGranularityLabel(t *testing.T) {
+	assert.Equal(t, Daily.Label(time.Date(2017, 4, 3, 0, 0, 0, 0, time.UTC)), "2017-04-03")
+	assert.Equal(t, Weekly.Label(time.Date(2017, 4, 3, 0, 0, 0, 0, time.UTC)), "2017-W14")
+	assert.Equal(t, Monthly.Label(time.Date(2017, 4, 1, 0, 0, 0, 0, time.UTC)), "2017-04")
+	// 2020-12-28 starts the 53rd week of 2020, and 2021-01-04 the 1st of 2021.
+	assert.Equal(t, Weekly.Label(time.Date(2020, 12, 28, 0, 0, 0, 0, time.UTC)), "2020-W53")
+	assert.Equal(t, Weekly.Label(time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC)), "2021-W01")
+}
+
+func TestBucketByDay(t *testing.T) {
+	day := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	loves := []Love{
+		testLove("hammy", "darwin", day.AddDate(0, 0, 2)),
+		testLove("hammy", "darwin", day),
+		testLove("darwin", "hammy", day.Add(time.Hour)),
+	}
+	midnight := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, BucketByDay(loves, nil), []Bucket{
+		{Start: midnight, Count: 2},
+		{Start: midnight.AddDate(0, 0, 1)},
+		{Start: midnight.AddDate(0, 0, 2), Count: 1},
+	})
+	assert.Empty(t, BucketByDay(nil, nil))
+}
+
+func TestBucketByWeekISO(t *testing.T) {
+	// A week which spans the end of 2020 is the 53rd week of 2020.
+	loves := []Love{
+		testLove("hammy", "darwin", time.Date(2020, 12, 31, 12, 0, 0, 0, time.UTC)),
+		testLove("hammy", "darwin", time.Date(2021, 1, 3, 12, 0, 0, 0, time.UTC)),
+		testLove("hammy", "darwin", time.Date(2021, 1, 4, 12, 0, 0, 0, time.UTC)),
+	}
+	buckets := BucketByWeek(loves, time.UTC)
+	assert.Equal(t, len(buckets), 2)
+	assert.Equal(t, buckets[0].Count, 2)
+	year, week := buckets[0].ISOWeek()
+	assert.Equal(t, []int{year, week}, []int{2020, 53})
+	year, week = buckets[1].ISOWeek()
+	assert.Equal(t, []int{year, week}, []int{2021, 1})
+}
+
+func TestBucketByMonth(t *testing.T) {
+	loves := []Love{
+		testLove("hammy", "darwin", time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)),
+		testLove("hammy", "darwin", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)),
+	}
+	assert.Equal(t, BucketByMonth(loves, time.UTC), []Bucket{
+		{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Count: 1},
+		{Start: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Count: 1},
+	})
+}
+
+func TestBucketLocation(t *testing.T) {
+	newYork := time.FixedZone("EST", -5*60*60)
+	// 02:00 UTC on Tuesday is 21:00 on Monday in New York.
+	loves := []Love{testLove("hammy", "darwin", time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC))}
+	buckets := BucketByDay(loves, newYork)
+	assert.Equal(t, len(buckets), 1)
+	assert.Equal(t, buckets[0].Start, time.Date(2024, 1, 1, 0, 0, 0, 0, newYork))
+	buckets = BucketByDay(loves, nil)
+	assert.Equal(t, buckets[0].Start, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+}
+
+func TestBucketDaylightSaving(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	// The clocks went forward on 2024-03-10, a 23-hour day.
+	loves := []Love{
+		testLove("hammy", "darwin", time.Date(2024, 3, 9, 12, 0, 0, 0, loc)),
+		testLove("hammy", "darwin", time.Date(2024, 3, 11, 12, 0, 0, 0, loc)),
+	}
+	buckets := BucketByDay(loves, loc)
+	assert.Equal(t, len(buckets), 3)
+	assert.Equal(t, buckets[1].Start, time.Date(2024, 3, 10, 0, 0, 0, 0, loc))
+	assert.Equal(t, buckets[2].Start, time.Date(2024, 3, 11, 0, 0, 0, 0, loc))
+}
+
+func TestSplitBy(t *testing.T) {
+	monday := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	loves := []Love{
+		testLove("hammy", "darwin", monday),
+		testLove("darwin", "hammy", monday.Add(time.Hour)),
+		testLove("darwin", "jeremy", monday.Add(time.Hour)),
+		testLove("hammy", "jeremy", monday.AddDate(0, 0, 14)),
+	}
+	buckets, err := SplitBy(Weekly, "hammy", loves, time.UTC)
+	assert.Nil(t, err)
+	assert.Equal(t, buckets, []Bucket{
+		{Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Count: 2, Sent: 1, Received: 1},
+		{Start: time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), Count: 1, Sent: 1},
+	})
+
+	_, err = SplitBy(Granularity("fortnight"), "hammy", loves, time.UTC)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,14 @@
+package love
+
+/*
+LoveService is the set of operations provided by the Yelp Love API. *Client
+implements it. Code which depends on LoveService rather than *Client may be
+given a mock, a cache, or any other wrapper around a real client instead.
+*/
+type LoveService interface {
+	GetLove(from string, to string, limit int64) ([]Love, error)
+	SendLove(from string, to string, message string) error
+	Autocomplete(term string) ([]User, error)
+}
+
+var _ LoveService = (*Client)(nil)
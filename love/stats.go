@@ -0,0 +1,74 @@
+package love
+
+import "sort"
+import "time"
+
+/*
+Stats summarizes a collection of love. BySender and ByRecipient count the love
+sent by and received by each user. ByWeek counts the love sent during each week,
+keyed by the start of the week: midnight on Monday, in the location of the
+timestamps.
+*/
+type Stats struct {
+	Total       int
+	BySender    map[string]int
+	ByRecipient map[string]int
+	ByWeek      map[time.Time]int
+}
+
+/*
+A key and the number of times it occurred, as returned by TopCounts.
+*/
+type Count struct {
+	Key   string
+	Count int
+}
+
+/*
+Compute statistics for a collection of love.
+*/
+func ComputeStats(loves []Love) *Stats {
+	stats := &Stats{
+		Total:       len(loves),
+		BySender:    make(map[string]int),
+		ByRecipient: make(map[string]int),
+		ByWeek:      make(map[time.Time]int),
+	}
+	for _, l := range loves {
+		stats.BySender[l.Sender]++
+		stats.ByRecipient[l.Recipient]++
+		stats.ByWeek[WeekStart(l.Timestamp)]++
+	}
+	return stats
+}
+
+/*
+Return midnight on the Monday starting the week t falls in, in t's location.
+Weeks start on Monday, as in ISO 8601.
+*/
+func WeekStart(t time.Time) time.Time {
+	days := (int(t.Weekday()) + 6) % 7
+	year, month, day := t.Date()
+	return time.Date(year, month, day-days, 0, 0, 0, 0, t.Location())
+}
+
+/*
+Return the n keys with the highest counts, highest first, breaking ties by key.
+If n <= 0, every key is returned.
+*/
+func TopCounts(counts map[string]int, n int) []Count {
+	result := make([]Count, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, Count{key, count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+	if n > 0 && n < len(result) {
+		result = result[:n]
+	}
+	return result
+}
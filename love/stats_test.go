@@ -0,0 +1,52 @@
+package love
+
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "time"
+
+func testLove(sender, recipient string, timestamp time.Time) Love {
+	return Love{
+		Sender:    sender,
+		Recipient: recipient,
+		Message:   "message",
+		Timestamp: timestamp,
+	}
+}
+
+func TestComputeStats(t *testing.T) {
+	// 2024-01-01 was a Monday.
+	monday := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	loves := []Love{
+		testLove("hammy", "darwin", monday),
+		testLove("hammy", "darwin", monday.Add(6*24*time.Hour)),
+		testLove("hammy", "jeremy", monday.Add(7*24*time.Hour)),
+		testLove("darwin", "hammy", monday.Add(8*24*time.Hour)),
+	}
+
+	stats := ComputeStats(loves)
+	assert.Equal(t, stats.Total, 4)
+	assert.Equal(t, stats.BySender, map[string]int{"hammy": 3, "darwin": 1})
+	assert.Equal(t, stats.ByRecipient,
+		map[string]int{"darwin": 2, "jeremy": 1, "hammy": 1})
+	week1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	week2 := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, stats.ByWeek, map[time.Time]int{week1: 2, week2: 2})
+}
+
+func TestWeekStart(t *testing.T) {
+	sunday := time.Date(2024, 1, 7, 23, 59, 0, 0, time.UTC)
+	assert.Equal(t, WeekStart(sunday), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	monday := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, WeekStart(monday), monday)
+	// Crossing a year boundary.
+	wednesday := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, WeekStart(wednesday),
+		time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC))
+}
+
+func TestTopCounts(t *testing.T) {
+	counts := map[string]int{"a": 1, "b": 3, "c": 3, "d": 2}
+	assert.Equal(t, TopCounts(counts, 2), []Count{{"b", 3}, {"c", 3}})
+	assert.Equal(t, len(TopCounts(counts, 0)), 4)
+	assert.Equal(t, len(TopCounts(counts, 10)), 4)
+}
@@ -0,0 +1,62 @@
+package love
+
+import "strings"
+import "text/template"
+
+/*
+Parse a message template, in the syntax of text/template. Executing the template
+fails if it refers to a field which is missing from the data, rather than
+sending a message containing "<no value>".
+*/
+func ParseMessageTemplate(text string) (*template.Template, error) {
+	return template.New("message").Option("missingkey=error").Parse(text)
+}
+
+/*
+Render a message for each recipient from a template, such as:
+
+	{{.FirstName}}, thanks for {{.Reason}}!
+
+The template is executed with data[recipient], plus Sender and Recipient, which
+hold the usernames of the sender and recipient unless data sets them. Returns
+the messages by recipient, or the first error from executing the template.
+*/
+func RenderMessages(tmpl *template.Template, from string, to []string,
+	data map[string]map[string]string) (map[string]string, error) {
+	messages := make(map[string]string, len(to))
+	for _, recipient := range to {
+		fields := map[string]string{"Sender": from, "Recipient": recipient}
+		for key, value := range data[recipient] {
+			fields[key] = value
+		}
+		var message strings.Builder
+		if err := tmpl.Execute(&message, fields); err != nil {
+			return nil, err
+		}
+		messages[recipient] = message.String()
+	}
+	return messages, nil
+}
+
+/*
+Send each recipient a separate love, with a message rendered for them from a
+template by RenderMessages, using at most concurrency simultaneous requests.
+Nothing is sent unless the message renders for every recipient; otherwise the
+rendering error is returned. Groups are expanded first, so that each member's
+message is rendered for them. The results are reported as by SendLoveEach.
+*/
+func (c *Client) SendLoveTemplate(from string, to []string, tmpl *template.Template,
+	data map[string]map[string]string, concurrency int) (map[string]error, error) {
+	to, err := c.expandGroups(to)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := RenderMessages(tmpl, from, to, data)
+	if err != nil {
+		return nil, err
+	}
+	message := func(recipient string) string {
+		return messages[recipient]
+	}
+	return c.sendEach(from, to, message, concurrency), nil
+}
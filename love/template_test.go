@@ -0,0 +1,78 @@
+package love
+
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "io/ioutil"
+import "net/http"
+import "net/url"
+import "sync"
+
+func TestRenderMessages(t *testing.T) {
+	tmpl, err := ParseMessageTemplate("{{.FirstName}}, thanks for {{.Reason}}! -{{.Sender}}")
+	assert.Nil(t, err)
+	data := map[string]map[string]string{
+		"darwin": {"FirstName": "Darwin", "Reason": "the demo"},
+		"jeremy": {"FirstName": "Jeremy", "Reason": "the review"},
+	}
+	messages, err := RenderMessages(tmpl, "hammy", []string{"darwin", "jeremy"}, data)
+	assert.Nil(t, err)
+	assert.Equal(t, messages, map[string]string{
+		"darwin": "Darwin, thanks for the demo! -hammy",
+		"jeremy": "Jeremy, thanks for the review! -hammy",
+	})
+}
+
+func TestRenderMessagesMissingField(t *testing.T) {
+	tmpl, _ := ParseMessageTemplate("{{.FirstName}}, thanks!")
+	data := map[string]map[string]string{"darwin": {"FirstName": "Darwin"}}
+	_, err := RenderMessages(tmpl, "hammy", []string{"darwin", "jeremy"}, data)
+	assert.NotNil(t, err)
+}
+
+func TestSendLoveTemplate(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	var mutex sync.Mutex
+	received := make(map[string]string)
+	httpmock.RegisterResponder(
+		"POST", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			values, _ := url.ParseQuery(string(body))
+			mutex.Lock()
+			received[values.Get("recipient")] = values.Get("message")
+			mutex.Unlock()
+			return httpmock.NewStringResponse(201, "Love sent!"), nil
+		},
+	)
+
+	tmpl, _ := ParseMessageTemplate("Thanks {{.Recipient}} for {{.Reason}}")
+	data := map[string]map[string]string{
+		"darwin": {"Reason": "the demo"},
+		"jeremy": {"Reason": "the review"},
+	}
+	results, err := client.SendLoveTemplate("hammy", []string{"darwin", "jeremy"}, tmpl,
+		data, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, results, map[string]error{"darwin": nil, "jeremy": nil})
+	assert.Equal(t, received, map[string]string{
+		"darwin": "Thanks darwin for the demo",
+		"jeremy": "Thanks jeremy for the review",
+	})
+}
+
+func TestSendLoveTemplateRenderError(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	tmpl, _ := ParseMessageTemplate("{{.Reason}}")
+	data := map[string]map[string]string{"darwin": {"Reason": "the demo"}}
+	results, err := client.SendLoveTemplate("hammy", []string{"darwin", "jeremy"}, tmpl,
+		data, 0)
+	assert.NotNil(t, err)
+	assert.Nil(t, results)
+}
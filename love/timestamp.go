@@ -0,0 +1,81 @@
+package love
+
+import "errors"
+import "fmt"
+import "time"
+
+/*
+Layouts accepted for timestamps returned by the API. Yelp Love sends Python
+isoformat() timestamps without a zone, which include microseconds unless they
+are zero. time.Parse accepts fractional seconds even when the layout has none,
+so the plain layouts cover both precisions. Timestamps with an explicit offset
+are accepted too.
+*/
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	timestampFormat,
+	"2006-01-02 15:04:05",
+}
+
+/*
+Parse a timestamp from the API. Timestamps without a zone are interpreted in
+loc, and the result is always expressed in loc.
+*/
+func parseTimestamp(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	return time.Time{}, errors.New("invalid timestamp encoding")
+}
+
+/*
+Return the location of the server's timestamps.
+*/
+func (c *Client) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+	return c.Location
+}
+
+/*
+Describe when a love was sent relative to now, as a person would: "just now",
+"5 minutes ago", "3 hours ago", "yesterday", "last Tuesday" for the past week,
+and then the date, such as "Jan 2", with the year if it is not the current
+one. Days are counted in the location of now, so pass time.Now() to describe
+times in the local time zone. Times in the future are given as a date and time.
+*/
+func FormatRelative(t, now time.Time) string {
+	t = t.In(now.Location())
+	elapsed := now.Sub(t)
+	switch {
+	case elapsed < -time.Minute:
+		return t.Format("Jan 2, 2006 15:04")
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return plural(int(elapsed/time.Minute), "minute") + " ago"
+	case elapsed < 24*time.Hour && t.Day() == now.Day():
+		return plural(int(elapsed/time.Hour), "hour") + " ago"
+	}
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	switch {
+	case !t.Before(today.AddDate(0, 0, -1)):
+		return "yesterday"
+	case !t.Before(today.AddDate(0, 0, -6)):
+		return "last " + t.Weekday().String()
+	case t.Year() == now.Year():
+		return t.Format("Jan 2")
+	}
+	return t.Format("Jan 2, 2006")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,100 @@
+package love
+
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "time"
+
+func TestParseTimestamp(t *testing.T) {
+	zone := time.FixedZone("PST", -8*60*60)
+	cases := map[string]time.Time{
+		"2000-01-01T01:01:01":        time.Date(2000, 1, 1, 1, 1, 1, 0, zone),
+		"2000-01-01T01:01:01.552636": time.Date(2000, 1, 1, 1, 1, 1, 552636000, zone),
+		"2000-01-01 01:01:01":        time.Date(2000, 1, 1, 1, 1, 1, 0, zone),
+		"2000-01-01T09:01:01Z":       time.Date(2000, 1, 1, 1, 1, 1, 0, zone),
+	}
+	for s, expected := range cases {
+		parsed, err := parseTimestamp(s, zone)
+		assert.Nil(t, err)
+		assert.True(t, parsed.Equal(expected), s)
+		assert.Equal(t, parsed.Location(), zone)
+	}
+
+	_, err := parseTimestamp("yesterday", zone)
+	assert.NotNil(t, err)
+}
+
+func TestGetLoveLocation(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	zone := time.FixedZone("PST", -8*60*60)
+	client := getTestClient()
+	client.Location = zone
+	params := map[string]string{
+		"sender":  "hammy",
+		"limit":   "100",
+		"since":   "1999-12-31T17:00:00",
+		"api_key": testApiKey,
+	}
+
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		newGetValidateResponder(t, 200, singleGetLoveResponse, params),
+	)
+
+	loves, err := client.GetLoveFiltered(LoveFilter{
+		Sender: "hammy",
+		Since:  time.Date(2000, 1, 1, 1, 0, 0, 0, time.UTC),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 1)
+	assert.Equal(t, loves[0].Timestamp.Location(), zone)
+	assert.Equal(t, loves[0].Timestamp.Hour(), 1)
+	assert.Equal(t, loves[0].Timestamp.UTC().Hour(), 9)
+}
+
+func TestGetLoveDefaultLocation(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	client := getTestClient()
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		httpmock.NewStringResponder(200, singleGetLoveResponse),
+	)
+
+	loves, err := client.GetLove("hammy", "", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, loves[0].Timestamp.Location(), time.UTC)
+}
+
+func TestFormatRelative(t *testing.T) {
+	// A Thursday.
+	now := time.Date(2017, 6, 15, 14, 30, 0, 0, time.UTC)
+	for _, test := range []struct {
+		t        time.Time
+		expected string
+	}{
+		{now.Add(-10 * time.Second), "just now"},
+		{now.Add(10 * time.Second), "just now"},
+		{now.Add(-time.Minute), "1 minute ago"},
+		{now.Add(-45 * time.Minute), "45 minutes ago"},
+		{now.Add(-3 * time.Hour), "3 hours ago"},
+		{time.Date(2017, 6, 14, 23, 0, 0, 0, time.UTC), "yesterday"},
+		{time.Date(2017, 6, 14, 0, 0, 0, 0, time.UTC), "yesterday"},
+		{time.Date(2017, 6, 13, 23, 59, 0, 0, time.UTC), "last Tuesday"},
+		{time.Date(2017, 6, 9, 0, 0, 0, 0, time.UTC), "last Friday"},
+		{time.Date(2017, 6, 8, 23, 0, 0, 0, time.UTC), "Jun 8"},
+		{time.Date(2016, 12, 25, 9, 0, 0, 0, time.UTC), "Dec 25, 2016"},
+		{now.Add(2 * time.Hour), "Jun 15, 2017 16:30"},
+	} {
+		assert.Equal(t, FormatRelative(test.t, now), test.expected)
+	}
+
+	// Days are counted in the location of now.
+	zone := time.FixedZone("UTC-8", -8*60*60)
+	late := time.Date(2017, 6, 15, 1, 0, 0, 0, time.UTC)
+	assert.Equal(t, FormatRelative(late.Add(-2*time.Hour), late.In(zone)), "2 hours ago")
+	assert.Equal(t, FormatRelative(late.Add(-20*time.Hour), late), "yesterday")
+}
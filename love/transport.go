@@ -0,0 +1,126 @@
+package love
+
+import "context"
+import "encoding/json"
+import "io"
+import "io/ioutil"
+import "net/http"
+import "net/url"
+import "strings"
+
+/*
+An apiRequest is a request to an endpoint of the API, such as "/love", with
+query parameters, and a form to send as its body if form is not nil. Every
+request the client makes is described by one, and goes through the same layers:
+do applies the Breaker, tryBases builds the request at each base URL in turn
+with newRequest, and the client's http.RoundTripper, wrapped in its Middleware,
+makes it. The response is then checked with checkStatus and decoded with
+decodeJSON.
+*/
+type apiRequest struct {
+	method   string
+	endpoint string
+	query    url.Values
+	form     url.Values
+	header   http.Header
+}
+
+/*
+Return a GET request to an endpoint with query parameters.
+*/
+func getRequest(endpoint string, query url.Values) *apiRequest {
+	return &apiRequest{method: "GET", endpoint: endpoint, query: query}
+}
+
+/*
+Return the path of the request below a base URL, with its query.
+*/
+func (r *apiRequest) path() string {
+	if len(r.query) == 0 {
+		return r.endpoint
+	}
+	return r.endpoint + "?" + r.query.Encode()
+}
+
+/*
+Create the request at a base URL with a context, authenticated by the client's
+Authenticator and identified by its User-Agent. The form, if any, is encoded as
+the body.
+*/
+func (c *Client) newRequest(ctx context.Context, base string, r *apiRequest) (*http.Request, error) {
+	var body io.Reader
+	if r.form != nil {
+		body = strings.NewReader(r.form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, base+r.path(), body)
+	if err != nil {
+		return nil, c.redactError(err)
+	}
+	req.Header.Set("User-Agent", c.userAgent())
+	if r.form != nil {
+		req.Header.Set("Content-Type", formContentType)
+	}
+	for name, value := range r.header {
+		req.Header[name] = value
+	}
+	if err := c.authenticator().Authenticate(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+/*
+Fail with an *APIError for the endpoint if the status of a response is not
+status. The body of such a response is consumed and closed.
+*/
+func checkStatus(endpoint string, resp *http.Response, status int) error {
+	if resp.StatusCode != status {
+		return newAPIError(endpoint, resp)
+	}
+	return nil
+}
+
+/*
+Read and close the body of a response, failing with ErrResponseTooLarge if it is
+longer than MaxResponseBytes.
+*/
+func (c *Client) readBody(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	max := c.MaxResponseBytes
+	if max == 0 {
+		max = DefaultMaxResponseBytes
+	} else if max < 0 {
+		return ioutil.ReadAll(resp.Body)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > max {
+		return nil, ErrResponseTooLarge
+	}
+	return body, nil
+}
+
+/*
+Read the JSON body of a successful response into v.
+*/
+func (c *Client) decodeJSON(resp *http.Response, v interface{}) error {
+	body, err := c.readBody(resp)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+/*
+Make a GET request, which may be answered from the ResponseCache, and read the
+JSON body of its successful response into v.
+*/
+func (c *Client) getJSON(ctx context.Context, r *apiRequest, v interface{}) error {
+	body, err := c.getBody(ctx, r)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,64 @@
+package love
+
+import "context"
+import "errors"
+import "io/ioutil"
+import "net/http"
+import "net/http/httptest"
+import "net/url"
+import "testing"
+import "github.com/stretchr/testify/assert"
+
+func TestRequestPath(t *testing.T) {
+	assert.Equal(t, getRequest("/love", nil).path(), "/love")
+	query := url.Values{"term": {"dar win"}, "limit": {"5"}}
+	assert.Equal(t, getRequest("/autocomplete", query).path(), "/autocomplete?limit=5&term=dar+win")
+}
+
+func TestNewRequest(t *testing.T) {
+	client := getTestClient()
+	r := &apiRequest{
+		method:   "POST",
+		endpoint: "/love",
+		form:     url.Values{"sender": {"hammy"}},
+		header:   http.Header{"X-Request-Id": {"1"}},
+	}
+	req, err := client.newRequest(context.Background(), "https://fallback.example.com/api", r)
+	assert.Nil(t, err)
+	assert.Equal(t, req.URL.String(), "https://fallback.example.com/api/love")
+	assert.Equal(t, req.Header.Get("Content-Type"), formContentType)
+	assert.Equal(t, req.Header.Get("X-Request-Id"), "1")
+	body, _ := ioutil.ReadAll(req.Body)
+	assert.Equal(t, string(body), "api_key="+testApiKey+"&sender=hammy")
+
+	req, err = client.newRequest(context.Background(), testBaseUrl, getRequest("/love", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, req.URL.String(), testLoveUrl+"?api_key="+testApiKey)
+	assert.Equal(t, req.Header.Get("Content-Type"), "")
+}
+
+func TestCheckStatus(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.WriteHeader(201)
+	assert.Nil(t, checkStatus("/love", recorder.Result(), 201))
+
+	recorder = httptest.NewRecorder()
+	recorder.WriteHeader(401)
+	recorder.WriteString("bad key")
+	err := checkStatus("/love", recorder.Result(), 201)
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+}
+
+func TestDecodeJSON(t *testing.T) {
+	client := getTestClient()
+	recorder := httptest.NewRecorder()
+	recorder.WriteString(`[{"username": "darwin"}]`)
+	var users []map[string]string
+	assert.Nil(t, client.decodeJSON(recorder.Result(), &users))
+	assert.Equal(t, users[0]["username"], "darwin")
+
+	client.MaxResponseBytes = 4
+	recorder = httptest.NewRecorder()
+	recorder.WriteString(`[{"username": "darwin"}]`)
+	assert.Equal(t, client.decodeJSON(recorder.Result(), &users), ErrResponseTooLarge)
+}
@@ -0,0 +1,159 @@
+package love
+
+import "fmt"
+import "math"
+import "time"
+
+/*
+A Trend compares the love sent during a period with the love sent during the
+periods of the same length just before it, such as this week's love with the
+love of each of the 4 weeks before. Current counts the love sent during the
+period, and Average the mean of the love sent during each of the Periods
+periods before it. Length is the length of each period.
+*/
+type Trend struct {
+	Current int
+	Average float64
+	Periods int
+	Length  time.Duration
+}
+
+/*
+Compare the love sent from start up to but not including end with the love
+sent during each of the periods of the same length before it. The collection of
+love should include every love sent since TrendSince(start, end, periods).
+*/
+func ComputeTrend(loves []Love, start, end time.Time, periods int) Trend {
+	t := Trend{Periods: periods, Length: end.Sub(start)}
+	previous := 0
+	since := TrendSince(start, end, periods)
+	for _, l := range loves {
+		switch {
+		case l.Timestamp.Before(since) || !l.Timestamp.Before(end):
+		case l.Timestamp.Before(start):
+			previous++
+		default:
+			t.Current++
+		}
+	}
+	if periods > 0 {
+		t.Average = float64(previous) / float64(periods)
+	}
+	return t
+}
+
+/*
+Return the start of the earliest of the periods a trend from start to end
+compares against. Periods which are a whole number of days long are counted in
+calendar days, so that they still start at midnight after the clocks change.
+*/
+func TrendSince(start, end time.Time, periods int) time.Time {
+	days := wholeDays(start, end)
+	if days > 0 {
+		return start.AddDate(0, 0, -days*periods)
+	}
+	return start.Add(-time.Duration(periods) * end.Sub(start))
+}
+
+/*
+Return the number of calendar days from start to end, or 0 if they are not a
+whole number of days apart.
+*/
+func wholeDays(start, end time.Time) int {
+	days := int(math.Round(end.Sub(start).Hours() / 24))
+	if days > 0 && start.AddDate(0, 0, days).Equal(end) {
+		return days
+	}
+	return 0
+}
+
+/*
+Return the change in love from the average of the previous periods to the
+current one, as a fraction of the average: -0.4 if the love fell by 40%. If no
+love was sent during the previous periods, the change is 0.
+*/
+func (t Trend) Change() float64 {
+	if t.Average == 0 {
+		return 0
+	}
+	return (float64(t.Current) - t.Average) / t.Average
+}
+
+/*
+Report whether the love fell by at least threshold, a fraction of the average
+of the previous periods, such as 0.25 for a quarter.
+*/
+func (t Trend) Falling(threshold float64) bool {
+	return t.Average > 0 && t.Change() <= -threshold
+}
+
+/*
+Report whether the love rose by at least threshold, a fraction of the average
+of the previous periods.
+*/
+func (t Trend) Rising(threshold float64) bool {
+	return t.Average > 0 && t.Change() >= threshold
+}
+
+/*
+Describe the trend, such as "down 40% vs the previous 4 weeks".
+*/
+func (t Trend) String() string {
+	previous := "the previous " + t.periods()
+	change := int(math.Round(100 * t.Change()))
+	switch {
+	case t.Average == 0:
+		return "none in " + previous
+	case change < 0:
+		return fmt.Sprintf("down %d%% vs %s", -change, previous)
+	case change > 0:
+		return fmt.Sprintf("up %d%% vs %s", change, previous)
+	}
+	return "level with " + previous
+}
+
+/*
+Name the previous periods, such as "4 weeks", or "2 periods of 3 days".
+*/
+func (t Trend) periods() string {
+	days := int(math.Round(t.Length.Hours() / 24))
+	switch {
+	case t.Periods == 1 && days == 1:
+		return "day"
+	case t.Periods == 1 && days == 7:
+		return "week"
+	case days == 1:
+		return fmt.Sprintf("%d days", t.Periods)
+	case days == 7:
+		return fmt.Sprintf("%d weeks", t.Periods)
+	case t.Periods == 1:
+		return "period of the same length"
+	}
+	return fmt.Sprintf("%d periods of the same length", t.Periods)
+}
+
+/*
+Return the rolling average of the love in a series of buckets: for each
+bucket, the mean Count of the window buckets ending with it, or of every bucket
+so far for the first window-1 buckets. The average of a weekly series is the
+love velocity, the love sent a week, smoothed over the window.
+*/
+func RollingAverage(buckets []Bucket, window int) []float64 {
+	if window < 1 {
+		window = 1
+	}
+	averages := make([]float64, len(buckets))
+	sum := 0
+	for i, b := range buckets {
+		sum += b.Count
+		if i >= window {
+			sum -= buckets[i-window].Count
+		}
+		n := window
+		if i+1 < window {
+			n = i + 1
+		}
+		averages[i] = float64(sum) / float64(n)
+	}
+	return averages
+}
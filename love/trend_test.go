@@ -0,0 +1,64 @@
+package love
+
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "time"
+
+func TestComputeTrend(t *testing.T) {
+	start := time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	var loves []Love
+	// Five love in each of the 4 weeks before, and three this week.
+	for week := 1; week <= 4; week++ {
+		for i := 0; i < 5; i++ {
+			loves = append(loves, testLove("hammy", "darwin", start.AddDate(0, 0, -7*week+i)))
+		}
+	}
+	for i := 0; i < 3; i++ {
+		loves = append(loves, testLove("hammy", "darwin", start.AddDate(0, 0, i)))
+	}
+	// Love too old to compare against, and love after the period.
+	loves = append(loves,
+		testLove("hammy", "darwin", start.AddDate(0, 0, -29)),
+		testLove("hammy", "darwin", end))
+
+	trend := ComputeTrend(loves, start, end, 4)
+	assert.Equal(t, trend, Trend{Current: 3, Average: 5, Periods: 4, Length: 7 * 24 * time.Hour})
+	assert.InDelta(t, trend.Change(), -0.4, 1e-9)
+	assert.True(t, trend.Falling(0.25))
+	assert.False(t, trend.Falling(0.5))
+	assert.False(t, trend.Rising(0.25))
+	assert.Equal(t, trend.String(), "down 40% vs the previous 4 weeks")
+}
+
+func TestTrendString(t *testing.T) {
+	day := 24 * time.Hour
+	assert.Equal(t, Trend{Current: 6, Average: 4, Periods: 2, Length: day}.String(),
+		"up 50% vs the previous 2 days")
+	assert.Equal(t, Trend{Current: 4, Average: 4, Periods: 1, Length: 7 * day}.String(),
+		"level with the previous week")
+	assert.Equal(t, Trend{Current: 4, Periods: 3, Length: 3 * day}.String(),
+		"none in the previous 3 periods of the same length")
+	assert.Equal(t, Trend{Current: 4}.Change(), 0.0)
+	assert.False(t, Trend{Current: 0}.Falling(0.25))
+}
+
+func TestTrendSince(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	// The clocks went forward on 2024-03-10, so the week before is an hour
+	// shorter, but the periods compared against still start at midnight.
+	start := time.Date(2024, 3, 11, 0, 0, 0, 0, loc)
+	end := start.AddDate(0, 0, 7)
+	assert.Equal(t, TrendSince(start, end, 2), time.Date(2024, 2, 26, 0, 0, 0, 0, loc))
+	assert.Equal(t, TrendSince(start, start.Add(12*time.Hour), 2), start.Add(-24*time.Hour))
+}
+
+func TestRollingAverage(t *testing.T) {
+	buckets := []Bucket{{Count: 2}, {Count: 4}, {Count: 6}, {Count: 0}}
+	assert.Equal(t, RollingAverage(buckets, 2), []float64{2, 3, 5, 3})
+	assert.Equal(t, RollingAverage(buckets, 1), []float64{2, 4, 6, 0})
+	assert.Empty(t, RollingAverage(nil, 4))
+}
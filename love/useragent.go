@@ -0,0 +1,40 @@
+package love
+
+import "strings"
+
+/*
+The version of the love package, as sent in DefaultUserAgent.
+*/
+const Version = "0.2.0"
+
+/*
+The User-Agent header a Client sends unless its UserAgent is set, so that the
+administrators of an instance can tell which clients, and which versions of
+them, are making requests.
+*/
+const DefaultUserAgent = "golove/" + Version
+
+/*
+Return a User-Agent which identifies a program built on the love package, such
+as "love-bot/1.2 (ops@example.com)", followed by DefaultUserAgent, as products
+are listed in a User-Agent most significant first. Empty products are left out.
+*/
+func UserAgent(products ...string) string {
+	var parts []string
+	for _, product := range products {
+		if product = strings.TrimSpace(product); product != "" {
+			parts = append(parts, product)
+		}
+	}
+	return strings.Join(append(parts, DefaultUserAgent), " ")
+}
+
+/*
+Return the User-Agent the client sends.
+*/
+func (c *Client) userAgent() string {
+	if c.UserAgent != "" {
+		return c.UserAgent
+	}
+	return DefaultUserAgent
+}
@@ -0,0 +1,23 @@
+package love
+
+import "context"
+import "testing"
+import "github.com/stretchr/testify/assert"
+
+func TestUserAgent(t *testing.T) {
+	assert.Equal(t, UserAgent(), DefaultUserAgent)
+	assert.Equal(t, UserAgent("love-bot/1.2", " ", "(ops@example.com)"),
+		"love-bot/1.2 (ops@example.com) golove/"+Version)
+}
+
+func TestRequestUserAgent(t *testing.T) {
+	client := getTestClient()
+	req, err := client.newRequest(context.Background(), testBaseUrl, getRequest("/love", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, req.Header.Get("User-Agent"), DefaultUserAgent)
+
+	client.UserAgent = UserAgent("love-bot/1.2")
+	req, err = client.newRequest(context.Background(), testBaseUrl, getRequest("/love", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, req.Header.Get("User-Agent"), "love-bot/1.2 "+DefaultUserAgent)
+}
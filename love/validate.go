@@ -0,0 +1,117 @@
+package love
+
+import "context"
+import "errors"
+import "fmt"
+import "strings"
+
+/*
+ErrUnknownRecipient matches an *UnknownRecipientsError with errors.Is.
+*/
+var ErrUnknownRecipient = errors.New("love: unknown recipient")
+
+/*
+UnknownRecipientsError is returned by SendLove when StrictRecipients is set and
+some recipients do not exist. No love is sent.
+*/
+type UnknownRecipientsError struct {
+	Names []string
+}
+
+func (e *UnknownRecipientsError) Error() string {
+	return fmt.Sprintf("unknown recipients: %s", strings.Join(e.Names, ", "))
+}
+
+func (e *UnknownRecipientsError) Is(target error) bool {
+	return target == ErrUnknownRecipient
+}
+
+/*
+ErrTooManyRecipients matches a *TooManyRecipientsError with errors.Is.
+*/
+var ErrTooManyRecipients = errors.New("love: too many recipients")
+
+/*
+TooManyRecipientsError is returned when love is sent to more distinct
+recipients, Count, than the client's MaxRecipients, Max. No love is sent.
+*/
+type TooManyRecipientsError struct {
+	Count int
+	Max   int
+}
+
+func (e *TooManyRecipientsError) Error() string {
+	return fmt.Sprintf("%d recipients is more than the maximum of %d", e.Count, e.Max)
+}
+
+func (e *TooManyRecipientsError) Is(target error) bool {
+	return target == ErrTooManyRecipients
+}
+
+/*
+Return a *TooManyRecipientsError if there are more distinct names than
+MaxRecipients.
+*/
+func (c *Client) checkRecipientCount(names []string) error {
+	if c.MaxRecipients <= 0 {
+		return nil
+	}
+	distinct := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			distinct[name] = true
+		}
+	}
+	if len(distinct) > c.MaxRecipients {
+		return &TooManyRecipientsError{Count: len(distinct), Max: c.MaxRecipients}
+	}
+	return nil
+}
+
+/*
+Check that each name is the username of an existing user, by looking it up with
+Autocomplete and requiring a result whose username matches exactly. Returns the
+names which do not exist, in the order given, or an empty slice if they all do.
+*/
+func (c *Client) ValidateRecipients(names []string) ([]string, error) {
+	return c.ValidateRecipientsContext(context.Background(), names)
+}
+
+/*
+ValidateRecipientsContext is like ValidateRecipients, but each lookup is made
+with a context, which may cancel it.
+*/
+func (c *Client) ValidateRecipientsContext(ctx context.Context, names []string) ([]string, error) {
+	unknown := []string{}
+	for _, name := range names {
+		users, err := c.AutocompleteContext(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, u := range users {
+			if u.Username == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown, nil
+}
+
+/*
+Return an *UnknownRecipientsError if any of the names do not exist.
+*/
+func (c *Client) checkRecipients(ctx context.Context, names []string) error {
+	unknown, err := c.ValidateRecipientsContext(ctx, names)
+	if err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		return &UnknownRecipientsError{Names: unknown}
+	}
+	return nil
+}
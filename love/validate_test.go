@@ -0,0 +1,137 @@
+package love
+
+import "context"
+import "errors"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+
+func registerDirectory(t *testing.T) {
+	httpmock.RegisterResponder(
+		"GET", testAutocompleteUrl,
+		func(req *http.Request) (*http.Response, error) {
+			switch req.URL.Query().Get("term") {
+			case "darwin":
+				return httpmock.NewStringResponse(200,
+					`[{"label": "Darwin Dog (darwin)", "value": "darwin"},
+{"label": "Darwina Cat (darwina)", "value": "darwina"}]`), nil
+			case "dar":
+				return httpmock.NewStringResponse(200,
+					`[{"label": "Darwin Dog (darwin)", "value": "darwin"}]`), nil
+			}
+			return httpmock.NewStringResponse(200, "[]"), nil
+		},
+	)
+}
+
+func TestValidateRecipients(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerDirectory(t)
+
+	client := getTestClient()
+	unknown, err := client.ValidateRecipients([]string{"darwin", "dar", "nobody"})
+	assert.Nil(t, err)
+	assert.Equal(t, unknown, []string{"dar", "nobody"})
+
+	unknown, err = client.ValidateRecipients([]string{"darwin"})
+	assert.Nil(t, err)
+	assert.Equal(t, unknown, []string{})
+}
+
+func TestSendLoveStrictRecipients(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerDirectory(t)
+
+	posts := 0
+	var recipient string
+	httpmock.RegisterResponder(
+		"POST", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			posts++
+			recipient = req.FormValue("recipient")
+			return httpmock.NewStringResponse(201, "Love sent!"), nil
+		},
+	)
+
+	client := getTestClient()
+	client.StrictRecipients = true
+	err := client.SendLoves("hammy", []string{"darwin", "nobody"}, "message")
+	assert.True(t, errors.Is(err, ErrUnknownRecipient))
+	var unknownErr *UnknownRecipientsError
+	assert.True(t, errors.As(err, &unknownErr))
+	assert.Equal(t, unknownErr.Names, []string{"nobody"})
+	assert.Equal(t, posts, 0)
+
+	err = client.SendLove("hammy", "darwin", "message")
+	assert.Nil(t, err)
+	assert.Equal(t, posts, 1)
+
+	// The names validated are the names sent.
+	err = client.SendLove("hammy", " darwin , ", "message")
+	assert.Nil(t, err)
+	assert.Equal(t, posts, 2)
+	assert.Equal(t, recipient, "darwin")
+}
+
+type validateKey struct{}
+
+func TestSendLoveStrictRecipientsContext(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	registerDirectory(t)
+	httpmock.RegisterResponder("POST", testLoveUrl, httpmock.NewStringResponder(201, "Love sent!"))
+
+	client := getTestClient()
+	client.StrictRecipients = true
+	var lookups []interface{}
+	client.Middleware = []Middleware{func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/api/autocomplete" {
+				lookups = append(lookups, req.Context().Value(validateKey{}))
+			}
+			return next.RoundTrip(req)
+		})
+	}}
+	ctx := context.WithValue(context.Background(), validateKey{}, "traced")
+	assert.Nil(t, client.SendLoveContext(ctx, "hammy", "darwin", "message"))
+	assert.Equal(t, lookups, []interface{}{"traced"})
+}
+
+func TestSendLoveMaxRecipients(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	posts := 0
+	httpmock.RegisterResponder(
+		"POST", testLoveUrl,
+		func(*http.Request) (*http.Response, error) {
+			posts++
+			return httpmock.NewStringResponse(201, "Love sent!"), nil
+		},
+	)
+
+	client := getTestClient()
+	client.MaxRecipients = 2
+	err := client.SendLove("hammy", "darwin, jeremy,hammy", "message")
+	assert.True(t, errors.Is(err, ErrTooManyRecipients))
+	var tooManyErr *TooManyRecipientsError
+	assert.True(t, errors.As(err, &tooManyErr))
+	assert.Equal(t, tooManyErr.Count, 3)
+	assert.Equal(t, tooManyErr.Max, 2)
+	assert.Equal(t, posts, 0)
+
+	// Repeated recipients are only counted once.
+	err = client.SendLoves("hammy", []string{"darwin", "jeremy", "darwin"}, "message")
+	assert.Nil(t, err)
+	assert.Equal(t, posts, 1)
+
+	results := client.SendLoveEach("hammy", []string{"darwin", "jeremy", "hammy"}, "message", 2)
+	assert.Equal(t, len(results), 3)
+	for _, err := range results {
+		assert.True(t, errors.Is(err, ErrTooManyRecipients))
+	}
+	assert.Equal(t, posts, 1)
+}
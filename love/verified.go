@@ -0,0 +1,118 @@
+package love
+
+import "fmt"
+import "strings"
+
+/*
+RecipientError is the reason love could not be sent to Recipient. Err is an
+*UnknownRecipientsError if the recipient does not exist, and otherwise the
+error returned when sending them love.
+*/
+type RecipientError struct {
+	Recipient string
+	Err       error
+}
+
+func (e *RecipientError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Recipient, e.Err)
+}
+
+func (e *RecipientError) Unwrap() error {
+	return e.Err
+}
+
+/*
+MultiError is returned by SendLovesVerified when love could not be sent to some
+of the recipients. Sent holds the recipients who were sent love, and Failed
+the others, each in the order given. errors.Is and errors.As match the error of
+any failed recipient.
+*/
+type MultiError struct {
+	Sent   []string
+	Failed []*RecipientError
+}
+
+func (e *MultiError) Error() string {
+	reasons := make([]string, len(e.Failed))
+	for i, failure := range e.Failed {
+		reasons[i] = failure.Error()
+	}
+	return fmt.Sprintf("love could not be sent to %d of %d recipients: %s",
+		len(e.Failed), len(e.Failed)+len(e.Sent), strings.Join(reasons, "; "))
+}
+
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, failure := range e.Failed {
+		errs[i] = failure
+	}
+	return errs
+}
+
+/*
+Send a separate love from a user to each of the recipients, and report exactly
+which of them received it. The server accepts love for a comma separated list
+of recipients even if some of them do not exist, so unlike SendLoves, this
+first checks that each recipient exists, as StrictRecipients does, and then
+sends love to the ones who do individually, as SendLoveEach does.
+
+If every recipient was sent love, the result is nil. If some were not, it is a
+*MultiError listing who was and who was not. Any other error, such as a failure
+to check the recipients or a *TooManyRecipientsError, means no love was sent.
+*/
+func (c *Client) SendLovesVerified(from string, to []string, message string) error {
+	to, err := c.expandGroups(to)
+	if err != nil {
+		return err
+	}
+	var names []string
+	seen := make(map[string]bool, len(to))
+	for _, name := range to {
+		if name = strings.TrimSpace(name); name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if err := c.checkRecipientCount(names); err != nil {
+		return err
+	}
+	unknown, err := c.ValidateRecipients(names)
+	if err != nil {
+		return err
+	}
+	isUnknown := make(map[string]bool, len(unknown))
+	for _, name := range unknown {
+		isUnknown[name] = true
+	}
+	var known []string
+	for _, name := range names {
+		if !isUnknown[name] {
+			known = append(known, name)
+		}
+	}
+	concurrency := DefaultConcurrency
+	if c.DryRun != nil {
+		// Keep the requests written for a dry run apart.
+		concurrency = 1
+	}
+	results := c.SendLoveEach(from, known, message, concurrency)
+
+	multiErr := &MultiError{}
+	for _, name := range names {
+		var err error
+		if isUnknown[name] {
+			err = &UnknownRecipientsError{Names: []string{name}}
+		} else {
+			err = results[name]
+		}
+		if err != nil {
+			multiErr.Failed = append(multiErr.Failed, &RecipientError{name, err})
+		} else {
+			multiErr.Sent = append(multiErr.Sent, name)
+		}
+	}
+	if len(multiErr.Failed) > 0 {
+		return multiErr
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package love
+
+import "errors"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "io/ioutil"
+import "net/http"
+import "net/url"
+import "sync"
+
+func TestSendLovesVerified(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"GET", testAutocompleteUrl,
+		func(req *http.Request) (*http.Response, error) {
+			term := req.URL.Query().Get("term")
+			if term == "darwin" || term == "jeremy" {
+				return httpmock.NewStringResponse(200,
+					`[{"label": "Someone (`+term+`)", "value": "`+term+`"}]`), nil
+			}
+			return httpmock.NewStringResponse(200, "[]"), nil
+		},
+	)
+	var mutex sync.Mutex
+	received := make(map[string]int)
+	httpmock.RegisterResponder(
+		"POST", testLoveUrl,
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			values, _ := url.ParseQuery(string(body))
+			recipient := values.Get("recipient")
+			mutex.Lock()
+			received[recipient]++
+			mutex.Unlock()
+			if recipient == "jeremy" {
+				return httpmock.NewStringResponse(500, "oops"), nil
+			}
+			return httpmock.NewStringResponse(201, "Love sent!"), nil
+		},
+	)
+
+	client := getTestClient()
+	err := client.SendLovesVerified("hammy", []string{"darwin", "nobody", " jeremy", "darwin"},
+		"message")
+	var multiErr *MultiError
+	assert.True(t, errors.As(err, &multiErr))
+	assert.Equal(t, multiErr.Sent, []string{"darwin"})
+	assert.Equal(t, len(multiErr.Failed), 2)
+	assert.Equal(t, multiErr.Failed[0].Recipient, "nobody")
+	assert.True(t, errors.Is(multiErr.Failed[0], ErrUnknownRecipient))
+	assert.Equal(t, multiErr.Failed[1].Recipient, "jeremy")
+	assert.True(t, IsTemporary(multiErr.Failed[1]))
+	assert.True(t, errors.Is(err, ErrUnknownRecipient))
+	assert.Contains(t, err.Error(), "2 of 3 recipients")
+	assert.Equal(t, received, map[string]int{"darwin": 1, "jeremy": 1})
+
+	err = client.SendLovesVerified("hammy", []string{"darwin"}, "message")
+	assert.Nil(t, err)
+	assert.Equal(t, received["darwin"], 2)
+}
@@ -0,0 +1,244 @@
+package love
+
+import "context"
+import "time"
+
+/*
+The longest a Watcher waits between polls while polling keeps failing.
+*/
+const MaxWatchBackoff = 10 * time.Minute
+
+/*
+The interval a Watcher polls at when it is given one which is not positive.
+*/
+const DefaultWatchInterval = time.Minute
+
+/*
+A Watcher polls the API for love matching a filter and delivers each new love
+on C, oldest first. Love which already existed when the Watcher was created is
+not delivered. Errors from polling are delivered on Errors, which is buffered;
+an error is dropped if the previous one has not been received yet. Polling
+continues after an error, but the wait before the next poll doubles with each
+failure in a row, up to MaxWatchBackoff, so that an API which is down is not
+polled needlessly often. Both channels are closed once the Watcher stops.
+
+	w := client.NewWatcher(love.LoveFilter{Recipient: "darwin"}, time.Minute)
+	defer w.Stop()
+	for l := range w.C {
+		fmt.Println(l.Sender, "sent love:", l.Message)
+	}
+
+See also Watch, which stops when a context is done.
+*/
+type Watcher struct {
+	C      <-chan Love
+	Errors <-chan error
+
+	client   *Client
+	filter   LoveFilter
+	interval time.Duration
+	loves    chan Love
+	errors   chan error
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	started bool
+	cursor  Cursor
+}
+
+/*
+Create a Watcher which polls for love matching a filter every interval. The
+Limit of the filter is ignored, and an interval which is not positive is
+replaced by DefaultWatchInterval. The first poll happens immediately, and finds
+the love which already exists.
+*/
+func (c *Client) NewWatcher(f LoveFilter, interval time.Duration) *Watcher {
+	return c.newWatcher(context.Background(), f, interval, Cursor{})
+}
+
+/*
+Watch polls for love matching a filter every interval until ctx is done, and
+returns channels on which new love and errors are delivered, as by the C and
+Errors of a Watcher. An interval which is not positive is replaced by
+DefaultWatchInterval. The first poll happens immediately, and finds the love
+which already exists, which is not delivered. Both channels are closed once ctx
+is done and polling has stopped; a request in progress is cancelled.
+
+	loves, errs := client.Watch(ctx, love.LoveFilter{Recipient: "darwin"}, time.Minute)
+	for loves != nil || errs != nil {
+		select {
+		case l, ok := <-loves:
+			if !ok {
+				loves = nil
+				continue
+			}
+			fmt.Println(l.Sender, "sent love:", l.Message)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			log.Print(err)
+		}
+	}
+*/
+func (c *Client) Watch(ctx context.Context, f LoveFilter,
+	interval time.Duration) (<-chan Love, <-chan error) {
+	return c.WatchFrom(ctx, f, interval, Cursor{})
+}
+
+/*
+WatchFrom is like Watch, but resumes from a cursor which was saved earlier, so
+that love which arrived in between is delivered too, and none is delivered
+twice. A zero cursor starts from the love which exists now, as Watch does. To
+save it, add each love to the cursor with Add once it has been handled:
+
+	loves, errs := client.WatchFrom(ctx, filter, time.Minute, cursor)
+	...
+	case l := <-loves:
+		handle(l)
+		cursor.Add(l)
+		save(cursor)
+*/
+func (c *Client) WatchFrom(ctx context.Context, f LoveFilter, interval time.Duration,
+	cursor Cursor) (<-chan Love, <-chan error) {
+	w := c.newWatcher(ctx, f, interval, cursor)
+	return w.C, w.Errors
+}
+
+func (c *Client) newWatcher(ctx context.Context, f LoveFilter, interval time.Duration,
+	cursor Cursor) *Watcher {
+	if interval <= 0 {
+		// Otherwise the Watcher would poll without pausing.
+		interval = DefaultWatchInterval
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	w := &Watcher{
+		client:   c,
+		filter:   f,
+		interval: interval,
+		loves:    make(chan Love),
+		errors:   make(chan error, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		started:  !cursor.IsZero(),
+		cursor:   cursor,
+	}
+	// The cursor's Seen is appended to.
+	w.cursor.Seen = append([]string(nil), cursor.Seen...)
+	w.C = w.loves
+	w.Errors = w.errors
+	go w.run()
+	return w
+}
+
+/*
+Stop polling, and close C and Errors. Stop waits for the Watcher to finish. It
+may be called more than once.
+*/
+func (w *Watcher) Stop() {
+	w.cancel()
+	<-w.done
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+	defer close(w.errors)
+	defer close(w.loves)
+	failures := 0
+	for {
+		if w.poll() {
+			failures = 0
+		} else {
+			failures++
+		}
+		if w.ctx.Err() != nil {
+			return
+		}
+		timer := time.NewTimer(watchBackoff(w.interval, failures))
+		select {
+		case <-timer.C:
+		case <-w.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+/*
+Return the wait before polling again after failures in a row.
+*/
+func watchBackoff(interval time.Duration, failures int) time.Duration {
+	wait := interval
+	for i := 0; i < failures && wait < MaxWatchBackoff; i++ {
+		wait *= 2
+	}
+	if wait > MaxWatchBackoff && interval < MaxWatchBackoff {
+		wait = MaxWatchBackoff
+	}
+	return wait
+}
+
+/*
+Fetch and deliver new love, until the Watcher is stopped. Returns false if
+fetching failed.
+*/
+func (w *Watcher) poll() bool {
+	fresh, err := w.fetch()
+	if err != nil {
+		if w.ctx.Err() == nil {
+			select {
+			case w.errors <- err:
+			default:
+			}
+		}
+		return false
+	}
+	for _, l := range fresh {
+		select {
+		case w.loves <- l:
+		case <-w.ctx.Done():
+			return true
+		}
+	}
+	return true
+}
+
+/*
+Return the love which the cursor is not past, oldest first, and move the cursor
+past it. The API returns love newest first, so pages are only fetched until a
+love older than the newest one seen is found. The first poll of a Watcher
+without a cursor only moves the cursor past the love which already exists.
+*/
+func (w *Watcher) fetch() ([]Love, error) {
+	var found []Love
+	it := w.client.IterLoveFilteredContext(w.ctx, w.filter)
+	for it.Next() {
+		l := it.Love()
+		if l.Timestamp.Before(w.cursor.Newest) {
+			break
+		}
+		// The first poll only needs the love sharing the newest timestamp.
+		if !w.started && len(found) > 0 && l.Timestamp.Before(found[0].Timestamp) {
+			break
+		}
+		found = append(found, l)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	var fresh []Love
+	for i := len(found) - 1; i >= 0; i-- {
+		// Love fetched twice, as pages shift, is only added once.
+		if w.cursor.Add(found[i]) {
+			fresh = append(fresh, found[i])
+		}
+	}
+	if !w.started {
+		w.started = true
+		return nil, nil
+	}
+	return fresh, nil
+}
@@ -0,0 +1,200 @@
+package love
+
+import "context"
+import "gopkg.in/jarcoal/httpmock.v1"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "net/http"
+import "strings"
+import "sync"
+import "time"
+
+/*
+A responder serving a list of love which can grow while a test runs.
+*/
+type growingResponder struct {
+	mutex sync.Mutex
+	loves []string
+}
+
+func (g *growingResponder) add(message, timestamp string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.loves = append([]string{`{"sender": "hammy", "recipient": "darwin",
+"message": "` + message + `", "timestamp": "` + timestamp + `"}`}, g.loves...)
+}
+
+func (g *growingResponder) respond(req *http.Request) (*http.Response, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	body := "[" + strings.Join(g.loves, ",") + "]"
+	return httpmock.NewStringResponse(200, body), nil
+}
+
+func receive(t *testing.T, w *Watcher) Love {
+	select {
+	case l := <-w.C:
+		return l
+	case err := <-w.Errors:
+		t.Fatal(err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for love")
+	}
+	return Love{}
+}
+
+func TestWatcher(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	responder := &growingResponder{}
+	responder.add("old", "2000-01-01T00:00:00")
+	httpmock.RegisterResponder("GET", testLoveUrl, responder.respond)
+
+	client := getTestClient()
+	w := client.NewWatcher(LoveFilter{Recipient: "darwin"}, 10*time.Millisecond)
+	defer w.Stop()
+	time.Sleep(30 * time.Millisecond)
+
+	responder.add("first", "2000-01-02T00:00:00")
+	responder.add("second", "2000-01-02T00:00:00")
+	first := receive(t, w)
+	second := receive(t, w)
+	messages := []string{first.Message, second.Message}
+	assert.ElementsMatch(t, messages, []string{"first", "second"})
+
+	responder.add("third", "2000-01-03T00:00:00")
+	assert.Equal(t, receive(t, w).Message, "third")
+}
+
+func TestWatcherErrors(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder(
+		"GET", testLoveUrl,
+		httpmock.NewStringResponder(500, "oops"),
+	)
+
+	client := getTestClient()
+	w := client.NewWatcher(LoveFilter{Recipient: "darwin"}, 10*time.Millisecond)
+	select {
+	case err := <-w.Errors:
+		assert.NotNil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an error")
+	}
+	w.Stop()
+	_, ok := <-w.C
+	assert.False(t, ok)
+}
+
+func TestWatch(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	responder := &growingResponder{}
+	responder.add("old", "2000-01-01T00:00:00")
+	httpmock.RegisterResponder("GET", testLoveUrl, responder.respond)
+
+	client := getTestClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	loves, errs := client.Watch(ctx, LoveFilter{Recipient: "darwin"}, 10*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+
+	responder.add("new", "2000-01-02T00:00:00")
+	select {
+	case l := <-loves:
+		assert.Equal(t, l.Message, "new")
+	case err := <-errs:
+		t.Fatal(err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for love")
+	}
+
+	cancel()
+	for loves != nil || errs != nil {
+		select {
+		case _, ok := <-loves:
+			assert.False(t, ok)
+			loves = nil
+		case err, ok := <-errs:
+			assert.False(t, ok, "unexpected error %v", err)
+			errs = nil
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the channels to close")
+		}
+	}
+}
+
+func TestWatchBackoff(t *testing.T) {
+	assert.Equal(t, watchBackoff(time.Minute, 0), time.Minute)
+	assert.Equal(t, watchBackoff(time.Minute, 1), 2*time.Minute)
+	assert.Equal(t, watchBackoff(time.Minute, 3), 8*time.Minute)
+	assert.Equal(t, watchBackoff(time.Minute, 4), MaxWatchBackoff)
+	assert.Equal(t, watchBackoff(time.Minute, 100), MaxWatchBackoff)
+	assert.Equal(t, watchBackoff(time.Hour, 2), time.Hour)
+}
+
+func TestWatcherInterval(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var mutex sync.Mutex
+	polls := 0
+	httpmock.RegisterResponder("GET", testLoveUrl, func(req *http.Request) (*http.Response, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		polls++
+		return httpmock.NewStringResponse(200, "[]"), nil
+	})
+
+	client := getTestClient()
+	for _, interval := range []time.Duration{0, -time.Second} {
+		w := client.NewWatcher(LoveFilter{Recipient: "darwin"}, interval)
+		assert.Equal(t, w.interval, DefaultWatchInterval)
+		time.Sleep(30 * time.Millisecond)
+		w.Stop()
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, polls, 2)
+}
+
+func TestWatchFrom(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	responder := &growingResponder{}
+	responder.add("old", "2000-01-01T00:00:00")
+	responder.add("seen", "2000-01-02T00:00:00")
+	// Sent after the cursor was saved, but at the same time as seen.
+	responder.add("missed", "2000-01-02T00:00:00")
+	responder.add("new", "2000-01-03T00:00:00")
+	httpmock.RegisterResponder("GET", testLoveUrl, responder.respond)
+
+	var cursor Cursor
+	cursor.Add(Love{Sender: "hammy", Recipient: "darwin", Message: "seen",
+		Timestamp: time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)})
+	client := getTestClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	loves, errs := client.WatchFrom(ctx, LoveFilter{Recipient: "darwin"}, 10*time.Millisecond, cursor)
+	var messages []string
+	for len(messages) < 2 {
+		select {
+		case l := <-loves:
+			messages = append(messages, l.Message)
+		case err := <-errs:
+			t.Fatal(err)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for love")
+		}
+	}
+	assert.Equal(t, messages, []string{"missed", "new"})
+	select {
+	case l := <-loves:
+		t.Fatalf("unexpected love %q", l.Message)
+	case <-time.After(30 * time.Millisecond):
+	}
+}
@@ -0,0 +1,152 @@
+package love
+
+import "regexp"
+import "strings"
+import "unicode"
+
+/*
+Stopwords holds the common English words which say little about why love was
+sent, in lower case, such as "the" and "for". WordFrequencies leaves them out,
+unless given other stopwords.
+*/
+var Stopwords = stopwords(`
+	a about above after again all also am an and any are aren't as at be because
+	been before being below between both but by can can't could couldn't did
+	didn't do does doesn't doing don't down during each even every few for from
+	further get got had hadn't has hasn't have haven't having he he'd he'll he's
+	her here here's hers herself him himself his how how's i i'd i'll i'm i've if
+	in into is isn't it it's its itself just let's me more most much my myself no
+	nor not now of off on once one only or other ought our ours ourselves out over
+	own really same shan't she she'd she'll she's should shouldn't so some such
+	than that that's the their theirs them themselves then there there's these
+	they they'd they'll they're they've this those through to too under until up
+	us very was wasn't we we'd we'll we're we've were weren't what what's when
+	when's where where's which while who who's whom why why's will with won't
+	would wouldn't you you'd you'll you're you've your yours yourself yourselves
+`)
+
+func stopwords(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+/*
+WordOptions change how WordFrequencies counts the words in love. Stopwords
+holds the words to leave out, in lower case; if nil, Stopwords is used. With
+Bigrams, pairs of words which follow each other in a message, such as "great
+demo", are counted as well as single words. Stopwords and punctuation separate
+the words on either side of them, so "thanks for the help" has no bigrams.
+*/
+type WordOptions struct {
+	Stopwords map[string]bool
+	Bigrams   bool
+}
+
+var urlPattern = regexp.MustCompile(`\b(?:https?://|www\.)\S+`)
+
+/*
+Tokenize returns the words in a message, in lower case and in order, as counted
+by WordFrequencies. Markdown formatting, link URLs, emoji shortcodes and
+@mentions are left out, as are numbers; hashtags are words, without the #.
+Apostrophes within a word are kept, so "don't" is one word.
+*/
+func Tokenize(message string) []string {
+	var words []string
+	for _, phrase := range phrases(message) {
+		words = append(words, phrase...)
+	}
+	return words
+}
+
+/*
+Split the words in a message, as returned by Tokenize, into phrases, which
+punctuation, and anything left out of the words, separates.
+*/
+func phrases(message string) [][]string {
+	var text strings.Builder
+	for _, span := range ParseMarkdown(message) {
+		text.WriteString(span.Text)
+		text.WriteByte(' ')
+	}
+	plain := urlPattern.ReplaceAllString(text.String(), ".")
+	plain = shortcodePattern.ReplaceAllString(plain, ".")
+	plain = strings.ReplaceAll(plain, "’", "'")
+
+	var phrases [][]string
+	var phrase []string
+	runes := []rune(plain)
+	for i := 0; i < len(runes); i++ {
+		if !isWordRune(runes[i]) {
+			if runes[i] == '@' {
+				// Skip mentions, and the domains of email addresses.
+				for i+1 < len(runes) && isMentionRune(runes[i+1]) {
+					i++
+				}
+			}
+			if !unicode.IsSpace(runes[i]) && runes[i] != '#' && len(phrase) > 0 {
+				phrases = append(phrases, phrase)
+				phrase = nil
+			}
+			continue
+		}
+		end := i
+		for end < len(runes) && (isWordRune(runes[end]) ||
+			runes[end] == '\'' && end+1 < len(runes) && isWordRune(runes[end+1])) {
+			end++
+		}
+		word := strings.ToLower(string(runes[i:end]))
+		if strings.IndexFunc(word, unicode.IsLetter) >= 0 {
+			phrase = append(phrase, word)
+		} else if len(phrase) > 0 {
+			phrases = append(phrases, phrase)
+			phrase = nil
+		}
+		i = end - 1
+	}
+	if len(phrase) > 0 {
+		phrases = append(phrases, phrase)
+	}
+	return phrases
+}
+
+/*
+WordFrequencies counts the words, and optionally the bigrams, in the messages
+of a collection of love, for a word cloud. Each term is counted once for each
+love it appears in, so one effusive message cannot dominate. The n most
+frequent terms are returned, most frequent first, as by TopCounts; if n <= 0,
+every term is.
+*/
+func WordFrequencies(loves []Love, options WordOptions, n int) []Count {
+	stop := options.Stopwords
+	if stop == nil {
+		stop = Stopwords
+	}
+	counts := make(map[string]int)
+	for _, l := range loves {
+		seen := make(map[string]bool)
+		count := func(term string) {
+			if !seen[term] {
+				seen[term] = true
+				counts[term]++
+			}
+		}
+		for _, phrase := range phrases(l.Message) {
+			previous := ""
+			for _, word := range phrase {
+				if stop[word] {
+					previous = ""
+					continue
+				}
+				count(word)
+				if options.Bigrams && previous != "" {
+					count(previous + " " + word)
+				}
+				previous = word
+			}
+		}
+	}
+	return TopCounts(counts, n)
+}
@@ -0,0 +1,38 @@
+package love
+
+import "testing"
+import "github.com/stretchr/testify/assert"
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, Tokenize("Thanks @darwin.lee for the **GREAT** demo!"),
+		[]string{"thanks", "for", "the", "great", "demo"})
+	assert.Equal(t, Tokenize("See [the slides](https://example.com/slides) :tada: #Demo"),
+		[]string{"see", "the", "slides", "demo"})
+	assert.Equal(t, Tokenize("You’re the best, don't go! https://example.com/x 2017 v2"),
+		[]string{"you're", "the", "best", "don't", "go", "v2"})
+	assert.Equal(t, Tokenize("email me at hammy@example.com"),
+		[]string{"email", "me", "at", "hammy"})
+	assert.Empty(t, Tokenize(":+1: <3"))
+}
+
+func TestWordFrequencies(t *testing.T) {
+	loves := []Love{
+		{Message: "Thanks for the great demo, great work! Great."},
+		{Message: "great demo"},
+		{Message: "Thanks for the help"},
+	}
+	assert.Equal(t, WordFrequencies(loves, WordOptions{}, 0), []Count{
+		{"demo", 2}, {"great", 2}, {"thanks", 2}, {"help", 1}, {"work", 1},
+	})
+	assert.Equal(t, WordFrequencies(loves, WordOptions{Bigrams: true}, 3), []Count{
+		{"demo", 2}, {"great", 2}, {"great demo", 2},
+	})
+	bigrams := WordFrequencies(loves, WordOptions{Bigrams: true}, 0)
+	assert.Contains(t, bigrams, Count{"great work", 1})
+	assert.NotContains(t, bigrams, Count{"demo great", 1})
+
+	stop := map[string]bool{"thanks": true}
+	assert.Equal(t, WordFrequencies(loves, WordOptions{Stopwords: stop}, 3), []Count{
+		{"demo", 2}, {"for", 2}, {"great", 2},
+	})
+}
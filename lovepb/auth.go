@@ -0,0 +1,55 @@
+package lovepb
+
+import (
+	"context"
+	"crypto/subtle"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// The methods which send love, and so need authorizing.
+var sendMethods = map[string]bool{
+	LoveService_SendLove_FullMethodName: true,
+}
+
+/*
+Return an interceptor which only allows calls which send love, such as
+SendLove, if they carry token in their authorization metadata, as a bearer
+token:
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+
+Other calls are allowed without it. Calls without the token fail with
+Unauthenticated. Install the interceptor with grpc.UnaryInterceptor:
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(lovepb.AuthInterceptor(token)))
+*/
+func AuthInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (interface{}, error) {
+		if sendMethods[info.FullMethod] && !authorized(ctx, token) {
+			return nil, status.Error(codes.Unauthenticated, "sending love requires the token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+/*
+Report whether the metadata of an incoming call bears a token.
+*/
+func authorized(ctx context.Context, token string) bool {
+	if token == "" {
+		return false
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		bearer, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
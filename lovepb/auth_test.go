@@ -0,0 +1,41 @@
+package lovepb
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	client, server := newTestClientWith(t, &Server{},
+		grpc.UnaryInterceptor(AuthInterceptor("token")))
+	req := &SendLoveRequest{Sender: "hammy", Recipients: []string{"darwin"}, Message: "hi"}
+
+	_, err := client.SendLove(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	wrong := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	_, err = client.SendLove(wrong, req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Len(t, server.Loves(), 2)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer token")
+	_, err = client.SendLove(ctx, req)
+	assert.Nil(t, err)
+	assert.Len(t, server.Loves(), 3)
+
+	// Reading love needs no token.
+	_, err = client.GetLove(context.Background(), &GetLoveRequest{Recipient: "hammy"})
+	assert.Nil(t, err)
+}
+
+func TestAuthInterceptorNoToken(t *testing.T) {
+	client, _ := newTestClientWith(t, &Server{}, grpc.UnaryInterceptor(AuthInterceptor("")))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer ")
+	_, err := client.SendLove(ctx, &SendLoveRequest{Sender: "hammy",
+		Recipients: []string{"darwin"}, Message: "hi"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,205 @@
+// The love API as a gRPC service. See the lovepb package for the server.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: love.proto
+
+package lovepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	LoveService_GetLove_FullMethodName      = "/golove.v1.LoveService/GetLove"
+	LoveService_SendLove_FullMethodName     = "/golove.v1.LoveService/SendLove"
+	LoveService_Autocomplete_FullMethodName = "/golove.v1.LoveService/Autocomplete"
+)
+
+// LoveServiceClient is the client API for LoveService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LoveServiceClient interface {
+	// Retrieve love sent by or to a user.
+	GetLove(ctx context.Context, in *GetLoveRequest, opts ...grpc.CallOption) (*GetLoveResponse, error)
+	// Send love to one or more recipients.
+	SendLove(ctx context.Context, in *SendLoveRequest, opts ...grpc.CallOption) (*SendLoveResponse, error)
+	// Look up users whose username or name matches a term.
+	Autocomplete(ctx context.Context, in *AutocompleteRequest, opts ...grpc.CallOption) (*AutocompleteResponse, error)
+}
+
+type loveServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLoveServiceClient(cc grpc.ClientConnInterface) LoveServiceClient {
+	return &loveServiceClient{cc}
+}
+
+func (c *loveServiceClient) GetLove(ctx context.Context, in *GetLoveRequest, opts ...grpc.CallOption) (*GetLoveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetLoveResponse)
+	err := c.cc.Invoke(ctx, LoveService_GetLove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loveServiceClient) SendLove(ctx context.Context, in *SendLoveRequest, opts ...grpc.CallOption) (*SendLoveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendLoveResponse)
+	err := c.cc.Invoke(ctx, LoveService_SendLove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *loveServiceClient) Autocomplete(ctx context.Context, in *AutocompleteRequest, opts ...grpc.CallOption) (*AutocompleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AutocompleteResponse)
+	err := c.cc.Invoke(ctx, LoveService_Autocomplete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LoveServiceServer is the server API for LoveService service.
+// All implementations must embed UnimplementedLoveServiceServer
+// for forward compatibility.
+type LoveServiceServer interface {
+	// Retrieve love sent by or to a user.
+	GetLove(context.Context, *GetLoveRequest) (*GetLoveResponse, error)
+	// Send love to one or more recipients.
+	SendLove(context.Context, *SendLoveRequest) (*SendLoveResponse, error)
+	// Look up users whose username or name matches a term.
+	Autocomplete(context.Context, *AutocompleteRequest) (*AutocompleteResponse, error)
+	mustEmbedUnimplementedLoveServiceServer()
+}
+
+// UnimplementedLoveServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLoveServiceServer struct{}
+
+func (UnimplementedLoveServiceServer) GetLove(context.Context, *GetLoveRequest) (*GetLoveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLove not implemented")
+}
+func (UnimplementedLoveServiceServer) SendLove(context.Context, *SendLoveRequest) (*SendLoveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendLove not implemented")
+}
+func (UnimplementedLoveServiceServer) Autocomplete(context.Context, *AutocompleteRequest) (*AutocompleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Autocomplete not implemented")
+}
+func (UnimplementedLoveServiceServer) mustEmbedUnimplementedLoveServiceServer() {}
+func (UnimplementedLoveServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeLoveServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LoveServiceServer will
+// result in compilation errors.
+type UnsafeLoveServiceServer interface {
+	mustEmbedUnimplementedLoveServiceServer()
+}
+
+func RegisterLoveServiceServer(s grpc.ServiceRegistrar, srv LoveServiceServer) {
+	// If the following call pancis, it indicates UnimplementedLoveServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LoveService_ServiceDesc, srv)
+}
+
+func _LoveService_GetLove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoveServiceServer).GetLove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LoveService_GetLove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoveServiceServer).GetLove(ctx, req.(*GetLoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LoveService_SendLove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendLoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoveServiceServer).SendLove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LoveService_SendLove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoveServiceServer).SendLove(ctx, req.(*SendLoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LoveService_Autocomplete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AutocompleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LoveServiceServer).Autocomplete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LoveService_Autocomplete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LoveServiceServer).Autocomplete(ctx, req.(*AutocompleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// LoveService_ServiceDesc is the grpc.ServiceDesc for LoveService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LoveService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "golove.v1.LoveService",
+	HandlerType: (*LoveServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetLove",
+			Handler:    _LoveService_GetLove_Handler,
+		},
+		{
+			MethodName: "SendLove",
+			Handler:    _LoveService_SendLove_Handler,
+		},
+		{
+			MethodName: "Autocomplete",
+			Handler:    _LoveService_Autocomplete_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "love.proto",
+}
@@ -0,0 +1,159 @@
+/*
+Package lovepb exposes the love API as a gRPC service, so that it can be called
+from any language with gRPC support. The service is defined in love.proto, which
+other languages should generate their clients from; this package holds the
+generated Go code, including the client:
+
+	conn, err := grpc.NewClient("localhost:8080",
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatal(err)
+	}
+	client := lovepb.NewLoveServiceClient(conn)
+	resp, err := client.GetLove(ctx, &lovepb.GetLoveRequest{Recipient: "hammy"})
+
+Server implements the service with a love.Client:
+
+	server := grpc.NewServer()
+	lovepb.RegisterLoveServiceServer(server, &lovepb.Server{Client: client})
+
+SendLove sends love as anyone, so a server which others can reach should either
+be ReadOnly or require a token with AuthInterceptor.
+
+Errors from the love API are returned with the closest gRPC status code:
+InvalidArgument for bad parameters, FailedPrecondition when the love could not
+be sent, and Unavailable when the API is down or could not be reached.
+*/
+package lovepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative love.proto
+
+import (
+	"context"
+	"errors"
+	"github.com/hacsoc/golove/love"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"strings"
+)
+
+// The limit of GetLove requests which do not give one.
+const DefaultLimit = 20
+
+// The largest limit a GetLove request may ask for.
+const MaxLimit = 1000
+
+/*
+A Server implements LoveServiceServer by calling the love API with Client.
+*/
+type Server struct {
+	UnimplementedLoveServiceServer
+	Client *love.Client
+	// Rejects SendLove with PermissionDenied.
+	ReadOnly bool
+}
+
+func (s *Server) GetLove(ctx context.Context, req *GetLoveRequest) (*GetLoveResponse, error) {
+	if req.Sender == "" && req.Recipient == "" {
+		return nil, status.Error(codes.InvalidArgument, "a sender or recipient is required")
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+	if limit < 0 || limit > MaxLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "the limit must be between 1 and %d", MaxLimit)
+	}
+	f := love.LoveFilter{Sender: req.Sender, Recipient: req.Recipient, Limit: limit}
+	if req.Since != nil {
+		f.Since = req.Since.AsTime()
+	}
+	if req.Until != nil {
+		f.Until = req.Until.AsTime()
+	}
+	loves, err := s.Client.GetLoveFiltered(f)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	resp := &GetLoveResponse{Loves: make([]*Love, len(loves))}
+	for i, l := range loves {
+		resp.Loves[i] = FromLove(l)
+	}
+	return resp, nil
+}
+
+func (s *Server) SendLove(ctx context.Context, req *SendLoveRequest) (*SendLoveResponse, error) {
+	if s.ReadOnly {
+		return nil, status.Error(codes.PermissionDenied, "sending love is disabled")
+	}
+	if req.Sender == "" {
+		return nil, status.Error(codes.InvalidArgument, "a sender is required")
+	}
+	if len(req.Recipients) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one recipient is required")
+	}
+	to := strings.Join(req.Recipients, ",")
+	if err := s.Client.SendLoveContext(ctx, req.Sender, to, req.Message); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &SendLoveResponse{}, nil
+}
+
+func (s *Server) Autocomplete(ctx context.Context, req *AutocompleteRequest) (*AutocompleteResponse, error) {
+	users, err := s.Client.AutocompleteContext(ctx, req.Term)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	resp := &AutocompleteResponse{Users: make([]*User, len(users))}
+	for i, u := range users {
+		resp.Users[i] = &User{Username: u.Username, Display: u.Display}
+	}
+	return resp, nil
+}
+
+/*
+Convert an error from the love API to a gRPC status error.
+*/
+func statusError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	code := codes.Unknown
+	switch {
+	case errors.Is(err, love.ErrBadParams):
+		code = codes.InvalidArgument
+	case errors.Is(err, love.ErrLoveFailed):
+		code = codes.FailedPrecondition
+	case errors.Is(err, love.ErrUnauthorized):
+		// The server's API key was rejected; the caller can't fix that.
+		code = codes.Internal
+	case love.IsTemporary(err):
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
+
+/*
+Convert a love.Love to its protobuf message.
+*/
+func FromLove(l love.Love) *Love {
+	return &Love{
+		Sender:    l.Sender,
+		Recipient: l.Recipient,
+		Message:   l.Message,
+		Timestamp: timestamppb.New(l.Timestamp),
+	}
+}
+
+/*
+Convert a Love message to a love.Love, with its timestamp in UTC.
+*/
+func (l *Love) ToLove() love.Love {
+	return love.Love{
+		Sender:    l.GetSender(),
+		Recipient: l.GetRecipient(),
+		Message:   l.GetMessage(),
+		Timestamp: l.GetTimestamp().AsTime(),
+	}
+}
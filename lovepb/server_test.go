@@ -0,0 +1,155 @@
+package lovepb
+
+import (
+	"context"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net"
+	"testing"
+	"time"
+)
+
+func at(hour int) time.Time {
+	return time.Date(2017, 4, 1, hour, 0, 0, 0, time.UTC)
+}
+
+/*
+Start a gRPC server backed by a lovetest server, and return a client connected
+to it.
+*/
+func newTestClient(t *testing.T) (LoveServiceClient, *lovetest.Server) {
+	return newTestClientWith(t, &Server{})
+}
+
+/*
+Like newTestClient, but serving with s, whose Client is set to that of the
+lovetest server, and the given options.
+*/
+func newTestClientWith(t *testing.T, s *Server, opts ...grpc.ServerOption) (LoveServiceClient,
+	*lovetest.Server) {
+	server := lovetest.NewServer("secret")
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	server.AddLove(love.Love{Sender: "darwin", Recipient: "hammy", Message: "thanks!", Timestamp: at(1)})
+	server.AddLove(love.Love{Sender: "hammy", Recipient: "darwin", Message: "you too", Timestamp: at(2)})
+
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(opts...)
+	s.Client = server.Client()
+	RegisterLoveServiceServer(grpcServer, s)
+	go grpcServer.Serve(listener)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+		grpcServer.Stop()
+		server.Close()
+	})
+	return NewLoveServiceClient(conn), server
+}
+
+func TestGetLove(t *testing.T) {
+	client, _ := newTestClient(t)
+	resp, err := client.GetLove(context.Background(), &GetLoveRequest{Recipient: "hammy"})
+	assert.Nil(t, err)
+	assert.Len(t, resp.Loves, 1)
+	assert.Equal(t, love.Love{Sender: "darwin", Recipient: "hammy", Message: "thanks!",
+		Timestamp: at(1)}, resp.Loves[0].ToLove())
+
+	resp, err = client.GetLove(context.Background(), &GetLoveRequest{
+		Sender: "hammy",
+		Since:  timestamppb.New(at(3)),
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, resp.Loves)
+}
+
+func TestGetLoveLimit(t *testing.T) {
+	client, server := newTestClient(t)
+	for i := 0; i < 30; i++ {
+		server.AddLove(love.Love{Sender: "darwin", Recipient: "hammy", Message: "thanks!",
+			Timestamp: at(3).Add(time.Duration(i) * time.Minute)})
+	}
+	ctx := context.Background()
+	resp, err := client.GetLove(ctx, &GetLoveRequest{Recipient: "hammy"})
+	assert.Nil(t, err)
+	assert.Len(t, resp.Loves, DefaultLimit)
+	resp, err = client.GetLove(ctx, &GetLoveRequest{Recipient: "hammy", Limit: 25})
+	assert.Nil(t, err)
+	assert.Len(t, resp.Loves, 25)
+	for _, limit := range []int64{-1, MaxLimit + 1} {
+		_, err = client.GetLove(ctx, &GetLoveRequest{Recipient: "hammy", Limit: limit})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), limit)
+	}
+}
+
+func TestSendLove(t *testing.T) {
+	client, server := newTestClient(t)
+	_, err := client.SendLove(context.Background(), &SendLoveRequest{
+		Sender:     "hammy",
+		Recipients: []string{"darwin"},
+		Message:    "great work",
+	})
+	assert.Nil(t, err)
+	loves := server.Loves()
+	assert.Equal(t, "great work", loves[len(loves)-1].Message)
+}
+
+func TestReadOnly(t *testing.T) {
+	client, server := newTestClientWith(t, &Server{ReadOnly: true})
+	_, err := client.SendLove(context.Background(), &SendLoveRequest{
+		Sender:     "hammy",
+		Recipients: []string{"darwin"},
+		Message:    "great work",
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Len(t, server.Loves(), 2)
+	_, err = client.GetLove(context.Background(), &GetLoveRequest{Recipient: "hammy"})
+	assert.Nil(t, err)
+}
+
+func TestAutocomplete(t *testing.T) {
+	client, _ := newTestClient(t)
+	resp, err := client.Autocomplete(context.Background(), &AutocompleteRequest{Term: "dar"})
+	assert.Nil(t, err)
+	assert.Len(t, resp.Users, 1)
+	assert.Equal(t, "darwin", resp.Users[0].Username)
+}
+
+func TestErrorCodes(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx := context.Background()
+	_, err := client.GetLove(ctx, &GetLoveRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.SendLove(ctx, &SendLoveRequest{Sender: "hammy", Message: "hi"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.SendLove(ctx, &SendLoveRequest{Sender: "hammy",
+		Recipients: []string{"nobody"}, Message: "hi"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestStatusError(t *testing.T) {
+	ctx := context.Background()
+	err := statusError(ctx, &love.APIError{Endpoint: "/love", StatusCode: 503})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	err = statusError(ctx, &love.APIError{Endpoint: "/love", StatusCode: 401})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	err = statusError(ctx, &love.APIError{Endpoint: "/love", StatusCode: 422})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = statusError(canceled, context.Canceled)
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
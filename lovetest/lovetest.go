@@ -0,0 +1,275 @@
+/*
+Package lovetest provides an in-memory fake of the Yelp Love API, for testing
+code which uses the love client library without a network connection or a real
+instance.
+
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+
+	client := server.Client()
+	err := client.SendLove("hammy", "darwin", "thanks!")
+	// server.Loves() now contains the love
+
+The server implements GET and POST /api/love, and GET /api/autocomplete. Like a
+real instance, every request must carry the server's API key. Company values
+sent with love are stored and returned with it. Like stock Yelp Love, the
+server ignores the offset parameter of GET /api/love, unless Offset is set.
+*/
+package lovetest
+
+import "encoding/json"
+import "fmt"
+import "github.com/hacsoc/golove/love"
+import "net/http"
+import "net/http/httptest"
+import "sort"
+import "strconv"
+import "strings"
+import "sync"
+import "time"
+
+/*
+A Server is a fake Yelp Love instance listening on a local address. Users must be
+added with AddUser before love may be sent to or from them.
+*/
+type Server struct {
+	*httptest.Server
+
+	// The API key every request must provide.
+	ApiKey string
+	// Returns the timestamp of newly sent love. Defaults to time.Now.
+	Now func() time.Time
+	// Whether GET /api/love honors the offset parameter, which stock Yelp Love
+	// ignores, so that love.LoveIterator can page.
+	Offset bool
+
+	mutex sync.Mutex
+	loves []love.Love
+	users []love.User
+}
+
+/*
+Start a new Server which accepts the given API key. The caller should Close the
+server when done.
+*/
+func NewServer(apiKey string) *Server {
+	s := &Server{ApiKey: apiKey, Now: time.Now}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/love", s.handleLove)
+	mux.HandleFunc("/api/autocomplete", s.handleAutocomplete)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+/*
+Return the base URL of the API, suitable for love.NewClient.
+*/
+func (s *Server) BaseUrl() string {
+	return s.URL + "/api"
+}
+
+/*
+Return a client configured to talk to this server.
+*/
+func (s *Server) Client() *love.Client {
+	return love.NewClient(s.ApiKey, s.BaseUrl())
+}
+
+/*
+Register a user with the server, with a username and full name.
+*/
+func (s *Server) AddUser(username, name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.users = append(s.users, love.User{
+		Display:  fmt.Sprintf("%s (%s)", name, username),
+		Username: username,
+	})
+}
+
+/*
+Store a love directly, as if it had been sent in the past.
+*/
+func (s *Server) AddLove(l love.Love) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.loves = append(s.loves, l)
+}
+
+/*
+Return every love stored by the server, in the order they were sent.
+*/
+func (s *Server) Loves() []love.Love {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	loves := make([]love.Love, len(s.loves))
+	copy(loves, s.loves)
+	return loves
+}
+
+/*
+Remove all stored love.
+*/
+func (s *Server) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.loves = nil
+}
+
+func (s *Server) hasUser(username string) bool {
+	for _, u := range s.users {
+		if u.Username == username {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleLove(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		s.getLove(w, r)
+	case "POST":
+		s.sendLove(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if r.FormValue("api_key") != s.ApiKey {
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func (s *Server) getLove(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+	sender := r.FormValue("sender")
+	recipient := r.FormValue("recipient")
+	if sender == "" && recipient == "" {
+		http.Error(w, "sender or recipient required", 422)
+		return
+	}
+	limit, err := optionalInt(r.FormValue("limit"))
+	if err != nil {
+		http.Error(w, "invalid limit", 422)
+		return
+	}
+	offset, err := optionalInt(r.FormValue("offset"))
+	if err != nil {
+		http.Error(w, "invalid offset", 422)
+		return
+	}
+
+	s.mutex.Lock()
+	matches := []love.Love{}
+	for _, l := range s.loves {
+		if (sender == "" || l.Sender == sender) &&
+			(recipient == "" || l.Recipient == recipient) {
+			matches = append(matches, l)
+		}
+	}
+	s.mutex.Unlock()
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Timestamp.After(matches[j].Timestamp)
+	})
+	if !s.Offset {
+		offset = 0
+	}
+	if offset > len(matches) {
+		offset = len(matches)
+	}
+	matches = matches[offset:]
+	if limit > 0 && limit < len(matches) {
+		matches = matches[:limit]
+	}
+
+	writeJSON(w, matches)
+}
+
+func (s *Server) sendLove(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+	sender := r.FormValue("sender")
+	recipients := r.FormValue("recipient")
+	message := r.FormValue("message")
+	if sender == "" || recipients == "" || message == "" {
+		http.Error(w, "sender, recipient and message required", 422)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.hasUser(sender) {
+		http.Error(w, "unknown sender "+sender, 418)
+		return
+	}
+	// Like the real server, unknown recipients are silently ignored.
+	var sentTo []string
+	for _, recipient := range strings.Split(recipients, ",") {
+		recipient = strings.TrimSpace(recipient)
+		if recipient == sender {
+			http.Error(w, "you cannot send love to yourself", 418)
+			return
+		}
+		if s.hasUser(recipient) {
+			sentTo = append(sentTo, recipient)
+		}
+	}
+	if len(sentTo) == 0 {
+		http.Error(w, "no valid recipients", 418)
+		return
+	}
+	now := s.Now().UTC().Truncate(time.Microsecond)
+	for _, recipient := range sentTo {
+		s.loves = append(s.loves, love.Love{
+			Sender:    sender,
+			Recipient: recipient,
+			Message:   message,
+			Timestamp: now,
+			Values:    r.Form["values"],
+		})
+	}
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, "Love sent to %s!", strings.Join(sentTo, ", "))
+}
+
+func (s *Server) handleAutocomplete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(w, r) {
+		return
+	}
+	term := strings.ToLower(r.FormValue("term"))
+	result := []love.User{}
+	s.mutex.Lock()
+	for _, u := range s.users {
+		if term != "" && strings.Contains(strings.ToLower(u.Display), term) {
+			result = append(result, u)
+		}
+	}
+	s.mutex.Unlock()
+	writeJSON(w, result)
+}
+
+func optionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,188 @@
+package lovetest
+
+import "errors"
+import "github.com/hacsoc/golove/love"
+import "testing"
+import "github.com/stretchr/testify/assert"
+import "time"
+
+func newTestServer() *Server {
+	server := NewServer("secret")
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	server.AddUser("jeremy", "Jeremy Jones")
+	return server
+}
+
+func TestSendAndGetLove(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	client := server.Client()
+
+	err := client.SendLoves("hammy", []string{"darwin", "jeremy"}, "thanks!")
+	assert.Nil(t, err)
+
+	loves := server.Loves()
+	assert.Equal(t, len(loves), 2)
+	assert.Equal(t, loves[0].Recipient, "darwin")
+	assert.Equal(t, loves[1].Recipient, "jeremy")
+
+	received, err := client.GetLove("", "darwin", 20)
+	assert.Nil(t, err)
+	assert.Equal(t, len(received), 1)
+	assert.Equal(t, received[0].Sender, "hammy")
+	assert.Equal(t, received[0].Message, "thanks!")
+	assert.True(t, received[0].Timestamp.Equal(loves[0].Timestamp))
+}
+
+func TestLoveValues(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	client := server.Client()
+
+	err := client.SendLoveValues("hammy", "darwin", "thanks!", []string{"Ownership"})
+	assert.Nil(t, err)
+	received, err := client.GetLove("", "darwin", 20)
+	assert.Nil(t, err)
+	assert.Equal(t, len(received), 1)
+	assert.Equal(t, received[0].Values, []string{"Ownership"})
+}
+
+func TestGetLoveOrderAndLimit(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	client := server.Client()
+
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		server.AddLove(love.Love{
+			Sender:    "hammy",
+			Recipient: "darwin",
+			Message:   "message",
+			Timestamp: start.Add(time.Duration(i) * time.Hour),
+		})
+	}
+
+	loves, err := client.GetLove("hammy", "", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 2)
+	assert.Equal(t, loves[0].Timestamp.Hour(), 4)
+	assert.Equal(t, loves[1].Timestamp.Hour(), 3)
+
+	all, err := client.GetLoveAll("hammy", "")
+	assert.Nil(t, err)
+	assert.Equal(t, len(all), 5)
+}
+
+func addLoves(server *Server, n int) {
+	start := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		server.AddLove(love.Love{
+			Sender:    "hammy",
+			Recipient: "darwin",
+			Message:   "message",
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+}
+
+func TestOffsetIgnored(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	client := server.Client()
+	addLoves(server, 25)
+
+	it := client.IterLove("hammy", "")
+	it.PageSize = 10
+	var loves []love.Love
+	for it.Next() {
+		loves = append(loves, it.Love())
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, len(loves), 25)
+	for i := 1; i < len(loves); i++ {
+		assert.True(t, loves[i].Timestamp.Before(loves[i-1].Timestamp))
+	}
+
+	// More than a default page of love is fetched in one request.
+	addLoves(server, 150)
+	all, err := client.GetLoveAll("hammy", "")
+	assert.Nil(t, err)
+	assert.Equal(t, len(all), 175)
+}
+
+func TestOffset(t *testing.T) {
+	server := newTestServer()
+	server.Offset = true
+	defer server.Close()
+	client := server.Client()
+	addLoves(server, 25)
+
+	it := client.IterLove("hammy", "")
+	it.PageSize = 10
+	var loves []love.Love
+	for it.Next() {
+		loves = append(loves, it.Love())
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, len(loves), 25)
+	// Newest first, with no love repeated.
+	for i := 1; i < len(loves); i++ {
+		assert.True(t, loves[i].Timestamp.Before(loves[i-1].Timestamp))
+	}
+}
+
+func TestBadApiKey(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	client := love.NewClient("wrong", server.BaseUrl())
+
+	_, err := client.GetLove("hammy", "", 0)
+	assert.True(t, errors.Is(err, love.ErrUnauthorized))
+	err = client.SendLove("hammy", "darwin", "message")
+	assert.True(t, errors.Is(err, love.ErrUnauthorized))
+	_, err = client.Autocomplete("ha")
+	assert.True(t, errors.Is(err, love.ErrUnauthorized))
+}
+
+func TestSendLoveFailures(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	client := server.Client()
+
+	err := client.SendLove("nobody", "darwin", "message")
+	assert.True(t, errors.Is(err, love.ErrLoveFailed))
+	err = client.SendLove("hammy", "hammy", "message")
+	assert.True(t, errors.Is(err, love.ErrLoveFailed))
+	err = client.SendLove("hammy", "darwin", "")
+	assert.True(t, errors.Is(err, love.ErrBadParams))
+	assert.Equal(t, len(server.Loves()), 0)
+}
+
+func TestUnknownRecipientDropped(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	client := server.Client()
+
+	err := client.SendLove("hammy", "darwin,nobody", "message")
+	assert.Nil(t, err)
+	loves := server.Loves()
+	assert.Equal(t, len(loves), 1)
+	assert.Equal(t, loves[0].Recipient, "darwin")
+}
+
+func TestAutocomplete(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	client := server.Client()
+
+	users, err := client.Autocomplete("ha")
+	assert.Nil(t, err)
+	assert.Equal(t, len(users), 1)
+	assert.Equal(t, users[0].Username, "hammy")
+	assert.Equal(t, users[0].Display, "Hammy Havoc (hammy)")
+
+	users, err = client.Autocomplete("o")
+	assert.Nil(t, err)
+	assert.Equal(t, len(users), 3)
+}
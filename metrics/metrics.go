@@ -0,0 +1,158 @@
+/*
+Package metrics exports Prometheus metrics about the requests a love.Client
+makes, so that bots and services built on the client can be monitored.
+
+	m := metrics.New()
+	m.Instrument(client)
+	http.Handle("/metrics", m.Handler())
+
+The metrics are:
+
+	love_requests_total{endpoint, method, code}
+	love_request_errors_total{endpoint, method}
+	love_request_duration_seconds{endpoint, method}
+	love_circuit_state{base_url}
+	love_circuit_rejected_total{base_url}
+
+The code label is the HTTP status code, or "error" when no response was
+received. A request is an error when no response was received or the status is
+400 or more.
+
+The circuit metrics are only exported for clients with a Breaker. The state is
+0 when the circuit is closed, 1 when it is open and 2 when it is half-open (see
+love.CircuitState), and rejected counts the requests which failed with
+love.ErrCircuitOpen. The base_url label is the BaseUrl of the client; if several
+clients with the same BaseUrl are instrumented, the last one is reported.
+*/
+package metrics
+
+import (
+	"github.com/hacsoc/golove/love"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*
+Metrics is a prometheus.Collector of metrics about love API requests. It may
+instrument any number of clients.
+*/
+type Metrics struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+
+	breakerState    *prometheus.Desc
+	breakerRejected *prometheus.Desc
+	mutex           sync.Mutex
+	clients         map[string]*love.Client
+}
+
+/*
+Create a set of metrics, which must be registered to be exported, either with
+a prometheus.Registerer or by using Handler.
+*/
+func New() *Metrics {
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "love",
+			Name:      "requests_total",
+			Help:      "Requests made to the love API.",
+		}, []string{"endpoint", "method", "code"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "love",
+			Name:      "request_errors_total",
+			Help:      "Requests to the love API which failed or returned an error status.",
+		}, []string{"endpoint", "method"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "love",
+			Name:      "request_duration_seconds",
+			Help:      "Time taken by requests to the love API.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "method"}),
+		breakerState: prometheus.NewDesc("love_circuit_state",
+			"State of the circuit breaker: 0 closed, 1 open, 2 half-open.",
+			[]string{"base_url"}, nil),
+		breakerRejected: prometheus.NewDesc("love_circuit_rejected_total",
+			"Requests to the love API refused because the circuit breaker was open.",
+			[]string{"base_url"}, nil),
+		clients: make(map[string]*love.Client),
+	}
+}
+
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.errors.Describe(ch)
+	m.duration.Describe(ch)
+	ch <- m.breakerState
+	ch <- m.breakerRejected
+}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.errors.Collect(ch)
+	m.duration.Collect(ch)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for baseUrl, c := range m.clients {
+		if c.Breaker == nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(m.breakerState, prometheus.GaugeValue,
+			float64(c.Breaker.State()), baseUrl)
+		ch <- prometheus.MustNewConstMetric(m.breakerRejected, prometheus.CounterValue,
+			float64(c.Breaker.Rejected()), baseUrl)
+	}
+}
+
+/*
+Record the requests made by a client, by adding middleware to it, and the state
+of its circuit breaker, if it has one.
+*/
+func (m *Metrics) Instrument(c *love.Client) {
+	c.Middleware = append(c.Middleware, m.Middleware(c))
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.clients[c.BaseUrl] = c
+}
+
+/*
+Return middleware which records the requests made by a client. Instrument adds
+it to the client; use Middleware to control its order with other middleware.
+*/
+func (m *Metrics) Middleware(c *love.Client) love.Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return love.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			endpoint := c.Endpoint(req)
+			m.duration.WithLabelValues(endpoint, req.Method).
+				Observe(time.Since(start).Seconds())
+			code := "error"
+			if err == nil {
+				code = strconv.Itoa(resp.StatusCode)
+			}
+			m.requests.WithLabelValues(endpoint, req.Method, code).Inc()
+			if err != nil || resp.StatusCode >= 400 {
+				m.errors.WithLabelValues(endpoint, req.Method).Inc()
+			}
+			return resp, err
+		})
+	}
+}
+
+/*
+Return a handler which serves the metrics, along with the standard Go runtime
+and process metrics, in the Prometheus exposition format. Each call registers
+the metrics in a new registry.
+*/
+func (m *Metrics) Handler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(m, collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstrument(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+
+	m := New()
+	client := server.Client()
+	m.Instrument(client)
+
+	assert.Nil(t, client.SendLove("hammy", "darwin", "thanks"))
+	assert.NotNil(t, client.SendLove("hammy", "hammy", "thanks"))
+	_, err := client.GetLove("hammy", "", 10)
+	assert.Nil(t, err)
+
+	assert.Equal(t, testutil.ToFloat64(m.requests.WithLabelValues("/love", "POST", "201")), 1.0)
+	assert.Equal(t, testutil.ToFloat64(m.requests.WithLabelValues("/love", "POST", "418")), 1.0)
+	assert.Equal(t, testutil.ToFloat64(m.requests.WithLabelValues("/love", "GET", "200")), 1.0)
+	assert.Equal(t, testutil.ToFloat64(m.errors.WithLabelValues("/love", "POST")), 1.0)
+	assert.Equal(t, testutil.CollectAndCount(m.duration), 2)
+
+	client.BaseUrl = "http://127.0.0.1:1/api"
+	assert.NotNil(t, client.SendLove("hammy", "darwin", "thanks"))
+	assert.Equal(t, testutil.ToFloat64(m.requests.WithLabelValues("/love", "POST", "error")), 1.0)
+	assert.Equal(t, testutil.ToFloat64(m.errors.WithLabelValues("/love", "POST")), 2.0)
+}
+
+func TestHandler(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+
+	m := New()
+	client := server.Client()
+	m.Instrument(client)
+	client.Autocomplete("ham")
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+	assert.True(t, strings.Contains(string(body),
+		`love_requests_total{code="200",endpoint="/autocomplete",method="GET"} 1`))
+	assert.True(t, strings.Contains(string(body), "go_goroutines"))
+	assert.False(t, strings.Contains(string(body), "secret"))
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	m := New()
+	client := love.NewClient("secret", "http://127.0.0.1:1/api")
+	client.Breaker = &love.CircuitBreaker{Threshold: 1, Cooldown: time.Hour}
+	m.Instrument(client)
+	assert.NotNil(t, client.SendLove("hammy", "darwin", "thanks"))
+	assert.NotNil(t, client.SendLove("hammy", "darwin", "thanks"))
+
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+	assert.True(t, strings.Contains(string(body),
+		`love_circuit_state{base_url="http://127.0.0.1:1/api"} 1`))
+	assert.True(t, strings.Contains(string(body),
+		`love_circuit_rejected_total{base_url="http://127.0.0.1:1/api"} 1`))
+}
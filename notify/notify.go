@@ -0,0 +1,179 @@
+/*
+Package notify shows desktop notifications, such as for love as it arrives. A
+Notifier is backed by a program which ships with, or is commonly installed on,
+each operating system:
+
+	macOS    terminal-notifier, or osascript
+	Linux    notify-send (from libnotify)
+	Windows  powershell, as a toast
+
+Detect returns the first Notifier which is available:
+
+	n, err := notify.Detect()
+	if err != nil {
+		log.Fatal(err)
+	}
+	notify.Love(n, l)
+*/
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+/*
+A Notifier shows a notification with a title and a message.
+*/
+type Notifier interface {
+	Notify(title, message string) error
+}
+
+/*
+ErrUnsupported is returned by Detect when no Notifier is available.
+*/
+var ErrUnsupported = errors.New("notify: no desktop notifier is available")
+
+/*
+A Command is a Notifier which runs the program Name, with the arguments returned
+by Args for each notification.
+*/
+type Command struct {
+	Name string
+	Args func(title, message string) []string
+}
+
+func (c *Command) Notify(title, message string) error {
+	output, err := c.command(title, message).CombinedOutput()
+	if err != nil {
+		if text := strings.TrimSpace(string(output)); text != "" {
+			return fmt.Errorf("%s: %s: %s", c.Name, err, text)
+		}
+		return fmt.Errorf("%s: %s", c.Name, err)
+	}
+	return nil
+}
+
+func (c *Command) command(title, message string) *exec.Cmd {
+	return exec.Command(c.Name, c.Args(title, message)...)
+}
+
+/*
+Available reports whether the program is installed.
+*/
+func (c *Command) Available() bool {
+	_, err := exec.LookPath(c.Name)
+	return err == nil
+}
+
+/*
+The built in Notifiers.
+*/
+var (
+	// Linux and BSD.
+	NotifySend = &Command{
+		Name: "notify-send",
+		Args: func(title, message string) []string {
+			return []string{"--app-name=golove", "--", title, message}
+		},
+	}
+	// macOS, with terminal-notifier installed (eg from Homebrew).
+	TerminalNotifier = &Command{
+		Name: "terminal-notifier",
+		Args: func(title, message string) []string {
+			return []string{"-title", title, "-message", message, "-group", "golove"}
+		},
+	}
+	// macOS. The title and message are passed as arguments to the script, so
+	// they need no quoting.
+	OSAScript = &Command{
+		Name: "osascript",
+		Args: func(title, message string) []string {
+			return []string{
+				"-e", "on run argv",
+				"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+				"-e", "end run",
+				title, message,
+			}
+		},
+	}
+	// Windows 8 and later.
+	WindowsToast = &Command{
+		Name: "powershell",
+		Args: func(title, message string) []string {
+			script := fmt.Sprintf(toastScript, powershellQuote(title),
+				powershellQuote(message))
+			return []string{"-NoProfile", "-NonInteractive", "-Command", script}
+		},
+	}
+)
+
+// Notifications are attributed to PowerShell, since only installed apps may
+// show toasts.
+const toastScript = `$ErrorActionPreference = 'Stop'
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode(%s)) > $null
+$text.Item(1).AppendChild($template.CreateTextNode(%s)) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+$app = '{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe'
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier($app).Show($toast)`
+
+/*
+Quote a string for PowerShell. Within single quotes, the only special characters
+are the single quotes themselves, which include the typographic ones.
+*/
+func powershellQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\'', '‘', '’', '‚', '‛':
+			b.WriteRune(r)
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+/*
+Return the Notifiers which may be used on an operating system (a value of
+runtime.GOOS), in order of preference.
+*/
+func candidates(goos string) []*Command {
+	switch goos {
+	case "darwin":
+		return []*Command{TerminalNotifier, OSAScript}
+	case "windows":
+		return []*Command{WindowsToast}
+	case "linux", "freebsd", "openbsd", "netbsd", "dragonfly":
+		return []*Command{NotifySend}
+	}
+	return nil
+}
+
+/*
+Return the preferred Notifier for this operating system which is installed, or
+ErrUnsupported.
+*/
+func Detect() (Notifier, error) {
+	for _, c := range candidates(runtime.GOOS) {
+		if c.Available() {
+			return c, nil
+		}
+	}
+	return nil, ErrUnsupported
+}
+
+/*
+Show a notification for a love, titled with its sender.
+*/
+func Love(n Notifier, l love.Love) error {
+	return n.Notify("Love from "+l.Sender, l.Message)
+}
@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"github.com/hacsoc/golove/love"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type recorder struct {
+	title, message string
+}
+
+func (r *recorder) Notify(title, message string) error {
+	r.title, r.message = title, message
+	return nil
+}
+
+func TestLove(t *testing.T) {
+	r := &recorder{}
+	err := Love(r, love.Love{Sender: "hammy", Recipient: "darwin", Message: "thanks!"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Love from hammy", r.title)
+	assert.Equal(t, "thanks!", r.message)
+}
+
+func TestCommandArgs(t *testing.T) {
+	cmd := NotifySend.command("Love from hammy", "-thanks!")
+	assert.Equal(t, []string{"notify-send", "--app-name=golove", "--",
+		"Love from hammy", "-thanks!"}, cmd.Args)
+
+	cmd = OSAScript.command("Love from hammy", `say "thanks"`)
+	assert.Equal(t, "Love from hammy", cmd.Args[len(cmd.Args)-2])
+	assert.Equal(t, `say "thanks"`, cmd.Args[len(cmd.Args)-1])
+
+	cmd = WindowsToast.command("Love from hammy", "it's great")
+	assert.Contains(t, cmd.Args[len(cmd.Args)-1], "CreateTextNode('it''s great')")
+}
+
+func TestPowershellQuote(t *testing.T) {
+	assert.Equal(t, "'thanks'", powershellQuote("thanks"))
+	assert.Equal(t, "'don''t'", powershellQuote("don't"))
+	assert.Equal(t, "'don’’t $x'", powershellQuote("don’t $x"))
+}
+
+func TestCandidates(t *testing.T) {
+	assert.Equal(t, []*Command{TerminalNotifier, OSAScript}, candidates("darwin"))
+	assert.Equal(t, []*Command{NotifySend}, candidates("linux"))
+	assert.Equal(t, []*Command{WindowsToast}, candidates("windows"))
+	assert.Empty(t, candidates("plan9"))
+}
+
+func TestCommandNotify(t *testing.T) {
+	ok := &Command{Name: "true", Args: func(title, message string) []string {
+		return nil
+	}}
+	assert.Nil(t, ok.Notify("title", "message"))
+
+	failing := &Command{Name: "sh", Args: func(title, message string) []string {
+		return []string{"-c", "echo no display >&2; exit 1"}
+	}}
+	err := failing.Notify("title", "message")
+	assert.EqualError(t, err, "sh: exit status 1: no display")
+
+	missing := &Command{Name: "golove-no-such-notifier", Args: NotifySend.Args}
+	assert.False(t, missing.Available())
+	assert.NotNil(t, missing.Notify("title", "message"))
+}
@@ -0,0 +1,190 @@
+/*
+Package openapi describes the love API in OpenAPI 3, in love.json, so that
+clients in other languages can be generated from it, and so that the endpoints
+the love package uses are written down in one place:
+
+	openapi-generator generate -i openapi/love.json -g python -o love-python
+
+The love package's Client is written by hand, as a friendlier facade than a
+generated client: it expands groups, checks messages, fails over and so on.
+It is kept in sync with love.json by this package's tests, which fail if the
+Client makes a request to an endpoint, or with a parameter, which love.json does
+not describe. To add an endpoint to the Client, describe it in love.json first,
+then add a method which makes the request (see the other methods of the Client),
+and a test which calls it to the tests of this package.
+
+Spec holds love.json, and Load parses it into a Document, which holds the parts
+of the description that tools written in Go are likely to need.
+*/
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+/*
+The OpenAPI description of the love API, as JSON.
+*/
+//go:embed love.json
+var Spec []byte
+
+/*
+A Document is an OpenAPI description of an API. Paths maps each path, below
+the URL of a server, to its operations by lowercase HTTP method.
+*/
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+/*
+Info names and describes the API.
+*/
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+/*
+Components holds the schemas which others refer to by name.
+*/
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+/*
+An Operation is a request which may be made to a path with a method. Responses
+are keyed by status code, or "default".
+*/
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+/*
+A Parameter of an operation, in the "query", "header" or "path".
+*/
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+/*
+A RequestBody describes the body of a request, by its content type.
+*/
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+/*
+A Response is either described in place, or refers to one in the components
+with Ref.
+*/
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+/*
+A MediaType describes a body of one content type.
+*/
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+/*
+A Schema describes a value. Ref refers to a schema in the components, such as
+"#/components/schemas/Love", instead.
+*/
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+}
+
+/*
+Parse Spec.
+*/
+func Load() (*Document, error) {
+	var d Document
+	if err := json.Unmarshal(Spec, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+/*
+Return the operation for a method and path, or nil if there is none.
+*/
+func (d *Document) Operation(method, path string) *Operation {
+	return d.Paths[path][strings.ToLower(method)]
+}
+
+/*
+Return the schema a schema refers to, or the schema itself if it does not
+refer to another. A reference which is not to the document's components
+returns nil.
+*/
+func (d *Document) Resolve(s *Schema) *Schema {
+	if s == nil || s.Ref == "" {
+		return s
+	}
+	name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+	if name == s.Ref {
+		return nil
+	}
+	return d.Components.Schemas[name]
+}
+
+/*
+Return the parameter of the operation with a name, in the query or a header,
+or nil if there is none.
+*/
+func (o *Operation) Parameter(in, name string) *Parameter {
+	for i := range o.Parameters {
+		if o.Parameters[i].In == in && o.Parameters[i].Name == name {
+			return &o.Parameters[i]
+		}
+	}
+	return nil
+}
+
+/*
+Return the fields of the form the operation takes as its body, sorted, or nil
+if it takes none.
+*/
+func (o *Operation) FormFields() []string {
+	if o.RequestBody == nil {
+		return nil
+	}
+	form := o.RequestBody.Content["application/x-www-form-urlencoded"]
+	if form == nil || form.Schema == nil {
+		return nil
+	}
+	var fields []string
+	for name := range form.Schema.Properties {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,181 @@
+package openapi
+
+import (
+	"bytes"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadTest(t *testing.T) *Document {
+	d, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+/*
+Call fn with every schema below s, including s itself.
+*/
+func walkSchema(s *Schema, fn func(*Schema)) {
+	if s == nil {
+		return
+	}
+	fn(s)
+	walkSchema(s.Items, fn)
+	for _, p := range s.Properties {
+		walkSchema(p, fn)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	d := loadTest(t)
+	assert.Equal(t, d.OpenAPI, "3.0.3")
+	ids := make(map[string]bool)
+	var schemas []*Schema
+	for path, operations := range d.Paths {
+		for method, op := range operations {
+			assert.NotEmpty(t, op.OperationID, "%s %s", method, path)
+			assert.False(t, ids[op.OperationID], "%s is not unique", op.OperationID)
+			ids[op.OperationID] = true
+			for _, p := range op.Parameters {
+				schemas = append(schemas, p.Schema)
+			}
+			if op.RequestBody != nil {
+				for _, m := range op.RequestBody.Content {
+					schemas = append(schemas, m.Schema)
+				}
+			}
+			for _, r := range op.Responses {
+				for _, m := range r.Content {
+					schemas = append(schemas, m.Schema)
+				}
+			}
+		}
+	}
+	for _, s := range d.Components.Schemas {
+		schemas = append(schemas, s)
+	}
+	for _, s := range schemas {
+		walkSchema(s, func(s *Schema) {
+			assert.NotNil(t, d.Resolve(s), "%s does not resolve", s.Ref)
+		})
+	}
+
+	op := d.Operation("POST", "/love")
+	assert.Equal(t, op.OperationID, "sendLove")
+	assert.Equal(t, op.FormFields(), []string{"message", "recipient", "sender", "values"})
+	assert.Nil(t, d.Operation("DELETE", "/love"))
+	assert.True(t, d.Operation("GET", "/autocomplete").Parameter("query", "term").Required)
+	assert.Nil(t, d.Operation("GET", "/autocomplete").Parameter("query", "limit"))
+	assert.Equal(t, d.Resolve(&Schema{Ref: "#/components/schemas/Love"}).Required,
+		[]string{"sender", "recipient", "message", "timestamp"})
+}
+
+/*
+A request made by a client, with the names of its query parameters and form
+fields, other than the API key.
+*/
+type request struct {
+	method string
+	path   string
+	query  []string
+	form   []string
+}
+
+func names(values url.Values) []string {
+	var names []string
+	for name := range values {
+		if name != "api_key" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+/*
+Record the requests made by a client, below its base URL.
+*/
+func recordRequests(client *love.Client, requests *[]request) {
+	client.Middleware = append(client.Middleware, func(next http.RoundTripper) http.RoundTripper {
+		return love.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			r := request{
+				method: req.Method,
+				path:   strings.TrimPrefix(req.URL.Path, "/api"),
+				query:  names(req.URL.Query()),
+			}
+			if req.Body != nil {
+				body, err := ioutil.ReadAll(req.Body)
+				if err != nil {
+					return nil, err
+				}
+				req.Body = ioutil.NopCloser(bytes.NewReader(body))
+				form, _ := url.ParseQuery(string(body))
+				r.form = names(form)
+			}
+			*requests = append(*requests, r)
+			return next.RoundTrip(req)
+		})
+	})
+}
+
+/*
+Every request the love package's Client makes must be described by love.json,
+and every operation in love.json must be made by the Client. When adding an
+endpoint, call the method which makes requests to it here.
+*/
+func TestClientInSync(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	client := server.Client()
+	var requests []request
+	recordRequests(client, &requests)
+
+	client.SendLoveValues("hammy", "darwin", "thanks", []string{"teamwork"})
+	client.GetLove("hammy", "darwin", 10)
+	client.IterLoveFiltered(love.LoveFilter{
+		Sender:    "hammy",
+		Recipient: "darwin",
+		Limit:     10,
+		Since:     time.Now().Add(-time.Hour),
+		Until:     time.Now(),
+		Keyword:   "thanks",
+		Value:     "teamwork",
+	}).Next()
+	client.GetLoveAll("hammy", "")
+	client.Autocomplete("ha")
+	client.GetLeaderboard(love.ThisWeek, nil)
+	client.GetUser("hammy")
+
+	d := loadTest(t)
+	made := make(map[*Operation]bool)
+	for _, r := range requests {
+		op := d.Operation(r.method, r.path)
+		if !assert.NotNil(t, op, "%s %s is not described", r.method, r.path) {
+			continue
+		}
+		made[op] = true
+		for _, name := range r.query {
+			assert.NotNil(t, op.Parameter("query", name),
+				"the %s parameter of %s %s is not described", name, r.method, r.path)
+		}
+		for _, name := range r.form {
+			assert.Contains(t, op.FormFields(), name,
+				"the %s field of %s %s is not described", name, r.method, r.path)
+		}
+	}
+	for path, operations := range d.Paths {
+		for method, op := range operations {
+			assert.True(t, made[op], "%s %s is not made by the client", method, path)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+/*
+Package pagerduty thanks the responders to an incident with love once it is
+resolved. Its Handler receives PagerDuty V3 webhooks, and when an incident is
+resolved, sends love from a bot user to everyone who was assigned to,
+acknowledged or resolved it:
+
+	Thanks for handling incident #123 (Checkout is down)!
+
+To use it, add a generic webhook subscription for the incident.acknowledged
+and incident.resolved events, and give the Handler its signing secret.
+
+Webhooks only identify users by their PagerDuty ID and name. Users are mapped to
+love usernames by the Users map, which may hold their IDs or email addresses.
+Email addresses are looked up with the PagerDuty REST API, if the Handler has an
+API token, and those in EmailDomain are assumed to be username@EmailDomain.
+*/
+package pagerduty
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The header holding the signatures of a delivery.
+const SignatureHeader = "X-PagerDuty-Signature"
+
+// The PagerDuty REST API, used to look up email addresses.
+const DefaultAPIURL = "https://api.pagerduty.com"
+
+// The message sent when a Handler has none.
+const DefaultMessage = "Thanks for handling incident #{{.Number}} ({{.Title}})! {{.URL}}"
+
+// The largest delivery accepted.
+const maxRequestBytes = 1024 * 1024
+
+/*
+A Handler serves PagerDuty webhook deliveries by sending love to the responders
+of resolved incidents. It answers 200 with a line for each responder, or 502
+if any love could not be sent, so that PagerDuty retries the delivery.
+
+Message is a template (see love.ParseMessageTemplate) holding the fields of the
+incident: Number, Title, URL, Service, Priority and Urgency, along with Sender
+and Recipient, the love usernames of the sender and recipient, and Name, the
+recipient's name in PagerDuty.
+
+Acknowledgements are remembered until the incident is resolved. They are kept
+in memory, and so are lost when the server restarts.
+*/
+type Handler struct {
+	// Sends the love.
+	Service love.LoveService
+	// The signing secret of the webhook subscription.
+	Secret string
+	// The love username of the bot user who sends the love.
+	Sender string
+	// The message template, or DefaultMessage if empty.
+	Message string
+	// Maps PagerDuty user IDs or email addresses to love usernames.
+	Users map[string]string
+	// The domain of email addresses whose local part is a love username.
+	EmailDomain string
+	// A PagerDuty REST API token, used to look up email addresses.
+	APIToken string
+	// Empty for DefaultAPIURL.
+	APIURL     string
+	HTTPClient *http.Client
+
+	mutex      sync.Mutex
+	responders map[string][]reference
+	emails     map[string]string
+}
+
+// The parts of a V3 webhook payload which are used.
+type payload struct {
+	Event struct {
+		EventType string    `json:"event_type"`
+		Agent     reference `json:"agent"`
+		Data      struct {
+			ID        string      `json:"id"`
+			Number    int         `json:"number"`
+			Title     string      `json:"title"`
+			HTMLURL   string      `json:"html_url"`
+			Urgency   string      `json:"urgency"`
+			Service   reference   `json:"service"`
+			Priority  *reference  `json:"priority"`
+			Assignees []reference `json:"assignees"`
+		} `json:"data"`
+	} `json:"event"`
+}
+
+/*
+A reference to a PagerDuty object, such as a user. Summary is its name.
+*/
+type reference struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Summary string `json:"summary"`
+}
+
+func (r reference) isUser() bool {
+	return r.ID != "" && (r.Type == "user_reference" || r.Type == "user")
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !Verify(h.Secret, body, r.Header.Get(SignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	event := &p.Event
+	switch event.EventType {
+	case "incident.acknowledged":
+		h.remember(event.Data.ID, event.Agent)
+		fmt.Fprintln(w, "acknowledged")
+	case "incident.resolved":
+		responders := append(h.forget(event.Data.ID), event.Data.Assignees...)
+		responders = append(responders, event.Agent)
+		fields := map[string]string{
+			"Number":   strconv.Itoa(event.Data.Number),
+			"Title":    event.Data.Title,
+			"URL":      event.Data.HTMLURL,
+			"Service":  event.Data.Service.Summary,
+			"Urgency":  event.Data.Urgency,
+			"Priority": "",
+		}
+		if event.Data.Priority != nil {
+			fields["Priority"] = event.Data.Priority.Summary
+		}
+		lines, failed := h.thank(responders, fields)
+		if failed {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		if len(lines) == 0 {
+			lines = []string{"no responders"}
+		}
+		fmt.Fprintln(w, strings.Join(lines, "\n"))
+	default:
+		fmt.Fprintln(w, "ignored")
+	}
+}
+
+/*
+Report whether a signature header holds a correct signature of a body. The
+header may hold several signatures, separated by commas, while the secret is
+being changed.
+*/
+func Verify(secret string, body []byte, header string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := []byte("v1=" + hex.EncodeToString(mac.Sum(nil)))
+	for _, signature := range strings.Split(header, ",") {
+		if hmac.Equal(expected, []byte(strings.TrimSpace(signature))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) remember(incident string, agent reference) {
+	if !agent.isUser() {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.responders == nil {
+		h.responders = make(map[string][]reference)
+	}
+	h.responders[incident] = append(h.responders[incident], agent)
+}
+
+/*
+Forget the acknowledgements of an incident, returning who made them.
+*/
+func (h *Handler) forget(incident string) []reference {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	responders := h.responders[incident]
+	delete(h.responders, incident)
+	return responders
+}
+
+/*
+Send love to each distinct responder who is a user, returning a line describing
+the outcome for each, and whether any love could not be sent.
+*/
+func (h *Handler) thank(responders []reference, fields map[string]string) ([]string, bool) {
+	message := h.Message
+	if message == "" {
+		message = DefaultMessage
+	}
+	tmpl, err := love.ParseMessageTemplate(message)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid message: %s", err)}, true
+	}
+	var lines []string
+	failed := false
+	seen := make(map[string]bool)
+	thanked := map[string]bool{h.Sender: true}
+	for _, responder := range responders {
+		if !responder.isUser() || seen[responder.ID] {
+			continue
+		}
+		seen[responder.ID] = true
+		username, err := h.username(responder.ID)
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("%s: %s", responder.Summary, err))
+			continue
+		}
+		if thanked[username] {
+			continue
+		}
+		thanked[username] = true
+		data := make(map[string]string, len(fields)+1)
+		for key, value := range fields {
+			data[key] = value
+		}
+		data["Name"] = responder.Summary
+		messages, err := love.RenderMessages(tmpl, h.Sender, []string{username},
+			map[string]map[string]string{username: data})
+		if err == nil {
+			err = h.Service.SendLove(h.Sender, username, messages[username])
+		}
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("%s: %s", username, err))
+			failed = true
+		} else {
+			lines = append(lines, fmt.Sprintf("%s: sent", username))
+		}
+	}
+	return lines, failed
+}
+
+/*
+Return the love username of a PagerDuty user: from Users by their ID, or else
+by their email address, or else the local part of an address in EmailDomain.
+*/
+func (h *Handler) username(id string) (string, error) {
+	if username, ok := h.Users[id]; ok {
+		return username, nil
+	}
+	if h.APIToken == "" {
+		return "", fmt.Errorf("no love username for %s", id)
+	}
+	email, err := h.email(id)
+	if err != nil {
+		return "", err
+	}
+	if username, ok := h.Users[email]; ok {
+		return username, nil
+	}
+	if username, ok := h.Users[strings.ToLower(email)]; ok {
+		return username, nil
+	}
+	at := strings.LastIndex(email, "@")
+	if h.EmailDomain != "" && at >= 0 && strings.EqualFold(email[at+1:], h.EmailDomain) {
+		return email[:at], nil
+	}
+	return "", fmt.Errorf("no love username for %s", email)
+}
+
+/*
+Look up the email address of a PagerDuty user with the REST API, remembering
+it for later.
+*/
+func (h *Handler) email(id string) (string, error) {
+	h.mutex.Lock()
+	email, ok := h.emails[id]
+	h.mutex.Unlock()
+	if ok {
+		return email, nil
+	}
+	apiURL := h.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	req, err := http.NewRequest("GET", apiURL+"/users/"+id, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	req.Header.Set("Authorization", "Token token="+h.APIToken)
+	client := h.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("looking up %s: %s", id, resp.Status)
+	}
+	var user struct {
+		User struct {
+			Email string `json:"email"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return "", fmt.Errorf("looking up %s: %s", id, err)
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.emails == nil {
+		h.emails = make(map[string]string)
+	}
+	h.emails[id] = user.User.Email
+	return user.User.Email, nil
+}
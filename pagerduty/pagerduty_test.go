@@ -0,0 +1,167 @@
+package pagerduty
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testSecret = "whsec_123"
+
+const acknowledgedPayload = `{"event": {
+	"event_type": "incident.acknowledged",
+	"agent": {"id": "PJEREMY", "type": "user_reference", "summary": "Jeremy Jones"},
+	"data": {"id": "Q1", "number": 123}
+}}`
+
+const resolvedPayload = `{"event": {
+	"event_type": "incident.resolved",
+	"agent": {"id": "PHAMMY", "type": "user_reference", "summary": "Hammy Havoc"},
+	"data": {
+		"id": "Q1",
+		"number": 123,
+		"title": "Checkout is down",
+		"html_url": "https://acme.pagerduty.com/incidents/Q1",
+		"urgency": "high",
+		"service": {"id": "S1", "type": "service_reference", "summary": "Checkout"},
+		"priority": {"id": "P1", "type": "priority_reference", "summary": "SEV-1"},
+		"assignees": [
+			{"id": "PHAMMY", "type": "user_reference", "summary": "Hammy Havoc"},
+			{"id": "PDARWIN", "type": "user_reference", "summary": "Darwin Dog"}
+		]
+	}
+}}`
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newTestHandler() (*Handler, *lovetest.Server) {
+	server := lovetest.NewServer("secret")
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	server.AddUser("jeremy", "Jeremy Jones")
+	server.AddUser("oncall-bot", "On-call Bot")
+	handler := &Handler{
+		Service: server.Client(),
+		Secret:  testSecret,
+		Sender:  "oncall-bot",
+		Users:   map[string]string{"PHAMMY": "hammy", "PDARWIN": "darwin", "PJEREMY": "jeremy"},
+	}
+	return handler, server
+}
+
+func deliver(handler *Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/pagerduty", strings.NewReader(body))
+	req.Header.Set(SignatureHeader, sign(testSecret, body))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestResolved(t *testing.T) {
+	handler, server := newTestHandler()
+	handler.Message = "Thanks for handling {{.Priority}} {{.Title}}, {{.Name}}!"
+	recorder := deliver(handler, acknowledgedPayload)
+	assert.Equal(t, recorder.Body.String(), "acknowledged\n")
+
+	recorder = deliver(handler, resolvedPayload)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, recorder.Body.String(), "jeremy: sent\nhammy: sent\ndarwin: sent\n")
+	var messages []string
+	for _, l := range server.Loves() {
+		assert.Equal(t, l.Sender, "oncall-bot")
+		messages = append(messages, l.Message)
+	}
+	assert.Contains(t, messages, "Thanks for handling SEV-1 Checkout is down, Jeremy Jones!")
+	assert.Len(t, messages, 3)
+	assert.Nil(t, handler.forget("Q1"))
+}
+
+func TestResolvedDefaultMessage(t *testing.T) {
+	handler, server := newTestHandler()
+	deliver(handler, resolvedPayload)
+	assert.Equal(t, server.Loves()[0].Message,
+		"Thanks for handling incident #123 (Checkout is down)! https://acme.pagerduty.com/incidents/Q1")
+}
+
+func TestEmailLookup(t *testing.T) {
+	var auth []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/users/PHAMMY":
+			w.Write([]byte(`{"user": {"email": "Hammy.H@partner.com"}}`))
+		case "/users/PDARWIN":
+			w.Write([]byte(`{"user": {"email": "darwin@example.com"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer api.Close()
+
+	handler, server := newTestHandler()
+	handler.Users = map[string]string{"hammy.h@partner.com": "hammy"}
+	handler.EmailDomain = "Example.com"
+	handler.APIToken = "token"
+	handler.APIURL = api.URL
+	recorder := deliver(handler, resolvedPayload)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, recorder.Body.String(), "hammy: sent\ndarwin: sent\n")
+	assert.Len(t, server.Loves(), 2)
+	assert.Equal(t, auth, []string{"Token token=token", "Token token=token"})
+
+	// Addresses are remembered.
+	deliver(handler, resolvedPayload)
+	assert.Len(t, auth, 2)
+
+	// Other addresses have no username.
+	handler.EmailDomain = ""
+	handler.emails = nil
+	recorder = deliver(handler, resolvedPayload)
+	assert.Equal(t, recorder.Body.String(),
+		"hammy: sent\nDarwin Dog: no love username for darwin@example.com\n")
+}
+
+func TestUnknownResponder(t *testing.T) {
+	handler, server := newTestHandler()
+	handler.Users = nil
+	recorder := deliver(handler, resolvedPayload)
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Contains(t, recorder.Body.String(), "Hammy Havoc: no love username for PHAMMY")
+	assert.Len(t, server.Loves(), 0)
+}
+
+func TestSendFailure(t *testing.T) {
+	handler, _ := newTestHandler()
+	handler.Users["PDARWIN"] = "nobody"
+	recorder := deliver(handler, resolvedPayload)
+	assert.Equal(t, recorder.Code, http.StatusBadGateway)
+	assert.Contains(t, recorder.Body.String(), "nobody: ")
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(resolvedPayload)
+	assert.True(t, Verify(testSecret, body, sign(testSecret, resolvedPayload)))
+	assert.True(t, Verify(testSecret, body,
+		sign("old", resolvedPayload)+", "+sign(testSecret, resolvedPayload)))
+	assert.False(t, Verify(testSecret, body, sign("old", resolvedPayload)))
+	assert.False(t, Verify(testSecret, body, ""))
+
+	handler, _ := newTestHandler()
+	req := httptest.NewRequest("POST", "/pagerduty", strings.NewReader(resolvedPayload))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, recorder.Code, http.StatusUnauthorized)
+
+	recorder = deliver(handler, `{"event": {"event_type": "incident.triggered"}}`)
+	assert.Equal(t, recorder.Body.String(), "ignored\n")
+}
@@ -0,0 +1,329 @@
+/*
+Package proxy implements a caching reverse proxy for the love API, to protect
+the server from bursts of identical requests, such as from many dashboards
+showing the same love.
+
+GET requests for love and autocomplete are cached for a TTL, keyed by their
+path and query, which includes the API key, and by the headers which
+authenticate them, so a response is only shared with callers presenting the
+same credentials. Concurrent requests for the same key are
+coalesced into one request to the server. Cached responses carry an ETag, and
+requests with a matching If-None-Match are answered with 304 Not Modified. The
+X-Cache header of each response is HIT or MISS. At most MaxCacheEntries
+responses are cached, since clients choose the queries.
+
+Every other request, such as POSTing love, is passed through. Successfully
+sending love clears the cached love, so that the new love appears.
+*/
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long responses are cached by default.
+const DefaultTTL = 30 * time.Second
+
+// The most responses which are cached at once.
+const MaxCacheEntries = 1000
+
+/*
+A Proxy forwards requests to the love API at Target, which holds the scheme and
+host of the server; the path of each request is kept. For example, with a Target
+of https://cwrulove.appspot.com, clients use a base URL such as
+http://localhost:8080/api.
+*/
+type Proxy struct {
+	Target *url.URL
+	// Zero for DefaultTTL.
+	TTL time.Duration
+	// Used to make requests to the server; http.DefaultTransport if nil.
+	Transport http.RoundTripper
+	// Headers which authenticate requests, besides Authorization and
+	// X-Api-Key, such as the header of a love.HeaderAuth.
+	AuthHeaders []string
+
+	once     sync.Once
+	reverse  *httputil.ReverseProxy
+	mutex    sync.Mutex
+	cache    map[string]*entry
+	inflight map[string]*call
+	now      func() time.Time
+}
+
+/*
+A cached response.
+*/
+type entry struct {
+	status      int
+	contentType string
+	body        []byte
+	etag        string
+	expires     time.Time
+}
+
+/*
+A request to the server which other callers are waiting for.
+*/
+type call struct {
+	done  chan struct{}
+	entry *entry
+	err   error
+}
+
+func (p *Proxy) init() {
+	p.reverse = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(p.Target)
+			r.Out.Host = p.Target.Host
+		},
+		Transport: p.Transport,
+		ModifyResponse: func(resp *http.Response) error {
+			req := resp.Request
+			if req.Method == "POST" && isLove(req.URL.Path) && resp.StatusCode < 300 {
+				p.invalidate()
+			}
+			return nil
+		},
+	}
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.once.Do(p.init)
+	if r.Method != "GET" && r.Method != "HEAD" || !cacheable(r.URL.Path) {
+		p.reverse.ServeHTTP(w, r)
+		return
+	}
+	key := r.URL.Path + "?" + r.URL.Query().Encode() + "#" + p.credentials(r)
+	e, hit := p.lookup(key)
+	if !hit {
+		var err error
+		if e, err = p.fetch(key, r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+	header := w.Header()
+	if hit {
+		header.Set("X-Cache", "HIT")
+	} else {
+		header.Set("X-Cache", "MISS")
+	}
+	if e.contentType != "" {
+		header.Set("Content-Type", e.contentType)
+	}
+	if e.etag != "" {
+		header.Set("ETag", e.etag)
+		header.Set("Cache-Control", fmt.Sprintf("max-age=%d", int(p.ttl().Seconds())))
+		if etagMatches(r.Header.Get("If-None-Match"), e.etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.WriteHeader(e.status)
+	if r.Method != "HEAD" {
+		w.Write(e.body)
+	}
+}
+
+/*
+Return a hash of the headers which authenticate a request, which is part of its
+cache key, or "" if it has none.
+*/
+func (p *Proxy) credentials(r *http.Request) string {
+	hash := sha256.New()
+	found := false
+	for _, name := range p.authHeaders() {
+		for _, value := range r.Header.Values(name) {
+			fmt.Fprintf(hash, "%s: %s\n", http.CanonicalHeaderKey(name), value)
+			found = true
+		}
+	}
+	if !found {
+		return ""
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (p *Proxy) authHeaders() []string {
+	return append([]string{"Authorization", "X-Api-Key"}, p.AuthHeaders...)
+}
+
+func cacheable(path string) bool {
+	return isLove(path) || strings.HasSuffix(path, "/autocomplete")
+}
+
+func isLove(path string) bool {
+	return strings.HasSuffix(path, "/love")
+}
+
+func (p *Proxy) ttl() time.Duration {
+	if p.TTL == 0 {
+		return DefaultTTL
+	}
+	return p.TTL
+}
+
+func (p *Proxy) time() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+/*
+Return the fresh cached response for a key, if there is one.
+*/
+func (p *Proxy) lookup(key string) (*entry, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	e, ok := p.cache[key]
+	if !ok || !p.time().Before(e.expires) {
+		return nil, false
+	}
+	return e, true
+}
+
+/*
+Fetch the response for a key from the server, waiting for an identical request
+in progress rather than making another. Successful responses are cached.
+*/
+func (p *Proxy) fetch(key string, r *http.Request) (*entry, error) {
+	p.mutex.Lock()
+	if c, ok := p.inflight[key]; ok {
+		p.mutex.Unlock()
+		<-c.done
+		return c.entry, c.err
+	}
+	c := &call{done: make(chan struct{})}
+	if p.inflight == nil {
+		p.inflight = make(map[string]*call)
+	}
+	p.inflight[key] = c
+	p.mutex.Unlock()
+
+	c.entry, c.err = p.get(r)
+
+	p.mutex.Lock()
+	delete(p.inflight, key)
+	if c.err == nil && c.entry.status == http.StatusOK {
+		p.store(key, c.entry)
+	}
+	p.mutex.Unlock()
+	close(c.done)
+	return c.entry, c.err
+}
+
+/*
+Make a GET request to the server for a request. It is not canceled with the
+request, since other callers may be waiting for it.
+*/
+func (p *Proxy) get(r *http.Request) (*entry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), love.DefaultTimeout)
+	defer cancel()
+	out := &httputil.ProxyRequest{In: r, Out: r.Clone(ctx)}
+	out.SetURL(p.Target)
+	req := out.Out
+	req.Method = "GET"
+	req.Host = p.Target.Host
+	req.RequestURI = ""
+	req.Header = http.Header{}
+	if accept := r.Header.Get("Accept"); accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	for _, name := range p.authHeaders() {
+		if values := r.Header.Values(name); len(values) > 0 {
+			req.Header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	transport := p.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, love.DefaultMaxResponseBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > love.DefaultMaxResponseBytes {
+		return nil, love.ErrResponseTooLarge
+	}
+	e := &entry{
+		status:      resp.StatusCode,
+		contentType: resp.Header.Get("Content-Type"),
+		body:        body,
+	}
+	if resp.StatusCode == http.StatusOK {
+		hash := sha256.Sum256(body)
+		e.etag = `"` + hex.EncodeToString(hash[:16]) + `"`
+	}
+	return e, nil
+}
+
+/*
+Cache a response, removing any which have expired, and if the cache is still
+full, the response which expires first. The mutex must be held.
+*/
+func (p *Proxy) store(key string, e *entry) {
+	now := p.time()
+	if p.cache == nil {
+		p.cache = make(map[string]*entry)
+	}
+	var oldest string
+	var oldestExpires time.Time
+	for k, old := range p.cache {
+		if !now.Before(old.expires) {
+			delete(p.cache, k)
+		} else if oldestExpires.IsZero() || old.expires.Before(oldestExpires) {
+			oldest, oldestExpires = k, old.expires
+		}
+	}
+	if _, ok := p.cache[key]; !ok && len(p.cache) >= MaxCacheEntries {
+		delete(p.cache, oldest)
+	}
+	e.expires = now.Add(p.ttl())
+	p.cache[key] = e
+}
+
+/*
+Remove the cached love, which is stale once love has been sent.
+*/
+func (p *Proxy) invalidate() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for key := range p.cache {
+		path := key[:strings.Index(key, "?")]
+		if isLove(path) {
+			delete(p.cache, key)
+		}
+	}
+}
+
+/*
+Report whether an If-None-Match header matches an ETag.
+*/
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package proxy
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+/*
+A love server which counts requests, and responds to a GET with its path and
+query.
+*/
+type upstream struct {
+	*httptest.Server
+	hits    int32
+	status  int
+	release chan struct{}
+	// If set, GETs without this X-Api-Key are unauthorized.
+	key string
+}
+
+func newUpstream() *upstream {
+	u := &upstream{status: http.StatusOK}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&u.hits, 1)
+		if u.release != nil {
+			<-u.release
+		}
+		if r.Method == "POST" {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if u.key != "" && r.Header.Get("X-Api-Key") != u.key {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(u.status)
+		w.Write([]byte(`"` + r.URL.RequestURI() + `"`))
+	}))
+	return u
+}
+
+func (u *upstream) proxy() *Proxy {
+	target, _ := url.Parse(u.URL)
+	return &Proxy{Target: target}
+}
+
+func do(p *Proxy, method, path string, header http.Header) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(method, "http://proxy"+path, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	p.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestCacheHit(t *testing.T) {
+	u := newUpstream()
+	defer u.Close()
+	p := u.proxy()
+	first := do(p, "GET", "/api/love?api_key=k&sender=hammy", nil)
+	assert.Equal(t, 200, first.Code)
+	assert.Equal(t, "MISS", first.Header().Get("X-Cache"))
+	assert.Equal(t, `"/api/love?api_key=k&sender=hammy"`, first.Body.String())
+	assert.Equal(t, "application/json", first.Header().Get("Content-Type"))
+	assert.Equal(t, "max-age=30", first.Header().Get("Cache-Control"))
+	// The order of the query does not matter.
+	second := do(p, "GET", "/api/love?sender=hammy&api_key=k", nil)
+	assert.Equal(t, "HIT", second.Header().Get("X-Cache"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, first.Header().Get("ETag"), second.Header().Get("ETag"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&u.hits))
+}
+
+func TestExpiry(t *testing.T) {
+	u := newUpstream()
+	defer u.Close()
+	now := time.Date(2017, 4, 1, 0, 0, 0, 0, time.UTC)
+	p := u.proxy()
+	p.TTL = time.Minute
+	p.now = func() time.Time { return now }
+	do(p, "GET", "/api/autocomplete?api_key=k&term=ha", nil)
+	now = now.Add(59 * time.Second)
+	assert.Equal(t, "HIT", do(p, "GET", "/api/autocomplete?api_key=k&term=ha", nil).Header().Get("X-Cache"))
+	now = now.Add(time.Second)
+	assert.Equal(t, "MISS", do(p, "GET", "/api/autocomplete?api_key=k&term=ha", nil).Header().Get("X-Cache"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&u.hits))
+}
+
+func TestMaxCacheEntries(t *testing.T) {
+	u := newUpstream()
+	defer u.Close()
+	now := time.Date(2017, 4, 1, 0, 0, 0, 0, time.UTC)
+	p := u.proxy()
+	p.now = func() time.Time { return now }
+	for i := 0; i < MaxCacheEntries+10; i++ {
+		do(p, "GET", fmt.Sprintf("/api/autocomplete?api_key=k&term=%d", i), nil)
+		now = now.Add(time.Millisecond)
+	}
+	assert.Equal(t, MaxCacheEntries, len(p.cache))
+	// The first responses, which expire first, were removed.
+	assert.Equal(t, "MISS", do(p, "GET", "/api/autocomplete?api_key=k&term=0", nil).Header().Get("X-Cache"))
+	assert.Equal(t, "HIT", do(p, "GET", fmt.Sprintf("/api/autocomplete?api_key=k&term=%d", MaxCacheEntries+9),
+		nil).Header().Get("X-Cache"))
+
+	// Expired responses are removed before any which are fresh.
+	now = now.Add(DefaultTTL)
+	do(p, "GET", "/api/autocomplete?api_key=k&term=new", nil)
+	assert.Equal(t, 1, len(p.cache))
+}
+
+func TestNotModified(t *testing.T) {
+	u := newUpstream()
+	defer u.Close()
+	p := u.proxy()
+	etag := do(p, "GET", "/api/love?api_key=k", nil).Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	resp := do(p, "GET", "/api/love?api_key=k", http.Header{"If-None-Match": {`"other", ` + etag}})
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+	assert.Empty(t, resp.Body.String())
+	resp = do(p, "GET", "/api/love?api_key=k", http.Header{"If-None-Match": {`"other"`}})
+	assert.Equal(t, 200, resp.Code)
+}
+
+func TestCoalescing(t *testing.T) {
+	u := newUpstream()
+	u.release = make(chan struct{})
+	defer u.Close()
+	p := u.proxy()
+	var wg sync.WaitGroup
+	bodies := make([]string, 10)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i] = do(p, "GET", "/api/love?api_key=k&recipient=darwin", nil).Body.String()
+		}(i)
+	}
+	// Let the requests pile up behind the first.
+	for atomic.LoadInt32(&u.hits) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(u.release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&u.hits))
+	for _, body := range bodies {
+		assert.Equal(t, `"/api/love?api_key=k&recipient=darwin"`, body)
+	}
+}
+
+func TestSeparateKeys(t *testing.T) {
+	u := newUpstream()
+	defer u.Close()
+	p := u.proxy()
+	do(p, "GET", "/api/love?api_key=a&sender=hammy", nil)
+	resp := do(p, "GET", "/api/love?api_key=b&sender=hammy", nil)
+	assert.Equal(t, "MISS", resp.Header().Get("X-Cache"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&u.hits))
+}
+
+func TestErrorsNotCached(t *testing.T) {
+	u := newUpstream()
+	u.status = http.StatusInternalServerError
+	defer u.Close()
+	p := u.proxy()
+	resp := do(p, "GET", "/api/love?api_key=k", nil)
+	assert.Equal(t, 500, resp.Code)
+	assert.Empty(t, resp.Header().Get("ETag"))
+	do(p, "GET", "/api/love?api_key=k", nil)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&u.hits))
+}
+
+func TestPassThrough(t *testing.T) {
+	u := newUpstream()
+	defer u.Close()
+	p := u.proxy()
+	do(p, "GET", "/api/love?api_key=k", nil)
+	do(p, "GET", "/api/autocomplete?api_key=k&term=h", nil)
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "http://proxy/api/love",
+		strings.NewReader("api_key=k&sender=hammy&recipient=darwin&message=hi"))
+	p.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("X-Cache"))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&u.hits))
+	// Sending love clears the cached love, but not autocomplete.
+	assert.Equal(t, "MISS", do(p, "GET", "/api/love?api_key=k", nil).Header().Get("X-Cache"))
+	assert.Equal(t, "HIT", do(p, "GET", "/api/autocomplete?api_key=k&term=h", nil).Header().Get("X-Cache"))
+}
+
+func TestHeaderAuth(t *testing.T) {
+	u := newUpstream()
+	u.key = "k"
+	defer u.Close()
+	p := u.proxy()
+	resp := do(p, "GET", "/api/love?sender=hammy", http.Header{"X-Api-Key": {"k"}})
+	assert.Equal(t, 200, resp.Code)
+	assert.Equal(t, "MISS", resp.Header().Get("X-Cache"))
+	resp = do(p, "GET", "/api/love?sender=hammy", http.Header{"X-Api-Key": {"k"}})
+	assert.Equal(t, "HIT", resp.Header().Get("X-Cache"))
+	// Other credentials, or none, are not answered from the cache.
+	resp = do(p, "GET", "/api/love?sender=hammy", http.Header{"X-Api-Key": {"other"}})
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	resp = do(p, "GET", "/api/love?sender=hammy", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&u.hits))
+}
+
+func TestAuthHeaders(t *testing.T) {
+	var got http.Header
+	u := newUpstream()
+	defer u.Close()
+	p := u.proxy()
+	p.Transport = roundTripper(func(r *http.Request) (*http.Response, error) {
+		got = r.Header
+		return http.DefaultTransport.RoundTrip(r)
+	})
+	p.AuthHeaders = []string{"X-Auth-Token"}
+	do(p, "GET", "/api/love", http.Header{
+		"Authorization": {"Bearer t"},
+		"X-Auth-Token":  {"secret"},
+		"Cookie":        {"session=1"},
+	})
+	assert.Equal(t, "Bearer t", got.Get("Authorization"))
+	assert.Equal(t, "secret", got.Get("X-Auth-Token"))
+	assert.Empty(t, got.Get("Cookie"))
+	resp := do(p, "GET", "/api/love", http.Header{"Authorization": {"Bearer t"}})
+	assert.Equal(t, "MISS", resp.Header().Get("X-Cache"))
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,146 @@
+package report
+
+import (
+	"github.com/hacsoc/golove/love"
+	"io"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+/*
+The number of love sent during the week starting Start, as weeks are defined by
+love.WeekStart.
+*/
+type Week struct {
+	Start time.Time
+	Count int
+}
+
+/*
+Return the number of love sent during each week from the first love on the wall
+to the last, oldest first, including the weeks in between in which none was.
+Weeks start at midnight in the location of the love's timestamps.
+*/
+func (w *Wall) Weeks() []Week {
+	var loc *time.Location
+	if len(w.Loves) > 0 {
+		loc = w.Loves[0].Timestamp.Location()
+	}
+	var weeks []Week
+	for _, b := range love.BucketByWeek(w.Loves, loc) {
+		weeks = append(weeks, Week{b.Start, b.Count})
+	}
+	return weeks
+}
+
+/*
+Return at most n notable quotes from the love on the wall, newest first. The
+longest messages are chosen, one per sender, so that a single prolific sender
+cannot fill the list; the same message sent to several recipients counts once.
+*/
+func (w *Wall) Quotes(n int) []love.Love {
+	candidates := make([]love.Love, 0, len(w.Loves))
+	seen := make(map[string]bool)
+	for _, l := range w.Loves {
+		key := l.Sender + "\x00" + l.Message
+		if !seen[key] {
+			seen[key] = true
+			candidates = append(candidates, l)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].Message) > len(candidates[j].Message)
+	})
+	var quotes []love.Love
+	quoted := make(map[string]bool)
+	for _, l := range candidates {
+		if len(quotes) == n {
+			break
+		}
+		if !quoted[l.Sender] {
+			quoted[l.Sender] = true
+			quotes = append(quotes, l)
+		}
+	}
+	sort.SliceStable(quotes, func(i, j int) bool {
+		return quotes[i].Timestamp.After(quotes[j].Timestamp)
+	})
+	return quotes
+}
+
+// The number of quotes in a Markdown report.
+const MarkdownQuotes = 3
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "|", `\|`, "<", `\<`,
+)
+
+/*
+Escape text which is not markdown, such as a name, so that it is shown as it is.
+*/
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+/*
+Quote a message as a markdown block quote, keeping its own markdown but not
+HTML, which messages cannot contain.
+*/
+func quoteMarkdown(message string) string {
+	message = strings.ReplaceAll(love.RenderEmoji(message), "<", `\<`)
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	return "> " + strings.Join(lines, "\n> ")
+}
+
+var markdownTemplate = texttemplate.Must(texttemplate.New("markdown").Funcs(map[string]interface{}{
+	"escape": escapeMarkdown,
+	"quote":  quoteMarkdown,
+	"add":    func(a, b int) int { return a + b },
+	"quotes": func() int { return MarkdownQuotes },
+}).Parse(markdownText))
+
+const markdownText = `# {{escape .Title}}
+{{with .Period}}
+_{{.}}_
+{{end}}{{$summary := .Summary}}
+**{{$summary.Total}}** love from **{{$summary.Senders}}** senders to **{{$summary.Recipients}}** recipients.
+{{if $summary.TopRecipients}}
+## Top recipients
+
+| # | Recipient | Love |
+|--:|-----------|-----:|
+{{range $i, $c := $summary.TopRecipients}}| {{add $i 1}} | {{escape ($.Name $c.Key)}} | {{$c.Count}} |
+{{end}}{{end}}{{if $summary.TopSenders}}
+## Top senders
+
+| # | Sender | Love |
+|--:|--------|-----:|
+{{range $i, $c := $summary.TopSenders}}| {{add $i 1}} | {{escape ($.Name $c.Key)}} | {{$c.Count}} |
+{{end}}{{end}}{{with .Quotes quotes}}
+## Notable quotes
+{{range .}}
+{{quote .Message}}
+>
+> — {{escape ($.Name .Sender)}} to {{escape ($.Name .Recipient)}}, {{.Timestamp.Format "Mon Jan 2"}}
+{{end}}{{end}}{{with .Weeks}}
+## Love per week
+
+| Week of | Love |
+|---------|-----:|
+{{range .}}| {{.Start.Format "Jan 2, 2006"}} | {{.Count}} |
+{{end}}{{end}}`
+
+/*
+Write the wall as a Markdown report, for pasting into a wiki or newsletter: the
+totals, the top recipients and senders, a few notable quotes (see Quotes), and
+the love sent each week. A wall without a Title is titled "Love report".
+*/
+func (w *Wall) WriteMarkdown(out io.Writer) error {
+	page := *w
+	if page.Title == "" {
+		page.Title = "Love report"
+	}
+	return markdownTemplate.Execute(out, &page)
+}
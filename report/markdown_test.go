@@ -0,0 +1,91 @@
+package report
+
+import (
+	"bytes"
+	"github.com/hacsoc/golove/love"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestWeeks(t *testing.T) {
+	w := testWall()
+	assert.Equal(t, []Week{{weekStart, 3}}, w.Weeks())
+	w.Loves = append(w.Loves, love.Love{Sender: "hammy", Recipient: "jeremy",
+		Timestamp: weekStart.AddDate(0, 0, 15)})
+	assert.Equal(t, []Week{
+		{weekStart, 3},
+		{weekStart.AddDate(0, 0, 7), 0},
+		{weekStart.AddDate(0, 0, 14), 1},
+	}, w.Weeks())
+	assert.Empty(t, (&Wall{}).Weeks())
+}
+
+func TestQuotes(t *testing.T) {
+	w := testWall()
+	w.Loves = append(w.Loves,
+		love.Love{Sender: "hammy", Recipient: "jeremy", Message: "short",
+			Timestamp: weekStart.Add(96 * time.Hour)},
+		love.Love{Sender: "jeremy", Recipient: "darwin", Message: "great demo :tada:",
+			Timestamp: weekStart},
+	)
+	quotes := w.Quotes(2)
+	assert.Equal(t, 2, len(quotes))
+	assert.Equal(t, "you **too** #demo", quotes[0].Message)
+	assert.Equal(t, "darwin", quotes[1].Sender)
+	assert.Equal(t, 3, len(w.Quotes(10)))
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	w := testWall()
+	w.Profiles["jeremy"] = &love.Profile{Username: "jeremy", FullName: "Jeremy *J* Smith"}
+	var out bytes.Buffer
+	assert.Nil(t, w.WriteMarkdown(&out))
+	assert.Equal(t, `# Hackathon 2017
+
+_Apr 3, 2017 – Apr 9, 2017_
+
+**3** love from **3** senders to **2** recipients.
+
+## Top recipients
+
+| # | Recipient | Love |
+|--:|-----------|-----:|
+| 1 | hammy | 2 |
+| 2 | Darwin Watterson | 1 |
+
+## Top senders
+
+| # | Sender | Love |
+|--:|--------|-----:|
+| 1 | Darwin Watterson | 1 |
+| 2 | hammy | 1 |
+| 3 | Jeremy \*J\* Smith | 1 |
+
+## Notable quotes
+
+> you **too** #demo
+>
+> — hammy to Darwin Watterson, Thu Apr 6
+
+> thanks for \<b>everything\</b> ❤️ #demo
+>
+> — Darwin Watterson to hammy, Wed Apr 5
+
+> great demo 🎉
+>
+> — Jeremy \*J\* Smith to hammy, Mon Apr 3
+
+## Love per week
+
+| Week of | Love |
+|---------|-----:|
+| Apr 3, 2017 | 3 |
+`, out.String())
+}
+
+func TestWriteMarkdownEmpty(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, (&Wall{}).WriteMarkdown(&out))
+	assert.Equal(t, "# Love report\n\n**0** love from **0** senders to **0** recipients.\n", out.String())
+}
@@ -0,0 +1,168 @@
+/*
+Package report renders love as a "love wall": a self-contained HTML page with a
+card for each love, statistics about who sent and received it, and filters by
+person and text. The page needs nothing but a browser, so it can be shown on an
+office TV, attached to an email, or shared after a hackathon:
+
+	wall := &report.Wall{Title: "Hackathon 2017", Loves: loves}
+	file, err := os.Create("wall.html")
+	if err != nil {
+		// handle error
+	}
+	defer file.Close()
+	if err := wall.Write(file); err != nil {
+		// handle error
+	}
+
+Messages are shown with their emoji shortcodes and markdown rendered. See
+love.RenderEmoji and love.MarkdownHTML.
+
+A wall can also be written as a Markdown report, with the top recipients and
+senders, notable quotes and the love sent each week, for pasting into a wiki or
+newsletter. See Wall.WriteMarkdown.
+*/
+package report
+
+import (
+	"github.com/hacsoc/golove/love"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+A Wall is a page of love. Loves are shown in the order given, which is best
+newest first. Since and Until describe the period the love was sent in, and
+are zero if it is open-ended.
+
+Profiles holds the profiles of the users on the wall, by username, where the
+instance provides them, so that they are shown by their full names. It may be
+nil.
+
+If Refresh is greater than zero, the page reloads itself that often, so that a
+TV showing a file which is regenerated regularly keeps up to date.
+*/
+type Wall struct {
+	Title     string
+	Since     time.Time
+	Until     time.Time
+	Loves     []love.Love
+	Profiles  map[string]*love.Profile
+	Refresh   time.Duration
+	Generated time.Time
+}
+
+/*
+Summary statistics about the love on a wall. The top lists hold at most
+SummaryTop entries each.
+*/
+type Summary struct {
+	Total         int
+	Senders       int
+	Recipients    int
+	TopSenders    []love.Count
+	TopRecipients []love.Count
+	TopTags       []love.Count
+}
+
+// The length of the top lists of a Summary.
+const SummaryTop = 5
+
+/*
+Return the summary statistics of the wall.
+*/
+func (w *Wall) Summary() *Summary {
+	stats := love.ComputeStats(w.Loves)
+	tags := make(map[string]int)
+	for _, l := range w.Loves {
+		for _, tag := range love.ExtractHashtags(l.Message) {
+			tags["#"+tag]++
+		}
+	}
+	return &Summary{
+		Total:         stats.Total,
+		Senders:       len(stats.BySender),
+		Recipients:    len(stats.ByRecipient),
+		TopSenders:    love.TopCounts(stats.BySender, SummaryTop),
+		TopRecipients: love.TopCounts(stats.ByRecipient, SummaryTop),
+		TopTags:       love.TopCounts(tags, SummaryTop),
+	}
+}
+
+/*
+Return the name a user is shown by: their full name, if they have a profile
+with one, or else their username.
+*/
+func (w *Wall) Name(user string) string {
+	if p := w.Profiles[user]; p != nil && p.FullName != "" {
+		return p.FullName
+	}
+	return user
+}
+
+/*
+Return the users who sent or received love on the wall, sorted by the names
+they are shown by.
+*/
+func (w *Wall) Users() []string {
+	seen := make(map[string]bool)
+	var users []string
+	for _, l := range w.Loves {
+		for _, user := range []string{l.Sender, l.Recipient} {
+			if !seen[user] {
+				seen[user] = true
+				users = append(users, user)
+			}
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return strings.ToLower(w.Name(users[i])) < strings.ToLower(w.Name(users[j]))
+	})
+	return users
+}
+
+/*
+Return a description of the period of the wall, such as "Apr 3, 2017 – Apr 9,
+2017", "since Apr 3, 2017", or "" if it is open-ended. Until is exclusive, so
+the day before it is shown.
+*/
+func (w *Wall) Period() string {
+	const layout = "Jan 2, 2006"
+	switch {
+	case !w.Since.IsZero() && !w.Until.IsZero():
+		return w.Since.Format(layout) + " – " + w.Until.Add(-time.Nanosecond).Format(layout)
+	case !w.Since.IsZero():
+		return "since " + w.Since.Format(layout)
+	case !w.Until.IsZero():
+		return "until " + w.Until.Add(-time.Nanosecond).Format(layout)
+	}
+	return ""
+}
+
+var funcs = template.FuncMap{
+	"message": func(message string) template.HTML {
+		// MarkdownHTML escapes everything but its own markup.
+		return template.HTML(love.MarkdownHTML(love.RenderEmoji(message)))
+	},
+	"lower":   strings.ToLower,
+	"seconds": func(d time.Duration) int { return int(d.Seconds()) },
+}
+
+var wallTemplate = template.Must(template.New("wall").Funcs(funcs).Parse(wallHTML))
+
+/*
+Write the wall as an HTML page. A wall without a Title is titled "Love wall",
+and one without a Generated time was generated now.
+*/
+func (w *Wall) Write(out io.Writer) error {
+	page := *w
+	if page.Title == "" {
+		page.Title = "Love wall"
+	}
+	if page.Generated.IsZero() {
+		page.Generated = time.Now()
+	}
+	return wallTemplate.Execute(out, &page)
+}
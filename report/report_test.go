@@ -0,0 +1,88 @@
+package report
+
+import (
+	"bytes"
+	"github.com/hacsoc/golove/love"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var (
+	weekStart = time.Date(2017, 4, 3, 0, 0, 0, 0, time.UTC)
+	weekEnd   = time.Date(2017, 4, 10, 0, 0, 0, 0, time.UTC)
+)
+
+func testWall() *Wall {
+	return &Wall{
+		Title: "Hackathon 2017",
+		Since: weekStart,
+		Until: weekEnd,
+		Loves: []love.Love{
+			{Sender: "hammy", Recipient: "darwin", Message: "you **too** #demo",
+				Timestamp: weekStart.Add(72 * time.Hour)},
+			{Sender: "darwin", Recipient: "hammy", Message: "thanks for <b>everything</b> <3 #demo",
+				Timestamp: weekStart.Add(48 * time.Hour), Values: []string{"teamwork"}},
+			{Sender: "jeremy", Recipient: "hammy", Message: "great demo :tada:",
+				Timestamp: weekStart},
+		},
+		Profiles: map[string]*love.Profile{
+			"darwin": {Username: "darwin", FullName: "Darwin Watterson"},
+		},
+	}
+}
+
+func TestSummary(t *testing.T) {
+	s := testWall().Summary()
+	assert.Equal(t, 3, s.Total)
+	assert.Equal(t, 3, s.Senders)
+	assert.Equal(t, 2, s.Recipients)
+	assert.Equal(t, []love.Count{{Key: "hammy", Count: 2}, {Key: "darwin", Count: 1}}, s.TopRecipients)
+	assert.Equal(t, []love.Count{{Key: "#demo", Count: 2}}, s.TopTags)
+}
+
+func TestUsers(t *testing.T) {
+	w := testWall()
+	assert.Equal(t, "Darwin Watterson", w.Name("darwin"))
+	assert.Equal(t, "hammy", w.Name("hammy"))
+	assert.Equal(t, []string{"darwin", "hammy", "jeremy"}, w.Users())
+}
+
+func TestPeriod(t *testing.T) {
+	w := testWall()
+	assert.Equal(t, "Apr 3, 2017 – Apr 9, 2017", w.Period())
+	w.Until = time.Time{}
+	assert.Equal(t, "since Apr 3, 2017", w.Period())
+	w.Since = time.Time{}
+	assert.Equal(t, "", w.Period())
+}
+
+func TestWrite(t *testing.T) {
+	w := testWall()
+	w.Refresh = 5 * time.Minute
+	var out bytes.Buffer
+	assert.Nil(t, w.Write(&out))
+	page := out.String()
+	assert.Contains(t, page, "<title>Hackathon 2017</title>")
+	assert.Contains(t, page, `<meta http-equiv="refresh" content="300">`)
+	assert.Contains(t, page, "Apr 3, 2017 – Apr 9, 2017")
+	assert.Contains(t, page, `data-sender="hammy" data-recipient="darwin" data-text="you **too** #demo"`)
+	assert.Contains(t, page, "you <strong>too</strong> #demo")
+	assert.Contains(t, page, "thanks for &lt;b&gt;everything&lt;/b&gt; ❤️ #demo")
+	assert.Contains(t, page, "great demo 🎉")
+	assert.Contains(t, page, "Darwin Watterson &rarr; hammy")
+	assert.Contains(t, page, `<span class="value">teamwork</span>`)
+	assert.Equal(t, 3, bytes.Count(out.Bytes(), []byte(`<div class="card"`)))
+	assert.Equal(t, "Hackathon 2017", w.Title)
+	assert.True(t, w.Generated.IsZero())
+}
+
+func TestWriteEmpty(t *testing.T) {
+	var out bytes.Buffer
+	assert.Nil(t, (&Wall{}).Write(&out))
+	page := out.String()
+	assert.Contains(t, page, "<title>Love wall</title>")
+	assert.Contains(t, page, "No love yet.")
+	assert.NotContains(t, page, "http-equiv")
+	assert.NotContains(t, page, `<div class="card"`)
+}
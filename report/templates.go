@@ -0,0 +1,112 @@
+package report
+
+/*
+The page of a Wall. Everything it needs is inline, so that the file can be
+opened anywhere. The script only filters the cards; without it, every card is
+shown.
+*/
+const wallHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+{{if gt .Refresh 0}}<meta http-equiv="refresh" content="{{seconds .Refresh}}">
+{{end}}<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 0; padding: 1.5em; background: #fdf6f6; color: #222; }
+h1 { color: #d32323; margin: 0; }
+.period { color: #666; margin: 0.25em 0 1em; }
+.summary { display: flex; flex-wrap: wrap; gap: 1em; margin-bottom: 1em; }
+.stat { background: #fff; border-radius: 8px; padding: 0.75em 1em; box-shadow: 0 1px 3px rgba(0,0,0,0.1); }
+.stat .number { font-size: 2em; font-weight: bold; color: #d32323; }
+.stat ol { margin: 0.25em 0 0; padding-left: 1.5em; }
+.stat a { color: inherit; cursor: pointer; }
+.filters { display: flex; flex-wrap: wrap; gap: 0.5em; align-items: center; margin-bottom: 1em; }
+.filters input, .filters select { font-size: 1em; padding: 0.25em; }
+.cards { display: grid; grid-template-columns: repeat(auto-fill, minmax(18em, 1fr)); gap: 1em; }
+.card { background: #fff; border-top: 4px solid #d32323; border-radius: 8px; padding: 1em; box-shadow: 0 1px 3px rgba(0,0,0,0.1); }
+.card .people { font-weight: bold; }
+.card .message { margin: 0.5em 0; font-size: 1.1em; overflow-wrap: anywhere; }
+.card .meta { color: #666; font-size: 0.85em; }
+.card .value { background: #fde8e8; border-radius: 4px; padding: 0 0.3em; margin-right: 0.3em; }
+.empty { color: #666; }
+footer { color: #999; font-size: 0.8em; margin-top: 2em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Period}}<p class="period">{{.}}</p>
+{{end}}{{$summary := .Summary}}<div class="summary">
+<div class="stat"><div class="number">{{$summary.Total}}</div>love</div>
+<div class="stat"><div class="number">{{$summary.Senders}}</div>senders</div>
+<div class="stat"><div class="number">{{$summary.Recipients}}</div>recipients</div>
+{{if $summary.TopSenders}}<div class="stat">Top senders<ol>
+{{range $summary.TopSenders}}<li><a data-sender="{{.Key}}">{{$.Name .Key}}</a> ({{.Count}})</li>
+{{end}}</ol></div>
+{{end}}{{if $summary.TopRecipients}}<div class="stat">Top recipients<ol>
+{{range $summary.TopRecipients}}<li><a data-recipient="{{.Key}}">{{$.Name .Key}}</a> ({{.Count}})</li>
+{{end}}</ol></div>
+{{end}}{{if $summary.TopTags}}<div class="stat">Top tags<ol>
+{{range $summary.TopTags}}<li><a data-text="{{.Key}}">{{.Key}}</a> ({{.Count}})</li>
+{{end}}</ol></div>
+{{end}}</div>
+{{if .Loves}}<div class="filters">
+<input id="text" type="search" placeholder="Filter by text">
+<select id="sender"><option value="">Any sender</option>
+{{range .Users}}<option value="{{.}}">{{$.Name .}}</option>
+{{end}}</select>
+<select id="recipient"><option value="">Any recipient</option>
+{{range .Users}}<option value="{{.}}">{{$.Name .}}</option>
+{{end}}</select>
+<span id="count"></span>
+</div>
+<div class="cards">
+{{range .Loves}}<div class="card" data-sender="{{.Sender}}" data-recipient="{{.Recipient}}" data-text="{{lower .Message}}">
+<div class="people">{{$.Name .Sender}} &rarr; {{$.Name .Recipient}}</div>
+<div class="message">{{message .Message}}</div>
+<div class="meta">{{range .Values}}<span class="value">{{.}}</span>{{end}}{{.Timestamp.Format "Mon Jan 2, 2006 15:04"}}</div>
+</div>
+{{end}}</div>
+{{else}}<p class="empty">No love yet. Why not send some?</p>
+{{end}}<footer>Generated by golove on {{.Generated.Format "Jan 2, 2006 at 15:04"}}</footer>
+<script>
+(function() {
+	var text = document.getElementById("text");
+	var sender = document.getElementById("sender");
+	var recipient = document.getElementById("recipient");
+	var count = document.getElementById("count");
+	if (!text) {
+		return;
+	}
+	var cards = document.querySelectorAll(".card");
+	function filter() {
+		var query = text.value.toLowerCase();
+		var shown = 0;
+		cards.forEach(function(card) {
+			var match = card.dataset.text.indexOf(query) >= 0 &&
+				(!sender.value || card.dataset.sender === sender.value) &&
+				(!recipient.value || card.dataset.recipient === recipient.value);
+			card.style.display = match ? "" : "none";
+			if (match) {
+				shown++;
+			}
+		});
+		count.textContent = shown + " of " + cards.length;
+	}
+	[text, sender, recipient].forEach(function(input) {
+		input.addEventListener("input", filter);
+	});
+	document.querySelectorAll(".stat a").forEach(function(link) {
+		link.addEventListener("click", function() {
+			text.value = link.dataset.text || "";
+			sender.value = link.dataset.sender || "";
+			recipient.value = link.dataset.recipient || "";
+			filter();
+		});
+	});
+	filter();
+})();
+</script>
+</body>
+</html>
+`
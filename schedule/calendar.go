@@ -0,0 +1,408 @@
+package schedule
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+An Occasion is a day to send someone love on every year, such as their birthday
+or work anniversary.
+*/
+type Occasion struct {
+	// The kind of occasion, such as "birthday" or "anniversary", in lower
+	// case. It selects the message sent.
+	Kind     string
+	Username string
+	// The person's name, if known.
+	Name  string
+	Month time.Month
+	Day   int
+	// The year of the first occasion, such as the year someone joined, or 0
+	// if it is not known.
+	Year int
+}
+
+/*
+Return midnight on the occasion in a year. Occasions on February 29th are on
+February 28th in other years.
+*/
+func (o Occasion) In(year int, loc *time.Location) time.Time {
+	day := o.Day
+	if o.Month == time.February && day == 29 &&
+		time.Date(year, time.February, 29, 0, 0, 0, 0, loc).Month() != time.February {
+		day = 28
+	}
+	return time.Date(year, o.Month, day, 0, 0, 0, 0, loc)
+}
+
+/*
+Return the number of years since the first occasion, as of a year, or 0 if the
+first year is not known.
+*/
+func (o Occasion) Years(year int) int {
+	if o.Year == 0 || year < o.Year {
+		return 0
+	}
+	return year - o.Year
+}
+
+/*
+Parse the date of an occasion, as 2006-01-02, or 01-02 when the year is not
+known. iCalendar dates, 20060102, are also accepted.
+*/
+func parseOccasionDate(s string, o *Occasion) error {
+	s = strings.TrimSpace(s)
+	var layout string
+	switch len(s) {
+	case len("2006-01-02"):
+		layout = "2006-01-02"
+	case len("20060102"):
+		layout = "20060102"
+	case len("01-02"):
+		layout = "01-02"
+	default:
+		return fmt.Errorf("invalid date %q", s)
+	}
+	// Leap years have every day.
+	prefix := ""
+	if layout == "01-02" {
+		prefix, layout = "2000-", "2006-01-02"
+	}
+	t, err := time.Parse(layout, prefix+s)
+	if err != nil {
+		return fmt.Errorf("invalid date %q", s)
+	}
+	o.Month, o.Day = t.Month(), t.Day()
+	if prefix == "" {
+		o.Year = t.Year()
+	}
+	return nil
+}
+
+/*
+Read occasions from CSV, with a row for each: the username, the kind of
+occasion, the date, and optionally the person's name, as in:
+
+	darwin,birthday,05-17,Darwin Dog
+	hammy,anniversary,2015-09-01
+
+The date is 2006-01-02, or 01-02 when the year is not known. A first row
+starting with "username" is a header, and is skipped. Lines starting with # are
+ignored.
+*/
+func ReadOccasionsCSV(r io.Reader) ([]Occasion, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var occasions []Occasion
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return occasions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(record[0], "username") {
+			continue
+		}
+		if len(record) < 3 || len(record) > 4 {
+			return nil, fmt.Errorf("line %d: expected username, kind, date and name", line)
+		}
+		o := Occasion{
+			Username: strings.TrimSpace(record[0]),
+			Kind:     strings.ToLower(strings.TrimSpace(record[1])),
+		}
+		if len(record) == 4 {
+			o.Name = strings.TrimSpace(record[3])
+		}
+		if err := parseOccasionDate(record[2], &o); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		if o.Username == "" || o.Kind == "" {
+			return nil, fmt.Errorf("line %d: expected username, kind, date and name", line)
+		}
+		occasions = append(occasions, o)
+	}
+}
+
+/*
+Read occasions from the events of an iCalendar file, such as a calendar of
+birthdays exported from another app. Each event is an occasion on the date of
+its DTSTART, whatever its RRULE. The username is in the X-GOLOVE-USERNAME
+property, and the kind is the first of the CATEGORIES, as in:
+
+	BEGIN:VEVENT
+	DTSTART;VALUE=DATE:19900517
+	SUMMARY:Darwin Dog
+	CATEGORIES:Birthday
+	X-GOLOVE-USERNAME:darwin
+	END:VEVENT
+
+in which case the SUMMARY is the person's name, or else the SUMMARY is the kind
+and the username, separated by a colon, as in "Birthday: darwin". Since a
+recurring event usually starts on the first occasion, the year of DTSTART is
+taken as the year of the first occasion only for events which are not
+birthdays.
+*/
+func ReadOccasionsICal(r io.Reader) ([]Occasion, error) {
+	lines, err := unfoldICal(r)
+	if err != nil {
+		return nil, err
+	}
+	var occasions []Occasion
+	var event map[string]string
+	for _, line := range lines {
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		name, value := strings.ToUpper(line[:i]), line[i+1:]
+		if j := strings.Index(name, ";"); j >= 0 {
+			name = name[:j]
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			event = make(map[string]string)
+		case name == "END" && strings.EqualFold(value, "VEVENT") && event != nil:
+			o, err := icalOccasion(event)
+			if err != nil {
+				return nil, err
+			}
+			occasions = append(occasions, o)
+			event = nil
+		case event != nil:
+			if _, ok := event[name]; !ok {
+				event[name] = value
+			}
+		}
+	}
+	return occasions, nil
+}
+
+/*
+Read the lines of an iCalendar file, joining lines which are folded, that is,
+continued on lines starting with a space or tab.
+*/
+func unfoldICal(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+		} else {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+var icalEscapes = strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`)
+
+func icalOccasion(event map[string]string) (Occasion, error) {
+	summary := icalEscapes.Replace(event["SUMMARY"])
+	o := Occasion{Username: strings.TrimSpace(event["X-GOLOVE-USERNAME"])}
+	if o.Username != "" {
+		category := strings.SplitN(event["CATEGORIES"], ",", 2)[0]
+		o.Kind = strings.ToLower(strings.TrimSpace(icalEscapes.Replace(category)))
+		o.Name = strings.TrimSpace(summary)
+	} else if i := strings.Index(summary, ":"); i >= 0 {
+		o.Kind = strings.ToLower(strings.TrimSpace(summary[:i]))
+		o.Username = strings.TrimSpace(summary[i+1:])
+	}
+	if o.Username == "" || o.Kind == "" {
+		return o, fmt.Errorf("event %q: no username and kind", summary)
+	}
+	start := event["DTSTART"]
+	if len(start) > len("20060102") {
+		start = start[:len("20060102")]
+	}
+	if err := parseOccasionDate(start, &o); err != nil {
+		return o, fmt.Errorf("event %q: %s", summary, err)
+	}
+	if o.Kind == "birthday" {
+		o.Year = 0
+	}
+	return o, nil
+}
+
+/*
+What happens to love for an occasion which falls on a holiday.
+*/
+type HolidayRule int
+
+const (
+	// The love is sent on the holiday anyway.
+	SendOnHoliday HolidayRule = iota
+	// The love is not sent.
+	SkipHoliday
+	// The love is sent on the last working day before the holiday.
+	BeforeHoliday
+	// The love is sent on the first working day after the holiday.
+	AfterHoliday
+)
+
+var holidayRules = []string{"send", "skip", "before", "after"}
+
+/*
+Parse a HolidayRule from its name: send, skip, before or after.
+*/
+func ParseHolidayRule(s string) (HolidayRule, error) {
+	for i, name := range holidayRules {
+		if strings.EqualFold(s, name) {
+			return HolidayRule(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown holiday rule %q", s)
+}
+
+func (r HolidayRule) String() string {
+	return holidayRules[r]
+}
+
+/*
+Holidays are the days on which people are not working, and would not see love
+sent to them. The zero value has none.
+*/
+type Holidays struct {
+	// Whether Saturdays and Sundays are holidays.
+	Weekends bool
+	// Holidays on a date, as 2006-01-02, or every year, as 01-02.
+	Dates map[string]bool
+}
+
+/*
+Read holidays from a file with a date on each line, as 2006-01-02, or 01-02 for
+a holiday every year. Anything following the date, such as the name of the
+holiday, is ignored, as are blank lines and lines starting with #.
+*/
+func ReadHolidays(r io.Reader) (*Holidays, error) {
+	holidays := &Holidays{Dates: make(map[string]bool)}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		var o Occasion
+		if err := parseOccasionDate(fields[0], &o); err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		date := fmt.Sprintf("%02d-%02d", o.Month, o.Day)
+		if o.Year != 0 {
+			date = fmt.Sprintf("%04d-%s", o.Year, date)
+		}
+		holidays.Dates[date] = true
+	}
+	return holidays, scanner.Err()
+}
+
+/*
+Report whether a day is a holiday. A nil *Holidays has none.
+*/
+func (h *Holidays) IsHoliday(t time.Time) bool {
+	if h == nil {
+		return false
+	}
+	if h.Weekends && (t.Weekday() == time.Saturday || t.Weekday() == time.Sunday) {
+		return true
+	}
+	return h.Dates[t.Format("2006-01-02")] || h.Dates[t.Format("01-02")]
+}
+
+/*
+Apply a rule to a day: return the day the love for an occasion on it is sent,
+and whether it is sent at all. If there is no working day within a year, the
+love is not sent.
+*/
+func (h *Holidays) Adjust(t time.Time, rule HolidayRule) (time.Time, bool) {
+	if !h.IsHoliday(t) || rule == SendOnHoliday {
+		return t, true
+	}
+	step := 1
+	switch rule {
+	case SkipHoliday:
+		return t, false
+	case BeforeHoliday:
+		step = -1
+	}
+	for i := 0; i < 366; i++ {
+		t = t.AddDate(0, 0, step)
+		if !h.IsHoliday(t) {
+			return t, true
+		}
+	}
+	return t, false
+}
+
+/*
+A Date is when love is sent for an occasion, On a day.
+*/
+type Date struct {
+	Occasion
+	On time.Time
+	At time.Time
+}
+
+/*
+A Planner works out when love is sent for occasions: at Hour o'clock on the
+day, or according to Rule if the day is one of the Holidays.
+*/
+type Planner struct {
+	Hour     int
+	Holidays *Holidays
+	Rule     HolidayRule
+}
+
+/*
+Return the dates love is sent for occasions from a time until another,
+soonest first. An occasion is included if its love is sent in that time, even
+if the occasion itself is not, having been moved by a holiday. Times are in the
+location of from.
+*/
+func (p *Planner) Upcoming(occasions []Occasion, from, until time.Time) []Date {
+	loc := from.Location()
+	var dates []Date
+	for _, o := range occasions {
+		for year := from.Year() - 1; year <= until.Year()+1; year++ {
+			on := o.In(year, loc)
+			day, send := p.Holidays.Adjust(on, p.Rule)
+			if !send {
+				continue
+			}
+			at := time.Date(day.Year(), day.Month(), day.Day(), p.Hour, 0, 0, 0, loc)
+			if !at.Before(from) && at.Before(until) {
+				dates = append(dates, Date{o, on, at})
+			}
+		}
+	}
+	sort.SliceStable(dates, func(i, j int) bool { return dates[i].At.Before(dates[j].At) })
+	return dates
+}
+
+/*
+Return the fields of a date by name, for use as template data such as in
+love.RenderMessages: Kind, Name, Date (such as "May 17"), and Years, the number
+of years since the first occasion, which is empty if it is not known.
+*/
+func (d Date) Fields() map[string]string {
+	fields := map[string]string{
+		"Kind":  d.Kind,
+		"Name":  d.Name,
+		"Date":  d.On.Format("January 2"),
+		"Years": "",
+	}
+	if years := d.Years(d.On.Year()); years > 0 {
+		fields["Years"] = strconv.Itoa(years)
+	}
+	return fields
+}
@@ -0,0 +1,147 @@
+package schedule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, date int) time.Time {
+	return time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+}
+
+func TestReadOccasionsCSV(t *testing.T) {
+	occasions, err := ReadOccasionsCSV(strings.NewReader(`username,kind,date,name
+# Birthdays
+darwin, Birthday, 05-17, Darwin Dog
+hammy,anniversary,2015-09-01
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Occasion{
+		{Kind: "birthday", Username: "darwin", Name: "Darwin Dog", Month: time.May, Day: 17},
+		{Kind: "anniversary", Username: "hammy", Month: time.September, Day: 1, Year: 2015},
+	}, occasions)
+
+	for _, bad := range []string{
+		"darwin,birthday\n",
+		"darwin,birthday,05-32\n",
+		"darwin,birthday,May 17\n",
+		",birthday,05-17\n",
+	} {
+		_, err := ReadOccasionsCSV(strings.NewReader(bad))
+		assert.NotNil(t, err, bad)
+	}
+}
+
+func TestReadOccasionsICal(t *testing.T) {
+	occasions, err := ReadOccasionsICal(strings.NewReader(strings.ReplaceAll(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:19900517
+RRULE:FREQ=YEARLY
+SUMMARY:Darwin Dog
+CATEGORIES:Birthday,Team
+X-GOLOVE-USERNAME:darwin
+END:VEVENT
+BEGIN:VEVENT
+DTSTART:20150901T090000Z
+SUMMARY:Anniversary:
+  hammy
+END:VEVENT
+END:VCALENDAR
+`, "\n", "\r\n")))
+	assert.Nil(t, err)
+	assert.Equal(t, []Occasion{
+		{Kind: "birthday", Username: "darwin", Name: "Darwin Dog", Month: time.May, Day: 17},
+		{Kind: "anniversary", Username: "hammy", Month: time.September, Day: 1, Year: 2015},
+	}, occasions)
+
+	_, err = ReadOccasionsICal(strings.NewReader(
+		"BEGIN:VEVENT\nDTSTART:20170101\nSUMMARY:New Year\nEND:VEVENT\n"))
+	assert.NotNil(t, err)
+}
+
+func TestOccasionIn(t *testing.T) {
+	leap := Occasion{Month: time.February, Day: 29, Year: 2016}
+	assert.Equal(t, day(2017, 2, 28), leap.In(2017, time.UTC))
+	assert.Equal(t, day(2020, 2, 29), leap.In(2020, time.UTC))
+	assert.Equal(t, 4, leap.Years(2020))
+	assert.Equal(t, 0, leap.Years(2015))
+	assert.Equal(t, 0, Occasion{Month: time.May, Day: 1}.Years(2020))
+}
+
+func TestHolidays(t *testing.T) {
+	holidays, err := ReadHolidays(strings.NewReader(`# Office closures
+12-25 Christmas
+2017-11-23 Thanksgiving
+
+`))
+	assert.Nil(t, err)
+	holidays.Weekends = true
+	assert.True(t, holidays.IsHoliday(day(2018, 12, 25)))
+	assert.True(t, holidays.IsHoliday(day(2017, 11, 23)))
+	assert.False(t, holidays.IsHoliday(day(2018, 11, 23)))
+	// A Saturday.
+	assert.True(t, holidays.IsHoliday(day(2017, 4, 8)))
+	assert.False(t, holidays.IsHoliday(day(2017, 4, 7)))
+	assert.False(t, (*Holidays)(nil).IsHoliday(day(2017, 12, 25)))
+
+	_, err = ReadHolidays(strings.NewReader("Christmas\n"))
+	assert.NotNil(t, err)
+
+	// Monday, December 25, 2017.
+	christmas := day(2017, 12, 25)
+	for rule, expected := range map[HolidayRule]time.Time{
+		SendOnHoliday: christmas,
+		BeforeHoliday: day(2017, 12, 22),
+		AfterHoliday:  day(2017, 12, 26),
+	} {
+		adjusted, send := holidays.Adjust(christmas, rule)
+		assert.True(t, send, rule.String())
+		assert.Equal(t, expected, adjusted, rule.String())
+	}
+	_, send := holidays.Adjust(christmas, SkipHoliday)
+	assert.False(t, send)
+	adjusted, send := holidays.Adjust(day(2017, 12, 27), SkipHoliday)
+	assert.True(t, send)
+	assert.Equal(t, day(2017, 12, 27), adjusted)
+}
+
+func TestParseHolidayRule(t *testing.T) {
+	rule, err := ParseHolidayRule("Before")
+	assert.Nil(t, err)
+	assert.Equal(t, BeforeHoliday, rule)
+	_, err = ParseHolidayRule("never")
+	assert.NotNil(t, err)
+}
+
+func TestPlannerUpcoming(t *testing.T) {
+	occasions := []Occasion{
+		{Kind: "birthday", Username: "darwin", Month: time.April, Day: 8},
+		{Kind: "anniversary", Username: "hammy", Month: time.April, Day: 5, Year: 2015},
+		{Kind: "birthday", Username: "jeremy", Month: time.April, Day: 30},
+		// Sent in January of next year.
+		{Kind: "birthday", Username: "alice", Month: time.January, Day: 1},
+	}
+	planner := &Planner{Hour: 9, Holidays: &Holidays{Weekends: true}, Rule: BeforeHoliday}
+	dates := planner.Upcoming(occasions, now, now.AddDate(0, 0, 7))
+	// Hammy's anniversary was at 9am today, and Darwin's birthday, on
+	// Saturday, is moved to Friday.
+	assert.Len(t, dates, 1)
+	assert.Equal(t, "darwin", dates[0].Username)
+	assert.Equal(t, at(4, 7, 9, 0), dates[0].At)
+	assert.Equal(t, map[string]string{"Kind": "birthday", "Name": "", "Date": "April 8", "Years": ""},
+		dates[0].Fields())
+
+	planner.Rule = SkipHoliday
+	assert.Len(t, planner.Upcoming(occasions, now, now.AddDate(0, 0, 7)), 0)
+
+	// January 1, 2018 is a Monday.
+	planner.Rule = AfterHoliday
+	dates = planner.Upcoming(occasions, now, now.AddDate(1, 0, 0))
+	assert.Len(t, dates, 4)
+	assert.Equal(t, "alice", dates[2].Username)
+	assert.Equal(t, time.Date(2018, 1, 1, 9, 0, 0, 0, time.UTC), dates[2].At)
+	assert.Equal(t, "hammy", dates[3].Username)
+	assert.Equal(t, "3", dates[3].Fields()["Years"])
+}
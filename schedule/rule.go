@@ -0,0 +1,227 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+A Rule describes when recurring love is sent, like a line of a crontab. Create
+one with ParseRule.
+*/
+type Rule struct {
+	spec   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// Whether the day of the month or week is *, which matters because a day
+	// matches if it matches either, unless one of them is *.
+	domStar bool
+	dowStar bool
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug",
+	"sep", "oct", "nov", "dec"}
+
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// How far ahead Next looks before deciding that a rule never matches again.
+const maxLookahead = 5 * 366 * 24 * time.Hour
+
+/*
+ParseRule parses the five fields of a cron schedule, which are separated by
+spaces:
+
+	minute        0-59
+	hour          0-23
+	day of month  1-31
+	month         1-12, or jan-dec
+	day of week   0-6, or sun-sat (7 is also sunday)
+
+Each field is *, a number, a range such as 1-5, or a comma separated list of
+them, and each * or range may be followed by a step, such as /15, so that
+0-30/15 matches 0, 15 and 30. As in cron, when both the day of the month and
+day of the week are restricted, a day matches if it matches either. So
+"0 9 15 * *" is at 9am on the 15th of every month, and "30 16 * * fri" is at
+4:30pm every friday. The macros @yearly (@annually), @monthly, @weekly, @daily
+and @hourly are also accepted.
+*/
+func ParseRule(spec string) (*Rule, error) {
+	spec = strings.TrimSpace(spec)
+	fields := strings.Fields(spec)
+	if len(fields) == 1 {
+		if expanded, ok := macros[strings.ToLower(fields[0])]; ok {
+			fields = strings.Fields(expanded)
+		}
+	}
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("rule %q: expected 5 fields", spec)
+	}
+	r := &Rule{spec: spec}
+	var err error
+	parse := func(field string, min, max int, names []string, bits *uint64) {
+		if err == nil {
+			*bits, err = parseField(strings.ToLower(field), min, max, names)
+		}
+	}
+	parse(fields[0], 0, 59, nil, &r.minute)
+	parse(fields[1], 0, 23, nil, &r.hour)
+	parse(fields[2], 1, 31, nil, &r.dom)
+	parse(fields[3], 1, 12, monthNames, &r.month)
+	parse(fields[4], 0, 7, dayNames, &r.dow)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: %s", spec, err)
+	}
+	if r.dow&(1<<7) != 0 {
+		r.dow |= 1
+	}
+	r.domStar = strings.HasPrefix(fields[2], "*")
+	r.dowStar = strings.HasPrefix(fields[4], "*")
+	// Love on February 30th would never be sent.
+	if r.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("rule %q never occurs", spec)
+	}
+	return r, nil
+}
+
+/*
+Parse a field of a rule into a bit set of the values it matches. Names, if any,
+are the names of the values from min.
+*/
+func parseField(field string, min, max int, names []string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+		low, high := min, max
+		switch i := strings.Index(part, "-"); {
+		case part == "*":
+		case i >= 0:
+			var err error
+			if low, err = parseValue(part[:i], min, max, names); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(part[i+1:], min, max, names); err != nil {
+				return 0, err
+			}
+			if high < low {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			value, err := parseValue(part, min, max, names)
+			if err != nil {
+				return 0, err
+			}
+			low, high = value, value
+			if step != 1 {
+				// As in cron, 5/10 means 5-max/10.
+				high = max
+			}
+		}
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, min, max int, names []string) (int, error) {
+	for i, name := range names {
+		if s == name {
+			return min + i, nil
+		}
+	}
+	value, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if value < min || value > max {
+		return 0, fmt.Errorf("%d is not between %d and %d", value, min, max)
+	}
+	return value, nil
+}
+
+/*
+Return the rule as it was given to ParseRule.
+*/
+func (r *Rule) String() string {
+	return r.spec
+}
+
+func (r *Rule) matchDay(t time.Time) bool {
+	dom := r.dom&(1<<uint(t.Day())) != 0
+	dow := r.dow&(1<<uint(t.Weekday())) != 0
+	if r.domStar || r.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+/*
+Next returns the first time the rule matches strictly after a time, in that
+time's location, or the zero time if it does not match within five years. Times
+which do not exist, because the clocks go forward, are skipped.
+*/
+func (r *Rule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	end := after.Add(maxLookahead)
+	for t.Before(end) {
+		year, month, day := t.Date()
+		var next time.Time
+		switch {
+		case r.month&(1<<uint(month)) == 0:
+			next = time.Date(year, month+1, 1, 0, 0, 0, 0, loc)
+		case !r.matchDay(t):
+			next = time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+		case r.hour&(1<<uint(t.Hour())) == 0:
+			next = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, loc)
+		case r.minute&(1<<uint(t.Minute())) == 0:
+			next = t.Add(time.Minute)
+		default:
+			return t
+		}
+		// Where the clocks change, the next hour or day may be normalized to
+		// a time which is not later.
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+/*
+Occurrences returns the first n times the rule matches after a time, as by
+Next.
+*/
+func (r *Rule) Occurrences(after time.Time, n int) []time.Time {
+	var times []time.Time
+	for len(times) < n {
+		after = r.Next(after)
+		if after.IsZero() {
+			break
+		}
+		times = append(times, after)
+	}
+	return times
+}
@@ -0,0 +1,68 @@
+package schedule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRuleNext(t *testing.T) {
+	for spec, expected := range map[string][]time.Time{
+		"0 9 15 * *":     {at(4, 15, 9, 0), at(5, 15, 9, 0)},
+		"30 16 * * fri":  {at(4, 7, 16, 30), at(4, 14, 16, 30)},
+		"*/20 10 * * *":  {at(4, 5, 10, 20), at(4, 5, 10, 40), at(4, 6, 10, 0)},
+		"15 10 * * *":    {at(4, 6, 10, 15)},
+		"0 9-17/4 * * *": {at(4, 5, 13, 0), at(4, 5, 17, 0), at(4, 6, 9, 0)},
+		"0 0 1 jan,jul *": {time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// The 1st of the month or any monday.
+		"0 8 1 * 1":  {at(4, 10, 8, 0), at(4, 17, 8, 0), at(4, 24, 8, 0), at(5, 1, 8, 0)},
+		"0 8 * * 7":  {at(4, 9, 8, 0)},
+		"0 0 29 2 *": {time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		"@monthly":   {at(5, 1, 0, 0)},
+		"@Weekly":    {at(4, 9, 0, 0)},
+	} {
+		r, err := ParseRule(spec)
+		if assert.Nil(t, err, spec) {
+			assert.Equal(t, expected, r.Occurrences(now, len(expected)), spec)
+			assert.Equal(t, spec, r.String())
+		}
+	}
+}
+
+func TestRuleNextDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	r, _ := ParseRule("30 2 * * *")
+	// 2:30am does not exist on March 12, 2017.
+	next := r.Next(time.Date(2017, 3, 11, 12, 0, 0, 0, loc))
+	assert.Equal(t, time.Date(2017, 3, 13, 2, 30, 0, 0, loc), next)
+
+	// Kolkata is 5:30 ahead of UTC.
+	loc, err = time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip(err)
+	}
+	r, _ = ParseRule("0 9 * * *")
+	next = r.Next(time.Date(2017, 3, 11, 8, 45, 0, 0, loc))
+	assert.Equal(t, time.Date(2017, 3, 11, 9, 0, 0, 0, loc), next)
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	for spec, message := range map[string]string{
+		"0 9 * *":      `rule "0 9 * *": expected 5 fields`,
+		"@fortnightly": `rule "@fortnightly": expected 5 fields`,
+		"60 9 * * *":   `rule "60 9 * * *": 60 is not between 0 and 59`,
+		"0 9 0 * *":    `rule "0 9 0 * *": 0 is not between 1 and 31`,
+		"0 9 * foo *":  `rule "0 9 * foo *": invalid value "foo"`,
+		"0 9 * * 5-1":  `rule "0 9 * * 5-1": invalid range "5-1"`,
+		"*/0 9 * * *":  `rule "*/0 9 * * *": invalid step in "*/0"`,
+		"0 0 30 feb *": `rule "0 0 30 feb *" never occurs`,
+		"0 0 31 4,6 *": `rule "0 0 31 4,6 *" never occurs`,
+	} {
+		_, err := ParseRule(spec)
+		assert.EqualError(t, err, message)
+	}
+}
@@ -0,0 +1,242 @@
+/*
+Package schedule works out when scheduled love should be sent. ParseTime reads
+the times people write, such as "next friday 9am" or "in 2 hours":
+
+	at, err := schedule.ParseTime("tomorrow 9:30am", time.Now())
+
+The love itself is kept by the store package until it is due.
+*/
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+The hour of the day love is scheduled for when only a day is given, as in
+"friday".
+*/
+const DefaultHour = 9
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+var units = map[string]time.Duration{
+	"minute": time.Minute, "min": time.Minute, "m": time.Minute,
+	"hour": time.Hour, "hr": time.Hour, "h": time.Hour,
+	"day": 24 * time.Hour, "d": 24 * time.Hour,
+	"week": 7 * 24 * time.Hour, "w": 7 * 24 * time.Hour,
+}
+
+// Layouts of absolute times, which are read in now's location.
+var layouts = []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02T15:04"}
+
+/*
+ParseTime returns the time described by s, which must be after now. Times are
+in now's location. Case is ignored. The accepted forms are:
+
+	2017-04-07 16:30           an absolute time (or RFC 3339)
+	in 90m, in 2 hours         a duration from now, in minutes, hours, days or
+	                           weeks, or as accepted by time.ParseDuration
+	[day] [at] [time]          a day, a time of day, or both
+
+A day is today, tomorrow, a date such as 2017-04-07, or a weekday such as
+friday (or fri), which is the next friday, or today if it is friday and the
+time has not passed. "next friday" is never today. A time of day is 9am, 9:30pm,
+17:00, noon or midnight. With only a time, the day is today if the time has not
+passed, or tomorrow. With only a day, the time is DefaultHour o'clock.
+*/
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	t, err := parseTime(strings.TrimSpace(s), now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot understand the time %q", s)
+	}
+	if !t.After(now) {
+		return time.Time{}, fmt.Errorf("%s is in the past", t.Format("Mon Jan 2 15:04"))
+	}
+	return t, nil
+}
+
+func parseTime(s string, now time.Time) (time.Time, error) {
+	loc := now.Location()
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	words := strings.Fields(strings.ReplaceAll(strings.ToLower(s), ",", " "))
+	if len(words) == 0 {
+		return time.Time{}, errInvalid
+	}
+	if words[0] == "in" {
+		d, err := parseDuration(words[1:])
+		return now.Add(d), err
+	}
+
+	var day time.Time
+	var next, haveDay, haveTime, weekly bool
+	hour, minute := DefaultHour, 0
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		// Allow "9 am" as well as "9am".
+		if i+1 < len(words) && (words[i+1] == "am" || words[i+1] == "pm") {
+			word += words[i+1]
+			i++
+		}
+		weekday, isWeekday := weekdays[word]
+		switch {
+		case word == "at":
+		case word == "next" && !haveDay && !next:
+			next = true
+		case isWeekday && !haveDay:
+			day = weekdayAfter(now, weekday, next)
+			haveDay, weekly = true, !next
+		case next && !haveDay:
+			return time.Time{}, errInvalid
+		case (word == "today" || word == "tomorrow") && !haveDay:
+			day = now
+			if word == "tomorrow" {
+				day = now.AddDate(0, 0, 1)
+			}
+			haveDay = true
+		case !haveTime && parseClock(word, &hour, &minute):
+			haveTime = true
+		default:
+			date, err := time.ParseInLocation("2006-01-02", word, loc)
+			if err != nil || haveDay {
+				return time.Time{}, errInvalid
+			}
+			day, haveDay = date, true
+		}
+	}
+	if next && !haveDay {
+		return time.Time{}, errInvalid
+	}
+	if !haveDay {
+		day = now
+	}
+	year, month, date := day.Date()
+	t := time.Date(year, month, date, hour, minute, 0, 0, loc)
+	switch {
+	case !haveDay && !t.After(now):
+		t = t.AddDate(0, 0, 1)
+	case weekly && !t.After(now):
+		// "friday 9am" on a friday after 9am is next week.
+		t = t.AddDate(0, 0, 7)
+	}
+	return t, nil
+}
+
+var errInvalid = errors.New("invalid time")
+
+/*
+Return the next day which is a weekday: today or later, or with next, after
+today.
+*/
+func weekdayAfter(now time.Time, weekday time.Weekday, next bool) time.Time {
+	days := (int(weekday) - int(now.Weekday()) + 7) % 7
+	if days == 0 && next {
+		days = 7
+	}
+	return now.AddDate(0, 0, days)
+}
+
+/*
+Parse a time of day, such as 9am, 9:30pm, 17:00, noon or midnight.
+*/
+func parseClock(word string, hour, minute *int) bool {
+	switch word {
+	case "noon":
+		*hour, *minute = 12, 0
+		return true
+	case "midnight":
+		*hour, *minute = 0, 0
+		return true
+	}
+	suffix := ""
+	if strings.HasSuffix(word, "am") || strings.HasSuffix(word, "pm") {
+		suffix = word[len(word)-2:]
+		word = word[:len(word)-2]
+	}
+	h, m := word, "0"
+	if i := strings.Index(word, ":"); i >= 0 {
+		h, m = word[:i], word[i+1:]
+		if len(m) != 2 {
+			return false
+		}
+	} else if suffix == "" {
+		// A bare number is not a time.
+		return false
+	}
+	hh, err := strconv.Atoi(h)
+	if err != nil || len(h) > 2 {
+		return false
+	}
+	mm, err := strconv.Atoi(m)
+	if err != nil || mm < 0 || mm > 59 {
+		return false
+	}
+	switch {
+	case suffix == "" && (hh < 0 || hh > 23):
+		return false
+	case suffix != "" && (hh < 1 || hh > 12):
+		return false
+	case suffix == "am" && hh == 12:
+		hh = 0
+	case suffix == "pm" && hh != 12:
+		hh += 12
+	}
+	*hour, *minute = hh, mm
+	return true
+}
+
+/*
+Parse the duration following "in": a number and a unit, such as "2 hours" or
+"2h", or anything accepted by time.ParseDuration.
+*/
+func parseDuration(words []string) (time.Duration, error) {
+	if len(words) == 1 {
+		if d, err := time.ParseDuration(words[0]); err == nil && d > 0 {
+			return d, nil
+		}
+	}
+	var number, unit string
+	switch len(words) {
+	case 1:
+		i := strings.IndexFunc(words[0], func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return 0, errInvalid
+		}
+		number, unit = words[0][:i], words[0][i:]
+	case 2:
+		number, unit = words[0], words[1]
+	default:
+		return 0, errInvalid
+	}
+	n, err := strconv.Atoi(number)
+	if number == "a" || number == "an" {
+		n, err = 1, nil
+	}
+	if err != nil || n <= 0 {
+		return 0, errInvalid
+	}
+	size, ok := units[strings.TrimSuffix(unit, "s")]
+	if !ok {
+		size, ok = units[unit]
+	}
+	if !ok {
+		return 0, errInvalid
+	}
+	return time.Duration(n) * size, nil
+}
@@ -0,0 +1,63 @@
+package schedule
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Wednesday, April 5, 2017, at 10:15.
+var now = time.Date(2017, 4, 5, 10, 15, 0, 0, time.UTC)
+
+func at(month time.Month, day, hour, minute int) time.Time {
+	return time.Date(2017, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParseTime(t *testing.T) {
+	for s, expected := range map[string]time.Time{
+		"2017-04-07 16:30":          at(4, 7, 16, 30),
+		"2017-04-07T16:30":          at(4, 7, 16, 30),
+		"2017-04-07T16:30:00+02:00": at(4, 7, 14, 30),
+		"2017-04-07":                at(4, 7, DefaultHour, 0),
+		"2017-04-07 at 5pm":         at(4, 7, 17, 0),
+		"in 90m":                    at(4, 5, 11, 45),
+		"in 2 hours":                at(4, 5, 12, 15),
+		"in 3d":                     at(4, 8, 10, 15),
+		"in a week":                 at(4, 12, 10, 15),
+		"In 1 Day":                  at(4, 6, 10, 15),
+		"tomorrow":                  at(4, 6, DefaultHour, 0),
+		"tomorrow 9:30am":           at(4, 6, 9, 30),
+		"today at noon":             at(4, 5, 12, 0),
+		"5pm":                       at(4, 5, 17, 0),
+		"9 am":                      at(4, 6, 9, 0),
+		"midnight":                  at(4, 6, 0, 0),
+		"17:00":                     at(4, 5, 17, 0),
+		"friday":                    at(4, 7, DefaultHour, 0),
+		"Fri, 4:30pm":               at(4, 7, 16, 30),
+		"next friday 9am":           at(4, 7, 9, 0),
+		"at 11am wednesday":         at(4, 5, 11, 0),
+		"wednesday 9am":             at(4, 12, 9, 0),
+		"next wednesday":            at(4, 12, DefaultHour, 0),
+		"monday 12am":               at(4, 10, 0, 0),
+	} {
+		actual, err := ParseTime(s, now)
+		if assert.Nil(t, err, s) {
+			assert.Equal(t, expected, actual, s)
+		}
+	}
+}
+
+func TestParseTimeErrors(t *testing.T) {
+	for _, s := range []string{
+		"", "soon", "next", "next week", "in", "in 0 days", "in 2 fortnights",
+		"friday friday", "tomorrow friday", "9", "13pm", "25:00", "9:5", "9:60am",
+		"2017-04-31",
+	} {
+		_, err := ParseTime(s, now)
+		assert.EqualError(t, err, `cannot understand the time "`+s+`"`)
+	}
+	for _, s := range []string{"today 9am", "2017-04-01", "2017-04-05 10:15"} {
+		_, err := ParseTime(s, now)
+		assert.Contains(t, err.Error(), "is in the past", s)
+	}
+}
@@ -0,0 +1,104 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	slackapi "github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+	"net/url"
+)
+
+/*
+A Bot connects to Slack in Socket Mode, so that it needs no public URL. It
+answers slash commands like Handler, and posts love to its Wall as it arrives.
+
+The Slack app must have Socket Mode enabled, and the Socket client must be
+created with its app-level token (with the connections:write scope):
+
+	api := slack.New(botToken, slack.OptionAppLevelToken(appToken))
+	bot := &Bot{
+		Handler: &Handler{Service: client, Users: users},
+		Wall:    &Wall{Client: api, Channel: channel, Users: users},
+		Socket:  socketmode.New(api),
+	}
+*/
+type Bot struct {
+	// Answers slash commands. Its SigningSecret is not used, since the
+	// connection is authenticated by the app-level token.
+	Handler *Handler
+	// Posts love, if not nil.
+	Wall   *Wall
+	Socket *socketmode.Client
+	// Called with errors which do not stop the bot, if not nil.
+	OnError func(err error)
+}
+
+/*
+Run the bot until the context is done or the connection to Slack fails. Each
+love received from loves is posted to the Wall. Failures to connect are retried,
+unless the app-level token is invalid.
+*/
+func (b *Bot) Run(ctx context.Context, loves <-chan love.Love) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- b.Socket.RunContext(ctx)
+	}()
+	for {
+		select {
+		case evt := <-b.Socket.Events:
+			if e, ok := evt.Data.(*slackapi.ConnectionErrorEvent); ok {
+				b.error(fmt.Errorf("connecting to Slack (attempt %d): %s", e.Attempt,
+					e.ErrorObj))
+			}
+			if evt.Request == nil {
+				continue
+			}
+			if payload, ok := b.handle(evt); ok {
+				b.Socket.Ack(*evt.Request, payload)
+			} else {
+				b.Socket.Ack(*evt.Request)
+			}
+		case l, ok := <-loves:
+			if !ok {
+				loves = nil
+			} else if b.Wall != nil {
+				if err := b.Wall.Post(l); err != nil {
+					b.error(err)
+				}
+			}
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+/*
+Handle an event from Slack, returning the payload to acknowledge it with, if
+any.
+*/
+func (b *Bot) handle(evt socketmode.Event) (interface{}, bool) {
+	if evt.Type != socketmode.EventTypeSlashCommand || b.Handler == nil {
+		return nil, false
+	}
+	cmd, ok := evt.Data.(slackapi.SlashCommand)
+	if !ok {
+		return nil, false
+	}
+	return b.Handler.command(url.Values{
+		"command":   {cmd.Command},
+		"user_id":   {cmd.UserID},
+		"user_name": {cmd.UserName},
+		"text":      {cmd.Text},
+	}), true
+}
+
+func (b *Bot) error(err error) {
+	if b.OnError != nil {
+		b.OnError(err)
+	}
+}
@@ -0,0 +1,38 @@
+package slack
+
+import (
+	slackapi "github.com/slack-go/slack"
+	"github.com/slack-go/slack/socketmode"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBotSlashCommand(t *testing.T) {
+	handler, server := newTestHandler()
+	defer server.Close()
+	bot := &Bot{Handler: handler}
+	evt := socketmode.Event{
+		Type: socketmode.EventTypeSlashCommand,
+		Data: slackapi.SlashCommand{
+			Command:  "/love",
+			UserID:   "U1",
+			UserName: "hammy.slack",
+			Text:     "<@U2|darwin.slack> thanks!",
+		},
+		Request: &socketmode.Request{},
+	}
+	payload, ok := bot.handle(evt)
+	assert.True(t, ok)
+	assert.Equal(t, &response{"in_channel", "hammy sent love to darwin: thanks!"}, payload)
+	assert.Len(t, server.Loves(), 1)
+}
+
+func TestBotIgnoresOtherEvents(t *testing.T) {
+	handler, server := newTestHandler()
+	defer server.Close()
+	bot := &Bot{Handler: handler}
+	_, ok := bot.handle(socketmode.Event{Type: socketmode.EventTypeEventsAPI})
+	assert.False(t, ok)
+	_, ok = (&Bot{}).handle(socketmode.Event{Type: socketmode.EventTypeSlashCommand})
+	assert.False(t, ok)
+}
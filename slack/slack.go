@@ -0,0 +1,179 @@
+/*
+Package slack connects Slack to Yelp Love. Its Handler implements a Slack slash
+command, so that users can send love from Slack by typing:
+
+	/love @darwin @jeremy great job fixing the site!
+
+To use it, create a Slack app with a slash command whose request URL points to
+the Handler, and give the Handler the app's signing secret. The user sending
+the command is the sender of the love. Slack users are mapped to love usernames
+with the Users map. Recipients without an entry are assumed to have the same
+Slack and love username, but since users can change their Slack username, the
+sender must have an entry unless TrustUserNames is set.
+
+A Wall posts love to a Slack channel as it is received, and a Bot answers slash
+commands and runs a Wall over a Socket Mode connection, without a public URL.
+*/
+package slack
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Requests older than this are rejected, to prevent replay attacks.
+const maxRequestAge = 5 * time.Minute
+
+// The largest request body accepted.
+const maxRequestBytes = 64 * 1024
+
+/*
+A Handler serves Slack slash command requests by sending love.
+*/
+type Handler struct {
+	// Sends the love.
+	Service love.LoveService
+	// The signing secret of the Slack app, used to verify requests.
+	SigningSecret string
+	// Maps Slack user IDs (such as U012AB3CD) to love usernames.
+	Users map[string]string
+	// Lets senders who are not in Users send love as their Slack username,
+	// which anyone can change to impersonate someone else.
+	TrustUserNames bool
+
+	now func() time.Time
+}
+
+/*
+A Slack message in reply to a command.
+*/
+type response struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err := h.verify(r.Header, body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	reply(w, h.command(values))
+}
+
+/*
+Verify the signature Slack sends with each request. See
+https://api.slack.com/authentication/verifying-requests-from-slack
+*/
+func (h *Handler) verify(header http.Header, body []byte) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing request timestamp")
+	}
+	now := time.Now
+	if h.now != nil {
+		now = h.now
+	}
+	age := now().Sub(time.Unix(seconds, 0))
+	if age > maxRequestAge || age < -maxRequestAge {
+		return fmt.Errorf("request timestamp too old")
+	}
+	mac := hmac.New(sha256.New, []byte(h.SigningSecret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+/*
+Matches a user mention in command text. Slack escapes mentions as <@U123|name>
+or <@U123>, and leaves them as @name when escaping is disabled.
+*/
+var mention = regexp.MustCompile(`^(?:<@([A-Z0-9]+)(?:\|([^>]*))?>|@([\w.-]+))$`)
+
+/*
+Run a command, returning the reply to show the user.
+*/
+func (h *Handler) command(values url.Values) *response {
+	sender, ok := h.Users[values.Get("user_id")]
+	if !ok {
+		if !h.TrustUserNames {
+			return &response{"ephemeral", fmt.Sprintf(
+				"Your Slack user %s has no love username; ask an admin to add it.",
+				values.Get("user_id"))}
+		}
+		sender = values.Get("user_name")
+	}
+	words := strings.Fields(values.Get("text"))
+	var recipients []string
+	for len(words) > 0 {
+		match := mention.FindStringSubmatch(words[0])
+		if match == nil {
+			break
+		}
+		if match[1] != "" {
+			recipients = append(recipients, h.username(match[1], match[2]))
+		} else {
+			recipients = append(recipients, match[3])
+		}
+		words = words[1:]
+	}
+	if len(recipients) == 0 || len(words) == 0 {
+		return &response{"ephemeral", fmt.Sprintf(
+			"Usage: %s @user [@user...] message", values.Get("command"))}
+	}
+	message := strings.Join(words, " ")
+	to := strings.Join(recipients, ",")
+	if err := h.Service.SendLove(sender, to, message); err != nil {
+		return &response{"ephemeral", fmt.Sprintf("Failed to send love: %s", err)}
+	}
+	return &response{"in_channel", fmt.Sprintf("%s sent love to %s: %s", sender,
+		strings.Join(recipients, ", "), message)}
+}
+
+/*
+Return the love username of a Slack user, falling back to their Slack username.
+This is only safe for recipients, since the name comes from the request.
+*/
+func (h *Handler) username(id, name string) string {
+	if username, ok := h.Users[id]; ok {
+		return username
+	}
+	return name
+}
+
+func reply(w http.ResponseWriter, r *response) {
+	var buffer bytes.Buffer
+	json.NewEncoder(&buffer).Encode(r)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buffer.Bytes())
+}
@@ -0,0 +1,145 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func newTestHandler() (*Handler, *lovetest.Server) {
+	server := lovetest.NewServer("secret")
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	server.AddUser("jeremy", "Jeremy Jones")
+	handler := &Handler{
+		Service:       server.Client(),
+		SigningSecret: testSecret,
+		Users:         map[string]string{"U1": "hammy", "U2": "darwin"},
+	}
+	return handler, server
+}
+
+func signedRequest(secret string, timestamp time.Time, values url.Values) *http.Request {
+	body := values.Encode()
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", ts, body)
+	req := httptest.NewRequest("POST", "/slack", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func command(text string) url.Values {
+	return url.Values{
+		"command":   {"/love"},
+		"user_id":   {"U1"},
+		"user_name": {"hammy.slack"},
+		"text":      {text},
+	}
+}
+
+func serve(handler *Handler, req *http.Request) (*httptest.ResponseRecorder, *response) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	var resp response
+	json.Unmarshal(recorder.Body.Bytes(), &resp)
+	return recorder, &resp
+}
+
+func TestSendLove(t *testing.T) {
+	handler, server := newTestHandler()
+	defer server.Close()
+
+	req := signedRequest(testSecret, time.Now(),
+		command("<@U2|darwin> @jeremy great job!"))
+	recorder, resp := serve(handler, req)
+	assert.Equal(t, recorder.Code, 200)
+	assert.Equal(t, resp.ResponseType, "in_channel")
+	assert.Equal(t, resp.Text, "hammy sent love to darwin, jeremy: great job!")
+
+	loves := server.Loves()
+	assert.Equal(t, len(loves), 2)
+	assert.Equal(t, loves[0].Sender, "hammy")
+	assert.Equal(t, loves[0].Recipient, "darwin")
+	assert.Equal(t, loves[1].Recipient, "jeremy")
+	assert.Equal(t, loves[0].Message, "great job!")
+}
+
+func TestUsage(t *testing.T) {
+	handler, server := newTestHandler()
+	defer server.Close()
+
+	for _, text := range []string{"", "great job", "@darwin"} {
+		_, resp := serve(handler, signedRequest(testSecret, time.Now(), command(text)))
+		assert.Equal(t, resp.ResponseType, "ephemeral")
+		assert.True(t, strings.HasPrefix(resp.Text, "Usage: /love"))
+	}
+	assert.Equal(t, len(server.Loves()), 0)
+}
+
+func TestSendFailure(t *testing.T) {
+	handler, server := newTestHandler()
+	defer server.Close()
+
+	_, resp := serve(handler, signedRequest(testSecret, time.Now(),
+		command("@nobody thanks")))
+	assert.Equal(t, resp.ResponseType, "ephemeral")
+	assert.True(t, strings.HasPrefix(resp.Text, "Failed to send love"))
+}
+
+func TestUnknownSender(t *testing.T) {
+	handler, server := newTestHandler()
+	defer server.Close()
+
+	// Anyone can rename themselves hammy in Slack.
+	values := command("@darwin thanks")
+	values.Set("user_id", "U9")
+	values.Set("user_name", "hammy")
+	_, resp := serve(handler, signedRequest(testSecret, time.Now(), values))
+	assert.Equal(t, resp.ResponseType, "ephemeral")
+	assert.Equal(t, resp.Text, "Your Slack user U9 has no love username; ask an admin to add it.")
+	assert.Equal(t, len(server.Loves()), 0)
+
+	handler.TrustUserNames = true
+	_, resp = serve(handler, signedRequest(testSecret, time.Now(), values))
+	assert.Equal(t, resp.ResponseType, "in_channel")
+	assert.Equal(t, resp.Text, "hammy sent love to darwin: thanks")
+	loves := server.Loves()
+	assert.Equal(t, len(loves), 1)
+	assert.Equal(t, loves[0].Sender, "hammy")
+}
+
+func TestVerification(t *testing.T) {
+	handler, server := newTestHandler()
+	defer server.Close()
+
+	recorder, _ := serve(handler, signedRequest("wrong", time.Now(),
+		command("@darwin thanks")))
+	assert.Equal(t, recorder.Code, http.StatusUnauthorized)
+
+	recorder, _ = serve(handler, signedRequest(testSecret,
+		time.Now().Add(-10*time.Minute), command("@darwin thanks")))
+	assert.Equal(t, recorder.Code, http.StatusUnauthorized)
+
+	req := signedRequest(testSecret, time.Now(), command("@darwin thanks"))
+	req.Header.Del("X-Slack-Request-Timestamp")
+	recorder, _ = serve(handler, req)
+	assert.Equal(t, recorder.Code, http.StatusUnauthorized)
+
+	assert.Equal(t, len(server.Loves()), 0)
+}
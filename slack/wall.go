@@ -0,0 +1,100 @@
+package slack
+
+import (
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	slackapi "github.com/slack-go/slack"
+	"sort"
+	"strings"
+)
+
+/*
+A Poster posts messages to Slack. It is implemented by *slack.Client from
+github.com/slack-go/slack, created with the bot token of a Slack app which has
+the chat:write scope.
+*/
+type Poster interface {
+	PostMessage(channelID string, options ...slackapi.MsgOption) (string, string, error)
+}
+
+/*
+A Wall posts love to a Slack channel, so that a team can see the love its
+members receive. Senders and recipients with an entry in Users are mentioned;
+others are shown by their love username.
+*/
+type Wall struct {
+	Client Poster
+	// The ID of the channel (such as C012AB3CD), which the app must be in.
+	Channel string
+	// Maps Slack user IDs (such as U012AB3CD) to love usernames, as in Handler.
+	Users map[string]string
+
+	ids map[string]string
+}
+
+/*
+Post a love to the channel.
+*/
+func (w *Wall) Post(l love.Love) error {
+	text, blocks := w.Message(l)
+	_, _, err := w.Client.PostMessage(w.Channel, slackapi.MsgOptionText(text, false),
+		slackapi.MsgOptionBlocks(blocks...))
+	if err != nil {
+		return fmt.Errorf("posting love to %s: %s", w.Channel, err)
+	}
+	return nil
+}
+
+/*
+Return the message posted for a love: plain text, used in notifications, and the
+blocks shown in the channel.
+*/
+func (w *Wall) Message(l love.Love) (string, []slackapi.Block) {
+	text := fmt.Sprintf("%s sent love to %s: %s", l.Sender, l.Recipient, l.Message)
+	summary := fmt.Sprintf(":heart: %s sent love to %s", w.mention(l.Sender),
+		w.mention(l.Recipient))
+	quote := "> " + strings.Replace(escape(l.Message), "\n", "\n> ", -1)
+	section := slackapi.NewSectionBlock(
+		slackapi.NewTextBlockObject(slackapi.MarkdownType, summary+"\n"+quote, false, false),
+		nil, nil)
+	blocks := []slackapi.Block{section}
+	if !l.Timestamp.IsZero() {
+		date := fmt.Sprintf("<!date^%d^{date_short_pretty} at {time}|%s>",
+			l.Timestamp.Unix(), l.Timestamp.UTC().Format("2006-01-02 15:04 UTC"))
+		blocks = append(blocks, slackapi.NewContextBlock("",
+			slackapi.NewTextBlockObject(slackapi.MarkdownType, date, false, false)))
+	}
+	return text, blocks
+}
+
+/*
+Return a mention of the Slack user with a love username, or the username in bold
+if the user is unknown.
+*/
+func (w *Wall) mention(username string) string {
+	if w.ids == nil {
+		w.ids = make(map[string]string)
+		// Sort so that a username mapped from several IDs always gets the same one.
+		var ids []string
+		for id := range w.Users {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			if _, ok := w.ids[w.Users[id]]; !ok {
+				w.ids[w.Users[id]] = id
+			}
+		}
+	}
+	if id, ok := w.ids[username]; ok {
+		return fmt.Sprintf("<@%s>", id)
+	}
+	return "*" + escape(username) + "*"
+}
+
+/*
+Escape the characters Slack treats as control sequences in message text.
+*/
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
@@ -0,0 +1,72 @@
+package slack
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/hacsoc/golove/love"
+	slackapi "github.com/slack-go/slack"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type fakePoster struct {
+	channel string
+	values  url.Values
+	err     error
+}
+
+func (p *fakePoster) PostMessage(channel string, options ...slackapi.MsgOption) (string, string, error) {
+	p.channel = channel
+	_, p.values, _ = slackapi.UnsafeApplyMsgOptions("token", channel, "", options...)
+	return channel, "1234.5678", p.err
+}
+
+func testLove() love.Love {
+	return love.Love{
+		Sender:    "hammy",
+		Recipient: "jeremy",
+		Message:   "fixed <the> build & more",
+		Timestamp: time.Date(2017, 4, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWallPost(t *testing.T) {
+	poster := &fakePoster{}
+	wall := &Wall{Client: poster, Channel: "C1", Users: map[string]string{"U1": "hammy"}}
+	assert.Nil(t, wall.Post(testLove()))
+	assert.Equal(t, "C1", poster.channel)
+	assert.Equal(t, "hammy sent love to jeremy: fixed <the> build & more",
+		poster.values.Get("text"))
+	var blocks []struct {
+		Text     struct{ Text string }
+		Elements []struct{ Text string }
+	}
+	assert.Nil(t, json.Unmarshal([]byte(poster.values.Get("blocks")), &blocks))
+	assert.Len(t, blocks, 2)
+	assert.Equal(t, ":heart: <@U1> sent love to *jeremy*\n> fixed &lt;the&gt; build &amp; more",
+		blocks[0].Text.Text)
+	assert.Equal(t, "<!date^1491048000^{date_short_pretty} at {time}|2017-04-01 12:00 UTC>",
+		blocks[1].Elements[0].Text)
+}
+
+func TestWallPostError(t *testing.T) {
+	poster := &fakePoster{err: errors.New("channel_not_found")}
+	wall := &Wall{Client: poster, Channel: "C1"}
+	assert.EqualError(t, wall.Post(testLove()), "posting love to C1: channel_not_found")
+}
+
+func TestWallMessage(t *testing.T) {
+	wall := &Wall{Users: map[string]string{"U2": "darwin", "U1": "darwin", "U3": "jeremy"}}
+	l := testLove()
+	l.Sender = "darwin"
+	l.Message = "line one\nline two"
+	l.Timestamp = time.Time{}
+	text, blocks := wall.Message(l)
+	assert.Equal(t, "darwin sent love to jeremy: line one\nline two", text)
+	assert.Len(t, blocks, 1)
+	section := blocks[0].(*slackapi.SectionBlock)
+	assert.Equal(t, ":heart: <@U1> sent love to <@U3>\n> line one\n> line two",
+		section.Text.Text)
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	bolt "go.etcd.io/bbolt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+The time over which the weight of a use of a contact halves, so that recent
+recipients rank above those who were sent more love long ago.
+*/
+const ContactHalfLife = 14 * 24 * time.Hour
+
+/*
+A Contact is someone a sender has sent love to, with how often and how recently
+they did, for suggesting recipients.
+*/
+type Contact struct {
+	Sender   string
+	Username string
+	// The number of times love was sent to the contact.
+	Count int
+	Last  time.Time
+	// The weight of the uses of the contact, as of Last. Each use adds one,
+	// and the weight halves every ContactHalfLife.
+	Weight float64
+}
+
+/*
+Return the weight of the contact at a time: its Weight, decayed since Last.
+*/
+func (c Contact) Score(now time.Time) float64 {
+	age := now.Sub(c.Last)
+	if age < 0 {
+		age = 0
+	}
+	return c.Weight * math.Pow(0.5, float64(age)/float64(ContactHalfLife))
+}
+
+func contactKey(sender, username string) []byte {
+	return []byte(sender + "\x00" + username)
+}
+
+/*
+Record that a sender has sent love to some recipients now. Each distinct
+recipient is recorded once.
+*/
+func (s *Store) RecordContacts(sender string, to []string) error {
+	now := s.now()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(contactBucket)
+		seen := make(map[string]bool, len(to))
+		for _, username := range to {
+			username = strings.TrimSpace(username)
+			if username == "" || username == sender || seen[username] {
+				continue
+			}
+			seen[username] = true
+			c := Contact{Sender: sender, Username: username}
+			key := contactKey(sender, username)
+			if value := bucket.Get(key); value != nil {
+				if err := json.Unmarshal(value, &c); err != nil {
+					return err
+				}
+			}
+			c.Weight = c.Score(now) + 1
+			c.Count++
+			c.Last = now
+			value, err := json.Marshal(c)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+/*
+Return the contacts of a sender whose usernames start with prefix, ignoring
+case, ranked by their Score, highest first.
+*/
+func (s *Store) Contacts(sender, prefix string) ([]Contact, error) {
+	var contacts []Contact
+	prefix = strings.ToLower(prefix)
+	start := contactKey(sender, "")
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(contactBucket).Cursor()
+		for key, value := cursor.Seek(start); bytes.HasPrefix(key, start); key, value = cursor.Next() {
+			var c Contact
+			if err := json.Unmarshal(value, &c); err != nil {
+				return err
+			}
+			if strings.HasPrefix(strings.ToLower(c.Username), prefix) {
+				contacts = append(contacts, c)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	sort.SliceStable(contacts, func(i, j int) bool {
+		return contacts[i].Score(now) > contacts[j].Score(now)
+	})
+	return contacts, nil
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestContacts(t *testing.T) {
+	s := openTestStore(t)
+	defer s.Close()
+	now := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	// Darwin was sent love three times a while ago, and jeremy once today.
+	assert.Nil(t, s.RecordContacts("hammy", []string{"darwin", "darwin", "hammy"}))
+	assert.Nil(t, s.RecordContacts("hammy", []string{"darwin", "dan"}))
+	assert.Nil(t, s.RecordContacts("hammy", []string{"darwin"}))
+	assert.Nil(t, s.RecordContacts("dan", []string{"jeremy"}))
+	now = now.Add(2 * ContactHalfLife)
+	assert.Nil(t, s.RecordContacts("hammy", []string{"jeremy", " "}))
+
+	contacts, err := s.Contacts("hammy", "")
+	assert.Nil(t, err)
+	assert.Len(t, contacts, 3)
+	assert.Equal(t, contacts[0].Username, "jeremy")
+	assert.Equal(t, contacts[1].Username, "darwin")
+	assert.Equal(t, contacts[1].Count, 3)
+	assert.InDelta(t, contacts[1].Score(now), 0.75, 0.001)
+	assert.Equal(t, contacts[2].Username, "dan")
+
+	now = now.Add(2 * ContactHalfLife)
+	contacts, err = s.Contacts("hammy", "D")
+	assert.Nil(t, err)
+	assert.Len(t, contacts, 2)
+	assert.Equal(t, contacts[0].Username, "darwin")
+
+	contacts, err = s.Contacts("ham", "")
+	assert.Nil(t, err)
+	assert.Len(t, contacts, 0)
+}
@@ -0,0 +1,179 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"github.com/hacsoc/golove/love"
+	bolt "go.etcd.io/bbolt"
+	"time"
+)
+
+const (
+	// The wait before retrying a love after its first failure. It doubles
+	// after each further failure, up to maxRetryDelay.
+	minRetryDelay = time.Minute
+	maxRetryDelay = time.Hour
+)
+
+/*
+A Pending love is waiting in the queue to be sent. Recipient may hold several
+comma separated recipients, as with SendLove.
+*/
+type Pending struct {
+	ID        uint64
+	Sender    string
+	Recipient string
+	Message   string
+	Queued    time.Time
+	// The idempotency key the love is sent with, so that it is not sent
+	// twice if an attempt which failed reached the server anyway.
+	Key string `json:",omitempty"`
+	// The number of failed attempts to send the love, and the last error.
+	Attempts  int
+	LastError string
+	// The love is not retried by Flush before this time.
+	NextAttempt time.Time
+}
+
+/*
+The result of a Flush. Sent love has been removed from the queue, as has Failed
+love, which failed permanently (see love.IsTemporary) and will not be retried.
+Deferred is the number of love which remain in the queue.
+*/
+type FlushResult struct {
+	Sent     []Pending
+	Failed   []Pending
+	Deferred int
+}
+
+func queueKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+/*
+Add love to the queue, to be sent later by Flush. This is meant for love which
+could not be sent because the network or API was unavailable.
+*/
+func (s *Store) Enqueue(sender, to, message string) (*Pending, error) {
+	p := &Pending{
+		Sender:    sender,
+		Recipient: to,
+		Message:   message,
+		Queued:    s.now(),
+		Key:       love.NewIdempotencyKey(),
+	}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(queueBucket)
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		p.ID = id
+		return putPending(bucket, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func putPending(bucket *bolt.Bucket, p *Pending) error {
+	value, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(queueKey(p.ID), value)
+}
+
+/*
+Return the queued love, in the order it was queued.
+*/
+func (s *Store) Pending() ([]Pending, error) {
+	pending := []Pending{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(queueBucket).ForEach(func(key, value []byte) error {
+			var p Pending
+			if err := json.Unmarshal(value, &p); err != nil {
+				return err
+			}
+			pending = append(pending, p)
+			return nil
+		})
+	})
+	return pending, err
+}
+
+/*
+Try to send the queued love, in the order it was queued. Love which fails
+temporarily stays in the queue, and is not tried again until its NextAttempt,
+which backs off exponentially with each failure. With force, every love is
+tried, regardless of NextAttempt.
+
+If service is a *love.Client whose SendRecords is the store, love is sent with
+its Key, so that love which was received by the server even though sending it
+failed, such as by timing out, is not sent again. Love queued with a record of
+an attempt which failed (see PutSendRecord) is looked for in the API first.
+
+The error is only for failures of the database; failures to send are recorded
+in the result and the queue.
+*/
+func (s *Store) Flush(service love.LoveService, force bool) (*FlushResult, error) {
+	pending, err := s.Pending()
+	if err != nil {
+		return nil, err
+	}
+	result := &FlushResult{}
+	for i := range pending {
+		p := &pending[i]
+		if !force && s.now().Before(p.NextAttempt) {
+			result.Deferred++
+			continue
+		}
+		keep := false
+		err := sendPending(service, p, p.Key)
+		if err != nil {
+			p.Attempts++
+			p.LastError = err.Error()
+		}
+		switch {
+		case err == nil:
+			result.Sent = append(result.Sent, *p)
+		case love.IsTemporary(err):
+			p.NextAttempt = s.now().Add(retryDelay(p.Attempts))
+			result.Deferred++
+			keep = true
+		default:
+			result.Failed = append(result.Failed, *p)
+		}
+		err = s.db.Update(func(tx *bolt.Tx) error {
+			bucket := tx.Bucket(queueBucket)
+			if keep {
+				return putPending(bucket, p)
+			}
+			if err := deleteSendRecord(tx, p.Key); err != nil {
+				return err
+			}
+			return bucket.Delete(queueKey(p.ID))
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+/*
+Return the wait before retrying a love which has failed attempts times.
+*/
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
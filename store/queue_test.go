@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+/*
+A LoveService whose SendLove and SendLoveContext fail with err, when it is set.
+*/
+type failingService struct {
+	*love.Client
+	err error
+}
+
+func (f *failingService) SendLove(from, to, message string) error {
+	if f.err != nil {
+		return f.err
+	}
+	return f.Client.SendLove(from, to, message)
+}
+
+func (f *failingService) SendLoveContext(ctx context.Context, from, to, message string) error {
+	if f.err != nil {
+		return f.err
+	}
+	return f.Client.SendLoveContext(ctx, from, to, message)
+}
+
+func TestEnqueue(t *testing.T) {
+	s := openTestStore(t)
+	defer s.Close()
+
+	first, err := s.Enqueue("hammy", "darwin", "thanks")
+	assert.Nil(t, err)
+	second, err := s.Enqueue("hammy", "darwin,jeremy", "great job")
+	assert.Nil(t, err)
+	assert.True(t, second.ID > first.ID)
+
+	pending, err := s.Pending()
+	assert.Nil(t, err)
+	assert.Equal(t, len(pending), 2)
+	assert.Equal(t, pending[0].Message, "thanks")
+	assert.Equal(t, pending[1].Recipient, "darwin,jeremy")
+}
+
+func TestFlush(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	s := openTestStore(t)
+	defer s.Close()
+	now := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	s.Enqueue("hammy", "darwin", "thanks")
+	s.Enqueue("hammy", "hammy", "self love")
+
+	// The server is down, so both stay queued.
+	service := &failingService{server.Client(), &love.APIError{StatusCode: 503}}
+	result, err := s.Flush(service, false)
+	assert.Nil(t, err)
+	assert.Equal(t, result.Deferred, 2)
+	pending, _ := s.Pending()
+	assert.Equal(t, pending[0].Attempts, 1)
+	assert.Equal(t, pending[0].NextAttempt, now.Add(time.Minute))
+
+	// Nothing is retried before the next attempt is due.
+	service.err = nil
+	result, err = s.Flush(service, false)
+	assert.Nil(t, err)
+	assert.Equal(t, result.Deferred, 2)
+	assert.Equal(t, len(server.Loves()), 0)
+
+	// Self love fails permanently, and is dropped.
+	now = now.Add(time.Minute)
+	result, err = s.Flush(service, false)
+	assert.Nil(t, err)
+	assert.Equal(t, len(result.Sent), 1)
+	assert.Equal(t, result.Sent[0].Message, "thanks")
+	assert.Equal(t, len(result.Failed), 1)
+	assert.Equal(t, result.Failed[0].Message, "self love")
+	assert.Equal(t, result.Deferred, 0)
+	assert.Equal(t, len(server.Loves()), 1)
+	pending, _ = s.Pending()
+	assert.Equal(t, len(pending), 0)
+}
+
+func TestFlushForce(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	s := openTestStore(t)
+	defer s.Close()
+
+	s.Enqueue("hammy", "darwin", "thanks")
+	service := &failingService{server.Client(), &love.APIError{StatusCode: 500}}
+	s.Flush(service, false)
+	service.err = nil
+	result, err := s.Flush(service, true)
+	assert.Nil(t, err)
+	assert.Equal(t, len(result.Sent), 1)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, retryDelay(1), time.Minute)
+	assert.Equal(t, retryDelay(2), 2*time.Minute)
+	assert.Equal(t, retryDelay(4), 8*time.Minute)
+	assert.Equal(t, retryDelay(7), time.Hour)
+	assert.Equal(t, retryDelay(100), time.Hour)
+}
@@ -0,0 +1,284 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/schedule"
+	bolt "go.etcd.io/bbolt"
+	"sort"
+	"strings"
+	"time"
+)
+
+/*
+A Job is love scheduled to be sent at a later time. Its Pending fields record
+when it was scheduled (Queued) and its failed attempts, as for queued love.
+
+A recurring job has a Rule, in the syntax of schedule.ParseRule. Rather than
+being removed once it has been sent, or has failed permanently, it is scheduled
+again for the rule's next occurrence. If the scheduler was not running for
+several occurrences, the love is only sent once.
+*/
+type Job struct {
+	Pending
+	// The love is sent by SendDue at or after this time.
+	At   time.Time
+	Rule string `json:",omitempty"`
+}
+
+/*
+ErrNoJob is returned by Unschedule when there is no job with the given ID.
+*/
+var ErrNoJob = errors.New("store: no such scheduled love")
+
+/*
+The result of SendDue. Sent jobs have been removed from the schedule, as have
+Failed jobs, which failed permanently, unless they recur. Deferred is the number
+of due jobs which failed temporarily, and will be retried.
+*/
+type JobResult struct {
+	Sent     []Job
+	Failed   []Job
+	Deferred int
+}
+
+/*
+Schedule love to be sent at a time by SendDue.
+*/
+func (s *Store) Schedule(sender, to, message string, at time.Time) (*Job, error) {
+	return s.addJob(&Job{
+		Pending: Pending{Sender: sender, Recipient: to, Message: message},
+		At:      at,
+	})
+}
+
+/*
+Schedule love to be sent at each occurrence of a rule, starting with the next.
+*/
+func (s *Store) ScheduleRule(sender, to, message string, rule *schedule.Rule) (*Job, error) {
+	return s.addJob(&Job{
+		Pending: Pending{Sender: sender, Recipient: to, Message: message},
+		At:      rule.Next(s.now()),
+		Rule:    rule.String(),
+	})
+}
+
+func (s *Store) addJob(job *Job) (*Job, error) {
+	job.Queued = s.now()
+	job.Key = love.NewIdempotencyKey()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(scheduleBucket)
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		job.ID = id
+		return putJob(bucket, job)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func putJob(bucket *bolt.Bucket, job *Job) error {
+	value, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(queueKey(job.ID), value)
+}
+
+/*
+Return the scheduled jobs, soonest first.
+*/
+func (s *Store) Jobs() ([]Job, error) {
+	jobs := []Job{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(scheduleBucket).ForEach(func(key, value []byte) error {
+			var job Job
+			if err := json.Unmarshal(value, &job); err != nil {
+				return err
+			}
+			jobs = append(jobs, job)
+			return nil
+		})
+	})
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].At.Before(jobs[j].At) })
+	return jobs, err
+}
+
+/*
+Send the love of every job which is due. As with Flush, love which fails
+temporarily is retried after a delay which backs off exponentially, and love
+which fails for any other reason is dropped. Recurring jobs are scheduled again
+instead of being dropped, unless their rule never occurs again. Each occurrence
+is sent with its own idempotency key, as described for Flush.
+
+The error is only for failures of the database; failures to send are recorded
+in the result and the schedule.
+*/
+func (s *Store) SendDue(service love.LoveService) (*JobResult, error) {
+	jobs, err := s.Jobs()
+	if err != nil {
+		return nil, err
+	}
+	result := &JobResult{}
+	for i := range jobs {
+		job := &jobs[i]
+		now := s.now()
+		if now.Before(job.At) || now.Before(job.NextAttempt) {
+			continue
+		}
+		keep := false
+		key := job.sendKey()
+		err := sendPending(service, &job.Pending, key)
+		if err != nil {
+			job.Attempts++
+			job.LastError = err.Error()
+		}
+		switch {
+		case err == nil:
+			result.Sent = append(result.Sent, *job)
+		case love.IsTemporary(err):
+			job.NextAttempt = s.now().Add(retryDelay(job.Attempts))
+			result.Deferred++
+			keep = true
+		default:
+			result.Failed = append(result.Failed, *job)
+		}
+		if !keep && job.Rule != "" {
+			keep = s.recur(job, err == nil)
+		}
+		err = s.db.Update(func(tx *bolt.Tx) error {
+			bucket := tx.Bucket(scheduleBucket)
+			// The record of the occurrence is kept until it is sent.
+			if !keep || key != job.sendKey() {
+				if err := deleteSendRecord(tx, key); err != nil {
+					return err
+				}
+			}
+			if keep {
+				return putJob(bucket, job)
+			}
+			return bucket.Delete(queueKey(job.ID))
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+/*
+Return the idempotency key a job is sent with at its next occurrence, or "" if
+it has no key. Each occurrence of a recurring job is different love.
+*/
+func (j *Job) sendKey() string {
+	if j.Key == "" || j.Rule == "" {
+		return j.Key
+	}
+	return j.Key + "@" + j.At.UTC().Format(time.RFC3339)
+}
+
+/*
+Schedule a recurring job for the next occurrence of its rule after it was sent,
+or failed permanently, returning false if there is none.
+*/
+func (s *Store) recur(job *Job, sent bool) bool {
+	rule, err := schedule.ParseRule(job.Rule)
+	if err != nil {
+		return false
+	}
+	job.At = rule.Next(s.now())
+	job.NextAttempt = time.Time{}
+	if sent {
+		job.Attempts = 0
+		job.LastError = ""
+	}
+	return !job.At.IsZero()
+}
+
+/*
+Remove a job from the schedule, failing with ErrNoJob if there is none with the
+ID.
+*/
+func (s *Store) Unschedule(id uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(scheduleBucket)
+		value := bucket.Get(queueKey(id))
+		if value == nil {
+			return ErrNoJob
+		}
+		var job Job
+		if err := json.Unmarshal(value, &job); err != nil {
+			return err
+		}
+		if err := deleteSendRecord(tx, job.sendKey()); err != nil {
+			return err
+		}
+		return bucket.Delete(queueKey(id))
+	})
+}
+
+// How many occurrences of recurring jobs Conflicts compares.
+const maxConflictOccurrences = 400
+
+/*
+Return the scheduled jobs which conflict with a job: those from the same sender
+to any of the same recipients, which would be sent on the same day as it, in
+the location of its At. Recurring jobs are compared over their next 400
+occurrences, so a job every day conflicts with any other job to its recipients.
+The job itself need not be scheduled yet; if it is, it is ignored.
+*/
+func (s *Store) Conflicts(job Job) ([]Job, error) {
+	jobs, err := s.Jobs()
+	if err != nil {
+		return nil, err
+	}
+	loc := job.At.Location()
+	days := make(map[string]bool)
+	for _, t := range job.occurrences() {
+		days[t.In(loc).Format("2006-01-02")] = true
+	}
+	conflicts := []Job{}
+	for _, other := range jobs {
+		if (job.ID != 0 && other.ID == job.ID) || other.Sender != job.Sender ||
+			!shareRecipient(other.Recipient, job.Recipient) {
+			continue
+		}
+		for _, t := range other.occurrences() {
+			if days[t.In(loc).Format("2006-01-02")] {
+				conflicts = append(conflicts, other)
+				break
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+/*
+Return the times a job will be sent, up to maxConflictOccurrences.
+*/
+func (job Job) occurrences() []time.Time {
+	times := []time.Time{job.At}
+	if rule, err := schedule.ParseRule(job.Rule); err == nil {
+		times = append(times, rule.Occurrences(job.At, maxConflictOccurrences-1)...)
+	}
+	return times
+}
+
+/*
+Report whether two comma separated lists of recipients have one in common.
+*/
+func shareRecipient(a, b string) bool {
+	for _, x := range strings.Split(a, ",") {
+		for _, y := range strings.Split(b, ",") {
+			if strings.TrimSpace(x) == strings.TrimSpace(y) {
+				return true
+			}
+		}
+	}
+	return false
+}
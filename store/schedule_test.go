@@ -0,0 +1,189 @@
+package store
+
+import (
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/hacsoc/golove/schedule"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	s := openTestStore(t)
+	defer s.Close()
+	now := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	later, err := s.Schedule("hammy", "darwin", "happy birthday", now.Add(48*time.Hour))
+	assert.Nil(t, err)
+	sooner, err := s.Schedule("hammy", "darwin,jeremy", "sprint's over", now.Add(time.Hour))
+	assert.Nil(t, err)
+	assert.True(t, sooner.ID > later.ID)
+
+	jobs, err := s.Jobs()
+	assert.Nil(t, err)
+	assert.Equal(t, len(jobs), 2)
+	assert.Equal(t, jobs[0].Message, "sprint's over")
+	assert.Equal(t, jobs[0].At, now.Add(time.Hour))
+	assert.Equal(t, jobs[0].Queued, now)
+	assert.Equal(t, jobs[1].Recipient, "darwin")
+}
+
+func TestSendDue(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	s := openTestStore(t)
+	defer s.Close()
+	now := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	s.Schedule("hammy", "darwin", "thanks", now.Add(time.Hour))
+	s.Schedule("hammy", "hammy", "self love", now.Add(time.Hour))
+	s.Schedule("hammy", "darwin", "happy birthday", now.Add(24*time.Hour))
+
+	// Nothing is due yet.
+	service := &failingService{server.Client(), &love.APIError{StatusCode: 503}}
+	result, err := s.SendDue(service)
+	assert.Nil(t, err)
+	assert.Equal(t, result, &JobResult{})
+
+	// The server is down, so the due jobs are retried later.
+	now = now.Add(time.Hour)
+	result, err = s.SendDue(service)
+	assert.Nil(t, err)
+	assert.Equal(t, result.Deferred, 2)
+	jobs, _ := s.Jobs()
+	assert.Equal(t, len(jobs), 3)
+	assert.Equal(t, jobs[0].Attempts, 1)
+	assert.Equal(t, jobs[0].NextAttempt, now.Add(time.Minute))
+
+	service.err = nil
+	result, err = s.SendDue(service)
+	assert.Nil(t, err)
+	assert.Equal(t, result, &JobResult{})
+
+	// Self love fails permanently, and is dropped.
+	now = now.Add(time.Minute)
+	result, err = s.SendDue(service)
+	assert.Nil(t, err)
+	assert.Equal(t, len(result.Sent), 1)
+	assert.Equal(t, result.Sent[0].Message, "thanks")
+	assert.Equal(t, len(result.Failed), 1)
+	assert.Equal(t, result.Failed[0].Message, "self love")
+	assert.Equal(t, len(server.Loves()), 1)
+
+	jobs, _ = s.Jobs()
+	assert.Equal(t, len(jobs), 1)
+	assert.Equal(t, jobs[0].Message, "happy birthday")
+
+	now = now.Add(24 * time.Hour)
+	result, err = s.SendDue(service)
+	assert.Nil(t, err)
+	assert.Equal(t, len(result.Sent), 1)
+	jobs, _ = s.Jobs()
+	assert.Empty(t, jobs)
+}
+
+func TestScheduleRule(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	s := openTestStore(t)
+	defer s.Close()
+	// A Saturday.
+	now := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	monthly, _ := schedule.ParseRule("0 9 15 * *")
+	job, err := s.ScheduleRule("hammy", "darwin", "happy monthiversary", monthly)
+	assert.Nil(t, err)
+	assert.Equal(t, job.At, time.Date(2000, 1, 15, 9, 0, 0, 0, time.UTC))
+	assert.Equal(t, job.Rule, "0 9 15 * *")
+	selfish, _ := schedule.ParseRule("0 9 * * mon")
+	s.ScheduleRule("hammy", "hammy", "self love", selfish)
+
+	// The scheduler was down for a month and a half: the love is sent once.
+	now = time.Date(2000, 2, 29, 12, 0, 0, 0, time.UTC)
+	service := &failingService{server.Client(), nil}
+	result, err := s.SendDue(service)
+	assert.Nil(t, err)
+	assert.Equal(t, len(result.Sent), 1)
+	assert.Equal(t, len(result.Failed), 1)
+	assert.Equal(t, len(server.Loves()), 1)
+
+	// Both recur, even though one failed.
+	jobs, _ := s.Jobs()
+	assert.Equal(t, len(jobs), 2)
+	assert.Equal(t, jobs[0].Message, "self love")
+	assert.Equal(t, jobs[0].At, time.Date(2000, 3, 6, 9, 0, 0, 0, time.UTC))
+	assert.Equal(t, jobs[0].Attempts, 1)
+	assert.Equal(t, jobs[1].At, time.Date(2000, 3, 15, 9, 0, 0, 0, time.UTC))
+	assert.Equal(t, jobs[1].Attempts, 0)
+
+	// Temporary failures are retried without skipping to the next occurrence.
+	now = time.Date(2000, 3, 15, 9, 0, 0, 0, time.UTC)
+	service.err = &love.APIError{StatusCode: 503}
+	result, _ = s.SendDue(service)
+	assert.Equal(t, result.Deferred, 2)
+	jobs, _ = s.Jobs()
+	assert.Equal(t, jobs[1].At, now)
+	assert.Equal(t, jobs[1].NextAttempt, now.Add(time.Minute))
+}
+
+func TestUnschedule(t *testing.T) {
+	s := openTestStore(t)
+	defer s.Close()
+	job, _ := s.Schedule("hammy", "darwin", "thanks", time.Now().Add(time.Hour))
+	assert.Nil(t, s.Unschedule(job.ID))
+	jobs, _ := s.Jobs()
+	assert.Empty(t, jobs)
+	assert.Equal(t, s.Unschedule(job.ID), ErrNoJob)
+}
+
+func TestConflicts(t *testing.T) {
+	s := openTestStore(t)
+	defer s.Close()
+	now := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	birthday, _ := s.Schedule("hammy", "darwin,jeremy", "happy birthday", now.AddDate(0, 1, 0))
+	weekly, _ := schedule.ParseRule("0 17 * * fri")
+	friday, _ := s.ScheduleRule("hammy", "jeremy", "happy friday", weekly)
+	s.Schedule("darwin", "jeremy", "from someone else", now.AddDate(0, 1, 0))
+
+	ids := func(jobs []Job) []uint64 {
+		result := []uint64{}
+		for _, job := range jobs {
+			result = append(result, job.ID)
+		}
+		return result
+	}
+	conflicts, err := s.Conflicts(Job{
+		Pending: Pending{Sender: "hammy", Recipient: "darwin"},
+		At:      now.AddDate(0, 1, 0).Add(3 * time.Hour),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, ids(conflicts), []uint64{birthday.ID})
+
+	// The monthly rule falls on the birthday, and on a Friday on September 1.
+	conflicts, _ = s.Conflicts(Job{
+		Pending: Pending{Sender: "hammy", Recipient: " jeremy"},
+		At:      time.Date(2000, 2, 1, 9, 0, 0, 0, time.UTC),
+		Rule:    "0 9 1 * *",
+	})
+	assert.Equal(t, ids(conflicts), []uint64{friday.ID, birthday.ID})
+
+	conflicts, _ = s.Conflicts(Job{
+		Pending: Pending{Sender: "hammy", Recipient: "alice"},
+		At:      now.AddDate(0, 1, 0),
+	})
+	assert.Empty(t, conflicts)
+
+	// A scheduled job does not conflict with itself.
+	conflicts, _ = s.Conflicts(*friday)
+	assert.Empty(t, conflicts)
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hacsoc/golove/love"
+	bolt "go.etcd.io/bbolt"
+)
+
+var _ love.SendRecordStore = (*Store)(nil)
+
+/*
+Return the record of love sent with an idempotency key, or nil if there is
+none. With PutSendRecord, this makes the store a love.SendRecordStore, so that
+queued and scheduled love which is retried is not sent twice. See Flush.
+*/
+func (s *Store) SendRecord(key string) (*love.SendRecord, error) {
+	var record *love.SendRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(sendBucket).Get([]byte(key))
+		if value == nil {
+			return nil
+		}
+		record = &love.SendRecord{}
+		return json.Unmarshal(value, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+/*
+Store the record of love sent with an idempotency key.
+*/
+func (s *Store) PutSendRecord(r *love.SendRecord) error {
+	value, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sendBucket).Put([]byte(r.Key), value)
+	})
+}
+
+/*
+Delete the record of love which has left the queue or schedule, if it has one.
+*/
+func deleteSendRecord(tx *bolt.Tx, key string) error {
+	if key == "" {
+		return nil
+	}
+	return tx.Bucket(sendBucket).Delete([]byte(key))
+}
+
+/*
+The part of a *love.Client which sends love with a context, which carries the
+idempotency key of queued love.
+*/
+type contextSender interface {
+	SendLoveContext(ctx context.Context, from string, to string, message string) error
+}
+
+/*
+Send love from the queue or schedule with its idempotency key, if it has one
+and the service can send love with a context.
+*/
+func sendPending(service love.LoveService, p *Pending, key string) error {
+	sender, ok := service.(contextSender)
+	if !ok || key == "" {
+		return service.SendLove(p.Sender, p.Recipient, p.Message)
+	}
+	ctx := love.WithIdempotencyKey(context.Background(), key)
+	return sender.SendLoveContext(ctx, p.Sender, p.Recipient, p.Message)
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"errors"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+/*
+A transport which loses the response to love which is sent while lose is set,
+as if the request timed out after the server received it.
+*/
+type losingTransport struct {
+	lose bool
+}
+
+func (l *losingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil && l.lose && req.Method == "POST" {
+		resp.Body.Close()
+		return nil, errors.New("timeout awaiting response headers")
+	}
+	return resp, err
+}
+
+func TestSendRecord(t *testing.T) {
+	s := openTestStore(t)
+	defer s.Close()
+
+	record, err := s.SendRecord("key")
+	assert.Nil(t, err)
+	assert.Nil(t, record)
+
+	started := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, s.PutSendRecord(&love.SendRecord{Key: "key", Started: started}))
+	record, err = s.SendRecord("key")
+	assert.Nil(t, err)
+	assert.Equal(t, record, &love.SendRecord{Key: "key", Started: started})
+}
+
+func TestFlushIdempotent(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	server.AddUser("jeremy", "Jeremy Jaguar")
+	s := openTestStore(t)
+	defer s.Close()
+
+	transport := &losingTransport{lose: true}
+	client := server.Client()
+	client.HTTPClient = &http.Client{Transport: transport}
+	client.SendRecords = s
+	service := &failingService{Client: client}
+	thanks, _ := s.Enqueue("hammy", "darwin", "thanks")
+	s.Enqueue("hammy", "jeremy", "great job")
+
+	// Both love were received, but the client never heard so.
+	result, err := s.Flush(service, true)
+	assert.Nil(t, err)
+	assert.Equal(t, result.Deferred, 2)
+	assert.Equal(t, len(server.Loves()), 2)
+	record, _ := s.SendRecord(thanks.Key)
+	assert.False(t, record.Sent)
+
+	// The love is found in the API, and not sent again.
+	transport.lose = false
+	result, err = s.Flush(service, true)
+	assert.Nil(t, err)
+	assert.Equal(t, len(result.Sent), 2)
+	assert.Equal(t, len(server.Loves()), 2)
+	pending, _ := s.Pending()
+	assert.Empty(t, pending)
+	record, _ = s.SendRecord(thanks.Key)
+	assert.Nil(t, record)
+}
+
+func TestSendDueIdempotent(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+	s := openTestStore(t)
+	defer s.Close()
+	// The love is looked for in the API by the client's clock.
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	transport := &losingTransport{lose: true}
+	client := server.Client()
+	client.HTTPClient = &http.Client{Transport: transport}
+	client.SendRecords = s
+	job, _ := s.Schedule("hammy", "darwin", "thanks", now)
+	assert.NotEmpty(t, job.Key)
+
+	result, err := s.SendDue(client)
+	assert.Nil(t, err)
+	assert.Equal(t, result.Deferred, 1)
+	assert.Equal(t, len(server.Loves()), 1)
+
+	transport.lose = false
+	now = now.Add(time.Minute)
+	result, err = s.SendDue(client)
+	assert.Nil(t, err)
+	assert.Equal(t, len(result.Sent), 1)
+	assert.Equal(t, len(server.Loves()), 1)
+	record, _ := s.SendRecord(job.Key)
+	assert.Nil(t, record)
+}
@@ -0,0 +1,311 @@
+/*
+Package store keeps a local copy of love history in a bbolt database, so that it
+can be queried, summarized and exported without downloading it again.
+
+	s, err := store.Open(store.DefaultPath())
+	if err != nil {
+		// handle error
+	}
+	defer s.Close()
+
+	// fetch love received by darwin since the last sync
+	added, err := s.Sync(client, love.LoveFilter{Recipient: "darwin"})
+
+	// query the local copy
+	loves, err := s.Query(love.LoveFilter{Recipient: "darwin", Keyword: "thanks"})
+
+Each love is stored once, keyed by its sender, recipient and timestamp, so
+syncing overlapping filters (such as love sent by one user and received by
+another) does not duplicate records.
+
+The store also holds a queue of love waiting to be sent, for when the API cannot
+be reached. See Enqueue and Flush. Similarly, it holds love scheduled to be sent
+later. See Schedule and SendDue. It records which of that love has been sent, so
+that love which is retried is not sent twice. See SendRecord. And it records who each sender sends love to,
+to suggest recipients. See RecordContacts and Contacts.
+*/
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/hacsoc/golove/love"
+	bolt "go.etcd.io/bbolt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+var (
+	loveBucket     = []byte("love")
+	cursorBucket   = []byte("cursors")
+	queueBucket    = []byte("queue")
+	scheduleBucket = []byte("schedule")
+	contactBucket  = []byte("contacts")
+	sendBucket     = []byte("sends")
+)
+
+/*
+A Store is a local database of love.
+*/
+type Store struct {
+	db  *bolt.DB
+	now func() time.Time
+}
+
+/*
+Return the default location of the database: golove/love.db in
+$XDG_DATA_HOME, or ~/.local/share.
+*/
+func DefaultPath() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "golove", "love.db")
+}
+
+/*
+Open the database at path, creating it and its directory if necessary. Only one
+process may have the database open at a time.
+*/
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{loveBucket, cursorBucket, queueBucket, scheduleBucket,
+			contactBucket, sendBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db, now: time.Now}, nil
+}
+
+/*
+Close the database.
+*/
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+/*
+The key a love is stored under. The hash of the love tells apart love sent by
+the same sender to the same recipient at the same time.
+*/
+func loveKey(l love.Love) []byte {
+	key := legacyLoveKey(l)
+	key = append(key, 0)
+	return append(key, l.Hash()...)
+}
+
+/*
+The key love was stored under by earlier versions, without its hash.
+*/
+func legacyLoveKey(l love.Love) []byte {
+	var key bytes.Buffer
+	key.WriteString(l.Sender)
+	key.WriteByte(0)
+	key.WriteString(l.Recipient)
+	key.WriteByte(0)
+	key.WriteString(l.Timestamp.UTC().Format(time.RFC3339Nano))
+	return key.Bytes()
+}
+
+/*
+Report whether a love is already stored under its legacy key.
+*/
+func storedLegacy(bucket *bolt.Bucket, l love.Love) (bool, error) {
+	value := bucket.Get(legacyLoveKey(l))
+	if value == nil {
+		return false, nil
+	}
+	var stored love.Love
+	if err := json.Unmarshal(value, &stored); err != nil {
+		return false, err
+	}
+	return stored.Message == l.Message, nil
+}
+
+/*
+Store love, returning how many were not already stored.
+*/
+func (s *Store) Put(loves []love.Love) (int, error) {
+	added := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(loveBucket)
+		for _, l := range loves {
+			key := loveKey(l)
+			if bucket.Get(key) != nil {
+				continue
+			}
+			if stored, err := storedLegacy(bucket, l); err != nil {
+				return err
+			} else if stored {
+				continue
+			}
+			value, err := json.Marshal(l)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(key, value); err != nil {
+				return err
+			}
+			added++
+		}
+		return nil
+	})
+	return added, err
+}
+
+/*
+Return the stored love matching a filter, newest first. As with the API, Limit
+bounds the number of love returned when it is greater than zero. Unlike the API,
+a filter without a sender or recipient matches love between any users.
+*/
+func (s *Store) Query(f love.LoveFilter) ([]love.Love, error) {
+	return s.find(f, f.Match)
+}
+
+/*
+Return the stored love matching a filter whose message matches a regular
+expression, newest first, as Query would. This is a full-text search of the
+local history, which the API cannot do.
+*/
+func (s *Store) Search(pattern *regexp.Regexp, f love.LoveFilter) ([]love.Love, error) {
+	return s.find(f, func(l love.Love) bool {
+		return f.Match(l) && pattern.MatchString(l.Message)
+	})
+}
+
+/*
+Return the stored love for which match returns true, newest first, up to the
+Limit of a filter.
+*/
+func (s *Store) find(f love.LoveFilter, match func(love.Love) bool) ([]love.Love, error) {
+	loves := []love.Love{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(loveBucket).ForEach(func(key, value []byte) error {
+			var l love.Love
+			if err := json.Unmarshal(value, &l); err != nil {
+				return err
+			}
+			if match(l) {
+				loves = append(loves, l)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	love.SortByTimestamp(loves)
+	if f.Limit > 0 && int64(len(loves)) > f.Limit {
+		loves = loves[:f.Limit]
+	}
+	return loves, nil
+}
+
+/*
+The key the sync cursor of a filter is stored under. Only the sender and
+recipient identify a sync.
+*/
+func cursorKey(f love.LoveFilter) []byte {
+	return []byte(f.Sender + "\x00" + f.Recipient)
+}
+
+/*
+Return the timestamp of the newest love found by the last Sync of the sender and
+recipient of a filter, or the zero time if it was never synced.
+*/
+func (s *Store) LastSync(f love.LoveFilter) (time.Time, error) {
+	cursor, err := s.SyncCursor(f)
+	return cursor.Newest, err
+}
+
+/*
+Return the cursor of the last Sync of the sender and recipient of a filter, or
+a zero cursor if it was never synced.
+*/
+func (s *Store) SyncCursor(f love.LoveFilter) (love.Cursor, error) {
+	var cursor love.Cursor
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(cursorBucket).Get(cursorKey(f))
+		if value == nil {
+			return nil
+		}
+		// Earlier versions only stored the newest timestamp.
+		if !bytes.HasPrefix(value, []byte("{")) {
+			return cursor.Newest.UnmarshalText(value)
+		}
+		return json.Unmarshal(value, &cursor)
+	})
+	return cursor, err
+}
+
+/*
+Fetch love matching the sender and recipient of a filter which the cursor of
+the last sync is not past, and store it. Returns the number of love added.
+Other fields of the filter are ignored.
+
+The API returns love newest first, so fetching stops at the first love older
+than the newest one already synced. Love with the same timestamp as the newest
+synced love is fetched again, and skipped if the cursor has seen it, so that
+love which arrives later with the same timestamp is still found.
+*/
+func (s *Store) Sync(client *love.Client, f love.LoveFilter) (int, error) {
+	cursor, err := s.SyncCursor(f)
+	if err != nil {
+		return 0, err
+	}
+	var loves []love.Love
+	it := client.IterLove(f.Sender, f.Recipient)
+	for it.Next() {
+		l := it.Love()
+		if l.Timestamp.Before(cursor.Newest) {
+			break
+		}
+		if !cursor.Covers(l) {
+			loves = append(loves, l)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+	added, err := s.Put(loves)
+	if err != nil {
+		return added, err
+	}
+	moved := false
+	for i := len(loves) - 1; i >= 0; i-- {
+		if cursor.Add(loves[i]) {
+			moved = true
+		}
+	}
+	if moved {
+		err = s.db.Update(func(tx *bolt.Tx) error {
+			value, err := json.Marshal(cursor)
+			if err != nil {
+				return err
+			}
+			return tx.Bucket(cursorBucket).Put(cursorKey(f), value)
+		})
+	}
+	return added, err
+}
@@ -0,0 +1,211 @@
+package store
+
+import (
+	"encoding/json"
+	"github.com/hacsoc/golove/love"
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	s, err := Open(filepath.Join(t.TempDir(), "love.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func testLove(sender, recipient string, hours int) love.Love {
+	return love.Love{
+		Sender:    sender,
+		Recipient: recipient,
+		Message:   "message",
+		Timestamp: time.Date(2000, 1, 1, hours, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestPutAndQuery(t *testing.T) {
+	s := openTestStore(t)
+	defer s.Close()
+
+	added, err := s.Put([]love.Love{
+		testLove("hammy", "darwin", 1),
+		testLove("darwin", "hammy", 2),
+		testLove("hammy", "jeremy", 3),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, added, 3)
+
+	added, err = s.Put([]love.Love{testLove("hammy", "darwin", 1)})
+	assert.Nil(t, err)
+	assert.Equal(t, added, 0)
+
+	loves, err := s.Query(love.LoveFilter{Sender: "hammy"})
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 2)
+	assert.Equal(t, loves[0].Recipient, "jeremy")
+	assert.Equal(t, loves[1].Recipient, "darwin")
+
+	loves, err = s.Query(love.LoveFilter{Limit: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 1)
+	assert.Equal(t, loves[0].Timestamp.Hour(), 3)
+}
+
+func TestSearch(t *testing.T) {
+	s := openTestStore(t)
+	defer s.Close()
+
+	messages := []string{"Thanks for the database migration", "great demo", "migrating the DB"}
+	for i, message := range messages {
+		l := testLove("hammy", "darwin", i)
+		l.Message = message
+		_, err := s.Put([]love.Love{l})
+		assert.Nil(t, err)
+	}
+
+	loves, err := s.Search(regexp.MustCompile(`(?i)migrat`), love.LoveFilter{})
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 2)
+	assert.Equal(t, loves[0].Message, "migrating the DB")
+
+	loves, err = s.Search(regexp.MustCompile(`(?i)database|\bDB\b`),
+		love.LoveFilter{Sender: "hammy", Limit: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 1)
+	assert.Equal(t, loves[0].Message, "migrating the DB")
+
+	loves, err = s.Search(regexp.MustCompile(`demo`), love.LoveFilter{Sender: "darwin"})
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 0)
+}
+
+func TestSync(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+	server.AddLove(testLove("hammy", "darwin", 1))
+	server.AddLove(testLove("jeremy", "darwin", 2))
+	client := server.Client()
+
+	s := openTestStore(t)
+	defer s.Close()
+	filter := love.LoveFilter{Recipient: "darwin"}
+
+	last, err := s.LastSync(filter)
+	assert.Nil(t, err)
+	assert.True(t, last.IsZero())
+
+	added, err := s.Sync(client, filter)
+	assert.Nil(t, err)
+	assert.Equal(t, added, 2)
+	last, err = s.LastSync(filter)
+	assert.Nil(t, err)
+	assert.Equal(t, last.Hour(), 2)
+
+	added, err = s.Sync(client, filter)
+	assert.Nil(t, err)
+	assert.Equal(t, added, 0)
+
+	server.AddLove(testLove("hammy", "darwin", 5))
+	added, err = s.Sync(client, filter)
+	assert.Nil(t, err)
+	assert.Equal(t, added, 1)
+
+	loves, err := s.Query(love.LoveFilter{Recipient: "darwin"})
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 3)
+}
+
+func TestSyncMoreThanPage(t *testing.T) {
+	// A default server ignores offset, as stock Yelp Love does.
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+	for i := 0; i < love.DefaultPageSize+50; i++ {
+		server.AddLove(testLove("hammy", "darwin", i))
+	}
+	client := server.Client()
+
+	s := openTestStore(t)
+	defer s.Close()
+	filter := love.LoveFilter{Recipient: "darwin"}
+	added, err := s.Sync(client, filter)
+	assert.Nil(t, err)
+	assert.Equal(t, added, love.DefaultPageSize+50)
+
+	server.AddLove(testLove("jeremy", "darwin", 1000))
+	added, err = s.Sync(client, filter)
+	assert.Nil(t, err)
+	assert.Equal(t, added, 1)
+
+	loves, err := s.Query(filter)
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), love.DefaultPageSize+51)
+}
+
+func TestSyncSameTimestamp(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+	first := testLove("hammy", "darwin", 1)
+	server.AddLove(first)
+	client := server.Client()
+
+	s := openTestStore(t)
+	defer s.Close()
+	filter := love.LoveFilter{Recipient: "darwin"}
+	added, err := s.Sync(client, filter)
+	assert.Nil(t, err)
+	assert.Equal(t, added, 1)
+
+	// More love arrives with the same timestamp, from the same sender.
+	second := first
+	second.Message = "another message"
+	server.AddLove(second)
+	added, err = s.Sync(client, filter)
+	assert.Nil(t, err)
+	assert.Equal(t, added, 1)
+	cursor, err := s.SyncCursor(filter)
+	assert.Nil(t, err)
+	assert.Equal(t, cursor.Newest, first.Timestamp)
+	assert.Equal(t, len(cursor.Seen), 2)
+
+	added, err = s.Sync(client, filter)
+	assert.Nil(t, err)
+	assert.Equal(t, added, 0)
+	loves, err := s.Query(filter)
+	assert.Nil(t, err)
+	assert.Equal(t, len(loves), 2)
+}
+
+func TestLegacyKeys(t *testing.T) {
+	s := openTestStore(t)
+	defer s.Close()
+	l := testLove("hammy", "darwin", 1)
+	filter := love.LoveFilter{Recipient: "darwin"}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		value, _ := json.Marshal(l)
+		if err := tx.Bucket(loveBucket).Put(legacyLoveKey(l), value); err != nil {
+			return err
+		}
+		return tx.Bucket(cursorBucket).Put(cursorKey(filter), []byte("2000-01-01T01:00:00Z"))
+	})
+	assert.Nil(t, err)
+
+	added, err := s.Put([]love.Love{l})
+	assert.Nil(t, err)
+	assert.Equal(t, added, 0)
+	other := l
+	other.Message = "another message"
+	added, err = s.Put([]love.Love{other})
+	assert.Nil(t, err)
+	assert.Equal(t, added, 1)
+
+	cursor, err := s.SyncCursor(filter)
+	assert.Nil(t, err)
+	assert.Equal(t, cursor.Newest, l.Timestamp)
+	assert.Empty(t, cursor.Seen)
+}
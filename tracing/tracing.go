@@ -0,0 +1,80 @@
+/*
+Package tracing records OpenTelemetry spans for the requests a love.Client
+makes, so that love API calls appear in the distributed traces of the services
+which make them.
+
+	tracing.Instrument(client, nil)
+	err := client.SendLoveContext(ctx, "hammy", "darwin", "thanks!")
+
+Each request gets a client span, which is a child of the span in the context
+passed to the client's Context methods, such as SendLoveContext. Methods without
+a context start a new trace. The trace context is also sent to the server in the
+request headers, using the global propagator.
+
+Spans are named "love " followed by the method and endpoint, such as
+"love POST /love", and have the attributes http.request.method, love.endpoint
+and http.response.status_code. The client does not retry requests, so each span
+covers exactly one attempt.
+*/
+package tracing
+
+import (
+	"github.com/hacsoc/golove/love"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strconv"
+)
+
+// The name of the instrumentation library, used to create its tracer.
+const instrumentationName = "github.com/hacsoc/golove/tracing"
+
+/*
+Record a span for each request made by a client, by adding middleware to it.
+If provider is nil, the global TracerProvider is used.
+*/
+func Instrument(c *love.Client, provider trace.TracerProvider) {
+	c.Middleware = append(c.Middleware, Middleware(c, provider))
+}
+
+/*
+Return middleware which records a span for each request made by a client.
+Instrument adds it to the client; use Middleware to control its order with other
+middleware. If provider is nil, the global TracerProvider is used.
+*/
+func Middleware(c *love.Client, provider trace.TracerProvider) love.Middleware {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	tracer := provider.Tracer(instrumentationName)
+	return func(next http.RoundTripper) http.RoundTripper {
+		return love.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			endpoint := c.Endpoint(req)
+			ctx, span := tracer.Start(req.Context(), "love "+req.Method+" "+endpoint,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("http.request.method", req.Method),
+					attribute.String("love.endpoint", endpoint),
+				))
+			defer span.End()
+
+			// A RoundTripper must not modify the request it is given.
+			req = req.Clone(ctx)
+			otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, c.Redact(err.Error()))
+				return resp, err
+			}
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+			if resp.StatusCode >= 400 {
+				span.SetStatus(codes.Error, strconv.Itoa(resp.StatusCode))
+			}
+			return resp, nil
+		})
+	}
+}
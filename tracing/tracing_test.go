@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"github.com/hacsoc/golove/lovetest"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func newTestProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)), recorder
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestSpans(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+	server.AddUser("hammy", "Hammy Havoc")
+	server.AddUser("darwin", "Darwin Dog")
+
+	provider, recorder := newTestProvider()
+	client := server.Client()
+	Instrument(client, provider)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	assert.Nil(t, client.SendLoveContext(ctx, "hammy", "darwin", "thanks"))
+	_, err := client.GetLoveContext(ctx, "hammy", "", 1)
+	assert.Nil(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Equal(t, len(spans), 3)
+	send, get := spans[0], spans[1]
+	assert.Equal(t, send.Name(), "love POST /love")
+	assert.Equal(t, send.SpanKind(), trace.SpanKindClient)
+	assert.Equal(t, send.Parent().SpanID(), parent.SpanContext().SpanID())
+	assert.Equal(t, send.SpanContext().TraceID(), parent.SpanContext().TraceID())
+	attrs := attributes(send)
+	assert.Equal(t, attrs["http.request.method"].AsString(), "POST")
+	assert.Equal(t, attrs["love.endpoint"].AsString(), "/love")
+	assert.Equal(t, attrs["http.response.status_code"].AsInt64(), int64(201))
+	assert.Equal(t, send.Status().Code, codes.Unset)
+	assert.Equal(t, get.Name(), "love GET /love")
+	assert.Equal(t, get.Parent().SpanID(), parent.SpanContext().SpanID())
+}
+
+func TestErrorSpans(t *testing.T) {
+	server := lovetest.NewServer("secret")
+	defer server.Close()
+
+	provider, recorder := newTestProvider()
+	client := server.Client()
+	Instrument(client, provider)
+
+	assert.NotNil(t, client.SendLove("hammy", "hammy", "thanks"))
+	client.BaseUrl = "http://127.0.0.1:1/api"
+	assert.NotNil(t, client.SendLove("hammy", "darwin", "thanks"))
+
+	spans := recorder.Ended()
+	assert.Equal(t, len(spans), 2)
+	assert.False(t, spans[0].Parent().IsValid())
+	assert.Equal(t, spans[0].Status().Code, codes.Error)
+	assert.Equal(t, attributes(spans[0])["http.response.status_code"].AsInt64(), int64(418))
+	assert.Equal(t, spans[1].Status().Code, codes.Error)
+	assert.Equal(t, len(spans[1].Events()), 1)
+	assert.NotContains(t, spans[1].Status().Description, "secret")
+}
@@ -0,0 +1,173 @@
+/*
+Package webhook delivers love to other services as JSON webhooks. Each love is
+POSTed to every configured URL, with the same JSON encoding as the love API:
+
+	{"message": "thanks!", "recipient": "darwin", "sender": "hammy", "timestamp": "2017-04-01T12:00:00"}
+
+When a secret is configured, the X-Golove-Signature header holds
+"sha256=" followed by the hex HMAC-SHA256 of the body, keyed with the secret.
+Receivers should check it with Verify.
+*/
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/hacsoc/golove/love"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// The header holding the signature of the body.
+const SignatureHeader = "X-Golove-Signature"
+
+const (
+	// The default number of times a failed delivery is retried.
+	DefaultRetries = 3
+	// The default wait before the first retry. It doubles after each retry.
+	DefaultBackoff = time.Second
+)
+
+/*
+A Poster delivers love to a list of URLs. A delivery fails when the request
+fails or the response status is not 2xx. Failed deliveries are retried, unless
+the status is 4xx (other than 429 Too Many Requests), since repeating the same
+request will not help.
+*/
+type Poster struct {
+	URLs []string
+	// Signs the body when not empty.
+	Secret string
+	// Negative for no retries, zero for DefaultRetries.
+	Retries int
+	// Zero for DefaultBackoff.
+	Backoff    time.Duration
+	HTTPClient *http.Client
+
+	sleep func(time.Duration)
+}
+
+/*
+A DeliveryError is returned when love could not be delivered to a URL.
+*/
+type DeliveryError struct {
+	URL string
+	Err error
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("webhook: %s: %s", e.URL, e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+/*
+An unsuccessful response from a webhook endpoint.
+*/
+type statusError struct {
+	StatusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e *statusError) temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+/*
+Deliver a love to every URL. Every URL is tried even if an earlier one fails;
+the error for the first URL which failed is returned, as a *DeliveryError.
+*/
+func (p *Poster) Post(l love.Love) error {
+	body, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	var first error
+	for _, url := range p.URLs {
+		if err := p.deliver(url, body); err != nil && first == nil {
+			first = &DeliveryError{url, err}
+		}
+	}
+	return first
+}
+
+func (p *Poster) deliver(url string, body []byte) error {
+	retries := p.Retries
+	if retries == 0 {
+		retries = DefaultRetries
+	}
+	backoff := p.Backoff
+	if backoff == 0 {
+		backoff = DefaultBackoff
+	}
+	sleep := time.Sleep
+	if p.sleep != nil {
+		sleep = p.sleep
+	}
+	for attempt := 0; ; attempt++ {
+		err := p.attempt(url, body)
+		if err == nil {
+			return nil
+		}
+		if status, ok := err.(*statusError); ok && !status.temporary() {
+			return err
+		}
+		if attempt >= retries {
+			return err
+		}
+		sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (p *Poster) attempt(url string, body []byte) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(p.Secret, body))
+	}
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusError{resp.StatusCode}
+	}
+	return nil
+}
+
+/*
+Return the signature of a body, as sent in the X-Golove-Signature header.
+*/
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+/*
+Report whether signature is the correct signature of a body.
+*/
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
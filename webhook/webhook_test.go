@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"errors"
+	"github.com/hacsoc/golove/love"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testLove = love.Love{
+	Sender:    "hammy",
+	Recipient: "darwin",
+	Message:   "thanks!",
+	Timestamp: time.Date(2017, 4, 1, 12, 0, 0, 0, time.UTC),
+}
+
+/*
+A server which responds with each status in turn, recording the requests.
+*/
+type recorder struct {
+	statuses   []int
+	bodies     []string
+	signatures []string
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	r.bodies = append(r.bodies, string(body))
+	r.signatures = append(r.signatures, req.Header.Get(SignatureHeader))
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func newTestPoster(urls ...string) (*Poster, *[]time.Duration) {
+	var sleeps []time.Duration
+	poster := &Poster{
+		URLs:   urls,
+		Secret: "secret",
+		sleep:  func(d time.Duration) { sleeps = append(sleeps, d) },
+	}
+	return poster, &sleeps
+}
+
+func TestPost(t *testing.T) {
+	r := &recorder{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	poster, sleeps := newTestPoster(server.URL, server.URL)
+	assert.Nil(t, poster.Post(testLove))
+	assert.Equal(t, len(r.bodies), 2)
+	assert.Equal(t, r.bodies[0],
+		`{"message":"thanks!","recipient":"darwin","sender":"hammy","timestamp":"2017-04-01T12:00:00"}`)
+	assert.True(t, Verify("secret", []byte(r.bodies[0]), r.signatures[0]))
+	assert.False(t, Verify("wrong", []byte(r.bodies[0]), r.signatures[0]))
+	assert.Equal(t, len(*sleeps), 0)
+}
+
+func TestUnsigned(t *testing.T) {
+	r := &recorder{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	poster := &Poster{URLs: []string{server.URL}}
+	assert.Nil(t, poster.Post(testLove))
+	assert.Equal(t, r.signatures[0], "")
+}
+
+func TestRetry(t *testing.T) {
+	r := &recorder{statuses: []int{503, 429, 200}}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	poster, sleeps := newTestPoster(server.URL)
+	assert.Nil(t, poster.Post(testLove))
+	assert.Equal(t, len(r.bodies), 3)
+	assert.Equal(t, *sleeps, []time.Duration{time.Second, 2 * time.Second})
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	r := &recorder{statuses: []int{500, 500, 500}}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	poster, _ := newTestPoster(server.URL)
+	poster.Retries = 2
+	err := poster.Post(testLove)
+	var deliveryErr *DeliveryError
+	assert.True(t, errors.As(err, &deliveryErr))
+	assert.Equal(t, deliveryErr.URL, server.URL)
+	assert.Equal(t, err.Error(), "webhook: "+server.URL+": 500 Internal Server Error")
+	assert.Equal(t, len(r.bodies), 3)
+}
+
+func TestNoRetries(t *testing.T) {
+	r := &recorder{statuses: []int{500, 200}}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	poster, _ := newTestPoster(server.URL)
+	poster.Retries = -1
+	assert.NotNil(t, poster.Post(testLove))
+	assert.Equal(t, len(r.bodies), 1)
+}
+
+func TestClientErrorNotRetried(t *testing.T) {
+	bad := &recorder{statuses: []int{404}}
+	badServer := httptest.NewServer(bad)
+	defer badServer.Close()
+	good := &recorder{}
+	goodServer := httptest.NewServer(good)
+	defer goodServer.Close()
+
+	poster, sleeps := newTestPoster(badServer.URL, goodServer.URL)
+	err := poster.Post(testLove)
+	assert.NotNil(t, err)
+	assert.Equal(t, len(bad.bodies), 1)
+	assert.Equal(t, len(good.bodies), 1)
+	assert.Equal(t, len(*sleeps), 0)
+}